@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"csv2/ranking"
@@ -11,34 +12,68 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-func SetupDiscord(token, floodChannelID, relayChannelID string, rank *ranking.Ranking) *discordgo.Session {
+// SetupDiscord открывает соединения шардов этого процесса (SHARD_COUNT, SHARD_IDS) и возвращает их сессии.
+func SetupDiscord(token, floodChannelID, relayChannelID string, rank *ranking.Ranking) []*discordgo.Session {
+	count, ids, err := shardConfig(token)
+	if err != nil {
+		log.Fatalf("Invalid shard configuration: %v", err)
+	}
+
+	sessions := make([]*discordgo.Session, 0, len(ids))
+	for n, id := range ids {
+		if n > 0 {
+			// Discord разрешает не больше одного IDENTIFY в 5 секунд
+			time.Sleep(5 * time.Second)
+		}
+		sessions = append(sessions, openShard(token, id, count, rank))
+	}
+
+	log.Printf("Discord bot is running (shards %v of %d).", ids, count)
+	rank.SetShards(sessions)
+
+	// Slash-команды глобальные и фоновые задачи общие: ими занимается только процесс с шардом 0
+	if ids[0] == 0 {
+		registerSlashCommands(sessions[0])
+		rank.StartScheduler()
+	} else {
+		log.Printf("Фоновые задачи выполняет процесс с шардом 0, здесь планировщик не запускается")
+	}
+
+	return sessions
+}
+
+// openShard открывает соединение одного шарда со шлюзом.
+func openShard(token string, shardID, shardCount int, rank *ranking.Ranking) *discordgo.Session {
 	dg, err := discordgo.New("Bot " + token)
 	if err != nil {
 		log.Fatalf("Failed to initialize Discord bot: %v", err)
 	}
+	dg.ShardID = shardID
+	dg.ShardCount = shardCount
 
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentMessageContent | discordgo.IntentsGuildVoiceStates
+	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentMessageContent | discordgo.IntentsGuildVoiceStates
+	dg.ShouldReconnectOnError = true
 
 	// Регистрируем обработчик голосовой активности
 	dg.AddHandler(rank.TrackVoiceActivity)
 
+	// Отслеживаем разрывы и восстановления соединения со шлюзом
+	registerSessionHandlers(dg, rank)
+
+	// Сообщаем админам о нехватке прав бота в каналах
+	rank.InstallPermissionProbe(dg)
+
 	for i := 0; i < 5; i++ {
 		err = dg.Open()
 		if err == nil {
 			break
 		}
-		log.Printf("Failed to open Discord session (attempt %d/5): %v", i+1, err)
+		log.Printf("Failed to open Discord session%s (attempt %d/5): %v", ranking.ShardLabel(dg), i+1, err)
 		time.Sleep(5 * time.Second)
 	}
 	if err != nil {
-		log.Fatalf("Failed to open Discord session after 5 attempts: %v", err)
+		log.Fatalf("Failed to open Discord session%s after 5 attempts: %v", ranking.ShardLabel(dg), err)
 	}
-
-	log.Println("Discord bot is running.")
-
-	// Регистрируем slash-команды
-	registerSlashCommands(dg)
-
 	return dg
 }
 
@@ -65,9 +100,9 @@ func SendFileToDiscord(dg *discordgo.Session, channelID, filePath, caption strin
 	return nil
 }
 
-// registerSlashCommands регистрирует slash-команды в Discord
-func registerSlashCommands(dg *discordgo.Session) {
-	commands := []*discordgo.ApplicationCommand{
+// slashCommands возвращает полный список slash-команд бота.
+func slashCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
 		{
 			Name:        "china",
 			Description: "Показать информацию о пользователе",
@@ -234,15 +269,120 @@ func registerSlashCommands(dg *discordgo.Session) {
 				},
 			},
 		},
+		// Команды контекстного меню пользователя (ПКМ по профилю → Приложения)
+		{Name: ranking.ContextMenuBalance, Type: discordgo.UserApplicationCommand},
+		{Name: ranking.ContextMenuDuel, Type: discordgo.UserApplicationCommand},
+		{Name: ranking.ContextMenuTransfer, Type: discordgo.UserApplicationCommand},
 	}
+}
 
-	// Регистрируем команды
-	for _, cmd := range commands {
-		_, err := dg.ApplicationCommandCreate(dg.State.User.ID, "", cmd)
-		if err != nil {
-			log.Printf("Failed to create slash command %s: %v", cmd.Name, err)
-		} else {
+// commandScope возвращает ID гильдии для регистрации команд: в режиме разработки (DEV_GUILD_ID)
+// команды регистрируются в одной гильдии и появляются сразу, иначе — глобально.
+func commandScope() string {
+	return os.Getenv("DEV_GUILD_ID")
+}
+
+// registerSlashCommands регистрирует slash-команды в Discord
+func registerSlashCommands(dg *discordgo.Session) {
+	created, updated, deleted, err := syncSlashCommands(dg)
+	if err != nil {
+		log.Printf("Failed to sync slash commands: %v", err)
+		return
+	}
+	log.Printf("Slash commands synced: %d created, %d updated, %d deleted", created, updated, deleted)
+}
+
+// syncSlashCommands приводит зарегистрированные команды к списку slashCommands:
+// создаёт недостающие, обновляет изменившиеся и удаляет устаревшие.
+func syncSlashCommands(dg *discordgo.Session) (created, updated, deleted int, err error) {
+	appID := dg.State.User.ID
+	guildID := commandScope()
+
+	existing, err := dg.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to list slash commands: %v", err)
+	}
+	registered := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, cmd := range existing {
+		registered[cmd.Name] = cmd
+	}
+
+	wanted := make(map[string]bool)
+	for _, cmd := range slashCommands() {
+		wanted[cmd.Name] = true
+		current, ok := registered[cmd.Name]
+		switch {
+		case !ok:
+			if _, err := dg.ApplicationCommandCreate(appID, guildID, cmd); err != nil {
+				log.Printf("Failed to create slash command %s: %v", cmd.Name, err)
+				continue
+			}
 			log.Printf("Successfully registered slash command: %s", cmd.Name)
+			created++
+		case !sameCommand(current, cmd):
+			if _, err := dg.ApplicationCommandEdit(appID, guildID, current.ID, cmd); err != nil {
+				log.Printf("Failed to update slash command %s: %v", cmd.Name, err)
+				continue
+			}
+			log.Printf("Updated slash command: %s", cmd.Name)
+			updated++
 		}
 	}
+
+	for name, cmd := range registered {
+		if wanted[name] {
+			continue
+		}
+		if err := dg.ApplicationCommandDelete(appID, guildID, cmd.ID); err != nil {
+			log.Printf("Failed to delete stale slash command %s: %v", name, err)
+			continue
+		}
+		log.Printf("Deleted stale slash command: %s", name)
+		deleted++
+	}
+	return created, updated, deleted, nil
+}
+
+// sameCommand сравнивает описание и опции команды, игнорируя поля, которые заполняет Discord.
+func sameCommand(a, b *discordgo.ApplicationCommand) bool {
+	if commandType(a) != commandType(b) || a.Description != b.Description {
+		return false
+	}
+	return optionsKey(a.Options) == optionsKey(b.Options)
+}
+
+// commandType возвращает тип команды; пустой тип Discord трактует как slash-команду.
+func commandType(cmd *discordgo.ApplicationCommand) discordgo.ApplicationCommandType {
+	if cmd.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return cmd.Type
+}
+
+// optionsKey сериализует значимые поля опций для сравнения.
+func optionsKey(options []*discordgo.ApplicationCommandOption) string {
+	var b strings.Builder
+	for _, o := range options {
+		fmt.Fprintf(&b, "%d|%s|%s|%t|", o.Type, o.Name, o.Description, o.Required)
+		for _, c := range o.Choices {
+			fmt.Fprintf(&b, "%s=%v,", c.Name, c.Value)
+		}
+		b.WriteString(optionsKey(o.Options))
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// handleSyncCommands обрабатывает команду /a_sync_commands.
+func handleSyncCommands(s *discordgo.Session, m *discordgo.MessageCreate) {
+	scope := "глобально (обновление до часа)"
+	if guildID := commandScope(); guildID != "" {
+		scope = fmt.Sprintf("в гильдии %s", guildID)
+	}
+	created, updated, deleted, err := syncSlashCommands(s)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Ошибка синхронизации команд**: "+err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Slash-команды синхронизированы %s**\nСоздано: %d, обновлено: %d, удалено: %d", scope, created, updated, deleted))
 }
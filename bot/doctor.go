@@ -0,0 +1,255 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv2/utils"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Самопроверка конфигурации (--check и проверка при запуске): переменные окружения,
+// Redis, Google Sheets, токены Discord и Telegram, каналы и права бота в них.
+
+// CheckStatus — итог одной проверки.
+type CheckStatus int
+
+const (
+	CheckOK CheckStatus = iota
+	CheckWarn
+	CheckFail
+)
+
+// CheckResult — результат одной проверки для отчёта.
+type CheckResult struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+}
+
+// requiredEnv — переменные, без которых бот не запустится.
+var requiredEnv = []string{
+	"DISCORD_TOKEN", "TELEGRAM_TOKEN", "TELEGRAM_CHAT_ID", "FLOOD_CHANNEL_ID", "RELAY_CHANNEL_ID",
+	"CINEMA_CHANNEL_ID", "REDIS_ADDR", "GOOGLE_SHEETS_ID", "GOOGLE_APPLICATION_CREDENTIALS",
+}
+
+// optionalEnv — переменные, без которых часть функций отключается.
+var optionalEnv = []struct{ name, feature string }{
+	{"ADMIN_FILE_PATH", "список админов"},
+	{"LOG_CHANNEL_ID", "лог операций с кредитами"},
+	{"ADMIN_CHANNEL_ID", "алерты для админов"},
+}
+
+// channelChecks — каналы из окружения и права, которые в них нужны боту.
+var channelChecks = []struct {
+	env   string
+	perms int64
+}{
+	{"FLOOD_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks | discordgo.PermissionReadMessageHistory},
+	{"RELAY_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionAttachFiles | discordgo.PermissionReadMessageHistory},
+	{"CINEMA_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks},
+	{"LOG_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages},
+	{"ADMIN_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks},
+	{"BIG_PULLS_CHANNEL_ID", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks},
+	{"ANNOUNCE_CHANNEL_IDS", discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks},
+}
+
+// permissionNames — названия прав для отчёта.
+var permissionNames = []struct {
+	perm int64
+	name string
+}{
+	{discordgo.PermissionViewChannel, "View Channel"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionEmbedLinks, "Embed Links"},
+	{discordgo.PermissionAttachFiles, "Attach Files"},
+	{discordgo.PermissionReadMessageHistory, "Read Message History"},
+}
+
+// RunChecks выполняет все проверки конфигурации.
+func RunChecks() []CheckResult {
+	var results []CheckResult
+	results = append(results, checkEnv()...)
+	results = append(results, checkRedis())
+	results = append(results, checkSheets())
+	results = append(results, checkDiscord()...)
+	results = append(results, checkTelegram())
+	return results
+}
+
+// PrintCheckReport печатает отчёт и возвращает false, если есть проваленные проверки.
+func PrintCheckReport(results []CheckResult) bool {
+	icons := map[CheckStatus]string{CheckOK: "✅", CheckWarn: "⚠️", CheckFail: "❌"}
+	failed, warned := 0, 0
+	fmt.Println("🩺 Проверка конфигурации ChinaBot")
+	for _, result := range results {
+		fmt.Printf("%s %-28s %s\n", icons[result.Status], result.Name, result.Detail)
+		switch result.Status {
+		case CheckFail:
+			failed++
+		case CheckWarn:
+			warned++
+		}
+	}
+	fmt.Printf("Итого: проверок %d, ошибок %d, предупреждений %d\n", len(results), failed, warned)
+	return failed == 0
+}
+
+// checkEnv проверяет обязательные и необязательные переменные окружения.
+func checkEnv() []CheckResult {
+	var results []CheckResult
+	for _, name := range requiredEnv {
+		if os.Getenv(name) == "" {
+			results = append(results, CheckResult{"env " + name, CheckFail, "не задана"})
+		}
+	}
+	for _, env := range optionalEnv {
+		if os.Getenv(env.name) == "" {
+			results = append(results, CheckResult{"env " + env.name, CheckWarn, "не задана — отключено: " + env.feature})
+		}
+	}
+	if chatID := os.Getenv("TELEGRAM_CHAT_ID"); chatID != "" {
+		if _, err := utils.ParseChatID(chatID); err != nil {
+			results = append(results, CheckResult{"env TELEGRAM_CHAT_ID", CheckFail, "не число: " + chatID})
+		}
+	}
+	if value := os.Getenv("SHARD_COUNT"); value != "" && value != "auto" {
+		if count, err := strconv.Atoi(value); err != nil || count < 1 {
+			results = append(results, CheckResult{"env SHARD_COUNT", CheckFail, "не положительное число и не auto: " + value})
+		} else if ids, err := parseShardIDs(os.Getenv("SHARD_IDS"), count); err != nil {
+			results = append(results, CheckResult{"env SHARD_IDS", CheckFail, err.Error()})
+		} else if len(ids) < count {
+			results = append(results, CheckResult{"env SHARD_IDS", CheckWarn, fmt.Sprintf("процесс обслуживает %d из %d шардов — балансы защищены от гонок только внутри одного процесса", len(ids), count)})
+		}
+	}
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			results = append(results, CheckResult{"env GOOGLE_APPLICATION_CREDENTIALS", CheckFail, "файл недоступен: " + err.Error()})
+		}
+	}
+	if path := os.Getenv("ADMIN_FILE_PATH"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			results = append(results, CheckResult{"env ADMIN_FILE_PATH", CheckFail, "файл недоступен: " + err.Error()})
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{"Переменные окружения", CheckOK, "все заданы"})
+	}
+	return results
+}
+
+// checkRedis проверяет подключение к Redis.
+func checkRedis() CheckResult {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return CheckResult{"Redis", CheckFail, "REDIS_ADDR не задан"}
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return CheckResult{"Redis", CheckFail, fmt.Sprintf("%s: %v", addr, err)}
+	}
+	return CheckResult{"Redis", CheckOK, addr}
+}
+
+// checkSheets проверяет доступ сервисного аккаунта к таблице Google Sheets.
+func checkSheets() CheckResult {
+	sheetID, credsPath := os.Getenv("GOOGLE_SHEETS_ID"), os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if sheetID == "" || credsPath == "" {
+		return CheckResult{"Google Sheets", CheckFail, "GOOGLE_SHEETS_ID или GOOGLE_APPLICATION_CREDENTIALS не заданы"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	srv, err := sheets.NewService(ctx, option.WithCredentialsFile(credsPath))
+	if err != nil {
+		return CheckResult{"Google Sheets", CheckFail, err.Error()}
+	}
+	sheet, err := srv.Spreadsheets.Get(sheetID).Fields("properties.title").Context(ctx).Do()
+	if err != nil {
+		return CheckResult{"Google Sheets", CheckFail, err.Error()}
+	}
+	return CheckResult{"Google Sheets", CheckOK, fmt.Sprintf("таблица «%s»", sheet.Properties.Title)}
+}
+
+// checkDiscord проверяет токен Discord, каналы из окружения и права бота в них.
+func checkDiscord() []CheckResult {
+	token := os.Getenv("DISCORD_TOKEN")
+	if token == "" {
+		return []CheckResult{{"Discord", CheckFail, "DISCORD_TOKEN не задан"}}
+	}
+	dg, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return []CheckResult{{"Discord", CheckFail, err.Error()}}
+	}
+	me, err := dg.User("@me")
+	if err != nil {
+		return []CheckResult{{"Discord", CheckFail, "токен отклонён: " + err.Error()}}
+	}
+	results := []CheckResult{{"Discord", CheckOK, "бот " + me.Username}}
+
+	for _, check := range channelChecks {
+		for _, channelID := range strings.Split(os.Getenv(check.env), ",") {
+			channelID = strings.TrimSpace(channelID)
+			if channelID == "" {
+				continue
+			}
+			results = append(results, checkDiscordChannel(dg, me.ID, check.env, channelID, check.perms))
+		}
+	}
+	return results
+}
+
+// checkDiscordChannel проверяет, что канал существует и у бота есть нужные права.
+func checkDiscordChannel(dg *discordgo.Session, botID, env, channelID string, required int64) CheckResult {
+	name := "канал " + env
+	channel, err := dg.Channel(channelID)
+	if err != nil {
+		return CheckResult{name, CheckFail, fmt.Sprintf("%s не найден или недоступен: %v", channelID, err)}
+	}
+	perms, err := dg.UserChannelPermissions(botID, channelID)
+	if err != nil {
+		return CheckResult{name, CheckFail, fmt.Sprintf("#%s: не удалось получить права: %v", channel.Name, err)}
+	}
+	var missing []string
+	for _, p := range permissionNames {
+		if required&p.perm != 0 && perms&p.perm == 0 {
+			missing = append(missing, p.name)
+		}
+	}
+	if len(missing) > 0 {
+		return CheckResult{name, CheckFail, fmt.Sprintf("#%s: не хватает прав: %s", channel.Name, strings.Join(missing, ", "))}
+	}
+	return CheckResult{name, CheckOK, "#" + channel.Name}
+}
+
+// checkTelegram проверяет токен Telegram и доступ бота к чату-мосту.
+func checkTelegram() CheckResult {
+	token := os.Getenv("TELEGRAM_TOKEN")
+	if token == "" {
+		return CheckResult{"Telegram", CheckFail, "TELEGRAM_TOKEN не задан"}
+	}
+	tgBot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return CheckResult{"Telegram", CheckFail, "токен отклонён: " + err.Error()}
+	}
+	chatID, err := utils.ParseChatID(os.Getenv("TELEGRAM_CHAT_ID"))
+	if err != nil {
+		return CheckResult{"Telegram", CheckFail, "бот " + tgBot.Self.UserName + ", но TELEGRAM_CHAT_ID некорректен"}
+	}
+	chat, err := tgBot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		return CheckResult{"Telegram", CheckFail, fmt.Sprintf("бот %s не видит чат %d: %v", tgBot.Self.UserName, chatID, err)}
+	}
+	return CheckResult{"Telegram", CheckOK, fmt.Sprintf("бот %s, чат «%s»", tgBot.Self.UserName, chat.Title)}
+}
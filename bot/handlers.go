@@ -3,12 +3,9 @@ package bot
 import (
 	"fmt"
 	"log"
-	"os"
 	"strings"
-	"time"
 
 	"csv2/ranking"
-	"csv2/utils"
 
 	"github.com/bwmarrin/discordgo"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -16,82 +13,92 @@ import (
 
 // Start sets up the Discord and Telegram bots and starts the relay system.
 func Start(discordToken, telegramToken, telegramChatID, floodChannelID, relayChannelID string, rank *ranking.Ranking) {
-	dg := SetupDiscord(discordToken, floodChannelID, relayChannelID, rank)
+	shards := SetupDiscord(discordToken, floodChannelID, relayChannelID, rank)
 	defer func() {
 		rank.Stop() // Останавливаем горутину сброса
-		dg.Close()
+		for _, dg := range shards {
+			dg.Close()
+		}
 	}()
 
 	tgBot, chatID := setupTelegram(telegramToken, telegramChatID)
+	rank.SetTelegramMirror(func(text string) error {
+		_, err := tgBot.Send(tgbotapi.NewMessage(chatID, text))
+		return err
+	})
+	rank.SetTelegramPhotoMirror(func(photoURL, caption string) error {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(photoURL))
+		photo.Caption = caption
+		_, err := tgBot.Send(photo)
+		return err
+	})
 
 	// Обработчик сообщений из Discord
-	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+	onMessage := func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		if m.Author.ID == s.State.User.ID {
 			return
 		}
 
 		if m.ChannelID == floodChannelID && strings.HasPrefix(m.Content, "/") {
-			log.Printf("Received command: %s from %s in flood channel", m.Content, m.Author.ID)
+			log.Printf("Received command: %s from %s in flood channel%s", m.Content, m.Author.ID, ranking.ShardLabel(s))
 			handleCommands(s, m, rank)
 			return
 		}
 
 		if m.ChannelID == relayChannelID {
-			log.Printf("Relaying message from Discord: %s from %s", m.Content, m.Author.ID)
-			// Текст без вложений
-			if m.Content != "" && len(m.Attachments) == 0 {
-				escapedContent := utils.EscapeMarkdownV2(m.Content)
-				escapedUsername := utils.EscapeMarkdownV2(m.Author.Username)
-				msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("\n*%s*: %s", escapedUsername, escapedContent))
-				msg.ParseMode = "MarkdownV2"
-				if _, err := tgBot.Send(msg); err != nil {
-					log.Printf("Failed to send message to Telegram: %v", err)
-				}
-			}
+			relayToTelegram(tgBot, chatID, m, rank)
+		}
+	}
 
-			// Вложения
-			if len(m.Attachments) > 0 {
-				for _, attachment := range m.Attachments {
-					caption := fmt.Sprintf("\n%s:", m.Author.Username)
-					if m.Content != "" {
-						caption = fmt.Sprintf("\n%s: %s", m.Author.Username, m.Content)
-					}
+	// Обработчик взаимодействий (кнопок и slash-команд)
+	onInteraction := func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		user := interactionUser(i)
+		if user == nil || user.ID == s.State.User.ID {
+			return
+		}
+		// Паника в любом обработчике (кнопки, модалки, slash- и контекстные команды) не роняет бота.
+		// Обработчики освобождают r.mu через defer, поэтому после паники мьютекс не остаётся занятым.
+		defer func() {
+			if p := recover(); p != nil {
+				ranking.CapturePanic(p, interactionTags(i, user.ID))
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{Content: "❌ Внутренняя ошибка, админы уже в курсе!", Flags: discordgo.MessageFlagsEphemeral},
+				})
+			}
+		}()
 
-					filePath := fmt.Sprintf("content/file_%d_%s", time.Now().UnixNano(), attachment.Filename)
-					if err := utils.DownloadFile(attachment.URL, filePath); err != nil {
-						log.Printf("Failed to download attachment: %v", err)
-						continue
-					}
+		// Slash-команды проходят лимит в handleCommands, кнопки, модалки и контекстные команды — здесь
+		limitable := i.Type == discordgo.InteractionMessageComponent || i.Type == discordgo.InteractionModalSubmit ||
+			(i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().CommandType == discordgo.UserApplicationCommand)
+		if limitable && rank.CheckInteractionRateLimit(s, i, user.ID) {
+			return
+		}
 
-					if strings.HasPrefix(attachment.ContentType, "image/") {
-						photo := tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(filePath))
-						photo.Caption = caption
-						if _, err := tgBot.Send(photo); err != nil {
-							log.Printf("Failed to send image to Telegram: %v", err)
-						}
-					} else {
-						doc := tgbotapi.NewDocument(chatID, tgbotapi.FilePath(filePath))
-						doc.Caption = caption
-						if _, err := tgBot.Send(doc); err != nil {
-							log.Printf("Failed to send document to Telegram: %v", err)
-						}
-					}
-					os.Remove(filePath)
-				}
-			}
+		// Обработка slash-команд
+		if i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().CommandType == discordgo.UserApplicationCommand {
+			rank.HandleUserContextCommand(s, i)
+			return
 		}
-	})
 
-	// Обработчик взаимодействий (кнопок и slash-команд)
-	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if i.Member.User.ID == s.State.User.ID {
+		if i.Type == discordgo.InteractionModalSubmit {
+			customID := i.ModalSubmitData().CustomID
+			if rank.CheckMaintenanceButton(s, i, customID) {
+				return
+			}
+			log.Printf("Modal submitted, CustomID: %s, UserID: %s", customID, user.ID)
+			switch {
+			case strings.HasPrefix(customID, "ctx_"):
+				rank.HandleContextModalSubmit(s, i)
+			case strings.HasPrefix(customID, "sell_menu_qty_"):
+				rank.HandleSellMenuQuantitySubmit(s, i)
+			}
 			return
 		}
 
-		// Обработка slash-команд
 		if i.Type == discordgo.InteractionApplicationCommand {
 			commandName := i.ApplicationCommandData().Name
-			log.Printf("Received slash command: %s from %s", commandName, i.Member.User.ID)
+			log.Printf("Received slash command: %s from %s%s", commandName, user.ID, ranking.ShardLabel(s))
 
 			// Создаем фиктивное сообщение для совместимости с существующими обработчиками
 			fakeMessage := &discordgo.MessageCreate{
@@ -99,7 +106,7 @@ func Start(discordToken, telegramToken, telegramChatID, floodChannelID, relayCha
 					ID:        i.ID,
 					ChannelID: i.ChannelID,
 					Content:   "/" + commandName + " " + getCommandOptions(i.ApplicationCommandData().Options),
-					Author:    i.Member.User,
+					Author:    user,
 				},
 			}
 
@@ -115,21 +122,79 @@ func Start(discordToken, telegramToken, telegramChatID, floodChannelID, relayCha
 
 		if i.Type == discordgo.InteractionMessageComponent {
 			customID := i.MessageComponentData().CustomID
-			log.Printf("Interaction received, CustomID: %s, ChannelID: %s, UserID: %s", customID, i.ChannelID, i.Member.User.ID)
+			if rank.CheckMaintenanceButton(s, i, customID) {
+				return
+			}
+			log.Printf("Interaction received, CustomID: %s, ChannelID: %s, UserID: %s", customID, i.ChannelID, user.ID)
 			switch {
+			case strings.HasPrefix(customID, "nft_sell_"):
+				log.Printf("Matched nft_sell_")
+				rank.HandleNFTSellButton(s, i)
 			case strings.HasPrefix(customID, "sell_confirm_"):
 				log.Printf("Matched sell_confirm_")
 				rank.HandleSellConfirm(s, i)
 			case strings.HasPrefix(customID, "sell_cancel_"):
 				log.Printf("Matched sell_cancel_")
 				rank.HandleSellCancel(s, i)
+			case strings.HasPrefix(customID, "vote_pick_"), strings.HasPrefix(customID, "vote_close_"):
+				log.Printf("Matched vote button")
+				rank.HandleVoteButton(s, i)
+			case strings.HasPrefix(customID, "escrow_accept_"), strings.HasPrefix(customID, "escrow_decline_"):
+				log.Printf("Matched escrow trade button")
+				rank.HandleEscrowTradeButton(s, i)
+			case strings.HasPrefix(customID, "comeback_claim_"):
+				log.Printf("Matched comeback_claim_")
+				rank.HandleComebackClaim(s, i)
+			case strings.HasPrefix(customID, "transfer_confirm_"), strings.HasPrefix(customID, "transfer_cancel_"),
+				strings.HasPrefix(customID, "transfer_accept_"), strings.HasPrefix(customID, "transfer_decline_"):
+				log.Printf("Matched transfer button")
+				rank.HandleTransferButton(s, i)
+			case strings.HasPrefix(customID, "airdrop_grab_"):
+				log.Printf("Matched airdrop_grab_")
+				rank.HandleAirdropGrab(s, i)
+			case strings.HasPrefix(customID, "offer_confirm_"), strings.HasPrefix(customID, "offer_cancel_"):
+				log.Printf("Matched offer button")
+				rank.HandleOfferButton(s, i)
+			case strings.HasPrefix(customID, "tutorial_check_"):
+				log.Printf("Matched tutorial_check_")
+				rank.HandleTutorialCheck(s, i)
+			case strings.HasPrefix(customID, "bracket_join_"):
+				log.Printf("Matched bracket_join_")
+				rank.HandleBracketJoin(s, i)
+			case strings.HasPrefix(customID, "top_page_"):
+				log.Printf("Matched top_page_")
+				rank.HandleTopPage(s, i)
+			case strings.HasPrefix(customID, "inv_page_"):
+				log.Printf("Matched inv_page_")
+				rank.HandleInventoryPage(s, i)
+			case strings.HasPrefix(customID, "a_inv_page_"):
+				log.Printf("Matched a_inv_page_")
+				rank.HandleAdminInventoryPage(s, i)
+			case strings.HasPrefix(customID, "trade_collection_confirm_"):
+				log.Printf("Matched trade_collection_confirm_")
+				rank.HandleTradeCollectionConfirm(s, i)
+			case strings.HasPrefix(customID, "trade_collection_cancel_"):
+				log.Printf("Matched trade_collection_cancel_")
+				rank.HandleTradeCollectionCancel(s, i)
+			case strings.HasPrefix(customID, "sell_menu_pick_"):
+				log.Printf("Matched sell_menu_pick_")
+				rank.HandleSellMenuPick(s, i)
+			case strings.HasPrefix(customID, "sell_menu_qty_"):
+				log.Printf("Matched sell_menu_qty_")
+				rank.HandleSellMenuQuantity(s, i)
+			case strings.HasPrefix(customID, "sell_menu_confirm_"):
+				log.Printf("Matched sell_menu_confirm_")
+				rank.HandleSellMenuConfirm(s, i)
+			case strings.HasPrefix(customID, "sell_menu_cancel_"):
+				log.Printf("Matched sell_menu_cancel_")
+				rank.HandleSellMenuCancel(s, i)
 			case strings.HasPrefix(customID, "sell_duplicates_confirm_"):
 				log.Printf("Matched sell_duplicates_confirm_")
 				rank.HandleSellDuplicatesConfirm(s, i)
 			case strings.HasPrefix(customID, "sell_duplicates_cancel_"):
 				log.Printf("Matched sell_duplicates_cancel_")
 				rank.HandleSellDuplicatesCancel(s, i)
-			case strings.HasPrefix(customID, "user_confirm_") || strings.HasPrefix(customID, "user_decline_") ||
+			case strings.HasPrefix(customID, "user_confirm_") || strings.HasPrefix(customID, "user_decline_") || strings.HasPrefix(customID, "user_existing_") ||
 				strings.HasPrefix(customID, "admin_accept_") || strings.HasPrefix(customID, "admin_reject_"):
 				log.Printf("Matched cinema button: %s", customID)
 				rank.HandleCinemaButton(s, i)
@@ -142,27 +207,70 @@ func Start(discordToken, telegramToken, telegramChatID, floodChannelID, relayCha
 			case strings.HasPrefix(customID, "blackjack_stand_"):
 				log.Printf("Matched blackjack_stand_")
 				rank.HandleBlackjackStand(s, i)
+			case strings.HasPrefix(customID, "blackjack_surrender_"):
+				log.Printf("Matched blackjack_surrender_")
+				rank.HandleBlackjackSurrender(s, i)
+			case strings.HasPrefix(customID, "blackjack_rebet_"):
+				log.Printf("Matched blackjack_rebet_")
+				rank.HandleBlackjackRebet(s, i)
 			case strings.HasPrefix(customID, "blackjack_replay_"):
 				log.Printf("Matched blackjack_replay_")
 				rank.HandleBlackjackReplay(s, i)
+			case strings.HasPrefix(customID, "rb_rebet_"):
+				log.Printf("Matched rb_rebet_")
+				rank.HandleRBRebet(s, i)
 			case strings.HasPrefix(customID, "rb_replay_"):
 				log.Printf("Matched rb_replay_, calling HandleRBReplay")
 				rank.HandleRBReplay(s, i)
+			case strings.HasPrefix(customID, "duel_cancel_"):
+				log.Printf("Matched duel_cancel_")
+				rank.HandleDuelCancel(s, i)
 			case strings.HasPrefix(customID, "duel_accept_"):
 				log.Printf("Matched duel_accept_")
 				rank.HandleDuelAccept(s, i)
+			case customID == "help_category" || customID == "help_category_admin":
+				log.Printf("Matched help_category")
+				rank.HandleHelpSelect(s, i)
 			default:
 				log.Printf("No match for CustomID: %s", customID)
 			}
 		} else {
 			log.Printf("Received non-component interaction: %v", i.Type)
 		}
-	})
+	}
+
+	// Каждый шард получает события только своих гильдий
+	for _, dg := range shards {
+		dg.AddHandler(onMessage)
+		dg.AddHandler(onInteraction)
+	}
 
-	go handleTelegramUpdates(tgBot, chatID, dg, relayChannelID)
+	go handleTelegramUpdates(tgBot, chatID, shards[0], relayChannelID, rank)
 	select {}
 }
 
+// interactionUser возвращает автора взаимодействия: на сервере он в i.Member, в ЛС — в i.User.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// interactionTags возвращает теги отчёта об ошибке для взаимодействия.
+func interactionTags(i *discordgo.InteractionCreate, userID string) map[string]string {
+	tags := map[string]string{"user_id": userID, "channel_id": i.ChannelID}
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		tags["custom_id"] = i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		tags["custom_id"] = i.ModalSubmitData().CustomID
+	case discordgo.InteractionApplicationCommand:
+		tags["command"] = i.ApplicationCommandData().Name
+	}
+	return tags
+}
+
 // getCommandOptions преобразует опции slash-команды в строку аргументов
 func getCommandOptions(options []*discordgo.ApplicationCommandInteractionDataOption) string {
 	var args []string
@@ -188,161 +296,72 @@ func getCommandOptions(options []*discordgo.ApplicationCommandInteractionDataOpt
 	return strings.Join(args, " ")
 }
 
-func setupTelegram(token, chatID string) (*tgbotapi.BotAPI, int64) {
-	bot, err := tgbotapi.NewBotAPI(token)
-	if err != nil {
-		log.Fatalf("Failed to initialize Telegram bot: %v", err)
+func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *ranking.Ranking) {
+	command := ranking.CanonicalCommand(strings.TrimSpace(strings.ToLower(m.Content)))
+	log.Printf("Processing command: %s from %s", command, m.Author.ID)
+	defer func() {
+		if p := recover(); p != nil {
+			ranking.CapturePanic(p, map[string]string{"command": strings.SplitN(command, " ", 2)[0], "user_id": m.Author.ID, "channel_id": m.ChannelID})
+			s.ChannelMessageSend(m.ChannelID, "❌ Внутренняя ошибка, админы уже в курсе!")
+		}
+	}()
+	// Массовые админ-команды выполняются по одному сообщению только один раз
+	if !rank.ClaimCommandMessage(m.ID, command) {
+		return
 	}
-	bot.Debug = true
-	log.Printf("Authorized on Telegram account %s", bot.Self.UserName)
-
-	parsedChatID, err := utils.ParseChatID(chatID)
-	if err != nil {
-		log.Fatalf("Invalid Telegram Chat ID: %v", err)
+	if rank.CheckRateLimit(s, m) {
+		return
 	}
-
-	return bot, parsedChatID
-}
-
-func handleTelegramUpdates(bot *tgbotapi.BotAPI, chatID int64, dg *discordgo.Session, relayChannelID string) {
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
-	updates := bot.GetUpdatesChan(updateConfig)
-
-	for update := range updates {
-		if update.Message == nil || update.Message.Chat.ID != chatID {
-			continue
-		}
-
-		log.Printf("Received Telegram message from %s: %s", update.Message.From.UserName, update.Message.Text)
-
-		// Текст без вложений
-		if update.Message.Text != "" && update.Message.Photo == nil && update.Message.VideoNote == nil && update.Message.Voice == nil && update.Message.Document == nil {
-			msg := fmt.Sprintf("➤ \n**%s**: %s", update.Message.From.UserName, update.Message.Text)
-			_, err := dg.ChannelMessageSend(relayChannelID, msg)
-			if err != nil {
-				log.Printf("Failed to send text message to Discord: %v", err)
-			}
-		}
-
-		// Фото
-		if len(update.Message.Photo) > 0 {
-			photoFileID := update.Message.Photo[len(update.Message.Photo)-1].FileID
-			fileURL, err := bot.GetFileDirectURL(photoFileID)
-			if err != nil {
-				log.Printf("Failed to get photo URL: %v", err)
-				continue
-			}
-
-			photoPath := fmt.Sprintf("content/photo_%d.jpg", time.Now().UnixNano())
-			if err := utils.DownloadFile(fileURL, photoPath); err != nil {
-				log.Printf("Failed to download photo: %v", err)
-				continue
-			}
-
-			caption := fmt.Sprintf("➤ %s:", update.Message.From.UserName)
-			if update.Message.Caption != "" {
-				caption = fmt.Sprintf("➤ \n**%s**: %s", update.Message.From.UserName, update.Message.Caption)
-			}
-
-			err = SendFileToDiscord(dg, relayChannelID, photoPath, caption)
-			if err != nil {
-				log.Printf("Failed to send photo to Discord: %v", err)
-			}
-			os.Remove(photoPath)
-		}
-
-		// Видеосообщения
-		if update.Message.VideoNote != nil {
-			videoFileID := update.Message.VideoNote.FileID
-			fileURL, err := bot.GetFileDirectURL(videoFileID)
-			if err != nil {
-				log.Printf("Failed to get video URL: %v", err)
-				continue
-			}
-
-			videoPath := fmt.Sprintf("content/video_%d.mp4", time.Now().UnixNano())
-			if err := utils.DownloadFile(fileURL, videoPath); err != nil {
-				log.Printf("Failed to download video: %v", err)
-				continue
-			}
-
-			caption := fmt.Sprintf("➤ %s:", update.Message.From.UserName)
-			err = SendFileToDiscord(dg, relayChannelID, videoPath, caption)
-			if err != nil {
-				log.Printf("Failed to send video to Discord: %v", err)
-			}
-			os.Remove(videoPath)
-		}
-
-		// Голосовые сообщения
-		if update.Message.Voice != nil {
-			voiceFileID := update.Message.Voice.FileID
-			fileURL, err := bot.GetFileDirectURL(voiceFileID)
-			if err != nil {
-				log.Printf("Failed to get voice URL: %v", err)
-				continue
-			}
-
-			voicePath := fmt.Sprintf("content/voice_%d.ogg", time.Now().UnixNano())
-			if err := utils.DownloadFile(fileURL, voicePath); err != nil {
-				log.Printf("Failed to download voice: %v", err)
-				continue
-			}
-
-			caption := fmt.Sprintf("➤ %s:", update.Message.From.UserName)
-			err = SendFileToDiscord(dg, relayChannelID, voicePath, caption)
-			if err != nil {
-				log.Printf("Failed to send voice to Discord: %v", err)
-			}
-			os.Remove(voicePath)
-		}
-
-		// Документы
-		if update.Message.Document != nil {
-			docFileID := update.Message.Document.FileID
-			fileURL, err := bot.GetFileDirectURL(docFileID)
-			if err != nil {
-				log.Printf("Failed to get document URL: %v", err)
-				continue
-			}
-
-			docPath := fmt.Sprintf("content/doc_%d_%s", time.Now().UnixNano(), update.Message.Document.FileName)
-			if err := utils.DownloadFile(fileURL, docPath); err != nil {
-				log.Printf("Failed to download document: %v", err)
-				continue
-			}
-
-			caption := fmt.Sprintf("➤ %s:", update.Message.From.UserName)
-			if update.Message.Caption != "" {
-				caption = fmt.Sprintf("➤ \n**%s**: %s", update.Message.From.UserName, update.Message.Caption)
-			}
-
-			err = SendFileToDiscord(dg, relayChannelID, docPath, caption)
-			if err != nil {
-				log.Printf("Failed to send document to Discord: %v", err)
-			}
-			os.Remove(docPath)
-		}
+	rank.TouchActivity(s, m.Author.ID)
+	rank.MarkUBIActivity(m.Author.ID, m.ChannelID)
+	rank.EnsureStartingBalance(s, m.Author.ID)
+	if rank.CheckMaintenance(s, m, command) {
+		return
 	}
-}
-
-func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *ranking.Ranking) {
-	command := strings.TrimSpace(strings.ToLower(m.Content))
-	log.Printf("Processing command: %s from %s", command, m.Author.ID)
 	switch {
 	case strings.HasPrefix(command, "/cpoll"):
 		log.Printf("Matched /cpoll")
 		rank.HandlePollCommand(s, m, m.Content)
+	case strings.HasPrefix(command, "/deposit"):
+		log.Printf("Matched /deposit")
+		rank.HandleDepositCommand(s, m, command)
+	case strings.HasPrefix(command, "/withdraw"):
+		log.Printf("Matched /withdraw")
+		rank.HandleWithdrawCommand(s, m, command)
+	case command == "/bank":
+		log.Printf("Matched /bank")
+		rank.HandleBankCommand(s, m)
+	case strings.HasPrefix(command, "/a_bank"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_bank")
+		rank.HandleBankSettingsCommand(s, m, command)
 	case strings.HasPrefix(command, "/dep"):
 		log.Printf("Matched /dep")
 		rank.HandleDepCommand(s, m, m.Content)
 	case strings.HasPrefix(command, "/closedep"):
 		log.Printf("Matched /closedep")
 		rank.HandleCloseDepCommand(s, m, m.Content)
-	case command == "/top5" || command == "/top":
+	case command == "/top" || strings.HasPrefix(command, "/top "):
 		log.Printf("Matched /top")
-		rank.HandleTopCommand(s, m)
+		rank.HandleTopCommand(s, m, command)
+	case strings.HasPrefix(command, "/top_voice"), strings.HasPrefix(command, "/top_duels"),
+		strings.HasPrefix(command, "/top_gamblers"), strings.HasPrefix(command, "/top_collectors"):
+		log.Printf("Matched /top_<категория>")
+		rank.HandleCategoryTopCommand(s, m, command)
+	case strings.HasPrefix(command, "/vote "):
+		log.Printf("Matched /vote")
+		rank.HandleVoteCommand(s, m, m.Content)
+	case command == "/faucet":
+		log.Printf("Matched /faucet")
+		rank.HandleFaucetCommand(s, m)
+	case strings.HasPrefix(command, "/a_faucet"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_faucet")
+		rank.HandleFaucetSettingsCommand(s, m, command)
 	case command == "/polls":
 		log.Printf("Matched /polls")
 		rank.HandlePollsCommand(s, m)
@@ -364,6 +383,147 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 	case strings.HasPrefix(command, "/duel"):
 		log.Printf("Matched /duel")
 		rank.HandleDuelCommand(s, m, m.Content)
+	case strings.HasPrefix(command, "/a_wipe"), strings.HasPrefix(command, "/a_restore"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_wipe or /a_restore")
+		rank.HandleWipeCommand(s, m, command)
+	case strings.HasPrefix(command, "/watch"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /watch")
+		rank.HandleWatchCommand(s, m, command)
+	case strings.HasPrefix(command, "/loan"):
+		log.Printf("Matched /loan")
+		rank.HandleLoanCommand(s, m, command)
+	case strings.HasPrefix(command, "/repay"):
+		log.Printf("Matched /repay")
+		rank.HandleRepayCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_jade"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_jade")
+		rank.HandleJadeAdminCommand(s, m, command)
+	case command == "/a_bulk_grant" || strings.HasPrefix(command, "/a_bulk_grant "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_bulk_grant")
+		rank.HandleBulkGrantCommand(s, m)
+	case strings.HasPrefix(command, "/a_flair"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_flair")
+		rank.HandleFlairAdminCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_rarity"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_rarity")
+		rank.HandleRarityStyleCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_craft"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_craft")
+		rank.HandleCraftAdminCommand(s, m, command)
+	case command == "/a_releases":
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_releases")
+		rank.HandleReleasesCommand(s, m)
+	case strings.HasPrefix(command, "/a_bracket"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_bracket")
+		rank.HandleBracketCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_airdrop"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_airdrop")
+		rank.HandleAirdropCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_payroll"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_payroll")
+		rank.HandlePayrollCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_debts"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_debts")
+		rank.HandleDebtsCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_inflation"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_inflation")
+		rank.HandleInflationCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_transfer"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_transfer")
+		rank.HandleTransferSettingsCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_stream"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_stream")
+		rank.HandleStreamSettingsCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_afk"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_afk")
+		rank.HandleAFKSettingsCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_event"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_event")
+		rank.HandleVoiceEventCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_tax"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_tax")
+		rank.HandleTransferTaxCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_ubi"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_ubi")
+		rank.HandleUBICommand(s, m, command)
+	case strings.HasPrefix(command, "/a_loan"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_loan")
+		rank.HandleLoanSettingsCommand(s, m, command)
+	case command == "/mydata":
+		log.Printf("Matched /mydata")
+		rank.HandleMyDataCommand(s, m)
+	case strings.HasPrefix(command, "/history"):
+		log.Printf("Matched /history")
+		rank.HandleHistoryCommand(s, m, command)
+	case command == "/profile" || strings.HasPrefix(command, "/profile "):
+		log.Printf("Matched /profile")
+		rank.HandleProfileCommand(s, m, command)
+	case command == "/wishlist" || strings.HasPrefix(command, "/wishlist "):
+		log.Printf("Matched /wishlist")
+		rank.HandleWishlistCommand(s, m, command)
+	case command == "/showcase" || strings.HasPrefix(command, "/showcase "):
+		log.Printf("Matched /showcase")
+		rank.HandleShowcaseCommand(s, m, command)
 	case strings.HasPrefix(command, "/stats"):
 		log.Printf("Matched /stats")
 		rank.HandleStatsCommand(s, m)
@@ -382,11 +542,11 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 	case strings.HasPrefix(command, "/cinema "):
 		log.Printf("Matched /cinema")
 		rank.HandleCinemaCommand(s, m, command)
-	case strings.HasPrefix(command, "/betcinema ") || strings.HasPrefix(command, "/bet_cinema "):
-		log.Printf("Matched /betcinema or /bet_cinema")
+	case strings.HasPrefix(command, "/betcinema "):
+		log.Printf("Matched /betcinema")
 		rank.HandleBetCinemaCommand(s, m, command)
-	case command == "/cinemalist" || command == "/cinema_list":
-		log.Printf("Matched /cinemalist or /cinema_list")
+	case command == "/cinemalist":
+		log.Printf("Matched /cinemalist")
 		rank.HandleCinemaListCommand(s, m)
 	case strings.HasPrefix(command, "/admin_adjust_cinema"):
 		log.Printf("Matched /admin_adjust_cinema")
@@ -412,15 +572,93 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 	case strings.HasPrefix(command, "/admin"):
 		log.Printf("Matched /admin")
 		rank.HandleAdminCommand(s, m, m.Content)
-	case command == "/chelp" || command == "/help":
-		log.Printf("Matched /chelp or /help")
-		rank.HandleChelpCommand(s, m)
+	case strings.HasPrefix(command, "/shop_add_role"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /shop_add_role")
+		rank.HandleShopAddRoleCommand(s, m, command)
+	case strings.HasPrefix(command, "/shop_remove_role"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /shop_remove_role")
+		rank.HandleShopRemoveRoleCommand(s, m, command)
+	case command == "/season":
+		log.Printf("Matched /season")
+		rank.HandleSeasonCommand(s, m)
+	case strings.HasPrefix(command, "/a_season_end"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_season_end")
+		rank.HandleSeasonEndCommand(s, m, command)
+	case command == "/quiet" || strings.HasPrefix(command, "/quiet "):
+		log.Printf("Matched /quiet")
+		rank.HandleQuietCommand(s, m, command)
+	case command == "/anon" || strings.HasPrefix(command, "/anon "):
+		log.Printf("Matched /anon")
+		rank.HandleAnonCommand(s, m, command)
+	case command == "/shop" || strings.HasPrefix(command, "/shop "):
+		log.Printf("Matched /shop")
+		rank.HandleShopCommand(s, m, command)
+	case command == "/shops":
+		log.Printf("Matched /shops")
+		rank.HandleShopsCommand(s, m)
+	case command == "/shop_open" || strings.HasPrefix(command, "/shop_open "):
+		log.Printf("Matched /shop_open")
+		rank.HandleShopOpenCommand(s, m, command)
+	case strings.HasPrefix(command, "/shop_add "):
+		log.Printf("Matched /shop_add")
+		rank.HandleShopAddCommand(s, m, command)
+	case strings.HasPrefix(command, "/shop_remove "):
+		log.Printf("Matched /shop_remove")
+		rank.HandleShopRemoveCommand(s, m, command)
+	case command == "/shop_close":
+		log.Printf("Matched /shop_close")
+		rank.HandleShopCloseCommand(s, m)
+	case strings.HasPrefix(command, "/shop_buy "):
+		log.Printf("Matched /shop_buy")
+		rank.HandleShopBuyCommand(s, m, command)
+	case command == "/themes":
+		log.Printf("Matched /themes")
+		rank.HandleThemesCommand(s, m)
+	case strings.HasPrefix(command, "/buy_theme"):
+		log.Printf("Matched /buy_theme")
+		rank.HandleBuyThemeCommand(s, m, command)
+	case strings.HasPrefix(command, "/theme "):
+		log.Printf("Matched /theme")
+		rank.HandleThemeCommand(s, m, command)
+	case command == "/tutorial":
+		log.Printf("Matched /tutorial")
+		rank.HandleTutorialCommand(s, m)
+	case command == "/flairs":
+		log.Printf("Matched /flairs")
+		rank.HandleFlairsCommand(s, m)
+	case strings.HasPrefix(command, "/buy_flair"):
+		log.Printf("Matched /buy_flair")
+		rank.HandleBuyFlairCommand(s, m, command)
+	case strings.HasPrefix(command, "/flair "):
+		log.Printf("Matched /flair")
+		rank.HandleFlairCommand(s, m, command)
+	case command == "/chelp" || strings.HasPrefix(command, "/chelp "):
+		log.Printf("Matched /chelp")
+		rank.HandleChelpCommand(s, m, command)
 	case command == "/china":
 		log.Printf("Matched /china")
 		rank.HandleChinaCommand(s, m)
+	case command == "/networth" || strings.HasPrefix(command, "/networth "):
+		log.Printf("Matched /networth")
+		rank.HandleNetWorthCommand(s, m, command)
 	case strings.HasPrefix(command, "/transfer"):
 		log.Printf("Matched /transfer")
 		rank.HandleTransferCommand(s, m, m.Content)
+	case strings.HasPrefix(command, "/mergecinema "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /mergecinema")
+		rank.HandleMergeCinemaCommand(s, m, command)
 	case strings.HasPrefix(command, "/removecinema "):
 		log.Printf("Matched /removecinema")
 		rank.HandleRemoveCinemaCommand(s, m, command)
@@ -429,18 +667,16 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 			return
 		}
 		log.Printf("Matched /sync_nfts")
-		err := rank.Kki.SyncFromSheets(rank)
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, "❌ **Ошибка синхронизации**: "+err.Error())
-		} else {
-			s.ChannelMessageSend(m.ChannelID, "✅ **NFT и кейсы синхронизированы из Google Sheets!**")
-		}
-	case command == "/inventory":
+		rank.HandleSyncNFTsCommand(s, m)
+	case command == "/inventory" || strings.HasPrefix(command, "/inventory "):
 		log.Printf("Matched /inventory")
-		rank.HandleInventoryCommand(s, m)
+		rank.HandleInventoryCommand(s, m, command)
 	case command == "/sell_duplicates":
 		log.Printf("Matched /sell_duplicates")
 		rank.HandleSellDuplicatesCommand(s, m)
+	case command == "/sell_menu" || strings.HasPrefix(command, "/sell_menu "):
+		log.Printf("Matched /sell_menu")
+		rank.HandleSellMenuCommand(s, m, command)
 	case command == "/top_inventories":
 		log.Printf("Matched /top_inventories")
 		rank.HandleTopInventoriesCommand(s, m)
@@ -450,12 +686,27 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 	case strings.HasPrefix(command, "/sell "):
 		log.Printf("Matched /sell")
 		rank.HandleSellCommand(s, m, command)
+	case strings.HasPrefix(command, "/trade_collection "):
+		log.Printf("Matched /trade_collection")
+		rank.HandleTradeCollectionCommand(s, m, command)
+	case command == "/craft" || strings.HasPrefix(command, "/craft "):
+		log.Printf("Matched /craft")
+		rank.HandleCraftCommand(s, m, command)
+	case command == "/offer" || strings.HasPrefix(command, "/offer "):
+		log.Printf("Matched /offer")
+		rank.HandleOfferCommand(s, m, command)
 	case strings.HasPrefix(command, "/trade_nft "):
 		log.Printf("Matched /trade_nft")
 		rank.HandleTradeNFTCommand(s, m, command)
+	case command == "/market" || strings.HasPrefix(command, "/market "):
+		log.Printf("Matched /market")
+		rank.HandleMarketCommand(s, m, command)
 	case strings.HasPrefix(command, "/open_case "):
 		log.Printf("Matched /open_case")
 		rank.HandleOpenCaseCommand(s, m, command)
+	case command == "/daily":
+		log.Printf("Matched /daily")
+		rank.HandleDailyCommand(s, m)
 	case command == "/daily_case":
 		log.Printf("Matched /daily_case")
 		rank.HandleDailyCaseCommand(s, m)
@@ -474,6 +725,24 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 		}
 		log.Printf("Matched /a_give_nft")
 		rank.HandleAdminGiveNFT(s, m, command)
+	case strings.HasPrefix(command, "/a_inventory"), strings.HasPrefix(command, "/a_cases"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_inventory")
+		rank.HandleAdminInventoryCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_announce"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_announce")
+		rank.HandleAnnounceCommand(s, m, command)
+	case strings.HasPrefix(command, "/a_recall"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_recall")
+		rank.HandleRecallCommand(s, m, command)
 	case strings.HasPrefix(command, "/a_remove_nft "):
 		if !rank.IsAdmin(m.Author.ID) {
 			return
@@ -492,12 +761,15 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 		}
 		log.Printf("Matched /a_give_holiday_case_all")
 		rank.HandleAdminGiveHolidayCaseAll(s, m, command)
-	case command == "/case_help":
+	case command == "/case_help" || strings.HasPrefix(command, "/case_help "):
 		log.Printf("Matched /case_help")
-		rank.HandleCaseHelpCommand(s, m)
+		rank.HandleCaseHelpCommand(s, m, command)
 	case strings.HasPrefix(command, "/show_nft "):
 		log.Printf("Matched /show_nft")
 		rank.HandleShowNFTCommand(s, m, command)
+	case strings.HasPrefix(command, "/nft_chart "):
+		log.Printf("Matched /nft_chart")
+		rank.HandleNFTChartCommand(s, m, command)
 	case strings.HasPrefix(command, "/nft_show "):
 		log.Printf("Matched /nft_show")
 		rank.HandleShowNFTCommand(s, m, command)
@@ -510,6 +782,21 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 	case command == "/case_bank":
 		log.Printf("Matched /case_bank")
 		rank.HandleCaseBankCommand(s, m)
+	case strings.HasPrefix(command, "/limit"):
+		log.Printf("Matched /limit")
+		rank.HandleLimitCommand(s, m, command)
+	case strings.HasPrefix(command, "/selfban"):
+		log.Printf("Matched /selfban")
+		rank.HandleSelfBanCommand(s, m, command)
+	case strings.HasPrefix(command, "/jade"):
+		log.Printf("Matched /jade")
+		rank.HandleJadeCommand(s, m, command)
+	case strings.HasPrefix(command, "/exchange"):
+		log.Printf("Matched /exchange")
+		rank.HandleExchangeCommand(s, m, command)
+	case strings.HasPrefix(command, "/buy_jade"):
+		log.Printf("Matched /buy_jade")
+		rank.HandleBuyJadeCommand(s, m, command)
 	case strings.HasPrefix(command, "/buy_case_bank "):
 		log.Printf("Matched /buy_case_bank")
 		rank.HandleBuyCaseBankCommand(s, m, command)
@@ -531,6 +818,78 @@ func handleCommands(s *discordgo.Session, m *discordgo.MessageCreate, rank *rank
 		}
 		log.Printf("Matched /a_refresh_bank")
 		rank.HandleAdminRefreshBankCommand(s, m)
+	case command == "/a_wager_cap" || strings.HasPrefix(command, "/a_wager_cap "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_wager_cap")
+		rank.HandleWagerCapCommand(s, m, command)
+	case command == "/a_duel_tie" || strings.HasPrefix(command, "/a_duel_tie "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_duel_tie")
+		rank.HandleDuelTieCommand(s, m, command)
+	case command == "/a_shards":
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_shards")
+		rank.HandleShardsCommand(s, m)
+	case command == "/a_economy":
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_economy")
+		rank.HandleEconomyCommand(s, m)
+	case command == "/a_retention" || strings.HasPrefix(command, "/a_retention "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_retention")
+		rank.HandleRetentionCommand(s, m, command)
+	case command == "/a_ratelimit" || strings.HasPrefix(command, "/a_ratelimit "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_ratelimit")
+		rank.HandleRateLimitCommand(s, m, command)
+	case command == "/a_reconcile":
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_reconcile")
+		rank.HandleReconcileCommand(s, m)
+	case command == "/a_jobs" || strings.HasPrefix(command, "/a_jobs "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_jobs")
+		rank.HandleJobsCommand(s, m, command)
+	case command == "/a_timeout" || strings.HasPrefix(command, "/a_timeout "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_timeout")
+		rank.HandleTimeoutCommand(s, m, command)
+	case command == "/a_sync_commands":
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_sync_commands")
+		handleSyncCommands(s, m)
+	case strings.HasPrefix(command, "/a_maintenance"):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_maintenance")
+		rank.HandleMaintenanceCommand(s, m, command)
+	case command == "/a_bet_limits" || strings.HasPrefix(command, "/a_bet_limits "):
+		if !rank.IsAdmin(m.Author.ID) {
+			return
+		}
+		log.Printf("Matched /a_bet_limits")
+		rank.HandleBetLimitsCommand(s, m, command)
 	default:
 		log.Printf("No match for command: %s", command)
 	}
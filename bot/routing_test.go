@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"csv2/ranking"
+)
+
+// commandRoutes разбирает handleCommands и возвращает команды, которые он сравнивает
+// целиком (command == "...") и по префиксу (strings.HasPrefix(command, "...")).
+func commandRoutes(t *testing.T) (exact, prefixes []string) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "handlers.go", nil, 0)
+	if err != nil {
+		t.Fatalf("не удалось разобрать handlers.go: %v", err)
+	}
+	var body *ast.BlockStmt
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "handleCommands" {
+			body = fn.Body
+		}
+	}
+	if body == nil {
+		t.Fatal("handleCommands не найден в handlers.go")
+	}
+
+	isCommand := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == "command"
+	}
+	literal := func(expr ast.Expr) (string, bool) {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(lit.Value)
+		return value, err == nil
+	}
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.BinaryExpr:
+			if n.Op == token.EQL && isCommand(n.X) {
+				if value, ok := literal(n.Y); ok {
+					exact = append(exact, value)
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if ok && sel.Sel.Name == "HasPrefix" && len(n.Args) == 2 && isCommand(n.Args[0]) {
+				if value, ok := literal(n.Args[1]); ok {
+					prefixes = append(prefixes, value)
+				}
+			}
+		}
+		return true
+	})
+	return exact, prefixes
+}
+
+// TestRegistryCommandsAreRouted проверяет, что каждая команда справки и каждый её псевдоним
+// после CanonicalCommand попадают в какую-нибудь ветку handleCommands.
+func TestRegistryCommandsAreRouted(t *testing.T) {
+	exact, prefixes := commandRoutes(t)
+	routed := func(command string) bool {
+		for _, route := range exact {
+			if command == route {
+				return true
+			}
+		}
+		for _, route := range prefixes {
+			if strings.HasPrefix(command, route) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, category := range ranking.HelpCategories {
+		for _, info := range ranking.CommandsInCategory(category.ID) {
+			names := append([]string{strings.Fields(info.Usage)[0]}, info.Aliases...)
+			for _, name := range names {
+				canonical := ranking.CanonicalCommand(name)
+				// Команда с аргументами роутится по префиксу "/name ", без — по точному имени
+				if !routed(canonical) && !routed(ranking.CanonicalCommand(name+" x")) {
+					t.Errorf("%s (из %q) не обрабатывается в handleCommands", name, info.Usage)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"csv2/ranking"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// flapWindow — окно, в котором считаются разрывы соединения для определения «мигания» сессии.
+const flapWindow = 10 * time.Minute
+
+// sessionMonitor отслеживает разрывы и восстановления соединения с Discord.
+type sessionMonitor struct {
+	mu          sync.Mutex
+	rank        *ranking.Ranking
+	disconnects []time.Time
+	lastAlert   time.Time
+	readyCount  int
+	threshold   int
+	channelID   string
+}
+
+// registerSessionHandlers подключает обработчики событий соединения с шлюзом Discord.
+func registerSessionHandlers(dg *discordgo.Session, rank *ranking.Ranking) {
+	threshold := 5
+	if value, err := strconv.Atoi(os.Getenv("SESSION_FLAP_THRESHOLD")); err == nil && value > 0 {
+		threshold = value
+	}
+	channelID := os.Getenv("ADMIN_CHANNEL_ID")
+	if channelID == "" {
+		channelID = os.Getenv("LOG_CHANNEL_ID")
+	}
+
+	mon := &sessionMonitor{rank: rank, threshold: threshold, channelID: channelID}
+	dg.AddHandler(mon.onConnect)
+	dg.AddHandler(mon.onDisconnect)
+	dg.AddHandler(mon.onResumed)
+	dg.AddHandler(mon.onReady)
+	dg.AddHandler(mon.onGuildCreate)
+}
+
+func (mon *sessionMonitor) onConnect(s *discordgo.Session, _ *discordgo.Connect) {
+	log.Printf("Соединение с шлюзом Discord установлено%s", ranking.ShardLabel(s))
+}
+
+func (mon *sessionMonitor) onDisconnect(s *discordgo.Session, _ *discordgo.Disconnect) {
+	now := time.Now()
+	mon.mu.Lock()
+	recent := mon.disconnects[:0]
+	for _, t := range mon.disconnects {
+		if now.Sub(t) < flapWindow {
+			recent = append(recent, t)
+		}
+	}
+	mon.disconnects = append(recent, now)
+	count := len(mon.disconnects)
+	alert := count >= mon.threshold && now.Sub(mon.lastAlert) >= flapWindow
+	if alert {
+		mon.lastAlert = now
+	}
+	mon.mu.Unlock()
+
+	log.Printf("Соединение с Discord потеряно%s (%d разрывов за %s), ожидаем переподключения", ranking.ShardLabel(s), count, flapWindow)
+	if alert && mon.channelID != "" {
+		// REST-запросы работают независимо от шлюза, поэтому сообщение уйдёт даже во время разрыва
+		msg := fmt.Sprintf("⚠️ Сессия Discord нестабильна%s: %d разрывов соединения за последние %d минут!", ranking.ShardLabel(s), count, int(flapWindow.Minutes()))
+		if _, err := s.ChannelMessageSend(mon.channelID, msg); err != nil {
+			log.Printf("Не удалось отправить предупреждение о нестабильной сессии: %v", err)
+		}
+	}
+}
+
+func (mon *sessionMonitor) onResumed(s *discordgo.Session, _ *discordgo.Resumed) {
+	log.Printf("Сессия Discord восстановлена%s (resume), пропущенные события будут доставлены", ranking.ShardLabel(s))
+}
+
+func (mon *sessionMonitor) onReady(s *discordgo.Session, r *discordgo.Ready) {
+	mon.mu.Lock()
+	mon.readyCount++
+	first := mon.readyCount == 1
+	mon.mu.Unlock()
+
+	if first {
+		log.Printf("Discord готов%s: %s, гильдий: %d", ranking.ShardLabel(s), r.User.Username, len(r.Guilds))
+		return
+	}
+	// Новая сессия вместо resume: события за время разрыва потеряны, обновляем кэши
+	log.Printf("Discord переподключён с новой сессией%s (%d-й Ready), обновляем кэши", ranking.ShardLabel(s), mon.readyCount)
+	mon.rank.RefreshAfterReconnect()
+}
+
+// onGuildCreate восстанавливает отслеживание голосовой активности по актуальным голосовым состояниям гильдии.
+func (mon *sessionMonitor) onGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == "" {
+			continue
+		}
+		mon.rank.TrackVoiceActivity(s, &discordgo.VoiceStateUpdate{VoiceState: vs})
+	}
+}
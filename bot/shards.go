@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// shardConfig читает SHARD_COUNT (число, по умолчанию 1, или auto — рекомендация Discord)
+// и SHARD_IDS (шарды этого процесса: "0,2,4-7", по умолчанию все).
+func shardConfig(token string) (count int, ids []int, err error) {
+	count = 1
+	switch value := strings.TrimSpace(os.Getenv("SHARD_COUNT")); value {
+	case "":
+	case "auto":
+		dg, err := discordgo.New("Bot " + token)
+		if err != nil {
+			return 0, nil, err
+		}
+		gateway, err := dg.GatewayBot()
+		if err != nil {
+			return 0, nil, fmt.Errorf("не удалось получить рекомендуемое число шардов: %v", err)
+		}
+		count = max(gateway.Shards, 1)
+	default:
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return 0, nil, fmt.Errorf("SHARD_COUNT должно быть положительным числом или auto, получено %q", value)
+		}
+	}
+	ids, err = parseShardIDs(os.Getenv("SHARD_IDS"), count)
+	return count, ids, err
+}
+
+// parseShardIDs разбирает список шардов вида "0,2,4-7". Пустой список — все шарды.
+func parseShardIDs(spec string, count int) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		ids := make([]int, count)
+		for i := range ids {
+			ids[i] = i
+		}
+		return ids, nil
+	}
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 0 || last < first || last >= count {
+			return nil, fmt.Errorf("некорректный шард %q в SHARD_IDS (шардов всего %d)", part, count)
+		}
+		for id := first; id <= last; id++ {
+			seen[id] = true
+		}
+	}
+	ids := make([]int, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"csv2/ranking"
+	"csv2/utils"
+
+	"github.com/bwmarrin/discordgo"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ретрансляция сообщений между каналом Discord (RELAY_CHANNEL_ID) и чатом Telegram.
+// Вся логика моста живёт здесь: Start только подключает её к обработчикам.
+
+func setupTelegram(token, chatID string) (*tgbotapi.BotAPI, int64) {
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		log.Fatalf("Failed to initialize Telegram bot: %v", err)
+	}
+	bot.Debug = true
+	log.Printf("Authorized on Telegram account %s", bot.Self.UserName)
+
+	parsedChatID, err := utils.ParseChatID(chatID)
+	if err != nil {
+		log.Fatalf("Invalid Telegram Chat ID: %v", err)
+	}
+
+	return bot, parsedChatID
+}
+
+// relayToTelegram пересылает сообщение канала-моста Discord в чат Telegram.
+func relayToTelegram(tgBot *tgbotapi.BotAPI, chatID int64, m *discordgo.MessageCreate, rank *ranking.Ranking) {
+	log.Printf("Relaying message from Discord: %s from %s", m.Content, m.Author.ID)
+	// Текст без вложений
+	if m.Content != "" && len(m.Attachments) == 0 {
+		escapedContent := utils.EscapeMarkdownV2(m.Content)
+		escapedUsername := utils.EscapeMarkdownV2(m.Author.Username)
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("\n*%s*: %s", escapedUsername, escapedContent))
+		msg.ParseMode = "MarkdownV2"
+		if _, err := tgBot.Send(msg); err != nil {
+			log.Printf("Failed to send message to Telegram: %v", err)
+			rank.ReportError("telegram", err)
+		}
+	}
+
+	// Вложения
+	for _, attachment := range m.Attachments {
+		caption := fmt.Sprintf("\n%s:", m.Author.Username)
+		if m.Content != "" {
+			caption = fmt.Sprintf("\n%s: %s", m.Author.Username, m.Content)
+		}
+
+		filePath := fmt.Sprintf("content/file_%d_%s", time.Now().UnixNano(), attachment.Filename)
+		if err := utils.DownloadFile(attachment.URL, filePath); err != nil {
+			log.Printf("Failed to download attachment: %v", err)
+			continue
+		}
+
+		if strings.HasPrefix(attachment.ContentType, "image/") {
+			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(filePath))
+			photo.Caption = caption
+			if _, err := tgBot.Send(photo); err != nil {
+				log.Printf("Failed to send image to Telegram: %v", err)
+				rank.ReportError("telegram", err)
+			}
+		} else {
+			doc := tgbotapi.NewDocument(chatID, tgbotapi.FilePath(filePath))
+			doc.Caption = caption
+			if _, err := tgBot.Send(doc); err != nil {
+				log.Printf("Failed to send document to Telegram: %v", err)
+				rank.ReportError("telegram", err)
+			}
+		}
+		os.Remove(filePath)
+	}
+}
+
+// telegramAttachment — вложение сообщения Telegram, которое пересылается в Discord файлом.
+type telegramAttachment struct {
+	kind        string // photo, video, voice, document — для логов
+	fileID      string
+	path        string
+	withCaption bool // добавлять ли подпись сообщения Telegram
+}
+
+// telegramAttachments возвращает вложения сообщения Telegram в порядке пересылки.
+func telegramAttachments(msg *tgbotapi.Message) []telegramAttachment {
+	now := time.Now().UnixNano()
+	var attachments []telegramAttachment
+	if len(msg.Photo) > 0 {
+		attachments = append(attachments, telegramAttachment{"photo", msg.Photo[len(msg.Photo)-1].FileID, fmt.Sprintf("content/photo_%d.jpg", now), true})
+	}
+	if msg.VideoNote != nil {
+		attachments = append(attachments, telegramAttachment{"video", msg.VideoNote.FileID, fmt.Sprintf("content/video_%d.mp4", now), false})
+	}
+	if msg.Voice != nil {
+		attachments = append(attachments, telegramAttachment{"voice", msg.Voice.FileID, fmt.Sprintf("content/voice_%d.ogg", now), false})
+	}
+	if msg.Document != nil {
+		attachments = append(attachments, telegramAttachment{"document", msg.Document.FileID, fmt.Sprintf("content/doc_%d_%s", now, msg.Document.FileName), true})
+	}
+	return attachments
+}
+
+func handleTelegramUpdates(bot *tgbotapi.BotAPI, chatID int64, dg *discordgo.Session, relayChannelID string, rank *ranking.Ranking) {
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = 60
+	updates := bot.GetUpdatesChan(updateConfig)
+
+	for update := range updates {
+		if update.Message == nil || update.Message.Chat.ID != chatID {
+			continue
+		}
+
+		log.Printf("Received Telegram message from %s: %s", update.Message.From.UserName, update.Message.Text)
+
+		attachments := telegramAttachments(update.Message)
+		// Текст без вложений
+		if update.Message.Text != "" && len(attachments) == 0 {
+			msg := fmt.Sprintf("➤ \n**%s**: %s", update.Message.From.UserName, update.Message.Text)
+			_, err := dg.ChannelMessageSend(relayChannelID, msg)
+			if err != nil {
+				log.Printf("Failed to send text message to Discord: %v", err)
+			}
+		}
+
+		for _, attachment := range attachments {
+			relayTelegramAttachment(bot, dg, relayChannelID, update.Message, attachment, rank)
+		}
+	}
+}
+
+// relayTelegramAttachment скачивает вложение из Telegram и отправляет его в канал-мост Discord.
+func relayTelegramAttachment(bot *tgbotapi.BotAPI, dg *discordgo.Session, relayChannelID string, msg *tgbotapi.Message, attachment telegramAttachment, rank *ranking.Ranking) {
+	fileURL, err := bot.GetFileDirectURL(attachment.fileID)
+	if err != nil {
+		log.Printf("Failed to get %s URL: %v", attachment.kind, err)
+		rank.ReportError("telegram", err)
+		return
+	}
+
+	if err := utils.DownloadFile(fileURL, attachment.path); err != nil {
+		log.Printf("Failed to download %s: %v", attachment.kind, err)
+		return
+	}
+	defer os.Remove(attachment.path)
+
+	caption := fmt.Sprintf("➤ %s:", msg.From.UserName)
+	if attachment.withCaption && msg.Caption != "" {
+		caption = fmt.Sprintf("➤ \n**%s**: %s", msg.From.UserName, msg.Caption)
+	}
+
+	if err := SendFileToDiscord(dg, relayChannelID, attachment.path, caption); err != nil {
+		log.Printf("Failed to send %s to Discord: %v", attachment.kind, err)
+	}
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"csv2/utils"
+
+	"github.com/bwmarrin/discordgo"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// telegramCall — запрос к фейковому Bot API.
+type telegramCall struct {
+	method string
+	fields map[string]string
+	files  []string
+}
+
+// fakeTelegram поднимает сервер, отвечающий как Bot API, и раздающий вложения по /files/.
+func fakeTelegram(t *testing.T) (*tgbotapi.BotAPI, *httptest.Server, func() []telegramCall) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []telegramCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/files/") {
+			w.Write([]byte("attachment"))
+			return
+		}
+		method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		call := telegramCall{method: method, fields: map[string]string{}}
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+			if err := req.ParseMultipartForm(1 << 20); err == nil {
+				for name, files := range req.MultipartForm.File {
+					for _, file := range files {
+						call.files = append(call.files, name+":"+file.Filename)
+					}
+				}
+			}
+		} else {
+			req.ParseForm()
+		}
+		for name := range req.Form {
+			call.fields[name] = req.Form.Get(name)
+		}
+		if method == "getMe" {
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"relay_bot"}}`))
+			return
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":42}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("не удалось создать бота: %v", err)
+	}
+	return bot, server, func() []telegramCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telegramCall(nil), calls...)
+	}
+}
+
+// inTempDir переходит во временный каталог с папкой content/, куда качаются вложения.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("content", 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelayToTelegramText(t *testing.T) {
+	bot, _, calls := fakeTelegram(t)
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content: "привет, мир!",
+		Author:  &discordgo.User{ID: "1", Username: "some_user"},
+	}}
+
+	relayToTelegram(bot, 42, m, nil)
+
+	got := calls()
+	if len(got) != 1 || got[0].method != "sendMessage" {
+		t.Fatalf("ожидался один sendMessage, получено %+v", got)
+	}
+	want := "\n*" + utils.EscapeMarkdownV2("some_user") + "*: " + utils.EscapeMarkdownV2("привет, мир!")
+	if got[0].fields["text"] != want {
+		t.Errorf("text = %q, ожидалось %q", got[0].fields["text"], want)
+	}
+	if got[0].fields["parse_mode"] != "MarkdownV2" || got[0].fields["chat_id"] != "42" {
+		t.Errorf("неверные параметры сообщения: %+v", got[0].fields)
+	}
+}
+
+func TestRelayToTelegramAttachments(t *testing.T) {
+	inTempDir(t)
+	bot, server, calls := fakeTelegram(t)
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content: "смотри",
+		Author:  &discordgo.User{ID: "1", Username: "user"},
+		Attachments: []*discordgo.MessageAttachment{
+			{URL: server.URL + "/files/cat.png", Filename: "cat.png", ContentType: "image/png"},
+			{URL: server.URL + "/files/notes.txt", Filename: "notes.txt", ContentType: "text/plain"},
+		},
+	}}
+
+	relayToTelegram(bot, 42, m, nil)
+
+	got := calls()
+	if len(got) != 2 {
+		t.Fatalf("ожидалось два вызова (фото и документ) без отдельного текста, получено %+v", got)
+	}
+	if got[0].method != "sendPhoto" || got[1].method != "sendDocument" {
+		t.Errorf("методы = %s, %s; ожидались sendPhoto, sendDocument", got[0].method, got[1].method)
+	}
+	for _, call := range got {
+		if call.fields["caption"] != "\nuser: смотри" {
+			t.Errorf("%s: caption = %q", call.method, call.fields["caption"])
+		}
+		if len(call.files) != 1 {
+			t.Errorf("%s: ожидался один файл, получено %v", call.method, call.files)
+		}
+	}
+	left, _ := os.ReadDir("content")
+	if len(left) != 0 {
+		t.Errorf("временные файлы не удалены: %v", left)
+	}
+}
+
+func TestTelegramAttachments(t *testing.T) {
+	msg := &tgbotapi.Message{
+		Photo:    []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}},
+		Voice:    &tgbotapi.Voice{FileID: "voice"},
+		Document: &tgbotapi.Document{FileID: "doc", FileName: "a.pdf"},
+	}
+	got := telegramAttachments(msg)
+	kinds := make([]string, len(got))
+	for i, attachment := range got {
+		kinds[i] = attachment.kind + ":" + attachment.fileID
+	}
+	if strings.Join(kinds, ",") != "photo:large,voice:voice,document:doc" {
+		t.Errorf("вложения = %v", kinds)
+	}
+	if !got[0].withCaption || got[1].withCaption || !got[2].withCaption {
+		t.Errorf("подпись должна идти только с фото и документом: %+v", got)
+	}
+	if !strings.HasSuffix(got[2].path, "_a.pdf") {
+		t.Errorf("путь документа %q должен сохранять имя файла", got[2].path)
+	}
+}
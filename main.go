@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
@@ -10,11 +11,26 @@ import (
 )
 
 func main() {
+	check := flag.Bool("check", false, "проверить конфигурацию и выйти")
+	flag.Parse()
+
 	err := godotenv.Load()
 	if err != nil {
 		log.Printf("Warning: Failed to load .env file: %v", err)
 	}
 
+	// Самопроверка перед запуском: при --check только печатаем отчёт
+	ok := bot.PrintCheckReport(bot.RunChecks())
+	if *check {
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+	if !ok {
+		log.Fatal("Configuration check failed, see the report above (run with --check to re-test)")
+	}
+
 	discordToken := os.Getenv("DISCORD_TOKEN")
 	telegramToken := os.Getenv("TELEGRAM_TOKEN")
 	floodChannelID := os.Getenv("FLOOD_CHANNEL_ID")
@@ -24,20 +40,6 @@ func main() {
 	redisAddr := os.Getenv("REDIS_ADDR")
 	telegramChatID := os.Getenv("TELEGRAM_CHAT_ID")
 
-	if discordToken == "" {
-		log.Fatal("DISCORD_TOKEN is not set")
-	}
-	if telegramToken == "" {
-		log.Fatal("TELEGRAM_TOKEN is not set")
-	}
-	if telegramChatID == "" {
-		log.Fatal("TELEGRAM_CHAT_ID is not set")
-	}
-
-	if cinemaChannelID == "" {
-		log.Fatal("CINEMA_CHANNEL_ID is not set")
-	}
-
 	rank, err := ranking.NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID)
 	if err != nil {
 		log.Fatalf("Failed to initialize ranking: %v", err)
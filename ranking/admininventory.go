@@ -0,0 +1,150 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminInventoryPageSize — сколько позиций показывается на странице /a_inventory и /a_cases.
+const adminInventoryPageSize = 10
+
+// adminInventoryLines возвращает строки инвентаря NFT пользователя и его общую стоимость.
+func (r *Ranking) adminInventoryLines(userID string) ([]string, int) {
+	var lines []string
+	total := 0
+	for nftID, count := range r.GetUserInventory(userID) {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("❓ **%s** (x%d) — нет в базе NFT", nftID, count))
+			continue
+		}
+		total += nft.Price * count
+		lines = append(lines, fmt.Sprintf("%s **%s** (x%d) — ID: %s | 💰 %d | %s", RarityEmojis[nft.Rarity], nft.Name, count, nftID, nft.Price, nft.Collection))
+	}
+	sort.Strings(lines)
+	return lines, total
+}
+
+// adminCaseLines возвращает строки инвентаря кейсов пользователя и его общую стоимость.
+func (r *Ranking) adminCaseLines(userID string) ([]string, int) {
+	var lines []string
+	total := 0
+	for caseID, count := range r.Kki.GetUserCaseInventory(r, userID) {
+		kase, ok := r.Kki.cases[caseID]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("❓ **%s** (x%d) — нет в базе кейсов", caseID, count))
+			continue
+		}
+		total += kase.Price * count
+		lines = append(lines, fmt.Sprintf("📦 **%s** (x%d) — ID: %s | 💰 %d", kase.Name, count, caseID, kase.Price))
+	}
+	sort.Strings(lines)
+	return lines, total
+}
+
+// adminInventoryPage формирует страницу инвентаря пользователя для админа. kind — "nft" или "cases".
+func (r *Ranking) adminInventoryPage(kind, userID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	title := "🎒 Инвентарь NFT"
+	lines, total := r.adminInventoryLines(userID)
+	if kind == "cases" {
+		title = "📦 Инвентарь кейсов"
+		lines, total = r.adminCaseLines(userID)
+	}
+
+	pages := (len(lines) + adminInventoryPageSize - 1) / adminInventoryPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	page = max(0, min(page, pages-1))
+
+	description := fmt.Sprintf("Игрок: <@%s>\nПозиций: %d | Общая стоимость: 💰 %d\n\n", userID, len(lines), total)
+	if len(lines) == 0 {
+		description += "Пусто."
+	} else {
+		start := page * adminInventoryPageSize
+		end := min(start+adminInventoryPageSize, len(lines))
+		description += strings.Join(lines[start:end], "\n")
+	}
+	if kind == "cases" {
+		key := fmt.Sprintf("case_limit:%s:%s", userID, time.Now().Format("2006-01-02"))
+		opened, _ := r.redis.Get(r.ctx, key).Int()
+		description += fmt.Sprintf("\n\n🔄 Открыто сегодня: %d/%d", opened, dailyCaseOpenLimit)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: truncate(description, 4000),
+		Color:       0x00BFFF,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Страница %d/%d | /a_recall @user — журнал NFT", page+1, pages)},
+	}
+	if pages == 1 {
+		return embed, []discordgo.MessageComponent{}
+	}
+	prefix := fmt.Sprintf("a_inv_page_%s_%s_", kind, userID)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "◀️ Назад", Style: discordgo.SecondaryButton, CustomID: prefix + strconv.Itoa(page-1), Disabled: page == 0},
+				discordgo.Button{Label: "Вперёд ▶️", Style: discordgo.SecondaryButton, CustomID: prefix + strconv.Itoa(page+1), Disabled: page == pages-1},
+			},
+		},
+	}
+	return embed, components
+}
+
+// HandleAdminInventoryCommand обрабатывает команды !a_inventory @user и !a_cases @user.
+func (r *Ranking) HandleAdminInventoryCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_inventory: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть чужие инвентари! 🔒")
+		return
+	}
+	kind := "nft"
+	if strings.HasPrefix(command, "/a_cases") {
+		kind = "cases"
+	}
+	if len(m.Mentions) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_inventory @user` или `/a_cases @user`")
+		return
+	}
+
+	embed, components := r.adminInventoryPage(kind, m.Mentions[0].ID, 0)
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components}); err != nil {
+		log.Printf("Не удалось отправить инвентарь %s: %v", m.Mentions[0].ID, err)
+	}
+}
+
+// HandleAdminInventoryPage листает страницы /a_inventory и /a_cases.
+func (r *Ranking) HandleAdminInventoryPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !r.IsAdmin(i.Member.User.ID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Только админы могут листать чужие инвентари! 🔒", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(i.MessageComponentData().CustomID, "a_inv_page_"), "_")
+	if len(parts) != 3 {
+		return
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+
+	embed, components := r.adminInventoryPage(parts[0], parts[1], page)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
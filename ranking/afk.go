@@ -0,0 +1,147 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Детектор AFK в войсе. Бот не слушает голос, поэтому AFK определяется по голосовому
+// состоянию: сидящие с выключенным микрофоном или звуком дольше порога, а также в AFK-канале
+// сервера получают только часть кредитов за войс. Порог и доля настраиваются для каждого сервера.
+
+// voiceIdle — состояние пользователя, который сейчас не может говорить или слушать.
+type voiceIdle struct {
+	GuildID    string
+	Since      time.Time
+	AFKChannel bool
+}
+
+// afkGuildKey возвращает ключ настроек AFK сервера.
+func afkGuildKey(guildID string) string {
+	return "afk:guild:" + guildID
+}
+
+// afkSetting возвращает настройку AFK сервера или значение по умолчанию из окружения.
+func (r *Ranking) afkSetting(guildID, field string, fallback int) int {
+	value, err := r.redis.HGet(r.ctx, afkGuildKey(guildID), field).Int()
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// AFKMinutes возвращает, через сколько минут с выключенным микрофоном пользователь считается AFK.
+func (r *Ranking) AFKMinutes(guildID string) int {
+	return r.afkSetting(guildID, "minutes", envInt("AFK_MINUTES", 30))
+}
+
+// AFKPercent возвращает долю кредитов за войс (в процентах), которую получает AFK-пользователь.
+func (r *Ranking) AFKPercent(guildID string) int {
+	return r.afkSetting(guildID, "percent", envInt("AFK_PERCENT", 0))
+}
+
+// updateVoiceIdle запоминает, с какого момента пользователь не говорит и не слушает.
+// Вызывается под r.mu.
+func (r *Ranking) updateVoiceIdle(s *discordgo.Session, vs *discordgo.VoiceStateUpdate) {
+	afkChannel := false
+	if guild, err := s.State.Guild(vs.GuildID); err == nil && guild.AfkChannelID != "" {
+		afkChannel = guild.AfkChannelID == vs.ChannelID
+	}
+	// Стрим или камера — тоже активность, даже с выключенным микрофоном
+	streaming := vs.SelfStream || vs.SelfVideo
+	if !afkChannel && (streaming || (!vs.SelfMute && !vs.SelfDeaf && !vs.Mute && !vs.Deaf)) {
+		delete(r.voiceIdle, vs.UserID)
+		return
+	}
+	idle, exists := r.voiceIdle[vs.UserID]
+	if !exists {
+		idle = voiceIdle{GuildID: vs.GuildID, Since: time.Now()}
+	}
+	idle.AFKChannel = afkChannel
+	r.voiceIdle[vs.UserID] = idle
+}
+
+// voiceAFKPercent возвращает долю кредитов за войс для пользователя: 100, если он активен.
+// Вызывается под r.mu.
+func (r *Ranking) voiceAFKPercent(userID string) int {
+	idle, ok := r.voiceIdle[userID]
+	if !ok {
+		return 100
+	}
+	if !idle.AFKChannel && time.Since(idle.Since) < time.Duration(r.AFKMinutes(idle.GuildID))*time.Minute {
+		return 100
+	}
+	return min(max(r.AFKPercent(idle.GuildID), 0), 100)
+}
+
+// scaleVoicePoints уменьшает начисление за minute-ю минуту до percent процентов так,
+// чтобы дробные части накапливались (при 50% кредит начисляется раз в две минуты).
+func scaleVoicePoints(points, percent, minute int) int {
+	if percent >= 100 {
+		return points
+	}
+	return points*percent*minute/100 - points*percent*(minute-1)/100
+}
+
+// afkSettings сопоставляет подкоманды !a_afk с полями настроек сервера.
+var afkSettings = map[string]string{
+	"minutes": "minutes",
+	"percent": "percent",
+}
+
+// HandleAFKSettingsCommand обрабатывает команду !a_afk [minutes|percent <значение>].
+func (r *Ranking) HandleAFKSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_afk: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать AFK! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_afk [minutes|percent <значение>]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		field, ok := afkSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 || (field == "percent" && value > 100) {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.redis.HSet(r.ctx, afkGuildKey(m.GuildID), field, value).Err(); err != nil {
+			log.Printf("Не удалось сохранить настройку AFK %s: %v", field, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ AFK `%s` = %d для этого сервера", field, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	r.mu.Lock()
+	idle := 0
+	for _, state := range r.voiceIdle {
+		if state.GuildID == m.GuildID {
+			idle++
+		}
+	}
+	r.mu.Unlock()
+	embed := &discordgo.MessageEmbed{
+		Title: "💤 AFK в войсе",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "⏳ AFK после", Value: fmt.Sprintf("%d мин. без микрофона/звука", r.AFKMinutes(m.GuildID)), Inline: true},
+			{Name: "💰 Доля кредитов", Value: fmt.Sprintf("%d%%", r.AFKPercent(m.GuildID)), Inline: true},
+			{Name: "🔇 Сейчас без микрофона", Value: strconv.Itoa(idle), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "AFK-канал сервера всегда считается AFK · /a_afk minutes|percent <значение>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
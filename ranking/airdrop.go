@@ -0,0 +1,299 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Аирдропы: время от времени в флуд-канал падает пакет кредитов с кнопкой «Схватить!».
+// Первые N нажавших делят банк поровну. Раздача закрывается, когда набрались все N
+// или истекло окно; незакрытые после перезапуска раздачи закрывает задача airdrop.
+const (
+	airdropNextKey = "airdrop:next" // unix-время следующего аирдропа
+	airdropOpenKey = "airdrop:open" // ZSET dropID -> unix-время закрытия
+	airdropWindow  = 5 * time.Minute
+	airdropKeyTTL  = 24 * time.Hour
+)
+
+// airdropGrabScript атомарно занимает место в раздаче: проверяет, что она не закрыта и игрок
+// ещё не хватал, и дописывает его в claims, пока мест меньше winners. Возвращает место (1..winners),
+// 0 — игрок уже хватал, -1 — раздача закрыта, -2 — места кончились. Раз settleAirdrop ставит
+// settled до чтения claims, каждый получивший место попадает в выплату.
+var airdropGrabScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then return -1 end
+if redis.call('SADD', KEYS[2], ARGV[1]) == 0 then return 0 end
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+if redis.call('LLEN', KEYS[3]) >= tonumber(ARGV[2]) then return -2 end
+local place = redis.call('RPUSH', KEYS[3], ARGV[1])
+redis.call('EXPIRE', KEYS[3], ARGV[3])
+return place
+`)
+
+// airdropKey возвращает ключ раздачи (HASH amount, winners, channel, message) или её части.
+func airdropKey(dropID, part string) string {
+	if part == "" {
+		return "airdrop:drop:" + dropID
+	}
+	return "airdrop:drop:" + dropID + ":" + part
+}
+
+// AirdropIntervalMinutes возвращает среднее время между аирдропами (0 — аирдропы выключены).
+func (r *Ranking) AirdropIntervalMinutes() int {
+	return r.GetIntSetting("airdrop_interval_minutes", envInt("AIRDROP_INTERVAL_MINUTES", 0))
+}
+
+// AirdropAmount возвращает размер банка аирдропа.
+func (r *Ranking) AirdropAmount() int {
+	return r.GetIntSetting("airdrop_amount", envInt("AIRDROP_AMOUNT", 300))
+}
+
+// AirdropWinners возвращает, сколько первых игроков делят банк.
+func (r *Ranking) AirdropWinners() int {
+	return max(r.GetIntSetting("airdrop_winners", envInt("AIRDROP_WINNERS", 3)), 1)
+}
+
+// scheduleNextAirdrop назначает следующий аирдроп через интервал ±50%.
+func (r *Ranking) scheduleNextAirdrop(now time.Time) {
+	interval := time.Duration(r.AirdropIntervalMinutes()) * time.Minute
+	next := now.Add(interval/2 + time.Duration(rand.Int63n(int64(interval)+1)))
+	r.redis.Set(r.ctx, airdropNextKey, next.Unix(), 0)
+}
+
+// runAirdrops закрывает истёкшие раздачи и сбрасывает новый аирдроп, если пришло время.
+func (r *Ranking) runAirdrops() error {
+	now := time.Now()
+	expired, err := r.redis.ZRangeByScore(r.ctx, airdropOpenKey, &redis.ZRangeBy{Min: "-inf", Max: strconv.FormatInt(now.Unix(), 10)}).Result()
+	if err != nil {
+		return err
+	}
+	for _, dropID := range expired {
+		r.settleAirdrop(dropID)
+	}
+
+	if r.AirdropIntervalMinutes() <= 0 || r.floodChannelID == "" || r.MaintenanceMode() {
+		return nil
+	}
+	next, err := r.redis.Get(r.ctx, airdropNextKey).Int64()
+	if err == redis.Nil {
+		r.scheduleNextAirdrop(now)
+		return nil
+	}
+	if err != nil || now.Unix() < next {
+		return err
+	}
+	r.scheduleNextAirdrop(now)
+	return r.dropAirdrop(r.AirdropAmount(), r.AirdropWinners())
+}
+
+// dropAirdrop публикует аирдроп в флуд-канал.
+func (r *Ranking) dropAirdrop(amount, winners int) error {
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	dropID := generateGameID("airdrop")
+	closes := time.Now().Add(airdropWindow)
+	embed := &discordgo.MessageEmbed{
+		Title:       "🪂 Аирдроп от Императора!",
+		Description: fmt.Sprintf("С неба упал пакет на **%s**!\nПервые **%d** успевших поделят его поровну.\n\nЗакрывается <t:%d:R>.", formatCredits(amount), winners, closes.Unix()),
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Кто не успел — тот опоздал! 🇨🇳"},
+	}
+	msg, err := s.ChannelMessageSendComplex(r.floodChannelID, &discordgo.MessageSend{
+		Embed: embed,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Схватить! 🪂", Style: discordgo.SuccessButton, CustomID: "airdrop_grab_" + dropID},
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось отправить аирдроп: %v", err)
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(r.ctx, airdropKey(dropID, ""), "amount", amount, "winners", winners, "channel", msg.ChannelID, "message", msg.ID)
+	pipe.Expire(r.ctx, airdropKey(dropID, ""), airdropKeyTTL)
+	pipe.ZAdd(r.ctx, airdropOpenKey, &redis.Z{Score: float64(closes.Unix()), Member: dropID})
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("не удалось сохранить аирдроп: %v", err)
+	}
+	log.Printf("Аирдроп %s: %d кредитов на %d игроков", dropID, amount, winners)
+	return nil
+}
+
+// HandleAirdropGrab обрабатывает нажатие кнопки «Схватить!».
+func (r *Ranking) HandleAirdropGrab(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	dropID := strings.TrimPrefix(i.MessageComponentData().CustomID, "airdrop_grab_")
+	userID := i.Member.User.ID
+
+	meta, err := r.redis.HGetAll(r.ctx, airdropKey(dropID, "")).Result()
+	if err != nil || len(meta) == 0 {
+		respondEphemeral(s, i, "❌ Этот аирдроп уже закончился!")
+		return
+	}
+	winners, _ := strconv.Atoi(meta["winners"])
+
+	// Проверка закрытия, отметка игрока и запись места выполняются одним скриптом, поэтому мест
+	// ровно winners даже при одновременных нажатиях и ни одно место не теряется при закрытии
+	keys := []string{airdropKey(dropID, "settled"), airdropKey(dropID, "seen"), airdropKey(dropID, "claims")}
+	place, err := airdropGrabScript.Run(r.ctx, r.redis, keys, userID, winners, int(airdropKeyTTL.Seconds())).Int64()
+	switch {
+	case err != nil:
+		log.Printf("Аирдроп %s: не удалось занять место для %s: %v", dropID, userID, err)
+		respondEphemeral(s, i, "❌ Ошибка Redis, попробуй ещё раз!")
+		return
+	case place == -1:
+		respondEphemeral(s, i, "❌ Этот аирдроп уже закончился!")
+		return
+	case place == 0:
+		respondEphemeral(s, i, "ℹ️ Ты уже схватил свою долю!")
+		return
+	case place < 0:
+		respondEphemeral(s, i, "😢 Не успел — пакет уже разобрали!")
+		return
+	}
+	log.Printf("Аирдроп %s: %s занял место %d/%d", dropID, userID, place, winners)
+
+	respondEphemeral(s, i, fmt.Sprintf("🪂 Схватил! Ты %d-й из %d. Кредиты придут, когда раздача закроется.", place, winners))
+	if int(place) == winners {
+		r.settleAirdrop(dropID)
+	}
+}
+
+// settleAirdrop закрывает раздачу и делит банк между успевшими. Остаток от деления не начисляется.
+func (r *Ranking) settleAirdrop(dropID string) {
+	if ok, err := r.redis.SetNX(r.ctx, airdropKey(dropID, "settled"), 1, airdropKeyTTL).Result(); err != nil || !ok {
+		return
+	}
+	r.redis.ZRem(r.ctx, airdropOpenKey, dropID)
+	meta, err := r.redis.HGetAll(r.ctx, airdropKey(dropID, "")).Result()
+	if err != nil || len(meta) == 0 {
+		log.Printf("Аирдроп %s не найден при закрытии: %v", dropID, err)
+		return
+	}
+	amount, _ := strconv.Atoi(meta["amount"])
+	claimers, _ := r.redis.LRange(r.ctx, airdropKey(dropID, "claims"), 0, -1).Result()
+
+	description := fmt.Sprintf("Пакет на **%s** никто не успел схватить. 😢", formatCredits(amount))
+	if len(claimers) > 0 {
+		share := amount / len(claimers)
+		r.payAirdrop(claimers, share)
+		mentions := make([]string, 0, len(claimers))
+		for _, userID := range claimers {
+			mentions = append(mentions, fmt.Sprintf("<@%s>", userID))
+		}
+		description = fmt.Sprintf("Пакет на **%s** разобран!\n%s получили по **%s**. 🎉", formatCredits(amount), strings.Join(mentions, ", "), formatCredits(share))
+		log.Printf("Аирдроп %s закрыт: %d игроков по %d", dropID, len(claimers), share)
+	}
+
+	s, err := r.Session()
+	if err != nil {
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🪂 Аирдроп завершён",
+		Description: description,
+		Color:       0x808080,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Следите за небом! 🇨🇳"},
+	}
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    meta["channel"],
+		ID:         meta["message"],
+		Embed:      embed,
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		log.Printf("Не удалось обновить сообщение аирдропа %s: %v", dropID, err)
+	}
+	if len(claimers) > 0 {
+		r.LogCreditOperation(s, fmt.Sprintf("🪂 Аирдроп %s: %s", formatCredits(amount), description))
+	}
+}
+
+// payAirdrop начисляет долю аирдропа каждому успевшему.
+func (r *Ranking) payAirdrop(claimers []string, share int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, userID := range claimers {
+		r.UpdateRatingFrom(userID, share, "airdrop", "")
+	}
+}
+
+// airdropSettings сопоставляет подкоманды !a_airdrop с настройками.
+var airdropSettings = map[string]string{
+	"every":   "airdrop_interval_minutes",
+	"amount":  "airdrop_amount",
+	"winners": "airdrop_winners",
+}
+
+// HandleAirdropCommand обрабатывает команду !a_airdrop [now | every <минут> | amount <сумма> | winners <N>].
+func (r *Ranking) HandleAirdropCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_airdrop: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать аирдропы! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_airdrop [now | every <минут> | amount <сумма> | winners <N>]` (every 0 — выключить)"
+	parts := strings.Fields(command)
+	switch {
+	case len(parts) == 2 && parts[1] == "now":
+		if r.floodChannelID == "" {
+			s.ChannelMessageSend(m.ChannelID, "❌ Не задан FLOOD_CHANNEL_ID!")
+			return
+		}
+		if err := r.dropAirdrop(r.AirdropAmount(), r.AirdropWinners()); err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "✅ Аирдроп сброшен в флуд-канал!")
+		return
+	case len(parts) == 3:
+		setting, ok := airdropSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 || (parts[1] != "every" && value == 0) {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		if parts[1] == "every" {
+			r.redis.Del(r.ctx, airdropNextKey)
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	case len(parts) != 1:
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	schedule := "выключены"
+	if interval := r.AirdropIntervalMinutes(); interval > 0 {
+		schedule = fmt.Sprintf("в среднем раз в %d мин", interval)
+		if next, err := r.redis.Get(r.ctx, airdropNextKey).Int64(); err == nil {
+			schedule += fmt.Sprintf(", следующий <t:%d:R>", next)
+		}
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "🪂 Аирдропы",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "⏰ Расписание", Value: schedule, Inline: false},
+			{Name: "💰 Банк", Value: formatCredits(r.AirdropAmount()), Inline: true},
+			{Name: "👥 Победителей", Value: strconv.Itoa(r.AirdropWinners()), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_airdrop now · every|amount|winners <значение>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,132 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// subsystemFeatures перечисляет функции бота, затронутые сбоями подсистемы.
+var subsystemFeatures = map[string]string{
+	"redis":     "балансы, игры, инвентари, кейсы — практически всё",
+	"sheets":    "синхронизация NFT и кейсов (/sync_nfts)",
+	"coingecko": "курс BTC и цены NFT (/btc, /prices)",
+	"telegram":  "ретрансляция сообщений Discord ↔ Telegram",
+	"tmdb":      "постеры победителей киноаукциона",
+}
+
+// errorBudget считает ошибки подсистем в скользящем окне и не даёт слать повторные оповещения.
+type errorBudget struct {
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	lastError map[string]string
+	alerted   map[string]time.Time
+}
+
+var budget = &errorBudget{
+	events:    make(map[string][]time.Time),
+	lastError: make(map[string]string),
+	alerted:   make(map[string]time.Time),
+}
+
+// SetSession сохраняет активную сессию Discord для фоновых оповещений.
+func (r *Ranking) SetSession(s *discordgo.Session) {
+	r.mu.Lock()
+	r.session = s
+	r.mu.Unlock()
+}
+
+// Session возвращает сессию Discord для фоновых задач; если бот ещё не подключён,
+// создаётся REST-сессия по DISCORD_TOKEN.
+func (r *Ranking) Session() (*discordgo.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session != nil {
+		return r.session, nil
+	}
+	s, err := discordgo.New("Bot " + os.Getenv("DISCORD_TOKEN"))
+	if err != nil {
+		return nil, err
+	}
+	r.InstallPermissionProbe(s)
+	r.session = s
+	return s, nil
+}
+
+// ReportError учитывает ошибку подсистемы (redis, sheets, coingecko, telegram). При превышении
+// порога ERROR_BUDGET_THRESHOLD за ERROR_BUDGET_WINDOW_MINUTES в канал логов уходит одно сводное оповещение.
+func (r *Ranking) ReportError(subsystem string, err error) {
+	if err == nil {
+		return
+	}
+	CaptureError(err, map[string]string{"subsystem": subsystem})
+	threshold := envInt("ERROR_BUDGET_THRESHOLD", 10)
+	window := time.Duration(envInt("ERROR_BUDGET_WINDOW_MINUTES", 5)) * time.Minute
+	now := time.Now()
+
+	budget.mu.Lock()
+	recent := budget.events[subsystem][:0]
+	for _, t := range budget.events[subsystem] {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	budget.events[subsystem] = recent
+	budget.lastError[subsystem] = err.Error()
+	count := len(recent)
+	alert := count >= threshold && now.Sub(budget.alerted[subsystem]) >= window
+	if alert {
+		budget.alerted[subsystem] = now
+	}
+	budget.mu.Unlock()
+
+	if alert {
+		go r.sendErrorBudgetAlert(subsystem, count, window, err)
+	}
+}
+
+// sendErrorBudgetAlert отправляет сводное оповещение о сбоях подсистемы в канал логов.
+func (r *Ranking) sendErrorBudgetAlert(subsystem string, count int, window time.Duration, err error) {
+	log.Printf("Превышен бюджет ошибок %s: %d ошибок за %s, последняя: %v", subsystem, count, window, err)
+	if r.logChannelID == "" {
+		return
+	}
+	s, sessErr := r.Session()
+	if sessErr != nil {
+		log.Printf("Не удалось создать сессию Discord для оповещения: %v", sessErr)
+		return
+	}
+
+	features := subsystemFeatures[subsystem]
+	if features == "" {
+		features = "неизвестно"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🚨 Сбой подсистемы: %s", strings.ToUpper(subsystem)),
+		Description: fmt.Sprintf("**%d** ошибок за последние %d мин. Следующее оповещение — не раньше чем через %d мин.", count, int(window.Minutes()), int(window.Minutes())),
+		Color:       0xFF0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Последняя ошибка", Value: fmt.Sprintf("```%s```", truncate(err.Error(), 1000))},
+			{Name: "Затронуто", Value: features},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if _, err := s.ChannelMessageSendEmbed(r.logChannelID, embed); err != nil {
+		log.Printf("Не удалось отправить оповещение о сбое %s: %v", subsystem, err)
+	}
+}
+
+// truncate обрезает строку до max символов.
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,207 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// announceTemplatesKey — хэш Redis с сохранёнными шаблонами объявлений (имя -> JSON AnnounceTemplate).
+const announceTemplatesKey = "announce:templates"
+
+// AnnounceTemplate описывает оформление объявления.
+type AnnounceTemplate struct {
+	Name   string `json:"name"`
+	Emoji  string `json:"emoji"`
+	Title  string `json:"title"` // заголовок по умолчанию, если в команде он не указан
+	Color  int    `json:"color"`
+	Footer string `json:"footer"`
+}
+
+// builtinAnnounceTemplates — встроенные шаблоны объявлений.
+var builtinAnnounceTemplates = map[string]AnnounceTemplate{
+	"event":  {Name: "event", Emoji: "🎉", Title: "Событие начинается!", Color: 0xFFD700, Footer: "Славь Императора и участвуй! 👑"},
+	"patch":  {Name: "patch", Emoji: "🛠️", Title: "Обновление бота", Color: 0x00BFFF, Footer: "Список изменений | ChinaBot 🇨🇳"},
+	"winner": {Name: "winner", Emoji: "🏆", Title: "Победитель определён!", Color: 0xFF4500, Footer: "Император гордится победителем! 👑"},
+}
+
+// SetTelegramMirror задаёт функцию, которой объявления дублируются в Telegram.
+func (r *Ranking) SetTelegramMirror(mirror func(text string) error) {
+	r.mu.Lock()
+	r.telegramMirror = mirror
+	r.mu.Unlock()
+}
+
+// SetTelegramPhotoMirror задаёт функцию, которой в Telegram отправляются картинки с подписью.
+func (r *Ranking) SetTelegramPhotoMirror(mirror func(photoURL, caption string) error) {
+	r.mu.Lock()
+	r.telegramPhotoMirror = mirror
+	r.mu.Unlock()
+}
+
+// announceChannels возвращает каналы для объявлений: ANNOUNCE_CHANNEL_IDS или канал флуда.
+func (r *Ranking) announceChannels() []string {
+	var channels []string
+	for _, id := range strings.Split(os.Getenv("ANNOUNCE_CHANNEL_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			channels = append(channels, id)
+		}
+	}
+	if len(channels) == 0 && r.floodChannelID != "" {
+		channels = append(channels, r.floodChannelID)
+	}
+	return channels
+}
+
+// announceTemplate ищет шаблон среди сохранённых, затем среди встроенных.
+func (r *Ranking) announceTemplate(name string) (AnnounceTemplate, bool) {
+	data, err := r.redis.HGet(r.ctx, announceTemplatesKey, name).Result()
+	if err == nil {
+		var tpl AnnounceTemplate
+		if err := json.Unmarshal([]byte(data), &tpl); err == nil {
+			return tpl, true
+		}
+		log.Printf("Не удалось разобрать шаблон объявления %s: %v", name, err)
+	}
+	tpl, ok := builtinAnnounceTemplates[name]
+	return tpl, ok
+}
+
+// announceTemplates возвращает все шаблоны, отсортированные по имени.
+func (r *Ranking) announceTemplates() []AnnounceTemplate {
+	all := make(map[string]AnnounceTemplate, len(builtinAnnounceTemplates))
+	for name, tpl := range builtinAnnounceTemplates {
+		all[name] = tpl
+	}
+	saved, _ := r.redis.HGetAll(r.ctx, announceTemplatesKey).Result()
+	for name, data := range saved {
+		var tpl AnnounceTemplate
+		if err := json.Unmarshal([]byte(data), &tpl); err == nil {
+			all[name] = tpl
+		}
+	}
+	result := make([]AnnounceTemplate, 0, len(all))
+	for _, tpl := range all {
+		result = append(result, tpl)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// HandleAnnounceCommand обрабатывает команду !a_announce <шаблон> [заголовок |] <текст>,
+// а также !a_announce templates | save <имя> <эмодзи> <#цвет> <заголовок> | delete <имя>.
+func (r *Ranking) HandleAnnounceCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_announce: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут делать объявления! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_announce <шаблон> [заголовок |] <текст>`, `/a_announce templates`, `/a_announce save <имя> <эмодзи> <#цвет> <заголовок>` или `/a_announce delete <имя>`"
+	// Текст берём из исходного сообщения, чтобы сохранить регистр
+	original := strings.Fields(m.Content)
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	switch parts[1] {
+	case "templates":
+		var lines []string
+		for _, tpl := range r.announceTemplates() {
+			tag := ""
+			if _, builtin := builtinAnnounceTemplates[tpl.Name]; builtin {
+				tag = " (встроенный)"
+			}
+			lines = append(lines, fmt.Sprintf("%s `%s`%s — %s", tpl.Emoji, tpl.Name, tag, tpl.Title))
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+			Title:       "📣 Шаблоны объявлений",
+			Description: strings.Join(lines, "\n"),
+			Color:       randomColor(),
+			Footer:      &discordgo.MessageEmbedFooter{Text: "/a_announce <шаблон> [заголовок |] <текст>"},
+		})
+		return
+	case "save":
+		if len(parts) < 6 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_announce save <имя> <эмодзи> <#цвет> <заголовок>`")
+			return
+		}
+		color, err := strconv.ParseInt(strings.TrimPrefix(parts[4], "#"), 16, 32)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Цвет должен быть в формате #RRGGBB!")
+			return
+		}
+		tpl := AnnounceTemplate{Name: parts[2], Emoji: original[3], Title: strings.Join(original[5:], " "), Color: int(color), Footer: "Славь Императора! 👑"}
+		data, _ := json.Marshal(tpl)
+		if err := r.redis.HSet(r.ctx, announceTemplatesKey, tpl.Name, data).Err(); err != nil {
+			log.Printf("Не удалось сохранить шаблон объявления %s: %v", tpl.Name, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Шаблон `%s` сохранён.", tpl.Name))
+		return
+	case "delete":
+		if len(parts) != 3 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_announce delete <имя>`")
+			return
+		}
+		if deleted, _ := r.redis.HDel(r.ctx, announceTemplatesKey, parts[2]).Result(); deleted == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Сохранённый шаблон `%s` не найден!", parts[2]))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Шаблон `%s` удалён.", parts[2]))
+		return
+	}
+
+	tpl, ok := r.announceTemplate(parts[1])
+	if !ok || len(original) < 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	title, text := tpl.Title, strings.Join(original[2:], " ")
+	if head, body, found := strings.Cut(text, "|"); found {
+		title, text = strings.TrimSpace(head), strings.TrimSpace(body)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", tpl.Emoji, title),
+		Description: text,
+		Color:       tpl.Color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: tpl.Footer},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	sent := 0
+	for _, channelID := range r.announceChannels() {
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			log.Printf("Не удалось отправить объявление в канал %s: %v", channelID, err)
+			continue
+		}
+		sent++
+	}
+
+	r.mu.Lock()
+	mirror := r.telegramMirror
+	r.mu.Unlock()
+	telegram := "не настроен"
+	if mirror != nil {
+		telegram = "✅"
+		if err := mirror(fmt.Sprintf("%s %s\n\n%s", tpl.Emoji, title, text)); err != nil {
+			log.Printf("Не удалось продублировать объявление в Telegram: %v", err)
+			r.ReportError("telegram", err)
+			telegram = "❌ ошибка"
+		}
+	}
+
+	r.LogCreditOperation(s, fmt.Sprintf("📣 <@%s> опубликовал объявление «%s» (шаблон %s)", m.Author.ID, title, tpl.Name))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📣 Объявление отправлено в каналов: %d. Telegram: %s", sent, telegram))
+}
@@ -0,0 +1,81 @@
+package ranking
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// anonUsersKey — множество игроков, скрывающих имя в таблицах лидеров и витрине крупных выигрышей.
+// Настоящие имена по-прежнему попадают в канал логов, так что админы видят, кто есть кто.
+const anonUsersKey = "anon:users"
+
+// isAnonymous сообщает, включён ли у игрока анонимный режим.
+func (r *Ranking) isAnonymous(userID string) bool {
+	anon, err := r.redis.SIsMember(r.ctx, anonUsersKey, userID).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить анонимный режим %s: %v", userID, err)
+	}
+	return anon
+}
+
+// anonAlias возвращает постоянный псевдоним игрока вида «Аноним #x3a7».
+func anonAlias(userID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return fmt.Sprintf("Аноним #x%03x", h.Sum32()%0x1000)
+}
+
+// publicMention возвращает упоминание игрока для публичных объявлений или его псевдоним.
+func (r *Ranking) publicMention(userID string) string {
+	if r.isAnonymous(userID) {
+		return "🕶️ " + anonAlias(userID)
+	}
+	return fmt.Sprintf("<@%s>", userID)
+}
+
+// publicName возвращает имя игрока для страниц и оверлея или его псевдоним.
+func (r *Ranking) publicName(userID string) string {
+	if userID != "" && r.isAnonymous(userID) {
+		return anonAlias(userID)
+	}
+	return r.displayName(userID)
+}
+
+// HandleAnonCommand обрабатывает команду !anon [on|off].
+func (r *Ranking) HandleAnonCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !anon: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	enable := !r.isAnonymous(m.Author.ID)
+	if len(parts) == 2 && (parts[1] == "on" || parts[1] == "off") {
+		enable = parts[1] == "on"
+	} else if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/anon [on|off]`")
+		return
+	}
+
+	var err error
+	if enable {
+		err = r.redis.SAdd(r.ctx, anonUsersKey, m.Author.ID).Err()
+	} else {
+		err = r.redis.SRem(r.ctx, anonUsersKey, m.Author.ID).Err()
+	}
+	if err != nil {
+		log.Printf("Не удалось переключить анонимный режим %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Попробуйте позже.")
+		return
+	}
+
+	alias := anonAlias(m.Author.ID)
+	if enable {
+		r.LogCreditOperation(s, fmt.Sprintf("🕶️ <@%s> включил анонимный режим и выступает как **%s**", m.Author.ID, alias))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🕶️ Анонимный режим включён: в топах и витрине ты — **%s**.", alias))
+		return
+	}
+	r.LogCreditOperation(s, fmt.Sprintf("🕶️ <@%s> выключил анонимный режим (был **%s**)", m.Author.ID, alias))
+	s.ChannelMessageSend(m.ChannelID, "👤 Анонимный режим выключен: в топах снова твоё имя.")
+}
@@ -0,0 +1,261 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Банк хранит вклады отдельно от баланса: на вклад раз в сутки начисляются проценты,
+// но тратить вложенные кредиты в играх нельзя, пока их не снимут.
+const (
+	bankDepositsKey = "bank:deposits"      // ZSET userID -> сумма вклада
+	bankInterestKey = "bank:interest_paid" // всего выплачено процентов
+)
+
+// bankInterestDayKey возвращает ключ множества вкладчиков, получивших проценты за день.
+// Повторный запуск задачи в тот же день (ретрай планировщика, перезапуск бота) их не удвоит.
+func bankInterestDayKey(day time.Time) string {
+	return "bank:interest:" + day.Format("2006-01-02")
+}
+
+// BankInterestBP возвращает дневную ставку в базисных пунктах (100 = 1% в день).
+func (r *Ranking) BankInterestBP() int {
+	return r.GetIntSetting("bank_interest_bp", envInt("BANK_INTEREST_BP", 50))
+}
+
+// BankMaxDeposit возвращает максимальный вклад одного игрока (0 — без ограничения).
+func (r *Ranking) BankMaxDeposit() int {
+	return r.GetIntSetting("bank_max_deposit", envInt("BANK_MAX_DEPOSIT", 100000))
+}
+
+// bankDeposit возвращает сумму вклада пользователя.
+func (r *Ranking) bankDeposit(userID string) int {
+	score, err := r.redis.ZScore(r.ctx, bankDepositsKey, userID).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось получить вклад %s: %v", userID, err)
+		}
+		return 0
+	}
+	return int(score)
+}
+
+// parseBankAmount разбирает сумму для !deposit/!withdraw; "all" означает всё доступное.
+func parseBankAmount(command string, available int) (int, bool) {
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		return 0, false
+	}
+	if parts[1] == "all" {
+		return available, available > 0
+	}
+	amount, err := strconv.Atoi(parts[1])
+	return amount, err == nil && amount > 0
+}
+
+// HandleDepositCommand обрабатывает команду !deposit <сумма|all>.
+func (r *Ranking) HandleDepositCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !deposit: %s от %s", command, m.Author.ID)
+
+	r.mu.Lock()
+	balance := r.GetRating(m.Author.ID)
+	deposit := r.bankDeposit(m.Author.ID)
+	amount, ok := parseBankAmount(command, balance)
+	if !ok {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/deposit <сумма|all>`")
+		return
+	}
+	if balance < amount {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(balance)))
+		return
+	}
+	if maxDeposit := r.BankMaxDeposit(); maxDeposit > 0 && deposit+amount > maxDeposit {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Максимальный вклад — %s. Сейчас на вкладе: %s", formatCredits(maxDeposit), formatCredits(deposit)))
+		return
+	}
+	if err := r.redis.ZIncrBy(r.ctx, bankDepositsKey, float64(amount), m.Author.ID).Err(); err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось пополнить вклад %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Банк временно закрыт, попробуй позже!")
+		return
+	}
+	r.UpdateRatingFrom(m.Author.ID, -amount, "bank", "")
+	r.mu.Unlock()
+
+	r.LogCreditOperation(s, fmt.Sprintf("🏦 <@%s> положил в банк %s", m.Author.ID, formatCredits(amount)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏦 <@%s> положил в банк %s. На вкладе: %s 📈", m.Author.ID, formatCredits(amount), formatCredits(deposit+amount)))
+}
+
+// HandleWithdrawCommand обрабатывает команду !withdraw <сумма|all>.
+func (r *Ranking) HandleWithdrawCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !withdraw: %s от %s", command, m.Author.ID)
+
+	r.mu.Lock()
+	deposit := r.bankDeposit(m.Author.ID)
+	amount, ok := parseBankAmount(command, deposit)
+	if !ok {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/withdraw <сумма|all>`")
+		return
+	}
+	if deposit < amount {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ На вкладе только %s!", formatCredits(deposit)))
+		return
+	}
+	if err := r.redis.ZIncrBy(r.ctx, bankDepositsKey, float64(-amount), m.Author.ID).Err(); err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось снять со вклада %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Банк временно закрыт, попробуй позже!")
+		return
+	}
+	if deposit == amount {
+		r.redis.ZRem(r.ctx, bankDepositsKey, m.Author.ID)
+	}
+	r.UpdateRatingFrom(m.Author.ID, amount, "bank", "")
+	r.mu.Unlock()
+
+	r.LogCreditOperation(s, fmt.Sprintf("🏦 <@%s> снял из банка %s", m.Author.ID, formatCredits(amount)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏦 <@%s> снял %s. На вкладе осталось: %s", m.Author.ID, formatCredits(amount), formatCredits(deposit-amount)))
+}
+
+// HandleBankCommand обрабатывает команду !bank.
+func (r *Ranking) HandleBankCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !bank от %s", m.Author.ID)
+
+	deposit := r.bankDeposit(m.Author.ID)
+	bp := r.BankInterestBP()
+	maxDeposit := "без ограничений"
+	if limit := r.BankMaxDeposit(); limit > 0 {
+		maxDeposit = formatCredits(limit)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🏦 Народный банк Императора",
+		Description: fmt.Sprintf("<@%s>, вклад приносит проценты каждый день. Тратить вложенные кредиты нельзя — сначала сними их.", m.Author.ID),
+		Color:       randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💼 На вкладе", Value: formatCredits(deposit), Inline: true},
+			{Name: "💰 На руках", Value: formatCredits(r.GetRating(m.Author.ID)), Inline: true},
+			{Name: "📈 Ставка", Value: fmt.Sprintf("%d.%02d%% в день", bp/100, bp%100), Inline: true},
+			{Name: "🪙 Завтра начислим", Value: formatCredits(deposit * bp / 10000), Inline: true},
+			{Name: "🔝 Максимальный вклад", Value: maxDeposit, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/deposit <сумма|all> · /withdraw <сумма|all>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// applyBankInterest начисляет дневные проценты на все вклады.
+func (r *Ranking) applyBankInterest() error {
+	bp := r.BankInterestBP()
+	if bp <= 0 {
+		return nil
+	}
+	deposits, err := r.redis.ZRangeWithScores(r.ctx, bankDepositsKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	maxDeposit := r.BankMaxDeposit()
+	dayKey := bankInterestDayKey(time.Now())
+	total, skipped := 0, 0
+	for _, entry := range deposits {
+		userID, _ := entry.Member.(string)
+		interest := int(entry.Score) * bp / 10000
+		if maxDeposit > 0 {
+			interest = min(interest, maxDeposit-int(entry.Score))
+		}
+		if interest <= 0 {
+			continue
+		}
+		added, err := r.redis.SAdd(r.ctx, dayKey, userID).Result()
+		if err != nil {
+			return fmt.Errorf("не удалось отметить начисление процентов %s: %v", userID, err)
+		}
+		if added == 0 {
+			skipped++
+			continue
+		}
+		r.redis.Expire(r.ctx, dayKey, 48*time.Hour)
+		if err := r.redis.ZIncrBy(r.ctx, bankDepositsKey, float64(interest), userID).Err(); err != nil {
+			log.Printf("Не удалось начислить проценты %s: %v", userID, err)
+			r.redis.SRem(r.ctx, dayKey, userID)
+			continue
+		}
+		total += interest
+	}
+	if skipped > 0 {
+		log.Printf("Проценты по %d вкладам сегодня уже начислены, пропускаем", skipped)
+	}
+	if total > 0 {
+		r.redis.IncrBy(r.ctx, bankInterestKey, int64(total))
+	}
+	log.Printf("Начислены проценты по вкладам: %d кредитов на %d вкладов (%d б.п.)", total, len(deposits), bp)
+	return nil
+}
+
+// bankSettings сопоставляет подкоманды !a_bank с настройками.
+var bankSettings = map[string]string{
+	"rate": "bank_interest_bp",
+	"max":  "bank_max_deposit",
+}
+
+// HandleBankSettingsCommand обрабатывает команду !a_bank [rate <б.п.>|max <сумма>].
+func (r *Ranking) HandleBankSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_bank: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать банк! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_bank [rate <б.п. в день>|max <сумма>]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		setting, ok := bankSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	deposits, _ := r.redis.ZRangeWithScores(r.ctx, bankDepositsKey, 0, -1).Result()
+	total := 0
+	for _, entry := range deposits {
+		total += int(entry.Score)
+	}
+	paid, _ := r.redis.Get(r.ctx, bankInterestKey).Int()
+	bp := r.BankInterestBP()
+	embed := &discordgo.MessageEmbed{
+		Title: "🏦 Банк: настройки",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📈 Ставка", Value: fmt.Sprintf("%d б.п. (%d.%02d%% в день)", bp, bp/100, bp%100), Inline: true},
+			{Name: "🔝 Максимальный вклад", Value: formatCredits(r.BankMaxDeposit()), Inline: true},
+			{Name: "💼 Вкладов", Value: fmt.Sprintf("%d на %s", len(deposits), formatCredits(total)), Inline: true},
+			{Name: "🪙 Выплачено процентов", Value: formatCredits(paid), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_bank rate|max <значение> · проценты начисляются в 05:00"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,146 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// betLimitGames перечисляет игры со столовыми лимитами ставок и их названия.
+var betLimitGames = map[string]string{
+	"bj":   "Блэкджек",
+	"rb":   "Красный-Чёрный",
+	"duel": "Дуэль",
+	"dep":  "Ставки на опросы",
+}
+
+// betLimitsUserKey возвращает хэш Redis с персональными лимитами ставок пользователя (поля min и max).
+func betLimitsUserKey(userID string) string {
+	return "bet_limits:" + userID
+}
+
+// BetLimits возвращает минимальную и максимальную ставку игры для пользователя (max 0 — без ограничения).
+// Глобальные лимиты: настройки <game>_min_bet/<game>_max_bet или переменные окружения <GAME>_MIN_BET/<GAME>_MAX_BET.
+// Персональные лимиты пользователя, заданные админом, имеют приоритет.
+func (r *Ranking) BetLimits(game, userID string) (int, int) {
+	upper := strings.ToUpper(game)
+	minBet := r.GetIntSetting(game+"_min_bet", envInt(upper+"_MIN_BET", 1))
+	maxBet := r.GetIntSetting(game+"_max_bet", envInt(upper+"_MAX_BET", 0))
+
+	override, err := r.redis.HGetAll(r.ctx, betLimitsUserKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось получить лимиты ставок %s: %v", userID, err)
+		return minBet, maxBet
+	}
+	if value, err := strconv.Atoi(override["min"]); err == nil {
+		minBet = value
+	}
+	if value, err := strconv.Atoi(override["max"]); err == nil {
+		maxBet = value
+	}
+	return minBet, maxBet
+}
+
+// checkBetLimits проверяет ставку по лимитам игры и возвращает текст отказа или пустую строку.
+func (r *Ranking) checkBetLimits(game, userID string, amount int) string {
+	if msg := r.loanDefaultMessage(userID); msg != "" {
+		return msg
+	}
+	minBet, maxBet := r.BetLimits(game, userID)
+	if amount < minBet {
+		return fmt.Sprintf("❌ Минимальная ставка в игре «%s»: %s!", betLimitGames[game], formatCredits(minBet))
+	}
+	if maxBet > 0 && amount > maxBet {
+		return fmt.Sprintf("❌ Максимальная ставка в игре «%s»: %s! 🐋", betLimitGames[game], formatCredits(maxBet))
+	}
+	return ""
+}
+
+// formatBetLimit форматирует пару лимитов для вывода.
+func formatBetLimit(minBet, maxBet int) string {
+	if maxBet <= 0 {
+		return fmt.Sprintf("%d – ∞", minBet)
+	}
+	return fmt.Sprintf("%d – %d", minBet, maxBet)
+}
+
+// HandleBetLimitsCommand обрабатывает команду !a_bet_limits [<игра>|@id <min> <max> | @id reset].
+func (r *Ranking) HandleBetLimitsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_bet_limits: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут менять лимиты ставок! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_bet_limits [<bj|rb|duel|dep> <min> <max> | @id <min> <max> | @id reset]` (max 0 — без ограничения)"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		var lines []string
+		for _, game := range []string{"bj", "rb", "duel", "dep"} {
+			minBet := r.GetIntSetting(game+"_min_bet", envInt(strings.ToUpper(game)+"_MIN_BET", 1))
+			maxBet := r.GetIntSetting(game+"_max_bet", envInt(strings.ToUpper(game)+"_MAX_BET", 0))
+			lines = append(lines, fmt.Sprintf("**%s** (`%s`): %s", betLimitGames[game], game, formatBetLimit(minBet, maxBet)))
+		}
+		s.ChannelMessageSend(m.ChannelID, "🎚️ Лимиты ставок:\n"+strings.Join(lines, "\n"))
+		return
+	}
+
+	target := parts[1]
+	if strings.HasPrefix(target, "<@") {
+		userID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(target, "<@"), "!"), ">")
+		if len(parts) == 3 && parts[2] == "reset" {
+			if err := r.redis.Del(r.ctx, betLimitsUserKey(userID)).Err(); err != nil {
+				log.Printf("Не удалось сбросить лимиты ставок %s: %v", userID, err)
+				s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения лимитов! Проверьте Redis-сервер.")
+				return
+			}
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Персональные лимиты ставок <@%s> сброшены.", userID))
+			return
+		}
+		if len(parts) != 4 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		minBet, errMin := strconv.Atoi(parts[2])
+		maxBet, errMax := strconv.Atoi(parts[3])
+		if errMin != nil || errMax != nil || minBet < 1 || maxBet < 0 || (maxBet > 0 && maxBet < minBet) {
+			s.ChannelMessageSend(m.ChannelID, "❌ Лимиты должны быть числами: min ≥ 1, max ≥ min или 0!")
+			return
+		}
+		if err := r.redis.HSet(r.ctx, betLimitsUserKey(userID), "min", minBet, "max", maxBet).Err(); err != nil {
+			log.Printf("Не удалось сохранить лимиты ставок %s: %v", userID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения лимитов! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Персональные лимиты ставок <@%s> во всех играх: %s", userID, formatBetLimit(minBet, maxBet)))
+		return
+	}
+
+	name, ok := betLimitGames[target]
+	if !ok || len(parts) != 4 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	minBet, errMin := strconv.Atoi(parts[2])
+	maxBet, errMax := strconv.Atoi(parts[3])
+	if errMin != nil || errMax != nil || minBet < 1 || maxBet < 0 || (maxBet > 0 && maxBet < minBet) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Лимиты должны быть числами: min ≥ 1, max ≥ min или 0!")
+		return
+	}
+	if err := r.SetIntSetting(target+"_min_bet", minBet); err != nil {
+		log.Printf("Не удалось сохранить лимиты ставок %s: %v", target, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения лимитов! Проверьте Redis-сервер.")
+		return
+	}
+	if err := r.SetIntSetting(target+"_max_bet", maxBet); err != nil {
+		log.Printf("Не удалось сохранить лимиты ставок %s: %v", target, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения лимитов! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Лимиты ставок в игре **%s**: %s", name, formatBetLimit(minBet, maxBet)))
+}
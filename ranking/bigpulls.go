@@ -0,0 +1,77 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// bigPullTitles — заголовки витрины крупных выпадений: чем реже редкость, тем громче.
+var bigPullTitles = map[string]string{
+	"Epic":      "🔥 Эпический дроп!",
+	"Nephrite":  "💚 Нефритовый дроп!",
+	"Exotic":    "🌈 Экзотический дроп!!",
+	"Legendary": "👑 ЛЕГЕНДАРНЫЙ ДРОП!!!",
+}
+
+// rarityRank возвращает порядковый номер редкости (чем больше, тем реже); -1 для неизвестной.
+func rarityRank(rarity string) int {
+	for i, p := range RarityProbabilities {
+		if p.Rarity == rarity {
+			return i
+		}
+	}
+	return -1
+}
+
+// bigPullMinRarity возвращает редкость, начиная с которой выпадение попадает в витрину.
+func bigPullMinRarity() string {
+	if rarity := os.Getenv("BIG_PULLS_MIN_RARITY"); rarityRank(rarity) >= 0 {
+		return rarity
+	}
+	return "Epic"
+}
+
+// showcaseBigPulls публикует выпавшие из кейса NFT редкости Epic и выше в канал BIG_PULLS_CHANNEL_ID.
+func (r *Ranking) showcaseBigPulls(s *discordgo.Session, userID string, kase Case, dropped []NFT) {
+	channelID := os.Getenv("BIG_PULLS_CHANNEL_ID")
+	if channelID == "" {
+		return
+	}
+	minRank := rarityRank(bigPullMinRarity())
+	for _, nft := range dropped {
+		if rarityRank(nft.Rarity) < minRank {
+			continue
+		}
+		title, ok := bigPullTitles[nft.Rarity]
+		if !ok {
+			title = "✨ Крупный дроп!"
+		}
+		price := nft.Price
+		if current, ok := r.Kki.nfts[nft.ID]; ok {
+			price = current.Price
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       title,
+			Description: fmt.Sprintf("%s выбил %s **%s** из кейса 📦 **%s**!", r.publicMention(userID), RarityEmojis[nft.Rarity], nft.Name, kase.Name),
+			Color:       RarityColors[nft.Rarity],
+			Image:       &discordgo.MessageEmbedImage{URL: r.NFTImageURL(nft)},
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Редкость", Value: nft.Rarity, Inline: true},
+				{Name: "Коллекция", Value: nft.Collection, Inline: true},
+				{Name: "Текущая цена", Value: formatCredits(price), Inline: true},
+			},
+			Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("ID: %s | Славь Императора! 👑", nft.ID)},
+		}
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			log.Printf("Не удалось опубликовать крупный дроп %s в канал %s: %v", nft.ID, channelID, err)
+			continue
+		}
+		go r.notifyWishlist(s, nft.ID, fmt.Sprintf("выпала из кейса 📦 **%s** у %s", kase.Name, r.publicMention(userID)), channelID, userID)
+		if r.isAnonymous(userID) {
+			r.LogCreditOperation(s, fmt.Sprintf("🕶️ Крупный дроп %s **%s** у **%s** — это <@%s>", RarityEmojis[nft.Rarity], nft.Name, anonAlias(userID), userID))
+		}
+	}
+}
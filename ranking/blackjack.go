@@ -22,6 +22,7 @@ type BlackjackGame struct {
 	GameID        string
 	PlayerID      string
 	Bet           int
+	HoldID        string // удержание основной ставки (см. Hold)
 	PlayerCards   []Card
 	DealerCards   []Card
 	Active        bool
@@ -29,13 +30,15 @@ type BlackjackGame struct {
 	MenuMessageID string
 	Color         int
 	ChannelID     string
+	SideBets      []SideBet
+	SideBetResult string
 }
 
 // StartBlackjackGame начинает новую игру в блэкджек.
 func (r *Ranking) StartBlackjackGame(s *discordgo.Session, m *discordgo.MessageCreate) {
 	r.mu.Lock()
 	gameID := generateGameID(m.Author.ID)
-	color := randomColor()
+	color := r.themeColor(m.Author.ID)
 	game := &BlackjackGame{
 		GameID:       gameID,
 		PlayerID:     m.Author.ID,
@@ -48,8 +51,8 @@ func (r *Ranking) StartBlackjackGame(s *discordgo.Session, m *discordgo.MessageC
 	r.mu.Unlock()
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Казино: Блэкджек 🎰",
-		Description: fmt.Sprintf("Добро пожаловать, <@%s>! 🎉\nСделай ставку, чтобы начать игру.\n\n**💰 Твой баланс:** %d кредитов\n\nНапиши: `/blackjack <сумма>`", m.Author.ID, r.GetRating(m.Author.ID)),
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Казино: Блэкджек 🎰"),
+		Description: fmt.Sprintf("Добро пожаловать, <@%s>! 🎉\nСделай ставку, чтобы начать игру.\n\n**💰 Твой баланс:** %s\n\nНапиши: `/blackjack <сумма>`", m.Author.ID, formatCredits(r.GetRating(m.Author.ID))),
 		Color:       color,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Играй с умом! 🍀",
@@ -71,8 +74,8 @@ func (r *Ranking) StartBlackjackGame(s *discordgo.Session, m *discordgo.MessageC
 // HandleBlackjackBet обрабатывает ставку в блэкджеке.
 func (r *Ranking) HandleBlackjackBet(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
 	parts := strings.Fields(command)
-	if len(parts) != 2 {
-		r.sendTemporaryReply(s, m, "❌ Используй: `/blackjack <сумма>`\nПример: `/blackjack 50`")
+	if len(parts) < 2 {
+		r.sendTemporaryReply(s, m, "❌ Используй: `/blackjack <сумма> [pp:<сумма>] [21+3:<сумма>]`\nПример: `/blackjack 50 pp:10`")
 		return
 	}
 
@@ -82,9 +85,20 @@ func (r *Ranking) HandleBlackjackBet(s *discordgo.Session, m *discordgo.MessageC
 		return
 	}
 
+	sideBets, err := parseSideBets(parts[2:])
+	if err != nil {
+		r.sendTemporaryReply(s, m, fmt.Sprintf("❌ %s!\nПример: `/blackjack 50 pp:10 21+3:10`", err))
+		return
+	}
+	total := amount + sideBetsTotal(sideBets)
+	if msg := r.checkBetLimits("bj", m.Author.ID, amount); msg != "" {
+		r.sendTemporaryReply(s, m, msg)
+		return
+	}
+
 	userRating := r.GetRating(m.Author.ID)
-	if userRating < amount {
-		r.sendTemporaryReply(s, m, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %d", userRating))
+	if userRating < total {
+		r.sendTemporaryReply(s, m, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(userRating)))
 		return
 	}
 
@@ -101,13 +115,47 @@ func (r *Ranking) HandleBlackjackBet(s *discordgo.Session, m *discordgo.MessageC
 		r.mu.Unlock()
 		return
 	}
+	if remaining, err := r.reserveDailyWager(m.Author.ID, total); err != nil {
+		r.mu.Unlock()
+		r.sendTemporaryReply(s, m, r.wagerCapMessage(remaining, err))
+		return
+	}
+	holdID, err := r.placeBlackjackBet(m.Author.ID, amount, sideBets)
+	if err != nil {
+		r.mu.Unlock()
+		r.releaseDailyWager(m.Author.ID, total, time.Now())
+		r.sendTemporaryReply(s, m, r.holdErrorMessage(m.Author.ID, err))
+		return
+	}
 
 	game.Bet = amount
+	game.HoldID = holdID
+	game.SideBets = sideBets
 	game.LastActivity = time.Now()
 	r.mu.Unlock()
 
-	r.UpdateRating(m.Author.ID, -amount)
+	r.dealBlackjack(s, game)
+}
 
+// placeBlackjackBet замораживает основную ставку до конца раздачи и сразу списывает побочные ставки,
+// которые рассчитываются по первой раздаче. Вызывается под r.mu.
+func (r *Ranking) placeBlackjackBet(playerID string, amount int, sideBets []SideBet) (string, error) {
+	side := sideBetsTotal(sideBets)
+	if r.GetRating(playerID) < amount+side {
+		return "", errEscrowNoCredits
+	}
+	holdID, err := r.holdLocked(playerID, amount, "blackjack", betHoldTTL)
+	if err != nil {
+		return "", err
+	}
+	if side > 0 {
+		r.UpdateRatingFrom(playerID, -side, "blackjack", "")
+	}
+	return holdID, nil
+}
+
+// dealBlackjack раздаёт карты по сделанной ставке, рассчитывает побочные ставки и показывает кнопки хода.
+func (r *Ranking) dealBlackjack(s *discordgo.Session, game *BlackjackGame) {
 	suits := []string{"♠️", "♥️", "♦️", "♣️"}
 	values := []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
 	deck := make([]Card, 0, 52)
@@ -127,25 +175,43 @@ func (r *Ranking) HandleBlackjackBet(s *discordgo.Session, m *discordgo.MessageC
 	game.LastActivity = time.Now()
 	r.mu.Unlock()
 
+	// Побочные ставки рассчитываются по первой раздаче независимо от исхода основной игры
+	game.SideBetResult = r.settleSideBets(s, game)
+
+	// Натуральный блэкджек у игрока или дилера завершает раздачу сразу.
+	// Блэкджек у дилера возможен только при тузе или десятке в открытой карте — тогда дилер проверяет скрытую карту.
+	if isNaturalBlackjack(playerCards) || isNaturalBlackjack(dealerCards) {
+		r.finishNaturalBlackjack(s, game.ChannelID, game)
+		return
+	}
+
+	footer := "Сделай ход! 🍀"
+	if dealerPeeks(dealerCards[0]) {
+		footer = "Дилер проверил скрытую карту — блэкджека нет. Сделай ход! 🍀"
+	}
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Блэкджек 🎲",
-		Description: fmt.Sprintf("<@%s> начал игру со ставкой %d кредитов! 💸\n\n**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s [Скрытая карта]", m.Author.ID, amount, r.cardsToString(playerCards), r.calculateHand(playerCards), r.cardToString(dealerCards[0])),
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+		Description: fmt.Sprintf("<@%s> начал игру со ставкой %s! 💸\n\n**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s [Скрытая карта]", game.PlayerID, formatCredits(game.Bet), r.cardsToString(playerCards), r.calculateHand(playerCards), r.cardToString(dealerCards[0])),
 		Color:       game.Color,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Сделай ход! 🍀",
+			Text: footer,
 		},
 	}
+	if game.SideBetResult != "" {
+		embed.Description += "\n\n" + game.SideBetResult
+	}
 	components := []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.Button{Label: "Взять карту 🃏", Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("blackjack_hit_%s", game.GameID)},
 				discordgo.Button{Label: "Остановиться ⏹️", Style: discordgo.SecondaryButton, CustomID: fmt.Sprintf("blackjack_stand_%s", game.GameID)},
+				discordgo.Button{Label: "Сдаться 🏳️", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("blackjack_surrender_%s", game.GameID)},
 			},
 		},
 	}
 
-	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel:    m.ChannelID,
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    game.ChannelID,
 		ID:         game.MenuMessageID,
 		Embed:      embed,
 		Components: &components,
@@ -153,6 +219,7 @@ func (r *Ranking) HandleBlackjackBet(s *discordgo.Session, m *discordgo.MessageC
 	if err != nil {
 		log.Printf("Не удалось обновить сообщение игры в блэкджек: %v", err)
 	}
+	r.publishBlackjack(game, "playing", "")
 }
 
 // HandleBlackjackHit обрабатывает действие "взять карту".
@@ -168,25 +235,50 @@ func (r *Ranking) HandleBlackjackHit(s *discordgo.Session, i *discordgo.Interact
 	}
 	gameID := strings.Join(parts[2:], "_")
 
-	r.mu.Lock()
-	game, exists := r.blackjackGames[gameID]
-	if !exists {
-		log.Printf("Игра не найдена для GameID: %s", gameID)
+	game, embed, components, err := r.blackjackHit(gameID)
+	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Игра не найдена!", Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: "❌ " + err.Error(), Flags: discordgo.MessageFlagsEphemeral},
 		})
-		r.mu.Unlock()
 		return
 	}
+
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         game.MenuMessageID,
+		Embed:      embed,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+}
+
+// activeBlackjackGame возвращает активную игру. Вызывается под r.mu.
+func (r *Ranking) activeBlackjackGame(gameID string) (*BlackjackGame, error) {
+	game, exists := r.blackjackGames[gameID]
+	if !exists {
+		log.Printf("Игра не найдена для GameID: %s", gameID)
+		return nil, fmt.Errorf("Игра не найдена!")
+	}
 	if !game.Active {
 		log.Printf("Игра неактивна для GameID: %s, PlayerID: %s", gameID, game.PlayerID)
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Игра завершена!", Flags: discordgo.MessageFlagsEphemeral},
-		})
-		r.mu.Unlock()
-		return
+		return nil, fmt.Errorf("Игра завершена!")
+	}
+	return game, nil
+}
+
+// blackjackHit сдаёт игроку карту и возвращает новое состояние стола.
+func (r *Ranking) blackjackHit(gameID string) (*BlackjackGame, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	game, err := r.activeBlackjackGame(gameID)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	deck := r.generateDeck()
@@ -196,7 +288,7 @@ func (r *Ranking) HandleBlackjackHit(s *discordgo.Session, i *discordgo.Interact
 	playerSum := r.calculateHand(game.PlayerCards)
 
 	embed := &discordgo.MessageEmbed{
-		Title: "♠️ Блэкджек 🎲",
+		Title: r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
 		Color: game.Color,
 	}
 	var components []discordgo.MessageComponent
@@ -204,23 +296,16 @@ func (r *Ranking) HandleBlackjackHit(s *discordgo.Session, i *discordgo.Interact
 		game.Active = false
 		embed.Description = fmt.Sprintf("Ты взял карту: %s\n**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s [Скрытая]\n\n❌ Перебор! Ты проиграл! 💥", r.cardToString(newCard), r.cardsToString(game.PlayerCards), playerSum, r.cardToString(game.DealerCards[0]))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Не повезло! 😢"}
-		components = []discordgo.MessageComponent{
-			discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					discordgo.Button{
-						Label:    "Сыграть снова 🎮",
-						Style:    discordgo.PrimaryButton,
-						CustomID: fmt.Sprintf("blackjack_replay_%s_%s", game.PlayerID, game.MenuMessageID),
-					},
-				},
-			},
-		}
+		components = blackjackEndComponents(game)
+		r.captureHold(game.HoldID)
 		// Обновляем статистику Blackjack (проигрыш)
 		r.UpdateBJStats(game.PlayerID, false)
+		r.publishBlackjack(game, "lost", "Перебор")
 		delete(r.blackjackGames, gameID)
 	} else {
 		embed.Description = fmt.Sprintf("Ты взял карту: %s\n**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s [Скрытая]", r.cardToString(newCard), r.cardsToString(game.PlayerCards), playerSum, r.cardToString(game.DealerCards[0]))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Продолжаем! 🍀"}
+		r.publishBlackjack(game, "playing", "")
 		components = []discordgo.MessageComponent{
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
@@ -230,19 +315,7 @@ func (r *Ranking) HandleBlackjackHit(s *discordgo.Session, i *discordgo.Interact
 			},
 		}
 	}
-	r.mu.Unlock()
-
-	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel:    i.ChannelID,
-		ID:         game.MenuMessageID,
-		Embed:      embed,
-		Components: &components,
-	})
-	if err != nil {
-		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
-	}
-
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+	return game, embed, components, nil
 }
 
 // HandleBlackjackStand обрабатывает действие "остановиться".
@@ -258,25 +331,39 @@ func (r *Ranking) HandleBlackjackStand(s *discordgo.Session, i *discordgo.Intera
 	}
 	gameID := strings.Join(parts[2:], "_")
 
-	r.mu.Lock()
-	game, exists := r.blackjackGames[gameID]
-	if !exists {
-		log.Printf("Игра не найдена для GameID: %s", gameID)
+	game, embed, components, err := r.blackjackStand(gameID)
+	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Игра не найдена!", Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: "❌ " + err.Error(), Flags: discordgo.MessageFlagsEphemeral},
 		})
-		r.mu.Unlock()
 		return
 	}
-	if !game.Active {
-		log.Printf("Игра неактивна для GameID: %s, PlayerID: %s", gameID, game.PlayerID)
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Игра завершена!", Flags: discordgo.MessageFlagsEphemeral},
-		})
-		r.mu.Unlock()
-		return
+
+	// Отвечаем сразу: анимация открытия карт дольше окна ответа на взаимодействие
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+
+	r.animateDealerReveal(s, i.ChannelID, game)
+
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         game.MenuMessageID,
+		Embed:      embed,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
+	}
+}
+
+// blackjackStand доигрывает руку дилера, рассчитывает игру и возвращает итоговый стол.
+func (r *Ranking) blackjackStand(gameID string) (*BlackjackGame, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	game, err := r.activeBlackjackGame(gameID)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	game.LastActivity = time.Now()
@@ -292,27 +379,30 @@ func (r *Ranking) HandleBlackjackStand(s *discordgo.Session, i *discordgo.Intera
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Блэкджек 🎲",
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
 		Description: fmt.Sprintf("**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s (Сумма: %d)", r.cardsToString(game.PlayerCards), playerSum, r.cardsToString(game.DealerCards), dealerSum),
 		Color:       game.Color,
 	}
 
+	r.captureHold(game.HoldID)
 	var result string
 	won := false
 	if dealerSum > 21 {
-		winnings := game.Bet * 2
-		r.UpdateRating(game.PlayerID, winnings)
-		result = fmt.Sprintf("✅ Дилер перебрал! Ты выиграл %d кредитов! 🎉", winnings)
+		winnings := r.scaleGamePayout(game.Bet, game.Bet*2)
+		r.UpdateRatingFrom(game.PlayerID, winnings, "blackjack", "")
+		r.repayLoanFromWinnings(game.PlayerID, winnings-game.Bet)
+		result = fmt.Sprintf("✅ Дилер перебрал! Ты выиграл %s! 🎉", formatCredits(winnings))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Победа! 🏆"}
 		won = true
 	} else if playerSum > dealerSum {
-		winnings := game.Bet * 2
-		r.UpdateRating(game.PlayerID, winnings)
-		result = fmt.Sprintf("✅ Ты выиграл! %d кредитов твои! 🎉", winnings)
+		winnings := r.scaleGamePayout(game.Bet, game.Bet*2)
+		r.UpdateRatingFrom(game.PlayerID, winnings, "blackjack", "")
+		r.repayLoanFromWinnings(game.PlayerID, winnings-game.Bet)
+		result = fmt.Sprintf("✅ Ты выиграл! %s твои! 🎉", formatCredits(winnings))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Победа! 🏆"}
 		won = true
 	} else if playerSum == dealerSum {
-		r.UpdateRating(game.PlayerID, game.Bet)
+		r.UpdateRatingFrom(game.PlayerID, game.Bet, "blackjack", "")
 		result = "🤝 Ничья! Твоя ставка возвращена. 🔄"
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Ничья! 🤝"}
 	} else {
@@ -324,8 +414,101 @@ func (r *Ranking) HandleBlackjackStand(s *discordgo.Session, i *discordgo.Intera
 
 	// Обновляем статистику Blackjack
 	r.UpdateBJStats(game.PlayerID, won)
+	status := "lost"
+	if won {
+		status = "won"
+	} else if playerSum == dealerSum {
+		status = "push"
+	}
+	r.publishBlackjack(game, status, embed.Footer.Text)
 
-	components := []discordgo.MessageComponent{
+	components := blackjackEndComponents(game)
+
+	game.Active = false
+	delete(r.blackjackGames, gameID)
+	return game, embed, components, nil
+}
+
+// HandleBlackjackSurrender обрабатывает сдачу: доступна только первым ходом и возвращает половину ставки.
+func (r *Ranking) HandleBlackjackSurrender(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, "_")
+	if len(parts) < 3 {
+		log.Printf("Неверный формат CustomID: %s", i.MessageComponentData().CustomID)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Ошибка: неверный формат кнопки!", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	gameID := strings.Join(parts[2:], "_")
+
+	game, err := r.endBlackjackForSurrender(gameID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ " + err.Error(), Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	r.captureHold(game.HoldID)
+	refund := game.Bet / 2
+	if refund > 0 {
+		r.UpdateRatingFrom(game.PlayerID, refund, "blackjack", "")
+	}
+	r.UpdateBJSurrender(game.PlayerID)
+	r.publishBlackjack(game, "surrender", "Сдача")
+	log.Printf("Игрок %s сдался в блэкджеке %s, возвращено %d из %d", game.PlayerID, gameID, refund, game.Bet)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+		Description: fmt.Sprintf("**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s (Сумма: %d)\n\n🏳️ Ты сдался! Возвращена половина ставки: %s.", r.cardsToString(game.PlayerCards), r.calculateHand(game.PlayerCards), r.cardsToString(game.DealerCards), r.calculateHand(game.DealerCards), formatCredits(refund)),
+		Color:       game.Color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Иногда отступить — тоже стратегия! 🏳️"},
+	}
+	components := blackjackEndComponents(game)
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         game.MenuMessageID,
+		Embed:      embed,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+}
+
+// endBlackjackForSurrender снимает игру со стола, если сдаться ещё можно (первый ход).
+func (r *Ranking) endBlackjackForSurrender(gameID string) (*BlackjackGame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	game, exists := r.blackjackGames[gameID]
+	if !exists || !game.Active {
+		return nil, fmt.Errorf("Игра не найдена или уже завершена!")
+	}
+	if len(game.PlayerCards) != 2 {
+		return nil, fmt.Errorf("Сдаться можно только первым ходом!")
+	}
+	game.Active = false
+	delete(r.blackjackGames, gameID)
+	return game, nil
+}
+
+// blackjackEndComponents возвращает кнопки завершённой игры: новая игра и повтор ставки.
+func blackjackEndComponents(game *BlackjackGame) []discordgo.MessageComponent {
+	pp, t := 0, 0
+	for _, bet := range game.SideBets {
+		switch bet.Kind {
+		case sideBetPerfectPairs:
+			pp = bet.Amount
+		case sideBet21Plus3:
+			t = bet.Amount
+		}
+	}
+	return []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.Button{
@@ -333,25 +516,109 @@ func (r *Ranking) HandleBlackjackStand(s *discordgo.Session, i *discordgo.Intera
 					Style:    discordgo.PrimaryButton,
 					CustomID: fmt.Sprintf("blackjack_replay_%s_%s", game.PlayerID, game.MenuMessageID),
 				},
+				discordgo.Button{
+					Label:    fmt.Sprintf("Повторить ставку 🔁 (%d)", game.Bet+sideBetsTotal(game.SideBets)),
+					Style:    discordgo.SuccessButton,
+					CustomID: fmt.Sprintf("blackjack_rebet_%s_%s_%d_%d_%d", game.PlayerID, game.MenuMessageID, game.Bet, pp, t),
+				},
 			},
 		},
 	}
+}
 
-	game.Active = false
-	delete(r.blackjackGames, gameID)
-	r.mu.Unlock()
+// HandleBlackjackRebet сразу начинает новую раздачу с той же ставкой и побочными ставками.
+func (r *Ranking) HandleBlackjackRebet(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
 
-	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel:    i.ChannelID,
-		ID:         game.MenuMessageID,
-		Embed:      embed,
-		Components: &components,
-	})
-	if err != nil {
-		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
+	parts := strings.Split(i.MessageComponentData().CustomID, "_")
+	if len(parts) != 7 {
+		log.Printf("Неверный формат CustomID: %s", i.MessageComponentData().CustomID)
+		ephemeral("❌ Ошибка: неверный формат кнопки!")
+		return
+	}
+	playerID, menuMessageID := parts[2], parts[3]
+	amount, errBet := strconv.Atoi(parts[4])
+	pp, errPP := strconv.Atoi(parts[5])
+	t, errT := strconv.Atoi(parts[6])
+	if errBet != nil || errPP != nil || errT != nil || amount <= 0 {
+		ephemeral("❌ Ошибка: неверный формат кнопки!")
+		return
+	}
+	if i.Member == nil || i.Member.User.ID != playerID {
+		ephemeral("❌ Это не твоя игра!")
+		return
 	}
 
+	var sideBets []SideBet
+	if pp > 0 {
+		sideBets = append(sideBets, SideBet{Kind: sideBetPerfectPairs, Amount: pp})
+	}
+	if t > 0 {
+		sideBets = append(sideBets, SideBet{Kind: sideBet21Plus3, Amount: t})
+	}
+	total := amount + sideBetsTotal(sideBets)
+	if msg := r.checkBetLimits("bj", playerID, amount); msg != "" {
+		ephemeral(msg)
+		return
+	}
+	if rating := r.GetRating(playerID); rating < total {
+		ephemeral(fmt.Sprintf("❌ Недостаточно кредитов для повтора ставки %s! Твой баланс: %s", formatCredits(total), formatCredits(rating)))
+		return
+	}
+
+	game, errText := r.startBlackjackRebet(playerID, menuMessageID, i.ChannelID, amount, sideBets)
+	if errText != "" {
+		ephemeral(errText)
+		return
+	}
+
+	log.Printf("Повтор ставки в блэкджеке: игрок %s, ставка %d, побочные %d", playerID, amount, total-amount)
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+
+	go r.blackjackTimeout(s, game.GameID)
+	r.dealBlackjack(s, game)
+}
+
+// startBlackjackRebet удерживает ставку и садит игрока за новый стол. При отказе возвращает
+// текст для игрока.
+func (r *Ranking) startBlackjackRebet(playerID, menuMessageID, channelID string, amount int, sideBets []SideBet) (*BlackjackGame, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := amount + sideBetsTotal(sideBets)
+	for _, g := range r.blackjackGames {
+		if g.PlayerID == playerID && g.Active {
+			return nil, "❌ У тебя уже есть активная игра в блэкджек!"
+		}
+	}
+	if remaining, err := r.reserveDailyWager(playerID, total); err != nil {
+		return nil, r.wagerCapMessage(remaining, err)
+	}
+	holdID, err := r.placeBlackjackBet(playerID, amount, sideBets)
+	if err != nil {
+		r.releaseDailyWager(playerID, total, time.Now())
+		return nil, r.holdErrorMessage(playerID, err)
+	}
+	game := &BlackjackGame{
+		GameID:        generateGameID(playerID),
+		PlayerID:      playerID,
+		Bet:           amount,
+		HoldID:        holdID,
+		SideBets:      sideBets,
+		Active:        true,
+		LastActivity:  time.Now(),
+		MenuMessageID: menuMessageID,
+		Color:         r.themeColor(playerID),
+		ChannelID:     channelID,
+	}
+	r.blackjackGames[game.GameID] = game
+	return game, ""
 }
 
 // HandleBlackjackReplay начинает новую игру в блэкджек.
@@ -369,7 +636,7 @@ func (r *Ranking) HandleBlackjackReplay(s *discordgo.Session, i *discordgo.Inter
 	menuMessageID := parts[3]
 
 	newGameID := generateGameID(playerID)
-	newColor := randomColor()
+	newColor := r.themeColor(playerID)
 	game := &BlackjackGame{
 		GameID:        newGameID,
 		PlayerID:      playerID,
@@ -385,8 +652,8 @@ func (r *Ranking) HandleBlackjackReplay(s *discordgo.Session, i *discordgo.Inter
 	r.mu.Unlock()
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Казино: Блэкджек 🎰",
-		Description: fmt.Sprintf("Добро пожаловать, <@%s>! 🎉\nСделай ставку, чтобы начать игру.\n\n**💰 Твой баланс:** %d кредитов\n\nНапиши: `/blackjack <сумма>`", playerID, r.GetRating(playerID)),
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Казино: Блэкджек 🎰"),
+		Description: fmt.Sprintf("Добро пожаловать, <@%s>! 🎉\nСделай ставку, чтобы начать игру.\n\n**💰 Твой баланс:** %s\n\nНапиши: `/blackjack <сумма>`", playerID, formatCredits(r.GetRating(playerID))),
 		Color:       newColor,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Играй с умом! 🍀",
@@ -445,9 +712,16 @@ func (r *Ranking) HandleEndBlackjackCommand(s *discordgo.Session, m *discordgo.M
 	delete(r.blackjackGames, game.GameID)
 	r.mu.Unlock()
 
+	description := fmt.Sprintf("Игра завершена админом: <@%s>! 🚫", targetID)
+	if game.Bet > 0 {
+		r.Release(game.HoldID)
+		r.UpdateBJStats(game.PlayerID, false)
+		description += fmt.Sprintf("\n\n🔄 Ставка %s возвращена.", formatCredits(game.Bet))
+	}
+
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Блэкджек 🎲",
-		Description: fmt.Sprintf("Игра завершена админом: <@%s>! 🚫", targetID),
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+		Description: description,
 		Color:       game.Color,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Игра остановлена! 🔴",
@@ -462,33 +736,183 @@ func (r *Ranking) HandleEndBlackjackCommand(s *discordgo.Session, m *discordgo.M
 	log.Printf("Игра в блэкджек для %s завершена админом %s", targetID, m.Author.ID)
 }
 
-// blackjackTimeout завершает игру по тайм-ауту.
+// blackjackTimeout завершает игру после тайм-аута бездействия и возвращает сделанную ставку.
 func (r *Ranking) blackjackTimeout(s *discordgo.Session, gameID string) {
-	time.Sleep(15 * time.Minute)
-	r.mu.Lock()
-	game, exists := r.blackjackGames[gameID]
-	if !exists || !game.Active {
+	var game *BlackjackGame
+	for {
+		timeout := r.GameTimeout("bj")
+		r.mu.Lock()
+		g, exists := r.blackjackGames[gameID]
+		if !exists || !g.Active {
+			r.mu.Unlock()
+			return
+		}
+		wait := time.Until(g.LastActivity.Add(timeout))
+		if wait > 0 {
+			r.mu.Unlock()
+			time.Sleep(wait)
+			continue
+		}
+		g.Active = false
+		delete(r.blackjackGames, gameID)
+		game = g
 		r.mu.Unlock()
-		return
+		break
+	}
+
+	description := fmt.Sprintf("Игра завершена, <@%s>! Время вышло! ⏰", game.PlayerID)
+	if game.Bet > 0 {
+		r.Release(game.HoldID)
+		r.UpdateBJStats(game.PlayerID, false)
+		r.LogCreditOperation(s, fmt.Sprintf("⏰ Блэкджек <@%s> завершён по тайм-ауту, ставка %s возвращена", game.PlayerID, formatCredits(game.Bet)))
+		description += fmt.Sprintf("\n\n🔄 Ставка %s возвращена.", formatCredits(game.Bet))
+		log.Printf("Блэкджек %s завершён по тайм-ауту, ставка %d возвращена игроку %s", gameID, game.Bet, game.PlayerID)
 	}
-	game.Active = false
-	delete(r.blackjackGames, gameID)
-	r.mu.Unlock()
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "♠️ Блэкджек 🎲",
-		Description: fmt.Sprintf("Игра завершена, <@%s>! Время вышло! ⏰", game.PlayerID),
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+		Description: description,
 		Color:       game.Color,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Время вышло! 😢",
 		},
 	}
-	_, err := s.ChannelMessageEditEmbed(game.ChannelID, game.MenuMessageID, embed)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    game.ChannelID,
+		ID:         game.MenuMessageID,
+		Embed:      embed,
+		Components: &[]discordgo.MessageComponent{},
+	})
 	if err != nil {
 		log.Printf("Не удалось обновить сообщение блэкджека по тайм-ауту: %v", err)
 	}
 }
 
+// bjRevealDelay — пауза между кадрами открытия карт дилера.
+const bjRevealDelay = 800 * time.Millisecond
+
+// isNaturalBlackjack проверяет, является ли рука натуральным блэкджеком (21 с первых двух карт).
+func isNaturalBlackjack(cards []Card) bool {
+	if len(cards) != 2 {
+		return false
+	}
+	hasAce, hasTen := false, false
+	for _, card := range cards {
+		switch card.Value {
+		case "A":
+			hasAce = true
+		case "10", "J", "Q", "K":
+			hasTen = true
+		}
+	}
+	return hasAce && hasTen
+}
+
+// dealerPeeks сообщает, проверяет ли дилер скрытую карту на блэкджек (открыт туз или десятка).
+func dealerPeeks(upCard Card) bool {
+	switch upCard.Value {
+	case "A", "10", "J", "Q", "K":
+		return true
+	}
+	return false
+}
+
+// naturalPayout возвращает выплату за натуральный блэкджек: ставка плюс выигрыш 3:2.
+func naturalPayout(bet int) int {
+	return bet + bet*3/2
+}
+
+// finishNaturalBlackjack завершает раздачу с натуральным блэкджеком у игрока и/или дилера.
+func (r *Ranking) finishNaturalBlackjack(s *discordgo.Session, channelID string, game *BlackjackGame) {
+	r.mu.Lock()
+	game.Active = false
+	delete(r.blackjackGames, game.GameID)
+	r.mu.Unlock()
+
+	r.captureHold(game.HoldID)
+	playerNatural := isNaturalBlackjack(game.PlayerCards)
+	dealerNatural := isNaturalBlackjack(game.DealerCards)
+
+	var result, footer string
+	won := false
+	status := "lost"
+	switch {
+	case playerNatural && dealerNatural:
+		r.UpdateRatingFrom(game.PlayerID, game.Bet, "blackjack", "")
+		result = "🤝 Блэкджек у обоих! Твоя ставка возвращена. 🔄"
+		footer = "Ничья! 🤝"
+		status = "push"
+	case playerNatural:
+		winnings := r.scaleGamePayout(game.Bet, naturalPayout(game.Bet))
+		r.UpdateRatingFrom(game.PlayerID, winnings, "blackjack", "")
+		r.repayLoanFromWinnings(game.PlayerID, winnings-game.Bet)
+		result = fmt.Sprintf("🂡 Блэкджек! Выплата 3:2 — ты получаешь %s! 🎉", formatCredits(winnings))
+		r.addJade(s, game.PlayerID, jadeNaturalBlackjackReward, "натуральный блэкджек")
+		result += fmt.Sprintf("\n💠 Бонус: %s", formatJade(jadeNaturalBlackjackReward))
+		footer = "Натуральный блэкджек! 🏆"
+		won = true
+		status = "won"
+	default:
+		result = "❌ Дилер проверил скрытую карту — у него блэкджек! 💥"
+		footer = "Не повезло! 😢"
+	}
+	r.UpdateBJStats(game.PlayerID, won)
+	r.publishBlackjack(game, status, footer)
+	log.Printf("Натуральный блэкджек в игре %s: игрок %v, дилер %v, ставка %d", game.GameID, playerNatural, dealerNatural, game.Bet)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+		Description: fmt.Sprintf("<@%s> начал игру со ставкой %s! 💸\n\n**🃏 Твои карты:** %s (Сумма: %d)\n**🃏 Карты дилера:** %s (Сумма: %d)\n\n%s", game.PlayerID, formatCredits(game.Bet), r.cardsToString(game.PlayerCards), r.calculateHand(game.PlayerCards), r.cardsToString(game.DealerCards), r.calculateHand(game.DealerCards), result),
+		Color:       game.Color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+	}
+	if game.SideBetResult != "" {
+		embed.Description += "\n\n" + game.SideBetResult
+	}
+	components := blackjackEndComponents(game)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    channelID,
+		ID:         game.MenuMessageID,
+		Embed:      embed,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение блэкджека: %v", err)
+	}
+}
+
+// animateDealerReveal показывает, как дилер переворачивает скрытую карту и добирает карты по одной.
+// Итоговый кадр с результатом отправляет вызывающий код.
+func (r *Ranking) animateDealerReveal(s *discordgo.Session, channelID string, game *BlackjackGame) {
+	playerLine := fmt.Sprintf("**🃏 Твои карты:** %s (Сумма: %d)", r.cardsToString(game.PlayerCards), r.calculateHand(game.PlayerCards))
+	frames := []string{
+		fmt.Sprintf("%s\n**🃏 Карты дилера:** %s, 🂠 _дилер переворачивает карту..._", playerLine, r.cardToString(game.DealerCards[0])),
+	}
+	for n := 2; n < len(game.DealerCards); n++ {
+		frames = append(frames, fmt.Sprintf("%s\n**🃏 Карты дилера:** %s (Сумма: %d)\n\n_Дилер берёт карту..._", playerLine, r.cardsToString(game.DealerCards[:n]), r.calculateHand(game.DealerCards[:n])))
+	}
+
+	for _, frame := range frames {
+		embed := &discordgo.MessageEmbed{
+			Title:       r.themeTitle(game.PlayerID, "bj", "♠️ Блэкджек 🎲"),
+			Description: frame,
+			Color:       game.Color,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Ход дилера... 🎴"},
+		}
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    channelID,
+			ID:         game.MenuMessageID,
+			Embed:      embed,
+			Components: &[]discordgo.MessageComponent{},
+		})
+		if err != nil {
+			log.Printf("Не удалось показать ход дилера: %v", err)
+			return
+		}
+		time.Sleep(bjRevealDelay)
+	}
+}
+
 // generateDeck создаёт колоду карт.
 func (r *Ranking) generateDeck() []Card {
 	suits := []string{"♠️", "♥️", "♦️", "♣️"}
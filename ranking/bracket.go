@@ -0,0 +1,492 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Турниры на выбывание. Взнос участника замораживается при записи, при старте турнира взносы
+// списываются в банк, а после финала банк целиком получает чемпион.
+const (
+	bracketActiveKey  = "bracket:active" // SET ID незавершённых турниров
+	bracketMaxPlayers = 64
+)
+
+// Статусы турнира.
+const (
+	bracketSignup    = "signup"
+	bracketRunning   = "running"
+	bracketFinished  = "finished"
+	bracketCancelled = "cancelled"
+)
+
+// BracketMatch — матч сетки. Пустой B означает, что A проходит дальше без игры.
+type BracketMatch struct {
+	A      string `json:"a"`
+	B      string `json:"b,omitempty"`
+	Winner string `json:"winner,omitempty"`
+}
+
+// Bracket — турнир на выбывание со взносом.
+type Bracket struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Fee       int               `json:"fee"`
+	CreatedBy string            `json:"created_by"`
+	ChannelID string            `json:"channel_id"`
+	MessageID string            `json:"message_id"`
+	Status    string            `json:"status"`
+	Players   []string          `json:"players"`
+	Holds     map[string]string `json:"holds,omitempty"` // userID -> ID удержания взноса
+	Rounds    [][]BracketMatch  `json:"rounds,omitempty"`
+	Pot       int               `json:"pot"`
+	Champion  string            `json:"champion,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// bracketKey возвращает ключ турнира.
+func bracketKey(id string) string {
+	return "bracket:" + id
+}
+
+// loadBracket читает турнир из Redis.
+func (r *Ranking) loadBracket(id string) (*Bracket, error) {
+	data, err := r.redis.Get(r.ctx, bracketKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("турнир %s не найден", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка Redis: %v", err)
+	}
+	var b Bracket
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// saveBracket сохраняет турнир. Завершённые турниры хранятся неделю.
+func (r *Ranking) saveBracket(b *Bracket) error {
+	data, _ := json.Marshal(b)
+	pipe := r.redis.TxPipeline()
+	if b.Status == bracketFinished || b.Status == bracketCancelled {
+		pipe.Set(r.ctx, bracketKey(b.ID), data, 7*24*time.Hour)
+		pipe.SRem(r.ctx, bracketActiveKey, b.ID)
+	} else {
+		pipe.Set(r.ctx, bracketKey(b.ID), data, 0)
+		pipe.SAdd(r.ctx, bracketActiveKey, b.ID)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// bracketRoundName возвращает название раунда по числу матчей в нём.
+func bracketRoundName(round, matches int) string {
+	switch matches {
+	case 1:
+		return "🏆 Финал"
+	case 2:
+		return "Полуфинал"
+	case 4:
+		return "Четвертьфинал"
+	}
+	return fmt.Sprintf("Раунд %d", round+1)
+}
+
+// bracketMessage формирует эмбед турнира и кнопку записи, пока запись открыта.
+func (r *Ranking) bracketMessage(b *Bracket) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	embed := &discordgo.MessageEmbed{
+		Title: "🏟️ Турнир: " + b.Name,
+		Color: 0xFFD700,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("ID %s · /a_bracket start|win|cancel %s", b.ID, b.ID),
+		},
+	}
+	components := []discordgo.MessageComponent{}
+
+	switch b.Status {
+	case bracketSignup:
+		players := "Пока никого — будь первым!"
+		if len(b.Players) > 0 {
+			mentions := make([]string, 0, len(b.Players))
+			for _, userID := range b.Players {
+				mentions = append(mentions, r.publicMention(userID))
+			}
+			players = strings.Join(mentions, ", ")
+		}
+		embed.Description = fmt.Sprintf("Запись открыта! Взнос: **%s**, весь банк забирает чемпион.", formatCredits(b.Fee))
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: fmt.Sprintf("👥 Участники (%d/%d)", len(b.Players), bracketMaxPlayers), Value: truncate(players, 1024)},
+			{Name: "💰 Банк", Value: formatCredits(b.Fee * len(b.Players)), Inline: true},
+		}
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: fmt.Sprintf("Участвовать (%d) ⚔️", b.Fee), Style: discordgo.PrimaryButton, CustomID: "bracket_join_" + b.ID},
+			}},
+		}
+		return embed, components
+	case bracketCancelled:
+		embed.Description = "❌ Турнир отменён, взносы возвращены."
+		return embed, components
+	case bracketFinished:
+		embed.Description = fmt.Sprintf("👑 Чемпион: %s — забирает **%s**!", r.publicMention(b.Champion), formatCredits(b.Pot))
+	default:
+		embed.Description = fmt.Sprintf("Турнир идёт! Банк: **%s**.", formatCredits(b.Pot))
+	}
+
+	for round, matches := range b.Rounds {
+		lines := make([]string, 0, len(matches))
+		for n, match := range matches {
+			line := fmt.Sprintf("`%d.` %s vs %s", n+1, r.publicMention(match.A), r.publicMention(match.B))
+			if match.B == "" {
+				line = fmt.Sprintf("`%d.` %s — проходит без игры", n+1, r.publicMention(match.A))
+			} else if match.Winner != "" {
+				line += " → 🏅 " + r.publicMention(match.Winner)
+			}
+			lines = append(lines, line)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  bracketRoundName(round, len(matches)),
+			Value: truncate(strings.Join(lines, "\n"), 1024),
+		})
+	}
+	return embed, components
+}
+
+// refreshBracketMessage перерисовывает сообщение турнира.
+func (r *Ranking) refreshBracketMessage(s *discordgo.Session, b *Bracket) {
+	embed, components := r.bracketMessage(b)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    b.ChannelID,
+		ID:         b.MessageID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение турнира %s: %v", b.ID, err)
+	}
+}
+
+// seedBracket перемешивает участников и строит первый раунд. Сетка дополняется до степени двойки
+// проходами без игры, и у каждого такого прохода есть живой участник.
+func seedBracket(players []string) []BracketMatch {
+	shuffled := append([]string(nil), players...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	size := 1
+	for size < len(shuffled) {
+		size *= 2
+	}
+	matches := make([]BracketMatch, size/2)
+	for i := range matches {
+		matches[i].A = shuffled[i]
+		if size/2+i < len(shuffled) {
+			matches[i].B = shuffled[size/2+i]
+		} else {
+			matches[i].Winner = shuffled[i]
+		}
+	}
+	return matches
+}
+
+// advanceBracket строит следующие раунды, пока текущий полностью сыгран.
+// Возвращает true, когда определился чемпион.
+func advanceBracket(b *Bracket) bool {
+	for {
+		current := b.Rounds[len(b.Rounds)-1]
+		for _, match := range current {
+			if match.Winner == "" {
+				return false
+			}
+		}
+		if len(current) == 1 {
+			b.Champion = current[0].Winner
+			return true
+		}
+		next := make([]BracketMatch, len(current)/2)
+		for i := range next {
+			next[i] = BracketMatch{A: current[2*i].Winner, B: current[2*i+1].Winner}
+		}
+		b.Rounds = append(b.Rounds, next)
+	}
+}
+
+// HandleBracketJoin обрабатывает нажатие кнопки записи на турнир.
+func (r *Ranking) HandleBracketJoin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	id := strings.TrimPrefix(i.MessageComponentData().CustomID, "bracket_join_")
+	userID := i.Member.User.ID
+
+	b, errText := r.joinBracket(id, userID)
+	if errText != "" {
+		respondEphemeral(s, i, errText)
+		return
+	}
+
+	log.Printf("Турнир %s: %s записался (взнос %d)", id, userID, b.Fee)
+	embed, components := r.bracketMessage(b)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// joinBracket записывает игрока на турнир и удерживает взнос. При отказе возвращает текст для игрока.
+func (r *Ranking) joinBracket(id, userID string) (*Bracket, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.loadBracket(id)
+	if err != nil {
+		return nil, "❌ " + err.Error()
+	}
+	if b.Status != bracketSignup {
+		return nil, "❌ Запись на турнир уже закрыта!"
+	}
+	for _, player := range b.Players {
+		if player == userID {
+			return nil, "ℹ️ Ты уже записан на турнир!"
+		}
+	}
+	if len(b.Players) >= bracketMaxPlayers {
+		return nil, fmt.Sprintf("❌ В турнире уже %d участников!", bracketMaxPlayers)
+	}
+	if b.Fee > 0 {
+		if b.Holds == nil {
+			b.Holds = make(map[string]string)
+		}
+		holdID, err := r.holdLocked(userID, b.Fee, "bracket", 0)
+		if err != nil {
+			return nil, r.holdErrorMessage(userID, err)
+		}
+		b.Holds[userID] = holdID
+	}
+	b.Players = append(b.Players, userID)
+	if err := r.saveBracket(b); err != nil {
+		if holdID := b.Holds[userID]; holdID != "" {
+			r.releaseLocked(holdID)
+		}
+		log.Printf("Не удалось сохранить турнир %s: %v", id, err)
+		return nil, "❌ Ошибка Redis, попробуй ещё раз!"
+	}
+	return b, ""
+}
+
+// startBracket закрывает запись, списывает взносы в банк и строит сетку.
+func (r *Ranking) startBracket(b *Bracket) error {
+	if b.Status != bracketSignup {
+		return fmt.Errorf("турнир %s уже начат или завершён", b.ID)
+	}
+	if len(b.Players) < 2 {
+		return fmt.Errorf("для старта нужно хотя бы 2 участника, записано %d", len(b.Players))
+	}
+	players := make([]string, 0, len(b.Players))
+	for _, userID := range b.Players {
+		if holdID, ok := b.Holds[userID]; ok {
+			credits, err := r.Capture(holdID)
+			if err != nil {
+				log.Printf("Турнир %s: взнос %s не списан (%v), участник исключён", b.ID, userID, err)
+				continue
+			}
+			b.Pot += credits
+		}
+		players = append(players, userID)
+	}
+	b.Players = players
+	b.Holds = nil
+	b.Status = bracketRunning
+	b.Rounds = [][]BracketMatch{seedBracket(players)}
+	advanceBracket(b)
+	return nil
+}
+
+// reportBracketWinner записывает победителя текущего матча. Возвращает true, если турнир завершён.
+func (r *Ranking) reportBracketWinner(b *Bracket, winnerID string) (bool, error) {
+	if b.Status != bracketRunning {
+		return false, fmt.Errorf("турнир %s не идёт", b.ID)
+	}
+	current := b.Rounds[len(b.Rounds)-1]
+	found := false
+	for n := range current {
+		match := &current[n]
+		if match.Winner == "" && (match.A == winnerID || match.B == winnerID) {
+			match.Winner = winnerID
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("у <@%s> нет несыгранного матча в текущем раунде", winnerID)
+	}
+	if !advanceBracket(b) {
+		return false, nil
+	}
+	b.Status = bracketFinished
+	if b.Pot > 0 {
+		r.UpdateRatingFrom(b.Champion, b.Pot, "bracket", "")
+	}
+	return true, nil
+}
+
+// cancelBracket отменяет турнир и возвращает взносы: до старта — откатом удержаний,
+// после старта — из банка.
+func (r *Ranking) cancelBracket(b *Bracket) error {
+	switch b.Status {
+	case bracketSignup:
+		for userID, holdID := range b.Holds {
+			if _, err := r.releaseLocked(holdID); err != nil {
+				log.Printf("Турнир %s: не удалось вернуть взнос %s: %v", b.ID, userID, err)
+			}
+		}
+		b.Holds = nil
+	case bracketRunning:
+		if b.Pot > 0 {
+			for _, userID := range b.Players {
+				r.UpdateRatingFrom(userID, b.Fee, "bracket", "")
+			}
+		}
+	default:
+		return fmt.Errorf("турнир %s уже завершён", b.ID)
+	}
+	b.Status = bracketCancelled
+	return nil
+}
+
+// HandleBracketCommand обрабатывает команду !a_bracket create|start|win|cancel.
+func (r *Ranking) HandleBracketCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_bracket: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут проводить турниры! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_bracket create <название> <взнос>`, `/a_bracket start <ID>`, `/a_bracket win <ID> @победитель` или `/a_bracket cancel <ID>`"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendActiveBrackets(s, m.ChannelID)
+		return
+	}
+
+	switch parts[1] {
+	case "create":
+		if len(parts) < 4 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		fee, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil || fee < 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Взнос должен быть неотрицательным числом!")
+			return
+		}
+		// Название берём из исходного сообщения: command приведена к нижнему регистру
+		original := strings.Fields(m.Content)
+		b := &Bracket{
+			ID:        generatePollID(),
+			Name:      truncate(strings.Join(original[2:len(original)-1], " "), 100),
+			Fee:       fee,
+			CreatedBy: m.Author.ID,
+			ChannelID: m.ChannelID,
+			Status:    bracketSignup,
+			Holds:     make(map[string]string),
+			CreatedAt: time.Now(),
+		}
+		embed, components := r.bracketMessage(b)
+		msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components})
+		if err != nil {
+			log.Printf("Не удалось отправить турнир %s: %v", b.ID, err)
+			return
+		}
+		b.MessageID = msg.ID
+		if err := r.saveBracket(b); err != nil {
+			log.Printf("Не удалось сохранить турнир %s: %v", b.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения турнира! Проверьте Redis-сервер.")
+			return
+		}
+		log.Printf("Турнир %s (%s) создан админом %s, взнос %d", b.ID, b.Name, m.Author.ID, fee)
+		return
+	case "start", "win", "cancel":
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	if len(parts) < 3 || (parts[1] == "win" && len(m.Mentions) != 1) {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	r.mu.Lock()
+	b, err := r.loadBracket(strings.ToUpper(parts[2]))
+	if err == nil {
+		switch parts[1] {
+		case "start":
+			err = r.startBracket(b)
+		case "win":
+			_, err = r.reportBracketWinner(b, m.Mentions[0].ID)
+		case "cancel":
+			err = r.cancelBracket(b)
+		}
+	}
+	if err == nil {
+		if saveErr := r.saveBracket(b); saveErr != nil {
+			log.Printf("Не удалось сохранить турнир %s: %v", b.ID, saveErr)
+		}
+	}
+	r.mu.Unlock()
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+		return
+	}
+
+	r.refreshBracketMessage(s, b)
+	switch {
+	case b.Status == bracketFinished:
+		text := fmt.Sprintf("🏆 <@%s> выиграл турнир «%s» и получил %s", b.Champion, b.Name, formatCredits(b.Pot))
+		r.LogCreditOperation(s, text)
+		s.ChannelMessageSend(b.ChannelID, "👑 "+text+"! Славь Императора! 🇨🇳")
+	case parts[1] == "cancel":
+		r.LogCreditOperation(s, fmt.Sprintf("❌ <@%s> отменил турнир «%s», взносы возвращены", m.Author.ID, b.Name))
+		s.ChannelMessageSend(m.ChannelID, "✅ Турнир отменён, взносы возвращены.")
+	case parts[1] == "start":
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Турнир «%s» начат: %d участников, банк %s.", b.Name, len(b.Players), formatCredits(b.Pot)))
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Победа <@%s> записана.", m.Mentions[0].ID))
+	}
+}
+
+// sendActiveBrackets отправляет список незавершённых турниров.
+func (r *Ranking) sendActiveBrackets(s *discordgo.Session, channelID string) {
+	ids, err := r.redis.SMembers(r.ctx, bracketActiveKey).Result()
+	if err != nil {
+		s.ChannelMessageSend(channelID, "❌ Ошибка Redis!")
+		return
+	}
+	sort.Strings(ids)
+	var lines []string
+	for _, id := range ids {
+		b, err := r.loadBracket(id)
+		if err != nil {
+			continue
+		}
+		status := "запись"
+		if b.Status == bracketRunning {
+			status = bracketRoundName(len(b.Rounds)-1, len(b.Rounds[len(b.Rounds)-1]))
+		}
+		lines = append(lines, fmt.Sprintf("`%s` **%s** — %s, участников: %d, взнос %s", b.ID, b.Name, status, len(b.Players), formatCredits(b.Fee)))
+	}
+	if len(lines) == 0 {
+		s.ChannelMessageSend(channelID, "ℹ️ Активных турниров нет. Создай: `/a_bracket create <название> <взнос>`")
+		return
+	}
+	s.ChannelMessageSend(channelID, "🏟️ **Активные турниры:**\n"+strings.Join(lines, "\n"))
+}
@@ -0,0 +1,213 @@
+package ranking
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Массовое начисление по CSV (!a_bulk_grant): строки «userID, сумма, причина». Файл проверяется
+// целиком до начала начислений — при любой ошибке не начисляется ничего, а все изменения
+// проводятся под r.mu одной пачкой, чтобы игры и переводы не вклинились посередине.
+const (
+	bulkGrantMaxRows   = 500
+	bulkGrantMaxBytes  = 1 << 20
+	bulkGrantMaxAmount = 1_000_000_000 // предел суммы одной строки, чтобы итоги не переполнились
+)
+
+// bulkGrantHTTPClient скачивает вложения с CDN Discord.
+var bulkGrantHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// bulkGrantRow — строка файла начислений.
+type bulkGrantRow struct {
+	Line   int
+	UserID string
+	Amount int
+	Reason string
+}
+
+// parseBulkGrantCSV разбирает файл начислений. Разделитель — запятая или точка с запятой
+// (экспорт из Excel); строка-заголовок пропускается. Возвращает все ошибки по строкам.
+func parseBulkGrantCSV(data []byte) ([]bulkGrantRow, []string) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	reader := csv.NewReader(strings.NewReader(text))
+	firstLine, _, _ := strings.Cut(text, "\n")
+	if strings.Contains(firstLine, ";") && !strings.Contains(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []bulkGrantRow
+	var problems []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("строка %d: %v", line, err))
+			break
+		}
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		userID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(record[0]), "<@"), "!"), ">")
+		if line == 1 && !isValidUserID(userID) {
+			continue // заголовок
+		}
+		if len(record) < 2 {
+			problems = append(problems, fmt.Sprintf("строка %d: нужна сумма", line))
+			continue
+		}
+		if !isValidUserID(userID) {
+			problems = append(problems, fmt.Sprintf("строка %d: некорректный ID %q", line, record[0]))
+			continue
+		}
+		amount, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil || amount == 0 || amount > bulkGrantMaxAmount || amount < -bulkGrantMaxAmount {
+			problems = append(problems, fmt.Sprintf("строка %d: некорректная сумма %q", line, record[1]))
+			continue
+		}
+		reason := ""
+		if len(record) > 2 {
+			reason = strings.TrimSpace(strings.Join(record[2:], ","))
+		}
+		rows = append(rows, bulkGrantRow{Line: line, UserID: userID, Amount: amount, Reason: reason})
+	}
+	if len(rows) > bulkGrantMaxRows {
+		problems = append(problems, fmt.Sprintf("слишком много строк: %d (максимум %d)", len(rows), bulkGrantMaxRows))
+	}
+	return rows, problems
+}
+
+// validateBulkGrantRows проверяет, что все игроки из файла уже есть в базе: опечатка в ID
+// иначе завела бы нового игрока с начислением. Вызывается под r.mu до любых изменений.
+func (r *Ranking) validateBulkGrantRows(rows []bulkGrantRow) []string {
+	pipe := r.redis.Pipeline()
+	exists := make([]*redis.IntCmd, len(rows))
+	for i, row := range rows {
+		exists[i] = pipe.Exists(r.ctx, "user:"+row.UserID)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return []string{fmt.Sprintf("не удалось проверить игроков: %v", err)}
+	}
+	var problems []string
+	for i, row := range rows {
+		if exists[i].Val() == 0 {
+			problems = append(problems, fmt.Sprintf("строка %d: игрок <@%s> не найден", row.Line, row.UserID))
+		}
+	}
+	return problems
+}
+
+// downloadAttachment скачивает вложение сообщения не больше limit байт.
+func downloadAttachment(attachment *discordgo.MessageAttachment, limit int64) ([]byte, error) {
+	if int64(attachment.Size) > limit {
+		return nil, fmt.Errorf("файл больше %d КБ", limit>>10)
+	}
+	resp, err := bulkGrantHTTPClient.Get(attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CDN ответил %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// HandleBulkGrantCommand обрабатывает команду !a_bulk_grant с приложенным CSV.
+func (r *Ranking) HandleBulkGrantCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !a_bulk_grant от %s", m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут начислять кредиты! 🔒")
+		return
+	}
+	if len(m.Attachments) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Приложи к `/a_bulk_grant` один CSV-файл со строками `userID, сумма, причина` (сумма может быть отрицательной).")
+		return
+	}
+
+	data, err := downloadAttachment(m.Attachments[0], bulkGrantMaxBytes)
+	if err != nil {
+		log.Printf("Не удалось скачать файл начислений: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось скачать файл: "+err.Error())
+		return
+	}
+	rows, problems := parseBulkGrantCSV(data)
+	if len(problems) > 0 {
+		s.ChannelMessageSend(m.ChannelID, truncate("❌ Файл не принят, ничего не начислено:\n• "+strings.Join(problems, "\n• "), 2000))
+		return
+	}
+	if len(rows) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ В файле нет строк для начисления.")
+		return
+	}
+
+	// Итоги считаются по фактическим изменениям: списание может упереться в нижнюю границу баланса.
+	totals := make(map[string]int)
+	granted, taken := 0, 0
+	r.mu.Lock()
+	if problems := r.validateBulkGrantRows(rows); len(problems) > 0 {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, truncate("❌ Файл не принят, ничего не начислено:\n• "+strings.Join(problems, "\n• "), 2000))
+		return
+	}
+	for _, row := range rows {
+		note := "массовое начисление"
+		if row.Reason != "" {
+			note += ": " + row.Reason
+		}
+		delta := r.updateRatingNoted(row.UserID, row.Amount, "admin", m.Author.ID, note)
+		totals[row.UserID] += delta
+		if delta > 0 {
+			granted += delta
+		} else {
+			taken -= delta
+		}
+		log.Printf("Массовое начисление (строка %d): %s %+d из %+d, причина: %q", row.Line, row.UserID, delta, row.Amount, row.Reason)
+	}
+	r.mu.Unlock()
+
+	userIDs := make([]string, 0, len(totals))
+	for userID := range totals {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return totals[userIDs[i]] > totals[userIDs[j]] })
+	var lines []string
+	for i, userID := range userIDs {
+		if i == 20 {
+			lines = append(lines, fmt.Sprintf("…и ещё %d", len(userIDs)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("<@%s> %s", userID, formatCreditsDelta(totals[userID])))
+	}
+
+	log.Printf("Админ %s провёл массовое начисление из файла: %d строк, %d игроков, +%d/-%d", m.Author.ID, len(rows), len(userIDs), granted, taken)
+	r.LogCreditOperation(s, fmt.Sprintf("📄 Админ <@%s> провёл массовое начисление из файла %s: %d строк, %d игроков, начислено %s, списано %s",
+		m.Author.ID, m.Attachments[0].Filename, len(rows), len(userIDs), formatCredits(granted), formatCredits(taken)))
+	embed := &discordgo.MessageEmbed{
+		Title:       "📄 Массовое начисление выполнено",
+		Description: strings.Join(lines, "\n"),
+		Color:       0x00FF00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📋 Строк", Value: strconv.Itoa(len(rows)), Inline: true},
+			{Name: "👥 Игроков", Value: strconv.Itoa(len(userIDs)), Inline: true},
+			{Name: "📈 Начислено", Value: formatCredits(granted), Inline: true},
+			{Name: "📉 Списано", Value: formatCredits(taken), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Файл: " + m.Attachments[0].Filename},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
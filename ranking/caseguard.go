@@ -0,0 +1,77 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Защита от повторного начисления наград кейса. Каждое открытие получает токен (ID сообщения
+// с командой), поэтому повторная доставка события или повтор обработчика не выдаст дроп дважды.
+const caseOpeningTTL = 24 * time.Hour
+
+// dailyCaseOpenLimit — сколько кейсов можно открыть за день.
+const dailyCaseOpenLimit = 5
+
+// caseOpenHoldTTL — сколько кейс удерживается в эскроу до подтверждения открытия.
+const caseOpenHoldTTL = 5 * time.Minute
+
+// caseOpeningKey возвращает ключ состояния открытия кейса по токену.
+func caseOpeningKey(token string) string {
+	return "case_open:" + token
+}
+
+// claimCaseOpening занимает токен открытия кейса. Возвращает false, если открытие с этим токеном
+// уже выполнялось, и ошибку, если Redis недоступен (в обоих случаях кейс не списывается).
+func (r *Ranking) claimCaseOpening(token, userID, caseID string) (bool, error) {
+	ok, err := r.redis.SetNX(r.ctx, caseOpeningKey(token), "claimed", caseOpeningTTL).Result()
+	if err != nil {
+		log.Printf("Не удалось занять токен открытия кейса %s: %v", token, err)
+		r.ReportError("redis", err)
+		return false, err
+	}
+	if !ok {
+		r.rejectDuplicateCaseOpening(token, userID, caseID, "повторное открытие")
+	}
+	return ok, nil
+}
+
+// markCaseOpeningCredited отмечает, что награды открытия начислены. Возвращает false, если
+// награды по этому токену уже были начислены. При сбое Redis начисление разрешается: повтор
+// всё равно будет отклонён claimCaseOpening.
+func (r *Ranking) markCaseOpeningCredited(token, userID, caseID string) bool {
+	prev, err := r.redis.GetSet(r.ctx, caseOpeningKey(token), "credited").Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось отметить начисление открытия кейса %s: %v", token, err)
+		return true
+	}
+	r.redis.Expire(r.ctx, caseOpeningKey(token), caseOpeningTTL)
+	if prev == "credited" {
+		r.rejectDuplicateCaseOpening(token, userID, caseID, "повторное начисление")
+		return false
+	}
+	return true
+}
+
+// reserveCaseOpenSlot атомарно занимает слот дневного лимита открытий. Возвращает false, если лимит исчерпан.
+func (r *Ranking) reserveCaseOpenSlot(key string) bool {
+	opened, err := r.redis.Incr(r.ctx, key).Result()
+	if err != nil {
+		log.Printf("Не удалось занять слот лимита %s: %v", key, err)
+		return false
+	}
+	r.redis.Expire(r.ctx, key, 24*time.Hour)
+	if opened > dailyCaseOpenLimit {
+		r.redis.Decr(r.ctx, key)
+		return false
+	}
+	return true
+}
+
+// rejectDuplicateCaseOpening логирует отклонённый дубликат для расследования.
+func (r *Ranking) rejectDuplicateCaseOpening(token, userID, caseID, reason string) {
+	log.Printf("Отклонено открытие кейса %s пользователем %s (токен %s): %s", caseID, userID, token, reason)
+	CaptureError(fmt.Errorf("дубликат открытия кейса: %s", reason), map[string]string{"user": userID, "case": caseID, "token": token})
+}
@@ -28,8 +28,10 @@ type PendingCinemaBid struct {
 	Name           string // for new movies
 	Index          int    // for existing movies (0-based)
 	Amount         int
+	HoldID         string // удержание кредитов до решения админов (см. Hold)
 	UserMessageID  string // ID of the message with buttons for the user
 	AdminMessageID string // ID of the message with buttons for admins
+	Suggested      string // похожий фильм, уже стоящий в списке (для кнопки «поставить на него»)
 }
 
 func randomColor() int {
@@ -125,7 +127,7 @@ func (r *Ranking) HandleCinemaCommand(s *discordgo.Session, m *discordgo.Message
 		log.Printf("Недостаточно кредитов для пользователя %s: баланс %d, требуется %d", m.Author.ID, balance, amount)
 		embed := &discordgo.MessageEmbed{
 			Title:       "🎥 Киноаукцион",
-			Description: fmt.Sprintf("❌ Недостаточно кредитов. Ваш баланс: %d", balance),
+			Description: fmt.Sprintf("❌ Недостаточно кредитов. Ваш баланс: %s", formatCredits(balance)),
 			Color:       0xFF0000,
 			Footer:      &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp:   time.Now().Format(time.RFC3339),
@@ -143,6 +145,11 @@ func (r *Ranking) HandleCinemaCommand(s *discordgo.Session, m *discordgo.Message
 		Name:   name,
 		Amount: amount,
 	}
+	// Похожий фильм уже есть в списке — предлагаем поставить на него, чтобы не плодить дубликаты
+	if similar := r.findSimilarCinemaOption(name); similar != -1 {
+		pendingBid.Suggested = r.cinemaOptions[similar].Name
+		log.Printf("Фильм %q похож на уже предложенный %q", name, pendingBid.Suggested)
+	}
 
 	bidData, err := json.Marshal(pendingBid)
 	if err != nil {
@@ -181,20 +188,29 @@ func (r *Ranking) HandleCinemaCommand(s *discordgo.Session, m *discordgo.Message
 		Color:       randomColor(),
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Фильм", Value: name, Inline: true},
-			{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", amount), Inline: true},
+			{Name: "Сумма", Value: formatCredits(amount), Inline: true},
 			{Name: "Пользователь", Value: fmt.Sprintf("<@%s>", m.Author.ID), Inline: true},
 		},
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{Label: "✅ Подтвердить", Style: discordgo.SuccessButton, CustomID: "user_confirm_" + bidID},
+		discordgo.Button{Label: "❌ Отменить", Style: discordgo.DangerButton, CustomID: "user_decline_" + bidID},
+	}
+	if pendingBid.Suggested != "" {
+		embed.Description = fmt.Sprintf("⚠️ Похожий фильм уже есть в списке: **%s**. Можно поставить на него, чтобы голоса не разделились.", pendingBid.Suggested)
+		label := "🎯 Поставить на «" + pendingBid.Suggested + "»"
+		if len([]rune(label)) > 80 {
+			label = string([]rune(label)[:79]) + "…"
+		}
+		buttons = append([]discordgo.MessageComponent{
+			discordgo.Button{Label: label, Style: discordgo.PrimaryButton, CustomID: "user_existing_" + bidID},
+		}, buttons...)
+	}
 	components := []discordgo.MessageComponent{
-		discordgo.ActionsRow{
-			Components: []discordgo.MessageComponent{
-				discordgo.Button{Label: "✅ Подтвердить", Style: discordgo.SuccessButton, CustomID: "user_confirm_" + bidID},
-				discordgo.Button{Label: "❌ Отменить", Style: discordgo.DangerButton, CustomID: "user_decline_" + bidID},
-			},
-		},
+		discordgo.ActionsRow{Components: buttons},
 	}
 
 	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
@@ -328,7 +344,7 @@ func (r *Ranking) HandleBetCinemaCommand(s *discordgo.Session, m *discordgo.Mess
 		log.Printf("Недостаточно кредитов для пользователя %s: баланс %d, требуется %d", m.Author.ID, balance, amount)
 		embed := &discordgo.MessageEmbed{
 			Title:       "🎥 Киноаукцион",
-			Description: fmt.Sprintf("❌ Недостаточно кредитов. Ваш баланс: %d", balance),
+			Description: fmt.Sprintf("❌ Недостаточно кредитов. Ваш баланс: %s", formatCredits(balance)),
 			Color:       0xFF0000,
 			Footer:      &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp:   time.Now().Format(time.RFC3339),
@@ -384,7 +400,7 @@ func (r *Ranking) HandleBetCinemaCommand(s *discordgo.Session, m *discordgo.Mess
 		Color:       randomColor(),
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Фильм", Value: selectedFilm.Name, Inline: true},
-			{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", amount), Inline: true},
+			{Name: "Сумма", Value: formatCredits(amount), Inline: true},
 			{Name: "Пользователь", Value: fmt.Sprintf("<@%s>", m.Author.ID), Inline: true},
 		},
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
@@ -526,18 +542,46 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if action == "user_existing" {
+		// Вместо нового фильма ставим на похожий из списка, дальше — обычное подтверждение
+		existing := -1
+		for idx, option := range r.cinemaOptions {
+			if bid.Suggested != "" && option.Name == bid.Suggested {
+				existing = idx
+				break
+			}
+		}
+		if !bid.IsNew || existing == -1 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ Этого фильма уже нет в списке — подтверди новую ставку или отмени её",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		log.Printf("Ставка %s перенесена с нового фильма %q на %q", bidID, bid.Name, bid.Suggested)
+		bid.IsNew = false
+		bid.Index = existing
+		bid.Name = bid.Suggested
+		action = "user_confirm"
+	}
+
 	if action == "user_confirm" {
-		// Проверка баланса
-		balance := r.GetRating(bid.UserID)
-		if balance < bid.Amount {
+		// Замораживаем кредиты до решения админов
+		holdID, err := r.holdLocked(bid.UserID, bid.Amount, "cinema", 0)
+		if err != nil {
+			log.Printf("Не удалось заморозить ставку %s: %v", bidID, err)
+			balance := r.GetRating(bid.UserID)
 			r.redis.Del(r.ctx, "pending_bid:"+bidID)
 			userEmbed := &discordgo.MessageEmbed{
 				Title:       "🎥 Киноаукцион",
-				Description: fmt.Sprintf("❌ Недостаточно кредитов для подтверждения. Ваш баланс: %d", balance),
+				Description: fmt.Sprintf("❌ Недостаточно кредитов для подтверждения. Ваш баланс: %s", formatCredits(balance)),
 				Color:       0xFF0000,
 				Fields: []*discordgo.MessageEmbedField{
 					{Name: "Фильм", Value: bid.Name, Inline: true},
-					{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+					{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 				},
 				Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 				Timestamp: time.Now().Format(time.RFC3339),
@@ -553,8 +597,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			return
 		}
 
-		// Замораживаем кредиты
-		r.UpdateRating(bid.UserID, -bid.Amount)
+		bid.HoldID = holdID
 
 		// Уведомляем админов в админ-чате
 		adminTags := ""
@@ -563,11 +606,11 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 		}
 		adminEmbed := &discordgo.MessageEmbed{
 			Title:       "🎥 Новая ставка на киноаукцион",
-			Description: fmt.Sprintf("%s Пришла заявка от <@%s> на фильм \"%s\" %d кредитов", adminTags, bid.UserID, bid.Name, bid.Amount),
+			Description: fmt.Sprintf("%s Пришла заявка от <@%s> на фильм \"%s\" %s", adminTags, bid.UserID, bid.Name, formatCredits(bid.Amount)),
 			Color:       randomColor(),
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Фильм", Value: bid.Name, Inline: true},
-				{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+				{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 				{Name: "Пользователь", Value: fmt.Sprintf("<@%s>", bid.UserID), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
@@ -589,7 +632,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 		})
 		if err != nil {
 			log.Printf("Ошибка отправки сообщения админам: %v", err)
-			r.UpdateRating(bid.UserID, bid.Amount) // Возвращаем кредиты
+			r.releaseLocked(bid.HoldID) // Возвращаем кредиты
 			r.redis.Del(r.ctx, "pending_bid:"+bidID)
 			userEmbed := &discordgo.MessageEmbed{
 				Title:       "🎥 Киноаукцион",
@@ -597,7 +640,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 				Color:       0xFF0000,
 				Fields: []*discordgo.MessageEmbedField{
 					{Name: "Фильм", Value: bid.Name, Inline: true},
-					{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+					{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 				},
 				Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 				Timestamp: time.Now().Format(time.RFC3339),
@@ -618,14 +661,14 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 		bidData, err := json.Marshal(bid)
 		if err != nil {
 			log.Printf("Ошибка сериализации ставки: %v", err)
-			r.UpdateRating(bid.UserID, bid.Amount) // Возвращаем кредиты
+			r.releaseLocked(bid.HoldID) // Возвращаем кредиты
 			r.redis.Del(r.ctx, "pending_bid:"+bidID)
 			return
 		}
 		err = r.redis.Set(r.ctx, "pending_bid:"+bidID, bidData, 0).Err()
 		if err != nil {
 			log.Printf("Ошибка сохранения ставки в Redis: %v", err)
-			r.UpdateRating(bid.UserID, bid.Amount) // Возвращаем кредиты
+			r.releaseLocked(bid.HoldID) // Возвращаем кредиты
 			r.redis.Del(r.ctx, "pending_bid:"+bidID)
 			return
 		}
@@ -636,8 +679,8 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			Color:       0x00FF00,
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Фильм", Value: bid.Name, Inline: true},
-				{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
-				{Name: "Новый баланс", Value: fmt.Sprintf("%d кредитов", r.GetRating(bid.UserID)), Inline: true},
+				{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
+				{Name: "Новый баланс", Value: formatCredits(r.GetRating(bid.UserID)), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -658,7 +701,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			},
 		})
 
-		r.LogCreditOperation(s, fmt.Sprintf("Заморожено %d кредитов у <@%s> за ставку на '%s'", bid.Amount, bid.UserID, bid.Name))
+		r.LogCreditOperation(s, fmt.Sprintf("Заморожено %s у <@%s> за ставку на '%s'", formatCredits(bid.Amount), bid.UserID, bid.Name))
 	} else if action == "user_decline" {
 		r.redis.Del(r.ctx, "pending_bid:"+bidID)
 
@@ -668,7 +711,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			Color:       0xFF0000,
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Фильм", Value: bid.Name, Inline: true},
-				{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+				{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -723,6 +766,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 		}
 
 		r.redis.Del(r.ctx, "pending_bid:"+bidID)
+		r.captureHold(bid.HoldID)
 
 		adminEmbed := &discordgo.MessageEmbed{
 			Title:       "🎥 Киноаукцион",
@@ -730,7 +774,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			Color:       0x00FF00,
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Фильм", Value: bid.Name, Inline: true},
-				{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+				{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 				{Name: "Пользователь", Value: fmt.Sprintf("<@%s>", bid.UserID), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
@@ -754,16 +798,20 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 
 		userEmbed := &discordgo.MessageEmbed{
 			Title:       "🎥 Киноаукцион",
-			Description: fmt.Sprintf("✅ Ваша ставка на '%s' (%d кредитов) принята админами!", bid.Name, bid.Amount),
+			Description: fmt.Sprintf("✅ Ваша ставка на '%s' (%s) принята админами!", bid.Name, formatCredits(bid.Amount)),
 			Color:       0x00FF00,
 			Footer:      &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp:   time.Now().Format(time.RFC3339),
 		}
 		s.ChannelMessageSendEmbed(r.floodChannelID, userEmbed)
 
-		r.LogCreditOperation(s, fmt.Sprintf("Ставка %d кредитов от <@%s> на '%s' принята", bid.Amount, bid.UserID, bid.Name))
+		r.LogCreditOperation(s, fmt.Sprintf("Ставка %s от <@%s> на '%s' принята", formatCredits(bid.Amount), bid.UserID, bid.Name))
 	} else if action == "admin_reject" {
-		r.UpdateRating(bid.UserID, bid.Amount)
+		if bid.HoldID != "" {
+			r.releaseLocked(bid.HoldID)
+		} else {
+			r.UpdateRatingFrom(bid.UserID, bid.Amount, "cinema", "") // ставка, замороженная до удержаний
+		}
 		r.redis.Del(r.ctx, "pending_bid:"+bidID)
 
 		adminEmbed := &discordgo.MessageEmbed{
@@ -772,7 +820,7 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 			Color:       0xFF0000,
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Фильм", Value: bid.Name, Inline: true},
-				{Name: "Сумма", Value: fmt.Sprintf("%d кредитов", bid.Amount), Inline: true},
+				{Name: "Сумма", Value: formatCredits(bid.Amount), Inline: true},
 				{Name: "Пользователь", Value: fmt.Sprintf("<@%s>", bid.UserID), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
@@ -796,17 +844,17 @@ func (r *Ranking) HandleCinemaButton(s *discordgo.Session, i *discordgo.Interact
 
 		userEmbed := &discordgo.MessageEmbed{
 			Title:       "🎥 Киноаукцион",
-			Description: fmt.Sprintf("❌ Ваша ставка на '%s' (%d кредитов) отклонена админами. Кредиты возвращены.", bid.Name, bid.Amount),
+			Description: fmt.Sprintf("❌ Ваша ставка на '%s' (%s) отклонена админами. Кредиты возвращены.", bid.Name, formatCredits(bid.Amount)),
 			Color:       0xFF0000,
 			Fields: []*discordgo.MessageEmbedField{
-				{Name: "Новый баланс", Value: fmt.Sprintf("%d кредитов", r.GetRating(bid.UserID)), Inline: true},
+				{Name: "Новый баланс", Value: formatCredits(r.GetRating(bid.UserID)), Inline: true},
 			},
 			Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 		s.ChannelMessageSendEmbed(r.floodChannelID, userEmbed)
 
-		r.LogCreditOperation(s, fmt.Sprintf("Возвращено %d кредитов <@%s> за отклонённую ставку на '%s'", bid.Amount, bid.UserID, bid.Name))
+		r.LogCreditOperation(s, fmt.Sprintf("Возвращено %s <@%s> за отклонённую ставку на '%s'", formatCredits(bid.Amount), bid.UserID, bid.Name))
 	}
 }
 
@@ -851,7 +899,7 @@ func (r *Ranking) HandleCinemaListCommand(s *discordgo.Session, m *discordgo.Mes
 			medal = "🥉"
 		}
 
-		builder.WriteString(fmt.Sprintf("%s **%d. %s** - `%d кредитов`\n", medal, i+1, filmName, option.Total))
+		builder.WriteString(fmt.Sprintf("%s **%d. %s** - %s\n", medal, i+1, filmName, formatCredits(option.Total)))
 	}
 
 	builder.WriteString("\n📋 **Команды:**\n")
@@ -1140,8 +1188,8 @@ func (r *Ranking) HandleRemoveLowestCommand(s *discordgo.Session, m *discordgo.M
 		option := r.cinemaOptions[index]
 		for userID, amount := range option.Bets {
 			log.Printf("Возврат %d кредитов пользователю %s за фильм '%s'", amount, userID, option.Name)
-			r.UpdateRating(userID, amount)
-			r.LogCreditOperation(s, fmt.Sprintf("Возвращено %d кредитов пользователю <@%s> за удаление фильма '%s'", amount, userID, option.Name))
+			r.UpdateRatingFrom(userID, amount, "cinema", "")
+			r.LogCreditOperation(s, fmt.Sprintf("Возвращено %s пользователю <@%s> за удаление фильма '%s'", formatCredits(amount), userID, option.Name))
 		}
 
 		// Удаляем элемент из слайса
@@ -1320,7 +1368,7 @@ func (r *Ranking) HandleAdjustCinemaCommand(s *discordgo.Session, m *discordgo.M
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Фильм", Value: filmToAdjust.Name, Inline: true},
 			{Name: "Корректировка", Value: adjustmentStr, Inline: true},
-			{Name: "Новая сумма", Value: fmt.Sprintf("%d кредитов", r.cinemaOptions[originalIndex].Total), Inline: true},
+			{Name: "Новая сумма", Value: formatCredits(r.cinemaOptions[originalIndex].Total), Inline: true},
 		},
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
 		Timestamp: time.Now().Format(time.RFC3339),
@@ -1503,7 +1551,7 @@ func (r *Ranking) HandleRemoveCinemaCommand(s *discordgo.Session, m *discordgo.M
 		Color:       randomColor(),
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Фильм", Value: removedFilm.Name, Inline: true},
-			{Name: "Бывшая сумма", Value: fmt.Sprintf("%d кредитов", removedFilm.Total), Inline: true},
+			{Name: "Бывшая сумма", Value: formatCredits(removedFilm.Total), Inline: true},
 			{Name: "Действие", Value: "Кредиты не возвращены (фильм просмотрен)", Inline: false},
 		},
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
@@ -1515,5 +1563,169 @@ func (r *Ranking) HandleRemoveCinemaCommand(s *discordgo.Session, m *discordgo.M
 	} else {
 		log.Printf("Сообщение об успешном удалении отправлено")
 	}
+	// Снятый без возврата фильм — просмотренный победитель раунда
+	go r.announceCinemaWinner(s, removedFilm)
 	log.Printf("Завершение обработки !removecinema")
 }
+
+// cinemaOptionByNumber переводит номер из списка (по убыванию суммы, с 1) в индекс r.cinemaOptions.
+// Вызывается под r.mu.
+func (r *Ranking) cinemaOptionByNumber(number int) int {
+	if number < 1 || number > len(r.cinemaOptions) {
+		return -1
+	}
+	sortedOptions := make([]CinemaOption, len(r.cinemaOptions))
+	copy(sortedOptions, r.cinemaOptions)
+	sort.Slice(sortedOptions, func(i, j int) bool {
+		return sortedOptions[i].Total > sortedOptions[j].Total
+	})
+	film := sortedOptions[number-1]
+	for i, option := range r.cinemaOptions {
+		if option.Name == film.Name && option.Total == film.Total {
+			return i
+		}
+	}
+	return -1
+}
+
+// remapPendingCinemaBids переводит ожидающие ставки после удаления варианта from: ставки на него
+// уходят в вариант to (индекс уже после удаления), индексы после from сдвигаются. Вызывается под r.mu.
+func (r *Ranking) remapPendingCinemaBids(from, to int) {
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(r.ctx, cursor, "pending_bid:*", 100).Result()
+		if err != nil {
+			log.Printf("Ошибка сканирования ожидающих ставок: %v", err)
+			return
+		}
+		for _, key := range keys {
+			data, err := r.redis.Get(r.ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			var bid PendingCinemaBid
+			if err := json.Unmarshal([]byte(data), &bid); err != nil || bid.IsNew {
+				continue
+			}
+			switch {
+			case bid.Index == from:
+				bid.Index = to
+			case bid.Index > from:
+				bid.Index--
+			default:
+				continue
+			}
+			updated, err := json.Marshal(bid)
+			if err != nil {
+				continue
+			}
+			if err := r.redis.Set(r.ctx, key, updated, redis.KeepTTL).Err(); err != nil {
+				log.Printf("Ошибка обновления ставки %s после объединения: %v", key, err)
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return
+		}
+	}
+}
+
+// HandleMergeCinemaCommand обрабатывает команду !mergecinema <номер1> <номер2>: вариант №2
+// вливается в вариант №1 вместе со ставками и суммой.
+func (r *Ranking) HandleMergeCinemaCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !mergecinema: %s от %s", command, m.Author.ID)
+
+	sendError := func(description string) {
+		embed := &discordgo.MessageEmbed{
+			Title:       "🎥 Киноаукцион",
+			Description: description,
+			Color:       0xFF0000,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+		if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+			log.Printf("Ошибка отправки сообщения для !mergecinema: %v", err)
+		}
+	}
+
+	if !r.IsAdmin(m.Author.ID) {
+		sendError("❌ Только админы могут объединять фильмы")
+		return
+	}
+
+	args := strings.Fields(command)
+	if len(args) != 3 {
+		sendError("❌ Неверный формат команды\nИспользуй: `/mergecinema <номер1> <номер2>` — №2 вливается в №1")
+		return
+	}
+	first, err1 := strconv.Atoi(args[1])
+	second, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil || first == second {
+		sendError("❌ Укажи два разных номера из `/cinemalist`")
+		return
+	}
+
+	r.mu.Lock()
+	target, source := r.cinemaOptionByNumber(first), r.cinemaOptionByNumber(second)
+	if target == -1 || source == -1 {
+		count := len(r.cinemaOptions)
+		r.mu.Unlock()
+		sendError(fmt.Sprintf("❌ Неверный номер варианта (доступно: 1-%d)", count))
+		return
+	}
+
+	previous := make([]CinemaOption, len(r.cinemaOptions))
+	copy(previous, r.cinemaOptions)
+	into, from := r.cinemaOptions[target], r.cinemaOptions[source]
+	bets := make(map[string]int, len(into.Bets)+len(from.Bets))
+	for userID, amount := range into.Bets {
+		bets[userID] += amount
+	}
+	for userID, amount := range from.Bets {
+		bets[userID] += amount
+	}
+	merged := CinemaOption{Name: into.Name, Total: into.Total + from.Total, Bets: bets}
+
+	options := make([]CinemaOption, 0, len(r.cinemaOptions)-1)
+	newTarget := target
+	for i, option := range r.cinemaOptions {
+		switch {
+		case i == source:
+			continue
+		case i == target:
+			newTarget = len(options)
+			options = append(options, merged)
+		default:
+			options = append(options, option)
+		}
+	}
+	r.cinemaOptions = options
+	if err := r.SaveCinemaOptions(); err != nil {
+		r.cinemaOptions = previous
+		r.mu.Unlock()
+		log.Printf("Ошибка сохранения cinemaOptions: %v", err)
+		sendError("❌ Ошибка при сохранении данных аукциона")
+		return
+	}
+	r.remapPendingCinemaBids(source, newTarget)
+	r.mu.Unlock()
+
+	log.Printf("Фильмы объединены: %s (%d) -> %s (%d), итог %d, ставок: %d", from.Name, from.Total, into.Name, into.Total, merged.Total, len(bets))
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎥 Киноаукцион",
+		Description: fmt.Sprintf("🔗 Фильм #%d объединён с #%d", second, first),
+		Color:       randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Остался", Value: merged.Name, Inline: true},
+			{Name: "Влит", Value: from.Name, Inline: true},
+			{Name: "Сумма", Value: fmt.Sprintf("%s + %s = %s", formatCredits(into.Total), formatCredits(from.Total), formatCredits(merged.Total)), Inline: false},
+			{Name: "Участников", Value: strconv.Itoa(len(bets)), Inline: true},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+		log.Printf("Ошибка отправки сообщения для !mergecinema: %v", err)
+	}
+	r.LogCreditOperation(s, fmt.Sprintf("🔗 %s объединил кино-варианты: **%s** (%s) влит в **%s**, итог %s", r.publicMention(m.Author.ID), from.Name, formatCredits(from.Total), merged.Name, formatCredits(merged.Total)))
+}
@@ -0,0 +1,83 @@
+package ranking
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cinemaSimilarityThreshold — минимальная похожесть названий (0..1), при которой новый фильм
+// считается возможным дубликатом уже предложенного.
+const cinemaSimilarityThreshold = 0.75
+
+// normalizeFilmName приводит название к виду для сравнения: нижний регистр, ё→е,
+// без знаков препинания и лишних пробелов.
+func normalizeFilmName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, ch := range strings.ToLower(name) {
+		switch {
+		case ch == 'ё':
+			ch = 'е'
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch):
+		default:
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// levenshtein возвращает редакционное расстояние между строками в рунах.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// filmNameSimilarity оценивает похожесть двух названий от 0 до 1. Название, целиком
+// входящее в другое («Дюна» и «Дюна 2021»), считается почти совпадающим.
+func filmNameSimilarity(a, b string) float64 {
+	a, b = normalizeFilmName(a), normalizeFilmName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if min(len(ra), len(rb)) >= 4 && (strings.Contains(a, b) || strings.Contains(b, a)) {
+		return 0.9
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+// findSimilarCinemaOption ищет среди вариантов аукциона самый похожий на name.
+// Возвращает индекс в r.cinemaOptions или -1. Вызывается под r.mu.
+func (r *Ranking) findSimilarCinemaOption(name string) int {
+	best, bestScore := -1, cinemaSimilarityThreshold
+	for i, option := range r.cinemaOptions {
+		if score := filmNameSimilarity(name, option.Name); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
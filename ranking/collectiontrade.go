@@ -0,0 +1,197 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// collectionTrade — ожидающая подтверждения передача коллекции.
+type collectionTrade struct {
+	TargetID   string
+	Collection string
+	Items      map[string]int // nftID -> количество
+	Value      int
+	MessageID  string
+}
+
+// collectionTradeKey возвращает ключ ожидающей передачи коллекции пользователя.
+func collectionTradeKey(userID string) string {
+	return "trade_collection:" + userID
+}
+
+// HandleTradeCollectionCommand обрабатывает команду !trade_collection @user <коллекция>.
+func (r *Ranking) HandleTradeCollectionCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !trade_collection: %s от %s", command, m.Author.ID)
+
+	if len(m.Mentions) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Упомяните одного пользователя**: /trade_collection @user <коллекция>")
+		return
+	}
+	targetID := m.Mentions[0].ID
+	if targetID == m.Author.ID {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Нельзя передать NFT себе.**")
+		return
+	}
+	parts := strings.Fields(command)
+	if len(parts) < 3 {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Использование**: /trade_collection @user <коллекция>")
+		return
+	}
+	collection := strings.Join(parts[2:], " ")
+
+	inv := r.GetUserInventory(m.Author.ID)
+	items := make(map[string]int)
+	var lines []string
+	value, total := 0, 0
+	collectionName := collection
+	for nftID, count := range inv {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok || count <= 0 || !strings.EqualFold(nft.Collection, collection) {
+			continue
+		}
+		collectionName = nft.Collection
+		items[nftID] = count
+		value += nft.Price * count
+		total += count
+		lines = append(lines, fmt.Sprintf("%s **%s** (ID: %s) x%d — 💰 %d", RarityEmojis[nft.Rarity], nft.Name, nftID, count, nft.Price*count))
+	}
+	if len(items) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **У вас нет NFT из коллекции «%s».**", collection))
+		return
+	}
+	sort.Strings(lines)
+
+	description := fmt.Sprintf("Вы хотите передать <@%s> **все** NFT коллекции **%s** (%d шт.)?\n\n%s\n\n**Общая оценка**: %s", targetID, collectionName, total, strings.Join(lines, "\n"), formatCredits(value))
+	embed := &discordgo.MessageEmbed{
+		Title:       "🤝 **Передача коллекции** ══════",
+		Description: truncate(description, 4000),
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Подтверждение действует 5 минут | Славь Императора! 👑"},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "✅ Передать", Style: discordgo.SuccessButton, CustomID: "trade_collection_confirm_" + m.Author.ID},
+				discordgo.Button{Label: "❌ Отменить", Style: discordgo.DangerButton, CustomID: "trade_collection_cancel_" + m.Author.ID},
+			},
+		},
+	}
+	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components})
+	if err != nil {
+		log.Printf("Не удалось отправить подтверждение передачи коллекции: %v", err)
+		return
+	}
+
+	data, _ := json.Marshal(collectionTrade{TargetID: targetID, Collection: collectionName, Items: items, Value: value, MessageID: msg.ID})
+	if err := r.redis.Set(r.ctx, collectionTradeKey(m.Author.ID), data, 5*time.Minute).Err(); err != nil {
+		log.Printf("Не удалось сохранить передачу коллекции %s: %v", m.Author.ID, err)
+	}
+}
+
+// HandleTradeCollectionConfirm выполняет подтверждённую передачу коллекции одной операцией.
+func (r *Ranking) HandleTradeCollectionConfirm(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := strings.TrimPrefix(i.MessageComponentData().CustomID, "trade_collection_confirm_")
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	if userID != i.Member.User.ID {
+		ephemeral("❌ **Кнопка не для вас! Император гневен! 👑**")
+		return
+	}
+
+	data, err := r.redis.GetDel(r.ctx, collectionTradeKey(userID)).Bytes()
+	if err != nil {
+		ephemeral("❌ **Передача устарела. Повторите команду.**")
+		return
+	}
+	var trade collectionTrade
+	if err := json.Unmarshal(data, &trade); err != nil {
+		log.Printf("Не удалось разобрать передачу коллекции %s: %v", userID, err)
+		ephemeral("❌ **Ошибка обработки передачи.**")
+		return
+	}
+
+	total, ok := r.moveCollection(userID, trade)
+	if !ok {
+		ephemeral("❌ **Инвентарь изменился — NFT больше не хватает. Повторите команду.**")
+		return
+	}
+	ledgerID := r.recordNFTMutation("trade_collection", userID, userID, trade.TargetID, trade.Items)
+
+	r.LogCreditOperation(s, fmt.Sprintf("🤝 <@%s> передал коллекцию **%s** (%d NFT, оценка 💰 %d) пользователю <@%s>%s", userID, trade.Collection, total, trade.Value, trade.TargetID, ledgerRef(ledgerID)))
+	log.Printf("Коллекция %s (%d NFT) передана от %s к %s", trade.Collection, total, userID, trade.TargetID)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🤝 **Коллекция передана** ══════",
+		Description: fmt.Sprintf("✅ **Передано** %d NFT коллекции **%s** пользователю <@%s>!\n**Общая оценка**: %s", total, trade.Collection, trade.TargetID, formatCredits(trade.Value)),
+		Color:       0x00FF00,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Славь Императора! 👑"},
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// HandleTradeCollectionCancel отменяет передачу коллекции.
+func (r *Ranking) HandleTradeCollectionCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := strings.TrimPrefix(i.MessageComponentData().CustomID, "trade_collection_cancel_")
+	if userID != i.Member.User.ID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ **Кнопка не для вас! Император гневен! 👑**", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	r.redis.Del(r.ctx, collectionTradeKey(userID))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🤝 **Передача отменена** ══════",
+				Description: "❌ Передача коллекции отменена.",
+				Color:       0xFF0000,
+			}},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// moveCollection переносит NFT передачи в инвентарь получателя и возвращает их число.
+// ok == false — у отправителя больше не хватает NFT, ничего не перенесено.
+func (r *Ranking) moveCollection(userID string, trade collectionTrade) (total int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv := r.GetUserInventory(userID)
+	for nftID, count := range trade.Items {
+		if inv[nftID] < count {
+			return 0, false
+		}
+	}
+	targetInv := r.GetUserInventory(trade.TargetID)
+	for nftID, count := range trade.Items {
+		inv[nftID] -= count
+		if inv[nftID] == 0 {
+			delete(inv, nftID)
+		}
+		targetInv[nftID] += count
+		total += count
+	}
+	r.SaveUserInventory(userID, inv)
+	r.SaveUserInventory(trade.TargetID, targetInv)
+	return total, true
+}
@@ -0,0 +1,147 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Пакет возвращения для игроков, проигравших всё после серии поражений: немного кредитов и кейс
+// раз в неделю, чтобы проигравшие оставались в игре.
+const comebackCooldown = 7 * 24 * time.Hour
+
+// comebackStreakKey возвращает ключ текущей серии поражений пользователя.
+func comebackStreakKey(userID string) string {
+	return "comeback:streak:" + userID
+}
+
+// comebackClaimedKey возвращает ключ недельного кулдауна пакета возвращения.
+func comebackClaimedKey(userID string) string {
+	return "comeback:claimed:" + userID
+}
+
+// comebackOfferedKey возвращает ключ, не дающий предлагать пакет после каждого проигрыша.
+func comebackOfferedKey(userID string) string {
+	return "comeback:offered:" + userID
+}
+
+// ComebackCredits возвращает сумму кредитов в пакете возвращения.
+func (r *Ranking) ComebackCredits() int {
+	return r.GetIntSetting("comeback_credits", envInt("COMEBACK_CREDITS", 50))
+}
+
+// ComebackMinStreak возвращает длину серии поражений, после которой предлагается пакет.
+func (r *Ranking) ComebackMinStreak() int {
+	return r.GetIntSetting("comeback_min_streak", envInt("COMEBACK_MIN_STREAK", 3))
+}
+
+// trackGameResult обновляет серию поражений и предлагает пакет возвращения обанкротившемуся игроку.
+// Вызывается из обновления статистики игр, поэтому работает в отдельной горутине.
+func (r *Ranking) trackGameResult(userID string, won bool) {
+	if won {
+		r.redis.Del(r.ctx, comebackStreakKey(userID))
+		return
+	}
+	streak, err := r.redis.Incr(r.ctx, comebackStreakKey(userID)).Result()
+	if err != nil {
+		log.Printf("Не удалось обновить серию поражений %s: %v", userID, err)
+		return
+	}
+	r.redis.Expire(r.ctx, comebackStreakKey(userID), comebackCooldown)
+
+	if int(streak) < r.ComebackMinStreak() || r.GetRating(userID) > 0 || r.floodChannelID == "" {
+		return
+	}
+	if claimed, _ := r.redis.Exists(r.ctx, comebackClaimedKey(userID)).Result(); claimed > 0 {
+		return
+	}
+	if ok, _ := r.redis.SetNX(r.ctx, comebackOfferedKey(userID), 1, 24*time.Hour).Result(); !ok {
+		return
+	}
+
+	s, err := r.Session()
+	if err != nil {
+		log.Printf("Не удалось получить сессию для пакета возвращения %s: %v", userID, err)
+		return
+	}
+	offer := formatCredits(r.ComebackCredits())
+	if kase, ok := r.comebackCase(); ok {
+		offer += fmt.Sprintf(" + 📦 %s", kase.Name)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🆘 Пакет возвращения",
+		Description: fmt.Sprintf("<@%s>, %d поражений подряд и пустой кошелёк... Император милостив!\n\nЗабери раз в неделю: %s", userID, streak, offer),
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Славь Императора и возвращайся в игру! 👑"},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "🎁 Забрать", Style: discordgo.SuccessButton, CustomID: "comeback_claim_" + userID},
+			},
+		},
+	}
+	if _, err := s.ChannelMessageSendComplex(r.floodChannelID, &discordgo.MessageSend{Embed: embed, Components: components}); err != nil {
+		log.Printf("Не удалось предложить пакет возвращения %s: %v", userID, err)
+	}
+}
+
+// HandleComebackClaim выдаёт пакет возвращения по кнопке.
+func (r *Ranking) HandleComebackClaim(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := strings.TrimPrefix(i.MessageComponentData().CustomID, "comeback_claim_")
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	if userID != i.Member.User.ID {
+		ephemeral("❌ **Кнопка не для вас! Император гневен! 👑**")
+		return
+	}
+	if r.GetRating(userID) > 0 {
+		ephemeral("❌ Пакет возвращения только для тех, у кого 0 кредитов!")
+		return
+	}
+	ok, err := r.redis.SetNX(r.ctx, comebackClaimedKey(userID), time.Now().Unix(), comebackCooldown).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить пакет возвращения %s: %v", userID, err)
+		ephemeral("❌ Ошибка! Попробуйте позже.")
+		return
+	}
+	if !ok {
+		ttl, _ := r.redis.TTL(r.ctx, comebackClaimedKey(userID)).Result()
+		ephemeral(fmt.Sprintf("⏳ Пакет уже получен на этой неделе. Следующий <t:%d:R>.", time.Now().Add(ttl).Unix()))
+		return
+	}
+
+	credits := r.ComebackCredits()
+	r.UpdateRatingFrom(userID, credits, "comeback", "")
+	r.redis.Del(r.ctx, comebackStreakKey(userID))
+	text := fmt.Sprintf("🎁 <@%s> получил пакет возвращения: %s", userID, formatCredits(credits))
+	if kase, ok := r.comebackCase(); ok {
+		inv := r.Kki.GetUserCaseInventory(r, userID)
+		inv[kase.ID]++
+		if err := r.Kki.SaveUserCaseInventory(r, userID, inv); err != nil {
+			log.Printf("Не удалось выдать кейс пакета возвращения %s: %v", userID, err)
+		} else {
+			text += fmt.Sprintf(" + 📦 %s (`/open_case %s`)", kase.Name, kase.ID)
+		}
+	}
+	r.LogCreditOperation(s, text)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🎁 Пакет возвращения получен",
+				Description: text + "\n\nУдачи, Император верит в тебя! 👑",
+				Color:       0x00FF00,
+			}},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
@@ -2,6 +2,7 @@ package ranking
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sort" // Added missing import
@@ -39,7 +40,13 @@ func (r *Ranking) HandleChinaCommand(s *discordgo.Session, m *discordgo.MessageC
 	}
 
 	userRating := r.GetRating(userID)
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("💰 %s, баланс: **%d** соцкредитов! 🇨🇳", username, userRating))
+	jade := r.jadeBalanceSuffix(userID)
+	username = r.userFlair(userID) + username
+	if held := r.HeldCredits(userID); held > 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("💰 %s, доступно: **%s** / на удержании: **%s** 🔒%s 🇨🇳", username, formatCredits(userRating), formatCredits(held), jade))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("💰 %s, баланс: **%s**!%s 🇨🇳", username, formatCredits(userRating), jade))
 }
 
 // isValidUserID проверяет, является ли строка валидным ID пользователя.
@@ -51,24 +58,123 @@ func isValidUserID(id string) bool {
 	return err == nil
 }
 
+// HandleTransferCommand обрабатывает команду !transfer @id <сумма> <причина>.
 func (r *Ranking) HandleTransferCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
 	log.Printf("Обработка перевода: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) < 4 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/transfer @id <сумма> <причина>`")
+		return
+	}
+
+	targetID := strings.TrimPrefix(parts[1], "<@")
+	targetID = strings.TrimSuffix(targetID, ">")
+	targetID = strings.TrimPrefix(targetID, "!")
+	if !isValidUserID(targetID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Укажи получателя упоминанием или ID!")
+		return
+	}
+
+	amount, err := strconv.Atoi(parts[2])
+	if err != nil || amount <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Сумма должна быть положительным числом! 💸")
+		return
+	}
+	reason := strings.Join(parts[3:], " ")
+
+	if err := r.requestTransferConfirmation(s, m.ChannelID, m.Author.ID, targetID, amount, reason); err != nil {
+		var limitErr *TransferLimitError
+		if errors.As(err, &limitErr) {
+			s.ChannelMessageSendEmbed(m.ChannelID, limitErr.Embed())
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+	}
+}
+
+// transferConfirmation формирует сообщение об успешном переводе с учётом налога.
+func transferConfirmation(fromID, toID string, amount, tax int, reason string) string {
+	text := fmt.Sprintf("✅ <@%s> перевёл **%s** <@%s>!", fromID, formatCredits(amount-tax), toID)
+	if tax > 0 {
+		text += fmt.Sprintf("\n🏛️ Налог на перевод: %s", formatCredits(tax))
+	}
+	return text + "\n📝 Причина: " + reason
 }
 
-// HandleTopCommand обрабатывает команду !top.
-func (r *Ranking) HandleTopCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	log.Printf("Обработка !top от %s", m.Author.ID)
-	topUsers := r.GetTop5()
-	if len(topUsers) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "🏆 Пока нет лидеров! Будь первым! 😎")
+// transferCredits переводит кредиты от одного пользователя другому и пишет перевод в лог.
+// С суммы удерживается налог (см. transferTax); получатель получает сумму за вычетом налога.
+// Перевод учитывается в лимитах отправителя (см. reserveTransferLimits).
+// Возвращает удержанный налог. Текст ошибки предназначен для пользователя.
+func (r *Ranking) transferCredits(s *discordgo.Session, fromID, toID string, amount int, reason string) (int, error) {
+	if fromID == toID {
+		return 0, fmt.Errorf("❌ Нельзя перевести кредиты самому себе!")
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("❌ Сумма должна быть положительным числом! 💸")
+	}
+	if err := r.reserveTransferLimits(fromID, amount); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	balance := r.GetRating(fromID)
+	if balance < amount {
+		r.mu.Unlock()
+		r.releaseTransferLimits(fromID, amount)
+		return 0, fmt.Errorf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(balance))
+	}
+	tax := r.transferTax(amount)
+	r.UpdateRatingFrom(fromID, -(amount - tax), "transfer", toID)
+	if tax > 0 {
+		r.UpdateRatingFrom(fromID, -tax, "transfer_tax", "")
+		r.collectTransferTax(tax)
+	}
+	r.UpdateRatingFrom(toID, amount-tax, "transfer", fromID)
+	r.mu.Unlock()
+
+	log.Printf("Перевод %d кредитов от %s к %s, налог %d (причина: %s)", amount, fromID, toID, tax, reason)
+	text := fmt.Sprintf("<@%s> перевёл %s <@%s>%s", fromID, formatCredits(amount-tax), toID, formatReason(reason))
+	if tax > 0 {
+		text += fmt.Sprintf(" · налог %s (%s)", formatCredits(tax), r.transferTaxDestination())
+	}
+	r.LogCreditOperation(s, text)
+	return tax, nil
+}
+
+// HandleTopCommand обрабатывает команду !top [rating|voice|duels|gamblers|collectors|nft] [страница] [size:N].
+func (r *Ranking) HandleTopCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !top: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		topUsers := r.GetTop5()
+		if len(topUsers) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "🏆 Пока нет лидеров! Будь первым! 😎")
+			return
+		}
+		response := "🏆 **Топ-5 пользователей:**\n"
+		for i, user := range topUsers {
+			response += fmt.Sprintf("%d. %s — %s\n", i+1, r.publicMention(user.ID), formatCredits(user.Rating))
+		}
+		s.ChannelMessageSend(m.ChannelID, response)
 		return
 	}
 
-	response := "🏆 **Топ-5 пользователей:**\n"
-	for i, user := range topUsers {
-		response += fmt.Sprintf("%d. <@%s> — %d кредитов\n", i+1, user.ID, user.Rating)
+	sortBy, page, size, ok := parseTopArgs(parts[1:])
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Используй: `/top [%s] [страница] [size:1-%d]`", strings.Join(topSortOrder, "|"), topMaxPageSize))
+		return
+	}
+	embed, components, err := r.topPage(sortBy, size, page-1)
+	if err != nil {
+		log.Printf("Не удалось получить топ пользователей из Redis: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка получения топа! Попробуйте позже.")
+		return
+	}
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components}); err != nil {
+		log.Printf("Не удалось отправить топ: %v", err)
 	}
-	s.ChannelMessageSend(m.ChannelID, response)
 }
 
 // getUsername получает имя пользователя по ID.
@@ -134,16 +240,16 @@ func (r *Ranking) HandleAdminCommand(s *discordgo.Session, m *discordgo.MessageC
 		reason = strings.Join(parts[3:], " ")
 	}
 
-	r.UpdateRating(targetID, amount)
+	r.UpdateRatingFrom(targetID, amount, "admin", m.Author.ID)
 	targetUsername, err := getUsername(s, targetID)
 	if err != nil {
 		targetUsername = "<@" + targetID + ">"
 	}
 	var msg string
 	if amount >= 0 {
-		msg = fmt.Sprintf("✅ %s получил %d соцкредитов от админа! 🎉", targetUsername, amount)
+		msg = fmt.Sprintf("✅ %s получил %s от админа! 🎉", targetUsername, formatCredits(amount))
 	} else {
-		msg = fmt.Sprintf("✅ У %s забрано %d соцкредитов админом! 🔽", targetUsername, -amount)
+		msg = fmt.Sprintf("✅ У %s забрано %s админом! 🔽", targetUsername, formatCredits(-amount))
 	}
 	if reason != "" {
 		msg += fmt.Sprintf("\n📝 Причина: %s", reason)
@@ -206,18 +312,18 @@ func (r *Ranking) HandleAdminMassCommand(s *discordgo.Session, m *discordgo.Mess
 		}
 		switch operation[0] {
 		case '+':
-			r.UpdateRating(userID, amount)
-			response += fmt.Sprintf("%s: +%d кредитов\n", username, amount)
-			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> добавил %d соцкредитов %s%s", m.Author.ID, amount, username, formatReason(reason)))
+			r.UpdateRatingFrom(userID, amount, "admin", m.Author.ID)
+			response += fmt.Sprintf("%s: +%s\n", username, formatCredits(amount))
+			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> добавил %s %s%s", m.Author.ID, formatCredits(amount), username, formatReason(reason)))
 		case '-':
-			r.UpdateRating(userID, -amount)
-			response += fmt.Sprintf("%s: -%d кредитов\n", username, amount)
-			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> удалил %d соцкредитов у %s%s", m.Author.ID, amount, username, formatReason(reason)))
+			r.UpdateRatingFrom(userID, -amount, "admin", m.Author.ID)
+			response += fmt.Sprintf("%s: -%s\n", username, formatCredits(amount))
+			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> удалил %s у %s%s", m.Author.ID, formatCredits(amount), username, formatReason(reason)))
 		case '=':
 			currentRating := r.GetRating(userID)
-			r.UpdateRating(userID, amount-currentRating)
-			response += fmt.Sprintf("%s: установлено %d кредитов\n", username, amount)
-			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> установил %d соцкредитов для %s%s", m.Author.ID, amount, username, formatReason(reason)))
+			r.UpdateRatingFrom(userID, amount-currentRating, "admin", m.Author.ID)
+			response += fmt.Sprintf("%s: установлено %s\n", username, formatCredits(amount))
+			r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> установил %s для %s%s", m.Author.ID, formatCredits(amount), username, formatReason(reason)))
 		}
 	}
 	if reason != "" {
@@ -277,7 +383,7 @@ func (r *Ranking) HandleStatsCommand(s *discordgo.Session, m *discordgo.MessageC
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("📊 Статистика %s", targetUsername),
+		Title:       fmt.Sprintf("📊 Статистика %s%s", r.userFlair(targetID), targetUsername),
 		Description: "Твои достижения в мире соцкредитов! 🌟",
 		Color:       0xFFD700, // Золотой цвет
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
@@ -286,7 +392,7 @@ func (r *Ranking) HandleStatsCommand(s *discordgo.Session, m *discordgo.MessageC
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:   "💰 Баланс",
-				Value:  fmt.Sprintf("**%d** соцкредитов", user.Rating),
+				Value:  fmt.Sprintf("**%s**", formatCredits(user.Rating)),
 				Inline: false,
 			},
 			{
@@ -301,7 +407,7 @@ func (r *Ranking) HandleStatsCommand(s *discordgo.Session, m *discordgo.MessageC
 			},
 			{
 				Name:   "♠️ Blackjack",
-				Value:  fmt.Sprintf("Сыграно: **%d**\nПобед: **%d**", user.BJPlayed, user.BJWon),
+				Value:  fmt.Sprintf("Сыграно: **%d**\nПобед: **%d**\nСдач: **%d**", user.BJPlayed, user.BJWon, user.BJSurrender),
 				Inline: true,
 			},
 			{
@@ -315,51 +421,12 @@ func (r *Ranking) HandleStatsCommand(s *discordgo.Session, m *discordgo.MessageC
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
-	s.ChannelMessageSendEmbed(m.ChannelID, embed)
-}
-
-// HandleChelpCommand обрабатывает команду !chelp.
-func (r *Ranking) HandleChelpCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	log.Printf("Обработка !chelp от %s", m.Author.ID)
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "📜 Руководство по ChinaBot 🇨🇳",
-		Description: "Добро пожаловать в мир соцкредитов! Вот команды, которые помогут тебе покорить рейтинг! 🚀",
-		Color:       0xFFD700, // Золотой цвет
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: "https://i.imgur.com/your-bot-icon.png", // Замени на иконку бота
-		},
-		Fields: []*discordgo.MessageEmbedField{
-			{Name: "💰 /china [@id]", Value: "Узнай свой баланс или баланс другого игрока.", Inline: false},
-			{Name: "🏆 /top", Value: "Посмотри топ-5 пользователей по кредитам.", Inline: false},
-			{Name: "📊 /stats", Value: "Проверь свою статистику: кредиты, игры, время в голосовых каналах.", Inline: false},
-			{Name: "📊 /adminstats @id <игра> <поле> <значение>", Value: "Измените статистику игрока (только админы).", Inline: false},
-			{Name: "📜 /transfer @id <сумма> <причина>", Value: "Передать кредиты другому", Inline: false},
-			{Name: "📝 /cpoll Вопрос [Вариант1] [Вариант2] ...", Value: "Создай опрос (только админы).", Inline: false},
-			{Name: "💸 /dep <ID_опроса> <номер_варианта> <сумма>", Value: "Поставь кредиты на вариант в опросе.", Inline: false},
-			{Name: "🔒 /closedep <ID_опроса> <номер>", Value: "Закрой опрос и распредели выигрыши (только админы).", Inline: false},
-			{Name: "📋 /polls", Value: "Посмотри активные опросы.", Inline: false},
-			{Name: "🎰 /rb", Value: "Начни игру в Красный-Чёрный.", Inline: false},
-			{Name: "🔴⚫ /rb <red/black> <сумма>", Value: "Сделай ставку в Красный-Чёрный.", Inline: false},
-			{Name: "♠️ /blackjack", Value: "Начни игру в Блэкджек.", Inline: false},
-			{Name: "🎲 /blackjack <сумма>", Value: "Сделай ставку в Блэкджеке.", Inline: false},
-			{Name: "⚔️ /duel <сумма>", Value: "Вызови любого на дуэль с указанной ставкой.", Inline: false},
-			{Name: "🎁 /admin @id <сумма> [причина]", Value: "Начисли или забери кредиты у пользователя (только админы).", Inline: false},
-			{Name: "⚙️ /adminmass <+/-/=сумма> @id1 @id2 ... [причина]", Value: "Массовое изменение рейтинга (только админы).", Inline: false},
-			{Name: "🚫 /endblackjack @id", Value: "Заверши игру в Блэкджек пользователя (только админы).", Inline: false},
-			{Name: "📜 /chelp", Value: "Покажи это руководство.", Inline: false},
-			{Name: "🎥 /cinema <название> <сумма>", Value: "Предложить новый вариант на киноаукцион.", Inline: false},
-			{Name: "🎥 /betcinema <номер> <сумма>", Value: "Поставить на существующий вариант.", Inline: false},
-			{Name: "📋 /cinemalist", Value: "Посмотреть актуальные варианты.", Inline: false},
-			{Name: "📋 /admincinemalist", Value: "Детальный список вариантов (админы).", Inline: false},
-			{Name: "🗑️ /removelowest <число>", Value: "Удалить <число> самых низких вариантов (админы).", Inline: false},
-			{Name: "⚙️ /adjustcinema <номер> <+/-сумма>", Value: "Корректировать сумму любого кино-варианта (админы).", Inline: false},
-			{Name: "🗑️ /removecinema @id <номер>", Value: "Удалить вариант, предложенный пользователем (админы).", Inline: false},
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Славь Императора и собирай кредиты! 👑 | Бот создан для веселья и рейтингов",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
+	if field := r.streamStatsField(targetID); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+	if showcase := r.Showcase(targetID); len(showcase) > 0 {
+		embed.Fields = append(embed.Fields, r.showcaseStatsField(showcase))
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: r.NFTImageURL(showcase[0])}
 	}
 	s.ChannelMessageSendEmbed(m.ChannelID, embed)
 }
@@ -0,0 +1,146 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Названия пользовательских команд контекстного меню (ПКМ по профилю → Приложения).
+const (
+	ContextMenuBalance  = "Проверить баланс"
+	ContextMenuDuel     = "Вызвать на дуэль"
+	ContextMenuTransfer = "Передать кредиты"
+)
+
+// respondEphemeral отвечает на взаимодействие сообщением, видимым только нажавшему.
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// HandleUserContextCommand обрабатывает команды контекстного меню пользователя.
+func (r *Ranking) HandleUserContextCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	userID := i.Member.User.ID
+	targetID := data.TargetID
+	log.Printf("Обработка контекстной команды «%s» от %s для %s", data.Name, userID, targetID)
+
+	if data.Name == ContextMenuBalance {
+		respondEphemeral(s, i, fmt.Sprintf("💰 Баланс <@%s>: **%s**! 🇨🇳", targetID, formatCredits(r.GetRating(targetID))))
+		return
+	}
+
+	if targetID == userID {
+		respondEphemeral(s, i, "❌ Выбери другого игрока, а не себя!")
+		return
+	}
+	if data.Resolved != nil {
+		if target, ok := data.Resolved.Users[targetID]; ok && target.Bot {
+			respondEphemeral(s, i, "❌ Боты не играют и не принимают кредиты! 🤖")
+			return
+		}
+	}
+
+	switch data.Name {
+	case ContextMenuDuel:
+		r.showContextModal(s, i, "ctx_duel_"+targetID, "⚔️ Вызов на дуэль", []discordgo.TextInput{
+			{CustomID: "amount", Label: "Ставка (кредиты)", Style: discordgo.TextInputShort, Placeholder: "100", Required: true, MaxLength: 9},
+		})
+	case ContextMenuTransfer:
+		r.showContextModal(s, i, "ctx_transfer_"+targetID, "💸 Перевод кредитов", []discordgo.TextInput{
+			{CustomID: "amount", Label: "Сумма", Style: discordgo.TextInputShort, Placeholder: "100", Required: true, MaxLength: 9},
+			{CustomID: "reason", Label: "Причина", Style: discordgo.TextInputShort, Placeholder: "За помощь с рейдом", Required: true, MaxLength: 200},
+		})
+	default:
+		respondEphemeral(s, i, "❌ Неизвестная команда!")
+	}
+}
+
+// showContextModal открывает форму для ввода параметров контекстной команды.
+func (r *Ranking) showContextModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID, title string, inputs []discordgo.TextInput) {
+	rows := make([]discordgo.MessageComponent, 0, len(inputs))
+	for _, input := range inputs {
+		rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{input}})
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{CustomID: customID, Title: title, Components: rows},
+	})
+	if err != nil {
+		log.Printf("Не удалось открыть форму %s: %v", customID, err)
+	}
+}
+
+// modalValues собирает значения полей отправленной формы по их CustomID.
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, row := range data.Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	return values
+}
+
+// HandleContextModalSubmit обрабатывает формы, открытые из контекстного меню пользователя.
+func (r *Ranking) HandleContextModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	userID := i.Member.User.ID
+	values := modalValues(data)
+	log.Printf("Обработка формы %s от %s", data.CustomID, userID)
+
+	amount, err := strconv.Atoi(values["amount"])
+	if err != nil || amount <= 0 {
+		respondEphemeral(s, i, "❌ Сумма должна быть положительным числом! 💸")
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(data.CustomID, "ctx_duel_"):
+		targetID := strings.TrimPrefix(data.CustomID, "ctx_duel_")
+		if err := r.createDuel(s, i.ChannelID, userID, targetID, amount); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("⚔️ Вызов отправлен <@%s>!", targetID))
+	case strings.HasPrefix(data.CustomID, "ctx_transfer_"):
+		targetID := strings.TrimPrefix(data.CustomID, "ctx_transfer_")
+		reason := values["reason"]
+		if r.needsRecipientAccept(amount) {
+			transfer := &PendingTransfer{ID: generateGameID(userID), From: userID, To: targetID, Amount: amount, Reason: reason, Created: time.Now().Unix()}
+			status, err := r.requestRecipientAccept(s, i.ChannelID, transfer)
+			if err != nil {
+				respondEphemeral(s, i, err.Error())
+				return
+			}
+			respondEphemeral(s, i, status)
+			return
+		}
+		tax, err := r.transferCredits(s, userID, targetID, amount, reason)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: transferConfirmation(userID, targetID, amount, tax, reason),
+			},
+		})
+	default:
+		respondEphemeral(s, i, "❌ Неизвестная форма!")
+	}
+}
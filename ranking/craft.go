@@ -0,0 +1,233 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Крафт: дубликаты одной редкости (всё сверх одной копии каждой NFT) переплавляются
+// в случайную NFT следующей редкости. Стоимость рецепта задаётся для исходной редкости:
+// настройка craft_<редкость>, переменная CRAFT_<РЕДКОСТЬ> или значение из craftDefaultCosts.
+// Нулевая стоимость выключает рецепт.
+var craftDefaultCosts = map[string]int{
+	"Common":     10,
+	"Rare":       8,
+	"Super-rare": 6,
+	"Epic":       5,
+	"Nephrite":   4,
+	"Exotic":     3,
+}
+
+// craftSettingName возвращает имя настройки стоимости рецепта для исходной редкости.
+func craftSettingName(rarity string) string {
+	return "craft_" + strings.ReplaceAll(strings.ToLower(rarity), "-", "_")
+}
+
+// CraftCost возвращает, сколько дубликатов редкости rarity нужно на одну NFT следующей редкости.
+func (r *Ranking) CraftCost(rarity string) int {
+	def := envInt(strings.ToUpper(craftSettingName(rarity)), craftDefaultCosts[rarity])
+	return max(r.GetIntSetting(craftSettingName(rarity), def), 0)
+}
+
+// findRarity ищет редкость по названию без учёта регистра.
+func findRarity(name string) (string, bool) {
+	for _, p := range RarityProbabilities {
+		if strings.EqualFold(p.Rarity, name) {
+			return p.Rarity, true
+		}
+	}
+	return "", false
+}
+
+// craftDuplicates возвращает дубликаты редкости в инвентаре: ID -> сколько копий сверх одной.
+func (r *Ranking) craftDuplicates(inv UserInventory, rarity string) (map[string]int, int) {
+	dups := make(map[string]int)
+	total := 0
+	for nftID, count := range inv {
+		if nft, ok := r.Kki.nfts[nftID]; ok && nft.Rarity == rarity && count > 1 {
+			dups[nftID] = count - 1
+			total += count - 1
+		}
+	}
+	return dups, total
+}
+
+// craftPool возвращает NFT, которые могут выпасть при крафте редкости (без невышедших коллекций).
+func (r *Ranking) craftPool(rarity string) []NFT {
+	unreleased := r.unreleasedCollections()
+	var pool []NFT
+	for _, nft := range r.Kki.nfts {
+		if nft.Rarity == rarity && !unreleased[nft.Collection] {
+			pool = append(pool, nft)
+		}
+	}
+	return pool
+}
+
+// craftNFT сжигает cost дубликатов редкости source (сначала самые многочисленные) и выдаёт
+// случайную NFT из pool. Возвращает сожжённые NFT и номер записи журнала о выдаче.
+func (r *Ranking) craftNFT(userID, source string, cost int, pool []NFT) (map[string]int, NFT, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv := r.GetUserInventory(userID)
+	dups, total := r.craftDuplicates(inv, source)
+	if total < cost {
+		return nil, NFT{}, 0, fmt.Errorf("нужно %d дубликатов %s, у тебя %d", cost, source, total)
+	}
+	ids := make([]string, 0, len(dups))
+	for nftID := range dups {
+		ids = append(ids, nftID)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if dups[ids[i]] != dups[ids[j]] {
+			return dups[ids[i]] > dups[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	burned := make(map[string]int)
+	left := cost
+	for _, nftID := range ids {
+		take := min(dups[nftID], left)
+		burned[nftID] = take
+		inv[nftID] -= take
+		left -= take
+		if left == 0 {
+			break
+		}
+	}
+
+	result := pool[rand.Intn(len(pool))]
+	inv[result.ID]++
+	r.SaveUserInventory(userID, inv)
+	r.recordNFTMutation("craft", userID, userID, "", burned)
+	ledgerID := r.recordNFTMutation("craft", userID, "", userID, map[string]int{result.ID: 1})
+	return burned, result, ledgerID, nil
+}
+
+// HandleCraftCommand обрабатывает команду !craft [редкость].
+func (r *Ranking) HandleCraftCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !craft: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendCraftRecipes(s, m)
+		return
+	}
+	target, ok := findRarity(strings.Join(parts[1:], "-"))
+	if !ok || rarityRank(target) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/craft <редкость>` — редкость, которую хочешь получить. Рецепты: `/craft`")
+		return
+	}
+	source := RarityProbabilities[rarityRank(target)-1].Rarity
+	cost := r.CraftCost(source)
+	if cost <= 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Крафт %s сейчас отключён.", target))
+		return
+	}
+	pool := r.craftPool(target)
+	if len(pool) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ NFT редкости %s пока нет — крафтить нечего.", target))
+		return
+	}
+	burned, result, ledgerID, err := r.craftNFT(m.Author.ID, source, cost, pool)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Крафт невозможен: "+err.Error())
+		return
+	}
+	log.Printf("Крафт: %s переплавил %d x %s в %s (%s)", m.Author.ID, cost, source, result.ID, result.Rarity)
+
+	msg, err := s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("⚒️ **Переплавляем %d x %s %s...**", cost, RarityEmojis[source], source),
+		Color:       RarityColors[source],
+	})
+	go func() {
+		if err == nil {
+			for _, step := range []string{"🔥 Плавим...", "⚒️ Куём...", "✨ Остужаем..."} {
+				time.Sleep(700 * time.Millisecond)
+				s.ChannelMessageEditEmbed(m.ChannelID, msg.ID, &discordgo.MessageEmbed{Description: step, Color: RarityColors[target]})
+			}
+			time.Sleep(700 * time.Millisecond)
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("⚒️ **Скрафчено**: %s **%s**", RarityEmojis[result.Rarity], result.Name),
+			Description: fmt.Sprintf("**ID**: %s\n**Редкость**: %s\n**Коллекция**: %s\n**Цена**: 💰 %d\n\n🔥 Переплавлено: %s%s", result.ID, result.Rarity, result.Collection, result.Price, r.describeNFTItems(burned), ledgerRef(ledgerID)),
+			Color:       RarityColors[result.Rarity],
+			Image:       &discordgo.MessageEmbedImage{URL: r.NFTImageURL(result)},
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Мастер: %s | Славь Императора! 👑", m.Author.Username)},
+		}
+		if err == nil {
+			s.ChannelMessageEditEmbed(m.ChannelID, msg.ID, embed)
+		} else {
+			s.ChannelMessageSendEmbed(m.ChannelID, embed)
+		}
+		r.LogCreditOperation(s, fmt.Sprintf("⚒️ %s скрафтил %s **%s** (%s) из %d x %s", r.publicMention(m.Author.ID), RarityEmojis[result.Rarity], result.Name, result.ID, cost, source))
+	}()
+}
+
+// sendCraftRecipes отправляет список рецептов и число дубликатов игрока.
+func (r *Ranking) sendCraftRecipes(s *discordgo.Session, m *discordgo.MessageCreate) {
+	inv := r.GetUserInventory(m.Author.ID)
+	var lines []string
+	for i, p := range RarityProbabilities[:len(RarityProbabilities)-1] {
+		target := RarityProbabilities[i+1].Rarity
+		cost := r.CraftCost(p.Rarity)
+		if cost <= 0 {
+			continue
+		}
+		_, have := r.craftDuplicates(inv, p.Rarity)
+		mark := "⬜"
+		if have >= cost {
+			mark = "✅"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d x %s %s → 1 x %s %s · `/craft %s` (дубликатов: %d)",
+			mark, cost, RarityEmojis[p.Rarity], p.Rarity, RarityEmojis[target], target, strings.ToLower(target), have))
+	}
+	description := "Рецепты отключены."
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Title:       "⚒️ Крафт",
+		Description: description,
+		Color:       0xFF8C00,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Переплавляются только дубликаты — одна копия каждой NFT остаётся"},
+	})
+}
+
+// HandleCraftAdminCommand обрабатывает команду !a_craft <редкость> <кол-во>.
+func (r *Ranking) HandleCraftAdminCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_craft: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_craft <исходная редкость> <кол-во>` (0 — отключить рецепт)")
+		return
+	}
+	rarity, ok := findRarity(parts[1])
+	if !ok || rarityRank(rarity) == len(RarityProbabilities)-1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Неизвестная редкость или из неё нечего крафтить.")
+		return
+	}
+	cost, err := strconv.Atoi(parts[2])
+	if err != nil || cost < 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Количество должно быть неотрицательным числом!")
+		return
+	}
+	if err := r.SetIntSetting(craftSettingName(rarity), cost); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	if cost == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Крафт из %s отключён.", rarity))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Рецепт: %d x %s → 1 x %s.", cost, rarity, RarityProbabilities[rarityRank(rarity)+1].Rarity))
+}
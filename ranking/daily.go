@@ -0,0 +1,89 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ежедневная награда: за каждый день подряд награда растёт, пропуск дня сбрасывает серию.
+// Состояние хранится в хэше daily:<userID> (поля last — дата последней награды, streak — серия).
+
+// dailyKey возвращает ключ ежедневной награды пользователя.
+func dailyKey(userID string) string {
+	return "daily:" + userID
+}
+
+// DailyBase возвращает базовую ежедневную награду.
+func (r *Ranking) DailyBase() int {
+	return r.GetIntSetting("daily_base", envInt("DAILY_BASE", 20))
+}
+
+// DailyStreakBonusPercent возвращает прибавку к награде за каждый день серии.
+func (r *Ranking) DailyStreakBonusPercent() int {
+	return r.GetIntSetting("daily_streak_bonus_percent", envInt("DAILY_STREAK_BONUS_PERCENT", 25))
+}
+
+// DailyStreakCap возвращает длину серии, после которой награда перестаёт расти.
+func (r *Ranking) DailyStreakCap() int {
+	return r.GetIntSetting("daily_streak_cap", envInt("DAILY_STREAK_CAP", 7))
+}
+
+// dailyReward считает награду за день серии streak (начиная с 1).
+func (r *Ranking) dailyReward(streak int) int {
+	bonusDays := min(streak, max(r.DailyStreakCap(), 1)) - 1
+	return r.DailyBase() * (100 + bonusDays*r.DailyStreakBonusPercent()) / 100
+}
+
+// HandleDailyCommand обрабатывает команду !daily.
+func (r *Ranking) HandleDailyCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !daily от %s", m.Author.ID)
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+	r.mu.Lock()
+	state, err := r.redis.HGetAll(r.ctx, dailyKey(m.Author.ID)).Result()
+	if err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось получить ежедневную награду %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка получения награды! Попробуйте позже.")
+		return
+	}
+	if state["last"] == today {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏳ Награда уже получена (серия: %s дн.). Следующая <t:%d:R>.", state["streak"], tomorrow.Unix()))
+		return
+	}
+
+	streak, _ := strconv.Atoi(state["streak"])
+	broken := streak > 0 && state["last"] != yesterday
+	if state["last"] == yesterday {
+		streak++
+	} else {
+		streak = 1
+	}
+	if err := r.redis.HSet(r.ctx, dailyKey(m.Author.ID), "last", today, "streak", streak).Err(); err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось сохранить ежедневную награду %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка получения награды! Попробуйте позже.")
+		return
+	}
+	reward := r.dailyReward(streak)
+	r.UpdateRatingFrom(m.Author.ID, reward, "daily", "")
+	r.mu.Unlock()
+
+	text := fmt.Sprintf("📅 <@%s> получил ежедневную награду %s! Серия: **%d** дн. 🔥", m.Author.ID, formatCredits(reward), streak)
+	if broken {
+		text += "\n💔 Пропущен день — серия началась заново."
+	}
+	if next := r.dailyReward(streak + 1); next > reward {
+		text += fmt.Sprintf("\nЗавтра: %s. Не пропусти!", formatCredits(next))
+	}
+	s.ChannelMessageSend(m.ChannelID, text)
+}
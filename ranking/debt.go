@@ -0,0 +1,150 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Режим долга: при debt_cap > 0 списания (проигрыши, штрафы, неактивность) могут уводить баланс
+// в минус до -debt_cap вместо молчаливого обнуления. На долг раз в сутки начисляются проценты.
+// Тратить в минус по-прежнему нельзя: покупки и ставки проверяют положительный баланс.
+const debtInterestKey = "debt:interest_charged" // всего начислено процентов по долгам
+
+// DebtCap возвращает максимальный долг игрока (0 — режим долга выключен, баланс не ниже 0).
+func (r *Ranking) DebtCap() int {
+	return r.GetIntSetting("debt_cap", envInt("DEBT_CAP", 0))
+}
+
+// DebtInterestBP возвращает дневную ставку по долгу в базисных пунктах (100 = 1% в день).
+func (r *Ranking) DebtInterestBP() int {
+	return r.GetIntSetting("debt_interest_bp", envInt("DEBT_INTEREST_BP", 100))
+}
+
+// balanceFloor возвращает минимально допустимый баланс после списания.
+// Если долг уже глубже лимита (лимит снизили), он не прощается, но и не растёт.
+func (r *Ranking) balanceFloor(oldRating int) int {
+	floor := -r.DebtCap()
+	if floor > 0 {
+		floor = 0
+	}
+	if oldRating < floor {
+		return oldRating
+	}
+	return floor
+}
+
+// applyDebtInterest начисляет дневные проценты на все отрицательные балансы.
+func (r *Ranking) applyDebtInterest() error {
+	bp := r.DebtInterestBP()
+	if bp <= 0 {
+		return nil
+	}
+	debtors, err := r.redis.ZRangeByScoreWithScores(r.ctx, economyBalancesKey, &redis.ZRangeBy{Min: "-inf", Max: "(0"}).Result()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, entry := range debtors {
+		userID, _ := entry.Member.(string)
+		interest := max(-int(entry.Score)*bp/10000, 1)
+		before := r.GetRating(userID)
+		if before >= 0 {
+			continue
+		}
+		r.UpdateRatingFrom(userID, -interest, "debt", "")
+		total += before - r.GetRating(userID)
+	}
+	if total > 0 {
+		r.redis.IncrBy(r.ctx, debtInterestKey, int64(total))
+	}
+	log.Printf("Начислены проценты по долгам: %d кредитов на %d должников (%d б.п.)", total, len(debtors), bp)
+	return nil
+}
+
+// debtSettings сопоставляет подкоманды !a_debts с настройками.
+var debtSettings = map[string]string{
+	"cap":  "debt_cap",
+	"rate": "debt_interest_bp",
+}
+
+// HandleDebtsCommand обрабатывает команду !a_debts [cap <сумма>|rate <б.п.>].
+func (r *Ranking) HandleDebtsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_debts: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть долги! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_debts [cap <сумма>|rate <б.п. в день>]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		setting, ok := debtSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	debtors, err := r.redis.ZRangeByScoreWithScores(r.ctx, economyBalancesKey, &redis.ZRangeBy{Min: "-inf", Max: "(0"}).Result()
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+		return
+	}
+	total := 0
+	var lines []string
+	for i, entry := range debtors {
+		userID, _ := entry.Member.(string)
+		total -= int(entry.Score)
+		if i < 20 {
+			lines = append(lines, fmt.Sprintf("%d. <@%s> — %s", i+1, userID, formatCredits(-int(entry.Score))))
+		}
+	}
+	if len(debtors) > len(lines) {
+		lines = append(lines, fmt.Sprintf("…и ещё %d", len(debtors)-len(lines)))
+	}
+	description := "Должников нет. Император доволен! 🇨🇳"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+
+	capText := "выключен (баланс не ниже 0)"
+	if debtCap := r.DebtCap(); debtCap > 0 {
+		capText = formatCredits(debtCap)
+	}
+	charged, _ := r.redis.Get(r.ctx, debtInterestKey).Int()
+	bp := r.DebtInterestBP()
+	embed := &discordgo.MessageEmbed{
+		Title:       "💳 Долги",
+		Description: truncate(description, 4000),
+		Color:       randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🧾 Общий долг", Value: fmt.Sprintf("%s у %d игроков", formatCredits(total), len(debtors)), Inline: true},
+			{Name: "🔻 Лимит долга", Value: capText, Inline: true},
+			{Name: "📈 Ставка", Value: fmt.Sprintf("%d б.п. (%d.%02d%% в день)", bp, bp/100, bp%100), Inline: true},
+			{Name: "🪙 Начислено процентов", Value: formatCredits(charged), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_debts cap|rate <значение> · проценты начисляются в 05:00"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
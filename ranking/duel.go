@@ -1,6 +1,7 @@
 package ranking
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -16,13 +17,20 @@ type Duel struct {
 	DuelID       string
 	ChallengerID string
 	OpponentID   string
+	TargetID     string // если задан, принять вызов может только этот игрок
 	Bet          int
+	HoldID       string // удержание ставки вызывающего (см. Hold)
 	Active       bool
 	ChannelID    string
 	MessageID    string
 	Created      time.Time
 }
 
+// DuelTiePercent возвращает шанс ничьей в дуэли в процентах: при ничьей обе ставки возвращаются без комиссии.
+func (r *Ranking) DuelTiePercent() int {
+	return r.GetIntSetting("duel_tie_percent", envInt("DUEL_TIE_PERCENT", 2))
+}
+
 // HandleDuelCommand обрабатывает команду !duel.
 func (r *Ranking) HandleDuelCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
 	log.Printf("Обработка !duel: %s от %s", command, m.Author.ID)
@@ -39,29 +47,55 @@ func (r *Ranking) HandleDuelCommand(s *discordgo.Session, m *discordgo.MessageCr
 		return
 	}
 
-	userRating := r.GetRating(m.Author.ID)
+	if err := r.createDuel(s, m.ChannelID, m.Author.ID, "", bet); err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+	}
+}
+
+// createDuel проверяет ставку и публикует вызов на дуэль. Если targetID задан, принять вызов может
+// только этот игрок. Текст ошибки предназначен для пользователя.
+func (r *Ranking) createDuel(s *discordgo.Session, channelID, challengerID, targetID string, bet int) error {
+	if msg := r.checkBetLimits("duel", challengerID, bet); msg != "" {
+		return errors.New(msg)
+	}
+
+	userRating := r.GetRating(challengerID)
 	if userRating < bet {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %d", userRating))
-		return
+		return fmt.Errorf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(userRating))
+	}
+	if remaining, err := r.reserveDailyWager(challengerID, bet); err != nil {
+		return errors.New(r.wagerCapMessage(remaining, err))
+	}
+	// Ставка вызывающего замораживается до ответа соперника
+	holdID, err := r.Hold(challengerID, bet, "duel", betHoldTTL)
+	if err != nil {
+		r.releaseDailyWager(challengerID, bet, time.Now())
+		return errors.New(r.holdErrorMessage(challengerID, err))
 	}
 
-	duelID := generateGameID(m.Author.ID)
+	duelID := generateGameID(challengerID)
 	r.mu.Lock()
 	duel := &Duel{
 		DuelID:       duelID,
-		ChallengerID: m.Author.ID,
+		ChallengerID: challengerID,
+		TargetID:     targetID,
 		Bet:          bet,
+		HoldID:       holdID,
 		Active:       true,
-		ChannelID:    m.ChannelID,
+		ChannelID:    channelID,
 		Created:      time.Now(),
 	}
 	r.duels[duelID] = duel
 	r.mu.Unlock()
 
+	description := fmt.Sprintf("%s вызывает на дуэль с ставкой **%s**! 💸 Ставка заморожена.\n\nНажми **Принять**, чтобы сразиться!\n_Отменить вызов может только его автор._", r.flairMention(challengerID), formatCredits(bet))
+	if targetID != "" {
+		description = fmt.Sprintf("%s вызывает %s на дуэль с ставкой **%s**! 💸\n\nПринять вызов может только <@%s>.\n_Отменить вызов может только его автор._", r.flairMention(challengerID), r.flairMention(targetID), formatCredits(bet), targetID)
+	}
 	embed := &discordgo.MessageEmbed{
-		Title:       "⚔️ Дуэль! ⚔️",
-		Description: fmt.Sprintf("<@%s> вызывает на дуэль с ставкой **%d** кредитов! 💸\n\nНажми **Принять**, чтобы сразиться!", m.Author.ID, bet),
-		Color:       randomColor(),
+		Title:       r.themeTitle(duel.ChallengerID, "duel", "⚔️ Дуэль! ⚔️"),
+		Description: description,
+		Color:       r.themeColor(duel.ChallengerID),
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Только смелые принимают вызов! 🛡️",
 		},
@@ -74,17 +108,32 @@ func (r *Ranking) HandleDuelCommand(s *discordgo.Session, m *discordgo.MessageCr
 					Style:    discordgo.PrimaryButton,
 					CustomID: fmt.Sprintf("duel_accept_%s", duelID),
 				},
+				discordgo.Button{
+					Label:    "Отменить ✖️",
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("duel_cancel_%s", duelID),
+				},
 			},
 		},
 	}
 
-	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+	var content string
+	if targetID != "" {
+		content = "<@" + targetID + ">"
+	}
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    content,
 		Embed:      embed,
 		Components: components,
 	})
 	if err != nil {
 		log.Printf("Не удалось отправить сообщение дуэли: %v", err)
-		return
+		r.mu.Lock()
+		delete(r.duels, duelID)
+		r.mu.Unlock()
+		r.Release(holdID)
+		r.releaseDailyWager(challengerID, bet, duel.Created)
+		return fmt.Errorf("❌ Не удалось создать дуэль, попробуй ещё раз!")
 	}
 
 	r.mu.Lock()
@@ -92,6 +141,7 @@ func (r *Ranking) HandleDuelCommand(s *discordgo.Session, m *discordgo.MessageCr
 	r.mu.Unlock()
 
 	go r.duelTimeout(s, duelID)
+	return nil
 }
 
 // HandleDuelAccept обрабатывает нажатие кнопки "Принять".
@@ -110,51 +160,40 @@ func (r *Ranking) HandleDuelAccept(s *discordgo.Session, i *discordgo.Interactio
 	duelID := strings.TrimPrefix(customID, "duel_accept_")
 	log.Printf("Извлечён duelID: %s", duelID)
 
-	r.mu.Lock()
-	duel, exists := r.duels[duelID]
-	if !exists {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Дуэль не найдена!", Flags: discordgo.MessageFlagsEphemeral},
-		})
-		r.mu.Unlock()
-		return
-	}
-	if !duel.Active {
+	duel, opponentHold, errText := r.acceptDuel(duelID, i.Member.User.ID)
+	if errText != "" {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Дуэль уже завершена!", Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: errText, Flags: discordgo.MessageFlagsEphemeral},
 		})
-		r.mu.Unlock()
 		return
 	}
-	if i.Member.User.ID == duel.ChallengerID {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "❌ Нельзя принять свою дуэль!", Flags: discordgo.MessageFlagsEphemeral},
-		})
-		r.mu.Unlock()
+
+	rand.Seed(time.Now().UnixNano())
+	if rand.Intn(100) < r.DuelTiePercent() {
+		r.settleDuelTie(s, i, duel, opponentHold)
 		return
 	}
 
-	opponentRating := r.GetRating(i.Member.User.ID)
-	if opponentRating < duel.Bet {
+	// Ставка вызывающего могла вернуться раньше (удержание истекло) — тогда дуэль не состоится
+	if _, err := r.Capture(duel.HoldID); err != nil {
+		log.Printf("Удержание вызывающего дуэли %s уже закрыто: %v", duelID, err)
+		r.Release(opponentHold)
+		r.releaseDailyWager(duel.OpponentID, duel.Bet, time.Now())
+		r.mu.Lock()
+		delete(r.duels, duelID)
+		r.mu.Unlock()
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %d", opponentRating), Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: "❌ Вызов больше недействителен: ставка вызывающего уже возвращена. Твоя ставка тоже возвращена.", Flags: discordgo.MessageFlagsEphemeral},
 		})
-		r.mu.Unlock()
+		if err := s.ChannelMessageDelete(duel.ChannelID, duel.MessageID); err != nil {
+			log.Printf("Не удалось удалить сообщение дуэли %s: %v", duelID, err)
+		}
 		return
 	}
+	r.captureHold(opponentHold)
 
-	duel.OpponentID = i.Member.User.ID
-	duel.Active = false
-	r.mu.Unlock()
-
-	r.UpdateRating(duel.ChallengerID, -duel.Bet)
-	r.UpdateRating(duel.OpponentID, -duel.Bet)
-
-	rand.Seed(time.Now().UnixNano())
 	winnerID := duel.ChallengerID
 	loserID := duel.OpponentID
 	if rand.Intn(2) == 1 {
@@ -162,14 +201,16 @@ func (r *Ranking) HandleDuelAccept(s *discordgo.Session, i *discordgo.Interactio
 	}
 
 	winnings := duel.Bet * 2
-	r.UpdateRating(winnerID, winnings)
+	r.UpdateRatingFrom(winnerID, winnings, "duel", loserID)
+	r.repayLoanFromWinnings(winnerID, winnings-duel.Bet)
 	r.UpdateDuelStats(winnerID, true)
 	r.UpdateDuelStats(loserID, false)
+	r.overlay.Publish(OverlayEvent{Type: "duel", Status: "won", GameID: duel.DuelID, Bet: duel.Bet, WinnerID: winnerID, LoserID: loserID})
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "⚔️ Дуэль завершена! ⚔️",
-		Description: fmt.Sprintf("<@%s> принял вызов <@%s>!\n\n🏆 **Победитель:** <@%s> (+%d кредитов)\n😢 **Проигравший:** <@%s> (-%d кредитов)", duel.OpponentID, duel.ChallengerID, winnerID, winnings, loserID, duel.Bet),
-		Color:       randomColor(),
+		Title:       r.themeTitle(duel.ChallengerID, "duel", "⚔️ Дуэль завершена! ⚔️"),
+		Description: fmt.Sprintf("%s принял вызов %s!\n\n🏆 **Победитель:** %s (+%s)\n😢 **Проигравший:** %s (-%s)", r.flairMention(duel.OpponentID), r.flairMention(duel.ChallengerID), r.flairMention(winnerID), formatCredits(winnings), r.flairMention(loserID), formatCredits(duel.Bet)),
+		Color:       r.themeColor(duel.ChallengerID),
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Славь Императора! 👑",
 		},
@@ -185,7 +226,7 @@ func (r *Ranking) HandleDuelAccept(s *discordgo.Session, i *discordgo.Interactio
 		log.Printf("Не удалось обновить сообщение дуэли: %v", err)
 	}
 
-	r.LogCreditOperation(s, fmt.Sprintf("<@%s> выиграл %d соц кредитов у <@%s> в дуэли", winnerID, winnings, loserID))
+	r.LogCreditOperation(s, fmt.Sprintf("<@%s> выиграл %s у <@%s> в дуэли", winnerID, formatCredits(winnings), loserID))
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
 
@@ -194,9 +235,138 @@ func (r *Ranking) HandleDuelAccept(s *discordgo.Session, i *discordgo.Interactio
 	r.mu.Unlock()
 }
 
+// acceptDuel проверяет вызов и удерживает ставку соперника. При отказе возвращает текст для игрока.
+func (r *Ranking) acceptDuel(duelID, opponentID string) (*Duel, string, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	duel, exists := r.duels[duelID]
+	if !exists {
+		return nil, "", "❌ Дуэль не найдена!"
+	}
+	if !duel.Active {
+		return nil, "", "❌ Дуэль уже завершена!"
+	}
+	if opponentID == duel.ChallengerID {
+		return nil, "", "❌ Нельзя принять свою дуэль!"
+	}
+	if duel.TargetID != "" && opponentID != duel.TargetID {
+		return nil, "", fmt.Sprintf("❌ Этот вызов адресован <@%s>!", duel.TargetID)
+	}
+	if msg := r.checkBetLimits("duel", opponentID, duel.Bet); msg != "" {
+		return nil, "", msg
+	}
+	if opponentRating := r.GetRating(opponentID); opponentRating < duel.Bet {
+		return nil, "", fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(opponentRating))
+	}
+	if remaining, err := r.reserveDailyWager(opponentID, duel.Bet); err != nil {
+		return nil, "", r.wagerCapMessage(remaining, err)
+	}
+	// Ставка соперника замораживается под r.mu: проверка баланса и списание атомарны,
+	// поэтому изменение баланса между нажатием кнопки и расчётом не даёт уйти в минус.
+	opponentHold, err := r.holdLocked(opponentID, duel.Bet, "duel", betHoldTTL)
+	if err != nil {
+		r.releaseDailyWager(opponentID, duel.Bet, time.Now())
+		return nil, "", r.holdErrorMessage(opponentID, err)
+	}
+
+	duel.OpponentID = opponentID
+	duel.Active = false
+	return duel, opponentHold, ""
+}
+
+// settleDuelTie завершает дуэль ничьей: обе ставки возвращаются владельцам целиком.
+func (r *Ranking) settleDuelTie(s *discordgo.Session, i *discordgo.InteractionCreate, duel *Duel, opponentHold string) {
+	for _, holdID := range []string{duel.HoldID, opponentHold} {
+		if _, err := r.Release(holdID); err != nil {
+			log.Printf("Не удалось вернуть ставку дуэли %s (%s): %v", duel.DuelID, holdID, err)
+		}
+	}
+	r.overlay.Publish(OverlayEvent{Type: "duel", Status: "push", GameID: duel.DuelID, Bet: duel.Bet, WinnerID: duel.ChallengerID, LoserID: duel.OpponentID})
+
+	embed := &discordgo.MessageEmbed{
+		Title:       r.themeTitle(duel.ChallengerID, "duel", "⚔️ Дуэль завершена! ⚔️"),
+		Description: fmt.Sprintf("%s принял вызов %s!\n\n🤝 **Ничья!** Клинки скрестились, никто не уступил.\n🔄 Обе ставки по %s возвращены.", r.flairMention(duel.OpponentID), r.flairMention(duel.ChallengerID), formatCredits(duel.Bet)),
+		Color:       r.themeColor(duel.ChallengerID),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Император ценит равных соперников! 👑",
+		},
+	}
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    duel.ChannelID,
+		ID:         duel.MessageID,
+		Embed:      embed,
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		log.Printf("Не удалось обновить сообщение дуэли: %v", err)
+	}
+	r.LogCreditOperation(s, fmt.Sprintf("Дуэль <@%s> и <@%s> на %s закончилась ничьей, ставки возвращены", duel.ChallengerID, duel.OpponentID, formatCredits(duel.Bet)))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+
+	r.mu.Lock()
+	delete(r.duels, duel.DuelID)
+	r.mu.Unlock()
+}
+
+// HandleDuelTieCommand обрабатывает команду !a_duel_tie [процент].
+func (r *Ranking) HandleDuelTieCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_duel_tie: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать дуэли! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Шанс ничьей в дуэли: **%d%%**. Изменить: `/a_duel_tie <процент>` (0 — без ничьих)", r.DuelTiePercent()))
+		return
+	}
+	percent, err := strconv.Atoi(parts[1])
+	if len(parts) != 2 || err != nil || percent < 0 || percent > 50 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_duel_tie <процент>` (0–50)")
+		return
+	}
+	if err := r.SetIntSetting("duel_tie_percent", percent); err != nil {
+		log.Printf("Не удалось сохранить шанс ничьей в дуэли: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Шанс ничьей в дуэли: **%d%%**.", percent))
+	r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> установил шанс ничьей в дуэли: %d%%", m.Author.ID, percent))
+}
+
+// HandleDuelCancel обрабатывает нажатие кнопки "Отменить": вызов может отозвать только его автор.
+func (r *Ranking) HandleDuelCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	duelID := strings.TrimPrefix(i.MessageComponentData().CustomID, "duel_cancel_")
+	log.Printf("Обработка отмены дуэли %s от %s", duelID, i.Member.User.ID)
+
+	duel, errText := r.withdrawDuel(duelID, i.Member.User.ID)
+	if errText != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: errText, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	r.Release(duel.HoldID)
+	r.releaseDailyWager(duel.ChallengerID, duel.Bet, duel.Created)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: fmt.Sprintf("✅ Вызов на дуэль со ставкой %s отменён, ставка возвращена.", formatCredits(duel.Bet)), Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err := s.ChannelMessageDelete(duel.ChannelID, duel.MessageID); err != nil {
+		log.Printf("Не удалось удалить сообщение дуэли %s: %v", duelID, err)
+	}
+	log.Printf("Дуэль %s отменена автором %s", duelID, duel.ChallengerID)
+}
+
 // duelTimeout завершает дуэль по тайм-ауту.
 func (r *Ranking) duelTimeout(s *discordgo.Session, duelID string) {
-	time.Sleep(15 * time.Minute)
+	time.Sleep(r.GameTimeout("duel"))
 	r.mu.Lock()
 	duel, exists := r.duels[duelID]
 	if !exists || !duel.Active {
@@ -207,10 +377,13 @@ func (r *Ranking) duelTimeout(s *discordgo.Session, duelID string) {
 	delete(r.duels, duelID)
 	r.mu.Unlock()
 
+	r.Release(duel.HoldID)
+	r.releaseDailyWager(duel.ChallengerID, duel.Bet, duel.Created)
+
 	embed := &discordgo.MessageEmbed{
-		Title:       "⚔️ Дуэль отменена! ⚔️",
-		Description: fmt.Sprintf("Дуэль <@%s> не была принята! ⏰", duel.ChallengerID),
-		Color:       randomColor(),
+		Title:       r.themeTitle(duel.ChallengerID, "duel", "⚔️ Дуэль отменена! ⚔️"),
+		Description: fmt.Sprintf("Дуэль <@%s> не была принята! ⏰\n\n🔄 Ставка %s возвращена.", duel.ChallengerID, formatCredits(duel.Bet)),
+		Color:       r.themeColor(duel.ChallengerID),
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Время вышло! 😢",
 		},
@@ -220,3 +393,20 @@ func (r *Ranking) duelTimeout(s *discordgo.Session, duelID string) {
 		log.Printf("Не удалось обновить сообщение дуэли по тайм-ауту: %v", err)
 	}
 }
+
+// withdrawDuel снимает вызов, если его отзывает автор. При отказе возвращает текст для игрока.
+func (r *Ranking) withdrawDuel(duelID, userID string) (*Duel, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	duel, exists := r.duels[duelID]
+	if !exists || !duel.Active {
+		return nil, "❌ Дуэль не найдена или уже завершена!"
+	}
+	if userID != duel.ChallengerID {
+		return nil, "❌ Отменить дуэль может только тот, кто её создал!"
+	}
+	duel.Active = false
+	delete(r.duels, duelID)
+	return duel, ""
+}
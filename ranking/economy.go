@@ -0,0 +1,210 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Ключи агрегатов экономики. Поддерживаются инкрементально при каждом изменении баланса,
+// поэтому снимок экономики не требует перебора всех пользователей.
+const (
+	economyTotalKey    = "economy:total"    // сумма кредитов в обороте
+	economyBalancesKey = "economy:balances" // ZSET userID -> баланс
+	economyActivityKey = "economy:activity" // ZSET userID -> unix-время последнего изменения баланса
+)
+
+// economyHourKey возвращает ключ почасового счётчика (minted, burned, case_bank).
+func economyHourKey(kind string, t time.Time) string {
+	return fmt.Sprintf("economy:%s:%s", kind, t.UTC().Format("2006010215"))
+}
+
+// recordEconomyDelta обновляет агрегаты экономики после изменения баланса пользователя.
+func (r *Ranking) recordEconomyDelta(userID string, oldRating, newRating int) {
+	delta := newRating - oldRating
+	now := time.Now()
+
+	pipe := r.redis.TxPipeline()
+	if delta != 0 {
+		pipe.IncrBy(r.ctx, economyTotalKey, int64(delta))
+		kind := "minted"
+		amount := delta
+		if delta < 0 {
+			kind = "burned"
+			amount = -delta
+		}
+		hourKey := economyHourKey(kind, now)
+		pipe.IncrBy(r.ctx, hourKey, int64(amount))
+		pipe.Expire(r.ctx, hourKey, 25*time.Hour)
+	}
+	pipe.ZAdd(r.ctx, economyBalancesKey, &redis.Z{Score: float64(newRating), Member: userID})
+	pipe.ZAdd(r.ctx, economyActivityKey, &redis.Z{Score: float64(now.Unix()), Member: userID})
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось обновить агрегаты экономики для %s: %v", userID, err)
+	}
+}
+
+// recordCaseBankTurnover учитывает покупку кейсов из банка в агрегатах экономики.
+func (r *Ranking) recordCaseBankTurnover(count, price int) {
+	now := time.Now()
+	casesKey := economyHourKey("case_bank_cases", now)
+	creditsKey := economyHourKey("case_bank_credits", now)
+	pipe := r.redis.TxPipeline()
+	pipe.IncrBy(r.ctx, casesKey, int64(count))
+	pipe.Expire(r.ctx, casesKey, 25*time.Hour)
+	pipe.IncrBy(r.ctx, creditsKey, int64(price))
+	pipe.Expire(r.ctx, creditsKey, 25*time.Hour)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось обновить оборот банка кейсов: %v", err)
+	}
+}
+
+// sumEconomyLast24h суммирует почасовые счётчики указанного вида за последние 24 часа.
+func (r *Ranking) sumEconomyLast24h(kind string) int {
+	now := time.Now()
+	keys := make([]string, 0, 24)
+	for i := 0; i < 24; i++ {
+		keys = append(keys, economyHourKey(kind, now.Add(-time.Duration(i)*time.Hour)))
+	}
+	values, err := r.redis.MGet(r.ctx, keys...).Result()
+	if err != nil {
+		log.Printf("Не удалось получить счётчики economy:%s: %v", kind, err)
+		return 0
+	}
+	total := 0
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			n, _ := strconv.Atoi(str)
+			total += n
+		}
+	}
+	return total
+}
+
+// ensureEconomyAggregates однократно строит агрегаты экономики, если их ещё нет в Redis.
+func (r *Ranking) ensureEconomyAggregates() {
+	exists, err := r.redis.Exists(r.ctx, economyTotalKey).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить агрегаты экономики: %v", err)
+		return
+	}
+	if exists > 0 {
+		return
+	}
+
+	total := 0
+	users := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(r.ctx, cursor, "user:*", 200).Result()
+		if err != nil {
+			log.Printf("Не удалось просканировать пользователей для агрегатов экономики: %v", err)
+			return
+		}
+		for _, key := range keys {
+			data, err := r.redis.Get(r.ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			var user User
+			if err := json.Unmarshal([]byte(data), &user); err != nil || user.ID == "" {
+				continue
+			}
+			r.redis.ZAdd(r.ctx, economyBalancesKey, &redis.Z{Score: float64(user.Rating), Member: user.ID})
+			total += user.Rating
+			users++
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	r.redis.Set(r.ctx, economyTotalKey, total, 0)
+	log.Printf("Построены агрегаты экономики: %d пользователей, %d кредитов в обороте", users, total)
+}
+
+// EconomySnapshot — снимок агрегатов экономики: для !a_economy и ночной выгрузки в Google Sheets.
+type EconomySnapshot struct {
+	At          time.Time
+	Total       int
+	Holders     int64
+	TopCount    int64
+	TopSum      int
+	TopShare    float64
+	Active24h   int64
+	Active7d    int64
+	Minted      int
+	Burned      int
+	BankCases   int
+	BankCredits int
+}
+
+// economySnapshot собирает снимок экономики из агрегатов в Redis.
+func (r *Ranking) economySnapshot() (EconomySnapshot, error) {
+	snap := EconomySnapshot{At: time.Now()}
+	total, err := r.redis.Get(r.ctx, economyTotalKey).Int()
+	if err != nil && err != redis.Nil {
+		return snap, err
+	}
+	snap.Total = total
+
+	snap.Holders, _ = r.redis.ZCount(r.ctx, economyBalancesKey, "(0", "+inf").Result()
+	snap.TopCount = max((snap.Holders+99)/100, 1)
+	if snap.Holders > 0 {
+		top, _ := r.redis.ZRevRangeWithScores(r.ctx, economyBalancesKey, 0, snap.TopCount-1).Result()
+		for _, z := range top {
+			snap.TopSum += int(z.Score)
+		}
+		if total > 0 {
+			snap.TopShare = float64(snap.TopSum) / float64(total) * 100
+		}
+	}
+
+	snap.Active24h, _ = r.redis.ZCount(r.ctx, economyActivityKey, strconv.FormatInt(snap.At.Add(-24*time.Hour).Unix(), 10), "+inf").Result()
+	snap.Active7d, _ = r.redis.ZCount(r.ctx, economyActivityKey, strconv.FormatInt(snap.At.Add(-7*24*time.Hour).Unix(), 10), "+inf").Result()
+
+	snap.Minted = r.sumEconomyLast24h("minted")
+	snap.Burned = r.sumEconomyLast24h("burned")
+	snap.BankCases = r.sumEconomyLast24h("case_bank_cases")
+	snap.BankCredits = r.sumEconomyLast24h("case_bank_credits")
+	return snap, nil
+}
+
+// HandleEconomyCommand обрабатывает команду !a_economy.
+func (r *Ranking) HandleEconomyCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !a_economy от %s", m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть экономику! 🔒")
+		return
+	}
+
+	snap, err := r.economySnapshot()
+	if err != nil {
+		log.Printf("Не удалось получить economy:total: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка чтения агрегатов экономики! Проверьте Redis-сервер.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "📊 Экономика сервера",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💰 В обороте", Value: fmt.Sprintf("%s\n%d держателей", formatCredits(snap.Total), snap.Holders), Inline: true},
+			{Name: "👑 Топ 1%", Value: fmt.Sprintf("%d игроков\n%s (%.1f%%)", snap.TopCount, formatCredits(snap.TopSum), snap.TopShare), Inline: true},
+			{Name: "🎮 Активные игроки", Value: fmt.Sprintf("24ч: %d\n7д: %d", snap.Active24h, snap.Active7d), Inline: true},
+			{Name: "📈 Начислено за 24ч", Value: fmt.Sprintf("+%d", snap.Minted), Inline: true},
+			{Name: "📉 Списано за 24ч", Value: fmt.Sprintf("-%d", snap.Burned), Inline: true},
+			{Name: "⚖️ Итог за 24ч", Value: fmt.Sprintf("%+d", snap.Minted-snap.Burned), Inline: true},
+			{Name: "🏦 Банк кейсов за 24ч", Value: fmt.Sprintf("%d кейсов за %s", snap.BankCases, formatCredits(snap.BankCredits)), Inline: false},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Начислено/списано — валовые движения, включая ставки и выигрыши · кэш игроков: %s", r.userCacheSummary())},
+		Timestamp: snap.At.Format(time.RFC3339),
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
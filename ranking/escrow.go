@@ -0,0 +1,227 @@
+package ranking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Эскроу удерживает кредиты, кейсы и NFT пользователя на время многошаговой операции.
+// Удержание либо подтверждается (ресурсы потрачены), либо откатывается (ресурсы возвращаются
+// владельцу). Удержания, не завершённые вовремя (например, после падения бота), откатывает
+// задача escrow_sweeper.
+const (
+	escrowExpiryKey = "escrow:expiry" // ZSET holdID -> unix-время истечения
+	escrowHeldKey   = "escrow:held"   // HASH userID -> сумма удержанных кредитов
+)
+
+var (
+	// errEscrowClosed возвращается, если удержание уже подтверждено, откачено или истекло.
+	errEscrowClosed = errors.New("удержание уже завершено")
+	// errEscrowNoCredits возвращается, если у владельца не хватает кредитов на удержание.
+	errEscrowNoCredits = errors.New("недостаточно кредитов")
+)
+
+// EscrowHold описывает ресурсы, удерживаемые у владельца.
+type EscrowHold struct {
+	ID        string         `json:"id"`
+	Owner     string         `json:"owner"`
+	Reason    string         `json:"reason"`
+	Source    string         `json:"source,omitempty"` // источник в журнале кредитов, по умолчанию escrow
+	Credits   int            `json:"credits,omitempty"`
+	Cases     map[string]int `json:"cases,omitempty"`
+	NFTs      map[string]int `json:"nfts,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// escrowKey возвращает ключ удержания.
+func escrowKey(id string) string {
+	return "escrow:hold:" + id
+}
+
+// ledgerSource возвращает источник, под которым кредиты удержания проходят в журнале.
+func (hold EscrowHold) ledgerSource() string {
+	if hold.Source != "" {
+		return hold.Source
+	}
+	return "escrow"
+}
+
+// escrowReserve списывает ресурсы удержания с владельца и сохраняет удержание.
+// Если ресурсов не хватает, ничего не списывается. ttl <= 0 — удержание без срока.
+func (r *Ranking) escrowReserve(hold EscrowHold, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.escrowReserveLocked(hold, ttl)
+}
+
+// escrowReserveLocked — escrowReserve для вызова под r.mu.
+func (r *Ranking) escrowReserveLocked(hold EscrowHold, ttl time.Duration) error {
+	if hold.Credits > 0 && r.GetRating(hold.Owner) < hold.Credits {
+		return errEscrowNoCredits
+	}
+	var caseInv UserCaseInventory
+	if len(hold.Cases) > 0 {
+		caseInv = r.Kki.GetUserCaseInventory(r, hold.Owner)
+		for caseID, count := range hold.Cases {
+			if caseInv[caseID] < count {
+				return fmt.Errorf("недостаточно кейсов %s", caseID)
+			}
+		}
+	}
+	var nftInv UserInventory
+	if len(hold.NFTs) > 0 {
+		nftInv = r.GetUserInventory(hold.Owner)
+		for nftID, count := range hold.NFTs {
+			if nftInv[nftID] < count {
+				return fmt.Errorf("недостаточно NFT %s", nftID)
+			}
+		}
+	}
+
+	hold.CreatedAt = time.Now()
+	if ttl > 0 {
+		hold.ExpiresAt = hold.CreatedAt.Add(ttl)
+	}
+	// Ключ удержания хранится без срока: если бы он истёк в Redis раньше, чем до него дошёл
+	// escrow_sweeper, удержанные ресурсы пропали бы без возврата. Истекает удержание только откатом.
+	data, _ := json.Marshal(hold)
+	ok, err := r.redis.SetNX(r.ctx, escrowKey(hold.ID), data, 0).Result()
+	if err != nil {
+		return fmt.Errorf("ошибка Redis: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("удержание %s уже существует", hold.ID)
+	}
+	if ttl > 0 {
+		r.redis.ZAdd(r.ctx, escrowExpiryKey, &redis.Z{Score: float64(hold.ExpiresAt.Unix()), Member: hold.ID})
+	}
+
+	if hold.Credits > 0 {
+		r.UpdateRatingFrom(hold.Owner, -hold.Credits, hold.ledgerSource(), "")
+		r.redis.HIncrBy(r.ctx, escrowHeldKey, hold.Owner, int64(hold.Credits))
+	}
+	if caseInv != nil {
+		for caseID, count := range hold.Cases {
+			caseInv[caseID] -= count
+			if caseInv[caseID] == 0 {
+				delete(caseInv, caseID)
+			}
+		}
+		r.Kki.SaveUserCaseInventory(r, hold.Owner, caseInv)
+	}
+	if nftInv != nil {
+		for nftID, count := range hold.NFTs {
+			nftInv[nftID] -= count
+			if nftInv[nftID] == 0 {
+				delete(nftInv, nftID)
+			}
+		}
+		r.SaveUserInventory(hold.Owner, nftInv)
+	}
+	return nil
+}
+
+// escrowTake атомарно забирает удержание из Redis. Только один из commit/rollback/sweeper его получит.
+func (r *Ranking) escrowTake(id string) (EscrowHold, error) {
+	var hold EscrowHold
+	data, err := r.redis.GetDel(r.ctx, escrowKey(id)).Bytes()
+	if err == redis.Nil {
+		return hold, errEscrowClosed
+	}
+	if err != nil {
+		return hold, err
+	}
+	r.redis.ZRem(r.ctx, escrowExpiryKey, id)
+	if err := json.Unmarshal(data, &hold); err != nil {
+		return hold, err
+	}
+	if hold.Credits > 0 {
+		if left, err := r.redis.HIncrBy(r.ctx, escrowHeldKey, hold.Owner, int64(-hold.Credits)).Result(); err == nil && left <= 0 {
+			r.redis.HDel(r.ctx, escrowHeldKey, hold.Owner)
+		}
+	}
+	return hold, nil
+}
+
+// escrowCommit подтверждает удержание: ресурсы считаются потраченными.
+// Ошибка errEscrowClosed означает, что удержание уже откачено и операцию выполнять нельзя.
+func (r *Ranking) escrowCommit(id string) (EscrowHold, error) {
+	return r.escrowTake(id)
+}
+
+// escrowRollback возвращает удержанные ресурсы владельцу.
+func (r *Ranking) escrowRollback(id string) error {
+	hold, err := r.escrowTake(id)
+	if err != nil {
+		return err
+	}
+	r.escrowRefund(hold)
+	return nil
+}
+
+// escrowRefund возвращает ресурсы удержания владельцу.
+func (r *Ranking) escrowRefund(hold EscrowHold) {
+	r.escrowDeliver(hold, hold.Owner, hold.ledgerSource())
+	log.Printf("Удержание %s (%s) возвращено %s", hold.ID, hold.Reason, hold.Owner)
+}
+
+// escrowDeliver зачисляет ресурсы подтверждённого или откаченного удержания получателю.
+func (r *Ranking) escrowDeliver(hold EscrowHold, recipient, source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.escrowDeliverLocked(hold, recipient, source)
+}
+
+// escrowDeliverLocked — escrowDeliver для вызова под r.mu.
+func (r *Ranking) escrowDeliverLocked(hold EscrowHold, recipient, source string) {
+	counterparty := hold.Owner
+	if recipient == hold.Owner {
+		counterparty = ""
+	}
+	if hold.Credits > 0 {
+		r.UpdateRatingFrom(recipient, hold.Credits, source, counterparty)
+	}
+	if len(hold.Cases) > 0 {
+		caseInv := r.Kki.GetUserCaseInventory(r, recipient)
+		for caseID, count := range hold.Cases {
+			caseInv[caseID] += count
+		}
+		r.Kki.SaveUserCaseInventory(r, recipient, caseInv)
+	}
+	if len(hold.NFTs) > 0 {
+		nftInv := r.GetUserInventory(recipient)
+		for nftID, count := range hold.NFTs {
+			nftInv[nftID] += count
+		}
+		r.SaveUserInventory(recipient, nftInv)
+	}
+}
+
+// sweepExpiredEscrow откатывает истёкшие удержания.
+func (r *Ranking) sweepExpiredEscrow() error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	ids, err := r.redis.ZRangeByScore(r.ctx, escrowExpiryKey, &redis.ZRangeBy{Min: "-inf", Max: now}).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err := r.escrowRollback(id)
+		if err == errEscrowClosed {
+			r.redis.ZRem(r.ctx, escrowExpiryKey, id)
+			continue
+		}
+		if err != nil {
+			log.Printf("Не удалось откатить удержание %s: %v", id, err)
+			continue
+		}
+		log.Printf("Удержание %s истекло и откачено", id)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// escrowTradeTTL — сколько сделка ждёт подтверждения второй стороны.
+const escrowTradeTTL = 5 * time.Minute
+
+// EscrowTrade — сделка между двумя игроками через эскроу. Ресурсы инициатора удерживаются
+// при создании сделки, ресурсы второй стороны — при подтверждении; затем стороны обмениваются
+// удержанным. Если вторая сторона не ответила вовремя, удержание инициатора откатывается.
+type EscrowTrade struct {
+	ID           string     `json:"id"`
+	Kind         string     `json:"kind"`
+	Initiator    EscrowHold `json:"initiator"`
+	Counterparty EscrowHold `json:"counterparty"`
+	Summary      string     `json:"summary"`
+	Taxed        bool       `json:"taxed,omitempty"` // кредиты облагаются налогом на переводы
+	Tax          int        `json:"tax,omitempty"`   // удержанный налог
+}
+
+// escrowTradeKey возвращает ключ сделки, ожидающей подтверждения.
+func escrowTradeKey(id string) string {
+	return "escrow:trade:" + id
+}
+
+// escrowOpenTrade удерживает ресурсы инициатора и сохраняет сделку до ответа второй стороны.
+func (r *Ranking) escrowOpenTrade(trade *EscrowTrade) error {
+	trade.Initiator.ID = "trade:" + trade.ID + ":initiator"
+	trade.Counterparty.ID = "trade:" + trade.ID + ":counterparty"
+	if err := r.escrowReserve(trade.Initiator, escrowTradeTTL); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(trade)
+	if err := r.redis.Set(r.ctx, escrowTradeKey(trade.ID), data, escrowTradeTTL).Err(); err != nil {
+		r.escrowRollback(trade.Initiator.ID)
+		return fmt.Errorf("ошибка Redis: %v", err)
+	}
+	return nil
+}
+
+// loadEscrowTrade читает сделку без изменения её состояния.
+func (r *Ranking) loadEscrowTrade(id string) (*EscrowTrade, error) {
+	data, err := r.redis.Get(r.ctx, escrowTradeKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errEscrowClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	var trade EscrowTrade
+	if err := json.Unmarshal(data, &trade); err != nil {
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// takeEscrowTrade атомарно забирает сделку: подтвердить, отклонить или просрочить её можно только один раз.
+func (r *Ranking) takeEscrowTrade(id string) (*EscrowTrade, error) {
+	data, err := r.redis.GetDel(r.ctx, escrowTradeKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errEscrowClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	var trade EscrowTrade
+	if err := json.Unmarshal(data, &trade); err != nil {
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// escrowSettleTrade удерживает ресурсы второй стороны и обменивает удержания.
+// При любой ошибке ресурсы обеих сторон возвращаются владельцам.
+func (r *Ranking) escrowSettleTrade(trade *EscrowTrade) error {
+	if err := r.escrowReserve(trade.Counterparty, time.Minute); err != nil {
+		r.escrowRollback(trade.Initiator.ID)
+		return err
+	}
+	if _, err := r.escrowCommit(trade.Initiator.ID); err != nil {
+		r.escrowRollback(trade.Counterparty.ID)
+		if err == errEscrowClosed {
+			return fmt.Errorf("время сделки истекло")
+		}
+		return err
+	}
+	if _, err := r.escrowCommit(trade.Counterparty.ID); err != nil {
+		r.escrowRefund(trade.Initiator)
+		return err
+	}
+	r.escrowDeliver(r.taxTradeCredits(trade, trade.Initiator), trade.Counterparty.Owner, trade.Kind)
+	r.escrowDeliver(r.taxTradeCredits(trade, trade.Counterparty), trade.Initiator.Owner, trade.Kind)
+	return nil
+}
+
+// taxTradeCredits удерживает налог на переводы с кредитов стороны, если сделка им облагается.
+func (r *Ranking) taxTradeCredits(trade *EscrowTrade, hold EscrowHold) EscrowHold {
+	if !trade.Taxed || hold.Credits <= 0 {
+		return hold
+	}
+	if tax := r.transferTax(hold.Credits); tax > 0 {
+		r.collectTransferTax(tax)
+		hold.Credits -= tax
+		trade.Tax += tax
+	}
+	return hold
+}
+
+// escrowTradeTimeout отменяет сделку, если вторая сторона не ответила вовремя.
+func (r *Ranking) escrowTradeTimeout(s *discordgo.Session, id, channelID, messageID string) {
+	time.Sleep(escrowTradeTTL)
+	trade, err := r.takeEscrowTrade(id)
+	if err != nil {
+		return
+	}
+	if err := r.escrowRollback(trade.Initiator.ID); err != nil && err != errEscrowClosed {
+		log.Printf("Не удалось откатить удержание сделки %s: %v", id, err)
+	}
+	log.Printf("Сделка %s истекла без ответа", id)
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    channelID,
+		ID:         messageID,
+		Embed:      escrowTradeEmbed(trade, "⏰ Сделка истекла", fmt.Sprintf("<@%s> не ответил вовремя. Ресурсы возвращены <@%s>.", trade.Counterparty.Owner, trade.Initiator.Owner), 0x808080),
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение сделки %s: %v", id, err)
+	}
+}
+
+// escrowTradeEmbed формирует эмбед сделки.
+func escrowTradeEmbed(trade *EscrowTrade, title, status string, color int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: fmt.Sprintf("<@%s> ⇄ <@%s>\n%s\n\n%s", trade.Initiator.Owner, trade.Counterparty.Owner, trade.Summary, status),
+		Color:       color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "🔒 Сделка через эскроу"},
+	}
+}
+
+// sendEscrowTrade публикует сделку с кнопками подтверждения для второй стороны.
+func (r *Ranking) sendEscrowTrade(s *discordgo.Session, channelID string, trade *EscrowTrade) error {
+	status := fmt.Sprintf("Ресурсы <@%s> заблокированы. <@%s>, подтверди сделку в течение %d минут.", trade.Initiator.Owner, trade.Counterparty.Owner, int(escrowTradeTTL.Minutes()))
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: "<@" + trade.Counterparty.Owner + ">",
+		Embed:   escrowTradeEmbed(trade, "🤝 Предложение сделки", status, 0xFFD700),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Подтвердить ✅", Style: discordgo.SuccessButton, CustomID: "escrow_accept_" + trade.ID},
+					discordgo.Button{Label: "Отклонить ✖️", Style: discordgo.DangerButton, CustomID: "escrow_decline_" + trade.ID},
+				},
+			},
+		},
+	})
+	if err != nil {
+		if taken, takeErr := r.takeEscrowTrade(trade.ID); takeErr == nil {
+			r.escrowRollback(taken.Initiator.ID)
+		}
+		return err
+	}
+	go r.escrowTradeTimeout(s, trade.ID, channelID, msg.ID)
+	return nil
+}
+
+// HandleEscrowTradeButton обрабатывает кнопки подтверждения и отклонения сделки.
+func (r *Ranking) HandleEscrowTradeButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	userID := i.Member.User.ID
+	accept := strings.HasPrefix(customID, "escrow_accept_")
+	id := strings.TrimPrefix(strings.TrimPrefix(customID, "escrow_accept_"), "escrow_decline_")
+	log.Printf("Обработка кнопки сделки %s от %s", customID, userID)
+
+	trade, err := r.loadEscrowTrade(id)
+	if err != nil {
+		respondEphemeral(s, i, "❌ Сделка уже завершена или истекла!")
+		return
+	}
+	if userID != trade.Counterparty.Owner && (accept || userID != trade.Initiator.Owner) {
+		respondEphemeral(s, i, "❌ Это не твоя сделка!")
+		return
+	}
+	trade, err = r.takeEscrowTrade(id)
+	if err != nil {
+		respondEphemeral(s, i, "❌ Сделка уже завершена или истекла!")
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	if !accept {
+		if err := r.escrowRollback(trade.Initiator.ID); err != nil && err != errEscrowClosed {
+			log.Printf("Не удалось откатить удержание сделки %s: %v", id, err)
+		}
+		log.Printf("Сделка %s отклонена %s", id, userID)
+		embed = escrowTradeEmbed(trade, "✖️ Сделка отменена", fmt.Sprintf("<@%s> отменил сделку. Ресурсы возвращены <@%s>.", userID, trade.Initiator.Owner), 0xFF0000)
+	} else if err := r.escrowSettleTrade(trade); err != nil {
+		log.Printf("Сделка %s не состоялась: %v", id, err)
+		embed = escrowTradeEmbed(trade, "❌ Сделка не состоялась", fmt.Sprintf("Причина: %s. Ресурсы возвращены владельцам.", err), 0xFF0000)
+	} else {
+		log.Printf("Сделка %s (%s) завершена", id, trade.Kind)
+		r.LogCreditOperation(s, fmt.Sprintf("🤝 Сделка через эскроу между <@%s> и <@%s>: %s", trade.Initiator.Owner, trade.Counterparty.Owner, trade.Summary))
+		embed = escrowTradeEmbed(trade, "✅ Сделка завершена", "Стороны обменялись ресурсами. Славь Императора! 👑", 0x00FF00)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
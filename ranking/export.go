@@ -0,0 +1,147 @@
+package ranking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// mydataCooldown — как часто пользователь может запрашивать выгрузку своих данных.
+const mydataCooldown = time.Hour
+
+// mydataCooldownKey возвращает ключ кулдауна выгрузки.
+func mydataCooldownKey(userID string) string {
+	return "mydata:cooldown:" + userID
+}
+
+// ExportUserData собирает всё, что бот хранит о пользователе: ключи пользователя с расшифрованными
+// значениями и его места в общих рейтингах.
+func (r *Ranking) ExportUserData(userID string) (map[string]interface{}, error) {
+	keys := make(map[string]interface{})
+	for _, key := range userDataKeys(userID) {
+		value, err := r.exportKey(key)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать %s: %v", key, err)
+		}
+		keys[key] = value
+	}
+	scores := make(map[string]float64)
+	for _, set := range userScoreSets {
+		if score, err := r.redis.ZScore(r.ctx, set, userID).Result(); err == nil {
+			scores[set] = score
+		}
+	}
+	started, _ := r.redis.SIsMember(r.ctx, startingBalanceKey, userID).Result()
+
+	return map[string]interface{}{
+		"user_id":          userID,
+		"exported_at":      time.Now().UTC(),
+		"keys":             keys,
+		"scores":           scores,
+		"starting_balance": started,
+	}, nil
+}
+
+// exportKey читает значение ключа любого типа. Строки с JSON разворачиваются в объекты.
+func (r *Ranking) exportKey(key string) (interface{}, error) {
+	kind, err := r.redis.Type(r.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "none":
+		return nil, redis.Nil
+	case "string":
+		value, err := r.redis.Get(r.ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		return decodeExportValue(value), nil
+	case "list":
+		items, err := r.redis.LRange(r.ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		decoded := make([]interface{}, len(items))
+		for i, item := range items {
+			decoded[i] = decodeExportValue(item)
+		}
+		return decoded, nil
+	case "set":
+		return r.redis.SMembers(r.ctx, key).Result()
+	case "hash":
+		return r.redis.HGetAll(r.ctx, key).Result()
+	case "zset":
+		entries, err := r.redis.ZRangeWithScores(r.ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		scores := make(map[string]float64, len(entries))
+		for _, entry := range entries {
+			member, _ := entry.Member.(string)
+			scores[member] = entry.Score
+		}
+		return scores, nil
+	}
+	return nil, fmt.Errorf("неизвестный тип %s", kind)
+}
+
+// decodeExportValue разворачивает JSON-строку в объект, остальные строки оставляет как есть.
+func decodeExportValue(value string) interface{} {
+	var decoded interface{}
+	if json.Valid([]byte(value)) && json.Unmarshal([]byte(value), &decoded) == nil {
+		return decoded
+	}
+	return value
+}
+
+// HandleMyDataCommand обрабатывает команду !mydata: отправляет пользователю в ЛС JSON со всеми его данными.
+func (r *Ranking) HandleMyDataCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !mydata от %s", m.Author.ID)
+
+	ok, err := r.redis.SetNX(r.ctx, mydataCooldownKey(m.Author.ID), time.Now().Unix(), mydataCooldown).Result()
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось подготовить выгрузку, попробуй позже!")
+		return
+	}
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "⏳ Выгрузку можно запрашивать не чаще раза в час!")
+		return
+	}
+
+	export, err := r.ExportUserData(m.Author.ID)
+	if err != nil {
+		log.Printf("Не удалось выгрузить данные %s: %v", m.Author.ID, err)
+		r.redis.Del(r.ctx, mydataCooldownKey(m.Author.ID))
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось подготовить выгрузку, попробуй позже!")
+		return
+	}
+	data, _ := json.MarshalIndent(export, "", "  ")
+
+	channel, err := s.UserChannelCreate(m.Author.ID)
+	if err == nil {
+		_, err = s.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+			Content: "📦 Все данные, которые бот хранит о тебе. Удалить их можно по запросу к админам.",
+			Files: []*discordgo.File{{
+				Name:        fmt.Sprintf("mydata-%s.json", m.Author.ID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(data),
+			}},
+		})
+	}
+	if err != nil {
+		log.Printf("Не удалось отправить выгрузку %s в ЛС: %v", m.Author.ID, err)
+		r.redis.Del(r.ctx, mydataCooldownKey(m.Author.ID))
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось написать тебе в ЛС — открой личные сообщения от участников сервера!")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("📬 <@%s>, выгрузка данных отправлена в ЛС.", m.Author.ID))
+}
@@ -0,0 +1,144 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// startingBalanceKey — множество пользователей, которым уже выдан (или не положен) стартовый баланс.
+const startingBalanceKey = "faucet:started"
+
+// faucetKey возвращает ключ кулдауна крана пользователя.
+func faucetKey(userID string) string {
+	return "faucet:cooldown:" + userID
+}
+
+// StartingBalance возвращает стартовый баланс новичка (0 — не выдаётся). По умолчанию выключен:
+// выдача создаёт кредиты из ничего, поэтому включается админом явно.
+func (r *Ranking) StartingBalance() int {
+	return r.GetIntSetting("starting_balance", envInt("STARTING_BALANCE", 0))
+}
+
+// FaucetAmount возвращает сумму, выдаваемую краном.
+func (r *Ranking) FaucetAmount() int {
+	return r.GetIntSetting("faucet_amount", envInt("FAUCET_AMOUNT", 10))
+}
+
+// FaucetCooldown возвращает кулдаун крана.
+func (r *Ranking) FaucetCooldown() time.Duration {
+	hours := r.GetIntSetting("faucet_cooldown_hours", envInt("FAUCET_COOLDOWN_HOURS", 24))
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// FaucetMaxBalance возвращает баланс, начиная с которого кран недоступен.
+func (r *Ranking) FaucetMaxBalance() int {
+	return r.GetIntSetting("faucet_max_balance", envInt("FAUCET_MAX_BALANCE", 50))
+}
+
+// EnsureStartingBalance выдаёт стартовый баланс при первом обращении пользователя к боту.
+// Игроки, у которых уже есть запись в Redis, стартовый баланс не получают.
+func (r *Ranking) EnsureStartingBalance(s *discordgo.Session, userID string) {
+	added, err := r.redis.SAdd(r.ctx, startingBalanceKey, userID).Result()
+	if err != nil || added == 0 {
+		return
+	}
+	amount := r.StartingBalance()
+	if amount <= 0 {
+		return
+	}
+	if exists, _ := r.redis.Exists(r.ctx, "user:"+userID).Result(); exists > 0 {
+		return
+	}
+	r.UpdateRatingFrom(userID, amount, "starting", "")
+	log.Printf("Пользователь %s получил стартовый баланс %d", userID, amount)
+	r.LogCreditOperation(s, fmt.Sprintf("🎁 <@%s> впервые пришёл к Императору и получил стартовые %s", userID, formatCredits(amount)))
+}
+
+// HandleFaucetCommand обрабатывает команду !faucet.
+func (r *Ranking) HandleFaucetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !faucet от %s", m.Author.ID)
+
+	maxBalance := r.FaucetMaxBalance()
+	if rating := r.GetRating(m.Author.ID); rating >= maxBalance {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Кран только для тех, у кого меньше %s. У тебя %s — Император не подаёт богатым! 👑", formatCredits(maxBalance), formatCredits(rating)))
+		return
+	}
+
+	cooldown := r.FaucetCooldown()
+	ok, err := r.redis.SetNX(r.ctx, faucetKey(m.Author.ID), time.Now().Unix(), cooldown).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить кулдаун крана %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка крана! Попробуйте позже.")
+		return
+	}
+	if !ok {
+		ttl, _ := r.redis.TTL(r.ctx, faucetKey(m.Author.ID)).Result()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏳ Кран уже использован. Следующий раз <t:%d:R>.", time.Now().Add(ttl).Unix()))
+		return
+	}
+
+	amount := r.FaucetAmount()
+	r.UpdateRatingFrom(m.Author.ID, amount, "faucet", "")
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🚰 <@%s> получил %s из крана Императора. Следующий раз <t:%d:R>.", m.Author.ID, formatCredits(amount), time.Now().Add(cooldown).Unix()))
+}
+
+// faucetSettings — настраиваемые параметры /a_faucet и соответствующие настройки.
+var faucetSettings = map[string]string{
+	"start":    "starting_balance",
+	"amount":   "faucet_amount",
+	"cooldown": "faucet_cooldown_hours",
+	"max":      "faucet_max_balance",
+}
+
+// HandleFaucetSettingsCommand обрабатывает команду !a_faucet [start|amount|cooldown|max <значение>].
+func (r *Ranking) HandleFaucetSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_faucet: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать кран! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_faucet [start|amount|cooldown|max <значение>]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		setting, ok := faucetSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🚰 Стартовый баланс и кран",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🎁 Стартовый баланс", Value: fmt.Sprintf("%d", r.StartingBalance()), Inline: true},
+			{Name: "🚰 Сумма крана", Value: fmt.Sprintf("%d", r.FaucetAmount()), Inline: true},
+			{Name: "⏳ Кулдаун", Value: r.FaucetCooldown().String(), Inline: true},
+			{Name: "💰 Кран доступен до", Value: formatCredits(r.FaucetMaxBalance()), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_faucet start|amount|cooldown|max <значение>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,277 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Флейры — значки перед именем игрока в embed'ах бота (!china, !stats, дуэли).
+// Каталог ведут админы, купленный флейр остаётся у игрока, даже если его сняли с продажи.
+const flairCatalogKey = "flairs:catalog" // хэш ID флейра -> Flair (JSON)
+
+// flairIDPattern — допустимые ID флейров.
+var flairIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,20}$`)
+
+// Flair — косметический значок из каталога.
+type Flair struct {
+	ID      string `json:"id"`
+	Emoji   string `json:"emoji"`
+	Name    string `json:"name"`
+	Price   int    `json:"price"`
+	AddedBy string `json:"added_by"`
+	Retired bool   `json:"retired,omitempty"` // снят с продажи
+}
+
+// flairsOwnedKey возвращает множество купленных флейров пользователя.
+func flairsOwnedKey(userID string) string {
+	return "flairs:owned:" + userID
+}
+
+// flairActiveKey возвращает ключ с выбранным флейром пользователя.
+func flairActiveKey(userID string) string {
+	return "flairs:active:" + userID
+}
+
+// flairCatalog возвращает каталог флейров, отсортированный по цене.
+func (r *Ranking) flairCatalog() ([]Flair, error) {
+	raw, err := r.redis.HGetAll(r.ctx, flairCatalogKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	flairs := make([]Flair, 0, len(raw))
+	for _, data := range raw {
+		var flair Flair
+		if err := json.Unmarshal([]byte(data), &flair); err != nil {
+			log.Printf("Не удалось разобрать флейр: %v", err)
+			continue
+		}
+		flairs = append(flairs, flair)
+	}
+	sort.Slice(flairs, func(i, j int) bool {
+		if flairs[i].Price != flairs[j].Price {
+			return flairs[i].Price < flairs[j].Price
+		}
+		return flairs[i].ID < flairs[j].ID
+	})
+	return flairs, nil
+}
+
+// findFlair ищет флейр в каталоге по ID.
+func (r *Ranking) findFlair(id string) (Flair, bool) {
+	var flair Flair
+	data, err := r.redis.HGet(r.ctx, flairCatalogKey, id).Bytes()
+	if err != nil {
+		return flair, false
+	}
+	return flair, json.Unmarshal(data, &flair) == nil
+}
+
+// ownsFlair проверяет, куплен ли флейр пользователем.
+func (r *Ranking) ownsFlair(userID, flairID string) bool {
+	owned, err := r.redis.SIsMember(r.ctx, flairsOwnedKey(userID), flairID).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить флейр %s пользователя %s: %v", flairID, userID, err)
+	}
+	return owned
+}
+
+// userFlair возвращает значок выбранного флейра с пробелом или пустую строку.
+// У анонимных игроков флейр не показывается, чтобы не выдать их.
+func (r *Ranking) userFlair(userID string) string {
+	if userID == "" || r.isAnonymous(userID) {
+		return ""
+	}
+	id, err := r.redis.Get(r.ctx, flairActiveKey(userID)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось получить флейр пользователя %s: %v", userID, err)
+		}
+		return ""
+	}
+	flair, ok := r.findFlair(id)
+	if !ok {
+		return ""
+	}
+	return flair.Emoji + " "
+}
+
+// flairMention возвращает упоминание игрока с его флейром.
+func (r *Ranking) flairMention(userID string) string {
+	return fmt.Sprintf("%s<@%s>", r.userFlair(userID), userID)
+}
+
+// HandleFlairsCommand обрабатывает команду !flairs: каталог флейров.
+func (r *Ranking) HandleFlairsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !flairs от %s", m.Author.ID)
+
+	flairs, err := r.flairCatalog()
+	if err != nil {
+		log.Printf("Не удалось получить каталог флейров: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка каталога флейров! Попробуйте позже.")
+		return
+	}
+	active, _ := r.redis.Get(r.ctx, flairActiveKey(m.Author.ID)).Result()
+	lines := make([]string, 0, len(flairs))
+	for _, flair := range flairs {
+		owned := r.ownsFlair(m.Author.ID, flair.ID)
+		if flair.Retired && !owned {
+			continue
+		}
+		status := fmt.Sprintf("%s — `/buy_flair %s`", formatCredits(flair.Price), flair.ID)
+		switch {
+		case flair.ID == active:
+			status = "✨ Выбран"
+		case owned:
+			status = fmt.Sprintf("✅ Куплен — `/flair %s`", flair.ID)
+		}
+		lines = append(lines, fmt.Sprintf("%s **%s** (`%s`) · %s", flair.Emoji, flair.Name, flair.ID, status))
+	}
+	if len(lines) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "✨ Каталог флейров пуст. Император ещё не выставил значки! 👑")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "✨ Флейры",
+		Description: truncate("Значок перед твоим именем в /china, /stats и дуэлях.\n\n"+strings.Join(lines, "\n"), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("/flair off — снять · Твой баланс: %s кредитов", compactNumber(r.GetRating(m.Author.ID)))},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// HandleBuyFlairCommand обрабатывает команду !buy_flair <ID>.
+func (r *Ranking) HandleBuyFlairCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !buy_flair: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/buy_flair <ID>`. Каталог: `/flairs`")
+		return
+	}
+	flair, ok := r.findFlair(strings.ToLower(parts[1]))
+	if !ok || flair.Retired {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такого флейра нет в продаже! Каталог: `/flairs`")
+		return
+	}
+	if r.ownsFlair(m.Author.ID, flair.ID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Флейр %s уже твой! Включить: `/flair %s`", flair.Emoji, flair.ID))
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rating := r.GetRating(m.Author.ID)
+	if rating < flair.Price {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Флейр стоит %s, твой баланс: %s", formatCredits(flair.Price), formatCredits(rating)))
+		return
+	}
+	if err := r.redis.SAdd(r.ctx, flairsOwnedKey(m.Author.ID), flair.ID).Err(); err != nil {
+		log.Printf("Не удалось сохранить флейр %s пользователя %s: %v", flair.ID, m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка покупки флейра! Проверьте Redis-сервер.")
+		return
+	}
+	r.UpdateRatingFrom(m.Author.ID, -flair.Price, "flair", "")
+	r.redis.Set(r.ctx, flairActiveKey(m.Author.ID), flair.ID, 0)
+
+	r.LogCreditOperation(s, fmt.Sprintf("✨ <@%s> купил флейр %s **%s** за %s", m.Author.ID, flair.Emoji, flair.Name, formatCredits(flair.Price)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Флейр %s **%s** куплен и включён!", flair.Emoji, flair.Name))
+}
+
+// HandleFlairCommand обрабатывает команду !flair <ID>|off: выбор купленного флейра.
+func (r *Ranking) HandleFlairCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !flair: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/flair <ID>` или `/flair off`. Каталог: `/flairs`")
+		return
+	}
+	if parts[1] == "off" {
+		r.redis.Del(r.ctx, flairActiveKey(m.Author.ID))
+		s.ChannelMessageSend(m.ChannelID, "✅ Флейр снят.")
+		return
+	}
+	id := strings.ToLower(parts[1])
+	flair, ok := r.findFlair(id)
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такого флейра нет! Каталог: `/flairs`")
+		return
+	}
+	if !r.ownsFlair(m.Author.ID, flair.ID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Сначала купи флейр: `/buy_flair %s` (%s)", flair.ID, formatCredits(flair.Price)))
+		return
+	}
+	if err := r.redis.Set(r.ctx, flairActiveKey(m.Author.ID), flair.ID, 0).Err(); err != nil {
+		log.Printf("Не удалось выбрать флейр %s пользователя %s: %v", flair.ID, m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка выбора флейра! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✨ Флейр %s **%s** включён!", flair.Emoji, flair.Name))
+}
+
+// HandleFlairAdminCommand обрабатывает команду !a_flair add <ID> <эмодзи> <цена> <название> | remove <ID>.
+func (r *Ranking) HandleFlairAdminCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_flair: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут управлять флейрами! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_flair add <ID> <эмодзи> <цена> <название>` или `/a_flair remove <ID>`"
+	parts := strings.Fields(command)
+	switch {
+	case len(parts) >= 6 && parts[1] == "add":
+		id := strings.ToLower(parts[2])
+		if !flairIDPattern.MatchString(id) {
+			s.ChannelMessageSend(m.ChannelID, "❌ ID флейра: латиница, цифры, `-` и `_`, до 20 символов.")
+			return
+		}
+		price, err := strconv.Atoi(parts[4])
+		if err != nil || price <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Цена должна быть положительным числом! 💸")
+			return
+		}
+		if _, exists := r.findFlair(id); exists {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Флейр `%s` уже есть в каталоге!", id))
+			return
+		}
+		// Эмодзи и название берём из исходного сообщения: command приведена к нижнему регистру,
+		// а имена кастомных эмодзи (<:Name:id>) чувствительны к регистру
+		original := strings.Fields(m.Content)
+		flair := Flair{ID: id, Emoji: original[3], Name: truncate(strings.Join(original[5:], " "), 50), Price: price, AddedBy: m.Author.ID}
+		data, _ := json.Marshal(flair)
+		if err := r.redis.HSet(r.ctx, flairCatalogKey, id, data).Err(); err != nil {
+			log.Printf("Не удалось сохранить флейр %s: %v", id, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка каталога флейров! Проверьте Redis-сервер.")
+			return
+		}
+		r.LogCreditOperation(s, fmt.Sprintf("✨ <@%s> добавил флейр %s **%s** за %s", m.Author.ID, flair.Emoji, flair.Name, formatCredits(price)))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Флейр %s **%s** продаётся за %s. Купить: `/buy_flair %s`", flair.Emoji, flair.Name, formatCredits(price), id))
+	case len(parts) == 3 && parts[1] == "remove":
+		flair, ok := r.findFlair(strings.ToLower(parts[2]))
+		if !ok || flair.Retired {
+			s.ChannelMessageSend(m.ChannelID, "❌ Такого флейра нет в продаже! Каталог: `/flairs`")
+			return
+		}
+		flair.Retired = true
+		data, _ := json.Marshal(flair)
+		if err := r.redis.HSet(r.ctx, flairCatalogKey, flair.ID, data).Err(); err != nil {
+			log.Printf("Не удалось снять флейр %s с продажи: %v", flair.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка каталога флейров! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Флейр %s снят с продажи. Купившие его игроки сохранят значок.", flair.Emoji))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
@@ -0,0 +1,61 @@
+package ranking
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultCreditEmoji — значок кредитов, если CREDIT_EMOJI не задан.
+const defaultCreditEmoji = "💰"
+
+// creditEmoji — значок кредитов в сообщениях. Можно указать эмодзи сервера: CREDIT_EMOJI=<:yuan:123456789>.
+var creditEmoji = func() string {
+	if emoji := strings.TrimSpace(os.Getenv("CREDIT_EMOJI")); emoji != "" {
+		return emoji
+	}
+	return defaultCreditEmoji
+}()
+
+// compactNumber сокращает большие числа: 12500 → 12.5k, 1234567 → 1.2M.
+// Числа меньше 10 000 выводятся полностью. Дробная часть отбрасывается, чтобы не завышать суммы.
+func compactNumber(n int) string {
+	sign := ""
+	value := int64(n)
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	if value < 10_000 {
+		return sign + strconv.FormatInt(value, 10)
+	}
+	units := []struct {
+		size   int64
+		suffix string
+	}{{1_000_000_000, "B"}, {1_000_000, "M"}, {1_000, "k"}}
+	for _, unit := range units {
+		if value < unit.size {
+			continue
+		}
+		tenths := value * 10 / unit.size
+		if tenths%10 == 0 {
+			return fmt.Sprintf("%s%d%s", sign, tenths/10, unit.suffix)
+		}
+		return fmt.Sprintf("%s%d.%d%s", sign, tenths/10, tenths%10, unit.suffix)
+	}
+	return sign + strconv.FormatInt(value, 10)
+}
+
+// formatCredits форматирует сумму кредитов для сообщений и эмбедов: значок валюты и сокращённое число.
+// В заголовках, футерах и подписях кнопок эмодзи сервера не отображаются — там используйте compactNumber.
+func formatCredits(n int) string {
+	return creditEmoji + " " + compactNumber(n)
+}
+
+// formatCreditsDelta форматирует изменение баланса со знаком: 💰 +50, 💰 -12.5k.
+func formatCreditsDelta(n int) string {
+	if n > 0 {
+		return creditEmoji + " +" + compactNumber(n)
+	}
+	return formatCredits(n)
+}
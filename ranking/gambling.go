@@ -0,0 +1,227 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ответственная игра: игрок сам задаёт дневной лимит проигрыша (!limit) или отлучает себя
+// от игр на срок (!selfban). Проверка встроена в reserveDailyWager, поэтому действует во всех
+// обработчиках ставок. Снижение лимита и отлучение вступают в силу сразу, а повышение или
+// снятие лимита — только через сутки, чтобы решение нельзя было отменить в азарте.
+const (
+	gamblingLimitDelay = 24 * time.Hour
+	selfBanMaxDays     = 365
+)
+
+// gamblingSources — источники журнала кредитов, по которым считается проигрыш за день.
+var gamblingSources = map[string]bool{"blackjack": true, "rb": true, "duel": true}
+
+// GamblingBlockError — отказ в ставке из-за лимита проигрыша или самоотлучения.
+type GamblingBlockError struct {
+	Until     time.Time // конец самоотлучения
+	Limit     int       // дневной лимит проигрыша
+	Remaining int       // сколько ещё можно проиграть сегодня
+}
+
+func (e *GamblingBlockError) Error() string {
+	if !e.Until.IsZero() {
+		return fmt.Sprintf("⛔ Ты отлучил себя от игр до <t:%d:f>. Император гордится твоей выдержкой! 🙏", e.Until.Unix())
+	}
+	return fmt.Sprintf("⛔ Дневной лимит проигрыша: %s. Можно рискнуть ещё %s — поставь меньше или возвращайся завтра.", formatCredits(e.Limit), formatCredits(e.Remaining))
+}
+
+// gamblingKey возвращает хэш настроек ответственной игры (limit, pending, pending_at, selfban_until).
+func gamblingKey(userID string) string {
+	return "gamble:" + userID
+}
+
+// gamblingLossKey возвращает ключ чистого проигрыша игрока за день.
+func gamblingLossKey(userID string, day time.Time) string {
+	return fmt.Sprintf("gamble:loss:%s:%s", userID, day.Format("2006-01-02"))
+}
+
+// recordGamblingResult учитывает изменение баланса от игры в дневном проигрыше.
+// Ставки списываются при размещении, поэтому незавершённые игры тоже считаются проигрышем.
+func (r *Ranking) recordGamblingResult(userID string, delta int, source string) {
+	if delta == 0 || !gamblingSources[source] {
+		return
+	}
+	key := gamblingLossKey(userID, time.Now())
+	r.redis.IncrBy(r.ctx, key, int64(-delta))
+	r.redis.Expire(r.ctx, key, 48*time.Hour)
+}
+
+// applyPendingGamblingLimit применяет отложенное повышение или снятие лимита, если сутки прошли.
+func (r *Ranking) applyPendingGamblingLimit(userID string, settings map[string]string) {
+	at, err := strconv.ParseInt(settings["pending_at"], 10, 64)
+	if err != nil || time.Now().Unix() < at {
+		return
+	}
+	settings["limit"] = settings["pending"]
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(r.ctx, gamblingKey(userID), "limit", settings["pending"])
+	pipe.HDel(r.ctx, gamblingKey(userID), "pending", "pending_at")
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось применить отложенный лимит проигрыша %s: %v", userID, err)
+	}
+	delete(settings, "pending")
+	delete(settings, "pending_at")
+}
+
+// checkGamblingLimits проверяет самоотлучение и дневной лимит проигрыша перед ставкой.
+func (r *Ranking) checkGamblingLimits(userID string, amount int) error {
+	settings, err := r.redis.HGetAll(r.ctx, gamblingKey(userID)).Result()
+	if err != nil || len(settings) == 0 {
+		return nil
+	}
+	if until, err := strconv.ParseInt(settings["selfban_until"], 10, 64); err == nil && time.Now().Unix() < until {
+		return &GamblingBlockError{Until: time.Unix(until, 0)}
+	}
+	r.applyPendingGamblingLimit(userID, settings)
+	limit, _ := strconv.Atoi(settings["limit"])
+	if limit <= 0 {
+		return nil
+	}
+	lost, _ := r.redis.Get(r.ctx, gamblingLossKey(userID, time.Now())).Int()
+	if lost+amount > limit {
+		return &GamblingBlockError{Limit: limit, Remaining: max(limit-lost, 0)}
+	}
+	return nil
+}
+
+// HandleLimitCommand обрабатывает команду !limit [set <дневной проигрыш>|off].
+func (r *Ranking) HandleLimitCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !limit: %s от %s", command, m.Author.ID)
+
+	userID := m.Author.ID
+	parts := strings.Fields(command)
+	settings, err := r.redis.HGetAll(r.ctx, gamblingKey(userID)).Result()
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+		return
+	}
+	r.applyPendingGamblingLimit(userID, settings)
+	current, _ := strconv.Atoi(settings["limit"])
+
+	if len(parts) == 1 {
+		s.ChannelMessageSendEmbed(m.ChannelID, r.gamblingStatusEmbed(userID, settings))
+		return
+	}
+
+	var next int
+	switch {
+	case len(parts) == 2 && parts[1] == "off":
+		next = 0
+	case len(parts) == 3 && parts[1] == "set":
+		next, err = strconv.Atoi(parts[2])
+		if err != nil || next <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Лимит должен быть положительным числом!")
+			return
+		}
+	default:
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/limit` — статус, `/limit set <дневной проигрыш>` или `/limit off`")
+		return
+	}
+
+	// Ужесточение — сразу, ослабление — через сутки
+	if current == 0 && next > 0 || next > 0 && next < current {
+		pipe := r.redis.TxPipeline()
+		pipe.HSet(r.ctx, gamblingKey(userID), "limit", next)
+		pipe.HDel(r.ctx, gamblingKey(userID), "pending", "pending_at")
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+			return
+		}
+		log.Printf("Пользователь %s установил дневной лимит проигрыша %d", userID, next)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Дневной лимит проигрыша: **%s**. Действует сразу.", formatCredits(next)))
+		return
+	}
+	if current == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Лимит проигрыша и так не установлен.")
+		return
+	}
+	at := time.Now().Add(gamblingLimitDelay)
+	if err := r.redis.HSet(r.ctx, gamblingKey(userID), "pending", next, "pending_at", at.Unix()).Err(); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+		return
+	}
+	log.Printf("Пользователь %s запросил смену лимита проигрыша %d -> %d", userID, current, next)
+	what := "снят"
+	if next > 0 {
+		what = "повышен до " + formatCredits(next)
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏳ Лимит будет %s <t:%d:R>. До тех пор действует текущий: %s.", what, at.Unix(), formatCredits(current)))
+}
+
+// HandleSelfBanCommand обрабатывает команду !selfban <дней> [confirm].
+func (r *Ranking) HandleSelfBanCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !selfban: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	usage := fmt.Sprintf("❌ Используй: `/selfban <дней>` (1–%d)", selfBanMaxDays)
+	if len(parts) < 2 || len(parts) > 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	days, err := strconv.Atoi(parts[1])
+	if err != nil || days < 1 || days > selfBanMaxDays {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	until := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	if len(parts) == 2 || parts[2] != "confirm" {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⚠️ Блэкджек, красное-чёрное и дуэли будут недоступны до <t:%d:f>. Отменить или сократить срок нельзя.\nПодтверди: `/selfban %d confirm`", until.Unix(), days))
+		return
+	}
+
+	current, _ := r.redis.HGet(r.ctx, gamblingKey(m.Author.ID), "selfban_until").Int64()
+	if current >= until.Unix() {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Ты уже отлучён до <t:%d:f> — срок можно только продлить.", current))
+		return
+	}
+	if err := r.redis.HSet(r.ctx, gamblingKey(m.Author.ID), "selfban_until", until.Unix()).Err(); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+		return
+	}
+	log.Printf("Пользователь %s отлучил себя от игр на %d дней", m.Author.ID, days)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Ты отлучён от игр до <t:%d:f>. Береги себя! 🙏", until.Unix()))
+}
+
+// gamblingStatusEmbed показывает лимит проигрыша и самоотлучение игрока.
+func (r *Ranking) gamblingStatusEmbed(userID string, settings map[string]string) *discordgo.MessageEmbed {
+	limit, _ := strconv.Atoi(settings["limit"])
+	lost, _ := r.redis.Get(r.ctx, gamblingLossKey(userID, time.Now())).Int()
+	limitText := "не установлен"
+	if limit > 0 {
+		limitText = fmt.Sprintf("%s (осталось %s)", formatCredits(limit), formatCredits(max(limit-lost, 0)))
+	}
+	if pending, ok := settings["pending"]; ok {
+		at, _ := strconv.ParseInt(settings["pending_at"], 10, 64)
+		next, _ := strconv.Atoi(pending)
+		change := "снятие"
+		if next > 0 {
+			change = "повышение до " + formatCredits(next)
+		}
+		limitText += fmt.Sprintf("\n⏳ %s <t:%d:R>", change, at)
+	}
+	selfban := "нет"
+	if until, err := strconv.ParseInt(settings["selfban_until"], 10, 64); err == nil && time.Now().Unix() < until {
+		selfban = fmt.Sprintf("до <t:%d:f>", until)
+	}
+	return &discordgo.MessageEmbed{
+		Title: "🛡️ Ответственная игра",
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📉 Проигрыш сегодня", Value: formatCredits(max(lost, 0)), Inline: true},
+			{Name: "🎯 Дневной лимит", Value: limitText, Inline: true},
+			{Name: "⛔ Самоотлучение", Value: selfban, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/limit set <сумма> · /limit off · /selfban <дней>"},
+	}
+}
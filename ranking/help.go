@@ -0,0 +1,439 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HelpCategory описывает раздел интерактивной справки.
+type HelpCategory struct {
+	ID          string
+	Name        string
+	Emoji       string
+	Description string
+}
+
+// CommandInfo описывает команду для справки.
+type CommandInfo struct {
+	Usage       string
+	Description string
+	Category    string
+	Admin       bool
+	Economy     bool     // команда изменяет балансы или инвентари (блокируется в режиме обслуживания)
+	Aliases     []string // альтернативные имена команды
+}
+
+// HelpCategories — разделы справки в порядке отображения.
+var HelpCategories = []HelpCategory{
+	{ID: "economy", Name: "Экономика", Emoji: "💰", Description: "Баланс, переводы, топы, курс BTC"},
+	{ID: "games", Name: "Игры", Emoji: "🎰", Description: "Красный-Чёрный, блэкджек, дуэли, ставки на опросы"},
+	{ID: "nft", Name: "NFT и кейсы", Emoji: "🃏", Description: "Инвентарь, кейсы, продажа и обмен NFT"},
+	{ID: "cinema", Name: "Кино", Emoji: "🎥", Description: "Киноаукцион: предложения и ставки"},
+	{ID: "admin", Name: "Админ", Emoji: "👑", Description: "Команды для администраторов"},
+}
+
+// commandRegistry — список всех команд бота. Новые команды добавляются через RegisterCommand,
+// чтобы справка не расходилась с роутером.
+var commandRegistry = []CommandInfo{
+	{Usage: "/china [@id]", Description: "Узнай свой баланс или баланс другого игрока: доступные кредиты и замороженные в ставках.", Category: "economy"},
+	{Usage: "/networth [@id]", Description: "Состояние игрока: кредиты, вклад, NFT и кейсы по текущим ценам и место среди всех игроков.", Category: "economy"},
+	{Usage: "/top [rating|voice|duels|gamblers|collectors|nft] [страница] [size:N]", Description: "Посмотри топ-5 по кредитам или полный топ с кнопками ◀️ ▶️: по кредитам, войсу, победам в дуэлях, выигрышу в играх, числу разных NFT или стоимости NFT.", Category: "economy", Aliases: []string{"/top5"}},
+	{Usage: "/top_voice [страница]", Description: "Топ по времени в войсе.", Category: "economy"},
+	{Usage: "/top_duels [страница]", Description: "Топ по победам в дуэлях.", Category: "economy"},
+	{Usage: "/top_gamblers [страница]", Description: "Топ по чистому выигрышу в блэкджеке, красном-чёрном и дуэлях.", Category: "economy"},
+	{Usage: "/top_collectors [страница]", Description: "Топ коллекционеров по числу разных NFT.", Category: "economy"},
+	{Usage: "/stats", Description: "Проверь свою статистику: кредиты, игры, время в голосовых каналах.", Category: "economy"},
+	{Usage: "/profile [@user]", Description: "Профиль игрока: баланс, коллекция и витрина NFT.", Category: "economy"},
+	{Usage: "/limit [set <сумма>|off]", Description: "Ответственная игра: дневной лимит проигрыша в блэкджеке, красном-чёрном и дуэлях.", Category: "economy"},
+	{Usage: "/selfban <дней>", Description: "Отлучить себя от игр на срок. Отменить нельзя.", Category: "economy"},
+	{Usage: "/jade", Description: "Твой нефрит 💠, курс обмена и нефритовая лавка.", Category: "economy"},
+	{Usage: "/exchange <кол-во>", Description: "Обменять нефрит на кредиты по курсу, зависящему от BTC.", Category: "economy", Economy: true},
+	{Usage: "/buy_jade <nft|case> <ID> [кол-во]", Description: "Купить NFT или кейс в нефритовой лавке.", Category: "economy", Economy: true},
+	{Usage: "/bank", Description: "Твой вклад в банке и дневная ставка.", Category: "economy"},
+	{Usage: "/deposit <сумма|all>", Description: "Положить кредиты на вклад под проценты.", Category: "economy", Economy: true},
+	{Usage: "/withdraw <сумма|all>", Description: "Снять кредиты со вклада.", Category: "economy", Economy: true},
+	{Usage: "/mydata", Description: "Получить в ЛС JSON со всеми данными, которые бот хранит о тебе.", Category: "economy"},
+	{Usage: "/loan [сумма]", Description: "Взять займ у казино или посмотреть текущий долг.", Category: "economy", Economy: true},
+	{Usage: "/repay <сумма|all>", Description: "Погасить займ досрочно.", Category: "economy", Economy: true},
+	{Usage: "/season", Description: "Текущий сезон, чемпионы прошлых сезонов и твой престиж.", Category: "economy"},
+	{Usage: "/anon [on|off]", Description: "Анонимный режим: скрыть имя в топах и витрине крупных выигрышей.", Category: "economy"},
+	{Usage: "/quiet [<с>-<до> [часовой пояс]|tz <пояс>|off]", Description: "Тихие часы: бот не пишет в ЛС, важное присылает одним сообщением после.", Category: "economy"},
+	{Usage: "/shop [buy <ID>]", Description: "Магазин ролей: список и покупка роли за кредиты.", Category: "economy", Economy: true},
+	{Usage: "/shop @user", Description: "Витрина лавки игрока. Все лавки: /shops.", Category: "nft", Economy: true},
+	{Usage: "/shops", Description: "Список открытых лавок игроков.", Category: "nft", Economy: true},
+	{Usage: "/shop_open [название]", Description: "Открыть свою лавку (или переименовать).", Category: "nft", Economy: true},
+	{Usage: "/shop_add nft|case <ID> <кол-во> <цена>", Description: "Выставить NFT или кейсы в свою лавку по своей цене. Товар удерживается в эскроу до продажи.", Category: "nft", Economy: true},
+	{Usage: "/shop_remove nft|case <ID>", Description: "Снять позицию из лавки и вернуть товар.", Category: "nft", Economy: true},
+	{Usage: "/shop_close", Description: "Закрыть лавку и вернуть весь товар.", Category: "nft", Economy: true},
+	{Usage: "/shop_buy @user nft|case <ID> [кол-во]", Description: "Купить товар в лавке игрока. С покупки берётся комиссия в общий фонд.", Category: "nft", Economy: true},
+	{Usage: "/history [@user] [n]", Description: "Последние операции с кредитами: игры, переводы, админ, войс.", Category: "economy"},
+	{Usage: "/transfer @id <сумма> <причина>", Description: "Передать кредиты другому (с суммы может удерживаться налог).", Category: "economy", Economy: true},
+	{Usage: "/faucet", Description: "Кран для бедных: немного кредитов раз в сутки, если баланс почти нулевой.", Category: "economy", Economy: true},
+	{Usage: "/btc", Description: "Текущий курс биткойна.", Category: "economy"},
+	{Usage: "/prices", Description: "Динамика цен NFT по редкостям.", Category: "economy", Aliases: []string{"/price_stats"}},
+	{Usage: "/chelp [--admin]", Description: "Покажи это руководство (--admin — с командами админов).", Category: "economy", Aliases: []string{"/help"}},
+
+	{Usage: "/rb", Description: "Начни игру в Красный-Чёрный.", Category: "games"},
+	{Usage: "/rb <red/black> <сумма>", Description: "Сделай ставку в Красный-Чёрный.", Category: "games", Economy: true},
+	{Usage: "/blackjack", Description: "Начни игру в Блэкджек.", Category: "games"},
+	{Usage: "/blackjack <сумма> [pp:<сумма>] [21+3:<сумма>]", Description: "Сделай ставку в Блэкджеке. Побочные ставки: Perfect Pairs (до 25:1) и 21+3 (до 100:1).", Category: "games", Economy: true},
+	{Usage: "/duel <сумма>", Description: "Вызови любого на дуэль с указанной ставкой.", Category: "games", Economy: true},
+	{Usage: "/polls", Description: "Посмотри активные опросы.", Category: "games"},
+	{Usage: "/vote Вопрос [Вариант1] [Вариант2] ...", Description: "Быстрое голосование кнопками без ставок (без вариантов — Да/Нет).", Category: "games"},
+	{Usage: "/dep <ID_опроса> <номер_варианта> <сумма>", Description: "Поставь кредиты на вариант в опросе.", Category: "games", Economy: true},
+	{Usage: "/themes", Description: "Магазин тем для игр.", Category: "games"},
+	{Usage: "/buy_theme <ID>", Description: "Купить тему (classic, cyberpunk, imperial).", Category: "games", Economy: true},
+	{Usage: "/theme <ID>", Description: "Включить купленную тему.", Category: "games"},
+	{Usage: "/tutorial", Description: "Обучение новичка: баланс, ежедневная награда, первый кейс в подарок и продажа NFT — с наградой за каждый шаг.", Category: "economy"},
+	{Usage: "/flairs", Description: "Каталог флейров — значков перед именем в /china, /stats и дуэлях.", Category: "economy"},
+	{Usage: "/buy_flair <ID>", Description: "Купить флейр за кредиты.", Category: "economy", Economy: true},
+	{Usage: "/flair <ID>|off", Description: "Включить купленный флейр или снять его.", Category: "economy"},
+
+	{Usage: "/inventory [страница] [size:N] [rarity:…] [collection:…] [name:…]", Description: "Мои NFT постранично, с общей стоимостью и фильтрами.", Category: "nft"},
+	{Usage: "/nft_show <ID>", Description: "Показать NFT.", Category: "nft"},
+	{Usage: "/showcase add|remove <ID>", Description: "Закрепить до 3 NFT на витрине в /stats и /profile.", Category: "nft"},
+	{Usage: "/wishlist [add|remove <ID>|clear]", Description: "Список желаний: оповещение в ЛС, когда NFT выставят на рынок или в лавку, она выпадет в крупном дропе или её предложат в обмене.", Category: "nft"},
+	{Usage: "/nft_chart <ID> [дней]", Description: "График цены NFT по каждому обновлению цен за последние дни.", Category: "nft"},
+	{Usage: "/sell <ID> <count>", Description: "Продать NFT.", Category: "nft", Economy: true},
+	{Usage: "/sell_duplicates", Description: "Продать все дубликаты.", Category: "nft", Economy: true},
+	{Usage: "/sell_menu [rarity:] [collection:] [name:]", Description: "Продать несколько NFT разом: выбери их в меню, поправь количество и подтверди одной кнопкой.", Category: "nft", Economy: true},
+	{Usage: "/trade_nft @user <ID> <count>", Description: "Передать NFT.", Category: "nft", Economy: true},
+	{Usage: "/craft [редкость]", Description: "Крафт: переплавить дубликаты в случайную NFT следующей редкости (например, 10 Common → 1 Rare). Без аргумента — рецепты.", Category: "nft", Economy: true},
+	{Usage: "/offer @user", Description: "Сложный обмен: обе стороны добавляют NFT, кейсы и кредиты (`/offer add|remove`), подтверждают кнопкой — и обмен проходит целиком через эскроу.", Category: "nft", Economy: true},
+	{Usage: "/market [list [фильтры] | sell <ID> <count> <цена> | buy <лот> | cancel <лот> | my]", Description: "Рынок игроков: лоты NFT удерживаются в эскроу до покупки, снятия или истечения срока.", Category: "nft", Economy: true},
+	{Usage: "/trade_collection @user <коллекция>", Description: "Передать все свои NFT коллекции одной операцией.", Category: "nft", Economy: true},
+	{Usage: "/top_inventories", Description: "Топ-10 инвентарей.", Category: "nft"},
+	{Usage: "/case_inventory", Description: "Мои кейсы.", Category: "nft"},
+	{Usage: "/open_case <ID>", Description: "Открыть кейс.", Category: "nft", Economy: true},
+	{Usage: "/daily", Description: "Ежедневная награда: чем длиннее серия дней подряд, тем больше кредитов.", Category: "economy", Economy: true},
+	{Usage: "/daily_case", Description: "Ежедневный кейс.", Category: "nft", Economy: true},
+	{Usage: "/case_bank", Description: "Кейсы в банке.", Category: "nft"},
+	{Usage: "/buy_case_bank <ID> <count>", Description: "Купить кейсы из банка.", Category: "nft", Economy: true},
+	{Usage: "/case_trade @user <ID> <count>", Description: "Купить кейс у игрока: кредиты блокируются, пока продавец не подтвердит сделку.", Category: "nft", Economy: true, Aliases: []string{"/buy_case_from"}},
+	{Usage: "/case_help [--admin]", Description: "Справка по кейсам и NFT.", Category: "nft"},
+
+	{Usage: "/cinema <название> <сумма>", Description: "Предложить новый вариант на киноаукцион.", Category: "cinema", Economy: true},
+	{Usage: "/betcinema <номер> <сумма>", Description: "Поставить на существующий вариант.", Category: "cinema", Economy: true, Aliases: []string{"/bet_cinema"}},
+	{Usage: "/cinemalist", Description: "Посмотреть актуальные варианты.", Category: "cinema", Aliases: []string{"/cinema_list"}},
+
+	{Usage: "/admin @id <сумма> [причина]", Description: "Начисли или забери кредиты у пользователя.", Category: "admin", Admin: true},
+	{Usage: "/adminmass <+/-/=сумма> @id1 @id2 ... [причина]", Description: "Массовое изменение рейтинга.", Category: "admin", Admin: true},
+	{Usage: "/a_bulk_grant + CSV", Description: "Массовое начисление из приложенного CSV (userID, сумма, причина): всё или ничего, с итоговым отчётом.", Category: "admin", Admin: true},
+	{Usage: "/a_bank [rate|max <значение>]", Description: "Настройки банка: дневная ставка в б.п. и максимальный вклад.", Category: "admin", Admin: true},
+	{Usage: "/a_season_end confirm", Description: "Завершить сезон: архив балансов, престиж призёрам, сброс экономики.", Category: "admin", Admin: true, Economy: true},
+	{Usage: "/shop_add_role @роль <цена>", Description: "Выставить роль Discord в магазин за кредиты.", Category: "admin", Admin: true},
+	{Usage: "/shop_remove_role <ID>", Description: "Снять роль с продажи.", Category: "admin", Admin: true},
+	{Usage: "/a_jade give @user <кол-во> | price <nft|case> <ID> <цена|off> | rate <кредитов>", Description: "Нефрит: выдать игроку, назначить цену товару в лавке, базовый курс обмена.", Category: "admin", Admin: true},
+	{Usage: "/a_debts [cap|rate <значение>]", Description: "Отчёт о долгах игроков; лимит долга (0 — без минуса) и дневная ставка в б.п.", Category: "admin", Admin: true},
+	{Usage: "/a_inflation [target <в сутки>|min <процент>|games on|off]", Description: "Регулятор инфляции: отчёт об эмиссии и снижение начислений за войс и игры.", Category: "admin", Admin: true},
+	{Usage: "/a_transfer [accept|cap|cooldown <значение>] | @user [cap|cooldown <значение>|reset]", Description: "Переводы: согласие получателя, дневной лимит и пауза, индивидуальные лимиты игрока.", Category: "admin", Admin: true},
+	{Usage: "/a_stream [bonus|viewer <в час>]", Description: "Бонус за Go Live: сколько кредитов в час получают стример и зрители его стрима.", Category: "admin", Admin: true},
+	{Usage: "/a_afk [minutes|percent <значение>]", Description: "AFK в войсе: через сколько минут без микрофона и какая доля кредитов начисляется.", Category: "admin", Admin: true},
+	{Usage: "/a_event #войс <минут> <множитель> | stop #войс", Description: "Ивент в войсе: кредиты за войс в канале с множителем и живой счётчик минут.", Category: "admin", Admin: true},
+	{Usage: "/a_tax [rate <процент> | mode burn|pot | payout @user]", Description: "Налог на переводы: ставка, сжигание или общий фонд, выплата фонда.", Category: "admin", Admin: true, Economy: true},
+	{Usage: "/a_flair add <ID> <эмодзи> <цена> <название> | remove <ID>", Description: "Добавить флейр в каталог или снять с продажи (купившие сохраняют значок).", Category: "admin", Admin: true},
+	{Usage: "/a_bracket [create <название> <взнос> | start <ID> | win <ID> @user | cancel <ID>]", Description: "Турнир на выбывание: запись кнопкой со взносом, сетка с проходами без игры, весь банк — чемпиону.", Category: "admin", Admin: true},
+	{Usage: "/a_airdrop [now | every <минут> | amount <сумма> | winners <N>]", Description: "Аирдропы во флуд-канале: первые N нажавших «Схватить!» делят банк.", Category: "admin", Admin: true},
+	{Usage: "/a_payroll [add @user <сумма> <daily|weekly> | remove @user]", Description: "Регулярные выплаты игрокам (например, модераторам) по расписанию.", Category: "admin", Admin: true},
+	{Usage: "/a_ubi [amount <сумма> | exclude|include #канал|@роль]", Description: "Еженедельный базовый доход: сумма и исключённые каналы/роли.", Category: "admin", Admin: true},
+	{Usage: "/a_loan [max|interest|days|repay <значение>]", Description: "Настройки займов казино.", Category: "admin", Admin: true},
+	{Usage: "/a_wipe @user", Description: "Перенести данные игрока в архив (удаление по запросу).", Category: "admin", Admin: true},
+	{Usage: "/a_restore @user", Description: "Вернуть данные игрока из архива.", Category: "admin", Admin: true},
+	{Usage: "/watch @user [порог|off]", Description: "Сообщать в канал логов о крупных изменениях баланса игрока.", Category: "admin", Admin: true},
+	{Usage: "/cpoll Вопрос [Вариант1] [Вариант2] ...", Description: "Создай опрос.", Category: "admin", Admin: true},
+	{Usage: "/closedep <ID_опроса> <номер>", Description: "Закрой опрос и распредели выигрыши.", Category: "admin", Admin: true},
+	{Usage: "/endblackjack @id", Description: "Заверши игру в Блэкджек пользователя.", Category: "admin", Admin: true},
+	{Usage: "/admincinemalist", Description: "Детальный список вариантов кино.", Category: "admin", Admin: true},
+	{Usage: "/removelowest <число>", Description: "Удалить <число> самых низких вариантов кино.", Category: "admin", Admin: true},
+	{Usage: "/adjustcinema <номер> <+/-сумма>", Description: "Корректировать сумму кино-варианта.", Category: "admin", Admin: true},
+	{Usage: "/mergecinema <номер1> <номер2>", Description: "Влить кино-вариант №2 в №1 вместе со ставками.", Category: "admin", Admin: true},
+	{Usage: "/removecinema @id <номер>", Description: "Удалить вариант, предложенный пользователем.", Category: "admin", Admin: true},
+	{Usage: "/sync_nfts", Description: "Синхронизация NFT и кейсов с Google Sheets.", Category: "admin", Admin: true},
+	{Usage: "/a_rarity [<редкость> emoji|color <значение>|reset]", Description: "Эмодзи (включая эмодзи сервера) и цвета редкостей в инвентаре, дропах и статистике цен.", Category: "admin", Admin: true},
+	{Usage: "/a_craft <редкость> <кол-во>", Description: "Рецепт крафта: сколько дубликатов исходной редкости нужно на одну NFT следующей (0 — отключить).", Category: "admin", Admin: true},
+	{Usage: "/a_releases", Description: "Расписание релизов коллекций из листа Releases: тизеры до выхода, запуск с кейсом в банке.", Category: "admin", Admin: true},
+	{Usage: "/a_give_case @user <ID>", Description: "Выдать кейс.", Category: "admin", Admin: true},
+	{Usage: "/a_give_nft @user <ID> <count>", Description: "Выдать NFT.", Category: "admin", Admin: true},
+	{Usage: "/a_remove_nft @user <ID> <count>", Description: "Удалить NFT.", Category: "admin", Admin: true},
+	{Usage: "/a_inventory @user", Description: "Инвентарь NFT игрока (по страницам).", Category: "admin", Admin: true},
+	{Usage: "/a_cases @user", Description: "Инвентарь кейсов игрока.", Category: "admin", Admin: true},
+	{Usage: "/a_recall <номер> | @user", Description: "Откатить операцию с NFT по журналу (кроме оплаченных сделок) или показать журнал игрока.", Category: "admin", Admin: true},
+	{Usage: "/a_holiday_case @user <count>", Description: "Выдать праздничные кейсы игроку.", Category: "admin", Admin: true},
+	{Usage: "/a_give_holiday_case_all <count>", Description: "Выдать праздничные кейсы всем.", Category: "admin", Admin: true},
+	{Usage: "/a_refresh_bank", Description: "Обновить банк кейсов.", Category: "admin", Admin: true},
+	{Usage: "/a_reset_case_limits", Description: "Сбросить лимиты кейсов.", Category: "admin", Admin: true},
+	{Usage: "/test_clear_all_nfts", Description: "Очистить все NFT.", Category: "admin", Admin: true},
+	{Usage: "/a_wager_cap [сумма]", Description: "Дневной лимит ставок на пользователя (0 — без лимита).", Category: "admin", Admin: true},
+	{Usage: "/a_economy", Description: "Снимок экономики сервера.", Category: "admin", Admin: true},
+	{Usage: "/a_retention [days <N> | decay <процент>]", Description: "Удержание игроков и списание за неактивность.", Category: "admin", Admin: true},
+	{Usage: "/a_jobs [run <имя>]", Description: "Состояние фоновых задач.", Category: "admin", Admin: true},
+	{Usage: "/a_ratelimit [<n>|unmute @user]", Description: "Лимит команд в минуту на игрока и муты за спам.", Category: "admin", Admin: true},
+	{Usage: "/a_reconcile", Description: "Сверить замороженные кредиты с кино-ставками, лотами, лавками и обменами.", Category: "admin", Admin: true},
+	{Usage: "/a_duel_tie [процент]", Description: "Шанс ничьей в дуэли: обе ставки возвращаются без комиссии.", Category: "admin", Admin: true},
+	{Usage: "/a_timeout [bj|rb|duel минуты]", Description: "Тайм-ауты игр.", Category: "admin", Admin: true},
+	{Usage: "/a_shards", Description: "Шарды бота в этом процессе: гильдии, пинг и состояние соединения.", Category: "admin", Admin: true},
+	{Usage: "/a_sync_commands", Description: "Синхронизировать slash-команды с Discord.", Category: "admin", Admin: true},
+	{Usage: "/a_bet_limits [<игра> <min> <max> | @id <min> <max> | @id reset]", Description: "Лимиты ставок по играм и для отдельных игроков.", Category: "admin", Admin: true},
+	{Usage: "/a_announce <шаблон> [заголовок |] <текст>", Description: "Объявление по шаблону (event, patch, winner) в каналы и Telegram. templates | save | delete — управление шаблонами.", Category: "admin", Admin: true},
+	{Usage: "/a_faucet [start|amount|cooldown|max <значение>]", Description: "Стартовый баланс новичков и настройки крана.", Category: "admin", Admin: true},
+	{Usage: "/a_maintenance on [причина] | off", Description: "Режим техработ: пауза игр, торговли и кейсов.", Category: "admin", Admin: true},
+}
+
+// RegisterCommand добавляет команду в справку.
+func RegisterCommand(info CommandInfo) {
+	commandRegistry = append(commandRegistry, info)
+}
+
+// CommandsInCategory возвращает команды раздела справки в порядке регистрации.
+func CommandsInCategory(category string) []CommandInfo {
+	return visibleCommands(category, true)
+}
+
+// visibleCommands возвращает команды раздела, скрывая админские, если showAdmin == false.
+func visibleCommands(category string, showAdmin bool) []CommandInfo {
+	var result []CommandInfo
+	for _, info := range commandRegistry {
+		if info.Category == category && (showAdmin || !info.Admin) {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// visibleCategories возвращает разделы справки, в которых есть доступные команды.
+func visibleCategories(showAdmin bool) []HelpCategory {
+	var result []HelpCategory
+	for _, category := range HelpCategories {
+		if len(visibleCommands(category.ID, showAdmin)) > 0 {
+			result = append(result, category)
+		}
+	}
+	return result
+}
+
+// hasAdminFlag сообщает, передан ли флаг --admin.
+func hasAdminFlag(command string) bool {
+	for _, field := range strings.Fields(strings.ToLower(command)) {
+		if field == "--admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// commandName возвращает имя команды (первое слово) из строки использования или ввода.
+func commandName(text string) string {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// LookupCommand ищет команду в реестре по имени или псевдониму.
+func LookupCommand(command string) (CommandInfo, bool) {
+	name := commandName(command)
+	for _, info := range commandRegistry {
+		if commandName(info.Usage) == name {
+			return info, true
+		}
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return info, true
+			}
+		}
+	}
+	return CommandInfo{}, false
+}
+
+// CanonicalCommand заменяет псевдоним в начале команды на основное имя из реестра,
+// чтобы роутер сопоставлял только основные имена, а все документированные псевдонимы работали.
+func CanonicalCommand(command string) string {
+	name := commandName(command)
+	for _, info := range commandRegistry {
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return commandName(info.Usage) + strings.TrimPrefix(strings.TrimLeft(command, " "), name)
+			}
+		}
+	}
+	return command
+}
+
+// IsEconomyCommand сообщает, изменяет ли команда балансы или инвентари.
+// Сравнение идёт по имени, поэтому /rb и /blackjack считаются экономическими в любой форме.
+func IsEconomyCommand(command string) bool {
+	name := commandName(command)
+	for _, info := range commandRegistry {
+		if !info.Economy {
+			continue
+		}
+		if commandName(info.Usage) == name {
+			return true
+		}
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// helpCategory ищет раздел справки по ID.
+func helpCategory(id string) (HelpCategory, bool) {
+	for _, category := range HelpCategories {
+		if category.ID == id {
+			return category, true
+		}
+	}
+	return HelpCategory{}, false
+}
+
+// helpOverviewEmbed формирует стартовую страницу справки.
+func helpOverviewEmbed(showAdmin bool) *discordgo.MessageEmbed {
+	categories := visibleCategories(showAdmin)
+	fields := make([]*discordgo.MessageEmbedField, 0, len(categories))
+	for _, category := range categories {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s %s (%d)", category.Emoji, category.Name, len(visibleCommands(category.ID, showAdmin))),
+			Value:  category.Description,
+			Inline: true,
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "📜 Руководство по ChinaBot 🇨🇳",
+		Description: "Добро пожаловать в мир соцкредитов! Выбери раздел в меню ниже, чтобы увидеть команды. 🚀",
+		Color:       0xFFD700, // Золотой цвет
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Славь Императора и собирай кредиты! 👑 | Бот создан для веселья и рейтингов",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// helpCategoryEmbed формирует страницу справки для раздела.
+func helpCategoryEmbed(category HelpCategory, showAdmin bool) *discordgo.MessageEmbed {
+	var lines []string
+	for _, info := range visibleCommands(category.ID, showAdmin) {
+		lines = append(lines, fmt.Sprintf("`%s`\n%s", info.Usage, info.Description))
+	}
+	description := strings.Join(lines, "\n\n")
+	if description == "" {
+		description = "В этом разделе пока нет команд."
+	}
+	if category.ID == "admin" {
+		description = "🔒 Только для администраторов.\n\n" + description
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", category.Emoji, category.Name),
+		Description: description,
+		Color:       0xFFD700,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Выбери другой раздел в меню ниже 👇",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// helpComponents формирует меню выбора раздела справки.
+// Полная справка (с админскими командами) использует отдельный CustomID, чтобы выбор раздела сохранял режим.
+func helpComponents(selected string, showAdmin bool) []discordgo.MessageComponent {
+	customID := "help_category"
+	if showAdmin {
+		customID = "help_category_admin"
+	}
+	categories := visibleCategories(showAdmin)
+	options := make([]discordgo.SelectMenuOption, 0, len(categories))
+	for _, category := range categories {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       category.Name,
+			Value:       category.ID,
+			Description: category.Description,
+			Emoji:       &discordgo.ComponentEmoji{Name: category.Emoji},
+			Default:     category.ID == selected,
+		})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    customID,
+					Placeholder: "📚 Выбери раздел справки",
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// HandleChelpCommand обрабатывает команду !chelp [--admin].
+// Админские команды видны только администраторам и только с флагом --admin.
+func (r *Ranking) HandleChelpCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !chelp: %s от %s", command, m.Author.ID)
+
+	showAdmin := hasAdminFlag(command)
+	if showAdmin && !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Полная справка доступна только админам! 🔒")
+		return
+	}
+
+	overview := helpOverviewEmbed(showAdmin)
+	if r.IsAdmin(m.Author.ID) && !showAdmin {
+		overview.Footer.Text = "👑 Админские команды: /chelp --admin"
+	}
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embed:      overview,
+		Components: helpComponents("", showAdmin),
+	})
+	if err != nil {
+		log.Printf("Не удалось отправить справку: %v", err)
+	}
+}
+
+// HandleHelpSelect обрабатывает выбор раздела в меню справки и обновляет сообщение на месте.
+func (r *Ranking) HandleHelpSelect(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return
+	}
+	showAdmin := i.MessageComponentData().CustomID == "help_category_admin"
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+	if showAdmin && !r.IsAdmin(userID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Полная справка доступна только админам! Вызови `/chelp`.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	category, ok := helpCategory(values[0])
+	if !ok || len(visibleCommands(category.ID, showAdmin)) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Раздел справки не найден!", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{helpCategoryEmbed(category, showAdmin)},
+			Components: helpComponents(category.ID, showAdmin),
+		},
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить справку: %v", err)
+	}
+}
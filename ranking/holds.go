@@ -0,0 +1,99 @@
+package ranking
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Удержания кредитов под ставки. Hold замораживает кредиты на время игры или аукциона,
+// Capture окончательно списывает их, Release возвращает владельцу. Удержания строятся на эскроу:
+// замороженная сумма уже списана с баланса, учитывается в escrow:held и видна в !china.
+
+// betHoldTTL — страховочный срок удержания ставки в играх, которые живут только в памяти бота:
+// если бот перезапустится посреди игры, escrow_sweeper вернёт ставку владельцу.
+const betHoldTTL = 24 * time.Hour
+
+// Hold замораживает amount кредитов пользователя и возвращает ID удержания.
+// source — источник операции в журнале кредитов (duel, blackjack, cinema).
+// ttl <= 0 — удержание без срока: его обязательно нужно подтвердить или вернуть.
+func (r *Ranking) Hold(userID string, amount int, source string, ttl time.Duration) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.holdLocked(userID, amount, source, ttl)
+}
+
+// holdLocked — Hold для вызова под r.mu.
+func (r *Ranking) holdLocked(userID string, amount int, source string, ttl time.Duration) (string, error) {
+	if amount <= 0 {
+		return "", fmt.Errorf("сумма удержания должна быть положительной")
+	}
+	hold := EscrowHold{
+		ID:      source + ":" + generateGameID(userID),
+		Owner:   userID,
+		Reason:  source,
+		Source:  source,
+		Credits: amount,
+	}
+	if err := r.escrowReserveLocked(hold, ttl); err != nil {
+		return "", err
+	}
+	return hold.ID, nil
+}
+
+// Capture окончательно списывает удержанные кредиты и возвращает их сумму.
+// Ошибка errEscrowClosed означает, что удержание уже возвращено владельцу.
+func (r *Ranking) Capture(holdID string) (int, error) {
+	hold, err := r.escrowCommit(holdID)
+	if err != nil {
+		return 0, err
+	}
+	return hold.Credits, nil
+}
+
+// Release возвращает удержанные кредиты владельцу и возвращает их сумму.
+func (r *Ranking) Release(holdID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.releaseLocked(holdID)
+}
+
+// releaseLocked — Release для вызова под r.mu.
+func (r *Ranking) releaseLocked(holdID string) (int, error) {
+	hold, err := r.escrowTake(holdID)
+	if err != nil {
+		return 0, err
+	}
+	r.escrowDeliverLocked(hold, hold.Owner, hold.ledgerSource())
+	log.Printf("Удержание %s (%s) возвращено %s", hold.ID, hold.Reason, hold.Owner)
+	return hold.Credits, nil
+}
+
+// captureHold подтверждает удержание игры и пишет в лог, если оно уже было закрыто.
+func (r *Ranking) captureHold(holdID string) {
+	if holdID == "" {
+		return
+	}
+	if _, err := r.Capture(holdID); err != nil {
+		log.Printf("Не удалось подтвердить удержание %s: %v", holdID, err)
+	}
+}
+
+// HeldCredits возвращает сумму кредитов пользователя, замороженных в удержаниях.
+func (r *Ranking) HeldCredits(userID string) int {
+	held, err := r.redis.HGet(r.ctx, escrowHeldKey, userID).Int()
+	if err != nil || held < 0 {
+		return 0
+	}
+	return held
+}
+
+// holdErrorMessage формирует сообщение для пользователя, если кредиты не удалось заморозить.
+func (r *Ranking) holdErrorMessage(userID string, err error) string {
+	if errors.Is(err, errEscrowNoCredits) {
+		return fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(r.GetRating(userID)))
+	}
+	log.Printf("Не удалось заморозить кредиты %s: %v", userID, err)
+	return "❌ Не удалось заморозить ставку! Попробуй позже."
+}
@@ -0,0 +1,272 @@
+package ranking
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedImageSize — максимальный размер загружаемой картинки NFT.
+const maxCachedImageSize = 8 << 20
+
+// ImageCache скачивает картинки NFT из ссылок Google Sheets и раздаёт их по стабильным URL
+// со встроенного HTTP-сервера, чтобы embed'ы не ломались при протухании исходных ссылок.
+type ImageCache struct {
+	dir       string
+	publicURL string
+	client    *http.Client
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// NewImageCache создаёт кэш картинок по переменным окружения:
+// IMAGE_PUBLIC_URL — внешний адрес сервера (без него кэш выключен),
+// IMAGE_CACHE_DIR — каталог кэша (по умолчанию image_cache).
+func NewImageCache() *ImageCache {
+	publicURL := strings.TrimSuffix(os.Getenv("IMAGE_PUBLIC_URL"), "/")
+	if publicURL == "" {
+		return nil
+	}
+	dir := os.Getenv("IMAGE_CACHE_DIR")
+	if dir == "" {
+		dir = "image_cache"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("Не удалось создать каталог кэша картинок %s: %v", dir, err)
+		return nil
+	}
+	return &ImageCache{
+		dir:       dir,
+		publicURL: publicURL,
+		client:    &http.Client{Timeout: 15 * time.Second},
+		pending:   make(map[string]bool),
+	}
+}
+
+// Register подключает раздачу закэшированных картинок к HTTP-серверу бота.
+func (c *ImageCache) Register(web *WebServer) {
+	web.Handle("/nft-images/", http.StripPrefix("/nft-images/", http.FileServer(http.Dir(c.dir))))
+}
+
+// fileBase возвращает имя файла кэша (без расширения) для исходной ссылки.
+func (c *ImageCache) fileBase(source string) string {
+	sum := sha1.Sum([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup ищет закэшированный файл для ссылки.
+func (c *ImageCache) lookup(source string) (string, bool) {
+	matches, _ := filepath.Glob(filepath.Join(c.dir, c.fileBase(source)+".*"))
+	if len(matches) == 0 {
+		return "", false
+	}
+	return filepath.Base(matches[0]), true
+}
+
+// URL возвращает стабильную ссылку на картинку. Если картинки ещё нет в кэше,
+// возвращается исходная ссылка, а загрузка запускается в фоне.
+func (c *ImageCache) URL(source string) string {
+	if c == nil || source == "" || !strings.HasPrefix(source, "http") {
+		return source
+	}
+	if name, ok := c.lookup(source); ok {
+		return c.publicURL + "/nft-images/" + name
+	}
+	go func() {
+		if err := c.Fetch(source); err != nil {
+			log.Printf("Не удалось закэшировать картинку %s: %v", source, err)
+		}
+	}()
+	return source
+}
+
+// Fetch скачивает картинку в кэш, если её там ещё нет.
+func (c *ImageCache) Fetch(source string) error {
+	if _, ok := c.lookup(source); ok {
+		return nil
+	}
+	c.mu.Lock()
+	if c.pending[source] {
+		c.mu.Unlock()
+		return nil
+	}
+	c.pending[source] = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, source)
+		c.mu.Unlock()
+	}()
+
+	resp, err := c.client.Get(source)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("статус %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("ссылка вернула не картинку (%s)", contentType)
+	}
+	ext := ".img"
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedImageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxCachedImageSize {
+		return fmt.Errorf("картинка больше %d байт", maxCachedImageSize)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы сервер не отдал недокачанную картинку
+	path := filepath.Join(c.dir, c.fileBase(source)+ext)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// BlurredURL возвращает ссылку на размытую копию картинки для тизеров. Пока оригинал не
+// скачан в кэш (или кэш выключен), возвращает пустую строку: тизер уходит без картинки.
+func (c *ImageCache) BlurredURL(source string) string {
+	if c == nil || source == "" || !strings.HasPrefix(source, "http") {
+		return ""
+	}
+	name, ok := c.lookup(source)
+	if !ok {
+		go func() {
+			if err := c.Fetch(source); err != nil {
+				log.Printf("Не удалось закэшировать картинку %s: %v", source, err)
+			}
+		}()
+		return ""
+	}
+	blurred := c.fileBase(source) + "-blur.jpg"
+	path := filepath.Join(c.dir, blurred)
+	if _, err := os.Stat(path); err != nil {
+		if err := blurImageFile(filepath.Join(c.dir, name), path); err != nil {
+			log.Printf("Не удалось размыть картинку %s: %v", source, err)
+			return ""
+		}
+	}
+	return c.publicURL + "/nft-images/" + blurred
+}
+
+// blurImageFile сохраняет размытую копию картинки в JPEG: усредняет её до грубой сетки
+// и растягивает обратно с билинейной интерполяцией.
+func blurImageFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return fmt.Errorf("пустая картинка")
+	}
+
+	const cells = 12
+	gw, gh := cells, max(1, cells*b.Dy()/b.Dx())
+	grid := image.NewRGBA(image.Rect(0, 0, gw, gh))
+	for gy := 0; gy < gh; gy++ {
+		for gx := 0; gx < gw; gx++ {
+			var rs, gs, bs, n uint64
+			for y := b.Min.Y + gy*b.Dy()/gh; y < b.Min.Y+(gy+1)*b.Dy()/gh; y++ {
+				for x := b.Min.X + gx*b.Dx()/gw; x < b.Min.X+(gx+1)*b.Dx()/gw; x++ {
+					cr, cg, cb, _ := img.At(x, y).RGBA()
+					rs, gs, bs, n = rs+uint64(cr>>8), gs+uint64(cg>>8), bs+uint64(cb>>8), n+1
+				}
+			}
+			if n > 0 {
+				grid.SetRGBA(gx, gy, color.RGBA{uint8(rs / n), uint8(gs / n), uint8(bs / n), 255})
+			}
+		}
+	}
+
+	w := min(b.Dx(), 512)
+	h := max(1, w*b.Dy()/b.Dx())
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	at := func(x, y int) color.RGBA {
+		return grid.RGBAAt(max(0, min(x, gw-1)), max(0, min(y, gh-1)))
+	}
+	for y := 0; y < h; y++ {
+		fy := (float64(y)+0.5)*float64(gh)/float64(h) - 0.5
+		y0, ty := int(math.Floor(fy)), fy-math.Floor(fy)
+		for x := 0; x < w; x++ {
+			fx := (float64(x)+0.5)*float64(gw)/float64(w) - 0.5
+			x0, tx := int(math.Floor(fx)), fx-math.Floor(fx)
+			c00, c10, c01, c11 := at(x0, y0), at(x0+1, y0), at(x0, y0+1), at(x0+1, y0+1)
+			mix := func(a, b, c, d uint8) uint8 {
+				return uint8(float64(a)*(1-tx)*(1-ty) + float64(b)*tx*(1-ty) + float64(c)*(1-tx)*ty + float64(d)*tx*ty + 0.5)
+			}
+			out.SetRGBA(x, y, color.RGBA{mix(c00.R, c10.R, c01.R, c11.R), mix(c00.G, c10.G, c01.G, c11.G), mix(c00.B, c10.B, c01.B, c11.B), 255})
+		}
+	}
+
+	tmp := dst + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(file, out, &jpeg.Options{Quality: 80}); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// NFTImageURL возвращает ссылку на картинку NFT для embed'а (через кэш, если он включён).
+func (r *Ranking) NFTImageURL(nft NFT) string {
+	return r.images.URL(nft.ImageURL)
+}
+
+// warmImageCache скачивает в кэш картинки всех NFT.
+func (r *Ranking) warmImageCache() error {
+	if r.images == nil || r.Kki == nil {
+		return nil
+	}
+	failed := 0
+	for _, nft := range r.Kki.nfts {
+		if nft.ImageURL == "" || !strings.HasPrefix(nft.ImageURL, "http") {
+			continue
+		}
+		if err := r.images.Fetch(nft.ImageURL); err != nil {
+			failed++
+			log.Printf("Не удалось закэшировать картинку NFT %s: %v", nft.ID, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("не удалось закэшировать %d картинок", failed)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Регулятор инфляции. Задача inflation_controller раз в 10 минут сравнивает чистую эмиссию
+// за последние 24 часа (начислено минус списано, см. recordEconomyDelta) с целевой
+// inflation_target и, если эмиссия выше цели, уменьшает процент начислений за войс
+// (и, если включено, выигрыша в играх) пропорционально превышению, но не ниже inflation_min_percent.
+const inflationStateKey = "inflation:state" // HASH percent, net, updated
+
+// InflationTarget возвращает целевую чистую эмиссию в сутки (0 — регулятор выключен).
+func (r *Ranking) InflationTarget() int {
+	return r.GetIntSetting("inflation_target", envInt("INFLATION_TARGET", 0))
+}
+
+// InflationMinPercent возвращает нижнюю границу процента начислений.
+func (r *Ranking) InflationMinPercent() int {
+	return r.GetIntSetting("inflation_min_percent", envInt("INFLATION_MIN_PERCENT", 25))
+}
+
+// inflationScalesGames сообщает, уменьшает ли регулятор выигрыши в играх.
+func (r *Ranking) inflationScalesGames() bool {
+	return r.GetIntSetting("inflation_games", envInt("INFLATION_SCALE_GAMES", 0)) > 0
+}
+
+// inflationPercent возвращает текущий процент начислений, рассчитанный регулятором (100 — без изменений).
+func (r *Ranking) inflationPercent() int {
+	if r.InflationTarget() <= 0 {
+		return 100
+	}
+	percent, err := r.redis.HGet(r.ctx, inflationStateKey, "percent").Int()
+	if err != nil || percent <= 0 || percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// inflationTargetPercent рассчитывает процент начислений по чистой эмиссии за сутки.
+func inflationTargetPercent(net, target, minPercent int) int {
+	if target <= 0 || net <= target {
+		return 100
+	}
+	percent := target * 100 / net
+	if percent < minPercent {
+		percent = minPercent
+	}
+	if percent < 1 {
+		percent = 1
+	}
+	return percent
+}
+
+// runInflationController пересчитывает процент начислений.
+func (r *Ranking) runInflationController() error {
+	net := r.sumEconomyLast24h("minted") - r.sumEconomyLast24h("burned")
+	percent := inflationTargetPercent(net, r.InflationTarget(), r.InflationMinPercent())
+	previous := r.inflationPercent()
+	err := r.redis.HSet(r.ctx, inflationStateKey, "percent", percent, "net", net, "updated", time.Now().Unix()).Err()
+	if err != nil {
+		return err
+	}
+	if percent != previous {
+		log.Printf("Регулятор инфляции: чистая эмиссия за 24ч %d, начисления %d%% (было %d%%)", net, percent, previous)
+	}
+	return nil
+}
+
+// recordVoiceMinted учитывает начисление за войс в почасовой статистике для отчёта регулятора.
+func (r *Ranking) recordVoiceMinted(points int) {
+	key := economyHourKey("voice_minted", time.Now())
+	r.redis.IncrBy(r.ctx, key, int64(points))
+	r.redis.Expire(r.ctx, key, 25*time.Hour)
+}
+
+// scaleGamePayout уменьшает чистый выигрыш игры (выплата сверх ставки) по проценту регулятора,
+// если масштабирование игр включено. Ставка всегда возвращается целиком.
+func (r *Ranking) scaleGamePayout(bet, payout int) int {
+	if payout <= bet || !r.inflationScalesGames() {
+		return payout
+	}
+	return bet + (payout-bet)*r.inflationPercent()/100
+}
+
+// HandleInflationCommand обрабатывает команду !a_inflation [target|min <значение>] [games on|off].
+func (r *Ranking) HandleInflationCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_inflation: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут управлять инфляцией! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_inflation [target <в сутки>|min <процент>|games on|off]` (target 0 — выключить)"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		s.ChannelMessageSendEmbed(m.ChannelID, r.inflationReport())
+		return
+	}
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	var setting string
+	var value int
+	switch parts[1] {
+	case "games":
+		if parts[2] != "on" && parts[2] != "off" {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		setting = "inflation_games"
+		if parts[2] == "on" {
+			value = 1
+		}
+	case "target", "min":
+		v, err := strconv.Atoi(parts[2])
+		if err != nil || v < 0 || (parts[1] == "min" && (v < 1 || v > 100)) {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		setting, value = "inflation_"+parts[1], v
+		if parts[1] == "min" {
+			setting = "inflation_min_percent"
+		}
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	if err := r.SetIntSetting(setting, value); err != nil {
+		log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	if err := r.runInflationController(); err != nil {
+		log.Printf("Не удалось пересчитать регулятор инфляции: %v", err)
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, r.inflationReport())
+}
+
+// inflationReport формирует отчёт регулятора инфляции.
+func (r *Ranking) inflationReport() *discordgo.MessageEmbed {
+	minted := r.sumEconomyLast24h("minted")
+	burned := r.sumEconomyLast24h("burned")
+	voice := r.sumEconomyLast24h("voice_minted")
+	target := "выключен"
+	if value := r.InflationTarget(); value > 0 {
+		target = fmt.Sprintf("%+d в сутки", value)
+	}
+	games := "нет"
+	if r.inflationScalesGames() {
+		games = "да"
+	}
+	updated := "ещё не запускался"
+	if ts, err := r.redis.HGet(r.ctx, inflationStateKey, "updated").Int64(); err == nil {
+		updated = fmt.Sprintf("<t:%d:R>", ts)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "📉 Регулятор инфляции",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📈 Начислено за 24ч", Value: fmt.Sprintf("+%d", minted), Inline: true},
+			{Name: "📉 Списано за 24ч", Value: fmt.Sprintf("-%d", burned), Inline: true},
+			{Name: "⚖️ Чистая эмиссия", Value: fmt.Sprintf("%+d", minted-burned), Inline: true},
+			{Name: "🎙️ Из них за войс", Value: fmt.Sprintf("+%d", voice), Inline: true},
+			{Name: "🎯 Цель", Value: target, Inline: true},
+			{Name: "✂️ Начисления", Value: fmt.Sprintf("%d%% (минимум %d%%)", r.inflationPercent(), r.InflationMinPercent()), Inline: true},
+			{Name: "🎲 Выигрыши в играх", Value: games, Inline: true},
+			{Name: "🕒 Пересчёт", Value: updated, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_inflation target <в сутки> · min <процент> · games on|off"},
+	}
+}
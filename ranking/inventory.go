@@ -0,0 +1,269 @@
+package ranking
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// inventoryMaxPageSize — наибольший размер страницы /inventory (ограничение описания эмбеда).
+const inventoryMaxPageSize = 25
+
+// inventoryFilterTTL — сколько хранится фильтр, чтобы кнопки листания продолжали его применять.
+const inventoryFilterTTL = 24 * time.Hour
+
+// InventoryPageSize возвращает размер страницы /inventory по умолчанию: настройка
+// inventory_page_size, переменная INVENTORY_PAGE_SIZE или 10.
+func (r *Ranking) InventoryPageSize() int {
+	size := r.GetIntSetting("inventory_page_size", envInt("INVENTORY_PAGE_SIZE", 10))
+	return max(1, min(size, inventoryMaxPageSize))
+}
+
+// inventoryFilter — условия отбора NFT в /inventory. Пустое поле не ограничивает выборку,
+// коллекция и название ищутся по подстроке без учёта регистра.
+type inventoryFilter struct {
+	Rarity     string
+	Collection string
+	Name       string
+}
+
+// parseInventoryFilter разбирает аргументы вида rarity:Legendary collection:holiday name:дракон.
+// Возвращает false, если аргумент не является фильтром.
+func parseInventoryFilter(f *inventoryFilter, arg string) (bool, error) {
+	key, value, ok := strings.Cut(arg, ":")
+	if !ok || value == "" {
+		return false, nil
+	}
+	switch key {
+	case "rarity":
+		rarity, found := findRarity(value)
+		if !found {
+			return true, fmt.Errorf("неизвестная редкость %q", value)
+		}
+		f.Rarity = rarity
+	case "collection":
+		f.Collection = strings.ToLower(value)
+	case "name":
+		f.Name = strings.ToLower(value)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// Empty сообщает, что фильтр ничего не ограничивает.
+func (f inventoryFilter) Empty() bool {
+	return f == inventoryFilter{}
+}
+
+// Match проверяет NFT по фильтру.
+func (f inventoryFilter) Match(nft NFT) bool {
+	return (f.Rarity == "" || nft.Rarity == f.Rarity) &&
+		(f.Collection == "" || strings.Contains(strings.ToLower(nft.Collection), f.Collection)) &&
+		(f.Name == "" || strings.Contains(strings.ToLower(nft.Name), f.Name))
+}
+
+// String возвращает фильтр в виде аргументов команды.
+func (f inventoryFilter) String() string {
+	var parts []string
+	if f.Rarity != "" {
+		parts = append(parts, "rarity:"+f.Rarity)
+	}
+	if f.Collection != "" {
+		parts = append(parts, "collection:"+f.Collection)
+	}
+	if f.Name != "" {
+		parts = append(parts, "name:"+f.Name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// inventoryFilterKey возвращает ключ сохранённого фильтра.
+func inventoryFilterKey(token string) string {
+	return "inventory_filter:" + token
+}
+
+// saveInventoryFilter сохраняет фильтр для кнопок листания и возвращает его короткий токен.
+// ID кнопки ограничен 100 символами, поэтому сам фильтр в него не кладём.
+func (r *Ranking) saveInventoryFilter(f inventoryFilter) string {
+	if f.Empty() {
+		return ""
+	}
+	sum := sha1.Sum([]byte(f.String()))
+	token := hex.EncodeToString(sum[:])[:12]
+	if err := r.redis.Set(r.ctx, inventoryFilterKey(token), f.String(), inventoryFilterTTL).Err(); err != nil {
+		log.Printf("Не удалось сохранить фильтр инвентаря %s: %v", token, err)
+	}
+	return token
+}
+
+// loadInventoryFilter восстанавливает фильтр по токену из кнопки.
+func (r *Ranking) loadInventoryFilter(token string) (inventoryFilter, bool) {
+	var f inventoryFilter
+	if token == "" {
+		return f, true
+	}
+	data, err := r.redis.Get(r.ctx, inventoryFilterKey(token)).Result()
+	if err != nil {
+		return f, false
+	}
+	for _, arg := range strings.Fields(data) {
+		parseInventoryFilter(&f, arg)
+	}
+	return f, true
+}
+
+// inventoryLines возвращает строки подходящих под фильтр NFT по алфавиту, их общую стоимость и число копий.
+func (r *Ranking) inventoryLines(userID string, filter inventoryFilter) ([]string, int, int) {
+	var lines []string
+	totalValue, copies := 0, 0
+	for nftID, count := range r.GetUserInventory(userID) {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok {
+			log.Printf("Warning: NFT %s not found for user %s", nftID, userID)
+			continue
+		}
+		if !filter.Match(nft) {
+			continue
+		}
+		price := r.CalculateNFTPrice(nft)
+		totalValue += price * count
+		copies += count
+		lines = append(lines, fmt.Sprintf("%s **%s** (x%d)\n📌 ID: %s · 💰 %d · %s", RarityEmojis[nft.Rarity], nft.Name, count, nftID, price, nft.Rarity))
+	}
+	sort.Strings(lines)
+	return lines, totalValue, copies
+}
+
+// inventoryPage формирует страницу инвентаря игрока с кнопками ◀️ ▶️. page считается с нуля,
+// token — сохранённый фильтр (пустой без фильтра).
+func (r *Ranking) inventoryPage(userID string, filter inventoryFilter, token string, size, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	lines, totalValue, copies := r.inventoryLines(userID, filter)
+	pages := max((len(lines)+size-1)/size, 1)
+	page = max(0, min(page, pages-1))
+
+	description := "Ничего нет, Император ждёт добычи! 😢"
+	if !filter.Empty() {
+		description = fmt.Sprintf("Ничего не найдено по фильтру `%s`.", filter)
+	}
+	if len(lines) > 0 {
+		start := page * size
+		description = fmt.Sprintf("Владелец: %s\n\n%s", r.publicMention(userID), strings.Join(lines[start:min(start+size, len(lines))], "\n\n"))
+	}
+	title := "🎒 Инвентарь"
+	if !filter.Empty() {
+		title = fmt.Sprintf("🎒 Инвентарь · 🔎 %s", filter)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(title, 250),
+		Description: truncate(description, 4000),
+		Color:       0x00FF00,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Страница %d/%d · NFT: %d (%d уникальных) · Общая стоимость: 💰 %d",
+			page+1, pages, copies, len(lines), totalValue)},
+	}
+	if pages == 1 {
+		return embed, []discordgo.MessageComponent{}
+	}
+	button := func(label string, target int, disabled bool) discordgo.Button {
+		customID := fmt.Sprintf("inv_page_%s_%d_%d", userID, size, target)
+		if token != "" {
+			customID += "_" + token
+		}
+		return discordgo.Button{Label: label, Style: discordgo.SecondaryButton, CustomID: customID, Disabled: disabled}
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				button("◀️ Назад", page-1, page == 0),
+				button("Вперёд ▶️", page+1, page == pages-1),
+			},
+		},
+	}
+	return embed, components
+}
+
+// HandleInventoryCommand обрабатывает команду !inventory [страница] [size:N] [rarity:…] [collection:…] [name:…].
+func (r *Ranking) HandleInventoryCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !inventory: %s от %s", command, m.Author.ID)
+
+	usage := fmt.Sprintf("❌ Используй: `/inventory [страница] [size:1-%d] [rarity:Legendary] [collection:holiday] [name:дракон]`", inventoryMaxPageSize)
+	page, size := 1, r.InventoryPageSize()
+	var filter inventoryFilter
+	for _, arg := range strings.Fields(command)[1:] {
+		if value, found := strings.CutPrefix(arg, "size:"); found {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > inventoryMaxPageSize {
+				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Размер страницы — от 1 до %d.", inventoryMaxPageSize))
+				return
+			}
+			size = n
+			continue
+		}
+		if matched, err := parseInventoryFilter(&filter, arg); err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error()+". Доступны: Common, Rare, Super-rare, Epic, Nephrite, Exotic, Legendary.")
+			return
+		} else if matched {
+			continue
+		}
+		n, ok := parseTopPage(arg)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		page = n
+	}
+
+	embed, components := r.inventoryPage(m.Author.ID, filter, r.saveInventoryFilter(filter), size, page-1)
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components}); err != nil {
+		log.Printf("Error sending inventory for user %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка при отображении инвентаря! Попробуйте позже.")
+	}
+}
+
+// HandleInventoryPage листает страницы /inventory. Листать может только владелец инвентаря.
+func (r *Ranking) HandleInventoryPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(strings.TrimPrefix(i.MessageComponentData().CustomID, "inv_page_"), "_")
+	if len(parts) != 3 && len(parts) != 4 {
+		return
+	}
+	if i.Member.User.ID != parts[0] {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Листать может только владелец инвентаря — открой свой: `/inventory`", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	size, errSize := strconv.Atoi(parts[1])
+	page, errPage := strconv.Atoi(parts[2])
+	if errSize != nil || errPage != nil || size < 1 || size > inventoryMaxPageSize {
+		return
+	}
+	token := ""
+	if len(parts) == 4 {
+		token = parts[3]
+	}
+	filter, ok := r.loadInventoryFilter(token)
+	if !ok {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "⌛ Фильтр устарел — повтори поиск командой `/inventory`", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	embed, components := r.inventoryPage(parts[0], filter, token, size, page)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,358 @@
+package ranking
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Нефрит — премиальная валюта. Его нельзя купить за кредиты: он выдаётся только за редкие
+// события (выпадение NFT редкости Nephrite и выше, натуральный блэкджек) и админами.
+// Нефрит обменивается на кредиты по плавающему курсу, привязанному к BTC, и тратится
+// в нефритовой лавке на NFT и кейсы, которым админ назначил цену в нефрите.
+const jadePricesKey = "jade:prices" // HASH "nft:<id>"/"case:<id>" -> цена в нефрите
+
+// errJadeNotEnough возвращается, если у игрока не хватает нефрита.
+var errJadeNotEnough = errors.New("недостаточно нефрита")
+
+// jadeDropRewards — сколько нефрита приносит выпадение NFT редкой редкости из кейса.
+var jadeDropRewards = map[string]int{
+	"Nephrite":  1,
+	"Exotic":    2,
+	"Legendary": 5,
+}
+
+// jadeNaturalBlackjackReward — нефрит за натуральный блэкджек.
+const jadeNaturalBlackjackReward = 1
+
+// jadeKey возвращает ключ баланса нефрита игрока.
+func jadeKey(userID string) string {
+	return "jade:" + userID
+}
+
+// jadePriceField возвращает поле цены товара в HASH jade:prices.
+func jadePriceField(kind, id string) string {
+	return kind + ":" + id
+}
+
+// JadeBalance возвращает баланс нефрита игрока.
+func (r *Ranking) JadeBalance(userID string) int {
+	balance, _ := r.redis.Get(r.ctx, jadeKey(userID)).Int()
+	return balance
+}
+
+// addJade начисляет игроку нефрит и пишет начисление в лог.
+func (r *Ranking) addJade(s *discordgo.Session, userID string, amount int, reason string) {
+	if amount <= 0 {
+		return
+	}
+	if err := r.redis.IncrBy(r.ctx, jadeKey(userID), int64(amount)).Err(); err != nil {
+		log.Printf("Не удалось начислить %d нефрита %s: %v", amount, userID, err)
+		return
+	}
+	log.Printf("Начислено %d нефрита %s: %s", amount, userID, reason)
+	r.LogCreditOperation(s, fmt.Sprintf("💠 <@%s> получил %s (%s)", userID, formatJade(amount), reason))
+}
+
+// spendJade списывает нефрит, если его хватает.
+func (r *Ranking) spendJade(userID string, amount int) error {
+	left, err := r.redis.DecrBy(r.ctx, jadeKey(userID), int64(amount)).Result()
+	if err != nil {
+		return err
+	}
+	if left < 0 {
+		r.redis.IncrBy(r.ctx, jadeKey(userID), int64(amount))
+		return errJadeNotEnough
+	}
+	return nil
+}
+
+// formatJade форматирует сумму нефрита.
+func formatJade(amount int) string {
+	return fmt.Sprintf("💠 %d нефрита", amount)
+}
+
+// awardJadeForDrops начисляет нефрит за редкие NFT, выпавшие из кейса.
+func (r *Ranking) awardJadeForDrops(s *discordgo.Session, userID string, dropped []NFT) {
+	total := 0
+	for _, nft := range dropped {
+		total += jadeDropRewards[nft.Rarity]
+	}
+	r.addJade(s, userID, total, "редкий дроп из кейса")
+}
+
+// JadeRate возвращает, сколько кредитов дают за 1 нефрит. Базовый курс JADE_BASE_RATE
+// умножается на отношение текущего курса BTC к среднему за историю наблюдений (от 0.5 до 2).
+func (r *Ranking) JadeRate() int {
+	base := r.GetIntSetting("jade_base_rate", envInt("JADE_BASE_RATE", 500))
+	bt := r.BitcoinTracker
+	bt.mu.Lock()
+	current := bt.CurrentPrice
+	sum := 0.0
+	for _, price := range bt.PriceHistory {
+		sum += price
+	}
+	count := len(bt.PriceHistory)
+	bt.mu.Unlock()
+
+	factor := 1.0
+	if current > 0 && count > 0 && sum > 0 {
+		factor = current / (sum / float64(count))
+	}
+	if factor < 0.5 {
+		factor = 0.5
+	} else if factor > 2 {
+		factor = 2
+	}
+	return max(int(float64(base)*factor), 1)
+}
+
+// jadePrices возвращает товары нефритовой лавки: поле -> цена.
+func (r *Ranking) jadePrices() map[string]int {
+	raw, err := r.redis.HGetAll(r.ctx, jadePricesKey).Result()
+	if err != nil {
+		log.Printf("Не удалось загрузить цены в нефрите: %v", err)
+		return nil
+	}
+	prices := make(map[string]int, len(raw))
+	for field, value := range raw {
+		if price, err := strconv.Atoi(value); err == nil && price > 0 {
+			prices[field] = price
+		}
+	}
+	return prices
+}
+
+// jadeItemName возвращает название товара лавки.
+func (r *Ranking) jadeItemName(kind, id string) (string, bool) {
+	switch kind {
+	case "nft":
+		if nft, ok := r.Kki.nfts[id]; ok {
+			return fmt.Sprintf("%s %s", RarityEmojis[nft.Rarity], nft.Name), true
+		}
+	case "case":
+		if kase, ok := r.Kki.cases[id]; ok {
+			return "📦 " + kase.Name, true
+		}
+	}
+	return "", false
+}
+
+// HandleJadeCommand обрабатывает команду !jade — баланс, курс и нефритовая лавка.
+func (r *Ranking) HandleJadeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !jade: %s от %s", command, m.Author.ID)
+
+	prices := r.jadePrices()
+	fields := make([]string, 0, len(prices))
+	for field := range prices {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var lines []string
+	for _, field := range fields {
+		kind, id, _ := strings.Cut(field, ":")
+		name, ok := r.jadeItemName(kind, id)
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (`%s %s`) — %s", name, kind, id, formatJade(prices[field])))
+	}
+	shop := "Пока пусто — Император готовит товары."
+	if len(lines) > 0 {
+		shop = truncate(strings.Join(lines, "\n"), 1024)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "💠 Нефрит",
+		Description: "Премиальная валюта Императора. Выдаётся за редкие дропы из кейсов и натуральный блэкджек.",
+		Color:       0x00A86B,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "👛 Твой нефрит", Value: formatJade(r.JadeBalance(m.Author.ID)), Inline: true},
+			{Name: "💱 Курс", Value: fmt.Sprintf("1 💠 = %s (зависит от BTC)", formatCredits(r.JadeRate())), Inline: true},
+			{Name: "🏮 Нефритовая лавка", Value: shop},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/exchange <кол-во> — обменять на кредиты · /buy_jade <nft|case> <ID> [кол-во]"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// HandleExchangeCommand обрабатывает команду !exchange <кол-во> — обмен нефрита на кредиты.
+func (r *Ranking) HandleExchangeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !exchange: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Используй: `/exchange <кол-во>` — сейчас 1 💠 = %s", formatCredits(r.JadeRate())))
+		return
+	}
+	amount, err := strconv.Atoi(parts[1])
+	if err != nil || amount <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Количество нефрита должно быть положительным числом!")
+		return
+	}
+
+	rate := r.JadeRate()
+	if err := r.spendJade(m.Author.ID, amount); err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно нефрита! У тебя: %s", formatJade(r.JadeBalance(m.Author.ID))))
+		return
+	}
+	credits := amount * rate
+	r.mu.Lock()
+	r.UpdateRatingFrom(m.Author.ID, credits, "jade", "")
+	r.mu.Unlock()
+
+	log.Printf("%s обменял %d нефрита на %d кредитов по курсу %d", m.Author.ID, amount, credits, rate)
+	r.LogCreditOperation(s, fmt.Sprintf("💱 <@%s> обменял %s на %s (курс %d)", m.Author.ID, formatJade(amount), formatCredits(credits), rate))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Обменяно %s на **%s** по курсу 1 💠 = %s!", formatJade(amount), formatCredits(credits), formatCredits(rate)))
+}
+
+// HandleBuyJadeCommand обрабатывает команду !buy_jade <nft|case> <ID> [кол-во].
+func (r *Ranking) HandleBuyJadeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !buy_jade: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) < 3 || len(parts) > 4 || (parts[1] != "nft" && parts[1] != "case") {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/buy_jade <nft|case> <ID> [кол-во]`")
+		return
+	}
+	kind, itemID := parts[1], parts[2]
+	count := 1
+	if len(parts) == 4 {
+		n, err := strconv.Atoi(parts[3])
+		if err != nil || n <= 0 || n > 10 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Количество должно быть от 1 до 10!")
+			return
+		}
+		count = n
+	}
+
+	name, ok := r.jadeItemName(kind, itemID)
+	price, err := r.redis.HGet(r.ctx, jadePricesKey, jadePriceField(kind, itemID)).Int()
+	if !ok || err != nil || price <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Этого товара нет в нефритовой лавке! Список: `/jade`")
+		return
+	}
+	total := price * count
+	if err := r.spendJade(m.Author.ID, total); err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно нефрита! Нужно: %s, у тебя: %s", formatJade(total), formatJade(r.JadeBalance(m.Author.ID))))
+		return
+	}
+
+	r.mu.Lock()
+	var saveErr error
+	if kind == "nft" {
+		inv := r.GetUserInventory(m.Author.ID)
+		inv[itemID] += count
+		r.SaveUserInventory(m.Author.ID, inv)
+	} else {
+		inv := r.Kki.GetUserCaseInventory(r, m.Author.ID)
+		inv[itemID] += count
+		saveErr = r.Kki.SaveUserCaseInventory(r, m.Author.ID, inv)
+	}
+	r.mu.Unlock()
+	if saveErr != nil {
+		r.redis.IncrBy(r.ctx, jadeKey(m.Author.ID), int64(total))
+		log.Printf("Не удалось выдать %s %s пользователю %s: %v", kind, itemID, m.Author.ID, saveErr)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения инвентаря, нефрит возвращён.")
+		return
+	}
+	ref := ""
+	if kind == "nft" {
+		ref = ledgerRef(r.recordNFTMutation("jade_shop", m.Author.ID, "", m.Author.ID, map[string]int{itemID: count}))
+	}
+
+	r.LogCreditOperation(s, fmt.Sprintf("🏮 <@%s> купил %d x %s за %s%s", m.Author.ID, count, name, formatJade(total), ref))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Куплено %d x %s за %s! 💠", count, name, formatJade(total)))
+}
+
+// HandleJadeAdminCommand обрабатывает команду !a_jade give @user <кол-во> | price <nft|case> <ID> <цена|off> | rate <кредитов>.
+func (r *Ranking) HandleJadeAdminCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_jade: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут управлять нефритом! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_jade give @user <кол-во>`, `/a_jade price <nft|case> <ID> <цена|off>` или `/a_jade rate <кредитов за 1 💠>`"
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	switch parts[1] {
+	case "give":
+		if len(parts) != 4 || len(m.Mentions) != 1 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		amount, err := strconv.Atoi(parts[3])
+		if err != nil || amount <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Количество должно быть положительным числом!")
+			return
+		}
+		userID := m.Mentions[0].ID
+		r.addJade(s, userID, amount, fmt.Sprintf("выдал админ <@%s>", m.Author.ID))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ <@%s> получил %s. Баланс: %s", userID, formatJade(amount), formatJade(r.JadeBalance(userID))))
+	case "price":
+		if len(parts) != 5 || (parts[2] != "nft" && parts[2] != "case") {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		kind, itemID := parts[2], parts[3]
+		name, ok := r.jadeItemName(kind, itemID)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ %s с ID %s не найден!", strings.ToUpper(kind), itemID))
+			return
+		}
+		if parts[4] == "off" {
+			r.redis.HDel(r.ctx, jadePricesKey, jadePriceField(kind, itemID))
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ %s убран из нефритовой лавки.", name))
+			return
+		}
+		price, err := strconv.Atoi(parts[4])
+		if err != nil || price <= 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.redis.HSet(r.ctx, jadePricesKey, jadePriceField(kind, itemID), price).Err(); err != nil {
+			log.Printf("Не удалось сохранить цену в нефрите %s %s: %v", kind, itemID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения цены! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ %s продаётся в нефритовой лавке за %s.", name, formatJade(price)))
+	case "rate":
+		if len(parts) != 3 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		rate, err := strconv.Atoi(parts[2])
+		if err != nil || rate <= 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting("jade_base_rate", rate); err != nil {
+			log.Printf("Не удалось сохранить настройку jade_base_rate: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Базовый курс: 1 💠 = %s. С учётом BTC сейчас: %s.", formatCredits(rate), formatCredits(r.JadeRate())))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// jadeBalanceSuffix возвращает пометку о нефрите для /china (пусто, если нефрита нет).
+func (r *Ranking) jadeBalanceSuffix(userID string) string {
+	balance := r.JadeBalance(userID)
+	if balance <= 0 {
+		return ""
+	}
+	return " · " + formatJade(balance)
+}
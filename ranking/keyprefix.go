@@ -0,0 +1,163 @@
+package ranking
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// keylessCommands — команды Redis без ключей в аргументах.
+var keylessCommands = map[string]bool{
+	"ping": true, "echo": true, "auth": true, "hello": true, "select": true, "quit": true,
+	"multi": true, "exec": true, "discard": true, "info": true, "dbsize": true, "time": true,
+	"client": true, "config": true, "command": true, "flushdb": true, "flushall": true, "script": true,
+}
+
+// scriptCommands — команды Lua-скриптов: args[1] — сам скрипт или его SHA, args[2] — число ключей,
+// за которым идут ключи, а затем аргументы скрипта.
+var scriptCommands = map[string]bool{
+	"eval": true, "evalsha": true, "eval_ro": true, "evalsha_ro": true,
+}
+
+// multiKeyCommands — команды, у которых все аргументы после имени являются ключами.
+var multiKeyCommands = map[string]bool{
+	"del": true, "unlink": true, "exists": true, "mget": true, "touch": true, "watch": true,
+}
+
+// knownKeyPatterns — шаблоны ключей бота, которые переносятся под префикс.
+// При добавлении нового семейства ключей его нужно дописать сюда.
+var knownKeyPatterns = []string{
+	"user:*", "inventory:*", "case_inventory:*", "case_limit:*", "case_buy_limit:*", "daily_case:*",
+	"case:*", "case_open:*", "nft:*", "nft_price_history:*", "nft_price_ticks:*", "nft_last_sale:*", "nft_ledger:*", "ledger:*", "watch:*", "bank:*", "tombstone:*", "mydata:*", "loan:*", "daily:*", "ubi:*", "transfer_tax:*", "transfer:*", "transfer_limit:*", "inflation:*", "perm_alert:*", "jade:*", "processed_msg:*", "gamble:*", "debt:*", "payroll:*", "airdrop:*", "bracket:*", "market:*", "player_shop:*", "releases:*", "offer:*", "tutorial:*", "showcase:*", "wishlist:*", "wishlist_notified:*", "inventory_filter:*", "ratelimit:*", "sheets_sync:*", "shop:*", "anon:*", "quiet:*", "quiet_queue:*", "season:*", "voice_event:*", "afk:*", "stream:*", "pending_bid:*", "sell_duplicates:*", "sell_menu:*", "trade_collection:*", "wager_limit:*", "bet_limits:*", "settings:*", "themes:*", "flairs:*", "vote:*",
+	"economy:*", "top:*", "comeback:*", "faucet:*", "announce:*", "escrow:*", "retention:*", "scheduler:*", "case_bank", "cinema_options", "bitcoin_price", "schema_version",
+}
+
+// prefixHook добавляет префикс ко всем ключам на уровне клиента Redis, чтобы несколько ботов
+// могли делить один Redis. Результаты KEYS и SCAN возвращаются без префикса.
+type prefixHook struct {
+	prefix string
+}
+
+func (h prefixHook) apply(cmd redis.Cmder) {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return
+	}
+	name := strings.ToLower(cmd.Name())
+	switch {
+	case keylessCommands[name]:
+	case name == "scan":
+		for i := 2; i+1 < len(args); i++ {
+			if s, ok := args[i].(string); ok && strings.EqualFold(s, "match") {
+				args[i+1] = h.prefix + fmt.Sprint(args[i+1])
+				return
+			}
+		}
+	case scriptCommands[name]:
+		if len(args) < 3 {
+			return
+		}
+		numKeys, err := strconv.Atoi(fmt.Sprint(args[2]))
+		if err != nil {
+			return
+		}
+		for i := 3; i < 3+numKeys && i < len(args); i++ {
+			args[i] = h.prefix + fmt.Sprint(args[i])
+		}
+	case multiKeyCommands[name]:
+		for i := 1; i < len(args); i++ {
+			args[i] = h.prefix + fmt.Sprint(args[i])
+		}
+	case name == "rename" || name == "renamenx":
+		args[1] = h.prefix + fmt.Sprint(args[1])
+		if len(args) > 2 {
+			args[2] = h.prefix + fmt.Sprint(args[2])
+		}
+	default:
+		args[1] = h.prefix + fmt.Sprint(args[1])
+	}
+}
+
+func (h prefixHook) strip(cmd redis.Cmder) {
+	switch c := cmd.(type) {
+	case *redis.StringSliceCmd:
+		if strings.ToLower(c.Name()) != "keys" {
+			return
+		}
+		keys := c.Val()
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, h.prefix)
+		}
+		c.SetVal(keys)
+	case *redis.ScanCmd:
+		keys, cursor := c.Val()
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, h.prefix)
+		}
+		c.SetVal(keys, cursor)
+	}
+}
+
+func (h prefixHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.apply(cmd)
+	return ctx, nil
+}
+
+func (h prefixHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.strip(cmd)
+	return nil
+}
+
+func (h prefixHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		h.apply(cmd)
+	}
+	return ctx, nil
+}
+
+func (h prefixHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.strip(cmd)
+	}
+	return nil
+}
+
+// moveKeysToPrefix переносит существующие ключи бота без префикса под префикс.
+// Использует отдельный клиент без хука, чтобы видеть «сырые» имена ключей.
+func (r *Ranking) moveKeysToPrefix(prefix string) error {
+	raw := redis.NewClient(r.redis.Options())
+	defer raw.Close()
+
+	moved, skipped := 0, 0
+	for _, pattern := range knownKeyPatterns {
+		var cursor uint64
+		for {
+			keys, next, err := raw.Scan(r.ctx, cursor, pattern, 200).Result()
+			if err != nil {
+				return fmt.Errorf("не удалось просканировать %s: %v", pattern, err)
+			}
+			for _, key := range keys {
+				ok, err := raw.RenameNX(r.ctx, key, prefix+key).Result()
+				if err != nil {
+					return fmt.Errorf("не удалось перенести %s: %v", key, err)
+				}
+				if ok {
+					moved++
+				} else {
+					skipped++
+					log.Printf("Ключ %s уже существует, %s оставлен без изменений", prefix+key, key)
+				}
+			}
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	log.Printf("Перенос ключей под префикс %q: перенесено %d, пропущено %d", prefix, moved, skipped)
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
@@ -82,6 +83,9 @@ type KKI struct {
 	ctx    context.Context
 	sheets *sheets.Service
 	mu     sync.Mutex
+
+	etag string // ETag последнего ответа Google Sheets
+	sync sheetSyncQueue
 }
 
 // NewKKI инициализирует KKI с подключением к Google Sheets и Redis
@@ -111,25 +115,52 @@ func NewKKI(ctx context.Context) (*KKI, error) {
 	return k, nil
 }
 
-// SyncFromSheets загружает данные из Google Sheets в Redis и память
+// SyncFromSheets загружает данные из Google Sheets в Redis и память.
+// Оба листа читаются одним запросом; если таблица не менялась (ETag) или строка не изменилась
+// (хэш строки), разбор и запись в Redis пропускаются.
 func (k *KKI) SyncFromSheets(r *Ranking) error {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 
-	// Загрузка NFT
-	resp, err := k.sheets.Spreadsheets.Values.Get(os.Getenv("GOOGLE_SHEETS_ID"), "NFTs!A:G").Do()
+	k.syncReleases(r)
+
+	call := k.sheets.Spreadsheets.Values.BatchGet(os.Getenv("GOOGLE_SHEETS_ID")).
+		Ranges(sheetsNFTRange, sheetsCaseRange).
+		Fields("valueRanges(range,values)")
+	if k.etag != "" && len(k.nfts) > 0 {
+		call = call.IfNoneMatch(k.etag)
+	}
+	resp, err := call.Do()
+	if googleapi.IsNotModified(err) {
+		log.Printf("Google Sheets не изменились с прошлой синхронизации")
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("не удалось загрузить NFTs: %v", err)
+		r.ReportError("sheets", err)
+		return fmt.Errorf("не удалось загрузить NFTs и Cases: %v", err)
+	}
+	if len(resp.ValueRanges) != 2 {
+		return fmt.Errorf("Google Sheets вернули %d диапазонов вместо 2", len(resp.ValueRanges))
 	}
+	k.etag = resp.Header.Get("ETag")
 
-	k.nfts = make(map[string]NFT)
-	for i, row := range resp.Values {
-		if i == 0 {
+	// Загрузка NFT
+	nftHashes := k.loadRowHashes(r, "nfts")
+	newHashes := make(map[string]string, len(resp.ValueRanges[0].Values))
+	nfts := make(map[string]NFT, len(resp.ValueRanges[0].Values))
+	changed := 0
+	for _, row := range resp.ValueRanges[0].Values {
+		if len(row) < 7 {
 			continue
 		}
-		if len(row) < 7 {
+		id := fmt.Sprintf("%v", row[0])
+		hash := sheetRowHash(row)
+		newHashes[id] = hash
+		if old, ok := k.nfts[id]; ok && nftHashes[id] == hash {
+			nfts[id] = old
 			continue
 		}
+		changed++
 
 		rarity := fmt.Sprintf("%v", row[4])
 		basePrice, exists := BaseRarityPrices[rarity]
@@ -139,7 +170,7 @@ func (k *KKI) SyncFromSheets(r *Ranking) error {
 		}
 
 		nft := NFT{
-			ID:           fmt.Sprintf("%v", row[0]),
+			ID:           id,
 			Name:         fmt.Sprintf("%v", row[1]),
 			Description:  fmt.Sprintf("%v", row[2]),
 			ReleaseDate:  fmt.Sprintf("%v", row[3]),
@@ -155,38 +186,43 @@ func (k *KKI) SyncFromSheets(r *Ranking) error {
 		nft.Price = r.CalculateNFTPrice(nft)
 		nft.LastUpdated = time.Now()
 
-		k.nfts[nft.ID] = nft
+		nfts[nft.ID] = nft
 		jsonData, _ := json.Marshal(nft)
 		r.redis.Set(r.ctx, "nft:"+nft.ID, jsonData, 0)
 	}
+	k.nfts = nfts
+	k.saveRowHashes(r, "nfts", newHashes)
 
-	log.Printf("Total NFTs loaded: %d", len(k.nfts))
+	log.Printf("Total NFTs loaded: %d (изменено %d)", len(k.nfts), changed)
 
 	// Загрузка кейсов
-	resp, err = k.sheets.Spreadsheets.Values.Get(os.Getenv("GOOGLE_SHEETS_ID"), "Cases!A:D").Do()
-	if err != nil {
-		return fmt.Errorf("не удалось загрузить Cases: %v", err)
-	}
-
-	k.cases = make(map[string]Case)
-	for i, row := range resp.Values {
-		if i == 0 {
-			continue // Пропускаем заголовок
-		}
+	caseHashes := k.loadRowHashes(r, "cases")
+	newHashes = make(map[string]string, len(resp.ValueRanges[1].Values))
+	cases := make(map[string]Case, len(resp.ValueRanges[1].Values))
+	for _, row := range resp.ValueRanges[1].Values {
 		if len(row) < 4 {
 			continue
 		}
+		id := fmt.Sprintf("%v", row[0])
+		hash := sheetRowHash(row)
+		newHashes[id] = hash
+		if old, ok := k.cases[id]; ok && caseHashes[id] == hash {
+			cases[id] = old
+			continue
+		}
 		price, _ := strconv.Atoi(fmt.Sprintf("%v", row[3]))
 		kase := Case{
-			ID:                   fmt.Sprintf("%v", row[0]),
+			ID:                   id,
 			Name:                 fmt.Sprintf("%v", row[1]),
 			ContainedCollections: fmt.Sprintf("%v", row[2]),
 			Price:                price,
 		}
-		k.cases[kase.ID] = kase
+		cases[kase.ID] = kase
 		jsonData, _ := json.Marshal(kase)
 		r.redis.Set(r.ctx, "case:"+kase.ID, jsonData, 0)
 	}
+	k.cases = cases
+	k.saveRowHashes(r, "cases", newHashes)
 
 	return nil
 }
@@ -211,47 +247,35 @@ func (k *KKI) SaveUserCaseInventory(r *Ranking, userID string, inv UserCaseInven
 	return nil
 }
 
-// StartPriceUpdater запускает обновление цен каждые 15 минут
-func (r *Ranking) StartPriceUpdater() {
-	go func() {
-		ticker := time.NewTicker(15 * time.Minute)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				log.Printf("🔄 Автоматическое обновление цен NFT...")
-
-				// Обновляем курс BTC
-				_, err := r.GetBitcoinPrice()
-				if err != nil {
-					log.Printf("Ошибка обновления курса BTC: %v", err)
-					continue
-				}
-
-				// Обновляем цены всех NFT
-				r.mu.Lock()
-				for id, nft := range r.Kki.nfts {
-					newPrice := r.CalculateNFTPrice(nft)
-					if newPrice != nft.Price {
-						nft.Price = newPrice
-						nft.LastUpdated = time.Now()
-						r.Kki.nfts[id] = nft
-
-						// Обновляем в Redis
-						jsonData, _ := json.Marshal(nft)
-						r.redis.Set(r.ctx, "nft:"+nft.ID, jsonData, 0)
-					}
-				}
-				r.mu.Unlock()
-
-				log.Printf("✅ Цены NFT обновлены по курсу BTC: $%.2f", r.BitcoinTracker.CurrentPrice)
-
-			case <-r.stopResetChan:
-				return
-			}
+// updateNFTPrices пересчитывает цены всех NFT по текущему курсу BTC
+func (r *Ranking) updateNFTPrices() error {
+	log.Printf("🔄 Автоматическое обновление цен NFT...")
+
+	// Обновляем курс BTC
+	if _, err := r.GetBitcoinPrice(); err != nil {
+		return fmt.Errorf("ошибка обновления курса BTC: %v", err)
+	}
+
+	// Обновляем цены всех NFT
+	r.mu.Lock()
+	for id, nft := range r.Kki.nfts {
+		newPrice := r.CalculateNFTPrice(nft)
+		if newPrice != nft.Price {
+			nft.Price = newPrice
+			nft.LastUpdated = time.Now()
+			r.Kki.nfts[id] = nft
+
+			// Обновляем в Redis
+			jsonData, _ := json.Marshal(nft)
+			r.redis.Set(r.ctx, "nft:"+nft.ID, jsonData, 0)
 		}
-	}()
+	}
+	r.recordNFTPriceHistory(r.Kki.nfts)
+	r.recordNFTPriceTicks(r.Kki.nfts)
+	r.mu.Unlock()
+
+	log.Printf("✅ Цены NFT обновлены по курсу BTC: $%.2f", r.BitcoinTracker.CurrentPrice)
+	return nil
 }
 
 // HandleBitcoinPriceCommand !btc
@@ -0,0 +1,168 @@
+package ranking
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// leaderboardCacheTTL — как долго отрисованная страница лидеров отдаётся из кэша.
+const leaderboardCacheTTL = time.Minute
+
+// LeaderboardRow — строка публичной таблицы лидеров.
+type LeaderboardRow struct {
+	Place   int
+	Name    string
+	Balance int
+	Badges  []string
+	Rarest  string
+}
+
+// leaderboardPage отдаёт публичную таблицу лидеров (/leaderboard) по ZSET балансов экономики.
+type leaderboardPage struct {
+	r *Ranking
+
+	mu       sync.Mutex
+	rendered []byte
+	at       time.Time
+}
+
+// newLeaderboardPage создаёт обработчик страницы лидеров.
+func newLeaderboardPage(r *Ranking) *leaderboardPage {
+	return &leaderboardPage{r: r}
+}
+
+// ServeHTTP отдаёт страницу, перерисовывая её не чаще раза в минуту.
+func (p *leaderboardPage) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	if p.rendered == nil || time.Since(p.at) > leaderboardCacheTTL {
+		page, err := p.render()
+		if err != nil {
+			p.mu.Unlock()
+			log.Printf("Не удалось отрисовать таблицу лидеров: %v", err)
+			http.Error(w, "leaderboard unavailable", http.StatusInternalServerError)
+			return
+		}
+		p.rendered, p.at = page, time.Now()
+	}
+	page := p.rendered
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// render строит HTML таблицы лидеров.
+func (p *leaderboardPage) render() ([]byte, error) {
+	rows, err := p.r.leaderboardRows(envInt("LEADERBOARD_SIZE", 25))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = leaderboardTemplate.Execute(&buf, map[string]interface{}{
+		"Rows":    rows,
+		"Updated": time.Now().Format("02.01.2006 15:04"),
+	})
+	return buf.Bytes(), err
+}
+
+// leaderboardRows собирает строки таблицы лидеров из ZSET балансов.
+func (r *Ranking) leaderboardRows(limit int) ([]LeaderboardRow, error) {
+	top, err := r.redis.ZRevRangeWithScores(r.ctx, economyBalancesKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]LeaderboardRow, 0, len(top))
+	for i, entry := range top {
+		userID, _ := entry.Member.(string)
+		if entry.Score <= 0 {
+			break
+		}
+		user, _ := r.loadUser(userID)
+		rows = append(rows, LeaderboardRow{
+			Place:   i + 1,
+			Name:    r.publicName(userID),
+			Balance: int(entry.Score),
+			Badges:  r.userBadges(user),
+			Rarest:  r.rarestNFT(userID),
+		})
+	}
+	return rows, nil
+}
+
+// userBadges возвращает значки игрока по его статистике.
+func (r *Ranking) userBadges(user User) []string {
+	badges := r.prestigeBadges(user.ID)
+	if user.DuelsWon >= 10 {
+		badges = append(badges, "⚔️ Дуэлянт")
+	}
+	if user.BJWon >= 25 {
+		badges = append(badges, "🃏 Шулер")
+	}
+	if user.RBWon >= 25 {
+		badges = append(badges, "🎲 Везунчик")
+	}
+	if user.VoiceSeconds >= 100*3600 {
+		badges = append(badges, "🎙️ Голос народа")
+	}
+	if len(r.GetUserInventory(user.ID)) >= 50 {
+		badges = append(badges, "🖼️ Коллекционер")
+	}
+	return badges
+}
+
+// rarestNFT возвращает самую редкую (а при равной редкости — самую дорогую) NFT игрока.
+func (r *Ranking) rarestNFT(userID string) string {
+	rank := make(map[string]int, len(RarityProbabilities))
+	for i, p := range RarityProbabilities {
+		rank[p.Rarity] = i
+	}
+	var best NFT
+	found := false
+	for nftID := range r.GetUserInventory(userID) {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok {
+			continue
+		}
+		if !found || rank[nft.Rarity] > rank[best.Rarity] || (rank[nft.Rarity] == rank[best.Rarity] && nft.Price > best.Price) {
+			best, found = nft, true
+		}
+	}
+	if !found {
+		return "—"
+	}
+	return RarityEmojis[best.Rarity] + " " + best.Name
+}
+
+// leaderboardTemplate — HTML-шаблон таблицы лидеров.
+var leaderboardTemplate = template.Must(template.New("leaderboard").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>ChinaBot 🇨🇳 — таблица лидеров</title>
+<style>
+  body { background: #1e1f22; color: #f2f3f5; font-family: "Segoe UI", sans-serif; margin: 0; padding: 24px; }
+  h1 { color: #ffd700; margin-top: 0; }
+  table { border-collapse: collapse; width: 100%; max-width: 960px; }
+  th, td { padding: 8px 12px; text-align: left; border-bottom: 1px solid #3a3c42; }
+  th { color: #b5bac1; font-weight: 600; }
+  tr:nth-child(1) td { color: #ffd700; } tr:nth-child(2) td { color: #c0c0c0; } tr:nth-child(3) td { color: #cd7f32; }
+  .badge { display: inline-block; background: #2b2d31; border-radius: 6px; padding: 2px 6px; margin: 1px; font-size: 0.85em; }
+  .muted { color: #80848e; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>👑 Таблица лидеров соцкредитов</h1>
+<table>
+<tr><th>#</th><th>Игрок</th><th>Баланс</th><th>Значки</th><th>Редчайшая NFT</th></tr>
+{{range .Rows}}<tr><td>{{.Place}}</td><td>{{.Name}}</td><td>💰 {{.Balance}}</td><td>{{range .Badges}}<span class="badge">{{.}}</span>{{end}}</td><td>{{.Rarest}}</td></tr>
+{{else}}<tr><td colspan="5">Пока никого нет — Император ждёт героев!</td></tr>
+{{end}}</table>
+<p class="muted">Обновлено: {{.Updated}} · Славь Императора! 🇨🇳</p>
+</body>
+</html>
+`))
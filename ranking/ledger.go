@@ -0,0 +1,168 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Журнал кредитов: каждая операция UpdateRatingFrom попадает в список ledger:<userID>.
+const (
+	creditLedgerLimit   = 200 // сколько последних операций хранится у пользователя
+	historyDefaultCount = 10
+	historyMaxCount     = 25
+)
+
+// CreditLedgerEntry — запись журнала кредитов.
+type CreditLedgerEntry struct {
+	At           time.Time `json:"at"`
+	Source       string    `json:"source"`
+	Amount       int       `json:"amount"`
+	Balance      int       `json:"balance"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Note         string    `json:"note,omitempty"` // причина, указанная админом
+}
+
+// creditSourceLabels — подписи источников операций для /history.
+var creditSourceLabels = map[string]string{
+	"blackjack":    "🃏 Блэкджек",
+	"rb":           "🎲 Красное/чёрное",
+	"duel":         "⚔️ Дуэль",
+	"sidebet":      "🎲 Побочная ставка",
+	"poll":         "📊 Ставка в опросе",
+	"cinema":       "🎬 Кино-аукцион",
+	"transfer":     "💸 Перевод",
+	"transfer_tax": "🏛️ Налог на перевод",
+	"tax_pot":      "🏛️ Выплата из общего фонда",
+	"admin":        "👮 Админ",
+	"voice":        "🎙️ Войс",
+	"stream":       "📺 Стрим",
+	"faucet":       "🚰 Кран",
+	"starting":     "🎁 Стартовый баланс",
+	"comeback":     "🎁 Пакет возвращения",
+	"decay":        "⏳ Неактивность",
+	"theme":        "🎨 Тема",
+	"flair":        "✨ Флейр",
+	"market":       "🏪 Рынок",
+	"player_shop":  "🏬 Лавки игроков",
+	"offer":        "🔄 Обмен",
+	"tutorial":     "🎓 Обучение",
+	"role_shop":    "🛒 Магазин ролей",
+	"nft_sale":     "🖼️ Продажа NFT",
+	"case_buy":     "📦 Покупка кейсов",
+	"jade":         "💱 Обмен нефрита",
+	"case_sale":    "📦 Продажа кейсов",
+	"case_trade":   "🤝 Сделка с кейсами",
+	"escrow":       "🔒 Эскроу",
+	"bank":         "🏦 Банк",
+	"daily":        "📅 Ежедневная награда",
+	"ubi":          "🏛️ Базовый доход",
+	"payroll":      "💼 Зарплата",
+	"airdrop":      "🪂 Аирдроп",
+	"bracket":      "🏟️ Турнир",
+	"season":       "📅 Новый сезон",
+	"loan":         "🏦 Займ",
+	"loan_repay":   "🏦 Погашение займа",
+	"debt":         "💳 Проценты по долгу",
+	"other":        "❔ Прочее",
+}
+
+// creditLedgerKey возвращает ключ журнала кредитов пользователя.
+func creditLedgerKey(userID string) string {
+	return "ledger:" + userID
+}
+
+// recordCreditLedger записывает изменение баланса в журнал пользователя.
+func (r *Ranking) recordCreditLedger(userID string, amount, balance int, source, counterparty, note string) {
+	if amount == 0 {
+		return
+	}
+	data, _ := json.Marshal(CreditLedgerEntry{At: time.Now(), Source: source, Amount: amount, Balance: balance, Counterparty: counterparty, Note: note})
+	pipe := r.redis.Pipeline()
+	pipe.LPush(r.ctx, creditLedgerKey(userID), data)
+	pipe.LTrim(r.ctx, creditLedgerKey(userID), 0, creditLedgerLimit-1)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось записать операцию %s (%+d) пользователя %s в журнал: %v", source, amount, userID, err)
+		r.ReportError("redis", err)
+	}
+}
+
+// creditLedger возвращает последние n операций пользователя, новые первыми.
+func (r *Ranking) creditLedger(userID string, n int) ([]CreditLedgerEntry, error) {
+	raw, err := r.redis.LRange(r.ctx, creditLedgerKey(userID), 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]CreditLedgerEntry, 0, len(raw))
+	for _, item := range raw {
+		var entry CreditLedgerEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// HandleHistoryCommand обрабатывает команду !history [@user] [n].
+func (r *Ranking) HandleHistoryCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !history: %s от %s", command, m.Author.ID)
+
+	userID := m.Author.ID
+	if len(m.Mentions) > 0 {
+		userID = m.Mentions[0].ID
+	}
+	count := historyDefaultCount
+	for _, part := range strings.Fields(command)[1:] {
+		if strings.HasPrefix(part, "<@") {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/history [@user] [количество]`")
+			return
+		}
+		count = min(n, historyMaxCount)
+	}
+
+	entries, err := r.creditLedger(userID, count)
+	if err != nil {
+		log.Printf("Не удалось прочитать журнал кредитов %s: %v", userID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось загрузить историю операций!")
+		return
+	}
+	if len(entries) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ У <@%s> пока нет операций с кредитами.", userID))
+		return
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		label, ok := creditSourceLabels[entry.Source]
+		if !ok {
+			label = entry.Source
+		}
+		line := fmt.Sprintf("<t:%d:R> %s **%s**", entry.At.Unix(), label, formatCreditsDelta(entry.Amount))
+		if entry.Counterparty != "" {
+			line += fmt.Sprintf(" · <@%s>", entry.Counterparty)
+		}
+		if entry.Note != "" {
+			line += fmt.Sprintf(" · «%s»", truncate(entry.Note, 60))
+		}
+		line += fmt.Sprintf(" → %s", formatCredits(entry.Balance))
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📜 История операций",
+		Description: truncate(fmt.Sprintf("Игрок: <@%s>\n\n%s", userID, strings.Join(lines, "\n")), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Последние %d операций · /history [@user] [n]", len(entries))},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,290 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Займы у казино: игрок получает кредиты сразу, а долг с процентами гасится долей будущих
+// выигрышей и начислений за войс. Пока просроченный долг не погашен, новые игры недоступны.
+
+// loanIncomeSources — доходы, с которых удерживается погашение займа при любом начислении.
+// Игровые выплаты сюда не входят: в них возвращается ставка, поэтому погашение удерживается
+// явно с чистого выигрыша через repayLoanFromWinnings.
+var loanIncomeSources = map[string]bool{
+	"voice": true,
+}
+
+// Loan — текущий займ пользователя (хэш loan:<userID>).
+type Loan struct {
+	Principal int
+	Owed      int
+	TakenAt   time.Time
+	DueAt     time.Time
+}
+
+// loanKey возвращает ключ займа пользователя.
+func loanKey(userID string) string {
+	return "loan:" + userID
+}
+
+// LoanMax возвращает максимальную сумму займа.
+func (r *Ranking) LoanMax() int {
+	return r.GetIntSetting("loan_max", envInt("LOAN_MAX", 500))
+}
+
+// LoanInterestPercent возвращает процент, который добавляется к сумме займа.
+func (r *Ranking) LoanInterestPercent() int {
+	return r.GetIntSetting("loan_interest_percent", envInt("LOAN_INTEREST_PERCENT", 10))
+}
+
+// LoanTerm возвращает срок погашения займа.
+func (r *Ranking) LoanTerm() time.Duration {
+	days := r.GetIntSetting("loan_days", envInt("LOAN_DAYS", 7))
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// LoanRepayPercent возвращает долю дохода, удерживаемую в счёт займа.
+func (r *Ranking) LoanRepayPercent() int {
+	return r.GetIntSetting("loan_repay_percent", envInt("LOAN_REPAY_PERCENT", 50))
+}
+
+// getLoan возвращает займ пользователя; ok=false, если займа нет.
+func (r *Ranking) getLoan(userID string) (Loan, bool) {
+	var loan Loan
+	fields, err := r.redis.HGetAll(r.ctx, loanKey(userID)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось получить займ %s: %v", userID, err)
+		}
+		return loan, false
+	}
+	if len(fields) == 0 {
+		return loan, false
+	}
+	loan.Principal, _ = strconv.Atoi(fields["principal"])
+	loan.Owed, _ = strconv.Atoi(fields["owed"])
+	taken, _ := strconv.ParseInt(fields["taken_at"], 10, 64)
+	due, _ := strconv.ParseInt(fields["due_at"], 10, 64)
+	loan.TakenAt, loan.DueAt = time.Unix(taken, 0), time.Unix(due, 0)
+	return loan, loan.Owed > 0
+}
+
+// loanDefaultMessage возвращает текст отказа, если у пользователя просроченный займ.
+func (r *Ranking) loanDefaultMessage(userID string) string {
+	loan, ok := r.getLoan(userID)
+	if !ok || time.Now().Before(loan.DueAt) {
+		return ""
+	}
+	return fmt.Sprintf("❌ Займ просрочен! Погаси долг %s командой `/repay`, прежде чем играть. 🏦", formatCredits(loan.Owed))
+}
+
+// withholdLoanRepayment удерживает часть дохода в счёт займа и возвращает удержанную сумму.
+func (r *Ranking) withholdLoanRepayment(userID string, income int) int {
+	loan, ok := r.getLoan(userID)
+	if !ok {
+		return 0
+	}
+	amount := min(max(income*r.LoanRepayPercent()/100, 1), loan.Owed)
+	return r.repayLoan(userID, amount)
+}
+
+// repayLoanFromWinnings удерживает часть чистого выигрыша (без возвращённой ставки) в счёт займа.
+func (r *Ranking) repayLoanFromWinnings(userID string, profit int) {
+	if profit <= 0 {
+		return
+	}
+	if repaid := r.withholdLoanRepayment(userID, profit); repaid > 0 {
+		r.UpdateRatingFrom(userID, -repaid, "loan_repay", "")
+	}
+}
+
+// repayLoan уменьшает долг и возвращает фактически погашенную сумму. Полностью погашенный займ удаляется.
+func (r *Ranking) repayLoan(userID string, amount int) int {
+	owed, err := r.redis.HIncrBy(r.ctx, loanKey(userID), "owed", int64(-amount)).Result()
+	if err != nil {
+		log.Printf("Не удалось погасить займ %s: %v", userID, err)
+		return 0
+	}
+	if owed <= 0 {
+		r.redis.Del(r.ctx, loanKey(userID))
+		amount += int(owed) // не берём больше долга
+		log.Printf("Займ %s погашен полностью", userID)
+	}
+	return amount
+}
+
+// HandleLoanCommand обрабатывает команду !loan [сумма].
+func (r *Ranking) HandleLoanCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !loan: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendLoanStatus(s, m.ChannelID, m.Author.ID)
+		return
+	}
+	amount, err := strconv.Atoi(parts[1])
+	if len(parts) != 2 || err != nil || amount <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/loan <сумма>` или `/loan` — состояние займа")
+		return
+	}
+	if maxLoan := r.LoanMax(); amount > maxLoan {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Казино даёт в долг не больше %s!", formatCredits(maxLoan)))
+		return
+	}
+
+	r.mu.Lock()
+	if loan, ok := r.getLoan(m.Author.ID); ok {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Сначала погаси текущий займ: осталось %s.", formatCredits(loan.Owed)))
+		return
+	}
+	now := time.Now()
+	owed := amount * (100 + r.LoanInterestPercent()) / 100
+	due := now.Add(r.LoanTerm())
+	err = r.redis.HSet(r.ctx, loanKey(m.Author.ID), map[string]interface{}{
+		"principal": amount,
+		"owed":      owed,
+		"taken_at":  now.Unix(),
+		"due_at":    due.Unix(),
+	}).Err()
+	if err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось оформить займ %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Казино сейчас не выдаёт займы, попробуй позже!")
+		return
+	}
+	r.UpdateRatingFrom(m.Author.ID, amount, "loan", "")
+	r.mu.Unlock()
+
+	r.LogCreditOperation(s, fmt.Sprintf("🏦 <@%s> взял займ %s, вернуть %s до <t:%d:f>", m.Author.ID, formatCredits(amount), formatCredits(owed), due.Unix()))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏦 <@%s> получил займ %s. Вернуть %s до <t:%d:f> — %d%% выигрышей и войса идут в погашение. Просрочка закроет доступ к играм!",
+		m.Author.ID, formatCredits(amount), formatCredits(owed), due.Unix(), r.LoanRepayPercent()))
+}
+
+// HandleRepayCommand обрабатывает команду !repay <сумма|all>.
+func (r *Ranking) HandleRepayCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !repay: %s от %s", command, m.Author.ID)
+
+	r.mu.Lock()
+	loan, ok := r.getLoan(m.Author.ID)
+	if !ok {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ У тебя нет займа. Император доволен! 👑")
+		return
+	}
+	balance := r.GetRating(m.Author.ID)
+	amount, valid := parseBankAmount(command, min(balance, loan.Owed))
+	if !valid {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/repay <сумма|all>`")
+		return
+	}
+	amount = min(amount, loan.Owed)
+	if balance < amount {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(balance)))
+		return
+	}
+	paid := r.repayLoan(m.Author.ID, amount)
+	if paid > 0 {
+		r.UpdateRatingFrom(m.Author.ID, -paid, "loan_repay", "")
+	}
+	r.mu.Unlock()
+
+	left := loan.Owed - paid
+	r.LogCreditOperation(s, fmt.Sprintf("🏦 <@%s> погасил %s займа, осталось %s", m.Author.ID, formatCredits(paid), formatCredits(left)))
+	if left <= 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ <@%s> полностью погасил займ! Император доволен! 👑", m.Author.ID))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ <@%s> погасил %s. Осталось: %s", m.Author.ID, formatCredits(paid), formatCredits(left)))
+}
+
+// sendLoanStatus отправляет состояние займа пользователя.
+func (r *Ranking) sendLoanStatus(s *discordgo.Session, channelID, userID string) {
+	loan, ok := r.getLoan(userID)
+	if !ok {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("ℹ️ Займа нет. Взять до %s под %d%% на %d дн.: `/loan <сумма>`",
+			formatCredits(r.LoanMax()), r.LoanInterestPercent(), int(r.LoanTerm().Hours()/24)))
+		return
+	}
+	status := "✅ в срок"
+	if time.Now().After(loan.DueAt) {
+		status = "⛔ просрочен — игры недоступны"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "🏦 Займ у казино",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💸 Взято", Value: formatCredits(loan.Principal), Inline: true},
+			{Name: "📉 Осталось вернуть", Value: formatCredits(loan.Owed), Inline: true},
+			{Name: "📅 Срок", Value: fmt.Sprintf("<t:%d:R>", loan.DueAt.Unix()), Inline: true},
+			{Name: "Статус", Value: status, Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d%% выигрышей и войса идут в погашение · /repay <сумма|all>", r.LoanRepayPercent())},
+	}
+	s.ChannelMessageSendEmbed(channelID, embed)
+}
+
+// loanSettings сопоставляет подкоманды !a_loan с настройками.
+var loanSettings = map[string]string{
+	"max":      "loan_max",
+	"interest": "loan_interest_percent",
+	"days":     "loan_days",
+	"repay":    "loan_repay_percent",
+}
+
+// HandleLoanSettingsCommand обрабатывает команду !a_loan [max|interest|days|repay <значение>].
+func (r *Ranking) HandleLoanSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_loan: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать займы! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_loan [max|interest|days|repay <значение>]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		setting, ok := loanSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🏦 Займы: настройки",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💸 Максимум", Value: formatCredits(r.LoanMax()), Inline: true},
+			{Name: "📈 Процент", Value: fmt.Sprintf("%d%%", r.LoanInterestPercent()), Inline: true},
+			{Name: "📅 Срок", Value: fmt.Sprintf("%d дн.", int(r.LoanTerm().Hours()/24)), Inline: true},
+			{Name: "✂️ Удержание с дохода", Value: fmt.Sprintf("%d%%", r.LoanRepayPercent()), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_loan max|interest|days|repay <значение>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
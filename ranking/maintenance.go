@@ -0,0 +1,149 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// maintenanceReasonKey хранит причину технических работ.
+const maintenanceReasonKey = "settings:maintenance_reason"
+
+// maintenanceButtonPrefixes — кнопки, которые начинают новые экономические операции.
+// Кнопки уже идущих игр (взять карту, остановиться) работают, чтобы игроки могли доиграть.
+var maintenanceButtonPrefixes = []string{
+	"sell_confirm_",
+	"trade_collection_confirm_",
+	"escrow_accept_",
+	"comeback_claim_",
+	"nft_sell_",
+	"sell_duplicates_confirm_",
+	"sell_menu_confirm_",
+	"user_confirm_",
+	"user_existing_",
+	"blackjack_replay_",
+	"blackjack_rebet_",
+	"rb_replay_",
+	"rb_rebet_",
+	"duel_accept_",
+	"bracket_join_",
+	"tutorial_check_",
+	"offer_confirm_",
+	"ctx_duel_",
+	"ctx_transfer_",
+	"transfer_confirm_",
+	"transfer_accept_",
+	"airdrop_grab_",
+}
+
+// MaintenanceMode сообщает, включён ли режим технических работ.
+func (r *Ranking) MaintenanceMode() bool {
+	return r.GetIntSetting("maintenance", 0) == 1
+}
+
+// maintenanceReason возвращает причину технических работ, если она указана.
+func (r *Ranking) maintenanceReason() string {
+	reason, err := r.redis.Get(r.ctx, maintenanceReasonKey).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось получить причину техработ: %v", err)
+	}
+	return reason
+}
+
+// maintenanceEmbed формирует баннер о технических работах.
+func (r *Ranking) maintenanceEmbed() *discordgo.MessageEmbed {
+	description := "Игры, переводы, торговля и кейсы временно на паузе. Посмотреть баланс, топы и инвентарь можно как обычно. 🙏"
+	if reason := r.maintenanceReason(); reason != "" {
+		description = fmt.Sprintf("**Причина:** %s\n\n%s", reason, description)
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "🛠️ Технические работы",
+		Description: description,
+		Color:       0xFFA500,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Император скоро вернётся! 👑"},
+	}
+}
+
+// CheckMaintenance возвращает true и показывает баннер, если команда заблокирована техработами.
+// Администраторы не блокируются.
+func (r *Ranking) CheckMaintenance(s *discordgo.Session, m *discordgo.MessageCreate, command string) bool {
+	if !IsEconomyCommand(command) || !r.MaintenanceMode() || r.IsAdmin(m.Author.ID) {
+		return false
+	}
+	log.Printf("Команда %s от %s заблокирована режимом техработ", command, m.Author.ID)
+	s.ChannelMessageSendEmbed(m.ChannelID, r.maintenanceEmbed())
+	return true
+}
+
+// CheckMaintenanceButton возвращает true и отвечает эфемерным баннером, если кнопка заблокирована техработами.
+func (r *Ranking) CheckMaintenanceButton(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) bool {
+	blocked := false
+	for _, prefix := range maintenanceButtonPrefixes {
+		if strings.HasPrefix(customID, prefix) {
+			blocked = true
+			break
+		}
+	}
+	if !blocked || !r.MaintenanceMode() || r.IsAdmin(i.Member.User.ID) {
+		return false
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{r.maintenanceEmbed()},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	return true
+}
+
+// HandleMaintenanceCommand обрабатывает команду !a_maintenance on/off [причина].
+func (r *Ranking) HandleMaintenanceCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_maintenance: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут включать техработы! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		state := "выключен"
+		if r.MaintenanceMode() {
+			state = "включён"
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🛠️ Режим техработ %s. Используй: `/a_maintenance on [причина]` или `/a_maintenance off`", state))
+		return
+	}
+
+	switch parts[1] {
+	case "on":
+		// Причину берём из исходного текста, чтобы сохранить регистр
+		reason := ""
+		if original := strings.Fields(m.Content); len(original) > 2 {
+			reason = strings.Join(original[2:], " ")
+		}
+		if err := r.SetIntSetting("maintenance", 1); err != nil {
+			log.Printf("Не удалось включить техработы: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения! Проверьте Redis-сервер.")
+			return
+		}
+		r.redis.Set(r.ctx, maintenanceReasonKey, reason, 0)
+		s.ChannelMessageSendEmbed(m.ChannelID, r.maintenanceEmbed())
+		r.LogCreditOperation(s, fmt.Sprintf("🛠️ Админ <@%s> включил режим техработ: %s", m.Author.ID, reason))
+	case "off":
+		if err := r.SetIntSetting("maintenance", 0); err != nil {
+			log.Printf("Не удалось выключить техработы: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения! Проверьте Redis-сервер.")
+			return
+		}
+		r.redis.Del(r.ctx, maintenanceReasonKey)
+		s.ChannelMessageSend(m.ChannelID, "✅ Техработы завершены, экономика снова работает! 🎉")
+		r.LogCreditOperation(s, fmt.Sprintf("🛠️ Админ <@%s> выключил режим техработ", m.Author.ID))
+	default:
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_maintenance on [причина]` или `/a_maintenance off`")
+	}
+}
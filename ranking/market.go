@@ -0,0 +1,401 @@
+package ranking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Рынок игроков. Выставленные NFT удерживаются в эскроу до покупки, снятия или истечения лота;
+// истёкшие удержания возвращает продавцу escrow_sweeper, а сам лот при этом исчезает вместе с ключом.
+const (
+	marketSeqKey      = "market:seq"      // счётчик ID лотов
+	marketListingsKey = "market:listings" // ZSET ID лота -> unix-время выставления
+	marketMaxPerUser  = 10
+	marketMaxPrice    = 1_000_000_000 // предельная цена за штуку
+	marketPageSize    = 15
+)
+
+// MarketListing — лот на рынке: Count штук NFT по цене Price за штуку.
+type MarketListing struct {
+	ID        int64     `json:"id"`
+	Seller    string    `json:"seller"`
+	NFTID     string    `json:"nft_id"`
+	Count     int       `json:"count"`
+	Price     int       `json:"price"`
+	HoldID    string    `json:"hold_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Total возвращает цену лота целиком.
+func (l MarketListing) Total() int {
+	return l.Price * l.Count
+}
+
+// errMarketListingGone возвращается, если лот уже куплен, снят или истёк.
+var errMarketListingGone = errors.New("лот уже продан или снят")
+
+// marketListingKey возвращает ключ лота.
+func marketListingKey(id int64) string {
+	return fmt.Sprintf("market:listing:%d", id)
+}
+
+// marketSellerKey возвращает множество активных лотов продавца.
+func marketSellerKey(userID string) string {
+	return "market:seller:" + userID
+}
+
+// marketListingTTL возвращает срок жизни лота.
+func marketListingTTL() time.Duration {
+	return time.Duration(envInt("MARKET_LISTING_DAYS", 7)) * 24 * time.Hour
+}
+
+// loadMarketListing читает лот по ID.
+func (r *Ranking) loadMarketListing(id int64) (MarketListing, error) {
+	var listing MarketListing
+	data, err := r.redis.Get(r.ctx, marketListingKey(id)).Bytes()
+	if err == redis.Nil {
+		return listing, errMarketListingGone
+	}
+	if err != nil {
+		return listing, err
+	}
+	err = json.Unmarshal(data, &listing)
+	return listing, err
+}
+
+// dropMarketListing удаляет лот из индексов.
+func (r *Ranking) dropMarketListing(listing MarketListing) {
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, marketListingKey(listing.ID))
+	pipe.ZRem(r.ctx, marketListingsKey, listing.ID)
+	pipe.SRem(r.ctx, marketSellerKey(listing.Seller), listing.ID)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось удалить лот %d: %v", listing.ID, err)
+	}
+}
+
+// marketListings возвращает активные лоты. Лоты, чей ключ уже истёк, вычищаются из индексов.
+func (r *Ranking) marketListings() ([]MarketListing, error) {
+	ids, err := r.redis.ZRange(r.ctx, marketListingsKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	listings := make([]MarketListing, 0, len(ids))
+	for _, raw := range ids {
+		id, _ := strconv.ParseInt(raw, 10, 64)
+		listing, err := r.loadMarketListing(id)
+		if err == errMarketListingGone {
+			r.redis.ZRem(r.ctx, marketListingsKey, raw)
+			continue
+		}
+		if err != nil {
+			log.Printf("Не удалось прочитать лот %s: %v", raw, err)
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// marketSellerCount возвращает число активных лотов продавца.
+func (r *Ranking) marketSellerCount(userID string) int {
+	ids, err := r.redis.SMembers(r.ctx, marketSellerKey(userID)).Result()
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, raw := range ids {
+		id, _ := strconv.ParseInt(raw, 10, 64)
+		if exists, _ := r.redis.Exists(r.ctx, marketListingKey(id)).Result(); exists > 0 {
+			count++
+		} else {
+			r.redis.SRem(r.ctx, marketSellerKey(userID), raw)
+		}
+	}
+	return count
+}
+
+// createMarketListing удерживает NFT продавца и выставляет лот.
+func (r *Ranking) createMarketListing(sellerID, nftID string, count, price int) (MarketListing, error) {
+	listing := MarketListing{Seller: sellerID, NFTID: nftID, Count: count, Price: price}
+	// Цена лота целиком не должна переполнять int, иначе она станет отрицательной
+	if count <= 0 || price <= 0 || price > marketMaxPrice || price > math.MaxInt/count {
+		return listing, fmt.Errorf("цена за штуку — от 1 до %s, а лот целиком не должен стоить больше %s", formatCredits(marketMaxPrice), formatCredits(math.MaxInt))
+	}
+	if r.marketSellerCount(sellerID) >= marketMaxPerUser {
+		return listing, fmt.Errorf("у тебя уже %d лотов — сними какой-нибудь: `/market cancel <ID>`", marketMaxPerUser)
+	}
+	id, err := r.redis.Incr(r.ctx, marketSeqKey).Result()
+	if err != nil {
+		return listing, fmt.Errorf("ошибка Redis: %v", err)
+	}
+	ttl := marketListingTTL()
+	listing.ID = id
+	listing.HoldID = fmt.Sprintf("market:%d", id)
+	listing.CreatedAt = time.Now()
+	listing.ExpiresAt = listing.CreatedAt.Add(ttl)
+
+	hold := EscrowHold{ID: listing.HoldID, Owner: sellerID, Reason: "market", Source: "market", NFTs: map[string]int{nftID: count}}
+	if err := r.escrowReserve(hold, ttl); err != nil {
+		return listing, err
+	}
+	data, _ := json.Marshal(listing)
+	pipe := r.redis.TxPipeline()
+	pipe.Set(r.ctx, marketListingKey(id), data, ttl)
+	pipe.ZAdd(r.ctx, marketListingsKey, &redis.Z{Score: float64(listing.CreatedAt.Unix()), Member: id})
+	pipe.SAdd(r.ctx, marketSellerKey(sellerID), id)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		r.escrowRollback(hold.ID)
+		return listing, fmt.Errorf("ошибка Redis: %v", err)
+	}
+	return listing, nil
+}
+
+// buyMarketListing продаёт лот покупателю: списывает кредиты и передаёт удержанные NFT.
+func (r *Ranking) buyMarketListing(buyerID string, id int64) (MarketListing, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	listing, err := r.loadMarketListing(id)
+	if err != nil {
+		return listing, 0, err
+	}
+	if listing.Total() <= 0 {
+		return listing, 0, fmt.Errorf("лот #%d с некорректной ценой — его нужно снять", id)
+	}
+	if listing.Seller == buyerID {
+		return listing, 0, fmt.Errorf("нельзя купить свой лот — сними его: `/market cancel %d`", id)
+	}
+	if rating := r.GetRating(buyerID); rating < listing.Total() {
+		return listing, 0, fmt.Errorf("недостаточно кредитов: лот стоит %s, твой баланс %s", formatCredits(listing.Total()), formatCredits(rating))
+	}
+	hold, err := r.escrowCommit(listing.HoldID)
+	if err == errEscrowClosed {
+		r.dropMarketListing(listing)
+		return listing, 0, errMarketListingGone
+	}
+	if err != nil {
+		return listing, 0, fmt.Errorf("ошибка эскроу: %v", err)
+	}
+	r.dropMarketListing(listing)
+
+	r.UpdateRatingFrom(buyerID, -listing.Total(), "market", listing.Seller)
+	r.UpdateRatingFrom(listing.Seller, listing.Total(), "market", buyerID)
+	r.escrowDeliverLocked(hold, buyerID, "market")
+	r.recordNFTSale(listing.NFTID, listing.Price)
+	ledgerID := r.recordNFTMutation("market", buyerID, listing.Seller, buyerID, hold.NFTs)
+	return listing, ledgerID, nil
+}
+
+// cancelMarketListing снимает лот и возвращает NFT продавцу. Админ может снять любой лот.
+func (r *Ranking) cancelMarketListing(userID string, id int64) (MarketListing, error) {
+	listing, err := r.loadMarketListing(id)
+	if err != nil {
+		return listing, err
+	}
+	if listing.Seller != userID && !r.IsAdmin(userID) {
+		return listing, fmt.Errorf("это не твой лот")
+	}
+	if err := r.escrowRollback(listing.HoldID); err != nil {
+		if err == errEscrowClosed {
+			r.dropMarketListing(listing)
+			return listing, errMarketListingGone
+		}
+		return listing, fmt.Errorf("ошибка эскроу: %v", err)
+	}
+	r.dropMarketListing(listing)
+	return listing, nil
+}
+
+// marketFilter — фильтры списка лотов.
+type marketFilter struct {
+	Rarity string
+	NFTID  string
+	Seller string
+	Max    int
+	Page   int
+}
+
+// parseMarketFilter разбирает фильтры !market list: rarity:<редкость> nft:<ID> max:<цена> @продавец <страница>.
+func parseMarketFilter(args []string, mentions []*discordgo.User) (marketFilter, bool) {
+	filter := marketFilter{Page: 1}
+	if len(mentions) == 1 {
+		filter.Seller = mentions[0].ID
+	}
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "<@"):
+		case strings.HasPrefix(arg, "rarity:"):
+			filter.Rarity = strings.ToLower(strings.TrimPrefix(arg, "rarity:"))
+		case strings.HasPrefix(arg, "nft:"):
+			filter.NFTID = strings.TrimPrefix(arg, "nft:")
+		case strings.HasPrefix(arg, "max:"):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "max:"))
+			if err != nil || n <= 0 {
+				return filter, false
+			}
+			filter.Max = n
+		default:
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return filter, false
+			}
+			filter.Page = n
+		}
+	}
+	return filter, true
+}
+
+// matches проверяет лот по фильтрам.
+func (f marketFilter) matches(listing MarketListing, nft NFT) bool {
+	if f.Rarity != "" && strings.ToLower(nft.Rarity) != f.Rarity {
+		return false
+	}
+	if f.NFTID != "" && listing.NFTID != f.NFTID {
+		return false
+	}
+	if f.Seller != "" && listing.Seller != f.Seller {
+		return false
+	}
+	return f.Max == 0 || listing.Price <= f.Max
+}
+
+// HandleMarketCommand обрабатывает команду !market [list|sell|buy|cancel|my].
+func (r *Ranking) HandleMarketCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !market: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/market list [rarity:<редкость>] [nft:<ID>] [max:<цена>] [@продавец] [страница]`, `/market sell <nftID> <кол-во> <цена за штуку>`, `/market buy <ID>`, `/market cancel <ID>` или `/market my`"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendMarketListings(s, m.ChannelID, marketFilter{Page: 1})
+		return
+	}
+
+	switch parts[1] {
+	case "list":
+		filter, ok := parseMarketFilter(parts[2:], m.Mentions)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		r.sendMarketListings(s, m.ChannelID, filter)
+	case "my":
+		r.sendMarketListings(s, m.ChannelID, marketFilter{Seller: m.Author.ID, Page: 1})
+	case "sell":
+		if len(parts) != 5 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/market sell <nftID> <кол-во> <цена за штуку>`")
+			return
+		}
+		nftID := parts[2]
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ NFT не найдено. Проверьте ID.")
+			return
+		}
+		count, errCount := strconv.Atoi(parts[3])
+		price, errPrice := strconv.Atoi(parts[4])
+		if errCount != nil || errPrice != nil || count <= 0 || price <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Количество и цена должны быть положительными числами!")
+			return
+		}
+		listing, err := r.createMarketListing(m.Author.ID, nftID, count, price)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Не удалось выставить лот: "+err.Error())
+			return
+		}
+		log.Printf("Лот %d: %s выставил %d x %s по %d", listing.ID, m.Author.ID, count, nftID, price)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Лот `#%d` выставлен: %d x %s **%s** по %s (всего %s). NFT удержаны до <t:%d:f>. Снять: `/market cancel %d`",
+			listing.ID, count, RarityEmojis[nft.Rarity], nft.Name, formatCredits(price), formatCredits(listing.Total()), listing.ExpiresAt.Unix(), listing.ID))
+		go r.notifyWishlist(s, nftID, fmt.Sprintf("выставлена на рынок: лот `#%d`, %d шт. по %s. Купить: `/market buy %d`", listing.ID, count, formatCredits(price), listing.ID), m.ChannelID, m.Author.ID)
+	case "buy", "cancel":
+		if len(parts) != 3 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(parts[2], "#"), 10, 64)
+		if err != nil || id <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Номер лота должен быть положительным числом!")
+			return
+		}
+		if parts[1] == "cancel" {
+			listing, err := r.cancelMarketListing(m.Author.ID, id)
+			if err != nil {
+				s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+				return
+			}
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Лот `#%d` снят, NFT возвращены <@%s>.", id, listing.Seller))
+			return
+		}
+		listing, ledgerID, err := r.buyMarketListing(m.Author.ID, id)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Покупка невозможна: "+err.Error())
+			return
+		}
+		text := fmt.Sprintf("🏪 <@%s> купил лот #%d у <@%s>: %s за %s", m.Author.ID, id, listing.Seller, r.describeNFTItems(map[string]int{listing.NFTID: listing.Count}), formatCredits(listing.Total()))
+		log.Printf("Лот %d куплен %s у %s за %d", id, m.Author.ID, listing.Seller, listing.Total())
+		r.LogCreditOperation(s, text)
+		s.ChannelMessageSend(m.ChannelID, "✅ "+text+ledgerRef(ledgerID))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// sendMarketListings отправляет страницу лотов, подходящих под фильтры, от дешёвых к дорогим.
+func (r *Ranking) sendMarketListings(s *discordgo.Session, channelID string, filter marketFilter) {
+	listings, err := r.marketListings()
+	if err != nil {
+		log.Printf("Не удалось получить лоты рынка: %v", err)
+		s.ChannelMessageSend(channelID, "❌ Ошибка рынка! Попробуйте позже.")
+		return
+	}
+	var matched []MarketListing
+	for _, listing := range listings {
+		nft, ok := r.Kki.nfts[listing.NFTID]
+		if ok && filter.matches(listing, nft) {
+			matched = append(matched, listing)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Price != matched[j].Price {
+			return matched[i].Price < matched[j].Price
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	pages := max((len(matched)+marketPageSize-1)/marketPageSize, 1)
+	page := filter.Page
+	if page > pages {
+		page = pages
+	}
+	start := (page - 1) * marketPageSize
+	end := min(start+marketPageSize, len(matched))
+
+	lines := make([]string, 0, end-start)
+	for _, listing := range matched[start:end] {
+		nft := r.Kki.nfts[listing.NFTID]
+		lines = append(lines, fmt.Sprintf("`#%d` %s **%s** (`%s`) x%d — %s/шт · %s · до <t:%d:R>",
+			listing.ID, RarityEmojis[nft.Rarity], nft.Name, listing.NFTID, listing.Count, formatCredits(listing.Price), r.publicMention(listing.Seller), listing.ExpiresAt.Unix()))
+	}
+	description := "Лотов нет. Выстави свои NFT: `/market sell <nftID> <кол-во> <цена>`"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🏪 Рынок игроков",
+		Description: truncate(description, 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Страница %d/%d · лотов: %d · /market buy <ID>", page, pages, len(matched))},
+	}
+	s.ChannelMessageSendEmbed(channelID, embed)
+}
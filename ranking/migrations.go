@@ -0,0 +1,146 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Ключи состояния миграций.
+const (
+	schemaVersionKey = "schema_version"
+	schemaLockKey    = "schema_lock"
+)
+
+// Migration описывает версионированное изменение формата данных в Redis.
+// Миграции должны быть идемпотентными: при сбое посередине миграция перезапустится целиком.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(r *Ranking) error
+}
+
+// migrations — список миграций по возрастанию версии. Новые миграции добавляются только в конец.
+var migrations = []Migration{
+	{Version: 1, Name: "case_inventory_daily_to_daily_case", Up: migrateDailyCaseID},
+	{Version: 2, Name: "user_blob_fill_id", Up: migrateUserBlobIDs},
+	{Version: 3, Name: "top_sets_backfill", Up: migrateTopSets},
+}
+
+// runMigrations применяет непримененные миграции и сохраняет текущую версию схемы.
+func (r *Ranking) runMigrations() error {
+	ok, err := r.redis.SetNX(r.ctx, schemaLockKey, time.Now().Unix(), 10*time.Minute).Result()
+	if err != nil {
+		return fmt.Errorf("не удалось взять блокировку миграций: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("миграции уже выполняются другим экземпляром (ключ %s)", schemaLockKey)
+	}
+	defer r.redis.Del(r.ctx, schemaLockKey)
+
+	current, err := r.redis.Get(r.ctx, schemaVersionKey).Int()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("не удалось получить версию схемы: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		log.Printf("Применяется миграция %d: %s", m.Version, m.Name)
+		start := time.Now()
+		if err := m.Up(r); err != nil {
+			return fmt.Errorf("миграция %d (%s) не удалась: %v", m.Version, m.Name, err)
+		}
+		if err := r.redis.Set(r.ctx, schemaVersionKey, m.Version, 0).Err(); err != nil {
+			return fmt.Errorf("не удалось сохранить версию схемы %d: %v", m.Version, err)
+		}
+		current = m.Version
+		log.Printf("Миграция %d применена за %s", m.Version, time.Since(start))
+	}
+	log.Printf("Версия схемы данных: %d", current)
+	return nil
+}
+
+// scanKeys перебирает ключи по шаблону через SCAN и вызывает fn для каждого.
+func (r *Ranking) scanKeys(pattern string, fn func(key string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(r.ctx, cursor, pattern, 200).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// migrateDailyCaseID переносит кейсы со старым ID "daily" в "daily_case".
+func migrateDailyCaseID(r *Ranking) error {
+	migrated := 0
+	err := r.scanKeys("case_inventory:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var inv UserCaseInventory
+		if err := json.Unmarshal(data, &inv); err != nil {
+			log.Printf("Пропуск %s: %v", key, err)
+			return nil
+		}
+		count, ok := inv["daily"]
+		if !ok {
+			return nil
+		}
+		inv["daily_case"] += count
+		delete(inv, "daily")
+		jsonData, _ := json.Marshal(inv)
+		if err := r.redis.Set(r.ctx, key, jsonData, 0).Err(); err != nil {
+			return err
+		}
+		migrated++
+		return nil
+	})
+	log.Printf("Перенесено кейсов daily -> daily_case: %d инвентарей", migrated)
+	return err
+}
+
+// migrateUserBlobIDs заполняет поле id в JSON пользователей, сохранённых без него.
+func migrateUserBlobIDs(r *Ranking) error {
+	fixed := 0
+	err := r.scanKeys("user:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil {
+			log.Printf("Пропуск %s: %v", key, err)
+			return nil
+		}
+		if user.ID != "" {
+			return nil
+		}
+		user.ID = strings.TrimPrefix(key, "user:")
+		jsonData, _ := json.Marshal(user)
+		if err := r.redis.Set(r.ctx, key, jsonData, 0).Err(); err != nil {
+			return err
+		}
+		r.users.invalidate(user.ID)
+		fixed++
+		return nil
+	})
+	log.Printf("Заполнен id у %d пользователей", fixed)
+	return err
+}
@@ -0,0 +1,110 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// NetWorth — состояние игрока по категориям: кредиты, вклад, удержания, NFT и кейсы по текущим ценам.
+type NetWorth struct {
+	Credits int
+	Bank    int
+	Held    int
+	NFTs    int
+	Cases   int
+}
+
+// Total возвращает общее состояние.
+func (w NetWorth) Total() int {
+	return w.Credits + w.Bank + w.Held + w.NFTs + w.Cases
+}
+
+// casePortfolioValue возвращает стоимость кейсов игрока по ценам магазина.
+func (r *Ranking) casePortfolioValue(userID string) int {
+	total := 0
+	for caseID, count := range r.Kki.GetUserCaseInventory(r, userID) {
+		if kase, ok := r.Kki.cases[caseID]; ok {
+			total += kase.Price * count
+		}
+	}
+	return total
+}
+
+// netWorth считает состояние игрока.
+func (r *Ranking) netWorth(userID string) NetWorth {
+	return NetWorth{
+		Credits: r.GetRating(userID),
+		Bank:    r.bankDeposit(userID),
+		Held:    r.HeldCredits(userID),
+		NFTs:    r.nftPortfolioValue(userID),
+		Cases:   r.casePortfolioValue(userID),
+	}
+}
+
+// netWorthRank возвращает место игрока по состоянию среди всех игроков и их число.
+func (r *Ranking) netWorthRank(userID string, total int) (int, int, error) {
+	place, players := 1, 0
+	err := r.scanKeys("user:*", func(key string) error {
+		id := strings.TrimPrefix(key, "user:")
+		players++
+		if id != userID && r.netWorth(id).Total() > total {
+			place++
+		}
+		return nil
+	})
+	return place, players, err
+}
+
+// HandleNetWorthCommand обрабатывает команду !networth [@user].
+func (r *Ranking) HandleNetWorthCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !networth: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	userID := m.Author.ID
+	if len(parts) > 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/networth [@user]`")
+		return
+	}
+	if len(parts) == 2 {
+		target := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(parts[1], "<@"), "!"), ">")
+		if !isValidUserID(target) {
+			s.ChannelMessageSend(m.ChannelID, "❌ Некорректный ID пользователя! Используй формат: `/networth @id`")
+			return
+		}
+		userID = target
+	}
+
+	worth := r.netWorth(userID)
+	total := worth.Total()
+	rankText := "—"
+	if place, players, err := r.netWorthRank(userID, total); err != nil {
+		log.Printf("Не удалось посчитать место по состоянию %s: %v", userID, err)
+	} else if players > 0 {
+		rankText = fmt.Sprintf("#%d из %d", place, players)
+	}
+
+	share := func(value int) string {
+		if total <= 0 || value <= 0 {
+			return formatCredits(value)
+		}
+		return fmt.Sprintf("%s (%d%%)", formatCredits(value), value*100/total)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "💼 Состояние",
+		Description: fmt.Sprintf("Игрок: %s\n\n**Итого: %s**", r.publicMention(userID), formatCredits(total)),
+		Color:       0xFFD700,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💰 Кредиты", Value: share(worth.Credits), Inline: true},
+			{Name: "🏦 Вклад", Value: share(worth.Bank), Inline: true},
+			{Name: "🔒 На удержании", Value: share(worth.Held), Inline: true},
+			{Name: "🖼️ NFT", Value: share(worth.NFTs), Inline: true},
+			{Name: "📦 Кейсы", Value: share(worth.Cases), Inline: true},
+			{Name: "🏆 Место", Value: rankText, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "NFT — по текущим ценам с учётом курса BTC, кейсы — по ценам магазина"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,239 @@
+package ranking
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// nftPriceTicksDays — сколько дней хранятся цены NFT с каждого обновления цен (в отличие
+// от дневной истории nft_price_history, здесь точка на каждый запуск price_updater).
+const nftPriceTicksDays = 7
+
+// Размеры графика цены.
+const (
+	nftChartWidth   = 640
+	nftChartHeight  = 240
+	nftChartPadding = 16
+)
+
+// nftPricePoint — цена NFT в момент обновления.
+type nftPricePoint struct {
+	At    time.Time
+	Price int
+}
+
+// nftPriceTicksKey возвращает ZSET с ценами NFT (score — unix-время, member — "<время>:<цена>").
+func nftPriceTicksKey(nftID string) string {
+	return "nft_price_ticks:" + nftID
+}
+
+// recordNFTPriceTicks сохраняет текущие цены всех NFT как точку временного ряда и удаляет старые точки.
+func (r *Ranking) recordNFTPriceTicks(nfts map[string]NFT) {
+	now := time.Now()
+	cutoff := strconv.FormatInt(now.AddDate(0, 0, -nftPriceTicksDays).Unix(), 10)
+	pipe := r.redis.Pipeline()
+	for id, nft := range nfts {
+		key := nftPriceTicksKey(id)
+		pipe.ZAdd(r.ctx, key, &redis.Z{Score: float64(now.Unix()), Member: fmt.Sprintf("%d:%d", now.Unix(), nft.Price)})
+		pipe.ZRemRangeByScore(r.ctx, key, "-inf", "("+cutoff)
+		pipe.Expire(r.ctx, key, (nftPriceTicksDays+1)*24*time.Hour)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось сохранить временной ряд цен NFT: %v", err)
+	}
+}
+
+// NFTPriceTicks возвращает цены NFT с момента since в порядке времени.
+func (r *Ranking) NFTPriceTicks(nftID string, since time.Time) []nftPricePoint {
+	members, err := r.redis.ZRangeByScore(r.ctx, nftPriceTicksKey(nftID), &redis.ZRangeBy{Min: strconv.FormatInt(since.Unix(), 10), Max: "+inf"}).Result()
+	if err != nil {
+		log.Printf("Не удалось получить временной ряд цен NFT %s: %v", nftID, err)
+		return nil
+	}
+	points := make([]nftPricePoint, 0, len(members))
+	for _, member := range members {
+		at, price, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		unix, errAt := strconv.ParseInt(at, 10, 64)
+		value, errPrice := strconv.Atoi(price)
+		if errAt == nil && errPrice == nil {
+			points = append(points, nftPricePoint{At: time.Unix(unix, 0), Price: value})
+		}
+	}
+	return points
+}
+
+// downsamplePrices сокращает ряд до limit значений, беря каждую n-ю точку и последнюю.
+func downsamplePrices(points []nftPricePoint, limit int) []int {
+	step := max((len(points)+limit-1)/limit, 1)
+	values := make([]int, 0, limit+1)
+	for i := 0; i < len(points); i += step {
+		values = append(values, points[i].Price)
+	}
+	if (len(points)-1)%step != 0 {
+		values = append(values, points[len(points)-1].Price)
+	}
+	return values
+}
+
+// renderPriceChart рисует PNG с линией цены на тёмном фоне с сеткой.
+func renderPriceChart(points []nftPricePoint, lineColor int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, nftChartWidth, nftChartHeight))
+	background := color.RGBA{0x2B, 0x2D, 0x31, 0xFF}
+	grid := color.RGBA{0x40, 0x43, 0x49, 0xFF}
+	line := color.RGBA{uint8(lineColor >> 16), uint8(lineColor >> 8), uint8(lineColor), 0xFF}
+	if lineColor == 0xFFFFFF || lineColor == 0 {
+		line = color.RGBA{0x58, 0x65, 0xF2, 0xFF}
+	}
+	for y := 0; y < nftChartHeight; y++ {
+		for x := 0; x < nftChartWidth; x++ {
+			img.SetRGBA(x, y, background)
+		}
+	}
+	plotW, plotH := nftChartWidth-2*nftChartPadding, nftChartHeight-2*nftChartPadding
+	for i := 0; i <= 4; i++ {
+		y := nftChartPadding + plotH*i/4
+		for x := nftChartPadding; x < nftChartWidth-nftChartPadding; x++ {
+			img.SetRGBA(x, y, grid)
+		}
+	}
+
+	lo, hi := points[0].Price, points[0].Price
+	for _, p := range points {
+		lo = min(lo, p.Price)
+		hi = max(hi, p.Price)
+	}
+	start, end := points[0].At.Unix(), points[len(points)-1].At.Unix()
+	project := func(p nftPricePoint) (int, int) {
+		x := nftChartPadding
+		if end > start {
+			x += int(int64(plotW) * (p.At.Unix() - start) / (end - start))
+		}
+		y := nftChartPadding + plotH/2
+		if hi > lo {
+			y = nftChartPadding + plotH - plotH*(p.Price-lo)/(hi-lo)
+		}
+		return x, y
+	}
+	plot := func(x, y int) {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				img.SetRGBA(x+dx, y+dy, line)
+			}
+		}
+	}
+	px, py := project(points[0])
+	plot(px, py)
+	for _, p := range points[1:] {
+		x, y := project(p)
+		// Брезенхем между соседними точками
+		dx, dy := absInt(x-px), -absInt(y-py)
+		sx, sy := 1, 1
+		if px > x {
+			sx = -1
+		}
+		if py > y {
+			sy = -1
+		}
+		e := dx + dy
+		for cx, cy := px, py; ; {
+			plot(cx, cy)
+			if cx == x && cy == y {
+				break
+			}
+			e2 := 2 * e
+			if e2 >= dy {
+				e += dy
+				cx += sx
+			}
+			if e2 <= dx {
+				e += dx
+				cy += sy
+			}
+		}
+		px, py = x, y
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HandleNFTChartCommand обрабатывает команду !nft_chart <nftID> [дней].
+func (r *Ranking) HandleNFTChartCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !nft_chart: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) < 2 || len(parts) > 3 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Используй: `/nft_chart <nftID> [дней 1-%d]`", nftPriceTicksDays))
+		return
+	}
+	nft, ok := r.Kki.nfts[parts[1]]
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ NFT не найдено. Проверьте ID.")
+		return
+	}
+	days := nftPriceTicksDays
+	if len(parts) == 3 {
+		n, err := strconv.Atoi(parts[2])
+		if err != nil || n < 1 || n > nftPriceTicksDays {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Период — от 1 до %d дней.", nftPriceTicksDays))
+			return
+		}
+		days = n
+	}
+	points := r.NFTPriceTicks(nft.ID, time.Now().AddDate(0, 0, -days))
+	if len(points) < 2 {
+		s.ChannelMessageSend(m.ChannelID, "📉 Пока мало данных для графика — цены записываются при каждом обновлении, загляни позже.")
+		return
+	}
+
+	values := make([]int, len(points))
+	for i, p := range points {
+		values[i] = p.Price
+	}
+	first, last := values[0], values[len(values)-1]
+	change := 0.0
+	if first > 0 {
+		change = float64(last-first) / float64(first) * 100
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("📈 %s **%s** — цена за %d дн.", RarityEmojis[nft.Rarity], nft.Name, days),
+		Description: fmt.Sprintf("`%s`\n\n**Сейчас**: 💰 %d (%+.1f%%)\n**Мин**: 💰 %d · **Макс**: 💰 %d\n**Точек**: %d с <t:%d:f>",
+			sparkline(downsamplePrices(points, 30)), last, change, minInt(values), maxInt(values), len(points), points[0].At.Unix()),
+		Color:  RarityColors[nft.Rarity],
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("ID: %s · цена пересчитывается по курсу BTC", nft.ID)},
+	}
+	send := &discordgo.MessageSend{Embed: embed}
+	if chart, err := renderPriceChart(points, RarityColors[nft.Rarity]); err == nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://chart.png"}
+		send.Files = []*discordgo.File{{Name: "chart.png", ContentType: "image/png", Reader: bytes.NewReader(chart)}}
+	} else {
+		log.Printf("Не удалось нарисовать график NFT %s: %v", nft.ID, err)
+	}
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, send); err != nil {
+		log.Printf("Не удалось отправить график NFT %s: %v", nft.ID, err)
+	}
+}
+
+// absInt возвращает модуль числа.
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
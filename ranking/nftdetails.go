@@ -0,0 +1,241 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// nftPriceHistoryDays — сколько дней хранится дневная история цен NFT.
+const nftPriceHistoryDays = 30
+
+// NFTSale описывает последнюю продажу NFT.
+type NFTSale struct {
+	Price int       `json:"price"` // цена за штуку
+	At    time.Time `json:"at"`
+}
+
+// nftPriceHistoryKey возвращает хэш с дневной историей цены NFT (дата -> цена).
+func nftPriceHistoryKey(nftID string) string {
+	return "nft_price_history:" + nftID
+}
+
+// nftLastSaleKey возвращает ключ с последней продажей NFT.
+func nftLastSaleKey(nftID string) string {
+	return "nft_last_sale:" + nftID
+}
+
+// recordNFTPriceHistory сохраняет текущие цены всех NFT как цену дня и удаляет устаревшие дни.
+func (r *Ranking) recordNFTPriceHistory(nfts map[string]NFT) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	expired := now.AddDate(0, 0, -nftPriceHistoryDays).Format("2006-01-02")
+
+	pipe := r.redis.Pipeline()
+	for id, nft := range nfts {
+		pipe.HSet(r.ctx, nftPriceHistoryKey(id), today, nft.Price)
+		pipe.HDel(r.ctx, nftPriceHistoryKey(id), expired)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось сохранить историю цен NFT: %v", err)
+	}
+}
+
+// NFTPriceHistory возвращает цены NFT за последние days дней (дни без данных пропускаются).
+func (r *Ranking) NFTPriceHistory(nftID string, days int) []int {
+	history, err := r.redis.HGetAll(r.ctx, nftPriceHistoryKey(nftID)).Result()
+	if err != nil {
+		log.Printf("Не удалось получить историю цен NFT %s: %v", nftID, err)
+		return nil
+	}
+	var prices []int
+	now := time.Now()
+	for d := days - 1; d >= 0; d-- {
+		value, ok := history[now.AddDate(0, 0, -d).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		if price, err := strconv.Atoi(value); err == nil {
+			prices = append(prices, price)
+		}
+	}
+	return prices
+}
+
+// sparkline рисует мини-график из значений символами ▁▂▃▄▅▆▇█.
+func sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	bars := []rune("▁▂▃▄▅▆▇█")
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = (v - lo) * (len(bars) - 1) / (hi - lo)
+		}
+		sb.WriteRune(bars[idx])
+	}
+	return sb.String()
+}
+
+// recordNFTSale запоминает цену последней продажи NFT.
+func (r *Ranking) recordNFTSale(nftID string, unitPrice int) {
+	data, _ := json.Marshal(NFTSale{Price: unitPrice, At: time.Now()})
+	if err := r.redis.Set(r.ctx, nftLastSaleKey(nftID), data, 0).Err(); err != nil {
+		log.Printf("Не удалось сохранить последнюю продажу NFT %s: %v", nftID, err)
+	}
+}
+
+// LastNFTSale возвращает последнюю продажу NFT.
+func (r *Ranking) LastNFTSale(nftID string) (NFTSale, bool) {
+	var sale NFTSale
+	data, err := r.redis.Get(r.ctx, nftLastSaleKey(nftID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось получить последнюю продажу NFT %s: %v", nftID, err)
+		}
+		return sale, false
+	}
+	if err := json.Unmarshal(data, &sale); err != nil {
+		return sale, false
+	}
+	return sale, true
+}
+
+// countNFTCopies считает, сколько копий NFT существует на сервере и у скольких игроков они есть.
+func (r *Ranking) countNFTCopies(nftID string) (int, int) {
+	copies, owners := 0, 0
+	err := r.scanKeys("inventory:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var inv UserInventory
+		if err := json.Unmarshal(data, &inv); err != nil {
+			return nil
+		}
+		if count := inv[nftID]; count > 0 {
+			copies += count
+			owners++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Не удалось посчитать копии NFT %s: %v", nftID, err)
+	}
+	return copies, owners
+}
+
+// nftDetailFields формирует поля подробной карточки NFT: график цены, тираж и последняя продажа.
+func (r *Ranking) nftDetailFields(nft NFT) []*discordgo.MessageEmbedField {
+	chart := "нет данных"
+	if prices := r.NFTPriceHistory(nft.ID, 7); len(prices) > 0 {
+		first, last := prices[0], prices[len(prices)-1]
+		change := 0.0
+		if first > 0 {
+			change = float64(last-first) / float64(first) * 100
+		}
+		chart = fmt.Sprintf("`%s` %+.1f%%\nмин %d · макс %d", sparkline(prices), change, minInt(prices), maxInt(prices))
+	}
+
+	copies, owners := r.countNFTCopies(nft.ID)
+
+	lastSale := "ещё не продавалась"
+	if sale, ok := r.LastNFTSale(nft.ID); ok {
+		lastSale = fmt.Sprintf("💰 %d <t:%d:R>", sale.Price, sale.At.Unix())
+	}
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "📈 Цена за 7 дней", Value: chart, Inline: true},
+		{Name: "🧮 Тираж на сервере", Value: fmt.Sprintf("%d шт. у %d игроков", copies, owners), Inline: true},
+		{Name: "🏷️ Последняя продажа", Value: lastSale, Inline: true},
+	}
+}
+
+// nftDetailComponents возвращает кнопки быстрых действий с NFT.
+func nftDetailComponents(nftID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Продать 1 шт. 💰", Style: discordgo.SuccessButton, CustomID: "nft_sell_" + nftID},
+			},
+		},
+	}
+}
+
+// minInt возвращает минимальное значение среза.
+func minInt(values []int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		result = min(result, v)
+	}
+	return result
+}
+
+// maxInt возвращает максимальное значение среза.
+func maxInt(values []int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		result = max(result, v)
+	}
+	return result
+}
+
+// HandleNFTSellButton обрабатывает кнопку «Продать» в карточке NFT: показывает подтверждение продажи одной копии.
+func (r *Ranking) HandleNFTSellButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	nftID := strings.TrimPrefix(i.MessageComponentData().CustomID, "nft_sell_")
+	userID := i.Member.User.ID
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	nft, ok := r.Kki.nfts[nftID]
+	if !ok {
+		ephemeral("❌ **NFT не найдено.**")
+		return
+	}
+	if r.GetUserInventory(userID)[nftID] < 1 {
+		ephemeral("❌ **У тебя нет этой NFT.**")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🃏 **Подтверждение продажи** ══════",
+		Description: fmt.Sprintf("Вы хотите продать 1 x %s **%s** (ID для передачи и продажи: %s) за %s?", RarityEmojis[nft.Rarity], nft.Name, nftID, formatCredits(nft.Price)),
+		Color:       RarityColors[nft.Rarity],
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", i.Member.User.Username)},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "✅ Подтвердить", Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("sell_confirm_%s_%s_%d_%d", userID, nftID, 1, nft.Price)},
+				discordgo.Button{Label: "❌ Отменить", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("sell_cancel_%s", userID)},
+			},
+		},
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Не удалось показать подтверждение продажи NFT %s: %v", nftID, err)
+	}
+}
@@ -0,0 +1,271 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Журнал изменений инвентарей NFT. Каждая выдача, удаление, передача и открытие кейса получает
+// номер, по которому админ может откатить операцию командой /a_recall.
+const (
+	nftLedgerSeqKey = "nft_ledger:seq"
+	nftLedgerTTL    = 30 * 24 * time.Hour
+	nftLedgerIndex  = 50 // сколько последних записей хранится в индексе пользователя
+)
+
+// nftLedgerPaidKinds — операции, за которые NFT были оплачены. Откат вернул бы только NFT,
+// а оплата осталась бы у другой стороны, поэтому такие записи /a_recall не откатывает.
+var nftLedgerPaidKinds = map[string]bool{
+	"market":      true,
+	"player_shop": true,
+	"offer":       true,
+	"jade_shop":   true,
+	"nft_sale":    true,
+}
+
+// NFTLedgerEntry — запись журнала: NFT из Items перешли от From к To.
+// Пустой From означает, что NFT появились (выдача, кейс), пустой To — что NFT изъяты.
+type NFTLedgerEntry struct {
+	ID         int64          `json:"id"`
+	Kind       string         `json:"kind"`
+	Actor      string         `json:"actor"`
+	From       string         `json:"from,omitempty"`
+	To         string         `json:"to,omitempty"`
+	Items      map[string]int `json:"items"`
+	At         time.Time      `json:"at"`
+	RecalledBy string         `json:"recalled_by,omitempty"`
+	RecalledAt time.Time      `json:"recalled_at,omitempty"`
+}
+
+// nftLedgerKey возвращает ключ записи журнала.
+func nftLedgerKey(id int64) string {
+	return fmt.Sprintf("nft_ledger:%d", id)
+}
+
+// nftLedgerUserKey возвращает ключ индекса записей журнала пользователя.
+func nftLedgerUserKey(userID string) string {
+	return "nft_ledger:user:" + userID
+}
+
+// recordNFTMutation записывает изменение инвентарей в журнал и возвращает номер записи (0 при ошибке).
+func (r *Ranking) recordNFTMutation(kind, actor, from, to string, items map[string]int) int64 {
+	id, err := r.redis.Incr(r.ctx, nftLedgerSeqKey).Result()
+	if err != nil {
+		log.Printf("Не удалось получить номер записи журнала NFT: %v", err)
+		return 0
+	}
+	entry := NFTLedgerEntry{ID: id, Kind: kind, Actor: actor, From: from, To: to, Items: items, At: time.Now()}
+	data, _ := json.Marshal(entry)
+
+	pipe := r.redis.TxPipeline()
+	pipe.Set(r.ctx, nftLedgerKey(id), data, nftLedgerTTL)
+	for _, userID := range []string{from, to} {
+		if userID == "" {
+			continue
+		}
+		pipe.LPush(r.ctx, nftLedgerUserKey(userID), id)
+		pipe.LTrim(r.ctx, nftLedgerUserKey(userID), 0, nftLedgerIndex-1)
+		pipe.Expire(r.ctx, nftLedgerUserKey(userID), nftLedgerTTL)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось записать операцию %s в журнал NFT: %v", kind, err)
+		return 0
+	}
+	return id
+}
+
+// nftLedgerEntry читает запись журнала по номеру.
+func (r *Ranking) nftLedgerEntry(id int64) (NFTLedgerEntry, error) {
+	var entry NFTLedgerEntry
+	data, err := r.redis.Get(r.ctx, nftLedgerKey(id)).Bytes()
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// ledgerRef возвращает пометку с номером записи журнала для сообщений.
+func ledgerRef(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (журнал #%d)", id)
+}
+
+// describeNFTItems форматирует список NFT записи журнала.
+func (r *Ranking) describeNFTItems(items map[string]int) string {
+	ids := make([]string, 0, len(items))
+	for nftID := range items {
+		ids = append(ids, nftID)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, nftID := range ids {
+		name := nftID
+		if nft, ok := r.Kki.nfts[nftID]; ok {
+			name = fmt.Sprintf("%s %s (%s)", RarityEmojis[nft.Rarity], nft.Name, nftID)
+		}
+		parts = append(parts, fmt.Sprintf("%s x%d", name, items[nftID]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recallNFTMutation откатывает запись журнала: возвращает NFT от To к From одной транзакцией Redis.
+func (r *Ranking) recallNFTMutation(id int64, adminID string) (NFTLedgerEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, err := r.nftLedgerEntry(id)
+	if err == redis.Nil {
+		return entry, fmt.Errorf("запись #%d не найдена (хранится %d дней)", id, int(nftLedgerTTL.Hours()/24))
+	}
+	if err != nil {
+		return entry, fmt.Errorf("ошибка чтения записи #%d: %v", id, err)
+	}
+	if entry.RecalledBy != "" {
+		return entry, fmt.Errorf("запись #%d уже откачена <@%s>", id, entry.RecalledBy)
+	}
+	if nftLedgerPaidKinds[entry.Kind] {
+		return entry, fmt.Errorf("запись #%d — оплаченная сделка (%s): откат вернул бы NFT без оплаты, исправь её вручную", id, entry.Kind)
+	}
+
+	var toInv, fromInv UserInventory
+	if entry.To != "" {
+		toInv = r.GetUserInventory(entry.To)
+		var missing []string
+		for nftID, count := range entry.Items {
+			if toInv[nftID] < count {
+				missing = append(missing, fmt.Sprintf("%s (есть %d из %d)", nftID, toInv[nftID], count))
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return entry, fmt.Errorf("у <@%s> уже нет всех NFT: %s", entry.To, strings.Join(missing, ", "))
+		}
+		for nftID, count := range entry.Items {
+			toInv[nftID] -= count
+			if toInv[nftID] == 0 {
+				delete(toInv, nftID)
+			}
+		}
+	}
+	if entry.From != "" {
+		fromInv = r.GetUserInventory(entry.From)
+		for nftID, count := range entry.Items {
+			fromInv[nftID] += count
+		}
+	}
+
+	entry.RecalledBy = adminID
+	entry.RecalledAt = time.Now()
+	data, _ := json.Marshal(entry)
+
+	pipe := r.redis.TxPipeline()
+	if toInv != nil {
+		toData, _ := json.Marshal(toInv)
+		pipe.Set(r.ctx, "inventory:"+entry.To, toData, 0)
+		pipe.ZAdd(r.ctx, topCollectorsKey, &redis.Z{Score: float64(len(toInv)), Member: entry.To})
+	}
+	if fromInv != nil {
+		fromData, _ := json.Marshal(fromInv)
+		pipe.Set(r.ctx, "inventory:"+entry.From, fromData, 0)
+		pipe.ZAdd(r.ctx, topCollectorsKey, &redis.Z{Score: float64(len(fromInv)), Member: entry.From})
+	}
+	pipe.Set(r.ctx, nftLedgerKey(id), data, redis.KeepTTL)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return entry, fmt.Errorf("ошибка записи в Redis: %v", err)
+	}
+	return entry, nil
+}
+
+// HandleRecallCommand обрабатывает команду !a_recall <номер> | @user.
+func (r *Ranking) HandleRecallCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_recall: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут откатывать операции! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_recall <номер>` или `/a_recall @user` — последние операции игрока")
+		return
+	}
+
+	if len(m.Mentions) == 1 {
+		r.sendNFTLedger(s, m.ChannelID, m.Mentions[0].ID)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(parts[1], "#"), 10, 64)
+	if err != nil || id <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Номер записи должен быть положительным числом!")
+		return
+	}
+
+	entry, err := r.recallNFTMutation(id, m.Author.ID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Откат невозможен: "+err.Error())
+		return
+	}
+
+	text := fmt.Sprintf("↩️ <@%s> откатил операцию #%d (%s): %s", m.Author.ID, id, entry.Kind, r.describeNFTItems(entry.Items))
+	if entry.To != "" {
+		text += fmt.Sprintf("\nИзъято у <@%s>", entry.To)
+	}
+	if entry.From != "" {
+		text += fmt.Sprintf("\nВозвращено <@%s>", entry.From)
+	}
+	log.Printf("Операция журнала NFT #%d откачена админом %s", id, m.Author.ID)
+	r.LogCreditOperation(s, text)
+	s.ChannelMessageSend(m.ChannelID, "✅ "+text)
+}
+
+// sendNFTLedger отправляет последние записи журнала NFT пользователя.
+func (r *Ranking) sendNFTLedger(s *discordgo.Session, channelID, userID string) {
+	ids, err := r.redis.LRange(r.ctx, nftLedgerUserKey(userID), 0, 14).Result()
+	if err != nil || len(ids) == 0 {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("ℹ️ В журнале NFT нет операций <@%s>.", userID))
+		return
+	}
+
+	var lines []string
+	for _, raw := range ids {
+		id, _ := strconv.ParseInt(raw, 10, 64)
+		entry, err := r.nftLedgerEntry(id)
+		if err != nil {
+			continue
+		}
+		direction := "➕"
+		if entry.From == userID {
+			direction = "➖"
+		}
+		line := fmt.Sprintf("`#%d` %s %s <t:%d:R>: %s", entry.ID, direction, entry.Kind, entry.At.Unix(), r.describeNFTItems(entry.Items))
+		if entry.RecalledBy != "" {
+			line = "~~" + line + "~~ ↩️"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("ℹ️ Записи журнала NFT <@%s> устарели.", userID))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📒 Журнал NFT",
+		Description: truncate(fmt.Sprintf("Игрок: <@%s>\n\n%s", userID, strings.Join(lines, "\n")), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "/a_recall <номер> — откатить операцию"},
+	}
+	s.ChannelMessageSendEmbed(channelID, embed)
+}
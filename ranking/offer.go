@@ -0,0 +1,524 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Предложения обмена: две стороны собирают сделку из NFT, кейсов и кредитов командами /offer add,
+// затем обе подтверждают её кнопкой. Ресурсы удерживаются в эскроу только в момент исполнения,
+// поэтому обмен проходит целиком или не проходит вовсе.
+const offerTTL = 15 * time.Minute
+
+// OfferSide — вклад одной стороны в обмен.
+type OfferSide struct {
+	Credits int            `json:"credits,omitempty"`
+	Cases   map[string]int `json:"cases,omitempty"`
+	NFTs    map[string]int `json:"nfts,omitempty"`
+}
+
+// empty проверяет, что сторона ничего не кладёт в обмен.
+func (side OfferSide) empty() bool {
+	return side.Credits == 0 && len(side.Cases) == 0 && len(side.NFTs) == 0
+}
+
+// Offer — обмен между инициатором и второй стороной.
+type Offer struct {
+	ID           string                `json:"id"`
+	Initiator    string                `json:"initiator"`
+	Counterparty string                `json:"counterparty"`
+	ChannelID    string                `json:"channel_id"`
+	MessageID    string                `json:"message_id"`
+	Sides        map[string]*OfferSide `json:"sides"`
+	Confirmed    map[string]bool       `json:"confirmed,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	Tax          int                   `json:"tax,omitempty"` // налог на переводы с кредитов, удержанный при исполнении
+}
+
+// offerKey возвращает ключ обмена.
+func offerKey(id string) string {
+	return "offer:" + id
+}
+
+// offerUserKey возвращает ключ с ID открытого обмена пользователя.
+func offerUserKey(userID string) string {
+	return "offer:user:" + userID
+}
+
+// loadOffer читает обмен по ID.
+func (r *Ranking) loadOffer(id string) (*Offer, error) {
+	data, err := r.redis.Get(r.ctx, offerKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errEscrowClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	var offer Offer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, err
+	}
+	if offer.Confirmed == nil {
+		offer.Confirmed = map[string]bool{}
+	}
+	return &offer, nil
+}
+
+// userOffer возвращает открытый обмен пользователя.
+func (r *Ranking) userOffer(userID string) (*Offer, error) {
+	id, err := r.redis.Get(r.ctx, offerUserKey(userID)).Result()
+	if err == redis.Nil {
+		return nil, errEscrowClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.loadOffer(id)
+}
+
+// saveOffer сохраняет обмен, не продлевая его срок.
+func (r *Ranking) saveOffer(offer *Offer) error {
+	data, _ := json.Marshal(offer)
+	return r.redis.Set(r.ctx, offerKey(offer.ID), data, redis.KeepTTL).Err()
+}
+
+// storeOffer сохраняет обмен и ссылки на него у обеих сторон на срок ttl.
+func (r *Ranking) storeOffer(offer *Offer, ttl time.Duration) error {
+	data, _ := json.Marshal(offer)
+	pipe := r.redis.TxPipeline()
+	pipe.Set(r.ctx, offerKey(offer.ID), data, ttl)
+	pipe.Set(r.ctx, offerUserKey(offer.Initiator), offer.ID, ttl)
+	pipe.Set(r.ctx, offerUserKey(offer.Counterparty), offer.ID, ttl)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// takeOffer атомарно забирает обмен: исполнить или отменить его можно только один раз.
+func (r *Ranking) takeOffer(id string) (*Offer, error) {
+	data, err := r.redis.GetDel(r.ctx, offerKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, errEscrowClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	var offer Offer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, err
+	}
+	if offer.Confirmed == nil {
+		offer.Confirmed = map[string]bool{}
+	}
+	r.redis.Del(r.ctx, offerUserKey(offer.Initiator), offerUserKey(offer.Counterparty))
+	return &offer, nil
+}
+
+// describeOfferSide форматирует вклад стороны.
+func (r *Ranking) describeOfferSide(side *OfferSide) string {
+	if side == nil || side.empty() {
+		return "—"
+	}
+	var lines []string
+	if side.Credits > 0 {
+		lines = append(lines, "💰 "+formatCredits(side.Credits))
+	}
+	caseIDs := make([]string, 0, len(side.Cases))
+	for caseID := range side.Cases {
+		caseIDs = append(caseIDs, caseID)
+	}
+	sort.Strings(caseIDs)
+	for _, caseID := range caseIDs {
+		name := caseID
+		if kase, ok := r.Kki.cases[caseID]; ok {
+			name = fmt.Sprintf("%s (%s)", kase.Name, caseID)
+		}
+		lines = append(lines, fmt.Sprintf("📦 %s x%d", name, side.Cases[caseID]))
+	}
+	if len(side.NFTs) > 0 {
+		lines = append(lines, "🖼️ "+r.describeNFTItems(side.NFTs))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// offerMessage формирует эмбед обмена и кнопки подтверждения.
+func (r *Ranking) offerMessage(offer *Offer, status string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	field := func(userID string) *discordgo.MessageEmbedField {
+		name := r.displayName(userID)
+		if offer.Confirmed[userID] {
+			name += " ✅"
+		}
+		return &discordgo.MessageEmbedField{Name: name, Value: truncate(r.describeOfferSide(offer.Sides[userID]), 1024), Inline: true}
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔄 Обмен",
+		Description: fmt.Sprintf("<@%s> ⇄ <@%s>\n%s", offer.Initiator, offer.Counterparty, status),
+		Color:       0xFFD700,
+		Fields:      []*discordgo.MessageEmbedField{field(offer.Initiator), field(offer.Counterparty)},
+		Footer:      &discordgo.MessageEmbedFooter{Text: "/offer add nft|case <ID> <кол-во> · /offer add credits <сумма> · /offer remove nft|case|credits [ID]"},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Подтвердить ✅", Style: discordgo.SuccessButton, CustomID: "offer_confirm_" + offer.ID},
+			discordgo.Button{Label: "Отменить ✖️", Style: discordgo.DangerButton, CustomID: "offer_cancel_" + offer.ID},
+		}},
+	}
+	return embed, components
+}
+
+// refreshOfferMessage перерисовывает сообщение обмена.
+func (r *Ranking) refreshOfferMessage(s *discordgo.Session, offer *Offer, status string) {
+	embed, components := r.offerMessage(offer, status)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    offer.ChannelID,
+		ID:         offer.MessageID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить сообщение обмена %s: %v", offer.ID, err)
+	}
+}
+
+// closeOfferMessage заменяет сообщение обмена итогом без кнопок.
+func (r *Ranking) closeOfferMessage(offer *Offer, title, status string, color int) *discordgo.MessageEmbed {
+	embed, _ := r.offerMessage(offer, status)
+	embed.Title = title
+	embed.Color = color
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "🔒 Обмен через эскроу"}
+	return embed
+}
+
+// HandleOfferCommand обрабатывает команду !offer @user | add | remove | cancel.
+func (r *Ranking) HandleOfferCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !offer: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/offer @user` — начать обмен, `/offer add nft|case <ID> <кол-во>`, `/offer add credits <сумма>`, `/offer remove nft|case <ID>`, `/offer remove credits`, `/offer cancel`"
+	parts := strings.Fields(command)
+	if len(parts) < 2 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	switch parts[1] {
+	case "add", "remove":
+		r.editOffer(s, m, parts)
+	case "cancel":
+		offer, err := r.userOffer(m.Author.ID)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ У тебя нет открытого обмена.")
+			return
+		}
+		if offer, err = r.takeOffer(offer.ID); err != nil {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ У тебя нет открытого обмена.")
+			return
+		}
+		embed := r.closeOfferMessage(offer, "✖️ Обмен отменён", fmt.Sprintf("<@%s> отменил обмен.", m.Author.ID), 0xFF0000)
+		s.ChannelMessageEditComplex(&discordgo.MessageEdit{Channel: offer.ChannelID, ID: offer.MessageID, Embed: embed, Components: &[]discordgo.MessageComponent{}})
+		s.ChannelMessageSend(m.ChannelID, "✅ Обмен отменён.")
+	default:
+		if len(m.Mentions) != 1 || len(parts) != 2 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		r.openOffer(s, m, m.Mentions[0])
+	}
+}
+
+// openOffer создаёт пустой обмен с пользователем.
+func (r *Ranking) openOffer(s *discordgo.Session, m *discordgo.MessageCreate, target *discordgo.User) {
+	if target.ID == m.Author.ID || target.Bot {
+		s.ChannelMessageSend(m.ChannelID, "❌ Обмен возможен только с другим игроком!")
+		return
+	}
+	for _, userID := range []string{m.Author.ID, target.ID} {
+		if exists, _ := r.redis.Exists(r.ctx, offerUserKey(userID)).Result(); exists > 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ У <@%s> уже есть открытый обмен. Отменить: `/offer cancel`", userID))
+			return
+		}
+	}
+
+	offer := &Offer{
+		ID:           generatePollID(),
+		Initiator:    m.Author.ID,
+		Counterparty: target.ID,
+		ChannelID:    m.ChannelID,
+		Sides:        map[string]*OfferSide{m.Author.ID: {}, target.ID: {}},
+		Confirmed:    map[string]bool{},
+		CreatedAt:    time.Now(),
+	}
+	embed, components := r.offerMessage(offer, fmt.Sprintf("Добавляйте ресурсы командами `/offer add`. Обмен закроется <t:%d:R>.", offer.CreatedAt.Add(offerTTL).Unix()))
+	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content:    "<@" + target.ID + ">",
+		Embed:      embed,
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("Не удалось отправить обмен %s: %v", offer.ID, err)
+		return
+	}
+	offer.MessageID = msg.ID
+	if err := r.storeOffer(offer, offerTTL); err != nil {
+		log.Printf("Не удалось сохранить обмен %s: %v", offer.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка создания обмена! Проверьте Redis-сервер.")
+	}
+}
+
+// editOffer добавляет или убирает ресурсы стороны. Любое изменение сбрасывает подтверждения.
+func (r *Ranking) editOffer(s *discordgo.Session, m *discordgo.MessageCreate, parts []string) {
+	usage := "❌ Используй: `/offer add nft|case <ID> <кол-во>`, `/offer add credits <сумма>`, `/offer remove nft|case <ID>` или `/offer remove credits`"
+	if len(parts) < 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	add := parts[1] == "add"
+	kind := parts[2]
+
+	r.mu.Lock()
+	offer, err := r.userOffer(m.Author.ID)
+	if err != nil {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "❌ У тебя нет открытого обмена. Начни: `/offer @user`")
+		return
+	}
+	side := offer.Sides[m.Author.ID]
+	if side == nil {
+		side = &OfferSide{}
+		offer.Sides[m.Author.ID] = side
+	}
+
+	var problem string
+	switch {
+	case kind == "credits" && add && len(parts) == 4:
+		amount, err := strconv.Atoi(parts[3])
+		switch {
+		case err != nil || amount <= 0:
+			problem = "❌ Сумма должна быть положительным числом! 💸"
+		case r.GetRating(m.Author.ID) < amount:
+			problem = fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(r.GetRating(m.Author.ID)))
+		default:
+			side.Credits = amount
+		}
+	case kind == "credits" && !add && len(parts) == 3:
+		side.Credits = 0
+	case (kind == "nft" || kind == "case") && add && len(parts) == 5:
+		id := parts[3]
+		count, err := strconv.Atoi(parts[4])
+		if err != nil || count <= 0 {
+			problem = "❌ Количество должно быть положительным числом!"
+			break
+		}
+		if kind == "nft" {
+			if _, ok := r.Kki.nfts[id]; !ok {
+				problem = "❌ NFT не найдено. Проверьте ID."
+			} else if have := r.GetUserInventory(m.Author.ID)[id]; have < count {
+				problem = fmt.Sprintf("❌ У тебя только %d шт. этой NFT!", have)
+			} else {
+				if side.NFTs == nil {
+					side.NFTs = make(map[string]int)
+				}
+				side.NFTs[id] = count
+			}
+		} else {
+			if id == "daily" {
+				id = "daily_case"
+			}
+			if _, ok := r.Kki.cases[id]; !ok {
+				problem = "❌ Кейс не найден. Проверьте ID."
+			} else if have := r.Kki.GetUserCaseInventory(r, m.Author.ID)[id]; have < count {
+				problem = fmt.Sprintf("❌ У тебя только %d шт. этого кейса!", have)
+			} else {
+				if side.Cases == nil {
+					side.Cases = make(map[string]int)
+				}
+				side.Cases[id] = count
+			}
+		}
+	case kind == "nft" && !add && len(parts) == 4:
+		delete(side.NFTs, parts[3])
+	case kind == "case" && !add && len(parts) == 4:
+		delete(side.Cases, parts[3])
+	default:
+		problem = usage
+	}
+	if problem != "" {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, problem)
+		return
+	}
+	offer.Confirmed = map[string]bool{}
+	if err := r.saveOffer(offer); err != nil {
+		r.mu.Unlock()
+		log.Printf("Не удалось сохранить обмен %s: %v", offer.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка обмена! Проверьте Redis-сервер.")
+		return
+	}
+	r.mu.Unlock()
+
+	r.refreshOfferMessage(s, offer, fmt.Sprintf("<@%s> изменил свою часть — подтверждения сброшены.", m.Author.ID))
+	s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
+	if kind == "nft" && add {
+		go r.notifyWishlist(s, parts[3], fmt.Sprintf("предложена в обмене <@%s> ↔ <@%s>", offer.Initiator, offer.Counterparty), m.ChannelID, offer.Initiator, offer.Counterparty)
+	}
+}
+
+// HandleOfferButton обрабатывает кнопки подтверждения и отмены обмена.
+func (r *Ranking) HandleOfferButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	userID := i.Member.User.ID
+	confirm := strings.HasPrefix(customID, "offer_confirm_")
+	id := strings.TrimPrefix(strings.TrimPrefix(customID, "offer_confirm_"), "offer_cancel_")
+	log.Printf("Обработка кнопки обмена %s от %s", customID, userID)
+
+	offer, waiting, errText := r.markOfferConfirmed(id, userID, confirm)
+	if errText != "" {
+		respondEphemeral(s, i, errText)
+		return
+	}
+	if waiting {
+		embed, components := r.offerMessage(offer, fmt.Sprintf("<@%s> подтвердил обмен. Ждём вторую сторону.", userID))
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components},
+		})
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch {
+	case !confirm:
+		log.Printf("Обмен %s отменён %s", id, userID)
+		embed = r.closeOfferMessage(offer, "✖️ Обмен отменён", fmt.Sprintf("<@%s> отменил обмен.", userID), 0xFF0000)
+	default:
+		if err := r.executeOffer(offer); err != nil {
+			log.Printf("Обмен %s не состоялся: %v", id, err)
+			embed = r.closeOfferMessage(offer, "❌ Обмен не состоялся", fmt.Sprintf("Причина: %s. Ресурсы остались у владельцев.", err), 0xFF0000)
+			break
+		}
+		log.Printf("Обмен %s завершён", id)
+		r.LogCreditOperation(s, fmt.Sprintf("🔄 Обмен <@%s> ⇄ <@%s>: %s ⇄ %s", offer.Initiator, offer.Counterparty,
+			strings.ReplaceAll(r.describeOfferSide(offer.Sides[offer.Initiator]), "\n", ", "),
+			strings.ReplaceAll(r.describeOfferSide(offer.Sides[offer.Counterparty]), "\n", ", ")))
+		status := "Стороны обменялись ресурсами. Славь Императора! 👑"
+		if offer.Tax > 0 {
+			status += fmt.Sprintf("\nНалог на переводы: %s (%s)", formatCredits(offer.Tax), r.transferTaxDestination())
+		}
+		embed = r.closeOfferMessage(offer, "✅ Обмен завершён", status, 0x00FF00)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: []discordgo.MessageComponent{}},
+	})
+}
+
+// markOfferConfirmed отмечает подтверждение стороны. waiting — вторая сторона ещё не подтвердила;
+// иначе (подтвердили обе или обмен отменён) обмен забирается и возвращается для исполнения.
+// При отказе возвращает текст для игрока.
+func (r *Ranking) markOfferConfirmed(id, userID string, confirm bool) (offer *Offer, waiting bool, errText string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	offer, err := r.loadOffer(id)
+	if err != nil {
+		return nil, false, "❌ Обмен уже завершён или истёк!"
+	}
+	if userID != offer.Initiator && userID != offer.Counterparty {
+		return nil, false, "❌ Это не твой обмен!"
+	}
+	if !confirm {
+		if offer, err = r.takeOffer(id); err != nil {
+			return nil, false, "❌ Обмен уже завершён или истёк!"
+		}
+		return offer, false, ""
+	}
+	if offer.Sides[offer.Initiator].empty() && offer.Sides[offer.Counterparty].empty() {
+		return nil, false, "❌ Обмен пуст — добавьте что-нибудь командой `/offer add`."
+	}
+	offer.Confirmed[userID] = true
+	if !offer.Confirmed[offer.Initiator] || !offer.Confirmed[offer.Counterparty] {
+		if err := r.saveOffer(offer); err != nil {
+			return nil, false, "❌ Ошибка обмена! Попробуй ещё раз."
+		}
+		return offer, true, ""
+	}
+	return r.takeConfirmedOffer(id, userID)
+}
+
+// takeConfirmedOffer забирает обмен для исполнения и ещё раз проверяет подтверждения обеих сторон
+// на забранной версии: любая правка состава сбрасывает подтверждения, и такой обмен не исполняется,
+// а возвращается на подтверждение. Вызывается под r.mu.
+func (r *Ranking) takeConfirmedOffer(id, userID string) (*Offer, bool, string) {
+	offer, err := r.takeOffer(id)
+	if err != nil {
+		return nil, false, "❌ Обмен уже завершён или истёк!"
+	}
+	offer.Confirmed[userID] = true
+	if offer.Confirmed[offer.Initiator] && offer.Confirmed[offer.Counterparty] {
+		return offer, false, ""
+	}
+	if ttl := time.Until(offer.CreatedAt.Add(offerTTL)); ttl > 0 {
+		if err := r.storeOffer(offer, ttl); err != nil {
+			log.Printf("Не удалось вернуть обмен %s: %v", offer.ID, err)
+		}
+	}
+	return nil, false, "❌ Состав обмена изменился — проверь его и подтверди заново."
+}
+
+// executeOffer исполняет обмен через эскроу: удерживает ресурсы обеих сторон и передаёт их крест-накрест.
+// Если у какой-то стороны ресурсов уже не хватает, всё удержанное возвращается владельцам.
+func (r *Ranking) executeOffer(offer *Offer) error {
+	hold := func(owner, role string) EscrowHold {
+		side := offer.Sides[owner]
+		if side == nil {
+			side = &OfferSide{}
+		}
+		return EscrowHold{ID: "offer:" + offer.ID + ":" + role, Owner: owner, Reason: "offer", Source: "offer", Credits: side.Credits, Cases: side.Cases, NFTs: side.NFTs}
+	}
+	trade := &EscrowTrade{
+		ID:           offer.ID,
+		Kind:         "offer",
+		Initiator:    hold(offer.Initiator, "initiator"),
+		Counterparty: hold(offer.Counterparty, "counterparty"),
+		Taxed:        true,
+	}
+	// Кредиты в обмене — такой же перевод: они идут в дневной лимит и паузу переводов и облагаются
+	// налогом. Согласие получателя (как при крупном переводе) даёт его подтверждение обмена.
+	var reserved []EscrowHold
+	releaseLimits := func() {
+		for _, leg := range reserved {
+			r.releaseTransferLimits(leg.Owner, leg.Credits)
+		}
+	}
+	for _, leg := range []EscrowHold{trade.Initiator, trade.Counterparty} {
+		if leg.Credits <= 0 {
+			continue
+		}
+		if err := r.reserveTransferLimits(leg.Owner, leg.Credits); err != nil {
+			releaseLimits()
+			return fmt.Errorf("у <@%s>: %v", leg.Owner, err)
+		}
+		reserved = append(reserved, leg)
+	}
+	if err := r.escrowReserve(trade.Initiator, time.Minute); err != nil {
+		releaseLimits()
+		return fmt.Errorf("у <@%s>: %v", offer.Initiator, err)
+	}
+	if err := r.escrowSettleTrade(trade); err != nil {
+		releaseLimits()
+		return err
+	}
+	offer.Tax = trade.Tax
+	if len(trade.Initiator.NFTs) > 0 {
+		r.recordNFTMutation("offer", offer.Initiator, offer.Initiator, offer.Counterparty, trade.Initiator.NFTs)
+	}
+	if len(trade.Counterparty.NFTs) > 0 {
+		r.recordNFTMutation("offer", offer.Counterparty, offer.Counterparty, offer.Initiator, trade.Counterparty.NFTs)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// OverlayEvent — событие игры для оверлея стрима.
+type OverlayEvent struct {
+	Type        string    `json:"type"`   // blackjack, rb, duel
+	Status      string    `json:"status"` // playing, won, lost, push, surrender
+	GameID      string    `json:"game_id,omitempty"`
+	PlayerID    string    `json:"player_id,omitempty"`
+	Player      string    `json:"player,omitempty"`
+	Bet         int       `json:"bet"`
+	PlayerCards string    `json:"player_cards,omitempty"`
+	PlayerSum   int       `json:"player_sum,omitempty"`
+	DealerCards string    `json:"dealer_cards,omitempty"`
+	DealerSum   int       `json:"dealer_sum,omitempty"`
+	Choice      string    `json:"choice,omitempty"`
+	Result      string    `json:"result,omitempty"`
+	WinnerID    string    `json:"winner_id,omitempty"`
+	Winner      string    `json:"winner,omitempty"`
+	LoserID     string    `json:"loser_id,omitempty"`
+	Loser       string    `json:"loser,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// OverlayHub рассылает события игр подписчикам SSE (/overlay/events).
+type OverlayHub struct {
+	token  string
+	events chan OverlayEvent
+
+	mu     sync.Mutex
+	subs   map[chan []byte]bool
+	hands  map[string]OverlayEvent // активные раздачи блэкджека: gameID -> последнее состояние
+	recent [][]byte
+}
+
+// overlayRecentLimit — сколько последних событий получает новый подписчик.
+const overlayRecentLimit = 10
+
+// NewOverlayHub создаёт хаб оверлея. OVERLAY_TOKEN, если задан, требуется в параметре ?token=.
+func NewOverlayHub() *OverlayHub {
+	return &OverlayHub{
+		token:  os.Getenv("OVERLAY_TOKEN"),
+		events: make(chan OverlayEvent, 256),
+		subs:   make(map[chan []byte]bool),
+		hands:  make(map[string]OverlayEvent),
+	}
+}
+
+// Publish ставит событие в очередь рассылки. Не блокирует: вызывается в том числе под r.mu.
+func (h *OverlayHub) Publish(event OverlayEvent) {
+	if h == nil {
+		return
+	}
+	event.At = time.Now()
+	select {
+	case h.events <- event:
+	default:
+		log.Printf("Очередь оверлея переполнена, событие %s отброшено", event.Type)
+	}
+}
+
+// run дополняет события именами игроков и рассылает их подписчикам.
+func (h *OverlayHub) run(r *Ranking) {
+	for event := range h.events {
+		event.Player = r.publicName(event.PlayerID)
+		event.Winner = r.publicName(event.WinnerID)
+		event.Loser = r.publicName(event.LoserID)
+		// ID анонимных игроков в поток не попадают
+		for _, id := range []*string{&event.PlayerID, &event.WinnerID, &event.LoserID} {
+			if *id != "" && r.isAnonymous(*id) {
+				*id = ""
+			}
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+
+		h.mu.Lock()
+		if event.Type == "blackjack" {
+			if event.Status == "playing" {
+				h.hands[event.GameID] = event
+			} else {
+				delete(h.hands, event.GameID)
+			}
+		}
+		h.recent = append(h.recent, data)
+		if len(h.recent) > overlayRecentLimit {
+			h.recent = h.recent[len(h.recent)-overlayRecentLimit:]
+		}
+		for sub := range h.subs {
+			select {
+			case sub <- data:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Register подключает страницу оверлея и поток событий к HTTP-серверу бота и запускает рассылку.
+func (h *OverlayHub) Register(web *WebServer, r *Ranking) {
+	go h.run(r)
+	web.Handle("/overlay/events", http.HandlerFunc(h.serveEvents))
+	web.Handle("/overlay", http.HandlerFunc(h.servePage))
+}
+
+// authorized проверяет токен оверлея.
+func (h *OverlayHub) authorized(req *http.Request) bool {
+	return h.token == "" || req.URL.Query().Get("token") == h.token
+}
+
+// serveEvents отдаёт поток событий в формате Server-Sent Events.
+func (h *OverlayHub) serveEvents(w http.ResponseWriter, req *http.Request) {
+	if !h.authorized(req) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	sub := make(chan []byte, 32)
+	h.mu.Lock()
+	// Новый подписчик сразу получает активные раздачи и последние события
+	var backlog [][]byte
+	for _, hand := range h.hands {
+		if data, err := json.Marshal(hand); err == nil {
+			backlog = append(backlog, data)
+		}
+	}
+	backlog = append(backlog, h.recent...)
+	h.subs[sub] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.subs, sub)
+		h.mu.Unlock()
+	}()
+
+	for _, data := range backlog {
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case data := <-sub:
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// servePage отдаёт страницу оверлея для источника «Браузер» в OBS.
+func (h *OverlayHub) servePage(w http.ResponseWriter, req *http.Request) {
+	if !h.authorized(req) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, overlayPage)
+}
+
+// publishBlackjack отправляет состояние раздачи блэкджека в оверлей.
+// Пока раздача идёт, вторая карта дилера скрыта.
+func (r *Ranking) publishBlackjack(game *BlackjackGame, status, result string) {
+	if r.overlay == nil {
+		return
+	}
+	event := OverlayEvent{
+		Type:        "blackjack",
+		Status:      status,
+		GameID:      game.GameID,
+		PlayerID:    game.PlayerID,
+		Bet:         game.Bet,
+		PlayerCards: r.cardsToString(game.PlayerCards),
+		PlayerSum:   r.calculateHand(game.PlayerCards),
+		Result:      result,
+	}
+	if status == "playing" && len(game.DealerCards) > 0 {
+		event.DealerCards = r.cardToString(game.DealerCards[0]) + " 🂠"
+	} else {
+		event.DealerCards = r.cardsToString(game.DealerCards)
+		event.DealerSum = r.calculateHand(game.DealerCards)
+	}
+	r.overlay.Publish(event)
+}
+
+// overlayPage — страница оверлея: прозрачный фон, активные раздачи и лента результатов.
+const overlayPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>ChinaBot Casino Overlay</title>
+<style>
+  body { margin: 0; background: transparent; font-family: "Segoe UI", sans-serif; color: #fff; text-shadow: 0 0 4px #000; }
+  .box { background: rgba(20, 20, 30, 0.75); border: 2px solid #ffd700; border-radius: 12px; padding: 10px 14px; margin: 8px; max-width: 520px; }
+  .title { color: #ffd700; font-weight: bold; margin-bottom: 6px; }
+  .row { margin: 4px 0; }
+  .won { color: #4cff4c; } .lost { color: #ff5a5a; } .push, .surrender { color: #cccccc; }
+</style>
+</head>
+<body>
+<div class="box"><div class="title">♠️ Блэкджек — за столом</div><div id="hands">—</div></div>
+<div class="box"><div class="title">🎰 Последние игры</div><div id="feed"></div></div>
+<script>
+const hands = {};
+const feed = document.getElementById("feed");
+function esc(s) { return String(s || "").replace(/[&<>]/g, c => ({"&": "&amp;", "<": "&lt;", ">": "&gt;"}[c])); }
+function renderHands() {
+  const ids = Object.keys(hands);
+  document.getElementById("hands").innerHTML = ids.length ? ids.map(id => {
+    const e = hands[id];
+    return '<div class="row"><b>' + esc(e.player) + '</b> (' + e.bet + '): ' + esc(e.player_cards) + ' [' + e.player_sum + '] vs ' + esc(e.dealer_cards) + '</div>';
+  }).join("") : "—";
+}
+function describe(e) {
+  if (e.type === "blackjack") return "♠️ " + esc(e.player) + " (" + e.bet + "): " + esc(e.player_cards) + " [" + e.player_sum + "] vs " + esc(e.dealer_cards) + " [" + e.dealer_sum + "] — " + esc(e.result);
+  if (e.type === "rb") return "🎲 " + esc(e.player) + " поставил " + e.bet + " на " + esc(e.choice) + " — выпало " + esc(e.result);
+  if (e.type === "duel" && e.status === "push") return "⚔️ " + esc(e.winner) + " и " + esc(e.loser) + " — ничья, ставки возвращены (" + e.bet + ")";
+  if (e.type === "duel") return "⚔️ " + esc(e.winner) + " победил " + esc(e.loser) + " (ставка " + e.bet + ")";
+  return esc(e.type);
+}
+const source = new EventSource("/overlay/events" + location.search);
+source.onmessage = msg => {
+  const e = JSON.parse(msg.data);
+  if (e.type === "blackjack" && e.status === "playing") { hands[e.game_id] = e; renderHands(); return; }
+  if (e.type === "blackjack") { delete hands[e.game_id]; renderHands(); }
+  const row = document.createElement("div");
+  row.className = "row " + e.status;
+  row.innerHTML = describe(e);
+  feed.prepend(row);
+  while (feed.children.length > 8) feed.removeChild(feed.lastChild);
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,204 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Регулярные выплаты (зарплаты модераторам и т.п.). Выплаты хранятся в хэше payroll:entries
+// и проводятся задачей payroll: если срок выплаты прошёл, игрок получает сумму один раз,
+// а следующая выплата назначается через период от текущего момента.
+const payrollKey = "payroll:entries" // HASH userID -> PayrollEntry
+
+// payrollPeriods — периоды выплат.
+var payrollPeriods = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// payrollPeriodNames — подписи периодов.
+var payrollPeriodNames = map[string]string{
+	"daily":  "ежедневно",
+	"weekly": "еженедельно",
+}
+
+// PayrollEntry — регулярная выплата игроку.
+type PayrollEntry struct {
+	UserID  string    `json:"user_id"`
+	Amount  int       `json:"amount"`
+	Period  string    `json:"period"`
+	AddedBy string    `json:"added_by"`
+	NextAt  time.Time `json:"next_at"`
+	Created time.Time `json:"created"`
+
+	raw string // JSON, прочитанный из Redis, — для сравнения при сдвиге срока
+}
+
+// payrollAdvanceScript сдвигает срок выплаты, только если запись не изменилась с момента чтения:
+// удалённая или переназначенная админом во время прохода выплата не воскрешается старой версией.
+// Возвращает 1, если запись обновлена, и 0, если её удалили или изменили.
+var payrollAdvanceScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then return 0 end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// payrollEntries возвращает все регулярные выплаты.
+func (r *Ranking) payrollEntries() ([]PayrollEntry, error) {
+	raw, err := r.redis.HGetAll(r.ctx, payrollKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]PayrollEntry, 0, len(raw))
+	for userID, data := range raw {
+		var entry PayrollEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			log.Printf("Пропуск повреждённой выплаты %s: %v", userID, err)
+			continue
+		}
+		entry.raw = data
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].NextAt.Before(entries[j].NextAt) })
+	return entries, nil
+}
+
+// savePayrollEntry сохраняет регулярную выплату.
+func (r *Ranking) savePayrollEntry(entry PayrollEntry) error {
+	data, _ := json.Marshal(entry)
+	return r.redis.HSet(r.ctx, payrollKey, entry.UserID, data).Err()
+}
+
+// runPayroll проводит выплаты, срок которых наступил.
+func (r *Ranking) runPayroll() error {
+	entries, err := r.payrollEntries()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var paid []string
+	for _, entry := range entries {
+		if now.Before(entry.NextAt) {
+			continue
+		}
+		// Сначала сдвигаем срок, чтобы сбой после начисления не привёл к двойной выплате
+		entry.NextAt = now.Add(payrollPeriods[entry.Period])
+		data, _ := json.Marshal(entry)
+		advanced, err := payrollAdvanceScript.Run(r.ctx, r.redis, []string{payrollKey}, entry.UserID, entry.raw, data).Int()
+		if err != nil {
+			log.Printf("Не удалось обновить выплату %s: %v", entry.UserID, err)
+			continue
+		}
+		if advanced == 0 {
+			log.Printf("Выплата %s изменена или отменена во время проведения, пропускаем", entry.UserID)
+			continue
+		}
+		r.mu.Lock()
+		r.UpdateRatingFrom(entry.UserID, entry.Amount, "payroll", entry.AddedBy)
+		r.mu.Unlock()
+		paid = append(paid, fmt.Sprintf("<@%s> +%s", entry.UserID, formatCredits(entry.Amount)))
+	}
+	if len(paid) == 0 {
+		return nil
+	}
+	log.Printf("Проведены регулярные выплаты: %d", len(paid))
+	if s, err := r.Session(); err == nil {
+		r.LogCreditOperation(s, truncate("💼 Регулярные выплаты: "+strings.Join(paid, ", "), 2000))
+	}
+	return nil
+}
+
+// HandlePayrollCommand обрабатывает команду !a_payroll [add @user <сумма> <daily|weekly> | remove @user].
+func (r *Ranking) HandlePayrollCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_payroll: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать выплаты! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_payroll` — список, `/a_payroll add @user <сумма> <daily|weekly>` или `/a_payroll remove @user`"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendPayrollList(s, m.ChannelID)
+		return
+	}
+	if len(m.Mentions) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	userID := m.Mentions[0].ID
+
+	switch {
+	case parts[1] == "add" && len(parts) == 5:
+		amount, err := strconv.Atoi(parts[3])
+		if err != nil || amount <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Сумма должна быть положительным числом!")
+			return
+		}
+		period, ok := payrollPeriods[parts[4]]
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		now := time.Now()
+		entry := PayrollEntry{UserID: userID, Amount: amount, Period: parts[4], AddedBy: m.Author.ID, NextAt: now.Add(period), Created: now}
+		if err := r.savePayrollEntry(entry); err != nil {
+			log.Printf("Не удалось сохранить выплату %s: %v", userID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+			return
+		}
+		text := fmt.Sprintf("💼 <@%s> назначил <@%s> выплату %s %s, первая — <t:%d:f>", m.Author.ID, userID, formatCredits(amount), payrollPeriodNames[entry.Period], entry.NextAt.Unix())
+		r.LogCreditOperation(s, text)
+		s.ChannelMessageSend(m.ChannelID, "✅ "+text)
+	case parts[1] == "remove" && len(parts) == 3:
+		removed, err := r.redis.HDel(r.ctx, payrollKey, userID).Result()
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis, попробуй позже!")
+			return
+		}
+		if removed == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ У <@%s> нет регулярной выплаты.", userID))
+			return
+		}
+		text := fmt.Sprintf("💼 <@%s> отменил регулярную выплату <@%s>", m.Author.ID, userID)
+		r.LogCreditOperation(s, text)
+		s.ChannelMessageSend(m.ChannelID, "✅ "+text)
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// sendPayrollList отправляет список регулярных выплат.
+func (r *Ranking) sendPayrollList(s *discordgo.Session, channelID string) {
+	entries, err := r.payrollEntries()
+	if err != nil {
+		s.ChannelMessageSend(channelID, "❌ Ошибка Redis, попробуй позже!")
+		return
+	}
+	if len(entries) == 0 {
+		s.ChannelMessageSend(channelID, "ℹ️ Регулярных выплат нет. Добавить: `/a_payroll add @user <сумма> <daily|weekly>`")
+		return
+	}
+	lines := make([]string, 0, len(entries))
+	weekly := 0
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("<@%s> — %s %s, следующая <t:%d:R> (назначил <@%s>)", entry.UserID, formatCredits(entry.Amount), payrollPeriodNames[entry.Period], entry.NextAt.Unix(), entry.AddedBy))
+		weekly += entry.Amount * int(7*24*time.Hour/payrollPeriods[entry.Period])
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "💼 Регулярные выплаты",
+		Description: truncate(strings.Join(lines, "\n"), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Всего %d выплат · %s в неделю · /a_payroll remove @user", len(entries), formatCredits(weekly))},
+	}
+	s.ChannelMessageSendEmbed(channelID, embed)
+}
@@ -0,0 +1,194 @@
+package ranking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Проверка прав бота при ошибках Discord. Ответы 403 на изменяющие запросы REST
+// (отправка, редактирование, удаление сообщений, реакции, роли, ЛС) перехватываются на уровне
+// HTTP-клиента сессии: бот определяет, каких прав ему не хватает в канале, и один раз за
+// PERM_ALERT_HOURS отправляет админам понятное оповещение вместо строчки в логах.
+
+// permissionAction описывает действие бота и права, которые для него нужны.
+type permissionAction struct {
+	verb  string // «удалять сообщения» — для текста оповещения
+	perms []int64
+}
+
+var permissionActions = map[string]permissionAction{
+	"send":   {"отправлять сообщения", []int64{discordgo.PermissionViewChannel, discordgo.PermissionSendMessages, discordgo.PermissionEmbedLinks, discordgo.PermissionAttachFiles}},
+	"edit":   {"редактировать сообщения", []int64{discordgo.PermissionViewChannel, discordgo.PermissionSendMessages, discordgo.PermissionEmbedLinks}},
+	"delete": {"удалять сообщения", []int64{discordgo.PermissionViewChannel, discordgo.PermissionManageMessages}},
+	"react":  {"ставить реакции", []int64{discordgo.PermissionViewChannel, discordgo.PermissionReadMessageHistory, discordgo.PermissionAddReactions}},
+	"roles":  {"выдавать роли", []int64{discordgo.PermissionManageRoles}},
+	"dm":     {"писать в личные сообщения", nil},
+}
+
+// permissionLabels — названия прав, как они подписаны в настройках Discord.
+var permissionLabels = map[int64]string{
+	discordgo.PermissionViewChannel:        "Просматривать канал",
+	discordgo.PermissionSendMessages:       "Отправлять сообщения",
+	discordgo.PermissionEmbedLinks:         "Встраивать ссылки",
+	discordgo.PermissionAttachFiles:        "Прикреплять файлы",
+	discordgo.PermissionManageMessages:     "Управлять сообщениями",
+	discordgo.PermissionReadMessageHistory: "Читать историю сообщений",
+	discordgo.PermissionAddReactions:       "Добавлять реакции",
+	discordgo.PermissionManageRoles:        "Управлять ролями",
+}
+
+// permissionProbeTransport перехватывает ответы 403 Discord и передаёт их в handlePermissionError.
+type permissionProbeTransport struct {
+	r    *Ranking
+	next http.RoundTripper
+}
+
+// InstallPermissionProbe подключает проверку прав к HTTP-клиенту сессии Discord.
+func (r *Ranking) InstallPermissionProbe(s *discordgo.Session) {
+	if _, ok := s.Client.Transport.(*permissionProbeTransport); ok {
+		return
+	}
+	next := s.Client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	s.Client.Transport = &permissionProbeTransport{r: r, next: next}
+}
+
+func (t *permissionProbeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	// GET-запросы не трогаем: ими же пользуется сама проверка прав
+	if err != nil || resp.StatusCode != http.StatusForbidden || req.Method == http.MethodGet {
+		return resp, err
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, err
+	}
+	var apiErr discordgo.APIErrorMessage
+	if json.Unmarshal(body, &apiErr) != nil {
+		return resp, err
+	}
+	action, scope := classifyDiscordRequest(req.Method, req.URL.Path)
+	if action == "" {
+		return resp, err
+	}
+	switch apiErr.Code {
+	case discordgo.ErrCodeCannotSendMessagesToThisUser:
+		go t.r.handlePermissionError("dm", "", apiErr.Message)
+	case discordgo.ErrCodeMissingPermissions, discordgo.ErrCodeMissingAccess:
+		go t.r.handlePermissionError(action, scope, apiErr.Message)
+	}
+	return resp, err
+}
+
+// classifyDiscordRequest определяет по запросу REST действие бота и канал (или сервер для ролей).
+func classifyDiscordRequest(method, path string) (action, scope string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// /api/v9/channels/{id}/messages[/{id}[/reactions/...]]
+	for len(parts) > 0 && parts[0] != "channels" && parts[0] != "guilds" {
+		parts = parts[1:]
+	}
+	switch {
+	case len(parts) >= 5 && parts[0] == "guilds" && parts[2] == "members" && parts[4] == "roles":
+		return "roles", parts[1]
+	case len(parts) >= 5 && parts[0] == "channels" && parts[2] == "messages" && parts[4] == "reactions":
+		return "react", parts[1]
+	case len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages" && method == http.MethodPatch:
+		return "edit", parts[1]
+	case len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages" && method == http.MethodDelete:
+		return "delete", parts[1]
+	case len(parts) >= 3 && parts[0] == "channels" && parts[2] == "messages" && method == http.MethodPost:
+		return "send", parts[1]
+	}
+	return "", ""
+}
+
+// permissionAlertKey возвращает ключ, не дающий повторять оповещение о том же канале и действии.
+func permissionAlertKey(action, scope string) string {
+	return fmt.Sprintf("perm_alert:%s:%s", action, scope)
+}
+
+// handlePermissionError проверяет права бота и отправляет админам одно оповещение.
+func (r *Ranking) handlePermissionError(action, scope, apiMessage string) {
+	ttl := time.Duration(envInt("PERM_ALERT_HOURS", 6)) * time.Hour
+	ok, err := r.redis.SetNX(r.ctx, permissionAlertKey(action, scope), time.Now().Unix(), ttl).Result()
+	if err != nil || !ok {
+		return
+	}
+	s, err := r.Session()
+	if err != nil {
+		return
+	}
+
+	text := r.describePermissionError(s, action, scope)
+	log.Printf("Нехватка прав Discord: %s (%s)", text, apiMessage)
+	channelID := os.Getenv("ADMIN_CHANNEL_ID")
+	if channelID == "" {
+		channelID = r.logChannelID
+	}
+	if channelID == "" || (channelID == scope && action == "send") {
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔒 Боту не хватает прав",
+		Description: text,
+		Color:       0xFFA500,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Discord: %s · повтор не раньше чем через %d ч.", apiMessage, int(ttl.Hours()))},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		log.Printf("Не удалось отправить оповещение о правах: %v", err)
+	}
+}
+
+// describePermissionError формирует текст оповещения: что бот не может сделать, где и каких прав не хватает.
+func (r *Ranking) describePermissionError(s *discordgo.Session, action, scope string) string {
+	info := permissionActions[action]
+	if action == "dm" {
+		return "бот не может " + info.verb + ": у игрока закрыты ЛС от участников сервера. Попросите игроков открыть их, если нужны уведомления в ЛС."
+	}
+	if action == "roles" {
+		return "бот не может " + info.verb + ": нужно право «" + permissionLabels[discordgo.PermissionManageRoles] + "», а роль бота должна стоять выше выдаваемых ролей."
+	}
+
+	where := fmt.Sprintf("<#%s>", scope)
+	if channel, err := s.State.Channel(scope); err == nil {
+		where = "#" + channel.Name
+	} else if channel, err := s.Channel(scope); err == nil {
+		where = "#" + channel.Name
+	}
+	text := fmt.Sprintf("бот не может %s в %s", info.verb, where)
+
+	botID := ""
+	if s.State != nil && s.State.User != nil {
+		botID = s.State.User.ID
+	} else if me, err := s.User("@me"); err == nil {
+		botID = me.ID
+	}
+	perms, err := s.UserChannelPermissions(botID, scope)
+	if err != nil {
+		return text + " — проверьте права бота в канале."
+	}
+	var missing []string
+	for _, perm := range info.perms {
+		if perms&perm == 0 {
+			missing = append(missing, "«"+permissionLabels[perm]+"»")
+		}
+	}
+	if len(missing) == 0 {
+		return text + " — права канала в порядке, возможно, мешает переопределение для отдельного сообщения или треда."
+	}
+	return text + " — выдайте право " + strings.Join(missing, ", ") + "."
+}
@@ -0,0 +1,486 @@
+package ranking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Лавки игроков: каждый может открыть свою лавку и выставить NFT и кейсы по своим ценам.
+// Товар удерживается в эскроу (одно бессрочное удержание на позицию), поэтому продать его
+// мимо лавки нельзя. С каждой покупки бот берёт комиссию в общий фонд (см. /a_tax).
+const (
+	playerShopOpenKey  = "player_shop:open" // SET продавцов с открытой лавкой
+	playerShopMaxItems = 15
+	playerShopMaxPrice = 1_000_000_000 // предельная цена за штуку
+)
+
+// PlayerShopItem — позиция лавки: Count штук по цене Price за штуку.
+type PlayerShopItem struct {
+	Kind   string `json:"kind"` // nft или case
+	ItemID string `json:"item_id"`
+	Count  int    `json:"count"`
+	Price  int    `json:"price"`
+}
+
+// errPlayerShopItemGone возвращается, если позиция уже раскуплена или снята.
+var errPlayerShopItemGone = errors.New("такого товара в лавке нет")
+
+// playerShopInfoKey возвращает ключ описания лавки (HASH name, opened_at).
+func playerShopInfoKey(userID string) string {
+	return "player_shop:info:" + userID
+}
+
+// playerShopItemsKey возвращает ключ позиций лавки (HASH kind:itemID -> JSON PlayerShopItem).
+func playerShopItemsKey(userID string) string {
+	return "player_shop:items:" + userID
+}
+
+// playerShopHoldID возвращает ID удержания позиции.
+func playerShopHoldID(sellerID, kind, itemID string) string {
+	return "player_shop:" + sellerID + ":" + kind + ":" + itemID
+}
+
+// PlayerShopCommissionPercent возвращает комиссию лавок в процентах.
+func (r *Ranking) PlayerShopCommissionPercent() int {
+	return min(max(r.GetIntSetting("player_shop_commission", envInt("PLAYER_SHOP_COMMISSION_PERCENT", 5)), 0), 100)
+}
+
+// hold возвращает удержание, соответствующее позиции.
+func (item PlayerShopItem) hold(sellerID string) EscrowHold {
+	hold := EscrowHold{ID: playerShopHoldID(sellerID, item.Kind, item.ItemID), Owner: sellerID, Reason: "player_shop", Source: "player_shop"}
+	if item.Kind == "nft" {
+		hold.NFTs = map[string]int{item.ItemID: item.Count}
+	} else {
+		hold.Cases = map[string]int{item.ItemID: item.Count}
+	}
+	return hold
+}
+
+// playerShopItems возвращает позиции лавки, отсортированные по виду и ID.
+func (r *Ranking) playerShopItems(sellerID string) ([]PlayerShopItem, error) {
+	raw, err := r.redis.HGetAll(r.ctx, playerShopItemsKey(sellerID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]PlayerShopItem, 0, len(raw))
+	for _, data := range raw {
+		var item PlayerShopItem
+		if err := json.Unmarshal([]byte(data), &item); err == nil {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind > items[j].Kind
+		}
+		return items[i].ItemID < items[j].ItemID
+	})
+	return items, nil
+}
+
+// playerShopItem читает позицию лавки.
+func (r *Ranking) playerShopItem(sellerID, kind, itemID string) (PlayerShopItem, error) {
+	var item PlayerShopItem
+	data, err := r.redis.HGet(r.ctx, playerShopItemsKey(sellerID), kind+":"+itemID).Bytes()
+	if err == redis.Nil {
+		return item, errPlayerShopItemGone
+	}
+	if err != nil {
+		return item, err
+	}
+	err = json.Unmarshal(data, &item)
+	return item, err
+}
+
+// savePlayerShopItem сохраняет позицию или удаляет её, если товар кончился.
+func (r *Ranking) savePlayerShopItem(sellerID string, item PlayerShopItem) {
+	field := item.Kind + ":" + item.ItemID
+	if item.Count <= 0 {
+		r.redis.HDel(r.ctx, playerShopItemsKey(sellerID), field)
+		return
+	}
+	data, _ := json.Marshal(item)
+	r.redis.HSet(r.ctx, playerShopItemsKey(sellerID), field, data)
+}
+
+// describePlayerShopItem форматирует позицию лавки.
+func (r *Ranking) describePlayerShopItem(item PlayerShopItem) string {
+	if item.Kind == "nft" {
+		if nft, ok := r.Kki.nfts[item.ItemID]; ok {
+			return fmt.Sprintf("%s **%s** (`%s`)", RarityEmojis[nft.Rarity], nft.Name, item.ItemID)
+		}
+		return fmt.Sprintf("🖼️ `%s`", item.ItemID)
+	}
+	if kase, ok := r.Kki.cases[item.ItemID]; ok {
+		return fmt.Sprintf("📦 **%s** (`%s`)", kase.Name, item.ItemID)
+	}
+	return fmt.Sprintf("📦 `%s`", item.ItemID)
+}
+
+// parsePlayerShopItem разбирает вид и ID товара: nft <ID> или case <ID>.
+func (r *Ranking) parsePlayerShopItem(kind, itemID string) (string, string, error) {
+	switch kind {
+	case "nft":
+		if _, ok := r.Kki.nfts[itemID]; !ok {
+			return "", "", fmt.Errorf("NFT не найдено. Проверьте ID.")
+		}
+	case "case":
+		if itemID == "daily" {
+			itemID = "daily_case"
+		}
+		if _, ok := r.Kki.cases[itemID]; !ok {
+			return "", "", fmt.Errorf("кейс не найден. Проверьте ID.")
+		}
+	default:
+		return "", "", fmt.Errorf("вид товара — `nft` или `case`")
+	}
+	return kind, itemID, nil
+}
+
+// stockPlayerShopItem выставляет товар в лавку. Если позиция уже есть, товар добавляется к ней,
+// а цена заменяется новой.
+func (r *Ranking) stockPlayerShopItem(sellerID, kind, itemID string, count, price int) (PlayerShopItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, err := r.playerShopItem(sellerID, kind, itemID)
+	switch {
+	case err == errPlayerShopItemGone:
+		if n, _ := r.redis.HLen(r.ctx, playerShopItemsKey(sellerID)).Result(); n >= playerShopMaxItems {
+			return item, fmt.Errorf("в лавке уже %d позиций — сними какую-нибудь: `/shop_remove nft|case <ID>`", playerShopMaxItems)
+		}
+		item = PlayerShopItem{Kind: kind, ItemID: itemID}
+	case err != nil:
+		return item, fmt.Errorf("ошибка Redis: %v", err)
+	default:
+		// Объединяем с прежним удержанием: возвращаем его и удерживаем общий объём заново
+		old, err := r.escrowTake(item.hold(sellerID).ID)
+		switch {
+		case err == errEscrowClosed:
+			item.Count = 0
+		case err != nil:
+			return item, fmt.Errorf("ошибка эскроу: %v", err)
+		default:
+			r.escrowDeliverLocked(old, sellerID, old.ledgerSource())
+		}
+	}
+
+	stocked := item
+	stocked.Count += count
+	stocked.Price = price
+	if err := r.escrowReserveLocked(stocked.hold(sellerID), 0); err != nil {
+		if item.Count > 0 {
+			if err := r.escrowReserveLocked(item.hold(sellerID), 0); err != nil {
+				log.Printf("Не удалось восстановить позицию %s:%s лавки %s: %v", kind, itemID, sellerID, err)
+				item.Count = 0
+				r.savePlayerShopItem(sellerID, item)
+			}
+		}
+		return item, err
+	}
+	r.savePlayerShopItem(sellerID, stocked)
+	return stocked, nil
+}
+
+// unstockPlayerShopItem снимает позицию и возвращает товар продавцу.
+func (r *Ranking) unstockPlayerShopItem(sellerID, kind, itemID string) (PlayerShopItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, err := r.playerShopItem(sellerID, kind, itemID)
+	if err != nil {
+		return item, err
+	}
+	hold, err := r.escrowTake(item.hold(sellerID).ID)
+	if err == nil {
+		r.escrowDeliverLocked(hold, sellerID, hold.ledgerSource())
+	} else if err != errEscrowClosed {
+		return item, fmt.Errorf("ошибка эскроу: %v", err)
+	}
+	item.Count = 0
+	r.savePlayerShopItem(sellerID, item)
+	return item, nil
+}
+
+// buyPlayerShopItem покупает товар из лавки: списывает кредиты, передаёт товар из эскроу,
+// удерживает в комиссию долю в общий фонд. Остаток позиции удерживается заново.
+func (r *Ranking) buyPlayerShopItem(buyerID, sellerID, kind, itemID string, count int) (PlayerShopItem, int, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, err := r.playerShopItem(sellerID, kind, itemID)
+	if err != nil {
+		return item, 0, 0, err
+	}
+	if count > item.Count {
+		return item, 0, 0, fmt.Errorf("в лавке только %d шт.", item.Count)
+	}
+	// Сумма и комиссия (total * процент) не должны переполнять int
+	if count <= 0 || item.Price <= 0 || item.Price > math.MaxInt/100/count {
+		return item, 0, 0, fmt.Errorf("некорректная цена позиции — продавцу нужно выставить её заново")
+	}
+	total := item.Price * count
+	if rating := r.GetRating(buyerID); rating < total {
+		return item, 0, 0, fmt.Errorf("недостаточно кредитов: нужно %s, твой баланс %s", formatCredits(total), formatCredits(rating))
+	}
+	hold, err := r.escrowCommit(item.hold(sellerID).ID)
+	if err == errEscrowClosed {
+		item.Count = 0
+		r.savePlayerShopItem(sellerID, item)
+		return item, 0, 0, errPlayerShopItemGone
+	}
+	if err != nil {
+		return item, 0, 0, fmt.Errorf("ошибка эскроу: %v", err)
+	}
+
+	bought := item
+	bought.Count = count
+	rest := item
+	rest.Count -= count
+	r.escrowDeliverLocked(bought.hold(sellerID), buyerID, "player_shop")
+	if rest.Count > 0 {
+		r.escrowDeliverLocked(rest.hold(sellerID), sellerID, hold.ledgerSource())
+		if err := r.escrowReserveLocked(rest.hold(sellerID), 0); err != nil {
+			log.Printf("Не удалось заново удержать остаток позиции %s:%s лавки %s: %v", kind, itemID, sellerID, err)
+			rest.Count = 0
+		}
+	}
+	r.savePlayerShopItem(sellerID, rest)
+
+	commission := total * r.PlayerShopCommissionPercent() / 100
+	r.UpdateRatingFrom(buyerID, -total, "player_shop", sellerID)
+	r.UpdateRatingFrom(sellerID, total-commission, "player_shop", buyerID)
+	if commission > 0 {
+		if err := r.redis.IncrBy(r.ctx, transferTaxPotKey, int64(commission)).Err(); err != nil {
+			log.Printf("Не удалось зачислить комиссию лавки %d в общий фонд: %v", commission, err)
+		}
+	}
+	var ledgerID int64
+	if kind == "nft" {
+		r.recordNFTSale(itemID, item.Price)
+		ledgerID = r.recordNFTMutation("player_shop", buyerID, sellerID, buyerID, map[string]int{itemID: count})
+	}
+	return bought, commission, ledgerID, nil
+}
+
+// HandleShopOpenCommand обрабатывает команду !shop_open [название].
+func (r *Ranking) HandleShopOpenCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_open: %s от %s", command, m.Author.ID)
+
+	// Название берём из исходного сообщения: command приведена к нижнему регистру
+	name := strings.Join(strings.Fields(m.Content)[1:], " ")
+	if name == "" {
+		name = "Лавка " + m.Author.Username
+	}
+	name = truncate(name, 64)
+	pipe := r.redis.TxPipeline()
+	pipe.HSetNX(r.ctx, playerShopInfoKey(m.Author.ID), "opened_at", time.Now().Unix())
+	pipe.HSet(r.ctx, playerShopInfoKey(m.Author.ID), "name", name)
+	pipe.SAdd(r.ctx, playerShopOpenKey, m.Author.ID)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось открыть лавку %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка открытия лавки! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏬 Лавка **%s** открыта! Выставляй товар: `/shop_add nft|case <ID> <кол-во> <цена за штуку>`. Комиссия с продаж — %d%%.", name, r.PlayerShopCommissionPercent()))
+}
+
+// playerShopOpen проверяет, открыта ли лавка пользователя.
+func (r *Ranking) playerShopOpen(userID string) bool {
+	open, _ := r.redis.SIsMember(r.ctx, playerShopOpenKey, userID).Result()
+	return open
+}
+
+// HandleShopAddCommand обрабатывает команду !shop_add nft|case <ID> <кол-во> <цена>.
+func (r *Ranking) HandleShopAddCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_add: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 5 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/shop_add nft|case <ID> <кол-во> <цена за штуку>`")
+		return
+	}
+	if !r.playerShopOpen(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Сначала открой лавку: `/shop_open [название]`")
+		return
+	}
+	kind, itemID, err := r.parsePlayerShopItem(parts[1], parts[2])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+		return
+	}
+	count, errCount := strconv.Atoi(parts[3])
+	price, errPrice := strconv.Atoi(parts[4])
+	if errCount != nil || errPrice != nil || count <= 0 || price <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Количество и цена должны быть положительными числами!")
+		return
+	}
+	if price > playerShopMaxPrice {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Цена за штуку не может быть больше %s!", formatCredits(playerShopMaxPrice)))
+		return
+	}
+	item, err := r.stockPlayerShopItem(m.Author.ID, kind, itemID, count, price)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось выставить товар: "+err.Error())
+		return
+	}
+	log.Printf("Лавка %s: выставлено %d x %s:%s по %d", m.Author.ID, count, kind, itemID, price)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ В лавке: %s x%d по %s. Товар удержан, пока его не купят или ты не снимешь его: `/shop_remove %s %s`",
+		r.describePlayerShopItem(item), item.Count, formatCredits(item.Price), kind, itemID))
+	if kind == "nft" {
+		go r.notifyWishlist(s, itemID, fmt.Sprintf("появилась в лавке <@%s>: %d шт. по %s. Купить: `/shop_buy @user nft %s`", m.Author.ID, item.Count, formatCredits(item.Price), itemID), m.ChannelID, m.Author.ID)
+	}
+}
+
+// HandleShopRemoveCommand обрабатывает команду !shop_remove nft|case <ID>.
+func (r *Ranking) HandleShopRemoveCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_remove: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/shop_remove nft|case <ID>`")
+		return
+	}
+	kind, itemID := parts[1], parts[2]
+	if kind == "case" && itemID == "daily" {
+		itemID = "daily_case"
+	}
+	item, err := r.unstockPlayerShopItem(m.Author.ID, kind, itemID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ %s снят с продажи и возвращён в инвентарь.", r.describePlayerShopItem(item)))
+}
+
+// HandleShopCloseCommand обрабатывает команду !shop_close: весь товар возвращается владельцу.
+func (r *Ranking) HandleShopCloseCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !shop_close от %s", m.Author.ID)
+
+	if !r.playerShopOpen(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ У тебя нет открытой лавки.")
+		return
+	}
+	items, err := r.playerShopItems(m.Author.ID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка лавки! Проверьте Redis-сервер.")
+		return
+	}
+	for _, item := range items {
+		if _, err := r.unstockPlayerShopItem(m.Author.ID, item.Kind, item.ItemID); err != nil && err != errPlayerShopItemGone {
+			log.Printf("Не удалось снять %s:%s из лавки %s: %v", item.Kind, item.ItemID, m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Не удалось вернуть часть товара, попробуйте ещё раз: "+err.Error())
+			return
+		}
+	}
+	r.redis.SRem(r.ctx, playerShopOpenKey, m.Author.ID)
+	r.redis.Del(r.ctx, playerShopInfoKey(m.Author.ID), playerShopItemsKey(m.Author.ID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏬 Лавка закрыта, товар (%d поз.) возвращён в инвентарь.", len(items)))
+}
+
+// HandleShopBuyCommand обрабатывает команду !shop_buy @продавец nft|case <ID> [кол-во].
+func (r *Ranking) HandleShopBuyCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_buy: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/shop_buy @продавец nft|case <ID> [кол-во]`"
+	parts := strings.Fields(command)
+	if len(m.Mentions) != 1 || len(parts) < 4 || len(parts) > 5 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	seller := m.Mentions[0]
+	if seller.ID == m.Author.ID {
+		s.ChannelMessageSend(m.ChannelID, "❌ Нельзя купить в своей лавке — сними товар: `/shop_remove`")
+		return
+	}
+	kind, itemID := parts[2], parts[3]
+	if kind == "case" && itemID == "daily" {
+		itemID = "daily_case"
+	}
+	count := 1
+	if len(parts) == 5 {
+		n, err := strconv.Atoi(parts[4])
+		if err != nil || n <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Количество должно быть положительным числом!")
+			return
+		}
+		count = n
+	}
+	item, commission, ledgerID, err := r.buyPlayerShopItem(m.Author.ID, seller.ID, kind, itemID, count)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Покупка невозможна: "+err.Error())
+		return
+	}
+	total := item.Price * item.Count
+	log.Printf("Лавка %s: %s купил %d x %s:%s за %d (комиссия %d)", seller.ID, m.Author.ID, count, kind, itemID, total, commission)
+	text := fmt.Sprintf("🏬 <@%s> купил в лавке <@%s>: %s x%d за %s (комиссия в фонд: %s)", m.Author.ID, seller.ID, r.describePlayerShopItem(item), item.Count, formatCredits(total), formatCredits(commission))
+	r.LogCreditOperation(s, text)
+	s.ChannelMessageSend(m.ChannelID, "✅ "+text+ledgerRef(ledgerID))
+}
+
+// sendPlayerShop отправляет витрину лавки игрока.
+func (r *Ranking) sendPlayerShop(s *discordgo.Session, channelID string, seller *discordgo.User) {
+	if !r.playerShopOpen(seller.ID) {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("ℹ️ У <@%s> нет лавки. Открыть свою: `/shop_open [название]`", seller.ID))
+		return
+	}
+	name, _ := r.redis.HGet(r.ctx, playerShopInfoKey(seller.ID), "name").Result()
+	items, err := r.playerShopItems(seller.ID)
+	if err != nil {
+		log.Printf("Не удалось получить лавку %s: %v", seller.ID, err)
+		s.ChannelMessageSend(channelID, "❌ Ошибка лавки! Попробуйте позже.")
+		return
+	}
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%s x%d — %s/шт", r.describePlayerShopItem(item), item.Count, formatCredits(item.Price)))
+	}
+	description := "Витрина пуста."
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	s.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+		Title:       "🏬 " + name,
+		Description: truncate(fmt.Sprintf("Владелец: <@%s>\n\n%s", seller.ID, description), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("/shop_buy @%s nft|case <ID> [кол-во] · комиссия %d%%", seller.Username, r.PlayerShopCommissionPercent())},
+	})
+}
+
+// HandleShopsCommand обрабатывает команду !shops — список открытых лавок.
+func (r *Ranking) HandleShopsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !shops от %s", m.Author.ID)
+
+	sellers, err := r.redis.SMembers(r.ctx, playerShopOpenKey).Result()
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка лавок! Попробуйте позже.")
+		return
+	}
+	sort.Strings(sellers)
+	lines := make([]string, 0, len(sellers))
+	for _, sellerID := range sellers {
+		name, _ := r.redis.HGet(r.ctx, playerShopInfoKey(sellerID), "name").Result()
+		count, _ := r.redis.HLen(r.ctx, playerShopItemsKey(sellerID)).Result()
+		lines = append(lines, fmt.Sprintf("🏬 **%s** — <@%s> · позиций: %d", name, sellerID, count))
+	}
+	description := "Лавок пока нет. Открой первую: `/shop_open [название]`"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Title:       "🏬 Лавки игроков",
+		Description: truncate(description, 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "/shop @игрок — витрина лавки"},
+	})
+}
@@ -158,6 +158,10 @@ func (r *Ranking) HandleDepCommand(s *discordgo.Session, m *discordgo.MessageCre
 		s.ChannelMessageSend(m.ChannelID, "❌ Сумма должна быть положительным числом! 💸")
 		return
 	}
+	if msg := r.checkBetLimits("dep", m.Author.ID, amount); msg != "" {
+		s.ChannelMessageSend(m.ChannelID, msg)
+		return
+	}
 
 	r.mu.Lock()
 	poll, exists := r.polls[pollID]
@@ -175,12 +179,17 @@ func (r *Ranking) HandleDepCommand(s *discordgo.Session, m *discordgo.MessageCre
 
 	userRating := r.GetRating(m.Author.ID)
 	if userRating < amount {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %d 💰", userRating))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(userRating)))
+		r.mu.Unlock()
+		return
+	}
+	if remaining, err := r.reserveDailyWager(m.Author.ID, amount); err != nil {
+		s.ChannelMessageSend(m.ChannelID, r.wagerCapMessage(remaining, err))
 		r.mu.Unlock()
 		return
 	}
 
-	r.UpdateRating(m.Author.ID, -amount)
+	r.UpdateRatingFrom(m.Author.ID, -amount, "poll", "")
 	poll.Bets[m.Author.ID] += amount
 	poll.Choices[m.Author.ID] = option
 	r.mu.Unlock()
@@ -188,8 +197,8 @@ func (r *Ranking) HandleDepCommand(s *discordgo.Session, m *discordgo.MessageCre
 	coefficients := poll.GetCoefficients()
 	coefficient := coefficients[option-1]
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎲 <@%s> поставил %d кредитов на [%s] в опросе **%s** 📊\n**📈 Текущий коэффициент:** %.2f", m.Author.ID, amount, poll.Options[option-1], poll.Question, coefficient))
-	r.LogCreditOperation(s, fmt.Sprintf("<@%s> поставил %d соц кредитов на опрос %s", m.Author.ID, amount, pollID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎲 <@%s> поставил %s на [%s] в опросе **%s** 📊\n**📈 Текущий коэффициент:** %.2f", m.Author.ID, formatCredits(amount), poll.Options[option-1], poll.Question, coefficient))
+	r.LogCreditOperation(s, fmt.Sprintf("<@%s> поставил %s на опрос %s", m.Author.ID, formatCredits(amount), pollID))
 	log.Printf("Пользователь %s поставил %d на вариант %d в опросе %s, коэффициент: %.2f", m.Author.ID, amount, option, pollID, coefficient)
 }
 
@@ -258,9 +267,10 @@ func (r *Ranking) HandleCloseDepCommand(s *discordgo.Session, m *discordgo.Messa
 	for userID, choice := range poll.Choices {
 		if choice == winningOption {
 			winnings := int(float64(poll.Bets[userID]) * coefficient)
-			r.UpdateRating(userID, winnings+poll.Bets[userID])
-			response += fmt.Sprintf("<@%s>: %d кредитов (ставка: %d) 💰\n", userID, winnings+poll.Bets[userID], poll.Bets[userID])
-			r.LogCreditOperation(s, fmt.Sprintf("<@%s> выиграл %d соц кредитов в опросе %s", userID, winnings+poll.Bets[userID], pollID))
+			r.UpdateRatingFrom(userID, winnings+poll.Bets[userID], "poll", "")
+			r.repayLoanFromWinnings(userID, winnings)
+			response += fmt.Sprintf("<@%s>: %s (ставка: %s)\n", userID, formatCredits(winnings+poll.Bets[userID]), formatCredits(poll.Bets[userID]))
+			r.LogCreditOperation(s, fmt.Sprintf("<@%s> выиграл %s в опросе %s", userID, formatCredits(winnings+poll.Bets[userID]), pollID))
 		}
 	}
 	if winnersBet == 0 {
@@ -299,7 +309,7 @@ func (r *Ranking) HandlePollsCommand(s *discordgo.Session, m *discordgo.MessageC
 				if choice == i+1 {
 					bet := poll.Bets[userID]
 					potentialWin := int(float64(bet) * coefficients[i])
-					response += fmt.Sprintf("  - <@%s>: %d кредитов (💰 Потенциальный выигрыш: %d)\n", userID, bet, potentialWin+bet)
+					response += fmt.Sprintf("  - <@%s>: %s (Потенциальный выигрыш: %s)\n", userID, formatCredits(bet), formatCredits(potentialWin+bet))
 				}
 			}
 		}
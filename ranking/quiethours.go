@@ -0,0 +1,224 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Тихие часы: в заданный игроком промежуток бот не пишет ему в ЛС. Важные сообщения
+// (подарки, оповещения, на которые игрок подписался) копятся в очереди и приходят одним
+// сообщением, когда тихие часы закончатся; остальные просто не отправляются.
+
+// quietQueueLimit — сколько отложенных сообщений хранится для одного игрока.
+const quietQueueLimit = 20
+
+// quietQueueTTL — сколько живёт очередь отложенных сообщений.
+const quietQueueTTL = 72 * time.Hour
+
+// quietKey возвращает хэш тихих часов игрока: start, end (минуты от полуночи), tz.
+func quietKey(userID string) string {
+	return "quiet:" + userID
+}
+
+// quietQueueKey возвращает список отложенных на тихие часы сообщений.
+func quietQueueKey(userID string) string {
+	return "quiet_queue:" + userID
+}
+
+// quietDefaultLocation возвращает часовой пояс по умолчанию (QUIET_HOURS_TIMEZONE, по умолчанию Asia/Krasnoyarsk).
+func quietDefaultLocation() *time.Location {
+	name := os.Getenv("QUIET_HOURS_TIMEZONE")
+	if name == "" {
+		name = "Asia/Krasnoyarsk"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Ошибка загрузки часового пояса %s: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// QuietHours — настройки тихих часов игрока.
+type QuietHours struct {
+	Start, End int // минуты от полуночи; Start == End — тихие часы выключены
+	Location   *time.Location
+}
+
+// Active сообщает, попадает ли момент t в тихие часы. Промежуток может переходить через полночь.
+func (q QuietHours) Active(t time.Time) bool {
+	if q.Start == q.End {
+		return false
+	}
+	local := t.In(q.Location)
+	now := local.Hour()*60 + local.Minute()
+	if q.Start < q.End {
+		return now >= q.Start && now < q.End
+	}
+	return now >= q.Start || now < q.End
+}
+
+// String возвращает промежуток в виде ЧЧ:ММ–ЧЧ:ММ.
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d–%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+// QuietHours возвращает тихие часы игрока.
+func (r *Ranking) QuietHours(userID string) QuietHours {
+	q := QuietHours{Location: quietDefaultLocation()}
+	fields, err := r.redis.HGetAll(r.ctx, quietKey(userID)).Result()
+	if err != nil {
+		return q
+	}
+	q.Start, _ = strconv.Atoi(fields["start"])
+	q.End, _ = strconv.Atoi(fields["end"])
+	if fields["tz"] != "" {
+		if loc, err := time.LoadLocation(fields["tz"]); err == nil {
+			q.Location = loc
+		}
+	}
+	return q
+}
+
+// InQuietHours сообщает, что у игрока сейчас тихие часы.
+func (r *Ranking) InQuietHours(userID string) bool {
+	return r.QuietHours(userID).Active(time.Now())
+}
+
+// sendDM отправляет игроку личное сообщение с учётом тихих часов: важное откладывается
+// до их окончания, неважное отбрасывается. Ошибка — только если ЛС не удалось отправить.
+func (r *Ranking) sendDM(s *discordgo.Session, userID, text string, important bool) error {
+	if r.InQuietHours(userID) {
+		if !important {
+			log.Printf("Тихие часы %s: ЛС не отправлено", userID)
+			return nil
+		}
+		pipe := r.redis.TxPipeline()
+		pipe.RPush(r.ctx, quietQueueKey(userID), text)
+		pipe.LTrim(r.ctx, quietQueueKey(userID), -quietQueueLimit, -1)
+		pipe.Expire(r.ctx, quietQueueKey(userID), quietQueueTTL)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			log.Printf("Не удалось отложить ЛС для %s: %v", userID, err)
+		}
+		return nil
+	}
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageSend(channel.ID, text)
+	return err
+}
+
+// flushQuietQueues отправляет отложенные сообщения игрокам, у которых закончились тихие часы.
+func (r *Ranking) flushQuietQueues() error {
+	var users []string
+	err := r.scanKeys(quietQueueKey("*"), func(key string) error {
+		userID := strings.TrimPrefix(key, quietQueueKey(""))
+		if !r.InQuietHours(userID) {
+			users = append(users, userID)
+		}
+		return nil
+	})
+	if err != nil || len(users) == 0 {
+		return err
+	}
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		messages, err := r.redis.LRange(r.ctx, quietQueueKey(userID), 0, -1).Result()
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+		r.redis.Del(r.ctx, quietQueueKey(userID))
+		text := truncate("🌙 Пока у тебя были тихие часы:\n\n"+strings.Join(messages, "\n\n"), 2000)
+		if err := r.sendDM(s, userID, text, false); err != nil {
+			log.Printf("Не удалось доставить отложенные ЛС %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// parseClock разбирает время ЧЧ или ЧЧ:ММ в минуты от полуночи.
+func parseClock(value string) (int, bool) {
+	hours, minutes, hasMinutes := strings.Cut(value, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 23 {
+		return 0, false
+	}
+	m := 0
+	if hasMinutes {
+		if m, err = strconv.Atoi(minutes); err != nil || m < 0 || m > 59 {
+			return 0, false
+		}
+	}
+	return h*60 + m, true
+}
+
+// HandleQuietCommand обрабатывает команду !quiet [<с>-<до> [часовой пояс] | tz <часовой пояс> | off].
+func (r *Ranking) HandleQuietCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !quiet: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/quiet 23-8 [Europe/Moscow]`, `/quiet 23:30-07:00`, `/quiet tz <часовой пояс>` или `/quiet off`"
+	// Часовой пояс чувствителен к регистру, поэтому аргументы берутся из исходного сообщения
+	parts := strings.Fields(m.Content)
+	key := quietKey(m.Author.ID)
+	switch {
+	case len(parts) == 1:
+		q := r.QuietHours(m.Author.ID)
+		if q.Start == q.End {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🔔 Тихие часы выключены (часовой пояс: %s). Включить: `/quiet 23-8`", q.Location))
+			return
+		}
+		status := "сейчас не действуют"
+		if q.Active(time.Now()) {
+			status = "🌙 сейчас действуют"
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🌙 Тихие часы: **%s** (%s), %s. Важные ЛС придут после окончания, остальные не отправляются. Выключить: `/quiet off`", q, q.Location, status))
+	case len(parts) == 2 && strings.ToLower(parts[1]) == "off":
+		r.redis.HDel(r.ctx, key, "start", "end")
+		s.ChannelMessageSend(m.ChannelID, "🔔 Тихие часы выключены.")
+	case len(parts) == 3 && strings.ToLower(parts[1]) == "tz":
+		loc, err := time.LoadLocation(parts[2])
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Неизвестный часовой пояс. Пример: `Europe/Moscow`, `Asia/Krasnoyarsk`")
+			return
+		}
+		r.redis.HSet(r.ctx, key, "tz", loc.String())
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Часовой пояс тихих часов: %s (сейчас %s)", loc, time.Now().In(loc).Format("15:04")))
+	case len(parts) == 2 || len(parts) == 3:
+		from, to, ok := strings.Cut(parts[1], "-")
+		start, okStart := parseClock(from)
+		end, okEnd := parseClock(to)
+		if !ok || !okStart || !okEnd || start == end {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		fields := map[string]interface{}{"start": start, "end": end}
+		if len(parts) == 3 {
+			loc, err := time.LoadLocation(parts[2])
+			if err != nil {
+				s.ChannelMessageSend(m.ChannelID, "❌ Неизвестный часовой пояс. Пример: `Europe/Moscow`, `Asia/Krasnoyarsk`")
+				return
+			}
+			fields["tz"] = loc.String()
+		}
+		if err := r.redis.HSet(r.ctx, key, fields).Err(); err != nil {
+			log.Printf("Не удалось сохранить тихие часы %s: %v", m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения! Проверьте Redis-сервер.")
+			return
+		}
+		q := r.QuietHours(m.Author.ID)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🌙 Тихие часы: **%s** (%s). В это время бот не пишет в ЛС — важное придёт после.", q, q.Location))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
@@ -43,6 +43,9 @@ type BitcoinTracker struct {
 	LastUpdate    time.Time
 	PriceHistory  []float64
 	mu            sync.Mutex
+
+	failures     int       // ошибок CoinGecko подряд
+	backoffUntil time.Time // до этого времени CoinGecko не опрашивается
 }
 
 // RarityVolatility определяет волатильность цены для каждой редкости
@@ -69,25 +72,39 @@ var BaseRarityPrices = map[string]float64{
 
 // Ranking управляет рейтингами, опросами, играми и голосовой активностью.
 type Ranking struct {
-	mu                sync.Mutex
-	admins            map[string]bool
-	polls             map[string]*Poll
-	duels             map[string]*Duel
-	redis             *redis.Client
-	ctx               context.Context
-	voiceAct          map[string]int
-	redBlackGames     map[string]*RedBlackGame
-	blackjackGames    map[string]*BlackjackGame
-	floodChannelID    string
-	logChannelID      string
-	cinemaOptions     []CinemaOption
-	pendingCinemaBids map[string]PendingCinemaBid
-	cinemaChannelID   string
-	Kki               *KKI
-	sellMessageIDs    map[string]string // userID -> messageID
-	caseBank          *CaseBank
-	stopResetChan     chan struct{}
-	BitcoinTracker    *BitcoinTracker // НОВОЕ ПОЛЕ
+	mu                  sync.Mutex
+	admins              map[string]bool
+	polls               map[string]*Poll
+	duels               map[string]*Duel
+	redis               *redis.Client
+	ctx                 context.Context
+	voiceAct            map[string]int
+	voiceChannels       map[string]string    // userID -> голосовой канал, в котором сидит пользователь
+	voiceIdle           map[string]voiceIdle // userID -> с какого момента пользователь без микрофона/звука
+	voiceStreamers      map[string]bool      // userID -> стримит экран или включил камеру
+	voiceTrackers       map[string]uint64    // userID -> поколение работающего цикла startVoiceTracking
+	voiceGeneration     uint64
+	redBlackGames       map[string]*RedBlackGame
+	blackjackGames      map[string]*BlackjackGame
+	floodChannelID      string
+	logChannelID        string
+	cinemaOptions       []CinemaOption
+	pendingCinemaBids   map[string]PendingCinemaBid
+	cinemaChannelID     string
+	Kki                 *KKI
+	sellMessageIDs      map[string]string // userID -> messageID
+	caseBank            *CaseBank
+	scheduler           *Scheduler
+	session             *discordgo.Session
+	shards              []*discordgo.Session
+	users               *userCache
+	limiter             *commandLimiter
+	images              *ImageCache
+	web                 *WebServer
+	overlay             *OverlayHub
+	telegramMirror      func(text string) error
+	telegramPhotoMirror func(photoURL, caption string) error
+	BitcoinTracker      *BitcoinTracker // НОВОЕ ПОЛЕ
 }
 
 // NewRanking инициализирует структуру Ranking.
@@ -102,6 +119,10 @@ func NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID string
 		polls:             make(map[string]*Poll),
 		duels:             make(map[string]*Duel),
 		voiceAct:          map[string]int{},
+		voiceChannels:     map[string]string{},
+		voiceIdle:         map[string]voiceIdle{},
+		voiceStreamers:    map[string]bool{},
+		voiceTrackers:     map[string]uint64{},
 		redBlackGames:     make(map[string]*RedBlackGame),
 		blackjackGames:    make(map[string]*BlackjackGame),
 		ctx:               context.Background(),
@@ -111,6 +132,8 @@ func NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID string
 		pendingCinemaBids: make(map[string]PendingCinemaBid),
 		cinemaChannelID:   cinemaChannelID,
 		sellMessageIDs:    make(map[string]string),
+		users:             newUserCache(time.Duration(envInt("USER_CACHE_TTL_MS", 2000)) * time.Millisecond),
+		limiter:           newCommandLimiter(),
 		caseBank: &CaseBank{
 			Cases:       make(map[string]int),
 			LastUpdated: time.Now(),
@@ -138,6 +161,25 @@ func NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID string
 		return nil, fmt.Errorf("не удалось подключиться к Redis после 5 попыток: %v", redisErr)
 	}
 
+	// Префикс ключей для общего Redis (REDIS_KEY_PREFIX, например "chinabot:")
+	if prefix := os.Getenv("REDIS_KEY_PREFIX"); prefix != "" {
+		if os.Getenv("REDIS_KEY_PREFIX_MIGRATE") == "true" {
+			if err := r.moveKeysToPrefix(prefix); err != nil {
+				return nil, fmt.Errorf("не удалось перенести ключи под префикс %q: %v", prefix, err)
+			}
+		}
+		r.redis.AddHook(prefixHook{prefix: prefix})
+		log.Printf("Ключи Redis используют префикс %q", prefix)
+	}
+
+	// Миграции формата данных
+	if err := r.runMigrations(); err != nil {
+		return nil, err
+	}
+
+	// Агрегаты экономики для !a_economy
+	r.ensureEconomyAggregates()
+
 	// Загрузка администраторов из файла
 	file, err := os.Open(adminFilePath)
 	if err != nil {
@@ -160,14 +202,14 @@ func NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID string
 		log.Printf("Предупреждение: не удалось получить курс BTC: %v", err)
 	}
 
-	// Запускаем обновление цен
-	go r.StartPriceUpdater()
+	r.scheduler = NewScheduler(r)
 
-	r.stopResetChan = make(chan struct{})
-	go r.startDailyReset()
 	// Загрузка cinema options
 	r.LoadCinemaOptions()
 
+	// Оформление редкостей (эмодзи и цвета, переопределённые админами)
+	r.applyRarityStyle()
+
 	// Инициализация KKI
 	r.Kki, err = NewKKI(r.ctx)
 	if err != nil {
@@ -181,8 +223,29 @@ func NewRanking(adminFilePath, redisAddr, floodChannelID, cinemaChannelID string
 
 	// Инициализация банка кейсов
 	r.initializeCaseBank()
-	// Запуск обновления банка кейсов каждые 10 минут
-	go r.StartBitcoinUpdater() // <- ДОБАВЬТЕ ЭТУ СТРОКУ
+
+	// Встроенный HTTP-сервер: кэш картинок NFT (IMAGE_PUBLIC_URL), оверлей для стримов (OVERLAY_ENABLED)
+	// и публичная таблица лидеров (LEADERBOARD_ENABLED)
+	r.images = NewImageCache()
+	overlayEnabled := os.Getenv("OVERLAY_ENABLED") == "true"
+	leaderboardEnabled := os.Getenv("LEADERBOARD_ENABLED") == "true"
+	if r.images != nil || overlayEnabled || leaderboardEnabled {
+		r.web = NewWebServer()
+		if r.images != nil {
+			r.images.Register(r.web)
+		}
+		if overlayEnabled {
+			r.overlay = NewOverlayHub()
+			r.overlay.Register(r.web, r)
+		}
+		if leaderboardEnabled {
+			r.web.Handle("/leaderboard", newLeaderboardPage(r))
+		}
+		r.web.Start()
+	}
+
+	// Фоновые задачи: курс BTC, цены NFT, ежедневный сброс. Запускаются в StartScheduler
+	r.registerDefaultJobs()
 
 	return r, nil
 }
@@ -242,93 +305,7 @@ func (r *Ranking) GetUserInventory(userID string) UserInventory {
 func (r *Ranking) SaveUserInventory(userID string, inv UserInventory) {
 	jsonData, _ := json.Marshal(inv)
 	r.redis.Set(r.ctx, "inventory:"+userID, jsonData, 0)
-}
-
-// HandleInventoryCommand отображает инвентарь пользователя
-func (r *Ranking) HandleInventoryCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	log.Printf("Starting HandleInventoryCommand for user %s", m.Author.ID)
-	inv := r.GetUserInventory(m.Author.ID)
-	if len(inv) == 0 {
-		log.Printf("Inventory empty for user %s", m.Author.ID)
-		_, err := s.ChannelMessageSend(m.ChannelID, "🎒 **Ваш инвентарь пуст** ══════\nНичего нет, Император ждёт добычи! 😢")
-		if err != nil {
-			log.Printf("Error sending empty inventory message: %v", err)
-		}
-		return
-	}
-
-	var lines []string
-	var totalValue int
-	for nftID, count := range inv {
-		nft, ok := r.Kki.nfts[nftID]
-		if !ok {
-			log.Printf("Warning: NFT %s not found for user %s", nftID, m.Author.ID)
-			continue
-		}
-		value := r.CalculateNFTPrice(nft) * count
-		totalValue += value
-		rarityEmoji := RarityEmojis[nft.Rarity]
-		lines = append(lines, fmt.Sprintf("%s **%s** (x%d)\n📌 ID: %s\n💰 Цена: %d | %s", rarityEmoji, nft.Name, count, nftID, value/count, nft.Rarity))
-	}
-	sort.Strings(lines)
-
-	if len(lines) == 0 {
-		log.Printf("No valid NFTs found in inventory for user %s", m.Author.ID)
-		_, err := s.ChannelMessageSend(m.ChannelID, "🎒 **Ваш инвентарь пуст** ══════\nНичего нет, Император ждёт добычи! 😢")
-		if err != nil {
-			log.Printf("Error sending empty inventory message: %v", err)
-		}
-		return
-	}
-
-	// Split lines into chunks to avoid exceeding Discord's 6000-character embed limit
-	const maxItemsPerEmbed = 10 // Adjust based on typical NFT description length
-	const maxEmbedSize = 6000   // Discord's maximum embed size
-	var embeds []*discordgo.MessageEmbed
-	var currentLines []string
-	currentSize := len("🎒 **Инвентарь** ══════\n") + len(fmt.Sprintf("Общая стоимость: 💰 %d\n\n", totalValue)) + len(fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username))
-
-	for _, line := range lines {
-		lineSize := len(line) + len("\n\n")                                                   // Account for separator
-		if len(currentLines) >= maxItemsPerEmbed || currentSize+lineSize > maxEmbedSize-500 { // 500 chars buffer
-			// Create embed for current chunk
-			embed := &discordgo.MessageEmbed{
-				Title:       fmt.Sprintf("🎒 **Инвентарь (Часть %d)** ══════", len(embeds)+1),
-				Description: fmt.Sprintf("Общая стоимость: 💰 %d\n\n%s", totalValue, strings.Join(currentLines, "\n\n")),
-				Color:       0x00FF00,
-				Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username)},
-			}
-			embeds = append(embeds, embed)
-			log.Printf("Created embed %d with %d items, estimated size: %d chars", len(embeds), len(currentLines), currentSize)
-			currentLines = []string{}
-			currentSize = len("🎒 **Инвентарь** ══════\n") + len(fmt.Sprintf("Общая стоимость: 💰 %d\n\n", totalValue)) + len(fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username))
-		}
-		currentLines = append(currentLines, line)
-		currentSize += lineSize
-	}
-
-	// Add the final chunk
-	if len(currentLines) > 0 {
-		embed := &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("🎒 **Инвентарь (Часть %d)** ══════", len(embeds)+1),
-			Description: fmt.Sprintf("Общая стоимость: 💰 %d\n\n%s", totalValue, strings.Join(currentLines, "\n\n")),
-			Color:       0x00FF00,
-			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username)},
-		}
-		embeds = append(embeds, embed)
-		log.Printf("Created final embed %d with %d items, estimated size: %d chars", len(embeds), len(currentLines), currentSize)
-	}
-
-	// Send all embeds
-	for i, embed := range embeds {
-		_, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
-		if err != nil {
-			log.Printf("Error sending inventory embed %d for user %s: %v", i+1, m.Author.ID, err)
-			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка при отображении инвентаря! Попробуйте позже.")
-			return
-		}
-	}
-	log.Printf("Inventory sent successfully for user %s in %d embeds", m.Author.ID, len(embeds))
+	r.setTopScore(topCollectorsKey, userID, len(inv))
 }
 
 // HandleSellCommand !sell <nftID> <count>
@@ -367,7 +344,7 @@ func (r *Ranking) HandleSellCommand(s *discordgo.Session, m *discordgo.MessageCr
 	cancelID := fmt.Sprintf("sell_cancel_%s", m.Author.ID)
 	embed := &discordgo.MessageEmbed{
 		Title:       "🃏 **Подтверждение продажи** ══════",
-		Description: fmt.Sprintf("Вы хотите продать %d x %s **%s** (ID для передачи и продажи: %s) за 💰 %d кредитов?", count, RarityEmojis[nft.Rarity], nft.Name, nftID, sellPrice),
+		Description: fmt.Sprintf("Вы хотите продать %d x %s **%s** (ID для передачи и продажи: %s) за %s?", count, RarityEmojis[nft.Rarity], nft.Name, nftID, formatCredits(sellPrice)),
 		Color:       RarityColors[nft.Rarity],
 		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username)},
 	}
@@ -590,20 +567,21 @@ func (r *Ranking) HandleSellDuplicatesConfirm(s *discordgo.Session, i *discordgo
 			delete(inv, dup.NFTID)
 		}
 		nft := r.Kki.nfts[dup.NFTID]
+		r.recordNFTSale(dup.NFTID, nft.Price)
 		soldItems = append(soldItems, fmt.Sprintf("%s **%s** (x%d)", RarityEmojis[nft.Rarity], nft.Name, dup.Count))
 	}
 	r.SaveUserInventory(userID, inv)
 
 	// Начисляем кредиты
-	r.UpdateRating(userID, sellData.TotalSum)
+	r.UpdateRatingFrom(userID, sellData.TotalSum, "nft_sale", "")
 
 	// Логируем операцию
-	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** продал дубликаты NFT за 💰 %d кредитов: %s", i.Member.User.Username, sellData.TotalSum, strings.Join(soldItems, ", ")))
+	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** продал дубликаты NFT за %s: %s", i.Member.User.Username, formatCredits(sellData.TotalSum), strings.Join(soldItems, ", ")))
 
 	// Обновляем сообщение
 	embed := &discordgo.MessageEmbed{
 		Title:       "🛒 **Продажа дубликатов завершена** ══════",
-		Description: fmt.Sprintf("✅ **Продано** за 💰 %d кредитов:\n%s", sellData.TotalSum, strings.Join(soldItems, "\n")),
+		Description: fmt.Sprintf("✅ **Продано** за %s:\n%s", formatCredits(sellData.TotalSum), strings.Join(soldItems, "\n")),
 		Color:       0x00FF00,
 		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", i.Member.User.Username)},
 	}
@@ -621,7 +599,7 @@ func (r *Ranking) HandleSellDuplicatesConfirm(s *discordgo.Session, i *discordgo
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("✅ **Продано** за 💰 %d кредитов!", sellData.TotalSum),
+			Content: fmt.Sprintf("✅ **Продано** за %s!", formatCredits(sellData.TotalSum)),
 		},
 	})
 	if err != nil {
@@ -746,17 +724,18 @@ func (r *Ranking) HandleSellConfirm(s *discordgo.Session, i *discordgo.Interacti
 	r.SaveUserInventory(userID, inv)
 
 	// Начисление кредитов
-	r.UpdateRating(userID, sellPrice)
+	r.UpdateRatingFrom(userID, sellPrice, "nft_sale", "")
+	r.recordNFTSale(nftID, sellPrice/count)
 
 	// Отправка лога
 	nft := r.Kki.nfts[nftID]
-	r.LogCreditOperation(s, fmt.Sprintf("🃏 **%s** продал %d x %s **%s** (ID: %s) за 💰 %d кредитов.", i.Member.User.Username, count, RarityEmojis[nft.Rarity], nft.Name, nftID, sellPrice))
+	r.LogCreditOperation(s, fmt.Sprintf("🃏 **%s** продал %d x %s **%s** (ID: %s) за %s.", i.Member.User.Username, count, RarityEmojis[nft.Rarity], nft.Name, nftID, formatCredits(sellPrice)))
 
 	// Обновление сообщения для удаления кнопок
 	// В HandleSellConfirm тоже обновляем описание
 	embed := &discordgo.MessageEmbed{
 		Title:       "🃏 **Продажа завершена** ══════",
-		Description: fmt.Sprintf("✅ **Продано** %d x %s **%s** (ID: %s) за 💰 %d кредитов!", count, RarityEmojis[nft.Rarity], nft.Name, nftID, sellPrice),
+		Description: fmt.Sprintf("✅ **Продано** %d x %s **%s** (ID: %s) за %s!", count, RarityEmojis[nft.Rarity], nft.Name, nftID, formatCredits(sellPrice)),
 		Color:       RarityColors[nft.Rarity],
 		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", i.Member.User.Username)},
 	}
@@ -774,7 +753,7 @@ func (r *Ranking) HandleSellConfirm(s *discordgo.Session, i *discordgo.Interacti
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("✅ **Продано** %d x %s **%s** (ID: %s) за 💰 %d кредитов!", count, RarityEmojis[nft.Rarity], nft.Name, nftID, sellPrice),
+			Content: fmt.Sprintf("✅ **Продано** %d x %s **%s** (ID: %s) за %s!", count, RarityEmojis[nft.Rarity], nft.Name, nftID, formatCredits(sellPrice)),
 		},
 	})
 
@@ -864,9 +843,10 @@ func (r *Ranking) HandleTradeNFTCommand(s *discordgo.Session, m *discordgo.Messa
 	targetInv := r.GetUserInventory(targetID)
 	targetInv[nftID] += count
 	r.SaveUserInventory(targetID, targetInv)
+	ledgerID := r.recordNFTMutation("trade", m.Author.ID, m.Author.ID, targetID, map[string]int{nftID: count})
 
 	// Ответ
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Передано** %d x 🃏 **%s** (ID для передачи и продажи: %s) пользователю <@%s>.", count, nft.Name, nftID, targetID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Передано** %d x 🃏 **%s** (ID для передачи и продажи: %s) пользователю <@%s>.%s", count, nft.Name, nftID, targetID, ledgerRef(ledgerID)))
 }
 
 // HandleCaseTradeCommand !case_trade <@user> <caseID> <count>
@@ -923,25 +903,22 @@ func (r *Ranking) HandleCaseTradeCommand(s *discordgo.Session, m *discordgo.Mess
 		return
 	}
 
-	// Обновление кредитов
-	r.UpdateRating(m.Author.ID, -price)
-	r.UpdateRating(sellerID, price)
-
-	// Обновление инвентаря
-	buyerInv := r.Kki.GetUserCaseInventory(r, m.Author.ID)
-	buyerInv[caseID] += count
-	r.Kki.SaveUserCaseInventory(r, m.Author.ID, buyerInv)
-
-	sellerInv[caseID] -= count
-	if sellerInv[caseID] == 0 {
-		delete(sellerInv, caseID)
+	// Кредиты покупателя блокируются в эскроу, кейсы продавца — когда он подтвердит сделку
+	trade := &EscrowTrade{
+		ID:           generateGameID(m.Author.ID),
+		Kind:         "case_trade",
+		Initiator:    EscrowHold{Owner: m.Author.ID, Reason: "case_trade", Credits: price},
+		Counterparty: EscrowHold{Owner: sellerID, Reason: "case_trade", Cases: map[string]int{caseID: count}},
+		Summary:      fmt.Sprintf("%d x 📦 **%s** (ID: %s) за %s", count, kase.Name, caseID, formatCredits(price)),
+	}
+	if err := r.escrowOpenTrade(trade); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Не удалось заблокировать кредиты:** "+err.Error())
+		return
+	}
+	if err := r.sendEscrowTrade(s, m.ChannelID, trade); err != nil {
+		log.Printf("Не удалось отправить предложение сделки %s: %v", trade.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ **Не удалось создать сделку, кредиты возвращены.**")
 	}
-	r.Kki.SaveUserCaseInventory(r, sellerID, sellerInv)
-
-	// Лог операции
-	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** купил %d x 📦 **%s** (ID: %s) у <@%s> за 💰 %d кредитов.", m.Author.Username, count, kase.Name, caseID, sellerID, price))
-
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🛒 **Куплено** %d x 📦 **%s** (ID для открытия/передачи: %s) у <@%s> за 💰 %d кредитов.", count, kase.Name, caseID, sellerID, price))
 }
 
 // HandleOpenCaseCommand !open_case <caseID>
@@ -961,33 +938,24 @@ func (r *Ranking) HandleOpenCaseCommand(s *discordgo.Session, m *discordgo.Messa
 		return
 	}
 
-	// Проверка инвентаря кейсов
+	// Проверка: кейс есть, лимит не исчерпан, в кейсе есть NFT
 	userCaseInv := r.Kki.GetUserCaseInventory(r, m.Author.ID)
 	if userCaseInv[caseID] < 1 {
 		s.ChannelMessageSend(m.ChannelID, "❌ **У вас нет этого кейса.**")
 		return
 	}
-	userCaseInv[caseID]--
-	if userCaseInv[caseID] == 0 {
-		delete(userCaseInv, caseID)
-	}
-	r.Kki.SaveUserCaseInventory(r, m.Author.ID, userCaseInv)
-
-	// Проверка дневного лимита
 	key := fmt.Sprintf("case_limit:%s:%s", m.Author.ID, time.Now().Format("2006-01-02"))
-	opened, _ := r.redis.Get(r.ctx, key).Int()
-	if opened >= 5 {
-		s.ChannelMessageSend(m.ChannelID, "❌ **Достигнут дневной лимит (5 кейсов в день).**")
+	if opened, _ := r.redis.Get(r.ctx, key).Int(); opened >= dailyCaseOpenLimit {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **Достигнут дневной лимит (%d кейсов в день).**", dailyCaseOpenLimit))
 		return
 	}
-	r.redis.Incr(r.ctx, key)
-	r.redis.Expire(r.ctx, key, 24*time.Hour)
-
-	// Начало анимации
-	animMsg, _ := s.ChannelMessageSend(m.ChannelID, "🎰 **Открываем кейс...**")
 	collections := strings.Split(kase.ContainedCollections, ",")
+	unreleased := r.unreleasedCollections()
 	var possibleNFTs []NFT
 	for _, nft := range r.Kki.nfts {
+		if unreleased[nft.Collection] {
+			continue
+		}
 		for _, col := range collections {
 			if nft.Collection == col && (caseID != "daily_case" || nft.Collection != "holiday") {
 				possibleNFTs = append(possibleNFTs, nft)
@@ -996,7 +964,30 @@ func (r *Ranking) HandleOpenCaseCommand(s *discordgo.Session, m *discordgo.Messa
 		}
 	}
 	if len(possibleNFTs) == 0 {
-		s.ChannelMessageEdit(m.ChannelID, animMsg.ID, "❌ **В кейсе нет NFT.**")
+		s.ChannelMessageSend(m.ChannelID, "❌ **В кейсе нет NFT.**")
+		return
+	}
+
+	// Резервирование: токен открытия, слот дневного лимита и сам кейс в эскроу
+	token := m.ID
+	claimed, err := r.claimCaseOpening(token, m.Author.ID, caseID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ **Не удалось открыть кейс, попробуй позже.**")
+		return
+	}
+	if !claimed {
+		return
+	}
+	if !r.reserveCaseOpenSlot(key) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **Достигнут дневной лимит (%d кейсов в день).**", dailyCaseOpenLimit))
+		return
+	}
+	holdID := "case_open:" + token
+	hold := EscrowHold{ID: holdID, Owner: m.Author.ID, Reason: "open_case " + caseID, Cases: map[string]int{caseID: 1}}
+	if err := r.escrowReserve(hold, caseOpenHoldTTL); err != nil {
+		r.redis.Decr(r.ctx, key)
+		log.Printf("Не удалось зарезервировать кейс %s для %s: %v", caseID, m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ **У вас нет этого кейса.**")
 		return
 	}
 
@@ -1006,6 +997,15 @@ func (r *Ranking) HandleOpenCaseCommand(s *discordgo.Session, m *discordgo.Messa
 		dropped = append(dropped, r.rollNFT(possibleNFTs))
 	}
 
+	// Начало анимации
+	animMsg, err := s.ChannelMessageSend(m.ChannelID, "🎰 **Открываем кейс...**")
+	if err != nil {
+		log.Printf("Не удалось начать открытие кейса %s для %s: %v", caseID, m.Author.ID, err)
+		r.escrowRollback(holdID)
+		r.redis.Decr(r.ctx, key)
+		return
+	}
+
 	// Анимация в горутине
 	go func() {
 		rarities := []string{"Common", "Rare", "Super-rare", "Epic", "Nephrite", "Exotic", "Legendary"}
@@ -1033,7 +1033,7 @@ func (r *Ranking) HandleOpenCaseCommand(s *discordgo.Session, m *discordgo.Messa
 				Title:       fmt.Sprintf("🎉 **Выпало**: %s **%s**", RarityEmojis[nft.Rarity], nft.Name),
 				Description: fmt.Sprintf("**ID для передачи и продажи**: %s\n**Редкость**: %s\n**Описание**: %s\n**Дата выпуска**: %s\n**Цена**: 💰 %d\n**Коллекция**: %s%s", nft.ID, nft.Rarity, nft.Description, nft.ReleaseDate, nft.Price, nft.Collection, newTag),
 				Color:       RarityColors[nft.Rarity],
-				Image:       &discordgo.MessageEmbedImage{URL: nft.ImageURL},
+				Image:       &discordgo.MessageEmbedImage{URL: r.NFTImageURL(nft)},
 				Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", m.Author.Username)},
 			}
 			msg, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
@@ -1050,11 +1050,42 @@ func (r *Ranking) HandleOpenCaseCommand(s *discordgo.Session, m *discordgo.Messa
 			lines = append(lines, fmt.Sprintf("%s **%s** (ID: %s)", RarityEmojis[nft.Rarity], nft.Name, nft.ID))
 			time.Sleep(1 * time.Second)
 		}
-		r.SaveUserInventory(m.Author.ID, inv)
+		if _, err := r.escrowCommit(holdID); err != nil {
+			log.Printf("Открытие кейса %s пользователем %s не подтверждено: %v", caseID, m.Author.ID, err)
+			r.redis.Decr(r.ctx, key)
+			s.ChannelMessageSend(m.ChannelID, "❌ **Открытие кейса прервано, кейс возвращён в инвентарь.**")
+			return
+		}
+		if !r.markCaseOpeningCredited(token, m.Author.ID, caseID) {
+			return
+		}
+		r.recordWeeklyCaseOpening(caseID)
+		// Инвентарь перечитывается под r.mu: за время анимации он мог измениться
+		items := make(map[string]int)
+		for _, nft := range dropped {
+			items[nft.ID]++
+		}
+		r.addCaseDrops(m.Author.ID, items)
+		if ledgerID := r.recordNFTMutation("case_open", m.Author.ID, "", m.Author.ID, items); ledgerID != 0 {
+			log.Printf("Кейс %s открыт %s, журнал NFT #%d", caseID, m.Author.ID, ledgerID)
+		}
 		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎉 **Вы получили** ══════\n%s", strings.Join(lines, "\n")))
+		r.showcaseBigPulls(s, m.Author.ID, kase, dropped)
+		r.awardJadeForDrops(s, m.Author.ID, dropped)
 	}()
 }
 
+// addCaseDrops зачисляет выпавшие из кейса NFT в инвентарь пользователя.
+func (r *Ranking) addCaseDrops(userID string, items map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv := r.GetUserInventory(userID)
+	for nftID, count := range items {
+		inv[nftID] += count
+	}
+	r.SaveUserInventory(userID, inv)
+}
+
 // rollNFT выбирает случайный NFT с учётом редкости
 func (r *Ranking) rollNFT(possible []NFT) NFT {
 	totalProb := 0.0
@@ -1112,45 +1143,6 @@ func (r *Ranking) HandleDailyCaseCommand(s *discordgo.Session, m *discordgo.Mess
 	s.ChannelMessageSend(m.ChannelID, "✅ **Вы получили ежедневный кейс!** Используйте `/open_case daily_case` для открытия.")
 }
 
-// HandleBuyCaseFromCommand !buy_case_from <@user> <caseID> <count>
-func (r *Ranking) HandleBuyCaseFromCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
-	parts := strings.Split(command, " ")
-	if len(parts) < 4 {
-		s.ChannelMessageSend(m.ChannelID, "Использование: /buy_case_from @user <caseID> <count>")
-		return
-	}
-	sellerID := strings.Trim(parts[1], "<@!>")
-	caseID := parts[2]
-	count, _ := strconv.Atoi(parts[3])
-
-	kase, ok := r.Kki.cases[caseID]
-	if !ok {
-		s.ChannelMessageSend(m.ChannelID, "Некорректный кейс.")
-		return
-	}
-
-	sellerInv := r.Kki.GetUserCaseInventory(r, sellerID)
-	if sellerInv[caseID] < count {
-		s.ChannelMessageSend(m.ChannelID, "У продавца недостаточно кейсов.")
-		return
-	}
-
-	price := kase.Price * count
-	r.LogCreditOperation(s, fmt.Sprintf("%s купил %d x %s у %s за %d кредитов", m.Author.Username, count, kase.Name, sellerID, price))
-
-	buyerInv := r.Kki.GetUserCaseInventory(r, m.Author.ID)
-	buyerInv[caseID] += count
-	r.Kki.SaveUserCaseInventory(r, m.Author.ID, buyerInv)
-
-	sellerInv[caseID] -= count
-	if sellerInv[caseID] == 0 {
-		delete(sellerInv, caseID)
-	}
-	r.Kki.SaveUserCaseInventory(r, sellerID, sellerInv)
-
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Куплено %d x %s у <@%s> за %d кредитов.", count, kase.Name, sellerID, price))
-}
-
 // HandleAdminGiveCase !admin_give_case <userID> <caseID>
 func (r *Ranking) HandleAdminGiveCase(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
 	if len(m.Mentions) != 1 {
@@ -1204,8 +1196,9 @@ func (r *Ranking) HandleAdminGiveNFT(s *discordgo.Session, m *discordgo.MessageC
 	inv := r.GetUserInventory(userID)
 	inv[nftID] += count
 	r.SaveUserInventory(userID, inv)
+	ledgerID := r.recordNFTMutation("admin_give", m.Author.ID, "", userID, map[string]int{nftID: count})
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Выдано** %d x 🃏 **%s** (ID для передачи и продажи: %s) пользователю <@%s>.", count, nft.Name, nftID, userID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Выдано** %d x 🃏 **%s** (ID для передачи и продажи: %s) пользователю <@%s>.%s", count, nft.Name, nftID, userID, ledgerRef(ledgerID)))
 }
 
 // HandleAdminRemoveNFT !a_remove_nft <@user> <nftID> <count>
@@ -1244,8 +1237,9 @@ func (r *Ranking) HandleAdminRemoveNFT(s *discordgo.Session, m *discordgo.Messag
 		delete(inv, nftID)
 	}
 	r.SaveUserInventory(userID, inv)
+	ledgerID := r.recordNFTMutation("admin_remove", m.Author.ID, userID, "", map[string]int{nftID: count})
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Удалено** %d x 🃏 **%s** (ID для передачи и продажи: %s) у <@%s>.", count, nft.Name, nftID, userID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Удалено** %d x 🃏 **%s** (ID для передачи и продажи: %s) у <@%s>.%s", count, nft.Name, nftID, userID, ledgerRef(ledgerID)))
 }
 
 // HandleAdminHolidayCase !a_holiday_case <@user> <count>
@@ -1291,10 +1285,17 @@ func (r *Ranking) HandleShowNFTCommand(s *discordgo.Session, m *discordgo.Messag
 		Title:       fmt.Sprintf("🃏 %s **%s**", RarityEmojis[nft.Rarity], nft.Name),
 		Description: fmt.Sprintf("**ID для передачи и продажи**: %s\n**Описание**: %s\n**Редкость**: %s\n**Дата выпуска**: %s\n**Цена**: 💰 %d\n**Коллекция**: %s", nftID, nft.Description, nft.Rarity, nft.ReleaseDate, nft.Price, nft.Collection),
 		Color:       RarityColors[nft.Rarity],
-		Image:       &discordgo.MessageEmbedImage{URL: nft.ImageURL},
+		Image:       &discordgo.MessageEmbedImage{URL: r.NFTImageURL(nft)},
+		Fields:      r.nftDetailFields(nft),
 		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Похвастался: %s | Славь Императора! 👑", m.Author.Username)},
 	}
-	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embed:      embed,
+		Components: nftDetailComponents(nftID),
+	})
+	if err != nil {
+		log.Printf("Не удалось показать NFT %s: %v", nftID, err)
+	}
 }
 
 // ClearAllUserNFTs очищает все NFT и кейсы для теста
@@ -1356,7 +1357,7 @@ func (r *Ranking) HandleCaseInventoryCommand(s *discordgo.Session, m *discordgo.
 	// Проверка дневного лимита
 	key := fmt.Sprintf("case_limit:%s:%s", m.Author.ID, time.Now().Format("2006-01-02"))
 	opened, _ := r.redis.Get(r.ctx, key).Int()
-	limitMsg := fmt.Sprintf("🔄 **Лимит открытия кейсов сегодня**: %d/5", opened)
+	limitMsg := fmt.Sprintf("🔄 **Лимит открытия кейсов сегодня**: %d/%d", opened, dailyCaseOpenLimit)
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "📦 **Инвентарь кейсов** ══════",
@@ -1431,8 +1432,14 @@ func (r *Ranking) HandleAdminGiveHolidayCaseAll(s *discordgo.Session, m *discord
 	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Выдано** %d x 📦 **Праздничный кейс** (ID для открытия/передачи: holiday_case) %d участникам сервера!", count, successCount))
 }
 
-// HandleCaseHelpCommand !case_help - обновленная версия
-func (r *Ranking) HandleCaseHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+// HandleCaseHelpCommand !case_help [--admin] - обновленная версия
+func (r *Ranking) HandleCaseHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	showAdmin := hasAdminFlag(command)
+	if showAdmin && !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Полная справка доступна только админам! 🔒")
+		return
+	}
+
 	embed := &discordgo.MessageEmbed{
 		Title:       "📦 **Помощь по кейсам, NFT и экономике** ══════",
 		Description: "Славь Императора! 👑 Динамическая экономика привязана к курсу BTC",
@@ -1450,19 +1457,23 @@ func (r *Ranking) HandleCaseHelpCommand(s *discordgo.Session, m *discordgo.Messa
 			},
 			{
 				Name:   "🃏 **NFT и торговля**",
-				Value:  "```/inventory - Мои NFT\n/nft_show <ID> - Показать NFT\n/sell <ID> <count> - Продать NFT\n/sell_duplicates - Продать все дубликаты\n/trade_nft @user <ID> <count> - Передать NFT\n/top_inventories - Топ-10 инвентарей\n/market - Рыночные цены (скоро)```",
+				Value:  "```/inventory - Мои NFT\n/nft_show <ID> - Показать NFT\n/sell <ID> <count> - Продать NFT\n/sell_duplicates - Продать все дубликаты\n/trade_nft @user <ID> <count> - Передать NFT\n/top_inventories - Топ-10 инвентарей\n/market - Рынок игроков```",
 				Inline: true,
 			},
-			{
-				Name:   "👑 **Админские команды**",
-				Value:  "```/sync_nfts - Синхронизация с Sheets\n/a_give_case @user <ID> - Выдать кейс\n/a_give_nft @user <ID> <count> - Выдать NFT\n/a_remove_nft @user <ID> <count> - Удалить NFT\n/a_refresh_bank - Обновить банк кейсов\n/a_reset_case_limits - Сбросить лимиты\n/test_clear_all_nfts - Очистить всё```",
-				Inline: false,
-			},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: fmt.Sprintf("Вызвал: %s | Редкие NFT зависят от курса BTC!", m.Author.Username),
 		},
 	}
+	if showAdmin {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "👑 **Админские команды**",
+			Value:  "```/sync_nfts - Синхронизация с Sheets\n/a_give_case @user <ID> - Выдать кейс\n/a_give_nft @user <ID> <count> - Выдать NFT\n/a_remove_nft @user <ID> <count> - Удалить NFT\n/a_refresh_bank - Обновить банк кейсов\n/a_reset_case_limits - Сбросить лимиты\n/test_clear_all_nfts - Очистить всё```",
+			Inline: false,
+		})
+	} else if r.IsAdmin(m.Author.ID) {
+		embed.Footer.Text += " | 👑 /case_help --admin"
+	}
 	s.ChannelMessageSendEmbed(m.ChannelID, embed)
 }
 
@@ -1471,10 +1482,13 @@ func (r *Ranking) initializeCaseBank() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Получаем все доступные кейсы
+	// Получаем все доступные кейсы (кроме кейсов запуска невышедших коллекций)
+	unreleased := r.unreleasedCases()
 	allCases := make([]string, 0, len(r.Kki.cases))
 	for caseID := range r.Kki.cases {
-		allCases = append(allCases, caseID)
+		if !unreleased[caseID] {
+			allCases = append(allCases, caseID)
+		}
 	}
 
 	// Выбираем 2 случайных кейса
@@ -1593,10 +1607,13 @@ func (r *Ranking) refreshCaseBank() {
 
 	// Обновляем если прошло 12 часов ИЛИ если банк пустой
 	if time.Since(r.caseBank.LastUpdated) >= 12*time.Hour || len(r.caseBank.Cases) == 0 {
-		// Получаем все доступные кейсы из таблицы
+		// Получаем все доступные кейсы из таблицы (кроме кейсов запуска невышедших коллекций)
+		unreleased := r.unreleasedCases()
 		allCases := make([]string, 0, len(r.Kki.cases))
 		for caseID := range r.Kki.cases {
-			allCases = append(allCases, caseID)
+			if !unreleased[caseID] {
+				allCases = append(allCases, caseID)
+			}
 		}
 
 		// Рандомно выбираем 2 кейса (если меньше 2, выбираем все)
@@ -1710,7 +1727,7 @@ func (r *Ranking) HandleBuyCaseBankCommand(s *discordgo.Session, m *discordgo.Me
 	price := kase.Price * count
 	buyerCoins := r.GetRating(m.Author.ID)
 	if buyerCoins < price {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **Недостаточно кредитов. Нужно: %d, у вас: %d.**", price, buyerCoins))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **Недостаточно кредитов. Нужно: %s, у вас: %s.**", formatCredits(price), formatCredits(buyerCoins)))
 		return
 	}
 
@@ -1735,14 +1752,15 @@ func (r *Ranking) HandleBuyCaseBankCommand(s *discordgo.Session, m *discordgo.Me
 	}
 
 	// Обновление кредитов
-	r.UpdateRating(m.Author.ID, -price)
+	r.UpdateRatingFrom(m.Author.ID, -price, "case_buy", "")
 	r.redis.IncrBy(r.ctx, key, int64(count))
 	r.redis.Expire(r.ctx, key, 24*time.Hour)
+	r.recordCaseBankTurnover(count, price)
 
 	// Лог операции
-	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** купил %d x 📦 **%s** (ID: %s) из банка за 💰 %d кредитов.", m.Author.Username, count, kase.Name, caseID, price))
+	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** купил %d x 📦 **%s** (ID: %s) из банка за %s.", m.Author.Username, count, kase.Name, caseID, formatCredits(price)))
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Куплено** %d x 📦 **%s** (ID: %s) за 💰 %d кредитов!", count, kase.Name, caseID, price))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **Куплено** %d x 📦 **%s** (ID: %s) за %s!", count, kase.Name, caseID, formatCredits(price)))
 }
 
 // HandleResetCaseLimitsCommand !a_reset_case_limits
@@ -1803,37 +1821,6 @@ func (r *Ranking) HandleResetCaseLimitsCommand(s *discordgo.Session, m *discordg
 	log.Printf("Reset %d limits for all users", totalDeleted)
 }
 
-// startDailyReset запускает горутину для сброса лимитов в 4:00 по Красноярску
-func (r *Ranking) startDailyReset() {
-	// Загружаем часовой пояс Красноярска
-	loc, err := time.LoadLocation("Asia/Krasnoyarsk")
-	if err != nil {
-		log.Printf("Ошибка загрузки часового пояса Asia/Krasnoyarsk: %v", err)
-		return
-	}
-
-	for {
-		// Вычисляем время до следующего сброса (4:00 следующего дня)
-		now := time.Now().In(loc)
-		nextReset := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, loc)
-		if now.After(nextReset) || now.Equal(nextReset) {
-			nextReset = nextReset.Add(24 * time.Hour)
-		}
-		timeUntilReset := nextReset.Sub(now)
-
-		// Ожидаем до следующего сброса или сигнала остановки
-		select {
-		case <-time.After(timeUntilReset):
-			// Выполняем сброс всех лимитов
-			r.resetAllLimits()
-			log.Printf("Автоматический сброс лимитов выполнен в %s", time.Now().In(loc).Format(time.RFC3339))
-		case <-r.stopResetChan:
-			log.Printf("Горутина сброса лимитов остановлена")
-			return
-		}
-	}
-}
-
 // resetAllLimits сбрасывает все лимиты (открытие, покупка, ежедневный кейс)
 func (r *Ranking) resetAllLimits() {
 	r.mu.Lock()
@@ -1871,76 +1858,172 @@ func (r *Ranking) resetAllLimits() {
 		r.redis.Del(r.ctx, key)
 		log.Printf("Автоматически удален ключ daily_case: %s", key)
 	}
+
+	// Сброс дневных лимитов ставок
+	keys, err = r.redis.Keys(r.ctx, "wager_limit:*").Result()
+	if err != nil {
+		log.Printf("Ошибка получения ключей wager_limit: %v", err)
+		return
+	}
+	for _, key := range keys {
+		r.redis.Del(r.ctx, key)
+		log.Printf("Автоматически удален ключ wager_limit: %s", key)
+	}
 }
 
-// Stop прекращает работу горутины сброса лимитов
+// Stop останавливает фоновые задачи планировщика
 func (r *Ranking) Stop() {
-	close(r.stopResetChan)
+	r.scheduler.Stop()
+	r.web.Stop()
+}
+
+// Параметры опроса курса BTC. Бесплатный тариф CoinGecko быстро отвечает 429, поэтому
+// курс кэшируется, а при ошибках CoinGecko отключается с экспоненциальной задержкой
+// и курс берётся с Binance.
+const (
+	btcCacheKey       = "bitcoin_price"
+	btcMinBackoff     = time.Minute
+	btcMaxBackoff     = 30 * time.Minute
+	btcRequestTimeout = 10 * time.Second
+)
+
+// btcHTTPClient — HTTP-клиент для запросов курса с таймаутом.
+var btcHTTPClient = &http.Client{Timeout: btcRequestTimeout}
+
+// errBTCRateLimited возвращается, когда CoinGecko ответил 429.
+type errBTCRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e errBTCRateLimited) Error() string {
+	return fmt.Sprintf("CoinGecko: превышен лимит запросов, повтор через %s", e.retryAfter)
+}
+
+// btcCacheTTL возвращает, сколько курс считается свежим. По умолчанию чуть меньше периода
+// bitcoin_updater, чтобы обновлял курс только он, а команды брали значение из кэша.
+func btcCacheTTL() time.Duration {
+	return time.Duration(envInt("BTC_CACHE_SECONDS", 270)) * time.Second
 }
 
 // GetBitcoinPrice получает текущий курс биткойна
 func (r *Ranking) GetBitcoinPrice() (float64, error) {
-	cacheKey := "bitcoin_price"
-
-	// Пробуем получить из кэша
-	cached, err := r.redis.Get(r.ctx, cacheKey).Result()
-	if err == nil {
-		cachedPrice, _ := strconv.ParseFloat(cached, 64)
-		// Если кэш свежий (менее 10 минут), используем его
-		if time.Since(r.BitcoinTracker.LastUpdate) < 10*time.Minute {
-			return cachedPrice, nil
+	bt := r.BitcoinTracker
+	bt.mu.Lock()
+	current, lastUpdate, backoffUntil := bt.CurrentPrice, bt.LastUpdate, bt.backoffUntil
+	bt.mu.Unlock()
+
+	// Свежий курс из памяти
+	if current > 0 && time.Since(lastUpdate) < btcCacheTTL() {
+		return current, nil
+	}
+	// После перезапуска берём курс из Redis, пока он не устарел
+	if current == 0 {
+		if cached, err := r.redis.Get(r.ctx, btcCacheKey).Float64(); err == nil && cached > 0 {
+			r.recordBitcoinPrice(cached, false)
+			return cached, nil
 		}
 	}
 
-	// Получаем свежий курс
-	resp, err := http.Get("https://api.coingecko.com/api/v3/simple/price?ids=bitcoin&vs_currencies=usd")
+	var price float64
+	err := fmt.Errorf("CoinGecko отключён до %s", backoffUntil.Format("15:04:05"))
+	if time.Now().After(backoffUntil) {
+		price, err = r.getBitcoinPriceFromCoinGecko()
+		r.updateCoinGeckoBackoff(err)
+	}
 	if err != nil {
-		log.Printf("Ошибка запроса к CoinGecko: %v", err)
-
-		// Fallback: используем последнее известное значение
-		if r.BitcoinTracker.CurrentPrice > 0 {
-			return r.BitcoinTracker.CurrentPrice, nil
+		// Запасной источник — Binance
+		var altErr error
+		price, altErr = r.getBitcoinPriceFromAlternative()
+		if altErr != nil {
+			log.Printf("Не удалось получить курс BTC: %v; Binance: %v", err, altErr)
+			r.ReportError("coingecko", fmt.Errorf("%v; Binance: %v", err, altErr))
+			if current > 0 {
+				return current, nil
+			}
+			return 0, err
 		}
+	}
+
+	r.recordBitcoinPrice(price, true)
+	return price, nil
+}
+
+// getBitcoinPriceFromCoinGecko запрашивает курс у CoinGecko.
+func (r *Ranking) getBitcoinPriceFromCoinGecko() (float64, error) {
+	resp, err := btcHTTPClient.Get("https://api.coingecko.com/api/v3/simple/price?ids=bitcoin&vs_currencies=usd")
+	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		log.Printf("CoinGecko API вернул статус: %d", resp.StatusCode)
-		if r.BitcoinTracker.CurrentPrice > 0 {
-			return r.BitcoinTracker.CurrentPrice, nil
-		}
-		return 0, fmt.Errorf("API вернул статус %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return 0, errBTCRateLimited{retryAfter: time.Duration(retryAfter) * time.Second}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("CoinGecko API вернул статус %d", resp.StatusCode)
 	}
 
 	var data map[string]map[string]float64
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Printf("Ошибка парсинга ответа CoinGecko: %v", err)
-		if r.BitcoinTracker.CurrentPrice > 0 {
-			return r.BitcoinTracker.CurrentPrice, nil
+		return 0, fmt.Errorf("ошибка парсинга ответа CoinGecko: %v", err)
+	}
+	price := data["bitcoin"]["usd"]
+	if price <= 0 {
+		return 0, fmt.Errorf("CoinGecko вернул пустой курс")
+	}
+	return price, nil
+}
+
+// updateCoinGeckoBackoff сбрасывает задержку после успешного запроса или увеличивает её после ошибки.
+// Задержка растёт вдвое с каждой ошибкой подряд (со случайной добавкой), но не меньше Retry-After.
+func (r *Ranking) updateCoinGeckoBackoff(err error) {
+	bt := r.BitcoinTracker
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if err == nil {
+		if bt.failures > 0 {
+			log.Printf("CoinGecko снова доступен после %d ошибок", bt.failures)
 		}
-		return 0, err
+		bt.failures = 0
+		bt.backoffUntil = time.Time{}
+		return
 	}
 
-	price := data["bitcoin"]["usd"]
+	bt.failures++
+	backoff := btcMinBackoff << min(bt.failures-1, 5)
+	if backoff > btcMaxBackoff {
+		backoff = btcMaxBackoff
+	}
+	backoff += time.Duration(rand.Int63n(int64(backoff / 4)))
+	if limited, ok := err.(errBTCRateLimited); ok && limited.retryAfter > backoff {
+		backoff = limited.retryAfter
+	}
+	bt.backoffUntil = time.Now().Add(backoff)
+	log.Printf("CoinGecko недоступен (%v), ошибка %d подряд — следующий запрос через %s, курс берём с Binance", err, bt.failures, backoff.Round(time.Second))
+}
 
-	// Обновляем трекер
-	r.BitcoinTracker.mu.Lock()
-	r.BitcoinTracker.PreviousPrice = r.BitcoinTracker.CurrentPrice
-	r.BitcoinTracker.CurrentPrice = price
-	r.BitcoinTracker.LastUpdate = time.Now()
+// recordBitcoinPrice сохраняет курс в трекер, а свежий курс — ещё и в историю и кэш Redis.
+func (r *Ranking) recordBitcoinPrice(price float64, fresh bool) {
+	bt := r.BitcoinTracker
+	bt.mu.Lock()
+	bt.PreviousPrice = bt.CurrentPrice
+	bt.CurrentPrice = price
+	if !fresh {
+		bt.mu.Unlock()
+		return
+	}
+	bt.LastUpdate = time.Now()
 
 	// Сохраняем в историю (последние 24 часа)
-	r.BitcoinTracker.PriceHistory = append(r.BitcoinTracker.PriceHistory, price)
-	if len(r.BitcoinTracker.PriceHistory) > 288 { // 288 записей = 24 часа (каждые 5 мин)
-		r.BitcoinTracker.PriceHistory = r.BitcoinTracker.PriceHistory[1:]
+	bt.PriceHistory = append(bt.PriceHistory, price)
+	if len(bt.PriceHistory) > 288 { // 288 записей = 24 часа (каждые 5 мин)
+		bt.PriceHistory = bt.PriceHistory[1:]
 	}
-	r.BitcoinTracker.mu.Unlock()
+	bt.mu.Unlock()
 
-	// Сохраняем в Redis на 10 минут
-	r.redis.Set(r.ctx, cacheKey, fmt.Sprintf("%.2f", price), 10*time.Minute)
-
-	return price, nil
+	r.redis.Set(r.ctx, btcCacheKey, fmt.Sprintf("%.2f", price), 10*time.Minute)
 }
 
 // Get24hAverage возвращает среднюю цену BTC за 24 часа
@@ -2084,36 +2167,17 @@ func min(a, b int) int {
 	return b
 }
 
-// StartBitcoinUpdater запускает обновление курса BTC каждые 5 минут
-func (r *Ranking) StartBitcoinUpdater() {
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				price, err := r.GetBitcoinPrice()
-				if err != nil {
-					log.Printf("Ошибка обновления курса BTC: %v", err)
-					continue
-				}
-				log.Printf("✅ Курс BTC обновлен: $%.2f", price)
-			case <-r.stopResetChan:
-				return
-			}
-		}
-	}()
-}
-
 // getBitcoinPriceFromAlternative получает курс с альтернативного API
 func (r *Ranking) getBitcoinPriceFromAlternative() (float64, error) {
 	// Попробуем Binance API
-	resp, err := http.Get("https://api.binance.com/api/v3/ticker/price?symbol=BTCUSDT")
+	resp, err := btcHTTPClient.Get("https://api.binance.com/api/v3/ticker/price?symbol=BTCUSDT")
 	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Binance API вернул статус %d", resp.StatusCode)
+	}
 
 	var binanceData struct {
 		Symbol string `json:"symbol"`
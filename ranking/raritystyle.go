@@ -0,0 +1,143 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Оформление редкостей: админы переопределяют эмодзи (в том числе кастомные эмодзи сервера)
+// и цвета embed'ов. Переопределения хранятся в хэше settings:rarity_style (emoji:<редкость>,
+// color:<редкость>) и применяются заменой RarityEmojis и RarityColors целиком, поэтому
+// действуют везде, где используются эти карты: инвентарь, дропы, статистика цен.
+const rarityStyleKey = "settings:rarity_style"
+
+// Исходное оформление редкостей, к которому возвращает reset.
+var (
+	defaultRarityEmojis = copyRarityMap(RarityEmojis)
+	defaultRarityColors = copyRarityMap(RarityColors)
+)
+
+// customEmojiPattern совпадает с кастомным эмодзи Discord: <:имя:ID> или <a:имя:ID>.
+var customEmojiPattern = regexp.MustCompile(`^<a?:\w{2,32}:\d{17,20}>$`)
+
+// copyRarityMap возвращает копию карты оформления.
+func copyRarityMap[V any](src map[string]V) map[string]V {
+	dst := make(map[string]V, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// applyRarityStyle собирает оформление из значений по умолчанию и переопределений из Redis.
+// Карты заменяются целиком, а не меняются на месте, чтобы не гоняться с читающими их обработчиками.
+func (r *Ranking) applyRarityStyle() {
+	overrides, err := r.redis.HGetAll(r.ctx, rarityStyleKey).Result()
+	if err != nil {
+		log.Printf("Не удалось загрузить оформление редкостей: %v", err)
+		return
+	}
+	emojis := copyRarityMap(defaultRarityEmojis)
+	colors := copyRarityMap(defaultRarityColors)
+	for field, value := range overrides {
+		kind, rarity, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		switch kind {
+		case "emoji":
+			emojis[rarity] = value
+		case "color":
+			if color, err := strconv.Atoi(value); err == nil {
+				colors[rarity] = color
+			}
+		}
+	}
+	RarityEmojis = emojis
+	RarityColors = colors
+}
+
+// parseRarityColor разбирает цвет в формате #RRGGBB или 0xRRGGBB.
+func parseRarityColor(value string) (int, bool) {
+	value = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "#"), "0x")
+	if len(value) != 6 {
+		return 0, false
+	}
+	color, err := strconv.ParseInt(value, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(color), true
+}
+
+// validRarityEmoji проверяет, похоже ли значение на эмодзи: кастомное эмодзи сервера или короткий символ.
+func validRarityEmoji(value string) bool {
+	return customEmojiPattern.MatchString(value) || (utf8.RuneCountInString(value) <= 8 && !strings.ContainsAny(value, " `*_~|<>@"))
+}
+
+// HandleRarityStyleCommand обрабатывает команду !a_rarity [<редкость> emoji|color <значение>|reset].
+func (r *Ranking) HandleRarityStyleCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_rarity: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/a_rarity` — текущее оформление, `/a_rarity <редкость> emoji <эмодзи>|reset`, `/a_rarity <редкость> color <#RRGGBB>|reset`"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		lines := make([]string, 0, len(RarityProbabilities))
+		for _, p := range RarityProbabilities {
+			lines = append(lines, fmt.Sprintf("%s **%s** — `#%06X`", RarityEmojis[p.Rarity], p.Rarity, RarityColors[p.Rarity]))
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+			Title:       "🎨 Оформление редкостей",
+			Description: strings.Join(lines, "\n"),
+			Color:       0xFFD700,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "/a_rarity <редкость> emoji|color <значение>|reset"},
+		})
+		return
+	}
+	if len(parts) != 4 || (parts[2] != "emoji" && parts[2] != "color") {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	rarity, ok := findRarity(parts[1])
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Неизвестная редкость. Доступны: Common, Rare, Super-rare, Epic, Nephrite, Exotic, Legendary.")
+		return
+	}
+	kind, value := parts[2], parts[3]
+	field := kind + ":" + rarity
+
+	var err error
+	switch {
+	case value == "reset":
+		err = r.redis.HDel(r.ctx, rarityStyleKey, field).Err()
+	case kind == "emoji":
+		if !validRarityEmoji(value) {
+			s.ChannelMessageSend(m.ChannelID, "❌ Это не похоже на эмодзи. Подойдёт обычный эмодзи или эмодзи сервера.")
+			return
+		}
+		err = r.redis.HSet(r.ctx, rarityStyleKey, field, value).Err()
+	default:
+		color, ok := parseRarityColor(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ Цвет указывается в формате `#RRGGBB`.")
+			return
+		}
+		err = r.redis.HSet(r.ctx, rarityStyleKey, field, color).Err()
+	}
+	if err != nil {
+		log.Printf("Не удалось сохранить оформление %s: %v", field, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	r.applyRarityStyle()
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("✅ Оформление **%s** обновлено: %s `#%06X`", rarity, RarityEmojis[rarity], RarityColors[rarity]),
+		Color:       RarityColors[rarity],
+	})
+}
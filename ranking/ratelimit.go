@@ -0,0 +1,215 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Общий лимит команд на игрока поверх кулдаунов отдельных команд: ведро на rate_limit_per_minute
+// токенов, пополняемое равномерно. Нажатия кнопок и отправка модальных окон расходуют то же ведро. Кто опустошил ведро, получает мут от бота, и каждый следующий
+// мут в течение суток длиннее предыдущего. Мут и счётчик нарушений хранятся в Redis и переживают
+// перезапуск, вёдра — в памяти. Администраторы не ограничиваются.
+
+// rateLimitMuteSteps — длительность мута за 1-е, 2-е, ... нарушение за сутки.
+var rateLimitMuteSteps = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour}
+
+// rateLimitStrikeTTL — через сколько забываются нарушения.
+const rateLimitStrikeTTL = 24 * time.Hour
+
+// tokenBucket — ведро токенов игрока.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiterIdle — через сколько без команд ведро гарантированно полное и удаляется из памяти.
+const limiterIdle = time.Minute
+
+// commandLimiter хранит вёдра игроков.
+type commandLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// newCommandLimiter создаёт пустой набор вёдер.
+func newCommandLimiter() *commandLimiter {
+	return &commandLimiter{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+}
+
+// take забирает токен из ведра игрока. perMinute — ёмкость ведра и скорость пополнения в минуту.
+func (l *commandLimiter) take(userID string, perMinute int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Ведро пополняется целиком за минуту, поэтому простаивающие дольше limiterIdle можно
+	// удалить: новое ведро создастся полным, как и было бы после пополнения
+	if now.Sub(l.lastSweep) > limiterIdle {
+		for id, bucket := range l.buckets {
+			if now.Sub(bucket.last) > limiterIdle {
+				delete(l.buckets, id)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &tokenBucket{tokens: float64(perMinute), last: now}
+		l.buckets[userID] = b
+	}
+	b.tokens = minFloat(float64(perMinute), b.tokens+now.Sub(b.last).Minutes()*float64(perMinute))
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reset наполняет ведро игрока (после снятия мута).
+func (l *commandLimiter) reset(userID string) {
+	l.mu.Lock()
+	delete(l.buckets, userID)
+	l.mu.Unlock()
+}
+
+// minFloat возвращает меньшее из двух чисел.
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// rateLimitMuteKey возвращает ключ мута игрока.
+func rateLimitMuteKey(userID string) string {
+	return "ratelimit:mute:" + userID
+}
+
+// rateLimitStrikesKey возвращает ключ счётчика нарушений игрока.
+func rateLimitStrikesKey(userID string) string {
+	return "ratelimit:strikes:" + userID
+}
+
+// CommandRateLimit возвращает лимит команд в минуту: настройка rate_limit_per_minute,
+// переменная RATE_LIMIT_PER_MINUTE или 20. 0 выключает лимит.
+func (r *Ranking) CommandRateLimit() int {
+	return max(r.GetIntSetting("rate_limit_per_minute", envInt("RATE_LIMIT_PER_MINUTE", 20)), 0)
+}
+
+// rateLimitHit учитывает команду игрока. limited — команду нужно проигнорировать; notice — текст
+// о только что выданном муте (пустой, если игрок уже был в муте и сообщать ничего не нужно).
+func (r *Ranking) rateLimitHit(s *discordgo.Session, userID string) (limited bool, notice string) {
+	perMinute := r.CommandRateLimit()
+	if perMinute == 0 || r.IsAdmin(userID) {
+		return false, ""
+	}
+	if ttl, err := r.redis.PTTL(r.ctx, rateLimitMuteKey(userID)).Result(); err == nil && ttl > 0 {
+		return true, ""
+	}
+	if r.limiter.take(userID, perMinute, time.Now()) {
+		return false, ""
+	}
+
+	strikes, err := r.redis.Incr(r.ctx, rateLimitStrikesKey(userID)).Result()
+	if err != nil {
+		log.Printf("Не удалось учесть нарушение лимита %s: %v", userID, err)
+		return true, ""
+	}
+	r.redis.Expire(r.ctx, rateLimitStrikesKey(userID), rateLimitStrikeTTL)
+	mute := rateLimitMuteSteps[min(int(strikes), len(rateLimitMuteSteps))-1]
+	if err := r.redis.Set(r.ctx, rateLimitMuteKey(userID), strikes, mute).Err(); err != nil {
+		log.Printf("Не удалось замутить %s: %v", userID, err)
+	}
+	r.limiter.reset(userID)
+	log.Printf("Игрок %s превысил лимит %d команд/мин, мут на %s (нарушение #%d)", userID, perMinute, mute, strikes)
+	if strikes >= int64(len(rateLimitMuteSteps)) {
+		r.LogCreditOperation(s, fmt.Sprintf("🚦 <@%s> замучен ботом на %s за спам командами (нарушение #%d за сутки)", userID, formatTime(int(mute.Seconds())), strikes))
+	}
+	return true, fmt.Sprintf("🚦 <@%s>, слишком много команд (больше %d в минуту). Бот не будет отвечать тебе **%s**. Повторные нарушения за сутки — дольше.",
+		userID, perMinute, formatTime(int(mute.Seconds())))
+}
+
+// CheckRateLimit возвращает true, если команду нужно проигнорировать: игрок в муте или только что
+// превысил лимит. О муте сообщается один раз, дальнейшие команды молча пропускаются.
+func (r *Ranking) CheckRateLimit(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	limited, notice := r.rateLimitHit(s, m.Author.ID)
+	if notice != "" {
+		s.ChannelMessageSend(m.ChannelID, notice)
+	}
+	return limited
+}
+
+// CheckInteractionRateLimit — CheckRateLimit для кнопок, меню и модальных окон: они делят ведро
+// с командами. На взаимодействие нужно ответить, поэтому игрок в муте получает скрытое сообщение.
+func (r *Ranking) CheckInteractionRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) bool {
+	limited, notice := r.rateLimitHit(s, userID)
+	if !limited {
+		return false
+	}
+	if notice == "" {
+		notice = "🚦 Слишком много команд — бот пока не отвечает тебе. Подожди окончания мута."
+	}
+	respondEphemeral(s, i, notice)
+	return true
+}
+
+// HandleRateLimitCommand обрабатывает команду !a_ratelimit [<команд в минуту>|unmute @user].
+func (r *Ranking) HandleRateLimitCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_ratelimit: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	switch {
+	case len(parts) == 1:
+		steps := make([]string, len(rateLimitMuteSteps))
+		for i, step := range rateLimitMuteSteps {
+			steps[i] = formatTime(int(step.Seconds()))
+		}
+		limit := "выключен"
+		if perMinute := r.CommandRateLimit(); perMinute > 0 {
+			limit = fmt.Sprintf("%d команд в минуту", perMinute)
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🚦 Лимит: **%s**\nМуты за нарушения в течение суток: %s\n`/a_ratelimit <n>` — изменить (0 — выключить), `/a_ratelimit unmute @user` — снять мут",
+			limit, strings.Join(steps, " → ")))
+	case len(parts) == 3 && parts[1] == "unmute":
+		userID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(parts[2], "<@"), "!"), ">")
+		if !isValidUserID(userID) {
+			s.ChannelMessageSend(m.ChannelID, "❌ Некорректный ID пользователя! Используй формат: `/a_ratelimit unmute @id`")
+			return
+		}
+		removed, err := r.redis.Del(r.ctx, rateLimitMuteKey(userID), rateLimitStrikesKey(userID)).Result()
+		if err != nil && err != redis.Nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis! Попробуйте позже.")
+			return
+		}
+		r.limiter.reset(userID)
+		if removed == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ У <@%s> нет мута и нарушений.", userID))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Мут и нарушения <@%s> сброшены.", userID))
+	case len(parts) == 2:
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Лимит должен быть неотрицательным числом!")
+			return
+		}
+		if err := r.SetIntSetting("rate_limit_per_minute", n); err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		if n == 0 {
+			s.ChannelMessageSend(m.ChannelID, "✅ Лимит команд выключен.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Лимит: %d команд в минуту на игрока.", n))
+	default:
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_ratelimit [<команд в минуту>|unmute @user]`")
+	}
+}
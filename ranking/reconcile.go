@@ -0,0 +1,263 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ночная сверка замороженных кредитов. Сумма удержаний каждого игрока в escrow:hold:* должна
+// совпадать с escrow:held, а у каждого бессрочного удержания должна быть запись, ради которой
+// оно создано: ставка кино-аукциона, лот рынка, товар лавки, обмен, взнос турнира. Удержания
+// со сроком не проверяются — их откатывает escrow_sweeper. Отчёт уходит в лог-канал только
+// при расхождениях, сама сверка ничего не исправляет.
+
+// reconcileReport — результат сверки.
+type reconcileReport struct {
+	Holds        int
+	HeldCredits  int
+	PollStakes   int
+	Mismatches   []string // расхождения escrow:held с суммой удержаний
+	Orphans      []string // бессрочные удержания без записи-основания
+	Unbacked     []string // ставки кино-аукциона, чьё удержание пропало
+	OrphanedSums int
+}
+
+// Clean сообщает, что расхождений нет.
+func (rep reconcileReport) Clean() bool {
+	return len(rep.Mismatches) == 0 && len(rep.Orphans) == 0 && len(rep.Unbacked) == 0
+}
+
+// holdLiabilityExists проверяет, что у бессрочного удержания есть запись-основание.
+// cinemaHolds и bracketHolds собраны заранее по ожидающим ставкам и активным турнирам.
+func (r *Ranking) holdLiabilityExists(hold EscrowHold, cinemaHolds, bracketHolds map[string]bool) (bool, bool) {
+	exists := func(key string) bool {
+		n, err := r.redis.Exists(r.ctx, key).Result()
+		return err != nil || n > 0 // при ошибке Redis не считаем удержание потерянным
+	}
+	switch hold.Source {
+	case "cinema":
+		return cinemaHolds[hold.ID], true
+	case "bracket":
+		return bracketHolds[hold.ID], true
+	case "market":
+		id, err := strconv.ParseInt(strings.TrimPrefix(hold.ID, "market:"), 10, 64)
+		if err != nil {
+			return false, true
+		}
+		return exists(marketListingKey(id)), true
+	case "offer":
+		parts := strings.Split(hold.ID, ":")
+		if len(parts) != 3 {
+			return false, true
+		}
+		return exists(offerKey(parts[1])), true
+	case "player_shop":
+		parts := strings.SplitN(strings.TrimPrefix(hold.ID, "player_shop:"), ":", 3)
+		if len(parts) != 3 {
+			return false, true
+		}
+		ok, err := r.redis.HExists(r.ctx, playerShopItemsKey(parts[0]), parts[1]+":"+parts[2]).Result()
+		return err != nil || ok, true
+	}
+	return true, false
+}
+
+// reconcileHolds сверяет удержания с их основаниями и с escrow:held. Держит r.mu, чтобы не поймать
+// подтверждение ставки на полпути между созданием удержания и записью ставки.
+func (r *Ranking) reconcileHolds() (reconcileReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rep reconcileReport
+
+	// Ожидающие ставки кино-аукциона: после подтверждения игроком у ставки есть HoldID
+	cinemaHolds := make(map[string]bool)
+	bids := make(map[string]PendingCinemaBid)
+	err := r.scanKeys("pending_bid:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Result()
+		if err != nil {
+			return nil
+		}
+		var bid PendingCinemaBid
+		if json.Unmarshal([]byte(data), &bid) == nil && bid.HoldID != "" {
+			cinemaHolds[bid.HoldID] = true
+			bids[strings.TrimPrefix(key, "pending_bid:")] = bid
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	bracketHolds := make(map[string]bool)
+	ids, err := r.redis.SMembers(r.ctx, bracketActiveKey).Result()
+	if err != nil {
+		return rep, err
+	}
+	for _, id := range ids {
+		b, err := r.loadBracket(id)
+		if err != nil {
+			continue
+		}
+		for _, holdID := range b.Holds {
+			bracketHolds[holdID] = true
+		}
+	}
+
+	perOwner := make(map[string]int)
+	seen := make(map[string]bool)
+	err = r.scanKeys("escrow:hold:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var hold EscrowHold
+		if err := json.Unmarshal(data, &hold); err != nil {
+			rep.Orphans = append(rep.Orphans, fmt.Sprintf("`%s` — не разбирается: %v", key, err))
+			return nil
+		}
+		rep.Holds++
+		rep.HeldCredits += hold.Credits
+		perOwner[hold.Owner] += hold.Credits
+		seen[hold.ID] = true
+		if !hold.ExpiresAt.IsZero() {
+			return nil
+		}
+		if ok, known := r.holdLiabilityExists(hold, cinemaHolds, bracketHolds); known && !ok {
+			rep.Orphans = append(rep.Orphans, fmt.Sprintf("`%s` (%s) у <@%s>: %s, создано <t:%d:R>",
+				hold.ID, hold.ledgerSource(), hold.Owner, describeEscrowHold(hold), hold.CreatedAt.Unix()))
+			rep.OrphanedSums += hold.Credits
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	for bidID, bid := range bids {
+		if !seen[bid.HoldID] {
+			rep.Unbacked = append(rep.Unbacked, fmt.Sprintf("ставка `%s` <@%s> на %s — удержания `%s` нет", bidID, bid.UserID, formatCredits(bid.Amount), bid.HoldID))
+		}
+	}
+
+	recorded, err := r.redis.HGetAll(r.ctx, escrowHeldKey).Result()
+	if err != nil {
+		return rep, err
+	}
+	owners := make(map[string]bool)
+	for owner := range recorded {
+		owners[owner] = true
+	}
+	for owner := range perOwner {
+		owners[owner] = true
+	}
+	for owner := range owners {
+		held, _ := strconv.Atoi(recorded[owner])
+		if held != perOwner[owner] {
+			rep.Mismatches = append(rep.Mismatches, fmt.Sprintf("<@%s>: в escrow:held %s, в удержаниях %s", owner, formatCredits(held), formatCredits(perOwner[owner])))
+		}
+	}
+
+	// Ставки опросов списываются сразу и живут только в памяти — показываем их для сведения
+	for _, poll := range r.polls {
+		if poll.Active {
+			for _, amount := range poll.Bets {
+				rep.PollStakes += amount
+			}
+		}
+	}
+
+	sort.Strings(rep.Mismatches)
+	sort.Strings(rep.Orphans)
+	sort.Strings(rep.Unbacked)
+	return rep, nil
+}
+
+// describeEscrowHold кратко описывает ресурсы удержания.
+func describeEscrowHold(hold EscrowHold) string {
+	var parts []string
+	if hold.Credits > 0 {
+		parts = append(parts, formatCredits(hold.Credits))
+	}
+	for caseID, count := range hold.Cases {
+		parts = append(parts, fmt.Sprintf("кейс %s x%d", caseID, count))
+	}
+	for nftID, count := range hold.NFTs {
+		parts = append(parts, fmt.Sprintf("NFT %s x%d", nftID, count))
+	}
+	if len(parts) == 0 {
+		return "пусто"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reconcileEmbed формирует отчёт о сверке для лог-канала.
+func reconcileEmbed(rep reconcileReport) *discordgo.MessageEmbed {
+	list := func(lines []string) string {
+		if len(lines) > 10 {
+			lines = append(lines[:10:10], fmt.Sprintf("…и ещё %d", len(lines)-10))
+		}
+		return truncate(strings.Join(lines, "\n"), 1000)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "🧮 Сверка замороженных кредитов",
+		Description: fmt.Sprintf("Удержаний: **%d** на %s\nСтавки в активных опросах: %s",
+			rep.Holds, formatCredits(rep.HeldCredits), formatCredits(rep.PollStakes)),
+		Color:     0x00FF00,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if rep.Clean() {
+		embed.Description += "\n\n✅ Расхождений нет."
+		return embed
+	}
+	embed.Color = 0xFF0000
+	if len(rep.Mismatches) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("⚖️ escrow:held не сходится (%d)", len(rep.Mismatches)), Value: list(rep.Mismatches)})
+	}
+	if len(rep.Orphans) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("👻 Удержания без основания (%d, %s)", len(rep.Orphans), formatCredits(rep.OrphanedSums)), Value: list(rep.Orphans)})
+	}
+	if len(rep.Unbacked) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("🎬 Ставки без удержания (%d)", len(rep.Unbacked)), Value: list(rep.Unbacked)})
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Сверка ничего не исправляет — проверьте записи вручную (/admincinemalist, /a_inventory, /a_recall)"}
+	return embed
+}
+
+// runEscrowReconcile — ночная задача: сверяет удержания и пишет в лог-канал только при расхождениях.
+func (r *Ranking) runEscrowReconcile() error {
+	rep, err := r.reconcileHolds()
+	if err != nil {
+		return err
+	}
+	log.Printf("Сверка удержаний: %d удержаний на %d кредитов, расхождений: %d/%d/%d",
+		rep.Holds, rep.HeldCredits, len(rep.Mismatches), len(rep.Orphans), len(rep.Unbacked))
+	if rep.Clean() || r.logChannelID == "" {
+		return nil
+	}
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageSendEmbed(r.logChannelID, reconcileEmbed(rep))
+	return err
+}
+
+// HandleReconcileCommand обрабатывает команду !a_reconcile: сверка по запросу, отчёт показывается всегда.
+func (r *Ranking) HandleReconcileCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !a_reconcile от %s", m.Author.ID)
+
+	rep, err := r.reconcileHolds()
+	if err != nil {
+		log.Printf("Не удалось сверить удержания: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сверки! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, reconcileEmbed(rep))
+}
@@ -28,7 +28,7 @@ func (r *Ranking) StartRBGame(s *discordgo.Session, m *discordgo.MessageCreate)
 
 	r.mu.Lock()
 	gameID := generateGameID(m.Author.ID)
-	color := randomColor()
+	color := r.themeColor(m.Author.ID)
 	game := &RedBlackGame{
 		GameID:   gameID,
 		PlayerID: m.Author.ID,
@@ -39,8 +39,8 @@ func (r *Ranking) StartRBGame(s *discordgo.Session, m *discordgo.MessageCreate)
 	r.mu.Unlock()
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "🎰 Игра: Красный-Чёрный",
-		Description: fmt.Sprintf("Велком, <@%s>! 🥳\nИмператор велит: выбирать цвет и ставка делай!\n\n**💰 Баланса твоя:** %d кредитов\n\nПиши вот: `/rb <red/black> <сумма>`\nНапример: `/rb red 50`\nИмператор следит за тобой! 👑", m.Author.ID, r.GetRating(m.Author.ID)),
+		Title:       r.themeTitle(game.PlayerID, "rb", "🎰 Игра: Красный-Чёрный"),
+		Description: fmt.Sprintf("Велком, <@%s>! 🥳\nИмператор велит: выбирать цвет и ставка делай!\n\n**💰 Баланса твоя:** %s\n\nПиши вот: `/rb <red/black> <сумма>`\nНапример: `/rb red 50`\nИмператор следит за тобой! 👑", m.Author.ID, formatCredits(r.GetRating(m.Author.ID))),
 		Color:       color,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Славь Императора и везёт тебе! 🍀",
@@ -57,13 +57,13 @@ func (r *Ranking) StartRBGame(s *discordgo.Session, m *discordgo.MessageCreate)
 	r.mu.Unlock()
 
 	go func(messageID string, channelID string) {
-		time.Sleep(15 * time.Minute)
+		time.Sleep(r.GameTimeout("rb"))
 		r.mu.Lock()
 		if g, exists := r.redBlackGames[gameID]; exists && g.Active {
 			g.Active = false
 			delete(r.redBlackGames, gameID)
 			embed := &discordgo.MessageEmbed{
-				Title:       "🎰 Игра: Красный-Чёрный",
+				Title:       r.themeTitle(game.PlayerID, "rb", "🎰 Игра: Красный-Чёрный"),
 				Description: fmt.Sprintf("Игра закончи, <@%s>! Время нету. ⏰\nИмператор недоволен! 😡", m.Author.ID),
 				Color:       color,
 				Footer: &discordgo.MessageEmbedFooter{
@@ -98,6 +98,10 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 		r.sendTemporaryReply(s, m, "❌ Сумма надо число хорошее! Император не любит шутки! 😡")
 		return
 	}
+	if msg := r.checkBetLimits("rb", m.Author.ID, amount); msg != "" {
+		r.sendTemporaryReply(s, m, msg)
+		return
+	}
 
 	userRating := r.GetRating(m.Author.ID)
 	if userRating < amount {
@@ -118,23 +122,33 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 		r.mu.Unlock()
 		return
 	}
+	if remaining, err := r.reserveDailyWager(m.Author.ID, amount); err != nil {
+		r.mu.Unlock()
+		r.sendTemporaryReply(s, m, r.wagerCapMessage(remaining, err))
+		return
+	}
 
 	game.Bet = amount
 	game.Choice = choice
 	r.mu.Unlock()
 
-	r.UpdateRating(m.Author.ID, -amount)
+	r.UpdateRatingFrom(m.Author.ID, -amount, "rb", "")
 
+	r.spinRB(s, m.ChannelID, game)
+}
+
+// spinRB разыгрывает сделанную ставку RedBlack: анимация, результат и кнопки повтора.
+func (r *Ranking) spinRB(s *discordgo.Session, channelID string, game *RedBlackGame) {
 	embed := &discordgo.MessageEmbed{
-		Title:       "🎰 Игра: Красный-Чёрный",
-		Description: fmt.Sprintf("<@%s> ставка делай %d кредитов на %s!\n\n🎲 Крутим-крутим... Император смотрит! 👑", m.Author.ID, amount, choice),
+		Title:       r.themeTitle(game.PlayerID, "rb", "🎰 Игра: Красный-Чёрный"),
+		Description: fmt.Sprintf("<@%s> ставка делай %s на %s!\n\n🎲 Крутим-крутим... Император смотрит! 👑", game.PlayerID, formatCredits(game.Bet), game.Choice),
 		Color:       game.Color,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Славь Императора и везёт тебе! 🍀",
 		},
 	}
-	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel: m.ChannelID,
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel: channelID,
 		ID:      game.MenuMessageID,
 		Embed:   embed,
 	})
@@ -143,12 +157,13 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 		return
 	}
 
-	colors := []string{"🔴", "⚫"}
+	theme := r.UserTheme(game.PlayerID)
+	colors := []string{theme.RBRed, theme.RBBlack}
 	for i := 0; i < 5; i++ {
 		color := colors[i%2]
-		embed.Description = fmt.Sprintf("<@%s> ставка делай %d кредитов на %s!\n\n🎲 Крутим-крутим... %s Император смотрит! 👑", m.Author.ID, amount, choice, color)
+		embed.Description = fmt.Sprintf("<@%s> ставка делай %s на %s!\n\n🎲 Крутим-крутим... %s Император смотрит! 👑", game.PlayerID, formatCredits(game.Bet), game.Choice, color)
 		_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-			Channel: m.ChannelID,
+			Channel: channelID,
 			ID:      game.MenuMessageID,
 			Embed:   embed,
 		})
@@ -162,25 +177,31 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 	if rand.Intn(2) == 1 {
 		result = "black"
 	}
-	colorEmoji := "🔴"
+	colorEmoji := theme.RBRed
 	if result == "black" {
-		colorEmoji = "⚫"
+		colorEmoji = theme.RBBlack
 	}
 
-	embed.Description = fmt.Sprintf("<@%s> ставка делай %d кредитов на %s!\n\n🎲 Результат: %s", m.Author.ID, amount, choice, colorEmoji)
-	won := result == choice
+	embed.Description = fmt.Sprintf("<@%s> ставка делай %s на %s!\n\n🎲 Результат: %s", game.PlayerID, formatCredits(game.Bet), game.Choice, colorEmoji)
+	won := result == game.Choice
 	if won {
-		winnings := amount * 2
-		r.UpdateRating(m.Author.ID, winnings)
-		embed.Description += fmt.Sprintf("\n\n✅ Победа! Император доволен! Ты бери %d кредитов! 🎉", winnings)
+		winnings := r.scaleGamePayout(game.Bet, game.Bet*2)
+		r.UpdateRatingFrom(game.PlayerID, winnings, "rb", "")
+		r.repayLoanFromWinnings(game.PlayerID, winnings-game.Bet)
+		embed.Description += fmt.Sprintf("\n\n✅ Победа! Император доволен! Ты бери %s! 🎉", formatCredits(winnings))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Император хвалит тебя! 🏆"}
 	} else {
-		embed.Description += fmt.Sprintf("\n\n❌ Проиграл! Император гневен! Потерял: %d кредитов. 😢", amount)
+		embed.Description += fmt.Sprintf("\n\n❌ Проиграл! Император гневен! Потерял: %s. 😢", formatCredits(game.Bet))
 		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Император недоволен! 😡"}
 	}
 
 	// Обновляем статистику RedBlack
-	r.UpdateRBStats(m.Author.ID, won)
+	r.UpdateRBStats(game.PlayerID, won)
+	status := "lost"
+	if won {
+		status = "won"
+	}
+	r.overlay.Publish(OverlayEvent{Type: "rb", Status: status, PlayerID: game.PlayerID, Bet: game.Bet, Choice: game.Choice, Result: result})
 
 	customID := fmt.Sprintf("rb_replay_%s_%d", game.PlayerID, time.Now().UnixNano())
 	log.Printf("Установка CustomID кнопки: %s", customID)
@@ -192,11 +213,16 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 					Style:    discordgo.PrimaryButton,
 					CustomID: customID,
 				},
+				discordgo.Button{
+					Label:    fmt.Sprintf("Повторить ставку 🔁 (%s %d)", game.Choice, game.Bet),
+					Style:    discordgo.SuccessButton,
+					CustomID: fmt.Sprintf("rb_rebet_%s_%s_%d", game.PlayerID, game.Choice, game.Bet),
+				},
 			},
 		},
 	}
 	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel:    m.ChannelID,
+		Channel:    channelID,
 		ID:         game.MenuMessageID,
 		Embed:      embed,
 		Components: &components,
@@ -212,7 +238,7 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 	r.mu.Unlock()
 
 	go func(messageID string, channelID string) {
-		time.Sleep(15 * time.Minute)
+		time.Sleep(r.GameTimeout("rb"))
 		r.mu.Lock()
 		var activeGame *RedBlackGame
 		for _, g := range r.redBlackGames {
@@ -235,7 +261,81 @@ func (r *Ranking) HandleRBCommand(s *discordgo.Session, m *discordgo.MessageCrea
 			log.Printf("Кнопка RB отключена для сообщения %s", messageID)
 		}
 		r.mu.Unlock()
-	}(game.MenuMessageID, m.ChannelID)
+	}(game.MenuMessageID, channelID)
+}
+
+// HandleRBRebet сразу разыгрывает новый раунд с той же ставкой и цветом.
+func (r *Ranking) HandleRBRebet(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	parts := strings.Split(i.MessageComponentData().CustomID, "_")
+	if len(parts) != 5 {
+		log.Printf("Неверный формат CustomID: %s", i.MessageComponentData().CustomID)
+		ephemeral("❌ Ошибка: кнопка сломана! Император гневен! 😡")
+		return
+	}
+	playerID, choice := parts[2], parts[3]
+	amount, err := strconv.Atoi(parts[4])
+	if err != nil || amount <= 0 || (choice != "red" && choice != "black") {
+		ephemeral("❌ Ошибка: кнопка сломана! Император гневен! 😡")
+		return
+	}
+	if i.Member == nil || i.Member.User.ID != playerID {
+		ephemeral("❌ Кнопка не твоя! Император не позволит! 👑")
+		return
+	}
+	if msg := r.checkBetLimits("rb", playerID, amount); msg != "" {
+		ephemeral(msg)
+		return
+	}
+	if rating := r.GetRating(playerID); rating < amount {
+		ephemeral(fmt.Sprintf("❌ Кредитов мало! Баланса твоя: %d 😢 Император не даст взаймы!", rating))
+		return
+	}
+
+	game, errText := r.startRBRebet(playerID, choice, i.Message.ID, amount)
+	if errText != "" {
+		ephemeral(errText)
+		return
+	}
+
+	r.UpdateRatingFrom(playerID, -amount, "rb", "")
+	log.Printf("Повтор ставки RB: игрок %s, %d на %s", playerID, amount, choice)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+
+	r.spinRB(s, i.ChannelID, game)
+}
+
+// startRBRebet садит игрока за новую игру на том же сообщении. При отказе возвращает текст для игрока.
+func (r *Ranking) startRBRebet(playerID, choice, menuMessageID string, amount int) (*RedBlackGame, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, g := range r.redBlackGames {
+		if g.MenuMessageID == menuMessageID && g.Active {
+			return nil, "❌ Колесо ещё крутится! Император ждёт! 👑"
+		}
+	}
+	if remaining, err := r.reserveDailyWager(playerID, amount); err != nil {
+		return nil, r.wagerCapMessage(remaining, err)
+	}
+	game := &RedBlackGame{
+		GameID:        generateGameID(playerID),
+		PlayerID:      playerID,
+		Bet:           amount,
+		Choice:        choice,
+		Active:        true,
+		MenuMessageID: menuMessageID,
+		Color:         r.themeColor(playerID),
+	}
+	r.redBlackGames[game.GameID] = game
+	return game, ""
 }
 
 // HandleRBReplay обрабатывает повторную игру RedBlack.
@@ -279,7 +379,7 @@ func (r *Ranking) HandleRBReplay(s *discordgo.Session, i *discordgo.InteractionC
 	}
 
 	newGameID := generateGameID(playerID)
-	newColor := randomColor()
+	newColor := r.themeColor(playerID)
 	game := &RedBlackGame{
 		GameID:   newGameID,
 		PlayerID: playerID,
@@ -292,8 +392,8 @@ func (r *Ranking) HandleRBReplay(s *discordgo.Session, i *discordgo.InteractionC
 	log.Printf("Создана новая игра RB с ID %s для игрока %s", newGameID, playerID)
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "🎰 Игра: Красный-Чёрный",
-		Description: fmt.Sprintf("Велком снова, <@%s>! 🥳\nИмператор даёт шанс: выбирать цвет и ставка делай!\n\n**💰 Баланса твоя:** %d кредитов\n\nПиши вот: `/rb <red/black> <сумма>`\nНапример: `/rb red 50`\nИмператор следит за тобой! 👑", playerID, r.GetRating(playerID)),
+		Title:       r.themeTitle(game.PlayerID, "rb", "🎰 Игра: Красный-Чёрный"),
+		Description: fmt.Sprintf("Велком снова, <@%s>! 🥳\nИмператор даёт шанс: выбирать цвет и ставка делай!\n\n**💰 Баланса твоя:** %s\n\nПиши вот: `/rb <red/black> <сумма>`\nНапример: `/rb red 50`\nИмператор следит за тобой! 👑", playerID, formatCredits(r.GetRating(playerID))),
 		Color:       newColor,
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "Славь Императора и везёт тебе! 🍀",
@@ -326,13 +426,13 @@ func (r *Ranking) HandleRBReplay(s *discordgo.Session, i *discordgo.InteractionC
 	r.mu.Unlock()
 
 	go func(messageID string, channelID string) {
-		time.Sleep(15 * time.Minute)
+		time.Sleep(r.GameTimeout("rb"))
 		r.mu.Lock()
 		if g, exists := r.redBlackGames[newGameID]; exists && g.Active {
 			g.Active = false
 			delete(r.redBlackGames, newGameID)
 			timeoutEmbed := &discordgo.MessageEmbed{
-				Title:       "🎰 Игра: Красный-Чёрный",
+				Title:       r.themeTitle(game.PlayerID, "rb", "🎰 Игра: Красный-Чёрный"),
 				Description: fmt.Sprintf("Игра закончи, <@%s>! Время нету. ⏰\nИмператор недоволен! 😡", playerID),
 				Color:       newColor,
 				Footer: &discordgo.MessageEmbedFooter{
@@ -21,18 +21,24 @@ type User struct {
 	RBWon        int    `json:"rb_won"`
 	BJPlayed     int    `json:"bj_played"`
 	BJWon        int    `json:"bj_won"`
+	BJSurrender  int    `json:"bj_surrendered"`
 	VoiceSeconds int    `json:"voice_seconds"`
 }
 
-// GetRating получает рейтинг пользователя из Redis.
+// GetRating получает рейтинг пользователя (из кэша или Redis).
 func (r *Ranking) GetRating(userID string) int {
+	if user, _, hit := r.users.get(userID); hit {
+		return user.Rating
+	}
 	for i := 0; i < 3; i++ {
 		data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
 		if err == redis.Nil {
+			r.users.put(userID, User{ID: userID}, false)
 			return 0
 		}
 		if err != nil {
 			log.Printf("Не удалось получить рейтинг для %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
@@ -41,60 +47,114 @@ func (r *Ranking) GetRating(userID string) int {
 			log.Printf("Не удалось разобрать данные пользователя %s: %v", userID, err)
 			return 0
 		}
+		r.users.put(userID, user, true)
 		return user.Rating
 	}
 	log.Printf("Не удалось получить рейтинг для %s после 3 попыток", userID)
 	return 0
 }
 
-// UpdateRating обновляет рейтинг пользователя в Redis.
+// loadUser читает запись пользователя (из кэша или Redis).
+func (r *Ranking) loadUser(userID string) (User, bool) {
+	if user, exists, hit := r.users.get(userID); hit {
+		return user, exists
+	}
+	user := User{ID: userID}
+	data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
+	if err == redis.Nil {
+		r.users.put(userID, user, false)
+		return user, false
+	}
+	if err != nil {
+		return user, false
+	}
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		log.Printf("Не удалось разобрать данные пользователя %s: %v", userID, err)
+		return user, false
+	}
+	r.users.put(userID, user, true)
+	return user, true
+}
+
+// UpdateRating обновляет рейтинг пользователя в Redis без указания источника операции.
 func (r *Ranking) UpdateRating(userID string, points int) {
+	r.UpdateRatingFrom(userID, points, "other", "")
+}
+
+// UpdateRatingFrom обновляет рейтинг пользователя в Redis и записывает операцию в журнал кредитов.
+// source — подсистема (blackjack, transfer, admin...), counterparty — второй участник операции, если есть.
+func (r *Ranking) UpdateRatingFrom(userID string, points int, source, counterparty string) {
+	r.updateRatingNoted(userID, points, source, counterparty, "")
+}
+
+// updateRatingNoted — UpdateRatingFrom с причиной операции для журнала кредитов.
+// Возвращает фактическое изменение баланса с учётом нижней границы (0, если сохранить не удалось).
+func (r *Ranking) updateRatingNoted(userID string, points int, source, counterparty, note string) int {
 	user := User{ID: userID}
 	for i := 0; i < 3; i++ {
 		data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
 		if err == nil {
 			if err := json.Unmarshal([]byte(data), &user); err != nil {
 				log.Printf("Не удалось разобрать данные пользователя %s: %v", userID, err)
-				return
+				return 0
 			}
 			break
 		} else if err == redis.Nil {
 			break
 		} else {
 			log.Printf("Не удалось получить данные пользователя %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 		}
 	}
 
 	oldRating := user.Rating
 	user.Rating += points
-	if user.Rating < 0 {
-		user.Rating = 0
+	if floor := r.balanceFloor(oldRating); user.Rating < floor {
+		user.Rating = floor
 	}
 
 	dataBytes, err := json.Marshal(user)
 	if err != nil {
 		log.Printf("Не удалось сериализовать данные пользователя %s: %v", userID, err)
-		return
+		return 0
 	}
 
 	for i := 0; i < 3; i++ {
 		if err := r.redis.Set(r.ctx, "user:"+userID, dataBytes, 0).Err(); err != nil {
 			log.Printf("Не удалось сохранить данные пользователя %s в Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
+		r.users.put(userID, user, true)
 		log.Printf("Обновлён рейтинг для %s: %d (изменение: %d)", userID, user.Rating, points)
+		r.recordEconomyDelta(userID, oldRating, user.Rating)
+		r.recordCreditLedger(userID, user.Rating-oldRating, user.Rating, source, counterparty, note)
+		r.recordGamblingResult(userID, user.Rating-oldRating, source)
+		r.recordGamblingTop(userID, user.Rating-oldRating, source)
+		r.recordWeeklyDelta(userID, user.Rating-oldRating, source, counterparty)
 		// Логируем операцию в LOG_CHANNEL_ID
 		s, err := discordgo.New("Bot " + os.Getenv("DISCORD_TOKEN"))
 		if err == nil {
-			if points == 1 { // Предполагаем, что +1 — это за голосовую активность
-				r.LogCreditOperation(s, fmt.Sprintf("<@%s> получил +1 кредит за активность в войсе %d -> %d", userID, oldRating, user.Rating))
+			if source == "voice" {
+				r.LogCreditOperation(s, fmt.Sprintf("<@%s> получил %+d за активность в войсе %d -> %d", userID, points, oldRating, user.Rating))
 			} else {
-				r.LogCreditOperation(s, fmt.Sprintf("💰 <@%s> изменил баланс: %d → %d (%+d кредитов)", userID, oldRating, user.Rating, points))
+				text := fmt.Sprintf("💰 <@%s> изменил баланс: %s → %s (%+d)", userID, formatCredits(oldRating), formatCredits(user.Rating), points)
+				if note != "" {
+					text += " — " + note
+				}
+				r.LogCreditOperation(s, text)
 			}
+			r.checkWatchedBalance(s, userID, user.Rating-oldRating, user.Rating, source, counterparty)
 		}
-		return
+		// Часть дохода за войс идёт в погашение займа (с выигрышей — в самих играх)
+		if points > 0 && loanIncomeSources[source] {
+			if repaid := r.withholdLoanRepayment(userID, points); repaid > 0 {
+				r.UpdateRatingFrom(userID, -repaid, "loan_repay", "")
+			}
+		}
+		return user.Rating - oldRating
 	}
 	log.Printf("Не удалось сохранить данные пользователя %s в Redis после 3 попыток", userID)
 	if r.floodChannelID != "" {
@@ -103,10 +163,12 @@ func (r *Ranking) UpdateRating(userID string, points int) {
 			s.ChannelMessageSend(r.floodChannelID, "❌ Ошибка: Не удалось сохранить рейтинг в Redis после 3 попыток! Проверьте Redis-сервер.")
 		}
 	}
+	return 0
 }
 
 // UpdateDuelStats обновляет статистику дуэлей пользователя.
 func (r *Ranking) UpdateDuelStats(userID string, won bool) {
+	go r.trackGameResult(userID, won)
 	user := User{ID: userID}
 	for i := 0; i < 3; i++ {
 		data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
@@ -120,6 +182,7 @@ func (r *Ranking) UpdateDuelStats(userID string, won bool) {
 			break
 		} else {
 			log.Printf("Не удалось получить данные пользователя %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 		}
 	}
@@ -138,9 +201,12 @@ func (r *Ranking) UpdateDuelStats(userID string, won bool) {
 	for i := 0; i < 3; i++ {
 		if err := r.redis.Set(r.ctx, "user:"+userID, dataBytes, 0).Err(); err != nil {
 			log.Printf("Не удалось сохранить данные пользователя %s в Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
+		r.users.put(userID, user, true)
+		r.setTopScore(topDuelsKey, userID, user.DuelsWon)
 		log.Printf("Обновлена статистика дуэлей для %s: сыграно %d, выиграно %d", userID, user.DuelsPlayed, user.DuelsWon)
 		return
 	}
@@ -149,6 +215,7 @@ func (r *Ranking) UpdateDuelStats(userID string, won bool) {
 
 // UpdateRBStats обновляет статистику RedBlack.
 func (r *Ranking) UpdateRBStats(userID string, won bool) {
+	go r.trackGameResult(userID, won)
 	user := User{ID: userID}
 	for i := 0; i < 3; i++ {
 		data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
@@ -162,6 +229,7 @@ func (r *Ranking) UpdateRBStats(userID string, won bool) {
 			break
 		} else {
 			log.Printf("Не удалось получить данные пользователя %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 		}
 	}
@@ -180,9 +248,11 @@ func (r *Ranking) UpdateRBStats(userID string, won bool) {
 	for i := 0; i < 3; i++ {
 		if err := r.redis.Set(r.ctx, "user:"+userID, dataBytes, 0).Err(); err != nil {
 			log.Printf("Не удалось сохранить данные пользователя %s в Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
+		r.users.put(userID, user, true)
 		log.Printf("Обновлена статистика RedBlack для %s: сыграно %d, выиграно %d", userID, user.RBPlayed, user.RBWon)
 		return
 	}
@@ -191,6 +261,17 @@ func (r *Ranking) UpdateRBStats(userID string, won bool) {
 
 // UpdateBJStats обновляет статистику Blackjack.
 func (r *Ranking) UpdateBJStats(userID string, won bool) {
+	r.updateBJStats(userID, won, false)
+}
+
+// UpdateBJSurrender учитывает сдачу в Blackjack: игра считается сыгранной, сдача учитывается отдельно.
+func (r *Ranking) UpdateBJSurrender(userID string) {
+	r.updateBJStats(userID, false, true)
+}
+
+// updateBJStats сохраняет результат партии Blackjack в статистике пользователя.
+func (r *Ranking) updateBJStats(userID string, won, surrendered bool) {
+	go r.trackGameResult(userID, won)
 	user := User{ID: userID}
 	for i := 0; i < 3; i++ {
 		data, err := r.redis.Get(r.ctx, "user:"+userID).Result()
@@ -204,6 +285,7 @@ func (r *Ranking) UpdateBJStats(userID string, won bool) {
 			break
 		} else {
 			log.Printf("Не удалось получить данные пользователя %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 		}
 	}
@@ -212,6 +294,9 @@ func (r *Ranking) UpdateBJStats(userID string, won bool) {
 	if won {
 		user.BJWon++
 	}
+	if surrendered {
+		user.BJSurrender++
+	}
 
 	dataBytes, err := json.Marshal(user)
 	if err != nil {
@@ -222,10 +307,12 @@ func (r *Ranking) UpdateBJStats(userID string, won bool) {
 	for i := 0; i < 3; i++ {
 		if err := r.redis.Set(r.ctx, "user:"+userID, dataBytes, 0).Err(); err != nil {
 			log.Printf("Не удалось сохранить данные пользователя %s в Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
-		log.Printf("Обновлена статистика Blackjack для %s: сыграно %d, выиграно %d", userID, user.BJPlayed, user.BJWon)
+		r.users.put(userID, user, true)
+		log.Printf("Обновлена статистика Blackjack для %s: сыграно %d, выиграно %d, сдач %d", userID, user.BJPlayed, user.BJWon, user.BJSurrender)
 		return
 	}
 	log.Printf("Не удалось сохранить данные пользователя %s в Redis после 3 попыток", userID)
@@ -246,6 +333,7 @@ func (r *Ranking) UpdateVoiceSeconds(userID string, seconds int) {
 			break
 		} else {
 			log.Printf("Не удалось получить данные пользователя %s из Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 		}
 	}
@@ -261,9 +349,12 @@ func (r *Ranking) UpdateVoiceSeconds(userID string, seconds int) {
 	for i := 0; i < 3; i++ {
 		if err := r.redis.Set(r.ctx, "user:"+userID, dataBytes, 0).Err(); err != nil {
 			log.Printf("Не удалось сохранить данные пользователя %s в Redis (попытка %d/3): %v", userID, i+1, err)
+			r.ReportError("redis", err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
+		r.users.put(userID, user, true)
+		r.setTopScore(topVoiceKey, userID, user.VoiceSeconds)
 		//log.Printf("Обновлено время в голосовых каналах для %s: %d секунд", userID)
 		return
 	}
@@ -0,0 +1,318 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Релизы коллекций: админы заполняют лист Releases (коллекция, дата выхода, кейс запуска,
+// дней тизеров, картинка тизера). До даты выхода NFT коллекции не выпадают из кейсов, а кейс
+// запуска не попадает в банк. В последние дни перед выходом бот раз в день публикует тизер
+// с размытой картинкой, а в момент выхода объявляет релиз и кладёт кейс запуска в банк.
+const (
+	sheetsReleaseRange  = "Releases!A2:E"
+	releaseScheduleKey  = "releases:schedule" // HASH коллекция -> JSON CollectionRelease
+	releaseLaunchedKey  = "releases:launched" // SET вышедших коллекций
+	releaseTimeLayout   = "2006-01-02 15:04"
+	releaseTeaserKeyTTL = 48 * time.Hour
+)
+
+// CollectionRelease — запланированный выход коллекции.
+type CollectionRelease struct {
+	Collection string    `json:"collection"`
+	At         time.Time `json:"at"`
+	CaseID     string    `json:"case_id,omitempty"`
+	TeaserDays int       `json:"teaser_days"`
+	ImageURL   string    `json:"image_url,omitempty"`
+}
+
+// releaseTeaserKey возвращает ключ отметки о тизере коллекции за день.
+func releaseTeaserKey(collection, day string) string {
+	return "releases:teased:" + collection + ":" + day
+}
+
+// releaseLocation возвращает часовой пояс дат релизов (RELEASE_TIMEZONE, по умолчанию Asia/Krasnoyarsk).
+func releaseLocation() *time.Location {
+	name := os.Getenv("RELEASE_TIMEZONE")
+	if name == "" {
+		name = "Asia/Krasnoyarsk"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Ошибка загрузки часового пояса %s: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// ReleaseCaseStock возвращает, сколько кейсов запуска кладётся в банк в момент релиза.
+func (r *Ranking) ReleaseCaseStock() int {
+	return r.GetIntSetting("release_case_stock", envInt("RELEASE_CASE_STOCK", 50))
+}
+
+// syncReleases читает расписание релизов из листа Releases. Лист необязателен: если его нет,
+// расписание остаётся прежним.
+func (k *KKI) syncReleases(r *Ranking) {
+	resp, err := k.sheets.Spreadsheets.Values.Get(os.Getenv("GOOGLE_SHEETS_ID"), sheetsReleaseRange).Do()
+	if err != nil {
+		log.Printf("Не удалось загрузить расписание релизов: %v", err)
+		return
+	}
+	loc := releaseLocation()
+	schedule := make(map[string]string, len(resp.Values))
+	for _, row := range resp.Values {
+		if len(row) < 2 {
+			continue
+		}
+		cell := func(n int) string {
+			if n >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(fmt.Sprintf("%v", row[n]))
+		}
+		at, err := time.ParseInLocation(releaseTimeLayout, cell(1), loc)
+		if err != nil {
+			log.Printf("Релиз %s: неверная дата %q (нужен формат %s)", cell(0), cell(1), releaseTimeLayout)
+			continue
+		}
+		days, err := strconv.Atoi(cell(3))
+		if err != nil || days < 0 {
+			days = 3
+		}
+		release := CollectionRelease{Collection: cell(0), At: at, CaseID: cell(2), TeaserDays: days, ImageURL: cell(4)}
+		if release.Collection == "" {
+			continue
+		}
+		data, _ := json.Marshal(release)
+		schedule[release.Collection] = string(data)
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, releaseScheduleKey)
+	if len(schedule) > 0 {
+		pipe.HSet(r.ctx, releaseScheduleKey, schedule)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось сохранить расписание релизов: %v", err)
+		return
+	}
+	log.Printf("Расписание релизов загружено: %d коллекций", len(schedule))
+}
+
+// collectionReleases возвращает расписание релизов, отсортированное по дате выхода.
+func (r *Ranking) collectionReleases() []CollectionRelease {
+	raw, err := r.redis.HGetAll(r.ctx, releaseScheduleKey).Result()
+	if err != nil {
+		log.Printf("Не удалось получить расписание релизов: %v", err)
+		return nil
+	}
+	releases := make([]CollectionRelease, 0, len(raw))
+	for _, data := range raw {
+		var release CollectionRelease
+		if err := json.Unmarshal([]byte(data), &release); err == nil {
+			releases = append(releases, release)
+		}
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].At.Before(releases[j].At) })
+	return releases
+}
+
+// unreleasedCollections возвращает коллекции, дата выхода которых ещё не наступила.
+func (r *Ranking) unreleasedCollections() map[string]bool {
+	locked := make(map[string]bool)
+	now := time.Now()
+	for _, release := range r.collectionReleases() {
+		if now.Before(release.At) {
+			locked[release.Collection] = true
+		}
+	}
+	return locked
+}
+
+// unreleasedCases возвращает кейсы запуска ещё не вышедших коллекций.
+func (r *Ranking) unreleasedCases() map[string]bool {
+	locked := make(map[string]bool)
+	now := time.Now()
+	for _, release := range r.collectionReleases() {
+		if release.CaseID != "" && now.Before(release.At) {
+			locked[release.CaseID] = true
+		}
+	}
+	return locked
+}
+
+// releaseImage возвращает картинку тизера: из листа или самой редкой NFT коллекции.
+func (r *Ranking) releaseImage(release CollectionRelease) string {
+	if release.ImageURL != "" {
+		return release.ImageURL
+	}
+	best := NFT{}
+	for _, nft := range r.Kki.nfts {
+		if nft.Collection != release.Collection || nft.ImageURL == "" {
+			continue
+		}
+		if best.ID == "" || rarityRank(nft.Rarity) > rarityRank(best.Rarity) || (nft.Rarity == best.Rarity && nft.ID < best.ID) {
+			best = nft
+		}
+	}
+	return best.ImageURL
+}
+
+// releaseChannelID возвращает канал анонсов релизов (RELEASE_CHANNEL_ID или флуд-канал).
+func (r *Ranking) releaseChannelID() string {
+	if id := os.Getenv("RELEASE_CHANNEL_ID"); id != "" {
+		return id
+	}
+	return r.floodChannelID
+}
+
+// runCollectionReleases публикует тизеры и запускает коллекции, дата выхода которых наступила.
+func (r *Ranking) runCollectionReleases() error {
+	if r.Kki == nil || r.releaseChannelID() == "" {
+		return nil
+	}
+	loc := releaseLocation()
+	now := time.Now()
+	for _, release := range r.collectionReleases() {
+		if launched, _ := r.redis.SIsMember(r.ctx, releaseLaunchedKey, release.Collection).Result(); launched {
+			continue
+		}
+		if !now.Before(release.At) {
+			if added, err := r.redis.SAdd(r.ctx, releaseLaunchedKey, release.Collection).Result(); err != nil || added == 0 {
+				continue
+			}
+			if err := r.launchCollection(release); err != nil {
+				log.Printf("Не удалось объявить релиз коллекции %s: %v", release.Collection, err)
+			}
+			continue
+		}
+		if now.Before(release.At.AddDate(0, 0, -release.TeaserDays)) {
+			continue
+		}
+		day := now.In(loc).Format("2006-01-02")
+		if ok, err := r.redis.SetNX(r.ctx, releaseTeaserKey(release.Collection, day), 1, releaseTeaserKeyTTL).Result(); err != nil || !ok {
+			continue
+		}
+		if err := r.postReleaseTeaser(release); err != nil {
+			log.Printf("Не удалось опубликовать тизер коллекции %s: %v", release.Collection, err)
+		}
+	}
+	return nil
+}
+
+// collectionSize возвращает число NFT в коллекции.
+func (r *Ranking) collectionSize(collection string) int {
+	count := 0
+	for _, nft := range r.Kki.nfts {
+		if nft.Collection == collection {
+			count++
+		}
+	}
+	return count
+}
+
+// postReleaseTeaser публикует тизер коллекции с обратным отсчётом и размытой картинкой.
+func (r *Ranking) postReleaseTeaser(release CollectionRelease) error {
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	description := fmt.Sprintf("Новая коллекция **%s** выходит <t:%d:R> (<t:%d:f>).\nВ коллекции **%d** NFT — что внутри, пока секрет.",
+		release.Collection, release.At.Unix(), release.At.Unix(), r.collectionSize(release.Collection))
+	if kase, ok := r.Kki.cases[release.CaseID]; ok {
+		description += fmt.Sprintf("\n\nВ день релиза в банке появится кейс 📦 **%s**!", kase.Name)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔜 Скоро новая коллекция!",
+		Description: description,
+		Color:       0x9932CC,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Император готовит сюрприз 👑"},
+	}
+	if url := r.images.BlurredURL(r.releaseImage(release)); url != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: url}
+	}
+	_, err = s.ChannelMessageSendEmbed(r.releaseChannelID(), embed)
+	return err
+}
+
+// launchCollection объявляет выход коллекции и кладёт кейс запуска в банк.
+func (r *Ranking) launchCollection(release CollectionRelease) error {
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	log.Printf("Релиз коллекции %s", release.Collection)
+	description := fmt.Sprintf("Коллекция **%s** вышла! Её **%d** NFT уже выпадают из кейсов.", release.Collection, r.collectionSize(release.Collection))
+	if kase, ok := r.Kki.cases[release.CaseID]; ok {
+		stock := r.ReleaseCaseStock()
+		r.refreshCaseBank()
+		r.mu.Lock()
+		r.caseBank.Cases[kase.ID] += stock
+		jsonData, _ := json.Marshal(r.caseBank)
+		r.redis.Set(r.ctx, "case_bank", jsonData, 0)
+		r.mu.Unlock()
+		description += fmt.Sprintf("\n\n📦 В банк завезли **%d** кейсов **%s** по %s: `/buy_case_bank %s <кол-во>`", stock, kase.Name, formatCredits(kase.Price), kase.ID)
+	} else if release.CaseID != "" {
+		log.Printf("Кейс запуска %s коллекции %s не найден", release.CaseID, release.Collection)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🚀 Релиз коллекции!",
+		Description: description,
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Славь Императора! 👑"},
+	}
+	if url := r.images.URL(r.releaseImage(release)); strings.HasPrefix(url, "http") {
+		embed.Image = &discordgo.MessageEmbedImage{URL: url}
+	}
+	_, err = s.ChannelMessageSendEmbed(r.releaseChannelID(), embed)
+	return err
+}
+
+// HandleReleasesCommand обрабатывает команду !a_releases.
+func (r *Ranking) HandleReleasesCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !a_releases от %s", m.Author.ID)
+
+	releases := r.collectionReleases()
+	if len(releases) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Релизов не запланировано. Заполните лист `Releases`: коллекция, дата (`"+releaseTimeLayout+"`), кейс запуска, дней тизеров, картинка — и выполните `/sync_nfts`.")
+		return
+	}
+	launched, err := r.redis.SMembers(r.ctx, releaseLaunchedKey).Result()
+	if err != nil && err != redis.Nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка чтения релизов! Проверьте Redis-сервер.")
+		return
+	}
+	done := make(map[string]bool, len(launched))
+	for _, collection := range launched {
+		done[collection] = true
+	}
+	lines := make([]string, 0, len(releases))
+	for _, release := range releases {
+		status := fmt.Sprintf("⏳ <t:%d:R>", release.At.Unix())
+		if done[release.Collection] {
+			status = "🚀 вышла"
+		} else if !time.Now().Before(release.At) {
+			status = "🕐 запускается"
+		}
+		caseInfo := ""
+		if release.CaseID != "" {
+			caseInfo = " · 📦 " + release.CaseID
+		}
+		lines = append(lines, fmt.Sprintf("**%s** — <t:%d:f> · %s%s · тизеры за %d дн. · %d NFT",
+			release.Collection, release.At.Unix(), status, caseInfo, release.TeaserDays, r.collectionSize(release.Collection)))
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+		Title:       "🗓️ Релизы коллекций",
+		Description: truncate(strings.Join(lines, "\n"), 4000),
+		Color:       0x9932CC,
+	})
+}
@@ -0,0 +1,57 @@
+package ranking
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Защита от повторной обработки массовых админ-команд. В некоторых конфигурациях
+// (прокси, правка сообщения, повторная доставка шлюзом) одно и то же сообщение приходит
+// повторно, а массовые начисления не идемпотентны. ID обработанных сообщений хранятся
+// в Redis, и повторная доставка того же сообщения игнорируется.
+
+// replayProtectedCommands — команды, которые нельзя выполнить дважды по одному сообщению.
+var replayProtectedCommands = []string{
+	"/adminmass",
+	"/a_bulk_grant",
+	"/admin_give_holiday_case_all",
+	"/a_give_holiday_case_all",
+	"/a_announce",
+	"/a_season_end",
+}
+
+// processedMessageKey возвращает ключ обработанного сообщения.
+func processedMessageKey(messageID string) string {
+	return "processed_msg:" + messageID
+}
+
+// isReplayProtected сообщает, защищена ли команда от повторной обработки.
+func isReplayProtected(command string) bool {
+	name := strings.SplitN(command, " ", 2)[0]
+	for _, protected := range replayProtectedCommands {
+		if name == protected {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimCommandMessage отмечает сообщение с массовой админ-командой как обработанное.
+// Возвращает false, если это сообщение уже обрабатывалось (или Redis недоступен) —
+// тогда команду выполнять нельзя. Для остальных команд всегда возвращает true.
+func (r *Ranking) ClaimCommandMessage(messageID, command string) bool {
+	if messageID == "" || !isReplayProtected(command) {
+		return true
+	}
+	ttl := time.Duration(envInt("REPLAY_TTL_HOURS", 168)) * time.Hour
+	ok, err := r.redis.SetNX(r.ctx, processedMessageKey(messageID), command, ttl).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить повтор сообщения %s: %v", messageID, err)
+		return false
+	}
+	if !ok {
+		log.Printf("Сообщение %s с командой %s уже обработано, повтор проигнорирован", messageID, strings.SplitN(command, " ", 2)[0])
+	}
+	return ok
+}
@@ -0,0 +1,231 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Ключи метрик удержания.
+const (
+	retentionLastSeenKey = "retention:last_seen" // ZSET userID -> unix-время последней активности
+	retentionReturnsKey  = "retention:returns"   // ZSET userID -> unix-время последнего возвращения
+	retentionDecayedKey  = "retention:decayed"   // всего списано кредитов за неактивность
+)
+
+// retentionWinBackKey — метка выданного приветствия возвращения, чтобы параллельные
+// сообщения вернувшегося игрока не выдали кейс несколько раз.
+func retentionWinBackKey(userID string) string {
+	return "retention:winback:" + userID
+}
+
+// InactiveDays возвращает число дней без активности, после которого игрок считается неактивным.
+func (r *Ranking) InactiveDays() int {
+	return r.GetIntSetting("inactive_days", envInt("INACTIVE_DAYS", 14))
+}
+
+// InactiveDecayPercent возвращает процент ежедневного списания баланса неактивных игроков (0 — только отчёт).
+func (r *Ranking) InactiveDecayPercent() int {
+	return r.GetIntSetting("inactive_decay_percent", envInt("INACTIVE_DECAY_PERCENT", 0))
+}
+
+// TouchActivity отмечает активность пользователя и отправляет приветствие, если он вернулся после долгого отсутствия.
+func (r *Ranking) TouchActivity(s *discordgo.Session, userID string) {
+	now := time.Now()
+	lastSeen, err := r.redis.ZScore(r.ctx, retentionLastSeenKey, userID).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось получить последнюю активность %s: %v", userID, err)
+		return
+	}
+	if err := r.redis.ZAdd(r.ctx, retentionLastSeenKey, &redis.Z{Score: float64(now.Unix()), Member: userID}).Err(); err != nil {
+		log.Printf("Не удалось сохранить активность %s: %v", userID, err)
+		return
+	}
+	if err == redis.Nil || lastSeen == 0 {
+		return
+	}
+
+	away := now.Sub(time.Unix(int64(lastSeen), 0))
+	inactive := time.Duration(r.InactiveDays()) * 24 * time.Hour
+	if inactive <= 0 || away < inactive {
+		return
+	}
+	// ZScore и ZAdd не атомарны: несколько одновременных сообщений видят одно и то же старое время.
+	// Приветствие получает только тот, кто первым поставил метку; новое возвращение возможно
+	// не раньше, чем через ещё один срок неактивности.
+	claimed, err := r.redis.SetNX(r.ctx, retentionWinBackKey(userID), now.Unix(), inactive).Result()
+	if err != nil {
+		log.Printf("Не удалось отметить возвращение %s: %v", userID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	r.redis.ZAdd(r.ctx, retentionReturnsKey, &redis.Z{Score: float64(now.Unix()), Member: userID})
+	r.redis.ZRemRangeByScore(r.ctx, retentionReturnsKey, "-inf", strconv.FormatInt(now.Add(-30*24*time.Hour).Unix(), 10))
+	log.Printf("Пользователь %s вернулся после %d дней отсутствия", userID, int(away.Hours()/24))
+	go r.sendWinBack(s, userID, int(away.Hours()/24))
+}
+
+// comebackCase возвращает кейс, выдаваемый вернувшимся игрокам: COMEBACK_CASE_ID или самый дешёвый.
+func (r *Ranking) comebackCase() (Case, bool) {
+	if r.Kki == nil {
+		return Case{}, false
+	}
+	if id := os.Getenv("COMEBACK_CASE_ID"); id != "" {
+		kase, ok := r.Kki.cases[id]
+		if !ok {
+			log.Printf("COMEBACK_CASE_ID=%s не найден среди кейсов", id)
+		}
+		return kase, ok
+	}
+	var cheapest Case
+	found := false
+	for _, kase := range r.Kki.cases {
+		if !found || kase.Price < cheapest.Price {
+			cheapest = kase
+			found = true
+		}
+	}
+	return cheapest, found
+}
+
+// sendWinBack выдаёт вернувшемуся игроку кейс и отправляет ему личное сообщение.
+func (r *Ranking) sendWinBack(s *discordgo.Session, userID string, daysAway int) {
+	text := fmt.Sprintf("👋 С возвращением! Тебя не было %d дней, Император скучал! 👑", daysAway)
+	if kase, ok := r.comebackCase(); ok {
+		inv := r.Kki.GetUserCaseInventory(r, userID)
+		inv[kase.ID]++
+		if err := r.Kki.SaveUserCaseInventory(r, userID, inv); err != nil {
+			log.Printf("Не удалось выдать кейс возвращения %s пользователю %s: %v", kase.ID, userID, err)
+		} else {
+			text += fmt.Sprintf("\n\n🎁 Держи подарок: 📦 **%s** (ID: %s). Открыть: `/open_case %s`", kase.Name, kase.ID, kase.ID)
+			r.LogCreditOperation(s, fmt.Sprintf("🎁 <@%s> вернулся после %d дней и получил кейс возвращения 📦 %s", userID, daysAway, kase.Name))
+		}
+	}
+
+	if err := r.sendDM(s, userID, text, true); err != nil {
+		log.Printf("Не удалось отправить приветствие возвращения %s: %v", userID, err)
+	}
+}
+
+// applyInactiveDecay списывает процент баланса у игроков, неактивных дольше InactiveDays.
+func (r *Ranking) applyInactiveDecay() {
+	percent := r.InactiveDecayPercent()
+	if percent <= 0 {
+		return
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	cutoff := time.Now().Add(-time.Duration(r.InactiveDays()) * 24 * time.Hour).Unix()
+	inactive, err := r.redis.ZRangeByScore(r.ctx, retentionLastSeenKey, &redis.ZRangeBy{Min: "-inf", Max: strconv.FormatInt(cutoff, 10)}).Result()
+	if err != nil {
+		log.Printf("Ошибка получения неактивных игроков: %v", err)
+		return
+	}
+
+	total := 0
+	for _, userID := range inactive {
+		rating := r.GetRating(userID)
+		amount := rating * percent / 100
+		if amount <= 0 {
+			continue
+		}
+		r.UpdateRatingFrom(userID, -amount, "decay", "")
+		total += amount
+	}
+	if total > 0 {
+		r.redis.IncrBy(r.ctx, retentionDecayedKey, int64(total))
+	}
+	log.Printf("Списано %d кредитов за неактивность у %d игроков (%d%%)", total, len(inactive), percent)
+}
+
+// HandleRetentionCommand обрабатывает команду !a_retention [days <N> | decay <процент>].
+func (r *Ranking) HandleRetentionCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_retention: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть удержание! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		value, err := strconv.Atoi(parts[2])
+		if err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Значение должно быть неотрицательным числом!")
+			return
+		}
+		var setting string
+		switch parts[1] {
+		case "days":
+			if value == 0 {
+				s.ChannelMessageSend(m.ChannelID, "❌ Число дней должно быть больше нуля!")
+				return
+			}
+			setting = "inactive_days"
+		case "decay":
+			if value > 100 {
+				s.ChannelMessageSend(m.ChannelID, "❌ Процент списания должен быть от 0 до 100!")
+				return
+			}
+			setting = "inactive_decay_percent"
+		default:
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_retention [days <N> | decay <процент>]`")
+			return
+		}
+		if err := r.SetIntSetting(setting, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Настройка `%s` = %d", setting, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_retention [days <N> | decay <процент>]`")
+		return
+	}
+
+	now := time.Now()
+	since := func(d time.Duration) string { return strconv.FormatInt(now.Add(-d).Unix(), 10) }
+	day := 24 * time.Hour
+	inactiveDays := r.InactiveDays()
+
+	tracked, _ := r.redis.ZCard(r.ctx, retentionLastSeenKey).Result()
+	active1d, _ := r.redis.ZCount(r.ctx, retentionLastSeenKey, since(day), "+inf").Result()
+	active7d, _ := r.redis.ZCount(r.ctx, retentionLastSeenKey, since(7*day), "+inf").Result()
+	active30d, _ := r.redis.ZCount(r.ctx, retentionLastSeenKey, since(30*day), "+inf").Result()
+	inactive, _ := r.redis.ZCount(r.ctx, retentionLastSeenKey, "-inf", since(time.Duration(inactiveDays)*day)).Result()
+	returned7d, _ := r.redis.ZCount(r.ctx, retentionReturnsKey, since(7*day), "+inf").Result()
+	decayed, _ := r.redis.Get(r.ctx, retentionDecayedKey).Int()
+
+	decay := "выключено (только отчёт)"
+	if percent := r.InactiveDecayPercent(); percent > 0 {
+		decay = fmt.Sprintf("%d%% в день", percent)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "📉 Удержание игроков",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "👥 Отслеживается", Value: fmt.Sprintf("%d", tracked), Inline: true},
+			{Name: "🎮 Активны", Value: fmt.Sprintf("1д: %d\n7д: %d\n30д: %d", active1d, active7d, active30d), Inline: true},
+			{Name: "💤 Неактивны", Value: fmt.Sprintf("%d (>%d дней)", inactive, inactiveDays), Inline: true},
+			{Name: "👋 Вернулись за 7д", Value: fmt.Sprintf("%d", returned7d), Inline: true},
+			{Name: "🔥 Списание", Value: decay, Inline: true},
+			{Name: "💸 Списано всего", Value: formatCredits(decayed), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_retention days <N> | decay <процент>"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
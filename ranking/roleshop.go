@@ -0,0 +1,236 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Магазин ролей: админы выставляют роли Discord за кредиты, игроки покупают их командой /shop buy.
+const (
+	shopRolesKey = "shop:roles" // хэш ID товара -> ShopRole (JSON)
+	shopSeqKey   = "shop:seq"   // счётчик ID товаров
+)
+
+// ShopRole — роль Discord, продающаяся в магазине.
+type ShopRole struct {
+	ID      int    `json:"id"`
+	RoleID  string `json:"role_id"`
+	Name    string `json:"name"`
+	Price   int    `json:"price"`
+	AddedBy string `json:"added_by"`
+}
+
+// shopRoles возвращает роли магазина, отсортированные по цене.
+func (r *Ranking) shopRoles() ([]ShopRole, error) {
+	raw, err := r.redis.HGetAll(r.ctx, shopRolesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]ShopRole, 0, len(raw))
+	for _, data := range raw {
+		var role ShopRole
+		if err := json.Unmarshal([]byte(data), &role); err != nil {
+			log.Printf("Не удалось разобрать роль магазина: %v", err)
+			continue
+		}
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].Price != roles[j].Price {
+			return roles[i].Price < roles[j].Price
+		}
+		return roles[i].ID < roles[j].ID
+	})
+	return roles, nil
+}
+
+// shopRole ищет роль магазина по ID товара.
+func (r *Ranking) shopRole(id string) (ShopRole, bool) {
+	var role ShopRole
+	data, err := r.redis.HGet(r.ctx, shopRolesKey, id).Bytes()
+	if err != nil {
+		return role, false
+	}
+	return role, json.Unmarshal(data, &role) == nil
+}
+
+// memberHasRole проверяет, есть ли у участника роль.
+func memberHasRole(s *discordgo.Session, guildID, userID, roleID string) bool {
+	member, err := s.State.Member(guildID, userID)
+	if err != nil {
+		if member, err = s.GuildMember(guildID, userID); err != nil {
+			return false
+		}
+	}
+	for _, id := range member.Roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleShopAddRoleCommand обрабатывает команду !shop_add_role @роль <цена>.
+func (r *Ranking) HandleShopAddRoleCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_add_role: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут выставлять роли в магазин! 🔒")
+		return
+	}
+	parts := strings.Fields(command)
+	if len(parts) != 3 || len(m.MentionRoles) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/shop_add_role @роль <цена>`")
+		return
+	}
+	price, err := strconv.Atoi(parts[2])
+	if err != nil || price <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Цена должна быть положительным числом! 💸")
+		return
+	}
+
+	roleID := m.MentionRoles[0]
+	roles, err := r.shopRoles()
+	if err != nil {
+		log.Printf("Не удалось получить роли магазина: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка магазина! Проверьте Redis-сервер.")
+		return
+	}
+	for _, role := range roles {
+		if role.RoleID == roleID {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Роль <@&%s> уже продаётся (товар #%d).", roleID, role.ID))
+			return
+		}
+	}
+
+	name := roleID
+	if role, err := s.State.Role(m.GuildID, roleID); err == nil {
+		name = role.Name
+	}
+	id, err := r.redis.Incr(r.ctx, shopSeqKey).Result()
+	if err != nil {
+		log.Printf("Не удалось получить ID товара: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка магазина! Проверьте Redis-сервер.")
+		return
+	}
+	item := ShopRole{ID: int(id), RoleID: roleID, Name: name, Price: price, AddedBy: m.Author.ID}
+	data, _ := json.Marshal(item)
+	if err := r.redis.HSet(r.ctx, shopRolesKey, strconv.Itoa(item.ID), data).Err(); err != nil {
+		log.Printf("Не удалось сохранить роль магазина: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка магазина! Проверьте Redis-сервер.")
+		return
+	}
+
+	r.LogCreditOperation(s, fmt.Sprintf("🛒 <@%s> выставил роль **%s** в магазин за %s (товар #%d)", m.Author.ID, name, formatCredits(price), item.ID))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Роль <@&%s> продаётся за %s. Купить: `/shop buy %d`", roleID, formatCredits(price), item.ID))
+}
+
+// HandleShopRemoveRoleCommand обрабатывает команду !shop_remove_role <ID>.
+func (r *Ranking) HandleShopRemoveRoleCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop_remove_role: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут убирать роли из магазина! 🔒")
+		return
+	}
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/shop_remove_role <ID>`")
+		return
+	}
+	id := strings.TrimPrefix(parts[1], "#")
+	removed, err := r.redis.HDel(r.ctx, shopRolesKey, id).Result()
+	if err != nil || removed == 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такого товара нет! Список: `/shop`")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Товар #%s снят с продажи.", id))
+}
+
+// HandleShopCommand обрабатывает команду !shop [buy <ID> | @игрок].
+func (r *Ranking) HandleShopCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !shop: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	switch {
+	case len(parts) == 1:
+		r.sendRoleShop(s, m)
+	case len(parts) == 3 && parts[1] == "buy":
+		r.buyShopRole(s, m, strings.TrimPrefix(parts[2], "#"))
+	case len(parts) == 2 && len(m.Mentions) == 1:
+		r.sendPlayerShop(s, m.ChannelID, m.Mentions[0])
+	default:
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/shop` — список ролей, `/shop buy <ID>` — купить, `/shop @игрок` — лавка игрока")
+	}
+}
+
+// sendRoleShop отправляет список ролей магазина.
+func (r *Ranking) sendRoleShop(s *discordgo.Session, m *discordgo.MessageCreate) {
+	roles, err := r.shopRoles()
+	if err != nil {
+		log.Printf("Не удалось получить роли магазина: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка магазина! Попробуйте позже.")
+		return
+	}
+	if len(roles) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "🛒 Магазин ролей пуст. Император ещё не выставил товары! 👑")
+		return
+	}
+
+	lines := make([]string, 0, len(roles))
+	for _, role := range roles {
+		line := fmt.Sprintf("`#%d` <@&%s> — %s", role.ID, role.RoleID, formatCredits(role.Price))
+		if memberHasRole(s, m.GuildID, m.Author.ID, role.RoleID) {
+			line += " ✅"
+		}
+		lines = append(lines, line)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🛒 Магазин ролей",
+		Description: truncate(strings.Join(lines, "\n"), 4000),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("/shop buy <ID> · Твой баланс: %s кредитов", compactNumber(r.GetRating(m.Author.ID)))},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// buyShopRole списывает кредиты и выдаёт роль. Если Discord не выдал роль, кредиты возвращаются.
+func (r *Ranking) buyShopRole(s *discordgo.Session, m *discordgo.MessageCreate, id string) {
+	role, ok := r.shopRole(id)
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такого товара нет! Список: `/shop`")
+		return
+	}
+	if memberHasRole(s, m.GuildID, m.Author.ID, role.RoleID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Роль **%s** уже твоя!", role.Name))
+		return
+	}
+
+	r.mu.Lock()
+	rating := r.GetRating(m.Author.ID)
+	if rating < role.Price {
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Роль стоит %s, твой баланс: %s", formatCredits(role.Price), formatCredits(rating)))
+		return
+	}
+	r.UpdateRatingFrom(m.Author.ID, -role.Price, "role_shop", "")
+	r.mu.Unlock()
+
+	if err := s.GuildMemberRoleAdd(m.GuildID, m.Author.ID, role.RoleID); err != nil {
+		log.Printf("Не удалось выдать роль %s пользователю %s: %v", role.RoleID, m.Author.ID, err)
+		r.mu.Lock()
+		r.UpdateRatingFrom(m.Author.ID, role.Price, "role_shop", "")
+		r.mu.Unlock()
+		s.ChannelMessageSend(m.ChannelID, "❌ Не удалось выдать роль (проверьте права бота), кредиты возвращены.")
+		return
+	}
+
+	r.LogCreditOperation(s, fmt.Sprintf("🛒 <@%s> купил роль **%s** за %s", m.Author.ID, role.Name, formatCredits(role.Price)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ <@%s> купил роль <@&%s> за %s! 🎉", m.Author.ID, role.RoleID, formatCredits(role.Price)))
+}
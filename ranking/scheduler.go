@@ -0,0 +1,449 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// schedulerStateKey — хэш Redis с состоянием задач планировщика (имя -> JobState).
+const schedulerStateKey = "scheduler:jobs"
+
+// Job описывает периодическую задачу планировщика.
+type Job struct {
+	Name     string
+	Interval time.Duration                 // период запуска, если Next не задан
+	Next     func(now time.Time) time.Time // вычисляет время следующего запуска (например, ежедневно в 4:00)
+	Jitter   time.Duration                 // случайная задержка, добавляемая к каждому запуску
+	Run      func() error
+
+	state JobState
+	wake  chan struct{}
+}
+
+// JobState хранит состояние задачи, переживающее перезапуск бота.
+type JobState struct {
+	NextRun      time.Time `json:"next_run"`
+	LastRun      time.Time `json:"last_run"`
+	LastDuration int64     `json:"last_duration_ms"`
+	LastError    string    `json:"last_error,omitempty"`
+	Runs         int       `json:"runs"`
+	Running      bool      `json:"-"`
+}
+
+// Scheduler запускает именованные задачи по расписанию и сохраняет время следующего запуска в Redis.
+type Scheduler struct {
+	mu      sync.Mutex
+	r       *Ranking
+	jobs    map[string]*Job
+	stop    chan struct{}
+	started bool
+}
+
+// NewScheduler создаёт планировщик задач.
+func NewScheduler(r *Ranking) *Scheduler {
+	return &Scheduler{
+		r:    r,
+		jobs: make(map[string]*Job),
+		stop: make(chan struct{}),
+	}
+}
+
+// Register добавляет задачу. Если планировщик уже запущен, задача стартует сразу.
+func (sc *Scheduler) Register(job *Job) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, exists := sc.jobs[job.Name]; exists {
+		log.Printf("Задача %s уже зарегистрирована", job.Name)
+		return
+	}
+	job.wake = make(chan struct{}, 1)
+	sc.jobs[job.Name] = job
+	if sc.started {
+		go sc.loop(job)
+	}
+}
+
+// Start восстанавливает состояние задач из Redis и запускает их.
+func (sc *Scheduler) Start() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.started {
+		return
+	}
+	sc.started = true
+	for _, job := range sc.jobs {
+		go sc.loop(job)
+	}
+	log.Printf("Планировщик запущен, задач: %d", len(sc.jobs))
+}
+
+// Started сообщает, запущен ли планировщик в этом процессе.
+func (sc *Scheduler) Started() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.started
+}
+
+// StartScheduler запускает фоновые задачи. При разделении шардов между процессами (SHARD_IDS)
+// вызывается только в процессе с шардом 0, иначе выплаты (UBI, проценты, зарплаты, аирдропы)
+// начислялись бы по разу на каждый процесс.
+func (r *Ranking) StartScheduler() {
+	r.scheduler.Start()
+}
+
+// Stop останавливает все задачи.
+func (sc *Scheduler) Stop() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	select {
+	case <-sc.stop:
+	default:
+		close(sc.stop)
+	}
+}
+
+// RunNow запускает задачу вне расписания.
+func (sc *Scheduler) RunNow(name string) bool {
+	sc.mu.Lock()
+	job, ok := sc.jobs[name]
+	sc.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case job.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// nextRun вычисляет время следующего запуска задачи с учётом джиттера.
+func (job *Job) nextRun(now time.Time) time.Time {
+	next := now.Add(job.Interval)
+	if job.Next != nil {
+		next = job.Next(now)
+	}
+	if job.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(job.Jitter))))
+	}
+	return next
+}
+
+// loadState читает сохранённое состояние задачи из Redis.
+func (sc *Scheduler) loadState(job *Job) JobState {
+	var state JobState
+	data, err := sc.r.redis.HGet(sc.r.ctx, schedulerStateKey, job.Name).Result()
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		log.Printf("Не удалось разобрать состояние задачи %s: %v", job.Name, err)
+	}
+	return state
+}
+
+// saveState сохраняет состояние задачи в Redis.
+func (sc *Scheduler) saveState(job *Job, state JobState) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if err := sc.r.redis.HSet(sc.r.ctx, schedulerStateKey, job.Name, data).Err(); err != nil {
+		log.Printf("Не удалось сохранить состояние задачи %s: %v", job.Name, err)
+	}
+}
+
+// loop ожидает время запуска задачи и выполняет её до остановки планировщика.
+func (sc *Scheduler) loop(job *Job) {
+	state := sc.loadState(job)
+	now := time.Now()
+	if state.NextRun.IsZero() {
+		state.NextRun = job.nextRun(now)
+	} else if state.NextRun.Before(now) {
+		// Запуск пропущен, пока бот был выключен — выполняем в ближайшее время
+		state.NextRun = now
+		if job.Jitter > 0 {
+			state.NextRun = now.Add(time.Duration(rand.Int63n(int64(job.Jitter))))
+		}
+		log.Printf("Задача %s пропустила запуск, будет выполнена в %s", job.Name, state.NextRun.Format(time.RFC3339))
+	}
+	sc.setState(job, state)
+
+	for {
+		select {
+		case <-time.After(time.Until(state.NextRun)):
+		case <-job.wake:
+		case <-sc.stop:
+			log.Printf("Задача %s остановлена", job.Name)
+			return
+		}
+
+		state = sc.run(job, state)
+	}
+}
+
+// run выполняет задачу, перехватывая панику, и планирует следующий запуск.
+func (sc *Scheduler) run(job *Job, state JobState) JobState {
+	state.Running = true
+	sc.setState(job, state)
+
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				CapturePanic(p, map[string]string{"job": job.Name})
+				err = fmt.Errorf("паника: %v", p)
+			}
+		}()
+		return job.Run()
+	}()
+
+	state.Running = false
+	state.LastRun = start
+	state.LastDuration = time.Since(start).Milliseconds()
+	state.Runs++
+	state.LastError = ""
+	if err != nil {
+		state.LastError = err.Error()
+		log.Printf("Задача %s завершилась с ошибкой: %v", job.Name, err)
+		CaptureError(err, map[string]string{"job": job.Name})
+	}
+	state.NextRun = job.nextRun(time.Now())
+	sc.setState(job, state)
+	return state
+}
+
+// setState обновляет состояние задачи в памяти и в Redis.
+func (sc *Scheduler) setState(job *Job, state JobState) {
+	sc.mu.Lock()
+	job.state = state
+	sc.mu.Unlock()
+	sc.saveState(job, state)
+}
+
+// States возвращает снимок состояния всех задач, отсортированный по имени.
+func (sc *Scheduler) States() ([]string, map[string]JobState) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	names := make([]string, 0, len(sc.jobs))
+	states := make(map[string]JobState, len(sc.jobs))
+	for name, job := range sc.jobs {
+		names = append(names, name)
+		states[name] = job.state
+	}
+	sort.Strings(names)
+	return names, states
+}
+
+// dailyAt возвращает функцию расписания «каждый день в hour:00» в часовом поясе loc.
+func dailyAt(hour int, loc *time.Location) func(now time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		local := now.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, loc)
+		if !next.After(local) {
+			next = next.Add(24 * time.Hour)
+		}
+		return next
+	}
+}
+
+// weeklyAt возвращает функцию расписания «каждую неделю в день weekday в hour:00» в часовом поясе loc.
+func weeklyAt(weekday time.Weekday, hour int, loc *time.Location) func(now time.Time) time.Time {
+	daily := dailyAt(hour, loc)
+	return func(now time.Time) time.Time {
+		next := daily(now)
+		for next.Weekday() != weekday {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
+
+// registerDefaultJobs регистрирует фоновые задачи бота.
+func (r *Ranking) registerDefaultJobs() {
+	r.scheduler.Register(&Job{
+		Name:     "bitcoin_updater",
+		Interval: 5 * time.Minute,
+		Jitter:   time.Minute,
+		Run: func() error {
+			price, err := r.GetBitcoinPrice()
+			if err != nil {
+				return err
+			}
+			log.Printf("✅ Курс BTC обновлен: $%.2f", price)
+			return nil
+		},
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "price_updater",
+		Interval: 15 * time.Minute,
+		Jitter:   30 * time.Second,
+		Run:      r.updateNFTPrices,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "voice_events",
+		Interval: time.Minute,
+		Run:      r.refreshVoiceEvents,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "escrow_sweeper",
+		Interval: time.Minute,
+		Jitter:   5 * time.Second,
+		Run:      r.sweepExpiredEscrow,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "airdrop",
+		Interval: time.Minute,
+		Run:      r.runAirdrops,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "quiet_flush",
+		Interval: 10 * time.Minute,
+		Run:      r.flushQuietQueues,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "collection_releases",
+		Interval: time.Minute,
+		Run:      r.runCollectionReleases,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "payroll",
+		Interval: 10 * time.Minute,
+		Run:      r.runPayroll,
+	})
+
+	r.scheduler.Register(&Job{
+		Name:     "inflation_controller",
+		Interval: 10 * time.Minute,
+		Run:      r.runInflationController,
+	})
+
+	if r.images != nil {
+		r.scheduler.Register(&Job{
+			Name:     "image_cache",
+			Interval: 6 * time.Hour,
+			Jitter:   time.Minute,
+			Run:      r.warmImageCache,
+		})
+		r.scheduler.RunNow("image_cache")
+	}
+
+	loc, err := time.LoadLocation("Asia/Krasnoyarsk")
+	if err != nil {
+		log.Printf("Ошибка загрузки часового пояса Asia/Krasnoyarsk: %v", err)
+		return
+	}
+	r.scheduler.Register(&Job{
+		Name: "daily_reset",
+		Next: dailyAt(4, loc),
+		Run: func() error {
+			r.resetAllLimits()
+			log.Printf("Автоматический сброс лимитов выполнен в %s", time.Now().In(loc).Format(time.RFC3339))
+			r.applyInactiveDecay()
+			return nil
+		},
+	})
+	r.scheduler.Register(&Job{
+		Name: "escrow_reconcile",
+		Next: dailyAt(3, loc),
+		Run:  r.runEscrowReconcile,
+	})
+	r.scheduler.Register(&Job{
+		Name: "bank_interest",
+		Next: dailyAt(5, loc),
+		Run:  r.applyBankInterest,
+	})
+	r.scheduler.Register(&Job{
+		Name: "debt_interest",
+		Next: dailyAt(5, loc),
+		Run:  r.applyDebtInterest,
+	})
+	r.scheduler.Register(&Job{
+		Name: "weekly_ubi",
+		Next: weeklyAt(time.Monday, 12, loc),
+		Run:  r.payWeeklyUBI,
+	})
+	r.scheduler.Register(&Job{
+		Name: "weekly_economy_report",
+		Next: weeklyAt(time.Monday, 10, loc),
+		Run:  r.postWeeklyEconomyReport,
+	})
+	r.registerSheetsSnapshotJob(loc)
+}
+
+// HandleJobsCommand обрабатывает команду !a_jobs [run <имя>].
+func (r *Ranking) HandleJobsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_jobs: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть задачи! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 3 && parts[1] == "run" {
+		if !r.scheduler.Started() {
+			s.ChannelMessageSend(m.ChannelID, "❌ Планировщик работает в процессе с шардом 0 — запусти команду там.")
+			return
+		}
+		if !r.scheduler.RunNow(parts[2]) {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Задача `%s` не найдена!", parts[2]))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("▶️ Задача `%s` запущена вне расписания.", parts[2]))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_jobs [run <имя>]`")
+		return
+	}
+
+	names, states := r.scheduler.States()
+	if len(names) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Задачи не зарегистрированы.")
+		return
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(names))
+	for _, name := range names {
+		state := states[name]
+		status := "🟢"
+		if state.Running {
+			status = "🔄"
+		} else if state.LastError != "" {
+			status = "🔴"
+		}
+		lastRun := "ещё не запускалась"
+		if !state.LastRun.IsZero() {
+			lastRun = fmt.Sprintf("<t:%d:R> (%d мс)", state.LastRun.Unix(), state.LastDuration)
+		}
+		value := fmt.Sprintf("Следующий запуск: <t:%d:R>\nПоследний: %s\nЗапусков: %d", state.NextRun.Unix(), lastRun, state.Runs)
+		if state.LastError != "" {
+			value += fmt.Sprintf("\nОшибка: `%s`", state.LastError)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: status + " " + name, Value: value})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "⏱️ Фоновые задачи",
+		Color:  randomColor(),
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{Text: "/a_jobs run <имя> — запустить сейчас"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
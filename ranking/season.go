@@ -0,0 +1,276 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Сезоны: по окончании сезона балансы и статистика архивируются, экономика обнуляется
+// до стартового баланса, а лучшие игроки получают значки престижа.
+const (
+	seasonCurrentKey = "season:current" // номер текущего сезона
+	seasonStartedKey = "season:started" // unix-время начала текущего сезона
+	seasonWinners    = 3                // сколько лучших игроков получают престиж
+)
+
+// seasonPlaceBadges — значки престижа по местам.
+var seasonPlaceBadges = []string{"🥇", "🥈", "🥉"}
+
+// SeasonWinner — игрок, занявший призовое место в сезоне.
+type SeasonWinner struct {
+	UserID string `json:"user_id"`
+	Rating int    `json:"rating"`
+}
+
+// seasonArchiveKey возвращает ключ архива пользователей сезона (userID -> User JSON).
+func seasonArchiveKey(season int) string {
+	return fmt.Sprintf("season:%d:archive", season)
+}
+
+// seasonBankKey возвращает ключ архива банковских вкладов сезона.
+func seasonBankKey(season int) string {
+	return fmt.Sprintf("season:%d:bank", season)
+}
+
+// seasonLoansKey возвращает ключ архива списанных займов сезона (userID -> долг).
+func seasonLoansKey(season int) string {
+	return fmt.Sprintf("season:%d:loans", season)
+}
+
+// seasonWinnersKey возвращает ключ призёров сезона.
+func seasonWinnersKey(season int) string {
+	return fmt.Sprintf("season:%d:winners", season)
+}
+
+// prestigeKey возвращает ключ значков престижа пользователя.
+func prestigeKey(userID string) string {
+	return "season:prestige:" + userID
+}
+
+// currentSeason возвращает номер текущего сезона (первый сезон — 1).
+func (r *Ranking) currentSeason() int {
+	season, err := r.redis.Get(r.ctx, seasonCurrentKey).Int()
+	if err != nil || season < 1 {
+		return 1
+	}
+	return season
+}
+
+// prestigeBadges возвращает значки престижа пользователя за прошлые сезоны.
+func (r *Ranking) prestigeBadges(userID string) []string {
+	badges, err := r.redis.LRange(r.ctx, prestigeKey(userID), 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось получить престиж %s: %v", userID, err)
+	}
+	return badges
+}
+
+// seasonWinnersOf возвращает призёров завершённого сезона.
+func (r *Ranking) seasonWinnersOf(season int) []SeasonWinner {
+	var winners []SeasonWinner
+	data, err := r.redis.Get(r.ctx, seasonWinnersKey(season)).Bytes()
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &winners); err != nil {
+		log.Printf("Не удалось разобрать призёров сезона %d: %v", season, err)
+	}
+	return winners
+}
+
+// endSeason архивирует сезон, награждает призёров и сбрасывает балансы до стартового.
+// Возвращает призёров и число сброшенных игроков.
+func (r *Ranking) endSeason() ([]SeasonWinner, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	season := r.currentSeason()
+	refunded, forgiven, err := r.closeSeasonObligations(season)
+	if err != nil {
+		return nil, 0, err
+	}
+	log.Printf("Сезон %d: возвращено удержаний — %d, списано займов — %d", season, refunded, forgiven)
+
+	top, err := r.redis.ZRevRangeByScoreWithScores(r.ctx, economyBalancesKey, &redis.ZRangeBy{Min: "(0", Max: "+inf", Count: seasonWinners}).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("не удалось получить лидеров: %v", err)
+	}
+	winners := make([]SeasonWinner, 0, len(top))
+	for _, entry := range top {
+		userID, _ := entry.Member.(string)
+		winners = append(winners, SeasonWinner{UserID: userID, Rating: int(entry.Score)})
+	}
+
+	// Архив: пользователи целиком и банковские вклады. Уже заархивированные игроки пропускаются,
+	// чтобы повтор после сбоя не перезаписал архив сброшенным балансом.
+	startBalance := r.StartingBalance()
+	reset := 0
+	err = r.scanKeys("user:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil || user.ID == "" {
+			return nil
+		}
+		archived, err := r.redis.HExists(r.ctx, seasonArchiveKey(season), user.ID).Result()
+		if err != nil {
+			return err
+		}
+		if archived {
+			return nil
+		}
+		old := user.Rating
+		user.Rating = startBalance
+		updated, _ := json.Marshal(user)
+		pipe := r.redis.TxPipeline()
+		pipe.HSet(r.ctx, seasonArchiveKey(season), user.ID, data)
+		pipe.Set(r.ctx, key, updated, 0)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return err
+		}
+		r.users.put(user.ID, user, true)
+		r.recordEconomyDelta(user.ID, old, user.Rating)
+		r.recordCreditLedger(user.ID, user.Rating-old, user.Rating, "season", "", "")
+		reset++
+		return nil
+	})
+	if err != nil {
+		return nil, reset, fmt.Errorf("архивация прервана после %d игроков: %v", reset, err)
+	}
+	if exists, _ := r.redis.Exists(r.ctx, bankDepositsKey).Result(); exists > 0 {
+		r.redis.Rename(r.ctx, bankDepositsKey, seasonBankKey(season))
+	}
+
+	for place, winner := range winners {
+		badge := fmt.Sprintf("%s Сезон %d", seasonPlaceBadges[place], season)
+		r.redis.RPush(r.ctx, prestigeKey(winner.UserID), badge)
+	}
+	data, _ := json.Marshal(winners)
+	pipe := r.redis.TxPipeline()
+	pipe.Set(r.ctx, seasonWinnersKey(season), data, 0)
+	pipe.Set(r.ctx, seasonCurrentKey, season+1, 0)
+	pipe.Set(r.ctx, seasonStartedKey, time.Now().Unix(), 0)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return winners, reset, fmt.Errorf("не удалось начать новый сезон: %v", err)
+	}
+	return winners, reset, nil
+}
+
+// closeSeasonObligations закрывает обязательства сезона до сброса балансов: удержания эскроу
+// возвращаются владельцам (иначе возврат после сброса добавил бы кредиты старого сезона),
+// а займы архивируются и списываются вместе с балансами. Вызывается под r.mu.
+// Игры, чьи удержания возвращены, при завершении получат errEscrowClosed и не спишут ставку.
+func (r *Ranking) closeSeasonObligations(season int) (refunded, forgiven int, err error) {
+	err = r.scanKeys("escrow:hold:*", func(key string) error {
+		if _, err := r.releaseLocked(strings.TrimPrefix(key, "escrow:hold:")); err == nil {
+			refunded++
+		}
+		return nil
+	})
+	if err != nil {
+		return refunded, forgiven, fmt.Errorf("не удалось вернуть удержания: %v", err)
+	}
+	err = r.scanKeys("loan:*", func(key string) error {
+		userID := strings.TrimPrefix(key, "loan:")
+		if loan, ok := r.getLoan(userID); ok {
+			if err := r.redis.HSetNX(r.ctx, seasonLoansKey(season), userID, loan.Owed).Err(); err != nil {
+				return err
+			}
+		}
+		if err := r.redis.Del(r.ctx, key).Err(); err != nil {
+			return err
+		}
+		forgiven++
+		return nil
+	})
+	if err != nil {
+		return refunded, forgiven, fmt.Errorf("не удалось списать займы: %v", err)
+	}
+	return refunded, forgiven, nil
+}
+
+// formatSeasonWinners форматирует призёров сезона по строке на место.
+func (r *Ranking) formatSeasonWinners(winners []SeasonWinner) string {
+	if len(winners) == 0 {
+		return "—"
+	}
+	lines := make([]string, 0, len(winners))
+	for place, winner := range winners {
+		lines = append(lines, fmt.Sprintf("%s %s — %s", seasonPlaceBadges[place], r.publicMention(winner.UserID), formatCredits(winner.Rating)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HandleSeasonCommand обрабатывает команду !season.
+func (r *Ranking) HandleSeasonCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !season от %s", m.Author.ID)
+
+	season := r.currentSeason()
+	description := fmt.Sprintf("Идёт **сезон %d**.", season)
+	if started, err := r.redis.Get(r.ctx, seasonStartedKey).Int64(); err == nil {
+		description += fmt.Sprintf(" Начался <t:%d:D>.", started)
+	}
+	if badges := r.prestigeBadges(m.Author.ID); len(badges) > 0 {
+		description += "\n\nТвой престиж: " + strings.Join(badges, " · ")
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for past := season - 1; past >= 1 && len(fields) < 5; past-- {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("🏆 Сезон %d", past),
+			Value: r.formatSeasonWinners(r.seasonWinnersOf(past)),
+		})
+	}
+	if len(fields) == 0 {
+		description += "\n\nПрошлых сезонов пока нет — стань первым чемпионом! 👑"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📅 Сезоны",
+		Description: description,
+		Color:       randomColor(),
+		Fields:      fields,
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// HandleSeasonEndCommand обрабатывает команду !a_season_end confirm.
+func (r *Ranking) HandleSeasonEndCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_season_end: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут завершать сезон! 🔒")
+		return
+	}
+	parts := strings.Fields(command)
+	season := r.currentSeason()
+	if len(parts) != 2 || parts[1] != "confirm" {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⚠️ Завершение сезона %d заархивирует балансы и вклады и сбросит всем баланс до %s. Подтверди: `/a_season_end confirm`",
+			season, formatCredits(r.StartingBalance())))
+		return
+	}
+
+	winners, reset, err := r.endSeason()
+	if err != nil {
+		log.Printf("Ошибка завершения сезона %d: %v", season, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка завершения сезона: "+err.Error())
+		return
+	}
+
+	log.Printf("Сезон %d завершён админом %s: сброшено %d игроков", season, m.Author.ID, reset)
+	r.LogCreditOperation(s, fmt.Sprintf("📅 <@%s> завершил сезон %d: %d балансов заархивировано и сброшено до %s", m.Author.ID, season, reset, formatCredits(r.StartingBalance())))
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🏁 Сезон %d завершён!", season),
+		Description: fmt.Sprintf("Чемпионы сезона получают значки престижа:\n\n%s\n\nНачинается **сезон %d** — у всех по %s. Удачи! 🇨🇳", r.formatSeasonWinners(winners), season+1, formatCredits(r.StartingBalance())),
+		Color:       0xFFD700,
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -0,0 +1,423 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// sellMenuTTL — сколько живёт незавершённая продажа через меню.
+const sellMenuTTL = 10 * time.Minute
+
+// sellMenuOptions — предел вариантов в select-меню Discord.
+const sellMenuOptions = 25
+
+// sellMenuSession — состояние /sell_menu: какие NFT предложены в меню и сколько каждой продаётся.
+type sellMenuSession struct {
+	MessageID string
+	Offered   []string
+	Cart      map[string]int
+}
+
+// sellMenuKey возвращает ключ сессии /sell_menu игрока.
+func sellMenuKey(userID string) string {
+	return "sell_menu:" + userID
+}
+
+// loadSellMenu возвращает сессию /sell_menu игрока.
+func (r *Ranking) loadSellMenu(userID string) (sellMenuSession, bool) {
+	var session sellMenuSession
+	data, err := r.redis.Get(r.ctx, sellMenuKey(userID)).Bytes()
+	if err != nil || json.Unmarshal(data, &session) != nil {
+		return session, false
+	}
+	if session.Cart == nil {
+		session.Cart = make(map[string]int)
+	}
+	return session, true
+}
+
+// takeSellMenu атомарно забирает сессию /sell_menu: из двух одновременных подтверждений
+// корзину получит только одно.
+func (r *Ranking) takeSellMenu(userID string) (sellMenuSession, bool) {
+	var session sellMenuSession
+	data, err := r.redis.GetDel(r.ctx, sellMenuKey(userID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось забрать меню продажи %s: %v", userID, err)
+		}
+		return session, false
+	}
+	if json.Unmarshal(data, &session) != nil {
+		return session, false
+	}
+	return session, true
+}
+
+// saveSellMenu сохраняет сессию /sell_menu и продлевает её срок.
+func (r *Ranking) saveSellMenu(userID string, session sellMenuSession) {
+	data, _ := json.Marshal(session)
+	if err := r.redis.Set(r.ctx, sellMenuKey(userID), data, sellMenuTTL).Err(); err != nil {
+		log.Printf("Не удалось сохранить меню продажи %s: %v", userID, err)
+	}
+}
+
+// sellMenuTotal возвращает сумму продажи корзины по текущим ценам.
+func (r *Ranking) sellMenuTotal(cart map[string]int) int {
+	total := 0
+	for nftID, count := range cart {
+		if nft, ok := r.Kki.nfts[nftID]; ok {
+			total += r.CalculateNFTPrice(nft) * count
+		}
+	}
+	return total
+}
+
+// sellMenuMessage собирает embed и компоненты меню продажи по сессии и текущему инвентарю.
+func (r *Ranking) sellMenuMessage(userID string, session sellMenuSession) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	inv := r.GetUserInventory(userID)
+	options := make([]discordgo.SelectMenuOption, 0, len(session.Offered))
+	for _, nftID := range session.Offered {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok || inv[nftID] == 0 {
+			continue
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate(fmt.Sprintf("%s x%d", nft.Name, inv[nftID]), 100),
+			Value:       nftID,
+			Description: fmt.Sprintf("💰 %d за шт. · %s · ID %s", r.CalculateNFTPrice(nft), nft.Rarity, nftID),
+			Emoji:       &discordgo.ComponentEmoji{Name: RarityEmojis[nft.Rarity]},
+			Default:     session.Cart[nftID] > 0,
+		})
+	}
+
+	ids := make([]string, 0, len(session.Cart))
+	for nftID := range session.Cart {
+		ids = append(ids, nftID)
+	}
+	sort.Strings(ids)
+	var lines []string
+	for _, nftID := range ids {
+		nft := r.Kki.nfts[nftID]
+		lines = append(lines, fmt.Sprintf("%s **%s** `%s` x%d — 💰 %d", RarityEmojis[nft.Rarity], nft.Name, nftID, session.Cart[nftID], r.CalculateNFTPrice(nft)*session.Cart[nftID]))
+	}
+	description := "Выбери NFT в меню ниже — по умолчанию продаются все копии. Количество можно поправить кнопкой «✏️ Количество»."
+	if len(lines) > 0 {
+		description = fmt.Sprintf("%s\n\n%s\n\n**Итого**: %s", description, strings.Join(lines, "\n"), formatCredits(r.sellMenuTotal(session.Cart)))
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🛒 **Продажа NFT** ══════",
+		Description: description,
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Меню действует %d мин. | Славь Императора! 👑", int(sellMenuTTL.Minutes()))},
+	}
+	if len(options) == 0 {
+		return embed, []discordgo.MessageComponent{}
+	}
+
+	empty := len(session.Cart) == 0
+	minValues := 0
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    "sell_menu_pick_" + userID,
+				Placeholder: "Выбери NFT для продажи",
+				MinValues:   &minValues,
+				MaxValues:   len(options),
+				Options:     options,
+			},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "✏️ Количество", Style: discordgo.SecondaryButton, CustomID: "sell_menu_qty_" + userID, Disabled: empty},
+			discordgo.Button{Label: "✅ Продать", Style: discordgo.SuccessButton, CustomID: "sell_menu_confirm_" + userID, Disabled: empty},
+			discordgo.Button{Label: "❌ Отменить", Style: discordgo.DangerButton, CustomID: "sell_menu_cancel_" + userID},
+		}},
+	}
+	return embed, components
+}
+
+// HandleSellMenuCommand обрабатывает команду !sell_menu [rarity:<редкость>] [collection:<коллекция>] [name:<часть имени>].
+func (r *Ranking) HandleSellMenuCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !sell_menu: %s от %s", command, m.Author.ID)
+
+	var filter inventoryFilter
+	for _, arg := range strings.Fields(command)[1:] {
+		ok, err := parseInventoryFilter(&filter, arg)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ "+err.Error())
+			return
+		}
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/sell_menu [rarity:<редкость>] [collection:<коллекция>] [name:<часть имени>]`")
+			return
+		}
+	}
+
+	inv := r.GetUserInventory(m.Author.ID)
+	var ids []string
+	for nftID, count := range inv {
+		if nft, ok := r.Kki.nfts[nftID]; ok && count > 0 && filter.Match(nft) {
+			ids = append(ids, nftID)
+		}
+	}
+	if len(ids) == 0 {
+		if filter.Empty() {
+			s.ChannelMessageSend(m.ChannelID, "❌ **Ваш инвентарь пуст!**")
+		} else {
+			s.ChannelMessageSend(m.ChannelID, "❌ Нет NFT, подходящих под фильтр.")
+		}
+		return
+	}
+	value := func(nftID string) int {
+		return r.CalculateNFTPrice(r.Kki.nfts[nftID]) * inv[nftID]
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if value(ids[i]) != value(ids[j]) {
+			return value(ids[i]) > value(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	hidden := 0
+	if len(ids) > sellMenuOptions {
+		hidden = len(ids) - sellMenuOptions
+		ids = ids[:sellMenuOptions]
+	}
+
+	session := sellMenuSession{Offered: ids, Cart: make(map[string]int)}
+	embed, components := r.sellMenuMessage(m.Author.ID, session)
+	if hidden > 0 {
+		embed.Description += fmt.Sprintf("\n\n_В меню %d самых ценных позиций, ещё %d скрыто — сузь выбор фильтром._", sellMenuOptions, hidden)
+	}
+	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components})
+	if err != nil {
+		log.Printf("Не удалось отправить меню продажи %s: %v", m.Author.ID, err)
+		return
+	}
+	session.MessageID = msg.ID
+	r.saveSellMenu(m.Author.ID, session)
+}
+
+// sellMenuOwner проверяет, что кнопку меню нажал его владелец и сессия ещё жива.
+func (r *Ranking) sellMenuOwner(s *discordgo.Session, i *discordgo.InteractionCreate, prefix, customID string) (string, sellMenuSession, bool) {
+	userID := strings.TrimPrefix(customID, prefix)
+	if i.Member.User.ID != userID {
+		respondEphemeral(s, i, "❌ **Меню не для вас! Император гневен! 👑**")
+		return "", sellMenuSession{}, false
+	}
+	session, ok := r.loadSellMenu(userID)
+	if !ok || (i.Message != nil && session.MessageID != i.Message.ID) {
+		respondEphemeral(s, i, "❌ **Меню устарело — открой новое через `/sell_menu`.**")
+		return "", sellMenuSession{}, false
+	}
+	return userID, session, true
+}
+
+// updateSellMenu перерисовывает сообщение меню в ответ на взаимодействие.
+func (r *Ranking) updateSellMenu(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, session sellMenuSession) {
+	embed, components := r.sellMenuMessage(userID, session)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components},
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить меню продажи %s: %v", userID, err)
+	}
+}
+
+// HandleSellMenuPick обрабатывает выбор NFT в select-меню /sell_menu.
+func (r *Ranking) HandleSellMenuPick(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, session, ok := r.sellMenuOwner(s, i, "sell_menu_pick_", i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	inv := r.GetUserInventory(userID)
+	cart := make(map[string]int)
+	for _, nftID := range i.MessageComponentData().Values {
+		if inv[nftID] == 0 {
+			continue
+		}
+		if count := session.Cart[nftID]; count > 0 {
+			cart[nftID] = min(count, inv[nftID])
+		} else {
+			cart[nftID] = inv[nftID]
+		}
+	}
+	session.Cart = cart
+	r.saveSellMenu(userID, session)
+	r.updateSellMenu(s, i, userID, session)
+}
+
+// HandleSellMenuQuantity открывает форму с количеством для выбранных NFT.
+func (r *Ranking) HandleSellMenuQuantity(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, session, ok := r.sellMenuOwner(s, i, "sell_menu_qty_", i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	ids := make([]string, 0, len(session.Cart))
+	for nftID := range session.Cart {
+		ids = append(ids, nftID)
+	}
+	sort.Strings(ids)
+	lines := make([]string, 0, len(ids))
+	for _, nftID := range ids {
+		lines = append(lines, fmt.Sprintf("%s %d", nftID, session.Cart[nftID]))
+	}
+	r.showContextModal(s, i, "sell_menu_qty_"+userID, "Количество для продажи", []discordgo.TextInput{{
+		CustomID:    "items",
+		Label:       "ID и количество, по строке на NFT",
+		Style:       discordgo.TextInputParagraph,
+		Placeholder: "42 3",
+		Value:       strings.Join(lines, "\n"),
+		Required:    true,
+		MaxLength:   1000,
+	}})
+}
+
+// HandleSellMenuQuantitySubmit применяет количество из формы; 0 убирает NFT из продажи.
+func (r *Ranking) HandleSellMenuQuantitySubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, session, ok := r.sellMenuOwner(s, i, "sell_menu_qty_", i.ModalSubmitData().CustomID)
+	if !ok {
+		return
+	}
+	inv := r.GetUserInventory(userID)
+	cart := make(map[string]int)
+	for _, line := range strings.Split(modalValues(i.ModalSubmitData())["items"], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			respondEphemeral(s, i, fmt.Sprintf("❌ Строка `%s`: нужен формат `ID количество`.", truncate(line, 50)))
+			return
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count < 0 {
+			respondEphemeral(s, i, fmt.Sprintf("❌ Строка `%s`: некорректное количество.", truncate(line, 50)))
+			return
+		}
+		nftID := fields[0]
+		if _, ok := r.Kki.nfts[nftID]; !ok {
+			respondEphemeral(s, i, fmt.Sprintf("❌ NFT `%s` не найдено.", truncate(nftID, 20)))
+			return
+		}
+		if count > inv[nftID] {
+			respondEphemeral(s, i, fmt.Sprintf("❌ У тебя только %d x `%s`.", inv[nftID], nftID))
+			return
+		}
+		if count > 0 {
+			cart[nftID] = count
+		}
+	}
+	session.Cart = cart
+	r.saveSellMenu(userID, session)
+	r.updateSellMenu(s, i, userID, session)
+}
+
+// sellMenuItems атомарно списывает корзину из инвентаря. Возвращает цены за штуку.
+func (r *Ranking) sellMenuItems(userID string, cart map[string]int) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv := r.GetUserInventory(userID)
+	prices := make(map[string]int, len(cart))
+	for nftID, count := range cart {
+		nft, ok := r.Kki.nfts[nftID]
+		if !ok {
+			return nil, fmt.Errorf("NFT `%s` больше не существует", nftID)
+		}
+		if inv[nftID] < count {
+			return nil, fmt.Errorf("недостаточно **%s**: нужно %d, есть %d", nft.Name, count, inv[nftID])
+		}
+		prices[nftID] = r.CalculateNFTPrice(nft)
+	}
+	for nftID, count := range cart {
+		inv[nftID] -= count
+		if inv[nftID] <= 0 {
+			delete(inv, nftID)
+		}
+	}
+	r.SaveUserInventory(userID, inv)
+	return prices, nil
+}
+
+// HandleSellMenuConfirm продаёт всю корзину /sell_menu одной операцией.
+func (r *Ranking) HandleSellMenuConfirm(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, session, ok := r.sellMenuOwner(s, i, "sell_menu_confirm_", i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	if len(session.Cart) == 0 {
+		respondEphemeral(s, i, "❌ Ничего не выбрано.")
+		return
+	}
+	// Продаётся только корзина, которую удалось забрать: повторное нажатие её уже не найдёт
+	session, ok = r.takeSellMenu(userID)
+	if !ok || len(session.Cart) == 0 {
+		respondEphemeral(s, i, "❌ **Меню устарело — открой новое через `/sell_menu`.**")
+		return
+	}
+	prices, err := r.sellMenuItems(userID, session.Cart)
+	if err != nil {
+		respondEphemeral(s, i, "❌ Продажа отменена: "+err.Error()+". Открой меню заново.")
+		return
+	}
+
+	ids := make([]string, 0, len(session.Cart))
+	for nftID := range session.Cart {
+		ids = append(ids, nftID)
+	}
+	sort.Strings(ids)
+	total := 0
+	var soldItems []string
+	for _, nftID := range ids {
+		nft, count := r.Kki.nfts[nftID], session.Cart[nftID]
+		total += prices[nftID] * count
+		r.recordNFTSale(nftID, prices[nftID])
+		soldItems = append(soldItems, fmt.Sprintf("%s **%s** (x%d)", RarityEmojis[nft.Rarity], nft.Name, count))
+	}
+	r.UpdateRatingFrom(userID, total, "nft_sale", "")
+	ledgerID := r.recordNFTMutation("nft_sale", userID, userID, "", session.Cart)
+	r.LogCreditOperation(s, fmt.Sprintf("🛒 **%s** продал через меню NFT за %s: %s%s", i.Member.User.Username, formatCredits(total), strings.Join(soldItems, ", "), ledgerRef(ledgerID)))
+
+	emptyComponents := []discordgo.MessageComponent{}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🛒 **Продажа завершена** ══════",
+				Description: fmt.Sprintf("✅ **Продано** за %s:\n%s", formatCredits(total), strings.Join(soldItems, "\n")),
+				Color:       0x00FF00,
+				Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Владелец: %s | Славь Императора! 👑", i.Member.User.Username)},
+			}},
+			Components: emptyComponents,
+		},
+	})
+	if err != nil {
+		log.Printf("Не удалось обновить меню продажи %s: %v", userID, err)
+	}
+}
+
+// HandleSellMenuCancel закрывает меню продажи без изменений.
+func (r *Ranking) HandleSellMenuCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, _, ok := r.sellMenuOwner(s, i, "sell_menu_cancel_", i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	r.redis.Del(r.ctx, sellMenuKey(userID))
+	emptyComponents := []discordgo.MessageComponent{}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{{Title: "🛒 **Продажа отменена**", Color: 0xFF0000}},
+			Components: emptyComponents,
+		},
+	})
+}
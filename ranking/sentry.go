@@ -0,0 +1,122 @@
+package ranking
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sentryClient отправляет события в Sentry через HTTP API (store endpoint) без внешних зависимостей.
+type sentryClient struct {
+	endpoint    string
+	auth        string
+	environment string
+	http        *http.Client
+}
+
+var (
+	sentryOnce sync.Once
+	sentry     *sentryClient
+)
+
+// getSentry возвращает клиент Sentry, если задан SENTRY_DSN, иначе nil.
+func getSentry() *sentryClient {
+	sentryOnce.Do(func() {
+		dsn := os.Getenv("SENTRY_DSN")
+		if dsn == "" {
+			return
+		}
+		u, err := url.Parse(dsn)
+		if err != nil || u.User == nil {
+			log.Printf("Некорректный SENTRY_DSN: %v", err)
+			return
+		}
+		projectID := strings.TrimPrefix(u.Path, "/")
+		if projectID == "" {
+			log.Printf("Некорректный SENTRY_DSN: не указан проект")
+			return
+		}
+		environment := os.Getenv("SENTRY_ENVIRONMENT")
+		if environment == "" {
+			environment = "production"
+		}
+		sentry = &sentryClient{
+			endpoint:    fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, projectID),
+			auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=chinabot/1.0, sentry_key=%s", u.User.Username()),
+			environment: environment,
+			http:        &http.Client{Timeout: 5 * time.Second},
+		}
+		log.Printf("Отправка ошибок в Sentry включена (%s)", environment)
+	})
+	return sentry
+}
+
+// send асинхронно отправляет событие в Sentry.
+func (c *sentryClient) send(level, message, stack string, tags map[string]string) {
+	id := make([]byte, 16)
+	rand.Read(id)
+	event := map[string]interface{}{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   time.Now().UTC().Format("2006-01-02T15:04:05"),
+		"level":       level,
+		"platform":    "go",
+		"logger":      "chinabot",
+		"environment": c.environment,
+		"message":     message,
+		"tags":        tags,
+	}
+	if stack != "" {
+		event["extra"] = map[string]string{"stacktrace": stack}
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", c.auth)
+		resp, err := c.http.Do(req)
+		if err != nil {
+			log.Printf("Не удалось отправить событие в Sentry: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Sentry вернул статус %d", resp.StatusCode)
+		}
+	}()
+}
+
+// CaptureError отправляет ошибку в Sentry с контекстом (команда, пользователь, подсистема).
+func CaptureError(err error, tags map[string]string) {
+	if err == nil {
+		return
+	}
+	if c := getSentry(); c != nil {
+		c.send("error", err.Error(), "", tags)
+	}
+}
+
+// CapturePanic отправляет панику со стеком вызовов в Sentry и пишет её в лог.
+func CapturePanic(p interface{}, tags map[string]string) {
+	stack := string(debug.Stack())
+	log.Printf("Паника: %v (%v)\n%s", p, tags, stack)
+	if c := getSentry(); c != nil {
+		c.send("fatal", fmt.Sprintf("panic: %v", p), stack, tags)
+	}
+}
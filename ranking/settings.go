@@ -0,0 +1,104 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// timeoutGames перечисляет игры с настраиваемым тайм-аутом и их названия.
+var timeoutGames = map[string]string{
+	"bj":   "Блэкджек",
+	"rb":   "Красный-Чёрный",
+	"duel": "Дуэль",
+}
+
+// envInt читает числовую переменную окружения, возвращая def при отсутствии или ошибке.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", name, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetIntSetting возвращает настройку из Redis (settings:<name>) или def, если она не задана.
+func (r *Ranking) GetIntSetting(name string, def int) int {
+	value, err := r.redis.Get(r.ctx, "settings:"+name).Int()
+	if err == redis.Nil {
+		return def
+	}
+	if err != nil {
+		log.Printf("Не удалось получить настройку %s из Redis: %v", name, err)
+		return def
+	}
+	return value
+}
+
+// SetIntSetting сохраняет числовую настройку в Redis.
+func (r *Ranking) SetIntSetting(name string, value int) error {
+	return r.redis.Set(r.ctx, "settings:"+name, value, 0).Err()
+}
+
+// GameTimeout возвращает тайм-аут игры (bj, rb, duel): настройка <game>_timeout_minutes,
+// переменная окружения <GAME>_TIMEOUT_MINUTES или 15 минут.
+func (r *Ranking) GameTimeout(game string) time.Duration {
+	minutes := r.GetIntSetting(game+"_timeout_minutes", envInt(strings.ToUpper(game)+"_TIMEOUT_MINUTES", 15))
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// HandleTimeoutCommand обрабатывает команду !a_timeout [игра минуты].
+func (r *Ranking) HandleTimeoutCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_timeout: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут менять тайм-ауты! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		var lines []string
+		for _, game := range []string{"bj", "rb", "duel"} {
+			lines = append(lines, fmt.Sprintf("**%s** (`%s`): %d мин", timeoutGames[game], game, int(r.GameTimeout(game).Minutes())))
+		}
+		s.ChannelMessageSend(m.ChannelID, "⏰ Тайм-ауты игр:\n"+strings.Join(lines, "\n"))
+		return
+	}
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_timeout <bj|rb|duel> <минуты>`")
+		return
+	}
+
+	game := parts[1]
+	name, ok := timeoutGames[game]
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Неизвестная игра! Доступны: `bj`, `rb`, `duel`")
+		return
+	}
+	minutes, err := strconv.Atoi(parts[2])
+	if err != nil || minutes <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Тайм-аут должен быть положительным числом минут!")
+		return
+	}
+	if err := r.SetIntSetting(game+"_timeout_minutes", minutes); err != nil {
+		log.Printf("Не удалось сохранить тайм-аут %s: %v", game, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения тайм-аута! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Тайм-аут игры **%s** установлен: %d мин. Применяется к новым играм.", name, minutes))
+}
@@ -0,0 +1,122 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Шардирование: при SHARD_COUNT > 1 у каждого шарда своё соединение со шлюзом и своё состояние
+// (гильдии, участники, голосовые состояния). Гильдию всегда обслуживает шард (guild_id >> 22) % shard_count,
+// поэтому фоновые задачи, которым нужно состояние гильдии, берут сессию через SessionForGuild.
+//
+// Шардирование рассчитано на один процесс. Изменения балансов и инвентарей сериализуются r.mu
+// и проходят через кэш игроков в памяти (read-modify-write поверх Redis), а они у каждого процесса
+// свои: при разделении шардов между процессами через SHARD_IDS один игрок на двух серверах может
+// потерять обновление баланса. SHARD_IDS годится только для запуска всех шардов в одном процессе
+// или для отладки; самопроверка (--check) предупреждает, если процесс обслуживает не все шарды.
+
+// ShardForGuild возвращает номер шарда, который обслуживает гильдию.
+func ShardForGuild(guildID string, count int) int {
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil || count <= 1 {
+		return 0
+	}
+	return int((id >> 22) % uint64(count))
+}
+
+// SetShards запоминает сессии шардов этого процесса. Первая сессия используется для REST-запросов
+// фоновых задач (см. Session).
+func (r *Ranking) SetShards(sessions []*discordgo.Session) {
+	r.mu.Lock()
+	r.shards = sessions
+	if len(sessions) > 0 {
+		r.session = sessions[0]
+	}
+	r.mu.Unlock()
+}
+
+// Shards возвращает сессии шардов этого процесса.
+func (r *Ranking) Shards() []*discordgo.Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*discordgo.Session(nil), r.shards...)
+}
+
+// SessionForGuild возвращает сессию шарда, в состоянии которого есть гильдия.
+// Если гильдию обслуживает другой процесс, возвращается основная сессия: REST-запросы работают с любой.
+func (r *Ranking) SessionForGuild(guildID string) (*discordgo.Session, error) {
+	for _, s := range r.Shards() {
+		if s.ShardID == ShardForGuild(guildID, s.ShardCount) {
+			return s, nil
+		}
+	}
+	return r.Session()
+}
+
+// stateGuilds возвращает гильдии из состояния всех шардов процесса вместе с сессией шарда.
+func (r *Ranking) stateGuilds() map[string]*discordgo.Session {
+	guilds := make(map[string]*discordgo.Session)
+	for _, s := range r.Shards() {
+		if s.State == nil {
+			continue
+		}
+		s.State.RLock()
+		for _, guild := range s.State.Guilds {
+			guilds[guild.ID] = s
+		}
+		s.State.RUnlock()
+	}
+	return guilds
+}
+
+// ShardLabel возвращает подпись шарда для логов, пустую без шардирования.
+func ShardLabel(s *discordgo.Session) string {
+	if s == nil || s.ShardCount <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" [шард %d/%d]", s.ShardID, s.ShardCount)
+}
+
+// HandleShardsCommand обрабатывает команду !a_shards.
+func (r *Ranking) HandleShardsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !a_shards от %s", m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут смотреть шарды! 🔒")
+		return
+	}
+
+	shards := r.Shards()
+	if len(shards) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Бот ещё не подключён к шлюзу.")
+		return
+	}
+	var lines []string
+	guilds := 0
+	for _, shard := range shards {
+		count := 0
+		if shard.State != nil {
+			shard.State.RLock()
+			count = len(shard.State.Guilds)
+			shard.State.RUnlock()
+		}
+		guilds += count
+		status := "🟢"
+		if !shard.DataReady {
+			status = "🔴"
+		}
+		lines = append(lines, fmt.Sprintf("%s Шард **%d/%d** — гильдий: %d, пинг: %d мс", status, shard.ShardID, max(shard.ShardCount, 1), count, shard.HeartbeatLatency().Milliseconds()))
+	}
+	current := fmt.Sprintf("Этот канал обслуживает шард %d", s.ShardID)
+	embed := &discordgo.MessageEmbed{
+		Title:       "🧩 Шарды",
+		Description: strings.Join(lines, "\n"),
+		Color:       randomColor(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("В процессе: %d шардов, %d гильдий · %s · SHARD_COUNT, SHARD_IDS", len(shards), guilds, current)},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
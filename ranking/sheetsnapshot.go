@@ -0,0 +1,88 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Ночные снимки таблицы лидеров и агрегатов экономики в отдельный лист Google Sheets.
+// Включаются переменной SHEETS_SNAPSHOT_TAB (имя листа, его нужно создать заранее);
+// сервисному аккаунту нужен доступ на редактирование таблицы GOOGLE_SHEETS_ID.
+// Каждая ночь — одна строка: агрегаты экономики и топ игроков с балансами.
+
+// sheetsSnapshotTab возвращает имя листа для снимков или пустую строку, если выгрузка выключена.
+func sheetsSnapshotTab() string {
+	return os.Getenv("SHEETS_SNAPSHOT_TAB")
+}
+
+// sheetsSnapshotHeader возвращает строку заголовков листа снимков для топа из top игроков.
+func sheetsSnapshotHeader(top int) []interface{} {
+	header := []interface{}{"Дата", "В обороте", "Держателей", "Активных 24ч", "Активных 7д", "Начислено 24ч", "Списано 24ч", "Доля топ-1%", "Кейсов из банка 24ч"}
+	for place := 1; place <= top; place++ {
+		header = append(header, fmt.Sprintf("#%d", place), fmt.Sprintf("#%d баланс", place))
+	}
+	return header
+}
+
+// appendSheetsSnapshot дописывает снимок экономики и топа в лист SHEETS_SNAPSHOT_TAB.
+func (r *Ranking) appendSheetsSnapshot() error {
+	tab := sheetsSnapshotTab()
+	if tab == "" || r.Kki == nil || r.Kki.sheets == nil {
+		return nil
+	}
+	snap, err := r.economySnapshot()
+	if err != nil {
+		return fmt.Errorf("агрегаты экономики: %v", err)
+	}
+	top := envInt("SHEETS_SNAPSHOT_TOP", 10)
+	users, _, err := r.GetTop(0, top)
+	if err != nil {
+		return fmt.Errorf("топ игроков: %v", err)
+	}
+
+	row := []interface{}{
+		snap.At.Format("2006-01-02 15:04"), snap.Total, snap.Holders, snap.Active24h, snap.Active7d,
+		snap.Minted, snap.Burned, fmt.Sprintf("%.1f%%", snap.TopShare), snap.BankCases,
+	}
+	for _, user := range users {
+		row = append(row, r.publicName(user.ID), user.Rating)
+	}
+
+	values := r.Kki.sheets.Spreadsheets.Values
+	spreadsheetID := os.Getenv("GOOGLE_SHEETS_ID")
+	rows := [][]interface{}{row}
+	existing, err := values.Get(spreadsheetID, tab+"!A1:A1").Context(r.ctx).Do()
+	if err != nil {
+		return fmt.Errorf("чтение листа %s: %v", tab, err)
+	}
+	if len(existing.Values) == 0 {
+		rows = [][]interface{}{sheetsSnapshotHeader(top), row}
+	}
+
+	_, err = values.Append(spreadsheetID, tab+"!A1", &sheets.ValueRange{Values: rows}).
+		ValueInputOption("USER_ENTERED").
+		InsertDataOption("INSERT_ROWS").
+		Context(r.ctx).
+		Do()
+	if err != nil {
+		return fmt.Errorf("запись в лист %s: %v", tab, err)
+	}
+	log.Printf("Снимок экономики и топа (%d игроков) записан в лист %s", len(users), tab)
+	return nil
+}
+
+// registerSheetsSnapshotJob регистрирует ночную выгрузку снимков, если она включена.
+func (r *Ranking) registerSheetsSnapshotJob(loc *time.Location) {
+	if sheetsSnapshotTab() == "" {
+		return
+	}
+	r.scheduler.Register(&Job{
+		Name: "sheets_snapshot",
+		Next: dailyAt(envInt("SHEETS_SNAPSHOT_HOUR", 3), loc),
+		Run:  r.appendSheetsSnapshot,
+	})
+}
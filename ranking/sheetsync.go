@@ -0,0 +1,118 @@
+package ranking
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Диапазоны листов Google Sheets без строки заголовка.
+const (
+	sheetsNFTRange  = "NFTs!A2:G"
+	sheetsCaseRange = "Cases!A2:D"
+)
+
+// sheetRowHashKey возвращает ключ хэшей строк листа (ID -> хэш строки).
+func sheetRowHashKey(sheet string) string {
+	return "sheets_sync:rows:" + sheet
+}
+
+// sheetRowHash возвращает хэш содержимого строки таблицы.
+func sheetRowHash(row []interface{}) string {
+	h := sha1.New()
+	for _, cell := range row {
+		fmt.Fprintf(h, "%v\x1f", cell)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadRowHashes читает хэши строк листа с прошлой синхронизации.
+func (k *KKI) loadRowHashes(r *Ranking, sheet string) map[string]string {
+	hashes, err := r.redis.HGetAll(r.ctx, sheetRowHashKey(sheet)).Result()
+	if err != nil {
+		log.Printf("Не удалось прочитать хэши строк листа %s: %v", sheet, err)
+		return map[string]string{}
+	}
+	return hashes
+}
+
+// saveRowHashes заменяет хэши строк листа.
+func (k *KKI) saveRowHashes(r *Ranking, sheet string, hashes map[string]string) {
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, sheetRowHashKey(sheet))
+	if len(hashes) > 0 {
+		pipe.HSet(r.ctx, sheetRowHashKey(sheet), hashes)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось сохранить хэши строк листа %s: %v", sheet, err)
+	}
+}
+
+// SheetsSyncMinInterval возвращает минимальный интервал между синхронизациями с Google Sheets.
+func SheetsSyncMinInterval() time.Duration {
+	return time.Duration(envInt("SHEETS_SYNC_MIN_INTERVAL", 60)) * time.Second
+}
+
+// sheetSyncQueue объединяет запросы синхронизации: пока синхронизация запланирована,
+// новые запросы присоединяются к ней, а запуски идут не чаще SheetsSyncMinInterval.
+type sheetSyncQueue struct {
+	mu        sync.Mutex
+	lastStart time.Time
+	scheduled bool
+	waiters   []func(error)
+}
+
+// RequestSync ставит синхронизацию в очередь и вызывает done с её результатом.
+// Возвращает задержку до запуска и признак того, что запрос присоединился к уже запланированной синхронизации.
+func (k *KKI) RequestSync(r *Ranking, done func(error)) (time.Duration, bool) {
+	q := &k.sync
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delay := max(SheetsSyncMinInterval()-time.Since(q.lastStart), 0)
+	q.waiters = append(q.waiters, done)
+	if q.scheduled {
+		return delay, true
+	}
+	q.scheduled = true
+
+	go func() {
+		time.Sleep(delay)
+		q.mu.Lock()
+		waiters := q.waiters
+		q.waiters = nil
+		q.scheduled = false
+		q.lastStart = time.Now()
+		q.mu.Unlock()
+
+		err := k.SyncFromSheets(r)
+		for _, notify := range waiters {
+			notify(err)
+		}
+	}()
+	return delay, false
+}
+
+// HandleSyncNFTsCommand обрабатывает команду !sync_nfts.
+func (r *Ranking) HandleSyncNFTsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !sync_nfts от %s", m.Author.ID)
+
+	delay, joined := r.Kki.RequestSync(r, func(err error) {
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ **Ошибка синхронизации** (запрос <@%s>): %s", m.Author.ID, err.Error()))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ **NFT и кейсы синхронизированы из Google Sheets!** (запрос <@%s>)", m.Author.ID))
+	})
+	switch {
+	case joined:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏳ Синхронизация уже запланирована <t:%d:R>, результат придёт сюда.", time.Now().Add(delay).Unix()))
+	case delay > 0:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⏳ Синхронизация была недавно, следующая <t:%d:R>.", time.Now().Add(delay).Unix()))
+	}
+}
@@ -0,0 +1,192 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Витрина: игрок закрепляет до showcaseLimit NFT, которые показываются в !stats и !profile.
+// Хранится списком ID в showcase:<userID>. NFT, которых у игрока больше нет, не показываются.
+const showcaseLimit = 3
+
+// showcaseKey возвращает ключ витрины игрока.
+func showcaseKey(userID string) string {
+	return "showcase:" + userID
+}
+
+// Showcase возвращает закреплённые NFT игрока, которые всё ещё есть у него в инвентаре.
+func (r *Ranking) Showcase(userID string) []NFT {
+	ids, err := r.redis.LRange(r.ctx, showcaseKey(userID), 0, -1).Result()
+	if err != nil {
+		log.Printf("Не удалось загрузить витрину %s: %v", userID, err)
+		return nil
+	}
+	inv := r.GetUserInventory(userID)
+	nfts := make([]NFT, 0, len(ids))
+	for _, id := range ids {
+		if nft, ok := r.Kki.nfts[id]; ok && inv[id] > 0 {
+			nfts = append(nfts, nft)
+		}
+	}
+	return nfts
+}
+
+// showcaseStatsField возвращает поле витрины для !stats или nil, если витрина пуста.
+func (r *Ranking) showcaseStatsField(nfts []NFT) *discordgo.MessageEmbedField {
+	if len(nfts) == 0 {
+		return nil
+	}
+	lines := make([]string, len(nfts))
+	for i, nft := range nfts {
+		lines[i] = fmt.Sprintf("%s **%s** · %s", RarityEmojis[nft.Rarity], nft.Name, nft.ID)
+	}
+	return &discordgo.MessageEmbedField{Name: "🏆 Витрина", Value: strings.Join(lines, "\n"), Inline: false}
+}
+
+// showcaseEmbeds возвращает карточки закреплённых NFT с миниатюрами.
+func (r *Ranking) showcaseEmbeds(nfts []NFT) []*discordgo.MessageEmbed {
+	embeds := make([]*discordgo.MessageEmbed, len(nfts))
+	for i, nft := range nfts {
+		embeds[i] = &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("%s %s", RarityEmojis[nft.Rarity], nft.Name),
+			Description: fmt.Sprintf("**Редкость**: %s\n**Коллекция**: %s\n**Цена**: 💰 %d", nft.Rarity, nft.Collection, nft.Price),
+			Color:       RarityColors[nft.Rarity],
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: r.NFTImageURL(nft)},
+			Footer:      &discordgo.MessageEmbedFooter{Text: "ID: " + nft.ID},
+		}
+	}
+	return embeds
+}
+
+// HandleShowcaseCommand обрабатывает команду !showcase [add|remove <nftID>|clear].
+func (r *Ranking) HandleShowcaseCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !showcase: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	key := showcaseKey(m.Author.ID)
+	usage := fmt.Sprintf("❌ Используй: `/showcase add <nftID>`, `/showcase remove <nftID>`, `/showcase clear` (до %d NFT)", showcaseLimit)
+	if len(parts) == 1 {
+		nfts := r.Showcase(m.Author.ID)
+		if len(nfts) == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🏆 Витрина пуста. Закрепи до %d NFT: `/showcase add <nftID>`", showcaseLimit))
+			return
+		}
+		s.ChannelMessageSendEmbeds(m.ChannelID, r.showcaseEmbeds(nfts))
+		return
+	}
+
+	switch {
+	case parts[1] == "clear" && len(parts) == 2:
+		if err := r.redis.Del(r.ctx, key).Err(); err != nil {
+			log.Printf("Не удалось очистить витрину %s: %v", m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка при сохранении витрины! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "🧹 Витрина очищена.")
+	case parts[1] == "add" && len(parts) == 3:
+		nft, ok := r.Kki.nfts[parts[2]]
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ NFT не найдено. Проверьте ID.")
+			return
+		}
+		if r.GetUserInventory(m.Author.ID)[nft.ID] <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ На витрину можно поставить только свою NFT.")
+			return
+		}
+		// Проданные NFT чистим при добавлении, чтобы они не занимали место
+		current := r.Showcase(m.Author.ID)
+		ids := make([]interface{}, 0, len(current)+1)
+		for _, pinned := range current {
+			if pinned.ID == nft.ID {
+				s.ChannelMessageSend(m.ChannelID, "❌ Эта NFT уже на витрине.")
+				return
+			}
+			ids = append(ids, pinned.ID)
+		}
+		if len(ids) >= showcaseLimit {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ На витрине уже %d NFT. Убери одну: `/showcase remove <nftID>`", showcaseLimit))
+			return
+		}
+		ids = append(ids, nft.ID)
+		pipe := r.redis.TxPipeline()
+		pipe.Del(r.ctx, key)
+		pipe.RPush(r.ctx, key, ids...)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			log.Printf("Не удалось сохранить витрину %s: %v", m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка при сохранении витрины! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("🏆 %s **%s** на витрине (%d/%d). Её видно в `/stats` и `/profile`.", RarityEmojis[nft.Rarity], nft.Name, len(ids), showcaseLimit),
+			Color:       RarityColors[nft.Rarity],
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: r.NFTImageURL(nft)},
+		})
+	case parts[1] == "remove" && len(parts) == 3:
+		removed, err := r.redis.LRem(r.ctx, key, 0, parts[2]).Result()
+		if err != nil {
+			log.Printf("Не удалось обновить витрину %s: %v", m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка при сохранении витрины! Проверьте Redis-сервер.")
+			return
+		}
+		if removed == 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Этой NFT нет на витрине.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ NFT %s убрана с витрины.", parts[2]))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// HandleProfileCommand обрабатывает команду !profile [@user]: карточка игрока с витриной.
+func (r *Ranking) HandleProfileCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !profile: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	userID := m.Author.ID
+	if len(parts) > 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/profile [@user]`")
+		return
+	}
+	if len(parts) == 2 {
+		target := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(parts[1], "<@"), "!"), ">")
+		if !isValidUserID(target) {
+			s.ChannelMessageSend(m.ChannelID, "❌ Некорректный ID пользователя! Используй формат: `/profile @id`")
+			return
+		}
+		userID = target
+	}
+
+	inv := r.GetUserInventory(userID)
+	copies := 0
+	for _, count := range inv {
+		copies += count
+	}
+	worth := r.netWorth(userID)
+	nfts := r.Showcase(userID)
+	showcase := "Пусто — `/showcase add <nftID>`"
+	if field := r.showcaseStatsField(nfts); field != nil {
+		showcase = field.Value
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🪪 Профиль",
+		Description: fmt.Sprintf("%s%s", r.userFlair(userID), r.publicMention(userID)),
+		Color:       0xFFD700,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💰 Баланс", Value: formatCredits(worth.Credits), Inline: true},
+			{Name: "💼 Состояние", Value: formatCredits(worth.Total()), Inline: true},
+			{Name: "🖼️ Коллекция", Value: fmt.Sprintf("%d NFT (%d уникальных) · %s", copies, len(inv), formatCredits(worth.NFTs)), Inline: false},
+			{Name: "🏆 Витрина", Value: showcase, Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Славь Императора! 👑"},
+	}
+	if len(nfts) > 0 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: r.NFTImageURL(nfts[0])}
+	}
+	if _, err := s.ChannelMessageSendEmbeds(m.ChannelID, append([]*discordgo.MessageEmbed{embed}, r.showcaseEmbeds(nfts)...)); err != nil {
+		log.Printf("Не удалось отправить профиль %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,150 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Виды побочных ставок в блэкджеке.
+const (
+	sideBetPerfectPairs = "pp"
+	sideBet21Plus3      = "21+3"
+)
+
+// SideBet — побочная ставка, сделанная вместе с основной.
+type SideBet struct {
+	Kind   string
+	Amount int
+}
+
+// cardRanks — порядок рангов для проверки стритов (туз может быть и младшим, и старшим).
+var cardRanks = map[string]int{"A": 1, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7, "8": 8, "9": 9, "10": 10, "J": 11, "Q": 12, "K": 13}
+
+// parseSideBets разбирает аргументы вида pp:20 и 21+3:10.
+func parseSideBets(args []string) ([]SideBet, error) {
+	var bets []SideBet
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		kind, value, ok := strings.Cut(strings.ToLower(arg), ":")
+		if !ok || (kind != sideBetPerfectPairs && kind != sideBet21Plus3) {
+			return nil, fmt.Errorf("неизвестная побочная ставка `%s`", arg)
+		}
+		if seen[kind] {
+			return nil, fmt.Errorf("побочная ставка `%s` указана дважды", kind)
+		}
+		amount, err := strconv.Atoi(value)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("сумма побочной ставки `%s` должна быть положительным числом", kind)
+		}
+		seen[kind] = true
+		bets = append(bets, SideBet{Kind: kind, Amount: amount})
+	}
+	return bets, nil
+}
+
+// sideBetsTotal возвращает сумму всех побочных ставок.
+func sideBetsTotal(bets []SideBet) int {
+	total := 0
+	for _, bet := range bets {
+		total += bet.Amount
+	}
+	return total
+}
+
+// isRedSuit сообщает, красная ли масть.
+func isRedSuit(suit string) bool {
+	return suit == "♥️" || suit == "♦️"
+}
+
+// perfectPairsPayout оценивает Perfect Pairs по двум первым картам игрока.
+// Таблица выплат: идеальная пара 25:1, цветная пара 12:1, смешанная пара 6:1.
+func perfectPairsPayout(cards []Card) (int, string) {
+	if len(cards) < 2 || cards[0].Value != cards[1].Value {
+		return 0, ""
+	}
+	switch {
+	case cards[0].Suit == cards[1].Suit:
+		return 25, "Идеальная пара"
+	case isRedSuit(cards[0].Suit) == isRedSuit(cards[1].Suit):
+		return 12, "Цветная пара"
+	default:
+		return 6, "Смешанная пара"
+	}
+}
+
+// isStraight проверяет, образуют ли три карты стрит (A-2-3 и Q-K-A допустимы).
+func isStraight(cards []Card) bool {
+	ranks := make([]int, 0, len(cards))
+	for _, card := range cards {
+		ranks = append(ranks, cardRanks[card.Value])
+	}
+	sort.Ints(ranks)
+	if ranks[0]+1 == ranks[1] && ranks[1]+1 == ranks[2] {
+		return true
+	}
+	return ranks[0] == 1 && ranks[1] == 12 && ranks[2] == 13
+}
+
+// twentyOnePlusThreePayout оценивает 21+3 по двум картам игрока и открытой карте дилера.
+// Таблица выплат: одномастная тройка 100:1, стрит-флеш 40:1, тройка 30:1, стрит 10:1, флеш 5:1.
+func twentyOnePlusThreePayout(player []Card, upCard Card) (int, string) {
+	if len(player) < 2 {
+		return 0, ""
+	}
+	cards := []Card{player[0], player[1], upCard}
+	flush := cards[0].Suit == cards[1].Suit && cards[1].Suit == cards[2].Suit
+	trips := cards[0].Value == cards[1].Value && cards[1].Value == cards[2].Value
+	straight := isStraight(cards)
+	switch {
+	case trips && flush:
+		return 100, "Одномастная тройка"
+	case straight && flush:
+		return 40, "Стрит-флеш"
+	case trips:
+		return 30, "Тройка"
+	case straight:
+		return 10, "Стрит"
+	case flush:
+		return 5, "Флеш"
+	}
+	return 0, ""
+}
+
+// settleSideBets рассчитывает побочные ставки по первой раздаче независимо от основной игры
+// и возвращает строки для сообщения игры.
+func (r *Ranking) settleSideBets(s *discordgo.Session, game *BlackjackGame) string {
+	if len(game.SideBets) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(game.SideBets))
+	for _, bet := range game.SideBets {
+		var multiplier int
+		var combo, name string
+		switch bet.Kind {
+		case sideBetPerfectPairs:
+			name = "Perfect Pairs"
+			multiplier, combo = perfectPairsPayout(game.PlayerCards)
+		case sideBet21Plus3:
+			name = "21+3"
+			multiplier, combo = twentyOnePlusThreePayout(game.PlayerCards, game.DealerCards[0])
+		}
+
+		if multiplier == 0 {
+			lines = append(lines, fmt.Sprintf("❌ %s (%d): мимо", name, bet.Amount))
+			r.LogCreditOperation(s, fmt.Sprintf("🎲 Побочная ставка %s <@%s> в блэкджеке: проигрыш %s", name, game.PlayerID, formatCredits(bet.Amount)))
+			continue
+		}
+		winnings := bet.Amount + bet.Amount*multiplier
+		r.UpdateRatingFrom(game.PlayerID, winnings, "sidebet", "")
+		r.repayLoanFromWinnings(game.PlayerID, winnings-bet.Amount)
+		lines = append(lines, fmt.Sprintf("✅ %s (%d): %s %d:1 — +%s", name, bet.Amount, combo, multiplier, formatCredits(winnings)))
+		r.LogCreditOperation(s, fmt.Sprintf("🎲 Побочная ставка %s <@%s> в блэкджеке: %s %d:1, выплата %s", name, game.PlayerID, combo, multiplier, formatCredits(winnings)))
+		log.Printf("Побочная ставка %s игрока %s сыграла: %s, выплата %d", bet.Kind, game.PlayerID, combo, winnings)
+	}
+	return "**🎲 Побочные ставки:**\n" + strings.Join(lines, "\n")
+}
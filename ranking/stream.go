@@ -0,0 +1,151 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Бонус за Go Live. Пользователь, который стримит экран или включил камеру, получает к обычным
+// кредитам за войс бонус стримера, а слушатели в его канале — небольшой бонус зрителя.
+// Время и бонусы учитываются отдельно от войса в хеше stream:<userID>.
+
+// streamStatsKey возвращает ключ статистики стримов пользователя.
+func streamStatsKey(userID string) string {
+	return "stream:" + userID
+}
+
+// StreamBonusPerHour возвращает бонус стримера в кредитах за час.
+func (r *Ranking) StreamBonusPerHour() int {
+	return r.GetIntSetting("stream_bonus_per_hour", envInt("STREAM_BONUS_PER_HOUR", 30))
+}
+
+// StreamViewerBonusPerHour возвращает бонус зрителя стрима в кредитах за час.
+func (r *Ranking) StreamViewerBonusPerHour() int {
+	return r.GetIntSetting("stream_viewer_bonus_per_hour", envInt("STREAM_VIEWER_BONUS_PER_HOUR", 10))
+}
+
+// perMinuteShare возвращает часть почасовой ставки за minute-ю минуту так, чтобы за час
+// набиралась ровно ratePerHour (дробные части накапливаются).
+func perMinuteShare(ratePerHour, minute int) int {
+	if ratePerHour <= 0 || minute <= 0 {
+		return 0
+	}
+	return ratePerHour*minute/60 - ratePerHour*(minute-1)/60
+}
+
+// streamerInChannel возвращает стримера в голосовом канале, кроме самого пользователя.
+// Вызывается под r.mu.
+func (r *Ranking) streamerInChannel(channelID, exceptID string) string {
+	for streamerID := range r.voiceStreamers {
+		if streamerID != exceptID && r.voiceChannels[streamerID] == channelID {
+			return streamerID
+		}
+	}
+	return ""
+}
+
+// creditStreamBonus начисляет бонус стримера или зрителя за minute-ю минуту в войсе.
+// Вызывается под r.mu из трекера голосовой активности.
+func (r *Ranking) creditStreamBonus(userID string, minute int) {
+	key := streamStatsKey(userID)
+	if r.voiceStreamers[userID] {
+		r.redis.HIncrBy(r.ctx, key, "stream_seconds", 60)
+		if bonus := perMinuteShare(r.StreamBonusPerHour(), minute); bonus > 0 {
+			r.UpdateRatingFrom(userID, bonus, "stream", "")
+			r.redis.HIncrBy(r.ctx, key, "stream_bonus", int64(bonus))
+		}
+		return
+	}
+
+	streamerID := r.streamerInChannel(r.voiceChannels[userID], userID)
+	if streamerID == "" || r.voiceAFKPercent(userID) < 100 {
+		return
+	}
+	r.redis.HIncrBy(r.ctx, key, "watch_seconds", 60)
+	if bonus := perMinuteShare(r.StreamViewerBonusPerHour(), minute); bonus > 0 {
+		r.UpdateRatingFrom(userID, bonus, "stream", streamerID)
+		r.redis.HIncrBy(r.ctx, key, "watch_bonus", int64(bonus))
+	}
+}
+
+// streamStatsField возвращает поле статистики стримов для !stats или nil, если стримов не было.
+func (r *Ranking) streamStatsField(userID string) *discordgo.MessageEmbedField {
+	stats, err := r.redis.HGetAll(r.ctx, streamStatsKey(userID)).Result()
+	if err != nil || len(stats) == 0 {
+		return nil
+	}
+	value := func(field string) int {
+		n, _ := strconv.Atoi(stats[field])
+		return n
+	}
+	return &discordgo.MessageEmbedField{
+		Name: "📺 Стримы (Go Live)",
+		Value: fmt.Sprintf("Стримил: **%s** (+%s)\nСмотрел: **%s** (+%s)",
+			formatTime(value("stream_seconds")), formatCredits(value("stream_bonus")),
+			formatTime(value("watch_seconds")), formatCredits(value("watch_bonus"))),
+		Inline: false,
+	}
+}
+
+// streamSettings сопоставляет подкоманды !a_stream с настройками.
+var streamSettings = map[string]string{
+	"bonus":  "stream_bonus_per_hour",
+	"viewer": "stream_viewer_bonus_per_hour",
+}
+
+// HandleStreamSettingsCommand обрабатывает команду !a_stream [bonus|viewer <кредитов в час>].
+func (r *Ranking) HandleStreamSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_stream: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать бонус за стримы! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		name, ok := streamSettings[parts[1]]
+		value, err := strconv.Atoi(parts[2])
+		if !ok || err != nil || value < 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_stream [bonus|viewer <кредитов в час>]`")
+			return
+		}
+		if err := r.SetIntSetting(name, value); err != nil {
+			log.Printf("Не удалось сохранить настройку %s: %v", name, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ `%s` = %d кредитов в час", name, value))
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_stream [bonus|viewer <кредитов в час>]`")
+		return
+	}
+
+	r.mu.Lock()
+	var live []string
+	for streamerID := range r.voiceStreamers {
+		live = append(live, fmt.Sprintf("<@%s> в <#%s>", streamerID, r.voiceChannels[streamerID]))
+	}
+	r.mu.Unlock()
+	streams := "Сейчас никто не стримит."
+	if len(live) > 0 {
+		streams = strings.Join(live, "\n")
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "📺 Бонус за Go Live",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🎥 Стример", Value: fmt.Sprintf("+%d в час", r.StreamBonusPerHour()), Inline: true},
+			{Name: "👀 Зритель", Value: fmt.Sprintf("+%d в час", r.StreamViewerBonusPerHour()), Inline: true},
+			{Name: "🔴 В эфире", Value: truncate(streams, 1024), Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Бонус идёт сверх кредитов за войс · AFK-зрители бонус не получают"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
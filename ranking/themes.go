@@ -0,0 +1,195 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Theme описывает визуальную тему игровых embed'ов.
+type Theme struct {
+	ID      string
+	Name    string
+	Price   int
+	Color   int               // цвет embed'ов (0 — случайный, как раньше)
+	Icons   map[string]string // значок в заголовке по играм: bj, rb, duel
+	RBRed   string            // клетка «красное» на поле Красный-Чёрный
+	RBBlack string            // клетка «чёрное» на поле Красный-Чёрный
+}
+
+// defaultThemeID — тема по умолчанию, доступная всем бесплатно.
+const defaultThemeID = "classic"
+
+// Themes — темы, доступные в магазине, в порядке отображения.
+var Themes = []Theme{
+	{ID: "classic", Name: "Классика", Price: 0, RBRed: "🔴", RBBlack: "⚫"},
+	{ID: "cyberpunk", Name: "Киберпанк", Price: 500, Color: 0xFF00FF, Icons: map[string]string{"bj": "🤖", "rb": "🌆", "duel": "⚡"}, RBRed: "🟥", RBBlack: "⬛"},
+	{ID: "imperial", Name: "Императорская", Price: 1000, Color: 0xC8102E, Icons: map[string]string{"bj": "🐉", "rb": "🏮", "duel": "🗡️"}, RBRed: "🧧", RBBlack: "🀄"},
+}
+
+// themesOwnedKey возвращает множество купленных тем пользователя.
+func themesOwnedKey(userID string) string {
+	return "themes:owned:" + userID
+}
+
+// themeActiveKey возвращает ключ с выбранной темой пользователя.
+func themeActiveKey(userID string) string {
+	return "themes:active:" + userID
+}
+
+// findTheme ищет тему по ID.
+func findTheme(id string) (Theme, bool) {
+	for _, theme := range Themes {
+		if theme.ID == id {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+// UserTheme возвращает выбранную пользователем тему или классическую.
+func (r *Ranking) UserTheme(userID string) Theme {
+	id, err := r.redis.Get(r.ctx, themeActiveKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Не удалось получить тему пользователя %s: %v", userID, err)
+	}
+	if theme, ok := findTheme(id); ok {
+		return theme
+	}
+	theme, _ := findTheme(defaultThemeID)
+	return theme
+}
+
+// ownsTheme проверяет, куплена ли тема пользователем.
+func (r *Ranking) ownsTheme(userID, themeID string) bool {
+	if themeID == defaultThemeID {
+		return true
+	}
+	owned, err := r.redis.SIsMember(r.ctx, themesOwnedKey(userID), themeID).Result()
+	if err != nil {
+		log.Printf("Не удалось проверить тему %s пользователя %s: %v", themeID, userID, err)
+	}
+	return owned
+}
+
+// themeColor возвращает цвет игрового embed'а по теме пользователя.
+func (r *Ranking) themeColor(userID string) int {
+	if theme := r.UserTheme(userID); theme.Color != 0 {
+		return theme.Color
+	}
+	return randomColor()
+}
+
+// themeTitle заменяет значок в начале заголовка игры на значок темы пользователя.
+func (r *Ranking) themeTitle(userID, game, title string) string {
+	icon := r.UserTheme(userID).Icons[game]
+	if icon == "" {
+		return title
+	}
+	fields := strings.SplitN(title, " ", 2)
+	if len(fields) < 2 {
+		return icon + " " + title
+	}
+	return icon + " " + fields[1]
+}
+
+// HandleThemesCommand обрабатывает команду !themes: магазин тем.
+func (r *Ranking) HandleThemesCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !themes от %s", m.Author.ID)
+
+	active := r.UserTheme(m.Author.ID)
+	fields := make([]*discordgo.MessageEmbedField, 0, len(Themes))
+	for _, theme := range Themes {
+		status := fmt.Sprintf("%s — `/buy_theme %s`", formatCredits(theme.Price), theme.ID)
+		if r.ownsTheme(m.Author.ID, theme.ID) {
+			status = fmt.Sprintf("✅ Куплена — `/theme %s`", theme.ID)
+		}
+		if theme.ID == active.ID {
+			status = "🎨 Выбрана"
+		}
+		preview := fmt.Sprintf("%s %s · %s %s %s", theme.Icons["bj"], theme.Icons["rb"], theme.Icons["duel"], theme.RBRed, theme.RBBlack)
+		if theme.Icons == nil {
+			preview = fmt.Sprintf("♠️ 🎰 ⚔️ · %s %s", theme.RBRed, theme.RBBlack)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  theme.Name,
+			Value: fmt.Sprintf("%s\n%s", preview, status),
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎨 Магазин тем казино",
+		Description: "Темы меняют цвет и значки в блэкджеке, Красном-Чёрном и дуэлях.",
+		Color:       r.themeColor(m.Author.ID),
+		Fields:      fields,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Твой баланс: %s кредитов", compactNumber(r.GetRating(m.Author.ID)))},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
+
+// HandleBuyThemeCommand обрабатывает команду !buy_theme <ID>.
+func (r *Ranking) HandleBuyThemeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !buy_theme: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/buy_theme <ID>`. Список тем: `/themes`")
+		return
+	}
+	theme, ok := findTheme(parts[1])
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такой темы нет! Список тем: `/themes`")
+		return
+	}
+	if r.ownsTheme(m.Author.ID, theme.ID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Тема **%s** уже твоя! Включить: `/theme %s`", theme.Name, theme.ID))
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rating := r.GetRating(m.Author.ID)
+	if rating < theme.Price {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Недостаточно кредитов! Тема стоит %s, твой баланс: %s", formatCredits(theme.Price), formatCredits(rating)))
+		return
+	}
+	if err := r.redis.SAdd(r.ctx, themesOwnedKey(m.Author.ID), theme.ID).Err(); err != nil {
+		log.Printf("Не удалось сохранить тему %s пользователя %s: %v", theme.ID, m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка покупки темы! Проверьте Redis-сервер.")
+		return
+	}
+	r.UpdateRatingFrom(m.Author.ID, -theme.Price, "theme", "")
+	r.redis.Set(r.ctx, themeActiveKey(m.Author.ID), theme.ID, 0)
+
+	r.LogCreditOperation(s, fmt.Sprintf("🎨 <@%s> купил тему **%s** за %s", m.Author.ID, theme.Name, formatCredits(theme.Price)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Тема **%s** куплена и включена! 🎨", theme.Name))
+}
+
+// HandleThemeCommand обрабатывает команду !theme <ID>: выбор купленной темы.
+func (r *Ranking) HandleThemeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !theme: %s от %s", command, m.Author.ID)
+
+	parts := strings.Fields(command)
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/theme <ID>`. Список тем: `/themes`")
+		return
+	}
+	theme, ok := findTheme(parts[1])
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "❌ Такой темы нет! Список тем: `/themes`")
+		return
+	}
+	if !r.ownsTheme(m.Author.ID, theme.ID) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Сначала купи тему: `/buy_theme %s` (%s)", theme.ID, formatCredits(theme.Price)))
+		return
+	}
+	if err := r.redis.Set(r.ctx, themeActiveKey(m.Author.ID), theme.ID, 0).Err(); err != nil {
+		log.Printf("Не удалось выбрать тему %s пользователя %s: %v", theme.ID, m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка выбора темы! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎨 Тема **%s** включена!", theme.Name))
+}
@@ -0,0 +1,167 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Постер победителя киноаукциона: после того как просмотренный фильм снят с аукциона
+// (!removecinema), ищем его в TMDB и публикуем карточку с постером в каналы объявлений
+// и в Telegram. Ключ API — TMDB_API_KEY (v3); без него карточка уходит без описания.
+const (
+	tmdbAPIBase    = "https://api.themoviedb.org/3"
+	tmdbPosterBase = "https://image.tmdb.org/t/p/w500"
+)
+
+// tmdbHTTPClient — HTTP-клиент для запросов к TMDB с таймаутом.
+var tmdbHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// TMDBMovie — данные фильма из TMDB.
+type TMDBMovie struct {
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	Overview      string  `json:"overview"`
+	ReleaseDate   string  `json:"release_date"`
+	Runtime       int     `json:"runtime"`
+	PosterPath    string  `json:"poster_path"`
+	VoteAverage   float64 `json:"vote_average"`
+}
+
+// Year возвращает год выхода или пустую строку.
+func (m TMDBMovie) Year() string {
+	if len(m.ReleaseDate) >= 4 {
+		return m.ReleaseDate[:4]
+	}
+	return ""
+}
+
+// PosterURL возвращает ссылку на постер или пустую строку.
+func (m TMDBMovie) PosterURL() string {
+	if m.PosterPath == "" {
+		return ""
+	}
+	return tmdbPosterBase + m.PosterPath
+}
+
+// tmdbGet выполняет запрос к TMDB и разбирает JSON-ответ в out.
+func tmdbGet(path string, params url.Values, out interface{}) error {
+	params.Set("api_key", os.Getenv("TMDB_API_KEY"))
+	params.Set("language", "ru-RU")
+	resp, err := tmdbHTTPClient.Get(tmdbAPIBase + path + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TMDB API вернул статус %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ошибка парсинга ответа TMDB: %v", err)
+	}
+	return nil
+}
+
+// LookupMovie ищет фильм по названию и возвращает подробности лучшего совпадения.
+func LookupMovie(title string) (TMDBMovie, bool, error) {
+	if os.Getenv("TMDB_API_KEY") == "" {
+		return TMDBMovie{}, false, nil
+	}
+	var search struct {
+		Results []TMDBMovie `json:"results"`
+	}
+	if err := tmdbGet("/search/movie", url.Values{"query": {title}}, &search); err != nil {
+		return TMDBMovie{}, false, err
+	}
+	if len(search.Results) == 0 {
+		return TMDBMovie{}, false, nil
+	}
+	// Длительность есть только в карточке фильма, поиск её не возвращает
+	movie := search.Results[0]
+	if err := tmdbGet(fmt.Sprintf("/movie/%d", movie.ID), url.Values{}, &movie); err != nil {
+		log.Printf("Не удалось получить подробности фильма TMDB %d: %v", movie.ID, err)
+	}
+	return movie, true, nil
+}
+
+// announceCinemaWinner публикует карточку фильма-победителя в каналы объявлений и Telegram.
+func (r *Ranking) announceCinemaWinner(s *discordgo.Session, winner CinemaOption) {
+	movie, found, err := LookupMovie(winner.Name)
+	if err != nil {
+		log.Printf("Не удалось найти фильм %q в TMDB: %v", winner.Name, err)
+		r.ReportError("tmdb", err)
+	}
+
+	title := winner.Name
+	embed := &discordgo.MessageEmbed{
+		Title:       "🏆 Победитель киноаукциона",
+		Description: fmt.Sprintf("**%s**\n\nСобрано: %s от %d участников", title, formatCredits(winner.Total), len(winner.Bets)),
+		Color:       0xFF4500,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Киноаукцион 🎬"},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	var overview string
+	if found {
+		title = movie.Title
+		if year := movie.Year(); year != "" {
+			title = fmt.Sprintf("%s (%s)", movie.Title, year)
+		}
+		// Подпись к фото в Telegram ограничена 1024 символами
+		overview = movie.Overview
+		if runes := []rune(overview); len(runes) > 800 {
+			overview = string(runes[:799]) + "…"
+		}
+		embed.Description = fmt.Sprintf("**%s**\n\n%s", title, overview)
+		embed.URL = fmt.Sprintf("https://www.themoviedb.org/movie/%d", movie.ID)
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Собрано", Value: fmt.Sprintf("%s от %d участников", formatCredits(winner.Total), len(winner.Bets)), Inline: true},
+		}
+		if movie.Runtime > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Длительность", Value: fmt.Sprintf("%d ч %02d мин", movie.Runtime/60, movie.Runtime%60), Inline: true})
+		}
+		if movie.VoteAverage > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Рейтинг TMDB", Value: fmt.Sprintf("⭐ %.1f", movie.VoteAverage), Inline: true})
+		}
+		if poster := movie.PosterURL(); poster != "" {
+			embed.Image = &discordgo.MessageEmbedImage{URL: poster}
+		}
+	}
+	details := []string{fmt.Sprintf("🏆 Победитель киноаукциона: %s", title)}
+	if found && movie.Runtime > 0 {
+		details = append(details, fmt.Sprintf("⏱ %d ч %02d мин", movie.Runtime/60, movie.Runtime%60))
+	}
+	if overview != "" {
+		details = append(details, "", overview)
+	}
+
+	for _, channelID := range r.announceChannels() {
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			log.Printf("Не удалось отправить победителя киноаукциона в канал %s: %v", channelID, err)
+		}
+	}
+
+	r.mu.Lock()
+	mirror, photoMirror := r.telegramMirror, r.telegramPhotoMirror
+	r.mu.Unlock()
+	caption := strings.Join(details, "\n")
+	switch {
+	case found && movie.PosterURL() != "" && photoMirror != nil:
+		err = photoMirror(movie.PosterURL(), caption)
+	case mirror != nil:
+		err = mirror(caption)
+	default:
+		err = nil
+	}
+	if err != nil {
+		log.Printf("Не удалось продублировать победителя киноаукциона в Telegram: %v", err)
+		r.ReportError("telegram", err)
+	}
+}
@@ -0,0 +1,208 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// topMaxPageSize — наибольший размер страницы /top (ограничение описания эмбеда).
+const topMaxPageSize = 25
+
+// topSort описывает критерий сортировки таблицы /top.
+type topSort struct {
+	Title  string
+	Format func(value int) string
+}
+
+// topSorts — критерии сортировки /top: баланс, время в войсе, победы в дуэлях, выигрыш в играх,
+// число разных NFT и стоимость NFT.
+var topSorts = map[string]topSort{
+	"rating":     {Title: "💰 Топ по соцкредитам", Format: formatCredits},
+	"voice":      {Title: "🎙️ Топ по времени в войсе", Format: func(v int) string { return fmt.Sprintf("%.1f ч", float64(v)/3600) }},
+	"duels":      {Title: "⚔️ Топ по победам в дуэлях", Format: func(v int) string { return fmt.Sprintf("%d побед", v) }},
+	"gamblers":   {Title: "🎰 Топ игроков по чистому выигрышу", Format: func(v int) string { return "+" + formatCredits(v) }},
+	"collectors": {Title: "🗂️ Топ коллекционеров по числу разных NFT", Format: func(v int) string { return fmt.Sprintf("%d NFT", v) }},
+	"nft":        {Title: "🖼️ Топ по стоимости NFT", Format: func(v int) string { return "💎 " + formatCredits(v) }},
+}
+
+// topSortOrder — порядок критериев в подсказках.
+var topSortOrder = []string{"rating", "voice", "duels", "gamblers", "collectors", "nft"}
+
+// topEntry — строка таблицы /top: игрок и значение критерия.
+type topEntry struct {
+	UserID string
+	Value  int
+}
+
+// topEntries возвращает страницу таблицы по критерию и общее число игроков с ненулевым значением.
+// Баланс берётся из ZSET economy:balances, категории — из ZSET top:*, стоимость NFT считается по инвентарям.
+func (r *Ranking) topEntries(sortBy string, offset, limit int) ([]topEntry, int, error) {
+	if key, ok := topSets[sortBy]; ok {
+		return r.topFromSet(key, offset, limit)
+	}
+	if sortBy == "rating" {
+		users, total, err := r.GetTop(offset, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries := make([]topEntry, 0, len(users))
+		for _, user := range users {
+			entries = append(entries, topEntry{UserID: user.ID, Value: user.Rating})
+		}
+		return entries, total, nil
+	}
+
+	var entries []topEntry
+	err := r.scanKeys("user:*", func(key string) error {
+		userID := strings.TrimPrefix(key, "user:")
+		if value := r.nftPortfolioValue(userID); value > 0 {
+			entries = append(entries, topEntry{UserID: userID, Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	total := len(entries)
+	if offset >= total {
+		return nil, total, nil
+	}
+	return entries[offset:min(offset+limit, total)], total, nil
+}
+
+// nftPortfolioValue возвращает стоимость NFT игрока по текущим ценам.
+func (r *Ranking) nftPortfolioValue(userID string) int {
+	total := 0
+	for nftID, count := range r.GetUserInventory(userID) {
+		if nft, ok := r.Kki.nfts[nftID]; ok {
+			total += nft.Price * count
+		}
+	}
+	return total
+}
+
+// topPage формирует страницу /top с кнопками ◀️ ▶️. page считается с нуля.
+func (r *Ranking) topPage(sortBy string, size, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	board := topSorts[sortBy]
+	page = max(page, 0)
+	entries, total, err := r.topEntries(sortBy, page*size, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := max((total+size-1)/size, 1)
+	if page >= pages {
+		// Страницы больше нет (например, после сброса балансов) — показываем последнюю
+		page = pages - 1
+		if entries, total, err = r.topEntries(sortBy, page*size, size); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var lines []string
+	for i, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", page*size+i+1, r.publicMention(entry.UserID), board.Format(entry.Value)))
+	}
+	description := "Пока нет лидеров! Будь первым! 😎"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       board.Title,
+		Description: truncate(description, 4000),
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Страница %d/%d · игроков: %d · /top %s [страница] [size:N]", page+1, pages, total, strings.Join(topSortOrder, "|"))},
+	}
+	if pages == 1 {
+		return embed, []discordgo.MessageComponent{}, nil
+	}
+	prefix := fmt.Sprintf("top_page_%s_%d_", sortBy, size)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "◀️ Назад", Style: discordgo.SecondaryButton, CustomID: prefix + strconv.Itoa(page-1), Disabled: page == 0},
+				discordgo.Button{Label: "Вперёд ▶️", Style: discordgo.SecondaryButton, CustomID: prefix + strconv.Itoa(page+1), Disabled: page == pages-1},
+			},
+		},
+	}
+	return embed, components, nil
+}
+
+// parseTopArgs разбирает аргументы !top [критерий] [страница] [size:N]. Страница считается с единицы.
+func parseTopArgs(args []string) (sortBy string, page, size int, ok bool) {
+	sortBy, page, size = "rating", 1, topPageSize
+	for _, arg := range args {
+		if _, known := topSorts[arg]; known {
+			sortBy = arg
+			continue
+		}
+		if value, found := strings.CutPrefix(arg, "size:"); found {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > topMaxPageSize {
+				return "", 0, 0, false
+			}
+			size = n
+			continue
+		}
+		n, valid := parseTopPage(arg)
+		if !valid {
+			return "", 0, 0, false
+		}
+		page = n
+	}
+	return sortBy, page, size, true
+}
+
+// HandleCategoryTopCommand обрабатывает короткие команды !top_voice, !top_duels, !top_gamblers
+// и !top_collectors — то же, что !top <категория>.
+func (r *Ranking) HandleCategoryTopCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	name, args, _ := strings.Cut(command, " ")
+	category := strings.TrimPrefix(name, "/top_")
+	if _, ok := topSets[category]; !ok {
+		return
+	}
+	r.HandleTopCommand(s, m, strings.TrimSpace("/top "+category+" "+args))
+}
+
+// HandleTopPage листает страницы /top.
+func (r *Ranking) HandleTopPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(strings.TrimPrefix(i.MessageComponentData().CustomID, "top_page_"), "_")
+	if len(parts) != 3 {
+		return
+	}
+	if _, ok := topSorts[parts[0]]; !ok {
+		return
+	}
+	size, errSize := strconv.Atoi(parts[1])
+	page, errPage := strconv.Atoi(parts[2])
+	if errSize != nil || errPage != nil || size < 1 || size > topMaxPageSize {
+		return
+	}
+
+	embed, components, err := r.topPage(parts[0], size, page)
+	if err != nil {
+		log.Printf("Не удалось получить страницу топа %s: %v", parts[0], err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❌ Ошибка получения топа! Попробуйте позже.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
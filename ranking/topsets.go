@@ -0,0 +1,102 @@
+package ranking
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ZSET категорийных топов. Поддерживаются при каждом изменении статистики,
+// поэтому /top по категории не перебирает всех пользователей.
+const (
+	topVoiceKey      = "top:voice"      // ZSET userID -> секунд в войсе
+	topDuelsKey      = "top:duels"      // ZSET userID -> побед в дуэлях
+	topGamblersKey   = "top:gamblers"   // ZSET userID -> чистый выигрыш в играх
+	topCollectorsKey = "top:collectors" // ZSET userID -> число разных NFT
+)
+
+// topSets — критерии /top, которые читаются из отдельных ZSET.
+var topSets = map[string]string{
+	"voice":      topVoiceKey,
+	"duels":      topDuelsKey,
+	"gamblers":   topGamblersKey,
+	"collectors": topCollectorsKey,
+}
+
+// setTopScore записывает значение игрока в ZSET категории.
+func (r *Ranking) setTopScore(key, userID string, value int) {
+	if err := r.redis.ZAdd(r.ctx, key, &redis.Z{Score: float64(value), Member: userID}).Err(); err != nil {
+		log.Printf("Не удалось обновить %s для %s: %v", key, userID, err)
+	}
+}
+
+// recordGamblingTop учитывает результат игры в топе игроков. Ставка списывается при размещении,
+// выигрыш зачисляется при расчёте, так что сумма изменений — чистый выигрыш.
+func (r *Ranking) recordGamblingTop(userID string, delta int, source string) {
+	if delta == 0 || !gamblingSources[source] {
+		return
+	}
+	if err := r.redis.ZIncrBy(r.ctx, topGamblersKey, float64(delta), userID).Err(); err != nil {
+		log.Printf("Не удалось обновить %s для %s: %v", topGamblersKey, userID, err)
+	}
+}
+
+// topFromSet возвращает страницу топа из ZSET категории и число игроков с положительным значением.
+func (r *Ranking) topFromSet(key string, offset, limit int) ([]topEntry, int, error) {
+	total, err := r.redis.ZCount(r.ctx, key, "(0", "+inf").Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	top, err := r.redis.ZRevRangeByScoreWithScores(r.ctx, key, &redis.ZRangeBy{
+		Min: "(0", Max: "+inf", Offset: int64(offset), Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := make([]topEntry, 0, len(top))
+	for _, z := range top {
+		userID, _ := z.Member.(string)
+		entries = append(entries, topEntry{UserID: userID, Value: int(z.Score)})
+	}
+	return entries, int(total), nil
+}
+
+// migrateTopSets заполняет ZSET топов по войсу, дуэлям и коллекциям из сохранённых данных.
+// Чистый выигрыш в играх из прошлого не восстановить, он копится с момента миграции.
+func migrateTopSets(r *Ranking) error {
+	users := 0
+	err := r.scanKeys("user:*", func(key string) error {
+		data, err := r.redis.Get(r.ctx, key).Bytes()
+		if err != nil {
+			return nil
+		}
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil || user.ID == "" {
+			return nil
+		}
+		pipe := r.redis.Pipeline()
+		pipe.ZAdd(r.ctx, topVoiceKey, &redis.Z{Score: float64(user.VoiceSeconds), Member: user.ID})
+		pipe.ZAdd(r.ctx, topDuelsKey, &redis.Z{Score: float64(user.DuelsWon), Member: user.ID})
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return err
+		}
+		users++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	collectors := 0
+	err = r.scanKeys("inventory:*", func(key string) error {
+		userID := strings.TrimPrefix(key, "inventory:")
+		if err := r.redis.ZAdd(r.ctx, topCollectorsKey, &redis.Z{Score: float64(len(r.GetUserInventory(userID))), Member: userID}).Err(); err != nil {
+			return err
+		}
+		collectors++
+		return nil
+	})
+	log.Printf("Заполнены топы: %d пользователей, %d инвентарей", users, collectors)
+	return err
+}
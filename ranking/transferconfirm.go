@@ -0,0 +1,405 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Подтверждение переводов. !transfer сначала показывает получателя и сумму с кнопками
+// Подтвердить/Отменить. Если сумма не меньше порога transfer_accept_threshold, перевод после
+// подтверждения ещё и ждёт согласия получателя: кредиты отправителя замораживаются (см. Hold),
+// а при отказе или истечении срока возвращаются.
+const (
+	transferConfirmTTL = 5 * time.Minute // сколько ждёт подтверждения отправителя
+	transferAcceptTTL  = time.Hour       // сколько ждёт согласия получателя
+)
+
+// PendingTransfer — перевод, ожидающий подтверждения отправителя или согласия получателя.
+type PendingTransfer struct {
+	ID      string `json:"id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Amount  int    `json:"amount"`
+	Reason  string `json:"reason"`
+	HoldID  string `json:"hold_id,omitempty"` // заполнен, пока перевод ждёт согласия получателя
+	Created int64  `json:"created"`
+}
+
+// pendingTransferKey возвращает ключ перевода, ожидающего подтверждения.
+func pendingTransferKey(id string) string {
+	return "transfer:pending:" + id
+}
+
+// TransferAcceptThreshold возвращает сумму, начиная с которой перевод требует согласия получателя (0 — никогда).
+func (r *Ranking) TransferAcceptThreshold() int {
+	return r.GetIntSetting("transfer_accept_threshold", envInt("TRANSFER_ACCEPT_THRESHOLD", 0))
+}
+
+// needsRecipientAccept сообщает, должен ли получатель согласиться на перевод.
+func (r *Ranking) needsRecipientAccept(amount int) bool {
+	threshold := r.TransferAcceptThreshold()
+	return threshold > 0 && amount >= threshold
+}
+
+// savePendingTransfer сохраняет перевод, ожидающий ответа.
+func (r *Ranking) savePendingTransfer(transfer *PendingTransfer, ttl time.Duration) error {
+	data, _ := json.Marshal(transfer)
+	return r.redis.Set(r.ctx, pendingTransferKey(transfer.ID), data, ttl).Err()
+}
+
+// takePendingTransfer атомарно забирает перевод: обработать кнопку может только один клик.
+func (r *Ranking) takePendingTransfer(id string) (*PendingTransfer, error) {
+	data, err := r.redis.GetDel(r.ctx, pendingTransferKey(id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var transfer PendingTransfer
+	if err := json.Unmarshal(data, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// peekPendingTransfer читает перевод, не забирая его.
+func (r *Ranking) peekPendingTransfer(id string) (*PendingTransfer, error) {
+	data, err := r.redis.Get(r.ctx, pendingTransferKey(id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var transfer PendingTransfer
+	if err := json.Unmarshal(data, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// transferEmbed формирует эмбед перевода с текущим статусом.
+func transferEmbed(transfer *PendingTransfer, title, status string, color int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: status,
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📤 Отправитель", Value: fmt.Sprintf("<@%s>", transfer.From), Inline: true},
+			{Name: "📥 Получатель", Value: fmt.Sprintf("<@%s>", transfer.To), Inline: true},
+			{Name: "💰 Сумма", Value: formatCredits(transfer.Amount), Inline: true},
+			{Name: "📝 Причина", Value: truncate(transfer.Reason, 1024), Inline: false},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// transferButtons возвращает пару кнопок для шага перевода.
+func transferButtons(okLabel, okPrefix, cancelLabel, cancelPrefix, id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: okLabel, Style: discordgo.SuccessButton, CustomID: okPrefix + id},
+				discordgo.Button{Label: cancelLabel, Style: discordgo.DangerButton, CustomID: cancelPrefix + id},
+			},
+		},
+	}
+}
+
+// requestTransferConfirmation показывает отправителю перевод с кнопками подтверждения.
+func (r *Ranking) requestTransferConfirmation(s *discordgo.Session, channelID, fromID, toID string, amount int, reason string) error {
+	if fromID == toID {
+		return fmt.Errorf("❌ Нельзя перевести кредиты самому себе!")
+	}
+	if balance := r.GetRating(fromID); balance < amount {
+		return fmt.Errorf("❌ Недостаточно кредитов! Твой баланс: %s", formatCredits(balance))
+	}
+	if err := r.checkTransferLimits(fromID, amount); err != nil {
+		return err
+	}
+
+	transfer := &PendingTransfer{ID: generateGameID(fromID), From: fromID, To: toID, Amount: amount, Reason: reason, Created: time.Now().Unix()}
+	if err := r.savePendingTransfer(transfer, transferConfirmTTL); err != nil {
+		log.Printf("Не удалось сохранить перевод %s: %v", transfer.ID, err)
+		return fmt.Errorf("❌ Ошибка при создании перевода! Попробуйте позже.")
+	}
+
+	status := fmt.Sprintf("Проверь получателя и сумму и подтверди перевод в течение %d минут.", int(transferConfirmTTL.Minutes()))
+	if tax := r.transferTax(amount); tax > 0 {
+		status += fmt.Sprintf("\n🏛️ Налог: %s, получатель получит %s.", formatCredits(tax), formatCredits(amount-tax))
+	}
+	if r.needsRecipientAccept(amount) {
+		status += "\n🤝 Крупный перевод: после подтверждения его должен принять получатель."
+	}
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embed:      transferEmbed(transfer, "💸 Подтверждение перевода", status, 0xFFD700),
+		Components: transferButtons("✅ Подтвердить", "transfer_confirm_", "❌ Отменить", "transfer_cancel_", transfer.ID),
+	})
+	if err != nil {
+		r.redis.Del(r.ctx, pendingTransferKey(transfer.ID))
+		log.Printf("Не удалось отправить подтверждение перевода: %v", err)
+		return fmt.Errorf("❌ Не удалось отправить подтверждение перевода!")
+	}
+	return nil
+}
+
+// requestRecipientAccept замораживает сумму перевода и просит получателя принять его.
+// Возвращает текст статуса для сообщения отправителя. Текст ошибки предназначен для пользователя.
+func (r *Ranking) requestRecipientAccept(s *discordgo.Session, channelID string, transfer *PendingTransfer) (string, error) {
+	holdID, err := r.Hold(transfer.From, transfer.Amount, "escrow", transferAcceptTTL)
+	if err != nil {
+		return "", fmt.Errorf("%s", r.holdErrorMessage(transfer.From, err))
+	}
+	transfer.HoldID = holdID
+	if err := r.savePendingTransfer(transfer, transferAcceptTTL); err != nil {
+		r.Release(holdID)
+		log.Printf("Не удалось сохранить перевод %s: %v", transfer.ID, err)
+		return "", fmt.Errorf("❌ Ошибка при создании перевода! Попробуйте позже.")
+	}
+
+	status := fmt.Sprintf("<@%s>, тебе перевод! Прими его в течение %d минут. Кредиты отправителя заморожены.", transfer.To, int(transferAcceptTTL.Minutes()))
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    "<@" + transfer.To + ">",
+		Embed:      transferEmbed(transfer, "🤝 Перевод ждёт получателя", status, 0x00BFFF),
+		Components: transferButtons("✅ Принять", "transfer_accept_", "✖️ Отказаться", "transfer_decline_", transfer.ID),
+	})
+	if err != nil {
+		r.redis.Del(r.ctx, pendingTransferKey(transfer.ID))
+		r.Release(holdID)
+		log.Printf("Не удалось отправить запрос получателю перевода: %v", err)
+		return "", fmt.Errorf("❌ Не удалось отправить запрос получателю!")
+	}
+	return fmt.Sprintf("⏳ Ждём, пока <@%s> примет перевод. Кредиты заморожены.", transfer.To), nil
+}
+
+// HandleTransferButton обрабатывает кнопки подтверждения перевода и согласия получателя.
+func (r *Ranking) HandleTransferButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	parts := strings.SplitN(customID, "_", 3)
+	if len(parts) != 3 {
+		respondEphemeral(s, i, "❌ Ошибка: неверный формат кнопки!")
+		return
+	}
+	action, id := parts[1], parts[2]
+	userID := i.Member.User.ID
+	log.Printf("Обработка кнопки перевода %s от %s", customID, userID)
+
+	transfer, err := r.peekPendingTransfer(id)
+	if err == redis.Nil {
+		respondEphemeral(s, i, "❌ Перевод не найден или уже обработан!")
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка загрузки перевода %s: %v", id, err)
+		respondEphemeral(s, i, "❌ Ошибка при обработке перевода!")
+		return
+	}
+
+	// Отправитель подтверждает и отменяет перевод, получатель принимает или отказывается
+	owner := transfer.From
+	if action == "accept" || action == "decline" {
+		owner = transfer.To
+	}
+	if userID != owner && !(action == "decline" && userID == transfer.From) {
+		respondEphemeral(s, i, "❌ Кнопка не для вас! Император гневен! 👑")
+		return
+	}
+	if transfer, err = r.takePendingTransfer(id); err != nil {
+		respondEphemeral(s, i, "❌ Перевод не найден или уже обработан!")
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch action {
+	case "confirm":
+		if r.needsRecipientAccept(transfer.Amount) {
+			status, err := r.requestRecipientAccept(s, i.ChannelID, transfer)
+			if err != nil {
+				embed = transferEmbed(transfer, "❌ Перевод не выполнен", err.Error(), 0xFF0000)
+				break
+			}
+			embed = transferEmbed(transfer, "💸 Перевод подтверждён", status, 0x00BFFF)
+			break
+		}
+		embed = r.executePendingTransfer(s, transfer)
+	case "accept":
+		embed = r.executeHeldTransfer(s, transfer)
+	case "decline":
+		if _, err := r.Release(transfer.HoldID); err != nil && err != errEscrowClosed {
+			log.Printf("Не удалось вернуть удержание перевода %s: %v", transfer.ID, err)
+		}
+		embed = transferEmbed(transfer, "✖️ Перевод отклонён", fmt.Sprintf("<@%s> отменил перевод. Кредиты возвращены <@%s>.", userID, transfer.From), 0xFF0000)
+	case "cancel":
+		embed = transferEmbed(transfer, "✖️ Перевод отменён", "Перевод отменён, кредиты не списаны.", 0xFF0000)
+	default:
+		respondEphemeral(s, i, "❌ Неизвестная кнопка!")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// executePendingTransfer выполняет подтверждённый перевод и возвращает эмбед с результатом.
+func (r *Ranking) executePendingTransfer(s *discordgo.Session, transfer *PendingTransfer) *discordgo.MessageEmbed {
+	tax, err := r.transferCredits(s, transfer.From, transfer.To, transfer.Amount, transfer.Reason)
+	if err != nil {
+		return transferEmbed(transfer, "❌ Перевод не выполнен", err.Error(), 0xFF0000)
+	}
+	return transferEmbed(transfer, "✅ Перевод выполнен", transferConfirmation(transfer.From, transfer.To, transfer.Amount, tax, transfer.Reason), 0x00FF00)
+}
+
+// executeHeldTransfer передаёт получателю замороженные кредиты принятого перевода. Удержание
+// подтверждается и зачисляется напрямую, не возвращаясь отправителю, поэтому потратить
+// замороженные кредиты до зачисления нельзя.
+func (r *Ranking) executeHeldTransfer(s *discordgo.Session, transfer *PendingTransfer) *discordgo.MessageEmbed {
+	if err := r.reserveTransferLimits(transfer.From, transfer.Amount); err != nil {
+		if _, relErr := r.Release(transfer.HoldID); relErr != nil && relErr != errEscrowClosed {
+			log.Printf("Не удалось вернуть удержание перевода %s: %v", transfer.ID, relErr)
+		}
+		return transferEmbed(transfer, "❌ Перевод не выполнен", err.Error()+"\nКредиты возвращены отправителю.", 0xFF0000)
+	}
+	hold, err := r.escrowCommit(transfer.HoldID)
+	if err != nil {
+		r.releaseTransferLimits(transfer.From, transfer.Amount)
+		return transferEmbed(transfer, "⏰ Перевод истёк", "Срок ожидания вышел, кредиты уже возвращены отправителю.", 0x808080)
+	}
+
+	tax := r.deliverTransferHold(hold, transfer.To)
+
+	log.Printf("Перевод %d кредитов от %s к %s из удержания %s, налог %d (причина: %s)", transfer.Amount, transfer.From, transfer.To, transfer.HoldID, tax, transfer.Reason)
+	text := fmt.Sprintf("<@%s> перевёл %s <@%s>%s", transfer.From, formatCredits(transfer.Amount-tax), transfer.To, formatReason(transfer.Reason))
+	if tax > 0 {
+		text += fmt.Sprintf(" · налог %s (%s)", formatCredits(tax), r.transferTaxDestination())
+	}
+	r.LogCreditOperation(s, text)
+	return transferEmbed(transfer, "✅ Перевод выполнен", transferConfirmation(transfer.From, transfer.To, transfer.Amount, tax, transfer.Reason), 0x00FF00)
+}
+
+// deliverTransferHold удерживает налог с подтверждённого удержания перевода и зачисляет остаток
+// получателю. Возвращает удержанный налог.
+func (r *Ranking) deliverTransferHold(hold EscrowHold, to string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tax := r.transferTax(hold.Credits)
+	if tax > 0 {
+		r.collectTransferTax(tax)
+	}
+	hold.Credits -= tax
+	r.escrowDeliverLocked(hold, to, "transfer")
+	return tax
+}
+
+// HandleTransferSettingsCommand обрабатывает команду
+// !a_transfer [accept|cap|cooldown <значение>] | [@user [cap|cooldown <значение>|reset]].
+func (r *Ranking) HandleTransferSettingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_transfer: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать переводы! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_transfer [accept|cap|cooldown <значение>]` или `/a_transfer @user [cap|cooldown <значение>|reset]` (0 — без ограничения, cooldown в секундах)"
+	parts := strings.Fields(command)
+	if len(m.Mentions) == 1 {
+		r.handleTransferLimitOverride(s, m, parts, usage)
+		return
+	}
+	if len(parts) == 1 {
+		threshold := "выключено"
+		if value := r.TransferAcceptThreshold(); value > 0 {
+			threshold = "от " + formatCredits(value)
+		}
+		limit := "нет"
+		if value := r.TransferDailyCap(); value > 0 {
+			limit = formatCredits(value)
+		}
+		cooldown := "нет"
+		if value := r.TransferCooldown(); value > 0 {
+			cooldown = formatTime(value)
+		}
+		embed := &discordgo.MessageEmbed{
+			Title: "💸 Переводы",
+			Color: randomColor(),
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "🤝 Согласие получателя", Value: threshold, Inline: true},
+				{Name: "💰 Дневной лимит", Value: limit, Inline: true},
+				{Name: "⏳ Пауза", Value: cooldown, Inline: true},
+				{Name: "🏛️ Налог", Value: fmt.Sprintf("%d%%", r.TransferTaxPercent()), Inline: true},
+			},
+			Footer: &discordgo.MessageEmbedFooter{Text: "/a_transfer accept|cap|cooldown <значение> · /a_transfer @user · налог — /a_tax"},
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, embed)
+		return
+	}
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	value, err := strconv.Atoi(parts[2])
+	if err != nil || value < 0 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	var setting, text string
+	switch parts[1] {
+	case "accept":
+		setting = "transfer_accept_threshold"
+		text = fmt.Sprintf("✅ Согласие получателя требуется для переводов от %s (0 — никогда)", formatCredits(value))
+	case "cap":
+		setting = "transfer_daily_cap"
+		text = fmt.Sprintf("✅ Дневной лимит переводов: %s на игрока (0 — без лимита)", formatCredits(value))
+	case "cooldown":
+		setting = "transfer_cooldown"
+		text = fmt.Sprintf("✅ Пауза между переводами: %s (0 — без паузы)", formatTime(value))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	if err := r.SetIntSetting(setting, value); err != nil {
+		log.Printf("Не удалось сохранить настройку %s: %v", setting, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, text)
+}
+
+// handleTransferLimitOverride показывает или меняет индивидуальные лимиты переводов игрока.
+func (r *Ranking) handleTransferLimitOverride(s *discordgo.Session, m *discordgo.MessageCreate, parts []string, usage string) {
+	userID := m.Mentions[0].ID
+	switch {
+	case len(parts) == 2:
+		s.ChannelMessageSend(m.ChannelID, "ℹ️ Лимиты переводов "+r.transferLimitsSummary(userID))
+		return
+	case len(parts) == 3 && parts[2] == "reset":
+		r.redis.Del(r.ctx, transferLimitOverrideKey(userID))
+		log.Printf("Админ %s сбросил лимиты переводов %s", m.Author.ID, userID)
+		s.ChannelMessageSend(m.ChannelID, "✅ Лимиты переводов сброшены к общим: "+r.transferLimitsSummary(userID))
+		return
+	case len(parts) != 4 || (parts[2] != "cap" && parts[2] != "cooldown"):
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	value, err := strconv.Atoi(parts[3])
+	if err != nil || value < 0 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	if err := r.setTransferLimitOverride(userID, parts[2], value); err != nil {
+		log.Printf("Не удалось сохранить лимит переводов %s: %v", userID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+		return
+	}
+	log.Printf("Админ %s задал %s %s = %d", m.Author.ID, userID, parts[2], value)
+	s.ChannelMessageSend(m.ChannelID, "✅ Лимиты переводов обновлены: "+r.transferLimitsSummary(userID))
+}
@@ -0,0 +1,168 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Лимиты переводов: дневной потолок суммы и пауза между переводами одного отправителя.
+// Мешают перекачивать кредиты через твинков. Админ может задать игроку свои значения
+// (0 — без ограничения) в HASH transfer_limit:override:<id>.
+
+// TransferLimitError — отказ в переводе из-за лимита. Текст ошибки предназначен для пользователя.
+type TransferLimitError struct {
+	Cap          int // дневной лимит отправителя
+	Remaining    int // сколько ещё можно перевести сегодня
+	CooldownLeft time.Duration
+}
+
+func (e *TransferLimitError) Error() string {
+	if e.CooldownLeft > 0 {
+		return fmt.Sprintf("⏳ Следующий перевод можно сделать через %s!", formatTime(int(e.CooldownLeft.Seconds())+1))
+	}
+	return fmt.Sprintf("❌ Дневной лимит переводов исчерпан! Осталось на сегодня: %s из %s", formatCredits(e.Remaining), formatCredits(e.Cap))
+}
+
+// Embed возвращает эмбед отказа с оставшимся лимитом.
+func (e *TransferLimitError) Embed() *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🚫 Перевод отклонён",
+		Description: e.Error(),
+		Color:       0xFF0000,
+	}
+	if e.Cap > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "💰 Осталось сегодня", Value: fmt.Sprintf("%s из %s", formatCredits(e.Remaining), formatCredits(e.Cap)), Inline: true})
+	}
+	if e.CooldownLeft > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "⏳ Пауза", Value: formatTime(int(e.CooldownLeft.Seconds()) + 1), Inline: true})
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Лимит обнуляется в полночь"}
+	return embed
+}
+
+// transferLimitOverrideKey возвращает ключ индивидуальных лимитов переводов игрока.
+func transferLimitOverrideKey(userID string) string {
+	return "transfer_limit:override:" + userID
+}
+
+// transferDailyKey возвращает ключ суммы переводов игрока за день.
+func transferDailyKey(userID string, day time.Time) string {
+	return fmt.Sprintf("transfer_limit:daily:%s:%s", userID, day.Format("2006-01-02"))
+}
+
+// transferCooldownKey возвращает ключ паузы между переводами игрока.
+func transferCooldownKey(userID string) string {
+	return "transfer_limit:cooldown:" + userID
+}
+
+// transferLimitFor возвращает лимит игрока: индивидуальный, если задан, иначе общий.
+func (r *Ranking) transferLimitFor(userID, field string, def int) int {
+	value, err := r.redis.HGet(r.ctx, transferLimitOverrideKey(userID), field).Int()
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// TransferDailyCap возвращает общий дневной лимит переводов (0 — без лимита).
+func (r *Ranking) TransferDailyCap() int {
+	return r.GetIntSetting("transfer_daily_cap", envInt("TRANSFER_DAILY_CAP", 0))
+}
+
+// TransferCooldown возвращает общую паузу между переводами в секундах (0 — без паузы).
+func (r *Ranking) TransferCooldown() int {
+	return r.GetIntSetting("transfer_cooldown", envInt("TRANSFER_COOLDOWN_SECONDS", 0))
+}
+
+// checkTransferLimits проверяет лимиты отправителя, ничего не резервируя.
+func (r *Ranking) checkTransferLimits(userID string, amount int) error {
+	if r.transferLimitFor(userID, "cooldown", r.TransferCooldown()) > 0 {
+		if left, err := r.redis.PTTL(r.ctx, transferCooldownKey(userID)).Result(); err == nil && left > 0 {
+			return &TransferLimitError{CooldownLeft: left}
+		}
+	}
+	if limit := r.transferLimitFor(userID, "cap", r.TransferDailyCap()); limit > 0 {
+		spent, _ := r.redis.Get(r.ctx, transferDailyKey(userID, time.Now())).Int()
+		if spent+amount > limit {
+			return &TransferLimitError{Cap: limit, Remaining: max(limit-spent, 0)}
+		}
+	}
+	return nil
+}
+
+// reserveTransferLimits учитывает перевод в лимитах отправителя. Если перевод не помещается,
+// ничего не учитывается и возвращается *TransferLimitError. Вызывающий должен вызвать
+// releaseTransferLimits, если перевод в итоге не состоялся.
+func (r *Ranking) reserveTransferLimits(userID string, amount int) error {
+	if cooldown := r.transferLimitFor(userID, "cooldown", r.TransferCooldown()); cooldown > 0 {
+		ok, err := r.redis.SetNX(r.ctx, transferCooldownKey(userID), time.Now().Unix(), time.Duration(cooldown)*time.Second).Result()
+		if err != nil {
+			log.Printf("Не удалось проверить паузу переводов %s: %v", userID, err)
+		} else if !ok {
+			left, _ := r.redis.PTTL(r.ctx, transferCooldownKey(userID)).Result()
+			return &TransferLimitError{CooldownLeft: max(left, time.Second)}
+		}
+	}
+
+	limit := r.transferLimitFor(userID, "cap", r.TransferDailyCap())
+	if limit <= 0 {
+		return nil
+	}
+	key := transferDailyKey(userID, time.Now())
+	total, err := r.redis.IncrBy(r.ctx, key, int64(amount)).Result()
+	if err != nil {
+		log.Printf("Не удалось учесть перевод %d пользователя %s в дневном лимите: %v", amount, userID, err)
+		return nil
+	}
+	r.redis.Expire(r.ctx, key, 24*time.Hour)
+	if int(total) > limit {
+		r.redis.DecrBy(r.ctx, key, int64(amount))
+		r.redis.Del(r.ctx, transferCooldownKey(userID))
+		log.Printf("Перевод %d от %s отклонён: дневной лимит %d, уже переведено %d", amount, userID, limit, int(total)-amount)
+		return &TransferLimitError{Cap: limit, Remaining: max(limit-(int(total)-amount), 0)}
+	}
+	return nil
+}
+
+// releaseTransferLimits откатывает учёт несостоявшегося перевода.
+func (r *Ranking) releaseTransferLimits(userID string, amount int) {
+	r.redis.Del(r.ctx, transferCooldownKey(userID))
+	key := transferDailyKey(userID, time.Now())
+	if left, err := r.redis.DecrBy(r.ctx, key, int64(amount)).Result(); err == nil && left <= 0 {
+		r.redis.Del(r.ctx, key)
+	}
+}
+
+// transferLimitsSummary описывает лимиты игрока для /a_transfer.
+func (r *Ranking) transferLimitsSummary(userID string) string {
+	describe := func(field string, def int, format func(int) string) string {
+		value := r.transferLimitFor(userID, field, def)
+		text := "нет"
+		if value > 0 {
+			text = format(value)
+		}
+		if _, err := r.redis.HGet(r.ctx, transferLimitOverrideKey(userID), field).Result(); err != redis.Nil {
+			text += " (индивидуально)"
+		}
+		return text
+	}
+	spent, _ := r.redis.Get(r.ctx, transferDailyKey(userID, time.Now())).Int()
+	return fmt.Sprintf("<@%s>\n💰 Дневной лимит: %s\n⏳ Пауза: %s\n📤 Переведено сегодня: %s",
+		userID,
+		describe("cap", r.TransferDailyCap(), formatCredits),
+		describe("cooldown", r.TransferCooldown(), formatTime),
+		formatCredits(spent))
+}
+
+// setTransferLimitOverride задаёт игроку индивидуальный лимит или сбрасывает его (value < 0).
+func (r *Ranking) setTransferLimitOverride(userID, field string, value int) error {
+	if value < 0 {
+		return r.redis.HDel(r.ctx, transferLimitOverrideKey(userID), field).Err()
+	}
+	return r.redis.HSet(r.ctx, transferLimitOverrideKey(userID), field, strconv.Itoa(value)).Err()
+}
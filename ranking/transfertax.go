@@ -0,0 +1,130 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// transferTaxPotKey — общий фонд, куда поступает налог на переводы в режиме «фонд».
+const transferTaxPotKey = "transfer_tax:pot"
+
+// TransferTaxPercent возвращает процент налога на переводы (0 — без налога).
+func (r *Ranking) TransferTaxPercent() int {
+	return r.GetIntSetting("transfer_tax_percent", envInt("TRANSFER_TAX_PERCENT", 0))
+}
+
+// TransferTaxToPot сообщает, поступает ли налог в общий фонд (иначе он сжигается).
+func (r *Ranking) TransferTaxToPot() bool {
+	return r.GetIntSetting("transfer_tax_pot", envInt("TRANSFER_TAX_POT", 0)) != 0
+}
+
+// transferTax считает налог с перевода суммы amount.
+func (r *Ranking) transferTax(amount int) int {
+	percent := min(max(r.TransferTaxPercent(), 0), 100)
+	return amount * percent / 100
+}
+
+// transferTaxDestination возвращает описание того, куда уходит налог.
+func (r *Ranking) transferTaxDestination() string {
+	if r.TransferTaxToPot() {
+		return "в общий фонд"
+	}
+	return "сожжён"
+}
+
+// collectTransferTax зачисляет налог в общий фонд, если он не сжигается.
+func (r *Ranking) collectTransferTax(tax int) {
+	if !r.TransferTaxToPot() {
+		return
+	}
+	if err := r.redis.IncrBy(r.ctx, transferTaxPotKey, int64(tax)).Err(); err != nil {
+		log.Printf("Не удалось зачислить налог %d в общий фонд: %v", tax, err)
+	}
+}
+
+// transferTaxPot возвращает сумму в общем фонде.
+func (r *Ranking) transferTaxPot() int {
+	pot, _ := r.redis.Get(r.ctx, transferTaxPotKey).Int()
+	return pot
+}
+
+// HandleTransferTaxCommand обрабатывает команду !a_tax [rate <процент> | mode burn|pot | payout @user].
+func (r *Ranking) HandleTransferTaxCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_tax: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать налог! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_tax [rate <процент> | mode burn|pot | payout @user]`"
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		embed := &discordgo.MessageEmbed{
+			Title: "🏛️ Налог на переводы",
+			Color: randomColor(),
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "📈 Ставка", Value: fmt.Sprintf("%d%%", r.TransferTaxPercent()), Inline: true},
+				{Name: "🔥 Куда уходит", Value: r.transferTaxDestination(), Inline: true},
+				{Name: "🏦 Общий фонд", Value: formatCredits(r.transferTaxPot()), Inline: true},
+			},
+			Footer: &discordgo.MessageEmbedFooter{Text: "/a_tax rate <процент> | mode burn|pot | payout @user"},
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, embed)
+		return
+	}
+	if len(parts) != 3 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	switch parts[1] {
+	case "rate":
+		value, err := strconv.Atoi(parts[2])
+		if err != nil || value < 0 || value > 100 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Ставка налога — число от 0 до 100!")
+			return
+		}
+		if err := r.SetIntSetting("transfer_tax_percent", value); err != nil {
+			log.Printf("Не удалось сохранить настройку transfer_tax_percent: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Налог на переводы: %d%%", value))
+	case "mode":
+		toPot := map[string]int{"burn": 0, "pot": 1}
+		value, ok := toPot[parts[2]]
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		if err := r.SetIntSetting("transfer_tax_pot", value); err != nil {
+			log.Printf("Не удалось сохранить настройку transfer_tax_pot: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Налог теперь %s", r.transferTaxDestination()))
+	case "payout":
+		if len(m.Mentions) != 1 {
+			s.ChannelMessageSend(m.ChannelID, usage)
+			return
+		}
+		targetID := m.Mentions[0].ID
+		pot, err := r.redis.GetDel(r.ctx, transferTaxPotKey).Int()
+		if err != nil || pot <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ Общий фонд пуст.")
+			return
+		}
+		r.mu.Lock()
+		r.UpdateRatingFrom(targetID, pot, "tax_pot", m.Author.ID)
+		r.mu.Unlock()
+		r.LogCreditOperation(s, fmt.Sprintf("🏛️ <@%s> выплатил общий фонд %s игроку <@%s>", m.Author.ID, formatCredits(pot), targetID))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Общий фонд %s выплачен <@%s>!", formatCredits(pot), targetID))
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
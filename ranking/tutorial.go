@@ -0,0 +1,240 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Обучение новичков: пошаговый разбор экономики с кнопкой проверки. Каждый выполненный шаг
+// приносит награду, перед шагом с кейсом игрок получает свой первый кейс. Состояние хранится
+// в хэше tutorial:<userID> (step, step_at, case_id, case_count, done_at).
+
+// tutorialStep — шаг обучения.
+type tutorialStep struct {
+	Title string
+	Text  string
+}
+
+// tutorialSteps — шаги обучения по порядку.
+var tutorialSteps = []tutorialStep{
+	{Title: "💰 Баланс", Text: "Соцкредиты — валюта Императора. Свой баланс можно посмотреть командой `/china`. Нажми кнопку — покажу его прямо здесь."},
+	{Title: "📅 Ежедневная награда", Text: "Каждый день забирай награду командой `/daily`: чем длиннее серия, тем больше кредитов. Забери её и нажми «Проверить»."},
+	{Title: "📦 Первый кейс", Text: "Император дарит тебе кейс %s! Открой его командой `/open_case %s` и нажми «Проверить»."},
+	{Title: "🏷️ Продажа NFT", Text: "Лишние NFT можно продать: `/sell <ID> <кол-во>` или все дубликаты сразу `/sell_duplicates`. Посмотри ID в `/inventory`, продай что-нибудь и нажми «Проверить»."},
+}
+
+// tutorialKey возвращает ключ состояния обучения пользователя.
+func tutorialKey(userID string) string {
+	return "tutorial:" + userID
+}
+
+// TutorialStepReward возвращает награду за шаг обучения.
+func (r *Ranking) TutorialStepReward() int {
+	return r.GetIntSetting("tutorial_step_reward", envInt("TUTORIAL_STEP_REWARD", 10))
+}
+
+// tutorialCase возвращает кейс, который дарится на шаге с кейсом: TUTORIAL_CASE_ID или кейс пакета возвращения.
+func (r *Ranking) tutorialCase() (Case, bool) {
+	if id := os.Getenv("TUTORIAL_CASE_ID"); id != "" && r.Kki != nil {
+		if kase, ok := r.Kki.cases[id]; ok {
+			return kase, true
+		}
+		log.Printf("TUTORIAL_CASE_ID=%s не найден среди кейсов", id)
+	}
+	return r.comebackCase()
+}
+
+// tutorialMessage формирует эмбед и кнопку текущего шага.
+func (r *Ranking) tutorialMessage(userID string, state map[string]string, note string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	step, _ := strconv.Atoi(state["step"])
+	lines := make([]string, 0, len(tutorialSteps))
+	for n, st := range tutorialSteps {
+		mark := "⬜"
+		switch {
+		case n < step:
+			mark = "✅"
+		case n == step:
+			mark = "▶️"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", mark, st.Title))
+	}
+	description := strings.Join(lines, "\n")
+	if step < len(tutorialSteps) {
+		text := tutorialSteps[step].Text
+		if step == 2 {
+			name := state["case_id"]
+			if kase, ok := r.Kki.cases[state["case_id"]]; ok {
+				name = kase.Name
+			}
+			text = fmt.Sprintf(text, "**"+name+"**", state["case_id"])
+		}
+		description += "\n\n**" + tutorialSteps[step].Title + "**\n" + text
+	} else {
+		description += "\n\n🎉 Обучение пройдено! Дальше — `/chelp`, игры и рынок `/market`. Славь Императора! 🇨🇳"
+	}
+	if note != "" {
+		description += "\n\n" + note
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎓 Обучение",
+		Description: description,
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Награда за шаг: %s", formatCredits(r.TutorialStepReward()))},
+	}
+	components := []discordgo.MessageComponent{}
+	if step < len(tutorialSteps) {
+		label := "✅ Проверить"
+		if step == 0 {
+			label = "💰 Мой баланс"
+		}
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: label, Style: discordgo.PrimaryButton, CustomID: "tutorial_check_" + userID},
+			}},
+		}
+	}
+	return embed, components
+}
+
+// HandleTutorialCommand обрабатывает команду !tutorial.
+func (r *Ranking) HandleTutorialCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	log.Printf("Обработка !tutorial от %s", m.Author.ID)
+
+	state, err := r.redis.HGetAll(r.ctx, tutorialKey(m.Author.ID)).Result()
+	if err != nil {
+		log.Printf("Не удалось получить обучение %s: %v", m.Author.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка! Попробуйте позже.")
+		return
+	}
+	if state["done_at"] != "" {
+		s.ChannelMessageSend(m.ChannelID, "🎓 Ты уже прошёл обучение! Все команды: `/chelp`")
+		return
+	}
+	if len(state) == 0 {
+		state = map[string]string{"step": "0", "step_at": strconv.FormatInt(time.Now().Unix(), 10)}
+		r.redis.HSet(r.ctx, tutorialKey(m.Author.ID), state)
+	}
+	embed, components := r.tutorialMessage(m.Author.ID, state, "")
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: embed, Components: components}); err != nil {
+		log.Printf("Не удалось отправить обучение: %v", err)
+	}
+}
+
+// tutorialStepDone проверяет, выполнен ли текущий шаг. Возвращает подсказку, если нет.
+func (r *Ranking) tutorialStepDone(userID string, state map[string]string) (bool, string) {
+	step, _ := strconv.Atoi(state["step"])
+	stepAt, _ := strconv.ParseInt(state["step_at"], 10, 64)
+	switch step {
+	case 0:
+		return true, fmt.Sprintf("💰 Твой баланс: **%s**", formatCredits(r.GetRating(userID)))
+	case 1:
+		last, _ := r.redis.HGet(r.ctx, dailyKey(userID), "last").Result()
+		if last != time.Now().Format("2006-01-02") {
+			return false, "⏳ Награда ещё не получена — набери `/daily`."
+		}
+		return true, ""
+	case 2:
+		before, _ := strconv.Atoi(state["case_count"])
+		if r.Kki.GetUserCaseInventory(r, userID)[state["case_id"]] >= before {
+			return false, fmt.Sprintf("⏳ Кейс ещё не открыт — набери `/open_case %s`.", state["case_id"])
+		}
+		return true, ""
+	case 3:
+		entries, err := r.creditLedger(userID, creditLedgerLimit)
+		if err == nil {
+			for _, entry := range entries {
+				if entry.Source == "nft_sale" && entry.At.Unix() >= stepAt {
+					return true, ""
+				}
+			}
+		}
+		return false, "⏳ Продажи пока не видно — продай NFT через `/sell` или `/sell_duplicates`."
+	}
+	return false, ""
+}
+
+// advanceTutorial засчитывает шаг, выдаёт награду и готовит следующий шаг. Вызывается под r.mu.
+func (r *Ranking) advanceTutorial(userID string, state map[string]string) {
+	step, _ := strconv.Atoi(state["step"])
+	if reward := r.TutorialStepReward(); reward > 0 {
+		r.UpdateRatingFrom(userID, reward, "tutorial", "")
+	}
+	step++
+	state["step"] = strconv.Itoa(step)
+	state["step_at"] = strconv.FormatInt(time.Now().Unix(), 10)
+	if step == 2 {
+		if kase, ok := r.tutorialCase(); ok {
+			inv := r.Kki.GetUserCaseInventory(r, userID)
+			inv[kase.ID]++
+			r.Kki.SaveUserCaseInventory(r, userID, inv)
+			state["case_id"] = kase.ID
+			state["case_count"] = strconv.Itoa(inv[kase.ID])
+		} else {
+			// Кейсов нет — шаг с кейсом пропускается
+			step++
+			state["step"] = strconv.Itoa(step)
+		}
+	}
+	if step >= len(tutorialSteps) {
+		state["done_at"] = state["step_at"]
+	}
+	if err := r.redis.HSet(r.ctx, tutorialKey(userID), state).Err(); err != nil {
+		log.Printf("Не удалось сохранить обучение %s: %v", userID, err)
+	}
+}
+
+// HandleTutorialCheck обрабатывает кнопку проверки шага обучения.
+func (r *Ranking) HandleTutorialCheck(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := strings.TrimPrefix(i.MessageComponentData().CustomID, "tutorial_check_")
+	if userID != i.Member.User.ID {
+		respondEphemeral(s, i, "❌ Это чужое обучение! Начни своё: `/tutorial`")
+		return
+	}
+
+	state, step, note, done := r.completeTutorialStep(userID)
+	if !done {
+		respondEphemeral(s, i, note)
+		return
+	}
+
+	log.Printf("Обучение %s: шаг %d пройден", userID, step+1)
+	if state["done_at"] != "" {
+		r.LogCreditOperation(s, fmt.Sprintf("🎓 <@%s> прошёл обучение", userID))
+	}
+	if note != "" {
+		note += "\n"
+	}
+	note += fmt.Sprintf("✅ Шаг пройден! +%s", formatCredits(r.TutorialStepReward()))
+	embed, components := r.tutorialMessage(userID, state, note)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// completeTutorialStep засчитывает текущий шаг обучения, если он выполнен, и возвращает новое
+// состояние, номер пройденного шага и заметку к нему. Если шаг не засчитан, note — текст для игрока.
+func (r *Ranking) completeTutorialStep(userID string) (state map[string]string, step int, note string, done bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, err := r.redis.HGetAll(r.ctx, tutorialKey(userID)).Result()
+	if err != nil || len(state) == 0 || state["done_at"] != "" {
+		return nil, 0, "ℹ️ Обучение уже пройдено или не начато: `/tutorial`", false
+	}
+	if done, note = r.tutorialStepDone(userID, state); !done {
+		return nil, 0, note, false
+	}
+	step, _ = strconv.Atoi(state["step"])
+	r.advanceTutorial(userID, state)
+	return state, step, note, true
+}
@@ -0,0 +1,194 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Еженедельный базовый доход: раз в неделю каждый участник сервера, проявивший активность
+// (команды или войс) вне исключённых каналов, получает фиксированную сумму. Участники
+// с исключёнными ролями выплату не получают.
+const (
+	ubiActiveKey           = "ubi:active"            // SET участников, активных на этой неделе
+	ubiExcludedChannelsKey = "ubi:excluded_channels" // SET каналов, активность в которых не учитывается
+	ubiExcludedRolesKey    = "ubi:excluded_roles"    // SET ролей, которым выплата не положена
+)
+
+// UBIAmount возвращает сумму еженедельной выплаты (0 — выплаты отключены, так по умолчанию;
+// включается через `/a_ubi amount`).
+func (r *Ranking) UBIAmount() int {
+	return r.GetIntSetting("ubi_amount", envInt("UBI_AMOUNT", 0))
+}
+
+// MarkUBIActivity отмечает активность пользователя в канале для еженедельной выплаты.
+func (r *Ranking) MarkUBIActivity(userID, channelID string) {
+	if excluded, _ := r.redis.SIsMember(r.ctx, ubiExcludedChannelsKey, channelID).Result(); excluded {
+		return
+	}
+	if err := r.redis.SAdd(r.ctx, ubiActiveKey, userID).Err(); err != nil {
+		log.Printf("Не удалось отметить активность %s для базового дохода: %v", userID, err)
+	}
+}
+
+// ubiMember ищет участника на серверах бота (во всех шардах процесса); ok=false, если пользователь ни на одном из них не состоит.
+func (r *Ranking) ubiMember(userID string) (*discordgo.Member, bool) {
+	for guildID, s := range r.stateGuilds() {
+		if member, err := s.State.Member(guildID, userID); err == nil {
+			return member, true
+		}
+		if member, err := s.GuildMember(guildID, userID); err == nil {
+			return member, true
+		}
+	}
+	return nil, false
+}
+
+// payWeeklyUBI выплачивает базовый доход активным за неделю участникам и публикует итог в лог-канал.
+func (r *Ranking) payWeeklyUBI() error {
+	amount := r.UBIAmount()
+	if amount <= 0 {
+		return nil
+	}
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	// Забираем список целиком, чтобы активность во время выплаты попала уже в следующую неделю
+	active, err := r.redis.SPopN(r.ctx, ubiActiveKey, 1<<20).Result()
+	if err != nil {
+		return err
+	}
+	excludedRoles, err := r.redis.SMembers(r.ctx, ubiExcludedRolesKey).Result()
+	if err != nil {
+		return err
+	}
+	excluded := make(map[string]bool, len(excludedRoles))
+	for _, roleID := range excludedRoles {
+		excluded[roleID] = true
+	}
+
+	paid, skipped := 0, 0
+	for _, userID := range active {
+		member, ok := r.ubiMember(userID)
+		if !ok || member.User == nil || member.User.Bot {
+			skipped++
+			continue
+		}
+		optedOut := false
+		for _, roleID := range member.Roles {
+			optedOut = optedOut || excluded[roleID]
+		}
+		if optedOut {
+			skipped++
+			continue
+		}
+		r.mu.Lock()
+		r.UpdateRatingFrom(userID, amount, "ubi", "")
+		r.mu.Unlock()
+		paid++
+	}
+
+	log.Printf("Базовый доход выплачен: %d участникам по %d, пропущено %d", paid, amount, skipped)
+	r.LogCreditOperation(s, fmt.Sprintf("🏛️ Еженедельный базовый доход: %d участников получили по %s (всего %s), пропущено %d.",
+		paid, formatCredits(amount), formatCredits(paid*amount), skipped))
+	return nil
+}
+
+// parseUBITarget разбирает упоминание канала (<#id>) или роли (<@&id>).
+func parseUBITarget(token string) (key, id string, ok bool) {
+	switch {
+	case strings.HasPrefix(token, "<#") && strings.HasSuffix(token, ">"):
+		return ubiExcludedChannelsKey, strings.TrimSuffix(strings.TrimPrefix(token, "<#"), ">"), true
+	case strings.HasPrefix(token, "<@&") && strings.HasSuffix(token, ">"):
+		return ubiExcludedRolesKey, strings.TrimSuffix(strings.TrimPrefix(token, "<@&"), ">"), true
+	}
+	return "", "", false
+}
+
+// HandleUBICommand обрабатывает команду !a_ubi [amount <сумма> | exclude|include #канал|@роль].
+func (r *Ranking) HandleUBICommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_ubi: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут настраивать базовый доход! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_ubi [amount <сумма> | exclude #канал|@роль | include #канал|@роль]`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 {
+		switch parts[1] {
+		case "amount":
+			value, err := strconv.Atoi(parts[2])
+			if err != nil || value < 0 {
+				s.ChannelMessageSend(m.ChannelID, usage)
+				return
+			}
+			if err := r.SetIntSetting("ubi_amount", value); err != nil {
+				log.Printf("Не удалось сохранить настройку ubi_amount: %v", err)
+				s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+				return
+			}
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Еженедельный базовый доход: %s", formatCredits(value)))
+		case "exclude", "include":
+			key, id, ok := parseUBITarget(parts[2])
+			if !ok {
+				s.ChannelMessageSend(m.ChannelID, usage)
+				return
+			}
+			var err error
+			if parts[1] == "exclude" {
+				err = r.redis.SAdd(r.ctx, key, id).Err()
+			} else {
+				err = r.redis.SRem(r.ctx, key, id).Err()
+			}
+			if err != nil {
+				log.Printf("Не удалось изменить исключения базового дохода: %v", err)
+				s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения настройки! Проверьте Redis-сервер.")
+				return
+			}
+			verb := "исключён из выплат"
+			if parts[1] == "include" {
+				verb = "снова участвует в выплатах"
+			}
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ %s %s", parts[2], verb))
+		default:
+			s.ChannelMessageSend(m.ChannelID, usage)
+		}
+		return
+	}
+	if len(parts) != 1 {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+
+	channels, _ := r.redis.SMembers(r.ctx, ubiExcludedChannelsKey).Result()
+	roles, _ := r.redis.SMembers(r.ctx, ubiExcludedRolesKey).Result()
+	active, _ := r.redis.SCard(r.ctx, ubiActiveKey).Result()
+	mentions := func(ids []string, format string) string {
+		if len(ids) == 0 {
+			return "—"
+		}
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = fmt.Sprintf(format, id)
+		}
+		return truncate(strings.Join(out, ", "), 1024)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "🏛️ Еженедельный базовый доход",
+		Color: randomColor(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💰 Выплата", Value: formatCredits(r.UBIAmount()), Inline: true},
+			{Name: "👥 Активны на этой неделе", Value: strconv.FormatInt(active, 10), Inline: true},
+			{Name: "🚫 Исключённые каналы", Value: mentions(channels, "<#%s>"), Inline: false},
+			{Name: "🚫 Исключённые роли", Value: mentions(roles, "<@&%s>"), Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Выплата по понедельникам · /a_ubi amount|exclude|include"},
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embed)
+}
@@ -1,43 +1,50 @@
 package ranking
 
 import (
-	"encoding/json"
 	"log"
-	"sort"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
 )
 
-// GetTop5 возвращает топ-5 пользователей по рейтингу.
-func (r *Ranking) GetTop5() []User {
-	keys, err := r.redis.Keys(r.ctx, "user:*").Result()
+// topPageSize — сколько игроков по умолчанию показывает одна страница /top.
+const topPageSize = 10
+
+// GetTop возвращает игроков с положительным балансом с места offset+1 (не больше limit)
+// и общее число таких игроков. Рейтинг берётся из ZSET economy:balances, который
+// обновляется при каждом изменении баланса.
+func (r *Ranking) GetTop(offset, limit int) ([]User, int, error) {
+	positive := &redis.ZRangeBy{Min: "(0", Max: "+inf", Offset: int64(offset), Count: int64(limit)}
+	entries, err := r.redis.ZRevRangeByScoreWithScores(r.ctx, economyBalancesKey, positive).Result()
 	if err != nil {
-		log.Printf("Не удалось получить ключи пользователей из Redis: %v", err)
-		return nil
+		return nil, 0, err
 	}
-
-	users := make([]User, 0)
-	for _, key := range keys {
-		data, err := r.redis.Get(r.ctx, key).Result()
-		if err != nil {
-			log.Printf("Не удалось загрузить данные пользователя %s из Redis: %v", key, err)
-			continue
-		}
-		var user User
-		if err := json.Unmarshal([]byte(data), &user); err != nil {
-			log.Printf("Не удалось разобрать данные пользователя %s: %v", key, err)
-			continue
-		}
-		if user.Rating > 0 {
-			users = append(users, user)
-		}
+	total, err := r.redis.ZCount(r.ctx, economyBalancesKey, "(0", "+inf").Result()
+	if err != nil {
+		return nil, 0, err
 	}
 
-	sort.Slice(users, func(i, j int) bool {
-		return users[i].Rating > users[j].Rating
-	})
+	users := make([]User, 0, len(entries))
+	for _, entry := range entries {
+		userID, _ := entry.Member.(string)
+		users = append(users, User{ID: userID, Rating: int(entry.Score)})
+	}
+	return users, int(total), nil
+}
 
-	if len(users) > 5 {
-		return users[:5]
+// GetTop5 возвращает топ-5 пользователей по рейтингу.
+func (r *Ranking) GetTop5() []User {
+	users, _, err := r.GetTop(0, 5)
+	if err != nil {
+		log.Printf("Не удалось получить топ пользователей из Redis: %v", err)
+		return nil
 	}
 	log.Printf("Топ-5 пользователей: %v", users)
 	return users
 }
+
+// parseTopPage разбирает номер страницы из команды !top <страница>.
+func parseTopPage(arg string) (int, bool) {
+	page, err := strconv.Atoi(arg)
+	return page, err == nil && page > 0
+}
@@ -0,0 +1,110 @@
+package ranking
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// userCache — короткоживущий кэш записей user:<id> в памяти. Чтения (баланс на экране, проверки
+// ставок, таблицы лидеров) берут запись из кэша, все записи бота обновляют его сразу после
+// сохранения в Redis (write-through). Кэш свой у каждого процесса и согласован только с записями
+// этого процесса: бот рассчитан на один процесс со всеми шардами (см. shards.go). Запись другого
+// процесса станет видна здесь лишь через ttl, и проверки баланса до этого идут по старому значению.
+type userCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]userCacheEntry
+
+	hits, misses atomic.Int64
+}
+
+// userCacheEntry — закэшированная запись; exists=false означает, что пользователя нет в Redis.
+type userCacheEntry struct {
+	user    User
+	exists  bool
+	expires time.Time
+}
+
+// newUserCache создаёт кэш. ttl <= 0 отключает кэширование.
+func newUserCache(ttl time.Duration) *userCache {
+	return &userCache{ttl: ttl, entries: make(map[string]userCacheEntry)}
+}
+
+// get возвращает запись из кэша; hit=false, если записи нет или она устарела.
+func (c *userCache) get(userID string) (user User, exists, hit bool) {
+	if c == nil || c.ttl <= 0 {
+		return user, false, false
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, userID)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		c.misses.Add(1)
+		return user, false, false
+	}
+	c.hits.Add(1)
+	return entry.user, entry.exists, true
+}
+
+// put сохраняет запись пользователя после чтения или записи в Redis.
+func (c *userCache) put(userID string, user User, exists bool) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.entries[userID] = userCacheEntry{user: user, exists: exists, expires: time.Now().Add(c.ttl)}
+	// Устаревшие записи вычищаются, когда кэш разрастается
+	if len(c.entries) > 10000 {
+		now := time.Now()
+		for id, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.mu.Unlock()
+}
+
+// invalidate удаляет запись пользователя, изменённую в обход write-through (очистка, восстановление).
+func (c *userCache) invalidate(userID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// purge очищает кэш целиком (массовые изменения: новый сезон, миграции).
+func (c *userCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = make(map[string]userCacheEntry)
+	c.mu.Unlock()
+}
+
+// stats возвращает число попаданий и промахов с запуска.
+func (c *userCache) stats() (hits, misses int64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// userCacheSummary возвращает долю попаданий в кэш игроков для отчётов.
+func (r *Ranking) userCacheSummary() string {
+	hits, misses := r.users.stats()
+	if hits+misses == 0 {
+		return "нет обращений"
+	}
+	return fmt.Sprintf("%d%% попаданий из %d", hits*100/(hits+misses), hits+misses)
+}
@@ -20,22 +20,40 @@ func (r *Ranking) TrackVoiceActivity(s *discordgo.Session, vs *discordgo.VoiceSt
 			log.Printf("Пользователь %s покинул голосовой канал, сохранено %d секунд", userID, seconds)
 		}
 		delete(r.voiceAct, userID)
+		delete(r.voiceChannels, userID)
+		delete(r.voiceIdle, userID)
+		delete(r.voiceStreamers, userID)
+		delete(r.voiceTrackers, userID)
 		r.mu.Unlock()
 		log.Printf("Пользователь %s покинул голосовой канал, голосовая активность сброшена", userID)
 		return
 	}
 
+	r.TouchActivity(s, userID)
+	r.MarkUBIActivity(userID, channelID)
+
 	r.mu.Lock()
+	r.voiceChannels[userID] = channelID
+	r.updateVoiceIdle(s, vs)
+	if vs.SelfStream || vs.SelfVideo {
+		r.voiceStreamers[userID] = true
+	} else {
+		delete(r.voiceStreamers, userID)
+	}
 	if _, exists := r.voiceAct[userID]; !exists {
 		r.voiceAct[userID] = 0
-		go r.startVoiceTracking(s, userID)
+		r.voiceGeneration++
+		r.voiceTrackers[userID] = r.voiceGeneration
+		go r.startVoiceTracking(s, userID, r.voiceGeneration)
 		log.Printf("Начато отслеживание голосовой активности для %s", userID)
 	}
 	r.mu.Unlock()
 }
 
-// startVoiceTracking запускает цикл отслеживания голосовой активности.
-func (r *Ranking) startVoiceTracking(s *discordgo.Session, userID string) {
+// startVoiceTracking запускает цикл отслеживания голосовой активности. Цикл завершается, когда
+// для пользователя запущен цикл нового поколения (перезаход, сброс после переподключения),
+// чтобы за одну минуту в голосе не начислялось дважды.
+func (r *Ranking) startVoiceTracking(s *discordgo.Session, userID string, generation uint64) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
@@ -43,12 +61,24 @@ func (r *Ranking) startVoiceTracking(s *discordgo.Session, userID string) {
 		select {
 		case <-ticker.C:
 			r.mu.Lock()
+			if r.voiceTrackers[userID] != generation {
+				r.mu.Unlock()
+				log.Printf("Остановлено отслеживание для %s: запущено новое", userID)
+				return
+			}
 			if seconds, exists := r.voiceAct[userID]; exists {
 				r.voiceAct[userID] = seconds + 1
 				r.UpdateVoiceSeconds(userID, 1) // Обновляем VoiceSeconds в Redis
-				if r.voiceAct[userID]%60 == 0 { // Начисляем 1 поинт каждые 60 секунд
-					r.UpdateRating(userID, 1)
-					log.Printf("Начислен 1 соцкредит пользователю %s за %d секунд голосовой активности", userID, r.voiceAct[userID])
+				if r.voiceAct[userID]%60 == 0 { // Начисляем 1 поинт каждые 60 секунд (в зоне ивента — с множителем, AFK и при инфляции — меньше)
+					points := r.voiceCreditMultiplier(r.voiceChannels[userID])
+					percent := r.voiceAFKPercent(userID) * r.inflationPercent() / 100
+					points = scaleVoicePoints(points, percent, r.voiceAct[userID]/60)
+					if points > 0 {
+						r.UpdateRatingFrom(userID, points, "voice", "")
+						r.recordVoiceMinted(points)
+					}
+					log.Printf("Начислено %d соцкредитов пользователю %s за %d секунд голосовой активности", points, userID, r.voiceAct[userID])
+					r.creditStreamBonus(userID, r.voiceAct[userID]/60)
 				}
 				//log.Printf("Обновлено время для %s: %d секунд", userID, r.voiceAct[userID])
 			} else {
@@ -60,3 +90,30 @@ func (r *Ranking) startVoiceTracking(s *discordgo.Session, userID string) {
 		}
 	}
 }
+
+// ResetVoiceTracking останавливает отслеживание голосовой активности всех пользователей.
+// Используется после переподключения к Discord, когда пропущенные события могли оставить устаревшие записи;
+// отслеживание возобновляется по актуальным голосовым состояниям гильдий.
+func (r *Ranking) ResetVoiceTracking() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := len(r.voiceAct)
+	r.voiceAct = make(map[string]int)
+	r.voiceChannels = make(map[string]string)
+	r.voiceIdle = make(map[string]voiceIdle)
+	r.voiceStreamers = make(map[string]bool)
+	r.voiceTrackers = make(map[string]uint64) // старые циклы увидят чужое поколение и завершатся
+	log.Printf("Сброшено отслеживание голосовой активности для %d пользователей", count)
+}
+
+// RefreshAfterReconnect обновляет кэши, которые могли устареть за время разрыва соединения с Discord.
+func (r *Ranking) RefreshAfterReconnect() {
+	r.ResetVoiceTracking()
+	r.refreshCaseBank()
+
+	r.mu.Lock()
+	if err := r.LoadCinemaOptions(); err != nil {
+		log.Printf("Не удалось перезагрузить список фильмов: %v", err)
+	}
+	r.mu.Unlock()
+}
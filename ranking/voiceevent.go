@@ -0,0 +1,221 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ивенты в войсе: админ объявляет голосовой канал зоной ивента на время, в течение которого
+// кредиты за войс в нём начисляются с множителем, а embed-счётчик показывает общие минуты.
+const voiceEventsKey = "voice_event:active" // SET каналов с идущим ивентом
+
+// VoiceEvent — ивент в голосовом канале (хэш voice_event:<channelID>).
+type VoiceEvent struct {
+	ChannelID  string
+	Multiplier int
+	EndsAt     time.Time
+	StartedBy  string
+	Minutes    int
+	MsgChannel string
+	MsgID      string
+}
+
+// voiceEventKey возвращает ключ ивента голосового канала.
+func voiceEventKey(channelID string) string {
+	return "voice_event:" + channelID
+}
+
+// loadVoiceEvent читает ивент канала; ok=false, если ивента нет.
+func (r *Ranking) loadVoiceEvent(channelID string) (VoiceEvent, bool) {
+	event := VoiceEvent{ChannelID: channelID}
+	fields, err := r.redis.HGetAll(r.ctx, voiceEventKey(channelID)).Result()
+	if err != nil || len(fields) == 0 {
+		return event, false
+	}
+	event.Multiplier, _ = strconv.Atoi(fields["multiplier"])
+	event.Minutes, _ = strconv.Atoi(fields["minutes"])
+	endsAt, _ := strconv.ParseInt(fields["ends_at"], 10, 64)
+	event.EndsAt = time.Unix(endsAt, 0)
+	event.StartedBy = fields["started_by"]
+	event.MsgChannel = fields["msg_channel"]
+	event.MsgID = fields["msg_id"]
+	return event, true
+}
+
+// voiceCreditMultiplier возвращает множитель кредитов за войс в канале и учитывает минуту в счётчике ивента.
+func (r *Ranking) voiceCreditMultiplier(channelID string) int {
+	if channelID == "" {
+		return 1
+	}
+	event, ok := r.loadVoiceEvent(channelID)
+	if !ok || time.Now().After(event.EndsAt) || event.Multiplier < 1 {
+		return 1
+	}
+	r.redis.HIncrBy(r.ctx, voiceEventKey(channelID), "minutes", 1)
+	return event.Multiplier
+}
+
+// voiceEventEmbed формирует embed-счётчик ивента.
+func voiceEventEmbed(event VoiceEvent, listeners int) *discordgo.MessageEmbed {
+	finished := time.Now().After(event.EndsAt)
+	title := "🎉 Ивент в войсе идёт!"
+	status := fmt.Sprintf("Заканчивается <t:%d:R>", event.EndsAt.Unix())
+	color := 0x57F287
+	if finished {
+		title = "🏁 Ивент в войсе завершён"
+		status = fmt.Sprintf("Завершился <t:%d:f>", event.EndsAt.Unix())
+		color = 0x99AAB5
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "🎙️ Канал", Value: fmt.Sprintf("<#%s>", event.ChannelID), Inline: true},
+		{Name: "✖️ Множитель", Value: fmt.Sprintf("x%d", event.Multiplier), Inline: true},
+		{Name: "⏱️ Общие минуты", Value: strconv.Itoa(event.Minutes), Inline: true},
+	}
+	if !finished {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "👥 Сейчас в канале", Value: strconv.Itoa(listeners), Inline: true})
+	}
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: status,
+		Color:       color,
+		Fields:      fields,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Заходи в войс — Император удваивает щедрость! 👑"},
+	}
+}
+
+// voiceListeners возвращает число пользователей, которые сейчас сидят в голосовом канале.
+func (r *Ranking) voiceListeners(channelID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, id := range r.voiceChannels {
+		if id == channelID {
+			count++
+		}
+	}
+	return count
+}
+
+// refreshVoiceEvents обновляет embed-счётчики ивентов и закрывает завершившиеся.
+func (r *Ranking) refreshVoiceEvents() error {
+	channels, err := r.redis.SMembers(r.ctx, voiceEventsKey).Result()
+	if err != nil {
+		return err
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	for _, channelID := range channels {
+		event, ok := r.loadVoiceEvent(channelID)
+		if !ok {
+			r.redis.SRem(r.ctx, voiceEventsKey, channelID)
+			continue
+		}
+		if event.MsgID != "" {
+			if _, err := s.ChannelMessageEditEmbed(event.MsgChannel, event.MsgID, voiceEventEmbed(event, r.voiceListeners(channelID))); err != nil {
+				log.Printf("Не удалось обновить счётчик ивента в канале %s: %v", channelID, err)
+			}
+		}
+		if time.Now().After(event.EndsAt) {
+			r.finishVoiceEvent(s, event)
+		}
+	}
+	return nil
+}
+
+// finishVoiceEvent удаляет ивент и пишет итог в лог.
+func (r *Ranking) finishVoiceEvent(s *discordgo.Session, event VoiceEvent) {
+	r.redis.SRem(r.ctx, voiceEventsKey, event.ChannelID)
+	r.redis.Del(r.ctx, voiceEventKey(event.ChannelID))
+	log.Printf("Ивент в войсе %s завершён: %d минут", event.ChannelID, event.Minutes)
+	r.LogCreditOperation(s, fmt.Sprintf("🏁 Ивент в <#%s> завершён: %d общих минут с множителем x%d", event.ChannelID, event.Minutes, event.Multiplier))
+}
+
+// HandleVoiceEventCommand обрабатывает команду !a_event #канал <минут> <множитель> | stop #канал.
+func (r *Ranking) HandleVoiceEventCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_event: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут запускать ивенты! 🔒")
+		return
+	}
+
+	usage := "❌ Используй: `/a_event #войс <минут> <множитель>` или `/a_event stop #войс`"
+	parts := strings.Fields(command)
+	if len(parts) == 3 && parts[1] == "stop" {
+		channelID := strings.TrimSuffix(strings.TrimPrefix(parts[2], "<#"), ">")
+		event, ok := r.loadVoiceEvent(channelID)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ В этом канале нет ивента!")
+			return
+		}
+		event.EndsAt = time.Now()
+		if event.MsgID != "" {
+			s.ChannelMessageEditEmbed(event.MsgChannel, event.MsgID, voiceEventEmbed(event, 0))
+		}
+		r.finishVoiceEvent(s, event)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Ивент в <#%s> остановлен.", channelID))
+		return
+	}
+	if len(parts) != 4 || !strings.HasPrefix(parts[1], "<#") {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	channelID := strings.TrimSuffix(strings.TrimPrefix(parts[1], "<#"), ">")
+	minutes, err := strconv.Atoi(parts[2])
+	if err != nil || minutes <= 0 || minutes > 24*60 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Длительность — от 1 до 1440 минут!")
+		return
+	}
+	multiplier, err := strconv.Atoi(strings.TrimPrefix(parts[3], "x"))
+	if err != nil || multiplier < 2 || multiplier > 10 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Множитель — от 2 до 10!")
+		return
+	}
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+	}
+	if err != nil || (channel.Type != discordgo.ChannelTypeGuildVoice && channel.Type != discordgo.ChannelTypeGuildStageVoice) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Укажи голосовой канал!")
+		return
+	}
+
+	event := VoiceEvent{
+		ChannelID:  channelID,
+		Multiplier: multiplier,
+		EndsAt:     time.Now().Add(time.Duration(minutes) * time.Minute),
+		StartedBy:  m.Author.ID,
+		MsgChannel: m.ChannelID,
+	}
+	msg, err := s.ChannelMessageSendEmbed(m.ChannelID, voiceEventEmbed(event, r.voiceListeners(channelID)))
+	if err == nil {
+		event.MsgID = msg.ID
+	}
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, voiceEventKey(channelID))
+	pipe.HSet(r.ctx, voiceEventKey(channelID), map[string]interface{}{
+		"multiplier":  event.Multiplier,
+		"ends_at":     event.EndsAt.Unix(),
+		"started_by":  event.StartedBy,
+		"minutes":     0,
+		"msg_channel": event.MsgChannel,
+		"msg_id":      event.MsgID,
+	})
+	pipe.SAdd(r.ctx, voiceEventsKey, channelID)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось сохранить ивент в канале %s: %v", channelID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка запуска ивента! Проверьте Redis-сервер.")
+		return
+	}
+	r.LogCreditOperation(s, fmt.Sprintf("🎉 <@%s> запустил ивент в <#%s>: x%d на %d мин.", m.Author.ID, channelID, multiplier, minutes))
+}
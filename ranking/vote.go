@@ -0,0 +1,243 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Быстрые голосования без ставок: один голос на пользователя, итоги обновляются на месте.
+const (
+	voteTTL        = 7 * 24 * time.Hour
+	voteMaxOptions = 10
+)
+
+// QuickVote — голосование /vote.
+type QuickVote struct {
+	ID       string         `json:"id"`
+	Question string         `json:"question"`
+	Options  []string       `json:"options"`
+	Votes    map[string]int `json:"votes"` // userID -> индекс варианта
+	Creator  string         `json:"creator"`
+	Created  time.Time      `json:"created"`
+	Closed   bool           `json:"closed"`
+}
+
+// voteKey возвращает ключ голосования.
+func voteKey(id string) string {
+	return "vote:" + id
+}
+
+// loadVote читает голосование из Redis.
+func (r *Ranking) loadVote(id string) (*QuickVote, error) {
+	data, err := r.redis.Get(r.ctx, voteKey(id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var vote QuickVote
+	if err := json.Unmarshal(data, &vote); err != nil {
+		return nil, err
+	}
+	return &vote, nil
+}
+
+// saveVote сохраняет голосование в Redis.
+func (r *Ranking) saveVote(vote *QuickVote) error {
+	data, _ := json.Marshal(vote)
+	return r.redis.Set(r.ctx, voteKey(vote.ID), data, voteTTL).Err()
+}
+
+// voteEmbed формирует embed с текущими итогами голосования.
+func voteEmbed(vote *QuickVote) *discordgo.MessageEmbed {
+	counts := make([]int, len(vote.Options))
+	for _, idx := range vote.Votes {
+		if idx >= 0 && idx < len(counts) {
+			counts[idx]++
+		}
+	}
+	total := len(vote.Votes)
+
+	var lines []string
+	for i, option := range vote.Options {
+		percent := 0
+		if total > 0 {
+			percent = counts[i] * 100 / total
+		}
+		bar := strings.Repeat("🟩", percent/10) + strings.Repeat("⬜", 10-percent/10)
+		lines = append(lines, fmt.Sprintf("**%d. %s**\n%s %d (%d%%)", i+1, option, bar, counts[i], percent))
+	}
+
+	status := "Голосуй кнопками ниже — один голос, можно переголосовать."
+	color := 0x00BFFF
+	if vote.Closed {
+		status = "🔒 Голосование закрыто."
+		color = 0x808080
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "🗳️ " + vote.Question,
+		Description: fmt.Sprintf("%s\n\n%s", strings.Join(lines, "\n\n"), status),
+		Color:       color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Голосов: %d | ID: %s", total, vote.ID)},
+		Timestamp:   vote.Created.Format(time.RFC3339),
+	}
+}
+
+// voteComponents формирует кнопки вариантов и кнопку закрытия.
+func voteComponents(vote *QuickVote) []discordgo.MessageComponent {
+	if vote.Closed {
+		return []discordgo.MessageComponent{}
+	}
+	var rows []discordgo.MessageComponent
+	var buttons []discordgo.MessageComponent
+	for i, option := range vote.Options {
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncate(fmt.Sprintf("%d. %s", i+1, option), 80),
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("vote_pick_%s_%d", vote.ID, i),
+		})
+		if len(buttons) == 5 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+			buttons = nil
+		}
+	}
+	if len(buttons) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "🔒 Закрыть", Style: discordgo.SecondaryButton, CustomID: "vote_close_" + vote.ID},
+	}})
+	return rows
+}
+
+// HandleVoteCommand обрабатывает команду !vote Вопрос [Вариант1] [Вариант2] ...
+func (r *Ranking) HandleVoteCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !vote: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/vote Вопрос [Вариант1] [Вариант2] ...`"
+	var questionParts, options []string
+	for _, part := range splitCommand(command)[1:] {
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			if trimmed := strings.TrimSpace(strings.Trim(part, "[]")); trimmed != "" {
+				options = append(options, trimmed)
+			}
+		} else {
+			questionParts = append(questionParts, part)
+		}
+	}
+	question := strings.Join(questionParts, " ")
+	if question == "" {
+		s.ChannelMessageSend(m.ChannelID, usage)
+		return
+	}
+	if len(options) == 0 {
+		options = []string{"Да", "Нет"}
+	}
+	if len(options) < 2 || len(options) > voteMaxOptions {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Нужно от 2 до %d вариантов! 📊", voteMaxOptions))
+		return
+	}
+
+	vote := &QuickVote{
+		ID:       generatePollID(),
+		Question: question,
+		Options:  options,
+		Votes:    make(map[string]int),
+		Creator:  m.Author.ID,
+		Created:  time.Now(),
+	}
+	if err := r.saveVote(vote); err != nil {
+		log.Printf("Не удалось сохранить голосование %s: %v", vote.ID, err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения голосования! Проверьте Redis-сервер.")
+		return
+	}
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embed: voteEmbed(vote), Components: voteComponents(vote)}); err != nil {
+		log.Printf("Не удалось отправить голосование %s: %v", vote.ID, err)
+	}
+	log.Printf("Голосование %s создано %s: %s с вариантами %v", vote.ID, m.Author.ID, question, options)
+}
+
+// HandleVoteButton обрабатывает нажатия кнопок голосования (vote_pick_<id>_<вариант> и vote_close_<id>).
+func (r *Ranking) HandleVoteButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	userID := i.Member.User.ID
+	ephemeral := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	closing := strings.HasPrefix(customID, "vote_close_")
+	var voteID string
+	option := -1
+	if closing {
+		voteID = strings.TrimPrefix(customID, "vote_close_")
+	} else {
+		parts := strings.Split(strings.TrimPrefix(customID, "vote_pick_"), "_")
+		if len(parts) != 2 {
+			return
+		}
+		voteID = parts[0]
+		option, _ = strconv.Atoi(parts[1])
+	}
+
+	vote, reply, errText := r.castVote(voteID, userID, option, closing)
+	if errText != "" {
+		ephemeral(errText)
+		return
+	}
+	if vote == nil {
+		return
+	}
+
+	if reply != "" {
+		log.Printf("Голос %s в голосовании %s: %s", userID, vote.ID, reply)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{voteEmbed(vote)},
+			Components: voteComponents(vote),
+		},
+	})
+}
+
+// castVote отдаёт голос за вариант option или закрывает голосование. При отказе возвращает текст для
+// игрока; vote == nil без текста — кнопка с несуществующим вариантом, отвечать не нужно.
+func (r *Ranking) castVote(voteID, userID string, option int, closing bool) (vote *QuickVote, reply, errText string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	vote, err := r.loadVote(voteID)
+	if err != nil {
+		return nil, "", "❌ Голосование не найдено или устарело!"
+	}
+	if vote.Closed {
+		return nil, "", "🔒 Голосование уже закрыто!"
+	}
+	if closing {
+		if userID != vote.Creator && !r.IsAdmin(userID) {
+			return nil, "", "❌ Закрыть голосование может только его автор или админ!"
+		}
+		vote.Closed = true
+	} else {
+		if option < 0 || option >= len(vote.Options) {
+			return nil, "", ""
+		}
+		if prev, voted := vote.Votes[userID]; voted && prev == option {
+			return nil, "", fmt.Sprintf("ℹ️ Ты уже голосуешь за «%s».", vote.Options[option])
+		}
+		vote.Votes[userID] = option
+		reply = vote.Options[option]
+	}
+	if err := r.saveVote(vote); err != nil {
+		log.Printf("Не удалось сохранить голосование %s: %v", vote.ID, err)
+		return nil, "", "❌ Ошибка сохранения голоса! Попробуйте позже."
+	}
+	return vote, reply, ""
+}
@@ -0,0 +1,123 @@
+package ranking
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errWagerCapExceeded возвращается, когда ставка превышает дневной лимит пользователя.
+var errWagerCapExceeded = errors.New("дневной лимит ставок превышен")
+
+// DailyWagerCap возвращает дневной лимит суммарных ставок на пользователя (0 — без лимита).
+func (r *Ranking) DailyWagerCap() int {
+	return r.GetIntSetting("daily_wager_cap", envInt("DAILY_WAGER_CAP", 0))
+}
+
+// dailyWagerKey возвращает ключ Redis со суммой ставок пользователя за день.
+func dailyWagerKey(userID string, day time.Time) string {
+	return fmt.Sprintf("wager_limit:%s:%s", userID, day.Format("2006-01-02"))
+}
+
+// reserveDailyWager учитывает ставку в дневном лимите и возвращает оставшийся лимит.
+// Если ставка не помещается в лимит, она не учитывается и возвращается errWagerCapExceeded.
+func (r *Ranking) reserveDailyWager(userID string, amount int) (int, error) {
+	if err := r.checkGamblingLimits(userID, amount); err != nil {
+		return 0, err
+	}
+	limit := r.DailyWagerCap()
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	key := dailyWagerKey(userID, time.Now())
+	total, err := r.redis.IncrBy(r.ctx, key, int64(amount)).Result()
+	if err != nil {
+		log.Printf("Не удалось учесть ставку %d пользователя %s в дневном лимите: %v", amount, userID, err)
+		return 0, err
+	}
+	r.redis.Expire(r.ctx, key, 24*time.Hour)
+
+	if int(total) > limit {
+		r.redis.DecrBy(r.ctx, key, int64(amount))
+		log.Printf("Ставка %d пользователя %s отклонена: дневной лимит %d, уже поставлено %d", amount, userID, limit, int(total)-amount)
+		return limit - (int(total) - amount), errWagerCapExceeded
+	}
+	return limit - int(total), nil
+}
+
+// releaseDailyWager возвращает ранее учтённую ставку в дневной лимит (например, при отмене игры).
+func (r *Ranking) releaseDailyWager(userID string, amount int, placedAt time.Time) {
+	if r.DailyWagerCap() <= 0 {
+		return
+	}
+	key := dailyWagerKey(userID, placedAt)
+	left, err := r.redis.DecrBy(r.ctx, key, int64(amount)).Result()
+	if err != nil {
+		log.Printf("Не удалось вернуть ставку %d пользователя %s в дневной лимит: %v", amount, userID, err)
+		return
+	}
+	if left <= 0 {
+		r.redis.Del(r.ctx, key)
+	}
+}
+
+// wagerCapMessage формирует сообщение об отказе в ставке из-за дневного лимита.
+func (r *Ranking) wagerCapMessage(remaining int, err error) string {
+	var blockErr *GamblingBlockError
+	if errors.As(err, &blockErr) {
+		return blockErr.Error()
+	}
+	if errors.Is(err, errWagerCapExceeded) {
+		return fmt.Sprintf("❌ Дневной лимит ставок исчерпан! Осталось на сегодня: %s из %s ⏳", formatCredits(remaining), formatCredits(r.DailyWagerCap()))
+	}
+	return "❌ Не удалось проверить дневной лимит ставок, попробуй позже!"
+}
+
+// HandleWagerCapCommand обрабатывает команду !a_wager_cap [сумма].
+func (r *Ranking) HandleWagerCapCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !a_wager_cap: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут менять лимит ставок! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		limit := r.DailyWagerCap()
+		if limit <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "ℹ️ Дневной лимит ставок отключён. Установить: `/a_wager_cap <сумма>` (0 — отключить)")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("ℹ️ Дневной лимит ставок: **%s** на пользователя", formatCredits(limit)))
+		return
+	}
+	if len(parts) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_wager_cap <сумма>` (0 — отключить)")
+		return
+	}
+
+	limit, err := strconv.Atoi(parts[1])
+	if err != nil || limit < 0 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Лимит должен быть неотрицательным числом!")
+		return
+	}
+	if err := r.SetIntSetting("daily_wager_cap", limit); err != nil {
+		log.Printf("Не удалось сохранить дневной лимит ставок: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка сохранения лимита! Проверьте Redis-сервер.")
+		return
+	}
+
+	if limit == 0 {
+		s.ChannelMessageSend(m.ChannelID, "✅ Дневной лимит ставок отключён.")
+	} else {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Дневной лимит ставок установлен: **%s** на пользователя.", formatCredits(limit)))
+	}
+	r.LogCreditOperation(s, fmt.Sprintf("Админ <@%s> установил дневной лимит ставок: %d", m.Author.ID, limit))
+}
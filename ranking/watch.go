@@ -0,0 +1,114 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// watchUsersKey — хэш наблюдаемых пользователей: userID -> порог изменения баланса за одну операцию.
+const watchUsersKey = "watch:users"
+
+// defaultWatchThreshold — порог по умолчанию для /watch без суммы.
+const defaultWatchThreshold = 100
+
+// watchThreshold возвращает порог наблюдения за пользователем (0 — не наблюдается).
+func (r *Ranking) watchThreshold(userID string) int {
+	value, err := r.redis.HGet(r.ctx, watchUsersKey, userID).Int()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Не удалось получить порог наблюдения за %s: %v", userID, err)
+		}
+		return 0
+	}
+	return value
+}
+
+// checkWatchedBalance сообщает в канал логов, если баланс наблюдаемого пользователя
+// изменился за одну операцию больше порога.
+func (r *Ranking) checkWatchedBalance(s *discordgo.Session, userID string, delta, balance int, source, counterparty string) {
+	threshold := r.watchThreshold(userID)
+	if threshold <= 0 || max(delta, -delta) < threshold {
+		return
+	}
+	text := fmt.Sprintf("👁️ **Наблюдение:** баланс <@%s> изменился на **%s** (%s) → %s, порог %s",
+		userID, formatCreditsDelta(delta), source, formatCredits(balance), formatCredits(threshold))
+	if counterparty != "" {
+		text += fmt.Sprintf("\nВторой участник: <@%s>", counterparty)
+	}
+	log.Printf("Наблюдение: баланс %s изменился на %d (%s)", userID, delta, source)
+	r.LogCreditOperation(s, text)
+}
+
+// HandleWatchCommand обрабатывает команду !watch [@user] [порог|off].
+func (r *Ranking) HandleWatchCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !watch: %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут следить за балансами! 🔒")
+		return
+	}
+
+	parts := strings.Fields(command)
+	if len(parts) == 1 {
+		r.sendWatchList(s, m.ChannelID)
+		return
+	}
+	if len(m.Mentions) != 1 || len(parts) > 3 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/watch @user [порог]`, `/watch @user off` или `/watch` — список")
+		return
+	}
+	userID := m.Mentions[0].ID
+
+	threshold := defaultWatchThreshold
+	if len(parts) == 3 {
+		if parts[2] == "off" {
+			if err := r.redis.HDel(r.ctx, watchUsersKey, userID).Err(); err != nil {
+				s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis: "+err.Error())
+				return
+			}
+			log.Printf("Админ %s снял наблюдение с %s", m.Author.ID, userID)
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ Наблюдение за <@%s> снято.", userID))
+			return
+		}
+		value, err := strconv.Atoi(parts[2])
+		if err != nil || value <= 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Порог должен быть положительным числом!")
+			return
+		}
+		threshold = value
+	}
+
+	if err := r.redis.HSet(r.ctx, watchUsersKey, userID, threshold).Err(); err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Ошибка Redis: "+err.Error())
+		return
+	}
+	log.Printf("Админ %s включил наблюдение за %s (порог %d)", m.Author.ID, userID, threshold)
+	r.LogCreditOperation(s, fmt.Sprintf("👁️ Админ <@%s> включил наблюдение за <@%s> (порог %s)", m.Author.ID, userID, formatCredits(threshold)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("👁️ Слежу за <@%s>: операции больше %s попадут в канал логов.", userID, formatCredits(threshold)))
+}
+
+// sendWatchList отправляет список наблюдаемых пользователей.
+func (r *Ranking) sendWatchList(s *discordgo.Session, channelID string) {
+	watched, err := r.redis.HGetAll(r.ctx, watchUsersKey).Result()
+	if err != nil {
+		s.ChannelMessageSend(channelID, "❌ Ошибка Redis: "+err.Error())
+		return
+	}
+	if len(watched) == 0 {
+		s.ChannelMessageSend(channelID, "ℹ️ Сейчас ни за кем не следим.")
+		return
+	}
+	lines := make([]string, 0, len(watched))
+	for userID, raw := range watched {
+		threshold, _ := strconv.Atoi(raw)
+		lines = append(lines, fmt.Sprintf("<@%s> — порог %s", userID, formatCredits(threshold)))
+	}
+	sort.Strings(lines)
+	s.ChannelMessageSend(channelID, "👁️ **Под наблюдением:**\n"+strings.Join(lines, "\n"))
+}
@@ -0,0 +1,84 @@
+package ranking
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// displayNames кэширует имена пользователей Discord для веб-страниц бота.
+var displayNames = struct {
+	sync.Mutex
+	names map[string]string
+}{names: make(map[string]string)}
+
+// WebServer — встроенный HTTP-сервер бота. На нём живут картинки NFT, оверлей для стримов
+// и публичная таблица лидеров.
+type WebServer struct {
+	addr   string
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+// NewWebServer создаёт HTTP-сервер на HTTP_ADDR (для совместимости — IMAGE_CACHE_ADDR, по умолчанию :8081).
+func NewWebServer() *WebServer {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = os.Getenv("IMAGE_CACHE_ADDR")
+	}
+	if addr == "" {
+		addr = ":8081"
+	}
+	return &WebServer{addr: addr, mux: http.NewServeMux()}
+}
+
+// Handle регистрирует обработчик маршрута.
+func (w *WebServer) Handle(pattern string, handler http.Handler) {
+	w.mux.Handle(pattern, handler)
+}
+
+// Start запускает сервер в фоне.
+func (w *WebServer) Start() {
+	w.server = &http.Server{Addr: w.addr, Handler: w.mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		log.Printf("HTTP-сервер бота запущен на %s", w.addr)
+		if err := w.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP-сервер бота остановлен с ошибкой: %v", err)
+		}
+	}()
+}
+
+// Stop останавливает сервер.
+func (w *WebServer) Stop() {
+	if w != nil && w.server != nil {
+		w.server.Close()
+	}
+}
+
+// displayName возвращает имя пользователя Discord (с кэшем). Не вызывать под r.mu.
+func (r *Ranking) displayName(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	displayNames.Lock()
+	name, ok := displayNames.names[userID]
+	displayNames.Unlock()
+	if ok {
+		return name
+	}
+	name = userID
+	if s, err := r.Session(); err == nil {
+		if user, err := s.User(userID); err == nil {
+			name = user.Username
+			if user.GlobalName != "" {
+				name = user.GlobalName
+			}
+		}
+	}
+	displayNames.Lock()
+	displayNames.names[userID] = name
+	displayNames.Unlock()
+	return name
+}
@@ -0,0 +1,208 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Еженедельный отчёт экономики. Недельные агрегаты копятся инкрементально при каждом изменении
+// баланса и открытии кейса, а задача weekly_economy_report по понедельникам публикует итоги
+// прошлой недели в LOG_CHANNEL_ID.
+const weeklyStatsTTL = 35 * 24 * time.Hour
+
+// weeklyTradeSources — операции между игроками, которые попадают в «крупнейшие сделки».
+var weeklyTradeSources = map[string]bool{"transfer": true, "case_trade": true, "offer": true, "escrow": true}
+
+// economyWeekKey возвращает ключ недельного агрегата (totals, net, trades) по ISO-неделе.
+func economyWeekKey(kind string, t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("economy:week:%d-W%02d:%s", year, week, kind)
+}
+
+// recordWeeklyDelta учитывает изменение баланса в недельных агрегатах.
+func (r *Ranking) recordWeeklyDelta(userID string, delta int, source, counterparty string) {
+	if delta == 0 {
+		return
+	}
+	now := time.Now()
+	totalsKey := economyWeekKey("totals", now)
+	pipe := r.redis.Pipeline()
+	if delta > 0 {
+		pipe.HIncrBy(r.ctx, totalsKey, "minted", int64(delta))
+	} else {
+		pipe.HIncrBy(r.ctx, totalsKey, "burned", int64(-delta))
+	}
+	pipe.Expire(r.ctx, totalsKey, weeklyStatsTTL)
+	if gamblingSources[source] {
+		netKey := economyWeekKey("net", now)
+		pipe.ZIncrBy(r.ctx, netKey, float64(delta), userID)
+		pipe.Expire(r.ctx, netKey, weeklyStatsTTL)
+	}
+	if weeklyTradeSources[source] && counterparty != "" && delta > 0 {
+		tradesKey := economyWeekKey("trades", now)
+		member := fmt.Sprintf("%s|%s|%s|%d", source, counterparty, userID, now.UnixNano())
+		pipe.ZAdd(r.ctx, tradesKey, &redis.Z{Score: float64(delta), Member: member})
+		pipe.ZRemRangeByRank(r.ctx, tradesKey, 0, -11) // храним только 10 крупнейших
+		pipe.Expire(r.ctx, tradesKey, weeklyStatsTTL)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось обновить недельные агрегаты экономики для %s: %v", userID, err)
+	}
+}
+
+// recordWeeklyCaseOpening учитывает открытие кейса в недельном отчёте.
+func (r *Ranking) recordWeeklyCaseOpening(caseID string) {
+	totalsKey := economyWeekKey("totals", time.Now())
+	pipe := r.redis.Pipeline()
+	pipe.HIncrBy(r.ctx, totalsKey, "cases", 1)
+	pipe.HIncrBy(r.ctx, totalsKey, "case:"+caseID, 1)
+	pipe.Expire(r.ctx, totalsKey, weeklyStatsTTL)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось учесть открытие кейса %s в недельном отчёте: %v", caseID, err)
+	}
+}
+
+// nftPriceMover — изменение цены NFT за неделю.
+type nftPriceMover struct {
+	NFT     NFT
+	From    int
+	Percent float64
+}
+
+// weeklyNFTPriceMovers возвращает NFT, сильнее всего подорожавшие и подешевевшие за неделю.
+func (r *Ranking) weeklyNFTPriceMovers(limit int) (up, down []nftPriceMover) {
+	var movers []nftPriceMover
+	for id, nft := range r.Kki.nfts {
+		prices := r.NFTPriceHistory(id, 8)
+		if len(prices) < 2 || prices[0] <= 0 {
+			continue
+		}
+		last := prices[len(prices)-1]
+		if last == prices[0] {
+			continue
+		}
+		movers = append(movers, nftPriceMover{NFT: nft, From: prices[0], Percent: float64(last-prices[0]) * 100 / float64(prices[0])})
+	}
+	sort.Slice(movers, func(i, j int) bool { return movers[i].Percent > movers[j].Percent })
+	for _, m := range movers {
+		if m.Percent > 0 && len(up) < limit {
+			up = append(up, m)
+		}
+	}
+	for i := len(movers) - 1; i >= 0 && len(down) < limit; i-- {
+		if movers[i].Percent < 0 {
+			down = append(down, movers[i])
+		}
+	}
+	return up, down
+}
+
+// weeklyEconomyEmbed собирает отчёт за ISO-неделю, в которую входит момент week.
+func (r *Ranking) weeklyEconomyEmbed(week time.Time) (*discordgo.MessageEmbed, error) {
+	totals, err := r.redis.HGetAll(r.ctx, economyWeekKey("totals", week)).Result()
+	if err != nil {
+		return nil, err
+	}
+	atoi := func(key string) int {
+		v, _ := strconv.Atoi(totals[key])
+		return v
+	}
+	minted, burned := atoi("minted"), atoi("burned")
+
+	winners, _ := r.redis.ZRevRangeByScoreWithScores(r.ctx, economyWeekKey("net", week), &redis.ZRangeBy{Min: "(0", Max: "+inf", Count: 3}).Result()
+	losers, _ := r.redis.ZRangeByScoreWithScores(r.ctx, economyWeekKey("net", week), &redis.ZRangeBy{Min: "-inf", Max: "(0", Count: 3}).Result()
+	playerLines := func(entries []redis.Z) string {
+		if len(entries) == 0 {
+			return "—"
+		}
+		lines := make([]string, 0, len(entries))
+		for i, entry := range entries {
+			userID, _ := entry.Member.(string)
+			lines = append(lines, fmt.Sprintf("%d. %s %s", i+1, r.publicMention(userID), formatCreditsDelta(int(entry.Score))))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	trades, _ := r.redis.ZRevRangeWithScores(r.ctx, economyWeekKey("trades", week), 0, 2).Result()
+	tradeText := "—"
+	if len(trades) > 0 {
+		lines := make([]string, 0, len(trades))
+		for _, entry := range trades {
+			member, _ := entry.Member.(string)
+			parts := strings.Split(member, "|")
+			if len(parts) < 3 {
+				continue
+			}
+			label := creditSourceLabels[parts[0]]
+			lines = append(lines, fmt.Sprintf("%s %s → %s: %s", label, r.publicMention(parts[1]), r.publicMention(parts[2]), formatCredits(int(entry.Score))))
+		}
+		tradeText = strings.Join(lines, "\n")
+	}
+
+	caseText := fmt.Sprintf("%d", atoi("cases"))
+	bestCase, bestCount := "", 0
+	for field := range totals {
+		if caseID, ok := strings.CutPrefix(field, "case:"); ok && atoi(field) > bestCount {
+			bestCase, bestCount = caseID, atoi(field)
+		}
+	}
+	if bestCase != "" {
+		name := bestCase
+		if kase, ok := r.Kki.cases[bestCase]; ok {
+			name = kase.Name
+		}
+		caseText += fmt.Sprintf("\nЧаще всего: 📦 %s (%d)", name, bestCount)
+	}
+
+	up, down := r.weeklyNFTPriceMovers(3)
+	moverLines := func(movers []nftPriceMover) string {
+		if len(movers) == 0 {
+			return "—"
+		}
+		lines := make([]string, 0, len(movers))
+		for _, m := range movers {
+			lines = append(lines, fmt.Sprintf("%s %s: %s → %s (%+.1f%%)", RarityEmojis[m.NFT.Rarity], m.NFT.Name, formatCredits(m.From), formatCredits(m.NFT.Price), m.Percent))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	year, number := week.ISOWeek()
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📊 Экономика за неделю %d-W%02d", year, number),
+		Description: fmt.Sprintf("Эмиссия: **%s** · сожжено: **%s** · итог: **%s**", formatCredits(minted), formatCredits(burned), formatCreditsDelta(minted-burned)),
+		Color:       0xFFD700,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🏆 Главные победители в играх", Value: playerLines(winners), Inline: true},
+			{Name: "😢 Главные проигравшие", Value: playerLines(losers), Inline: true},
+			{Name: "🤝 Крупнейшие сделки", Value: truncate(tradeText, 1024)},
+			{Name: "📦 Открыто кейсов", Value: caseText, Inline: true},
+			{Name: "📈 NFT выросли", Value: truncate(moverLines(up), 1024)},
+			{Name: "📉 NFT подешевели", Value: truncate(moverLines(down), 1024)},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Славь Императора! 🇨🇳"},
+	}, nil
+}
+
+// postWeeklyEconomyReport публикует отчёт за прошлую неделю в канал логов.
+func (r *Ranking) postWeeklyEconomyReport() error {
+	if r.logChannelID == "" {
+		return nil
+	}
+	s, err := r.Session()
+	if err != nil {
+		return err
+	}
+	embed, err := r.weeklyEconomyEmbed(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageSendEmbed(r.logChannelID, embed)
+	return err
+}
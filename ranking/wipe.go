@@ -0,0 +1,208 @@
+package ranking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// Мягкое удаление данных пользователя: /a_wipe переносит экономические данные игрока в архив
+// tombstone:<userID>, /a_restore возвращает их. Архив хранится TOMBSTONE_TTL_DAYS дней.
+
+// UserTombstone — архив данных пользователя.
+type UserTombstone struct {
+	UserID  string             `json:"user_id"`
+	WipedBy string             `json:"wiped_by"`
+	WipedAt time.Time          `json:"wiped_at"`
+	Balance int                `json:"balance"`
+	Keys    map[string][]byte  `json:"keys"`   // ключ -> DUMP
+	TTLs    map[string]int64   `json:"ttls"`   // ключ -> оставшийся TTL в мс (0 — без срока)
+	Scores  map[string]float64 `json:"scores"` // ZSET -> счёт пользователя
+}
+
+// tombstoneKey возвращает ключ архива пользователя.
+func tombstoneKey(userID string) string {
+	return "tombstone:" + userID
+}
+
+// userDataKeys возвращает ключи, целиком принадлежащие пользователю.
+func userDataKeys(userID string) []string {
+	return []string{
+		"user:" + userID,
+		"inventory:" + userID,
+		"case_inventory:" + userID,
+		creditLedgerKey(userID),
+		nftLedgerUserKey(userID),
+		themesOwnedKey(userID),
+		themeActiveKey(userID),
+		flairsOwnedKey(userID),
+		flairActiveKey(userID),
+		betLimitsUserKey(userID),
+		comebackStreakKey(userID),
+		comebackClaimedKey(userID),
+		faucetKey(userID),
+		loanKey(userID),
+		dailyKey(userID),
+		prestigeKey(userID),
+		streamStatsKey(userID),
+		transferLimitOverrideKey(userID),
+		transferCooldownKey(userID),
+		jadeKey(userID),
+		gamblingKey(userID),
+		quietKey(userID),
+		quietQueueKey(userID),
+		tutorialKey(userID),
+		showcaseKey(userID),
+		wishlistKey(userID),
+	}
+}
+
+// userScoreSets — общие ZSET, в которых пользователь присутствует участником.
+// Множество faucet:started не трогаем, чтобы после очистки не выдать стартовый баланс повторно.
+var userScoreSets = []string{economyBalancesKey, economyActivityKey, bankDepositsKey, retentionLastSeenKey, retentionReturnsKey,
+	topVoiceKey, topDuelsKey, topGamblersKey, topCollectorsKey}
+
+// tombstoneTTL возвращает срок хранения архива.
+func tombstoneTTL() time.Duration {
+	return time.Duration(envInt("TOMBSTONE_TTL_DAYS", 30)) * 24 * time.Hour
+}
+
+// wipeUser переносит данные пользователя в архив и удаляет их из рабочих ключей.
+func (r *Ranking) wipeUser(userID, adminID string) (UserTombstone, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tomb := UserTombstone{
+		UserID:  userID,
+		WipedBy: adminID,
+		WipedAt: time.Now(),
+		Balance: r.GetRating(userID),
+		Keys:    make(map[string][]byte),
+		TTLs:    make(map[string]int64),
+		Scores:  make(map[string]float64),
+	}
+	exists, err := r.redis.Exists(r.ctx, tombstoneKey(userID)).Result()
+	if err != nil {
+		return tomb, fmt.Errorf("ошибка Redis: %v", err)
+	}
+	if exists > 0 {
+		return tomb, fmt.Errorf("данные <@%s> уже в архиве — сначала `/a_restore`", userID)
+	}
+
+	for _, key := range userDataKeys(userID) {
+		dump, err := r.redis.Dump(r.ctx, key).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return tomb, fmt.Errorf("не удалось прочитать %s: %v", key, err)
+		}
+		tomb.Keys[key] = []byte(dump)
+		if ttl, err := r.redis.PTTL(r.ctx, key).Result(); err == nil && ttl > 0 {
+			tomb.TTLs[key] = ttl.Milliseconds()
+		}
+	}
+	for _, set := range userScoreSets {
+		score, err := r.redis.ZScore(r.ctx, set, userID).Result()
+		if err == nil {
+			tomb.Scores[set] = score
+		}
+	}
+	if len(tomb.Keys) == 0 && len(tomb.Scores) == 0 {
+		return tomb, fmt.Errorf("у <@%s> нет данных", userID)
+	}
+
+	data, _ := json.Marshal(tomb)
+	pipe := r.redis.TxPipeline()
+	pipe.Set(r.ctx, tombstoneKey(userID), data, tombstoneTTL())
+	for key := range tomb.Keys {
+		pipe.Del(r.ctx, key)
+	}
+	for set := range tomb.Scores {
+		pipe.ZRem(r.ctx, set, userID)
+	}
+	r.unindexWishlist(pipe, userID)
+	pipe.IncrBy(r.ctx, economyTotalKey, int64(-tomb.Balance))
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return tomb, fmt.Errorf("ошибка записи в Redis: %v", err)
+	}
+	r.users.invalidate(userID)
+	return tomb, nil
+}
+
+// restoreUser возвращает данные пользователя из архива. Данные, появившиеся после очистки, заменяются архивными.
+func (r *Ranking) restoreUser(userID string) (UserTombstone, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tomb UserTombstone
+	data, err := r.redis.Get(r.ctx, tombstoneKey(userID)).Bytes()
+	if err == redis.Nil {
+		return tomb, fmt.Errorf("архива <@%s> нет (хранится %d дней)", userID, int(tombstoneTTL().Hours()/24))
+	}
+	if err != nil {
+		return tomb, fmt.Errorf("ошибка Redis: %v", err)
+	}
+	if err := json.Unmarshal(data, &tomb); err != nil {
+		return tomb, fmt.Errorf("архив повреждён: %v", err)
+	}
+	current := r.GetRating(userID)
+
+	pipe := r.redis.TxPipeline()
+	for key, dump := range tomb.Keys {
+		pipe.RestoreReplace(r.ctx, key, time.Duration(tomb.TTLs[key])*time.Millisecond, string(dump))
+	}
+	for set, score := range tomb.Scores {
+		pipe.ZAdd(r.ctx, set, &redis.Z{Score: score, Member: userID})
+	}
+	pipe.IncrBy(r.ctx, economyTotalKey, int64(tomb.Balance-current))
+	pipe.Del(r.ctx, tombstoneKey(userID))
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return tomb, fmt.Errorf("ошибка записи в Redis: %v", err)
+	}
+	r.users.invalidate(userID)
+	r.reindexWishlist(userID)
+	return tomb, nil
+}
+
+// HandleWipeCommand обрабатывает команды !a_wipe @user и !a_restore @user.
+func (r *Ranking) HandleWipeCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка %s от %s", command, m.Author.ID)
+
+	if !r.IsAdmin(m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "❌ Только админы могут удалять и восстанавливать данные! 🔒")
+		return
+	}
+	restore := strings.HasPrefix(command, "/a_restore")
+	if len(m.Mentions) != 1 || len(strings.Fields(command)) != 2 {
+		s.ChannelMessageSend(m.ChannelID, "❌ Используй: `/a_wipe @user` или `/a_restore @user`")
+		return
+	}
+	userID := m.Mentions[0].ID
+
+	if restore {
+		tomb, err := r.restoreUser(userID)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "❌ Восстановление невозможно: "+err.Error())
+			return
+		}
+		log.Printf("Админ %s восстановил данные %s из архива", m.Author.ID, userID)
+		r.LogCreditOperation(s, fmt.Sprintf("♻️ Админ <@%s> восстановил данные <@%s> (баланс %s, ключей: %d)", m.Author.ID, userID, formatCredits(tomb.Balance), len(tomb.Keys)))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("♻️ Данные <@%s> восстановлены: баланс %s, ключей: %d.", userID, formatCredits(tomb.Balance), len(tomb.Keys)))
+		return
+	}
+
+	tomb, err := r.wipeUser(userID, m.Author.ID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "❌ Очистка невозможна: "+err.Error())
+		return
+	}
+	log.Printf("Админ %s очистил данные %s (в архиве %d ключей)", m.Author.ID, userID, len(tomb.Keys))
+	r.LogCreditOperation(s, fmt.Sprintf("🗑️ Админ <@%s> очистил данные <@%s> (баланс %s, ключей: %d)", m.Author.ID, userID, formatCredits(tomb.Balance), len(tomb.Keys)))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🗑️ Данные <@%s> перенесены в архив на %d дней. Отменить: `/a_restore @user`.", userID, int(tombstoneTTL().Hours()/24)))
+}
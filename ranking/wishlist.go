@@ -0,0 +1,183 @@
+package ranking
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+)
+
+// wishlistLimit — сколько NFT можно держать в списке желаний.
+const wishlistLimit = 20
+
+// wishlistCooldown — не чаще одного оповещения об одной NFT игроку за этот срок.
+const wishlistCooldown = time.Hour
+
+// wishlistKey возвращает SET с ID NFT из списка желаний игрока.
+func wishlistKey(userID string) string {
+	return "wishlist:" + userID
+}
+
+// wishlistNFTKey возвращает SET с ID игроков, у которых NFT в списке желаний (обратный индекс wishlistKey).
+func wishlistNFTKey(nftID string) string {
+	return "wishlist:nft:" + nftID
+}
+
+// wishlistNotifiedKey — отметка о недавнем оповещении игрока об NFT.
+func wishlistNotifiedKey(userID, nftID string) string {
+	return "wishlist_notified:" + userID + ":" + nftID
+}
+
+// Wishlist возвращает ID NFT из списка желаний игрока.
+func (r *Ranking) Wishlist(userID string) []string {
+	ids, err := r.redis.SMembers(r.ctx, wishlistKey(userID)).Result()
+	if err != nil {
+		log.Printf("Не удалось получить список желаний %s: %v", userID, err)
+		return nil
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// wishlisters возвращает игроков, у которых NFT в списке желаний.
+func (r *Ranking) wishlisters(nftID string) []string {
+	users, err := r.redis.SMembers(r.ctx, wishlistNFTKey(nftID)).Result()
+	if err != nil {
+		log.Printf("Не удалось найти списки желаний с NFT %s: %v", nftID, err)
+	}
+	return users
+}
+
+// unindexWishlist добавляет в pipe удаление игрока из обратных индексов всех NFT его списка желаний.
+func (r *Ranking) unindexWishlist(pipe redis.Pipeliner, userID string) {
+	for _, nftID := range r.Wishlist(userID) {
+		pipe.SRem(r.ctx, wishlistNFTKey(nftID), userID)
+	}
+}
+
+// reindexWishlist заново вносит игрока в обратные индексы NFT его списка желаний.
+func (r *Ranking) reindexWishlist(userID string) {
+	ids := r.Wishlist(userID)
+	if len(ids) == 0 {
+		return
+	}
+	pipe := r.redis.TxPipeline()
+	for _, nftID := range ids {
+		pipe.SAdd(r.ctx, wishlistNFTKey(nftID), userID)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		log.Printf("Не удалось восстановить индекс списка желаний %s: %v", userID, err)
+	}
+}
+
+// notifyWishlist сообщает в ЛС всем, у кого NFT в списке желаний, что она появилась. В тихие часы
+// оповещение откладывается. Участники события (exclude) не оповещаются; если ЛС закрыты, игрок
+// упоминается в channelID.
+func (r *Ranking) notifyWishlist(s *discordgo.Session, nftID, event, channelID string, exclude ...string) {
+	nft, ok := r.Kki.nfts[nftID]
+	if !ok {
+		return
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, userID := range exclude {
+		skip[userID] = true
+	}
+	text := fmt.Sprintf("⭐ NFT из твоего списка желаний %s **%s** (ID: %s) %s\nУбрать из списка: `/wishlist remove %s`", RarityEmojis[nft.Rarity], nft.Name, nft.ID, event, nft.ID)
+	var pings []string
+	for _, userID := range r.wishlisters(nftID) {
+		if skip[userID] {
+			continue
+		}
+		fresh, err := r.redis.SetNX(r.ctx, wishlistNotifiedKey(userID, nftID), 1, wishlistCooldown).Result()
+		if err != nil || !fresh {
+			continue
+		}
+		if err := r.sendDM(s, userID, text, true); err != nil {
+			log.Printf("Не удалось отправить ЛС о списке желаний %s: %v", userID, err)
+			pings = append(pings, "<@"+userID+">")
+		}
+	}
+	if len(pings) > 0 && channelID != "" {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("⭐ %s — %s **%s** из вашего списка желаний %s", strings.Join(pings, " "), RarityEmojis[nft.Rarity], nft.Name, event))
+	}
+}
+
+// HandleWishlistCommand обрабатывает команду !wishlist [add|remove <nftID>|clear].
+func (r *Ranking) HandleWishlistCommand(s *discordgo.Session, m *discordgo.MessageCreate, command string) {
+	log.Printf("Обработка !wishlist: %s от %s", command, m.Author.ID)
+
+	usage := "❌ Используй: `/wishlist`, `/wishlist add <nftID>`, `/wishlist remove <nftID>` или `/wishlist clear`"
+	parts := strings.Fields(command)
+	key := wishlistKey(m.Author.ID)
+	switch {
+	case len(parts) == 1:
+		r.sendWishlist(s, m.ChannelID, m.Author.ID)
+	case len(parts) == 3 && parts[1] == "add":
+		nft, ok := r.Kki.nfts[parts[2]]
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "❌ NFT не найдено. Проверьте ID.")
+			return
+		}
+		if r.redis.SCard(r.ctx, key).Val() >= wishlistLimit && !r.redis.SIsMember(r.ctx, key, nft.ID).Val() {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ В списке желаний не больше %d NFT. Убери лишнее: `/wishlist remove <nftID>`", wishlistLimit))
+			return
+		}
+		pipe := r.redis.TxPipeline()
+		pipe.SAdd(r.ctx, key, nft.ID)
+		pipe.SAdd(r.ctx, wishlistNFTKey(nft.ID), m.Author.ID)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			log.Printf("Не удалось добавить NFT %s в список желаний %s: %v", nft.ID, m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⭐ %s **%s** в списке желаний. Напишу в ЛС, когда она появится на рынке, выпадет в крупном дропе или её предложат в обмене.", RarityEmojis[nft.Rarity], nft.Name))
+	case len(parts) == 3 && parts[1] == "remove":
+		removed, err := r.redis.SRem(r.ctx, key, parts[2]).Result()
+		if err != nil || removed == 0 {
+			s.ChannelMessageSend(m.ChannelID, "❌ Этой NFT нет в твоём списке желаний.")
+			return
+		}
+		r.redis.SRem(r.ctx, wishlistNFTKey(parts[2]), m.Author.ID)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("✅ NFT `%s` убрана из списка желаний.", parts[2]))
+	case len(parts) == 2 && parts[1] == "clear":
+		pipe := r.redis.TxPipeline()
+		r.unindexWishlist(pipe, m.Author.ID)
+		pipe.Del(r.ctx, key)
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			log.Printf("Не удалось очистить список желаний %s: %v", m.Author.ID, err)
+			s.ChannelMessageSend(m.ChannelID, "❌ Ошибка! Проверьте Redis-сервер.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "✅ Список желаний очищен.")
+	default:
+		s.ChannelMessageSend(m.ChannelID, usage)
+	}
+}
+
+// sendWishlist отправляет список желаний игрока.
+func (r *Ranking) sendWishlist(s *discordgo.Session, channelID, userID string) {
+	ids := r.Wishlist(userID)
+	if len(ids) == 0 {
+		s.ChannelMessageSend(channelID, "⭐ Список желаний пуст. Добавить: `/wishlist add <nftID>`")
+		return
+	}
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		nft, ok := r.Kki.nfts[id]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("❔ `%s` — NFT больше нет в каталоге", id))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s **%s** `%s` — 💰 %d", RarityEmojis[nft.Rarity], nft.Name, nft.ID, r.CalculateNFTPrice(nft)))
+	}
+	s.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("⭐ Список желаний (%d/%d)", len(ids), wishlistLimit),
+		Description: strings.Join(lines, "\n"),
+		Color:       0xFFD700,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Оповещения приходят в ЛС: рынок, крупные дропы, обмены"},
+	})
+}